@@ -0,0 +1,224 @@
+// Package topologyv1 is the hand-maintained Go mapping of topology.proto.
+// In the same spirit as the controller's hand-rolled /metrics endpoint, the
+// repo keeps the protoc toolchain out of the build: the .proto file is the
+// contract, these structs mirror its messages, and both ends encode them
+// with the JSON codec negotiated via the gRPC content-subtype. Keep this
+// file and topology.proto in sync.
+package topologyv1
+
+import (
+	"context"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+// JSONCodecName is the content-subtype both ends of a connection must use;
+// clients dial with grpc.CallContentSubtype(JSONCodecName).
+const JSONCodecName = "json"
+
+// ServiceName is the fully-qualified gRPC service name.
+const ServiceName = "komarov.topology.v1.TopologyService"
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec encodes the messages below without generated marshalers.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                               { return JSONCodecName }
+
+type GetTopologyRequest struct{}
+
+type GetTopologyResponse struct {
+	Links []Link `json:"links,omitempty"`
+	// Epoch is the newest measurement-cycle epoch in the snapshot; 0 from
+	// controllers predating epoch stamping.
+	Epoch int64 `json:"epoch,omitempty"`
+	// MixedEpochs marks a snapshot assembled from more than one
+	// measurement cycle (a scoped remeasurement refreshed only some
+	// nodes); consumers needing a consistent cut should re-sync later.
+	MixedEpochs bool `json:"mixedEpochs,omitempty"`
+}
+
+type GetLinkRequest struct {
+	Source string `json:"source,omitempty"`
+	Target string `json:"target,omitempty"`
+}
+
+type GetLinkResponse struct {
+	Link *Link `json:"link,omitempty"`
+}
+
+type WatchTopologyRequest struct{}
+
+// Link mirrors the CRD's link shape; formatted values ("12ms", "850mbps")
+// stay formatted so both representations read identically.
+type Link struct {
+	Source            string  `json:"source,omitempty"`
+	Target            string  `json:"target,omitempty"`
+	Latency           string  `json:"latency,omitempty"`
+	Bandwidth         string  `json:"bandwidth,omitempty"`
+	Cost              float64 `json:"cost,omitempty"`
+	Loss              float64 `json:"loss,omitempty"`
+	Path              string  `json:"path,omitempty"`
+	Via               string  `json:"via,omitempty"`
+	MeasurementSource string  `json:"measurementSource,omitempty"`
+}
+
+// TopologyDiff is one cycle's worth of changes.
+type TopologyDiff struct {
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// Change is one field-level difference, mirroring pkg/topology's Change.
+type Change struct {
+	Node   string `json:"node,omitempty"`
+	Target string `json:"target,omitempty"`
+	Field  string `json:"field,omitempty"`
+	Old    string `json:"old,omitempty"`
+	New    string `json:"new,omitempty"`
+}
+
+// TopologyServer is the server-side API of the service.
+type TopologyServer interface {
+	GetTopology(context.Context, *GetTopologyRequest) (*GetTopologyResponse, error)
+	GetLink(context.Context, *GetLinkRequest) (*GetLinkResponse, error)
+	WatchTopology(*WatchTopologyRequest, TopologyService_WatchTopologyServer) error
+}
+
+// TopologyService_WatchTopologyServer is the server side of the watch stream.
+type TopologyService_WatchTopologyServer interface {
+	Send(*TopologyDiff) error
+	grpc.ServerStream
+}
+
+type watchTopologyServer struct {
+	grpc.ServerStream
+}
+
+func (s *watchTopologyServer) Send(diff *TopologyDiff) error { return s.SendMsg(diff) }
+
+// RegisterTopologyServer registers the service implementation with a server.
+func RegisterTopologyServer(s *grpc.Server, srv TopologyServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*TopologyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "GetTopology", Handler: getTopologyHandler},
+		{MethodName: "GetLink", Handler: getLinkHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "WatchTopology", Handler: watchTopologyHandler, ServerStreams: true},
+	},
+	Metadata: "api/proto/topology/v1/topology.proto",
+}
+
+func getTopologyHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTopologyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TopologyServer).GetTopology(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + ServiceName + "/GetTopology"}
+	return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TopologyServer).GetTopology(ctx, req.(*GetTopologyRequest))
+	})
+}
+
+func getLinkHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TopologyServer).GetLink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + ServiceName + "/GetLink"}
+	return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TopologyServer).GetLink(ctx, req.(*GetLinkRequest))
+	})
+}
+
+func watchTopologyHandler(srv interface{}, stream grpc.ServerStream) error {
+	in := new(WatchTopologyRequest)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(TopologyServer).WatchTopology(in, &watchTopologyServer{stream})
+}
+
+// TopologyClient is the client-side API of the service.
+type TopologyClient interface {
+	GetTopology(ctx context.Context, in *GetTopologyRequest, opts ...grpc.CallOption) (*GetTopologyResponse, error)
+	GetLink(ctx context.Context, in *GetLinkRequest, opts ...grpc.CallOption) (*GetLinkResponse, error)
+	WatchTopology(ctx context.Context, in *WatchTopologyRequest, opts ...grpc.CallOption) (TopologyService_WatchTopologyClient, error)
+}
+
+// TopologyService_WatchTopologyClient is the client side of the watch stream.
+type TopologyService_WatchTopologyClient interface {
+	Recv() (*TopologyDiff, error)
+	grpc.ClientStream
+}
+
+type watchTopologyClient struct {
+	grpc.ClientStream
+}
+
+func (c *watchTopologyClient) Recv() (*TopologyDiff, error) {
+	diff := new(TopologyDiff)
+	if err := c.RecvMsg(diff); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+type topologyClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewTopologyClient wraps a connection; the connection must use the JSON
+// content-subtype (see JSONCodecName).
+func NewTopologyClient(cc grpc.ClientConnInterface) TopologyClient {
+	return &topologyClient{cc: cc}
+}
+
+func (c *topologyClient) GetTopology(ctx context.Context, in *GetTopologyRequest, opts ...grpc.CallOption) (*GetTopologyResponse, error) {
+	out := new(GetTopologyResponse)
+	if err := c.cc.Invoke(ctx, "/"+ServiceName+"/GetTopology", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *topologyClient) GetLink(ctx context.Context, in *GetLinkRequest, opts ...grpc.CallOption) (*GetLinkResponse, error) {
+	out := new(GetLinkResponse)
+	if err := c.cc.Invoke(ctx, "/"+ServiceName+"/GetLink", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *topologyClient) WatchTopology(ctx context.Context, in *WatchTopologyRequest, opts ...grpc.CallOption) (TopologyService_WatchTopologyClient, error) {
+	stream, err := c.cc.NewStream(ctx, &serviceDesc.Streams[0], "/"+ServiceName+"/WatchTopology", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &watchTopologyClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
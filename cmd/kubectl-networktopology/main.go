@@ -0,0 +1,532 @@
+// kubectl-networktopology is a kubectl plugin for inspecting network-aware
+// scheduling. Installed on the PATH it runs as "kubectl networktopology".
+// The "whatif" subcommand answers "what breaks if this node goes away": it
+// simulates removing a node from the topology and reports the running pods
+// whose network requirements would have no feasible node left, along with
+// zone and connectivity-island changes. The "blocked" subcommand renders the
+// NetworkSchedulingReport the scheduler plugin maintains: the pods currently
+// unschedulable for network reasons, grouped by reason. The "zones",
+// "capabilities" and "worst-links" subcommands render the summary views
+// computed by pkg/topology: zone×zone medians, capability→node listings, and
+// the unhealthiest links cluster-wide. The "node" subcommand shows one node's
+// links and any administrative overrides touching it. Everything above is
+// read-only; "set-link" marks a link administratively down (optionally with
+// an expiry) ahead of planned maintenance the measurements have not caught up
+// with, and "measure" requests an ad-hoc probe of specific links, executed by
+// a Job the controller pins to the source node.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/scheduler/networkaware"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+const (
+	topologyName = "cluster"
+	reportName   = "blocked"
+)
+
+var (
+	topologyGVR = v1alpha1.SchemeGroupVersion.WithResource("networktopologies")
+	reportGVR   = v1alpha1.SchemeGroupVersion.WithResource("networkschedulingreports")
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "whatif":
+		err = runWhatIf(os.Args[2:])
+	case "blocked":
+		err = runBlocked(os.Args[2:])
+	case "zones":
+		err = runZones(os.Args[2:])
+	case "capabilities":
+		err = runCapabilities(os.Args[2:])
+	case "worst-links":
+		err = runWorstLinks(os.Args[2:])
+	case "node":
+		err = runNode(os.Args[2:])
+	case "set-link":
+		err = runSetLink(os.Args[2:])
+	case "measure":
+		err = runMeasure(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage:
+  kubectl networktopology whatif --remove-node <name>   report pods and connectivity affected by removing a node
+  kubectl networktopology blocked                       list pods currently blocked by network constraints
+  kubectl networktopology zones                         zone-to-zone latency and bandwidth medians
+  kubectl networktopology capabilities                  which nodes carry each network capability
+  kubectl networktopology worst-links [-n 10]           the unhealthiest measured links cluster-wide
+  kubectl networktopology node <name>                   one node's links and administrative overrides
+  kubectl networktopology set-link [flags]              mark a link administratively down (or clear it)
+  kubectl networktopology measure --from a --to b,c     probe specific links ad hoc via a Job on the source node`)
+}
+
+func runWhatIf(args []string) error {
+	fs := flag.NewFlagSet("whatif", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	removeNode := fs.String("remove-node", "", "Node whose removal to simulate. Required.")
+	argsFile := fs.String("plugin-args", "", "NetworkAwareArgs file matching the scheduler config; defaults when empty.")
+	islandThreshold := fs.String("island-threshold", "30ms", "Latency threshold for connectivity islands; empty disables island analysis.")
+	fs.Parse(args)
+	if *removeNode == "" {
+		return fmt.Errorf("--remove-node is required")
+	}
+
+	pluginArgs := &networkaware.NetworkAwareArgs{}
+	if *argsFile != "" {
+		data, err := os.ReadFile(*argsFile)
+		if err != nil {
+			return fmt.Errorf("reading plugin args: %w", err)
+		}
+		if err := yaml.Unmarshal(data, pluginArgs); err != nil {
+			return fmt.Errorf("parsing plugin args: %w", err)
+		}
+	}
+	var thresholdMs int64
+	if *islandThreshold != "" {
+		d, err := units.ParseLatency(*islandThreshold)
+		if err != nil {
+			return fmt.Errorf("parsing --island-threshold: %w", err)
+		}
+		thresholdMs = d.Milliseconds()
+	}
+
+	config, err := buildConfig(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("listing nodes: %w", err)
+	}
+	var remaining []*v1.Node
+	found := false
+	for i := range nodes.Items {
+		if nodes.Items[i].Name == *removeNode {
+			found = true
+			continue
+		}
+		remaining = append(remaining, &nodes.Items[i])
+	}
+	if !found {
+		return fmt.Errorf("node %q not found in the cluster", *removeNode)
+	}
+
+	topo, err := fetchTopology(ctx, dyn)
+	if err != nil {
+		return err
+	}
+	if topo == nil {
+		fmt.Println("warning: no NetworkTopology object; evaluating with label fallback only")
+	}
+	pruned := topology.RemoveNode(topo, *removeNode)
+
+	pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("listing pods: %w", err)
+	}
+
+	fmt.Printf("Simulating removal of node %s\n\n", *removeNode)
+	reportCapabilities(topo, pruned, *removeNode)
+	reportZones(topo, pruned)
+	reportIslands(topo, pruned, thresholdMs)
+	return reportPods(pluginArgs, pods.Items, remaining, pruned)
+}
+
+// reportCapabilities lists capabilities that exist nowhere else once the node
+// is gone; pods requiring them are the first casualties.
+func reportCapabilities(before, after *v1alpha1.NetworkTopology, removed string) {
+	if before == nil {
+		return
+	}
+	spec, ok := topology.Shared(before).Node(removed)
+	if !ok {
+		return
+	}
+	survivors := map[string]bool{}
+	ix := topology.Shared(after)
+	for _, name := range ix.NodeNames() {
+		node, _ := ix.Node(name)
+		for _, c := range node.Capabilities {
+			survivors[c] = true
+		}
+	}
+	var lost []string
+	for _, c := range spec.Capabilities {
+		if !survivors[c] {
+			lost = append(lost, c)
+		}
+	}
+	if len(lost) > 0 {
+		sort.Strings(lost)
+		fmt.Printf("Capabilities lost cluster-wide: %v\n", lost)
+	}
+}
+
+// reportZones lists zones whose last node is the removed one.
+func reportZones(before, after *v1alpha1.NetworkTopology) {
+	if before == nil {
+		return
+	}
+	for zone, count := range zoneCounts(before) {
+		if zone != "" && zoneCounts(after)[zone] == 0 && count > 0 {
+			fmt.Printf("Zone %q loses its last node\n", zone)
+		}
+	}
+}
+
+func zoneCounts(topo *v1alpha1.NetworkTopology) map[string]int {
+	counts := map[string]int{}
+	ix := topology.Shared(topo)
+	for _, name := range ix.NodeNames() {
+		spec, _ := ix.Node(name)
+		counts[spec.Zone]++
+	}
+	return counts
+}
+
+// reportIslands compares the connectivity partition before and after.
+func reportIslands(before, after *v1alpha1.NetworkTopology, thresholdMs int64) {
+	beforeIslands := networkaware.Islands(before, thresholdMs)
+	afterIslands := networkaware.Islands(after, thresholdMs)
+	if beforeIslands == nil {
+		return
+	}
+	if b, a := islandCount(beforeIslands), islandCount(afterIslands); b != a {
+		fmt.Printf("Connectivity islands: %d -> %d\n", b, a)
+		for _, members := range islandMembers(afterIslands) {
+			fmt.Printf("  island: %v\n", members)
+		}
+	}
+}
+
+func islandCount(islands map[string]int) int {
+	seen := map[int]bool{}
+	for _, id := range islands {
+		seen[id] = true
+	}
+	return len(seen)
+}
+
+func islandMembers(islands map[string]int) [][]string {
+	byID := map[int][]string{}
+	for node, id := range islands {
+		byID[id] = append(byID[id], node)
+	}
+	ids := make([]int, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	members := make([][]string, 0, len(ids))
+	for _, id := range ids {
+		sort.Strings(byID[id])
+		members = append(members, byID[id])
+	}
+	return members
+}
+
+// reportPods simulates every network-annotated pod against the remaining
+// nodes and pruned topology, reporting the ones with no feasible node left.
+func reportPods(args *networkaware.NetworkAwareArgs, pods []v1.Pod, nodes []*v1.Node, topo *v1alpha1.NetworkTopology) error {
+	unschedulable := 0
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		if !requirements.HasAnnotations(pod) {
+			continue
+		}
+		results, err := networkaware.Simulate(args, pod, nodes, topo)
+		if err != nil {
+			return fmt.Errorf("simulating pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		reasons := map[string]bool{}
+		feasible := false
+		for _, r := range results {
+			if r.Feasible {
+				feasible = true
+				break
+			}
+			for _, v := range r.FilterVerdicts {
+				reasons[v] = true
+			}
+		}
+		if feasible {
+			continue
+		}
+		unschedulable++
+		fmt.Printf("Pod %s/%s would have no feasible node:\n", pod.Namespace, pod.Name)
+		for _, reason := range sortedReasons(reasons) {
+			fmt.Printf("  %s\n", reason)
+		}
+	}
+	if unschedulable == 0 {
+		fmt.Println("No network-annotated pods become unschedulable")
+	}
+	return nil
+}
+
+func sortedReasons(reasons map[string]bool) []string {
+	out := make([]string, 0, len(reasons))
+	for reason := range reasons {
+		out = append(out, reason)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// runBlocked renders the scheduler plugin's NetworkSchedulingReport.
+func runBlocked(args []string) error {
+	fs := flag.NewFlagSet("blocked", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	fs.Parse(args)
+
+	config, err := buildConfig(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	report, err := fetchReport(context.Background(), dyn)
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		fmt.Println("No NetworkSchedulingReport; either nothing is blocked or the scheduler plugin is not running")
+		return nil
+	}
+	if len(report.Status.Reasons) == 0 {
+		fmt.Println("No pods are blocked by network constraints")
+		return nil
+	}
+	for _, group := range report.Status.Reasons {
+		total := 0
+		for _, pod := range group.Pods {
+			total += int(pod.Attempts)
+		}
+		fmt.Printf("Blocked by %s (%d pods, %d rejections):\n", group.Reason, len(group.Pods), total)
+		for _, pod := range group.Pods {
+			fmt.Printf("  %s/%s  blocked for %s  attempts=%d\n",
+				pod.Namespace, pod.Name, age(pod.FirstSeen.Time), pod.Attempts)
+			if pod.Message != "" {
+				fmt.Printf("    %s\n", pod.Message)
+			}
+		}
+	}
+	if report.Status.UpdatedAt != nil {
+		fmt.Printf("\nReport updated %s ago\n", age(report.Status.UpdatedAt.Time))
+	}
+	return nil
+}
+
+// summaryTopology fetches the topology for the summary subcommands, failing
+// rather than printing empty tables when the object is missing.
+func summaryTopology(kubeconfig string) (*v1alpha1.NetworkTopology, error) {
+	config, err := buildConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	topo, err := fetchTopology(context.Background(), dyn)
+	if err != nil {
+		return nil, err
+	}
+	if topo == nil {
+		return nil, fmt.Errorf("no NetworkTopology object; is the topology controller running?")
+	}
+	return topo, nil
+}
+
+// runZones renders the zone-to-zone medians.
+func runZones(args []string) error {
+	fs := flag.NewFlagSet("zones", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	fs.Parse(args)
+	topo, err := summaryTopology(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	summaries := topology.Shared(topo).ZoneSummaries()
+	if len(summaries) == 0 {
+		fmt.Println("No measured links in the topology")
+		return nil
+	}
+	for _, s := range summaries {
+		fmt.Printf("%s -> %s  %d links  median latency %s  median bandwidth %s\n",
+			zoneLabel(s.Source), zoneLabel(s.Target), s.Links,
+			medianLatency(s.MedianLatencyMs), medianBandwidth(s.MedianBandwidthMbps))
+	}
+	return nil
+}
+
+func zoneLabel(zone string) string {
+	if zone == "" {
+		return "(no zone)"
+	}
+	return zone
+}
+
+func medianLatency(ms int64) string {
+	if ms < 0 {
+		return "unknown"
+	}
+	return fmt.Sprintf("%dms", ms)
+}
+
+func medianBandwidth(mbps int64) string {
+	if mbps < 0 {
+		return "unknown"
+	}
+	return fmt.Sprintf("%dmbps", mbps)
+}
+
+// runCapabilities lists which nodes carry each capability.
+func runCapabilities(args []string) error {
+	fs := flag.NewFlagSet("capabilities", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	fs.Parse(args)
+	topo, err := summaryTopology(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	byCapability := topology.Shared(topo).CapabilityNodes()
+	if len(byCapability) == 0 {
+		fmt.Println("No node in the topology declares a capability")
+		return nil
+	}
+	capabilities := make([]string, 0, len(byCapability))
+	for capability := range byCapability {
+		capabilities = append(capabilities, capability)
+	}
+	sort.Strings(capabilities)
+	for _, capability := range capabilities {
+		fmt.Printf("%s:\n", capability)
+		for _, node := range byCapability[capability] {
+			fmt.Printf("  %s\n", node)
+		}
+	}
+	return nil
+}
+
+// runWorstLinks renders the unhealthiest measured links.
+func runWorstLinks(args []string) error {
+	fs := flag.NewFlagSet("worst-links", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	n := fs.Int("n", 10, "How many links to show, unhealthiest first.")
+	fs.Parse(args)
+	if *n <= 0 {
+		return fmt.Errorf("-n must be positive")
+	}
+	topo, err := summaryTopology(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	links := topology.Shared(topo).WorstLinks(*n)
+	if len(links) == 0 {
+		fmt.Println("No measured links in the topology")
+		return nil
+	}
+	for _, link := range links {
+		bandwidth := link.Bandwidth
+		if bandwidth == "" {
+			bandwidth = "unknown"
+		}
+		fmt.Printf("%s -> %s  health %.2f  latency %s  bandwidth %s\n",
+			link.Source, link.Target, link.Health, link.Latency, bandwidth)
+	}
+	return nil
+}
+
+func age(t time.Time) string {
+	return time.Since(t).Round(time.Second).String()
+}
+
+func fetchReport(ctx context.Context, dyn dynamic.Interface) (*v1alpha1.NetworkSchedulingReport, error) {
+	obj, err := dyn.Resource(reportGVR).Get(ctx, reportName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching scheduling report: %w", err)
+	}
+	report := &v1alpha1.NetworkSchedulingReport{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, report); err != nil {
+		return nil, fmt.Errorf("converting NetworkSchedulingReport: %w", err)
+	}
+	return report, nil
+}
+
+func fetchTopology(ctx context.Context, dyn dynamic.Interface) (*v1alpha1.NetworkTopology, error) {
+	obj, err := dyn.Resource(topologyGVR).Get(ctx, topologyName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching topology: %w", err)
+	}
+	topo := &v1alpha1.NetworkTopology{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, topo); err != nil {
+		return nil, fmt.Errorf("converting NetworkTopology: %w", err)
+	}
+	return topo, nil
+}
+
+func buildConfig(kubeconfig string) (*rest.Config, error) {
+	if kubeconfig != "" {
+		return clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+	return rest.InClusterConfig()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// measureAnnotation mirrors the controller's ad-hoc measure request: the
+// value is "<source>:<target>[,<target>...]" and the controller clears it
+// once it has launched the probe Job.
+const measureAnnotation = requirements.AnnotationPrefix + "measure"
+
+// runMeasure requests an ad-hoc measurement between specific nodes, executed
+// by a probe Job the controller pins to the source node so the result
+// genuinely describes that node's path. Results land in the topology with
+// the controller's next write; this command only files the request.
+func runMeasure(args []string) error {
+	fs := flag.NewFlagSet("measure", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	from := fs.String("from", "", "Source node the probe Job runs on. Required.")
+	to := fs.String("to", "", "Comma-separated target nodes. Required.")
+	fs.Parse(args)
+	if *from == "" || *to == "" {
+		return fmt.Errorf("--from and --to are required")
+	}
+	for _, target := range strings.Split(*to, ",") {
+		if strings.TrimSpace(target) == *from {
+			return fmt.Errorf("--to includes the source node %q", *from)
+		}
+	}
+
+	config, err := buildConfig(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	obj, err := dyn.Resource(topologyGVR).Get(ctx, topologyName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("fetching topology: %w", err)
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[measureAnnotation] = *from + ":" + *to
+	obj.SetAnnotations(annotations)
+	if _, err := dyn.Resource(topologyGVR).Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("requesting measurement: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "measurement requested: %s -> %s; the controller launches a probe job on %s (requires --probe-job-image) and the result lands with its next topology write\n",
+		*from, *to, *from)
+	return nil
+}
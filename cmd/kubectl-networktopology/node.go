@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+)
+
+// runNode prints one node's view of the topology: its position, its outgoing
+// links, and any administrative overrides touching it — active or already
+// expired but not yet swept by the controller.
+func runNode(args []string) error {
+	fs := flag.NewFlagSet("node", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("node takes exactly one node name")
+	}
+	name := fs.Arg(0)
+
+	topo, err := summaryTopology(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	spec, ok := topology.Shared(topo).Node(name)
+	if !ok {
+		return fmt.Errorf("node %q is not in the topology", name)
+	}
+
+	fmt.Println(name)
+	if spec.Zone != "" {
+		fmt.Printf("  zone: %s\n", spec.Zone)
+	}
+	if spec.Region != "" {
+		fmt.Printf("  region: %s\n", spec.Region)
+	}
+	if spec.Cluster != "" {
+		fmt.Printf("  cluster: %s\n", spec.Cluster)
+	}
+	if len(spec.Capabilities) > 0 {
+		fmt.Printf("  capabilities: %s\n", strings.Join(spec.Capabilities, ", "))
+	}
+	if spec.HealthScore != nil {
+		fmt.Printf("  health: %.2f\n", *spec.HealthScore)
+	}
+	if spec.Maintenance {
+		fmt.Println("  maintenance: true")
+	}
+
+	targets := make([]string, 0, len(spec.Latency))
+	for target := range spec.Latency {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		line := fmt.Sprintf("  -> %s  latency %s", target, spec.Latency[target])
+		if bw := spec.Bandwidth[target]; bw != "" {
+			line += "  bandwidth " + bw
+		}
+		if cost, ok := spec.Cost[target]; ok {
+			line += fmt.Sprintf("  cost %.2f", cost)
+		}
+		fmt.Println(line)
+	}
+
+	for _, o := range topo.Spec.Overrides {
+		if o.Source != name && o.Target != name {
+			continue
+		}
+		line := fmt.Sprintf("  override: %s <-> %s %s", o.Source, o.Target, o.State)
+		switch {
+		case o.ExpiresAt == nil:
+			line += " (until cleared)"
+		case o.ExpiresAt.Time.Before(time.Now()):
+			line += fmt.Sprintf(" (expired %s ago, removal pending)",
+				time.Since(o.ExpiresAt.Time).Round(time.Second))
+		default:
+			line += fmt.Sprintf(" (expires in %s)",
+				time.Until(o.ExpiresAt.Time).Round(time.Second))
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// runSetLink writes or clears an administrative link override on the live
+// topology. The controller honors active overrides on its next cycle (skips
+// probing the pair, publishes no measurements for it), republishes them
+// until they lapse, and re-probes the pair immediately after expiry.
+func runSetLink(args []string) error {
+	fs := flag.NewFlagSet("set-link", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	source := fs.String("source", "", "Source node of the link. Required.")
+	target := fs.String("target", "", "Target node of the link. Required.")
+	state := fs.String("state", "", `Administrative state: "down" marks both directions of the pair down, "clear" removes an existing override.`)
+	until := fs.Duration("until", 0, "How long a down override lasts (e.g. 2h); 0 holds it until cleared.")
+	fs.Parse(args)
+	if *source == "" || *target == "" {
+		return fmt.Errorf("--source and --target are required")
+	}
+	if *state != "down" && *state != "clear" {
+		return fmt.Errorf(`--state must be "down" or "clear", got %q`, *state)
+	}
+
+	config, err := buildConfig(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	topo, err := fetchTopology(ctx, dyn)
+	if err != nil {
+		return err
+	}
+	if topo == nil {
+		return fmt.Errorf("no NetworkTopology object; is the topology controller running?")
+	}
+
+	// One override per unordered pair: setting replaces, clearing removes.
+	kept := make([]v1alpha1.LinkOverride, 0, len(topo.Spec.Overrides))
+	cleared := false
+	for _, o := range topo.Spec.Overrides {
+		if (o.Source == *source && o.Target == *target) || (o.Source == *target && o.Target == *source) {
+			cleared = true
+			continue
+		}
+		kept = append(kept, o)
+	}
+	switch *state {
+	case "down":
+		o := v1alpha1.LinkOverride{Source: *source, Target: *target, State: "down"}
+		if *until > 0 {
+			expires := metav1.NewTime(time.Now().Add(*until))
+			o.ExpiresAt = &expires
+		}
+		kept = append(kept, o)
+	case "clear":
+		if !cleared {
+			return fmt.Errorf("no override exists for %s <-> %s", *source, *target)
+		}
+	}
+	topo.Spec.Overrides = kept
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(topo)
+	if err != nil {
+		return fmt.Errorf("converting topology: %w", err)
+	}
+	u := &unstructured.Unstructured{Object: obj}
+	u.SetAPIVersion(v1alpha1.SchemeGroupVersion.String())
+	u.SetKind("NetworkTopology")
+	if _, err := dyn.Resource(topologyGVR).Update(ctx, u, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("updating topology: %w", err)
+	}
+	switch *state {
+	case "down":
+		if *until > 0 {
+			fmt.Fprintf(os.Stderr, "marked %s <-> %s down until %s\n", *source, *target,
+				time.Now().Add(*until).Format(time.RFC3339))
+		} else {
+			fmt.Fprintf(os.Stderr, "marked %s <-> %s down until cleared\n", *source, *target)
+		}
+	case "clear":
+		fmt.Fprintf(os.Stderr, "cleared override on %s <-> %s\n", *source, *target)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/scheduler/networkaware"
+)
+
+// runBench runs the plugin's extension points in isolation against generated
+// data and prints per-point latency percentiles, so plugin-cost regressions
+// are catchable without a cluster.
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	nodes := fs.Int("nodes", 200, "Number of generated nodes in the full-mesh topology.")
+	pods := fs.Int("pods", 500, "Number of pods scheduled through the plugin.")
+	fs.Parse(args)
+	if err := networkaware.RunBench(networkaware.BenchOptions{Nodes: *nodes, Pods: *pods}, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
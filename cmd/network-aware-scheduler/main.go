@@ -0,0 +1,75 @@
+// Command network-aware-scheduler runs kube-scheduler with the NetworkAware
+// plugin registered. Deploy it as a second scheduler named
+// "network-aware-scheduler" and opt pods in via spec.schedulerName.
+//
+// Tracing: the plugin emits OpenTelemetry spans per extension point when the
+// standard OTEL_EXPORTER_OTLP_* environment variables are set (sampling via
+// OTEL_TRACES_SAMPLER_ARG, stdout exporter via TRACING_STDOUT=true); without
+// them the instrumentation is a no-op.
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/component-base/cli"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/cmd/kube-scheduler/app"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/features"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/logging"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/scheduler/networkaware"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/tracing"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/version"
+)
+
+func main() {
+	// "bench" is a self-contained self-test, not a scheduler run: it never
+	// touches a cluster, so it bypasses the kube-scheduler command wiring.
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		os.Exit(runBench(os.Args[2:]))
+	}
+
+	ctx := context.Background()
+	shutdownTracing, err := tracing.Setup(ctx, "network-aware-scheduler", tracing.Options{
+		Enabled:     tracing.EnabledFromEnv(),
+		Stdout:      os.Getenv("TRACING_STDOUT") == "true",
+		SampleRatio: tracing.SampleRatioFromEnv(),
+	})
+	if err != nil {
+		klog.ErrorS(err, "Configuring tracing")
+		os.Exit(1)
+	}
+
+	command := app.NewSchedulerCommand(
+		app.WithPlugin(networkaware.Name, networkaware.New),
+	)
+	// kube-scheduler already owns --feature-gates for the Kubernetes gates,
+	// so the plugin's gates get their own flag, applied before the scheduler
+	// starts.
+	var networkGates string
+	command.Flags().StringVar(&networkGates, "network-feature-gates", "",
+		"Comma-separated Name=bool overrides of the NetworkAware plugin's feature gates, e.g. BandwidthReservation=false. Empty keeps every gate at its default.")
+	var logFormat string
+	command.Flags().StringVar(&logFormat, "log-format", logging.FormatText,
+		`Log output format: "text" (klog's default) or "json", one JSON object per line with field names shared with the topology controller.`)
+	previousPreRunE := command.PersistentPreRunE
+	command.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := logging.Setup(logFormat); err != nil {
+			return err
+		}
+		if err := features.SetDefaultFromFlag(networkGates); err != nil {
+			return err
+		}
+		klog.InfoS("Starting network-aware scheduler",
+			"gitSHA", version.GitSHA, "buildDate", version.BuildDate, "featureGates", features.Default.String())
+		if previousPreRunE != nil {
+			return previousPreRunE(cmd, args)
+		}
+		return nil
+	}
+	code := cli.Run(command)
+	shutdownTracing(ctx)
+	os.Exit(code)
+}
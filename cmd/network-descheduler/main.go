@@ -0,0 +1,279 @@
+// network-descheduler periodically re-evaluates running pods with
+// network.komarov.dev annotations against the current topology and evicts
+// pods whose required constraints have been violated for longer than the
+// grace period. Placement that was valid at scheduling time can rot as links
+// degrade; this closes the loop the scheduler plugin cannot.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+const topologyName = "cluster"
+
+var topologyGVR = v1alpha1.SchemeGroupVersion.WithResource("networktopologies")
+
+var (
+	violationsFoundTotal = metrics.NewCounterVec(&metrics.CounterOpts{
+		Name:           "networkdescheduler_violations_found_total",
+		Help:           "Number of required-mode violations observed on running pods, by kind.",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"kind"})
+
+	evictionsTotal = metrics.NewCounter(&metrics.CounterOpts{
+		Name:           "networkdescheduler_evictions_total",
+		Help:           "Number of pods evicted for persistent network violations.",
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	evictionsBlockedTotal = metrics.NewCounter(&metrics.CounterOpts{
+		Name:           "networkdescheduler_evictions_blocked_total",
+		Help:           "Number of evictions refused by a PodDisruptionBudget.",
+		StabilityLevel: metrics.ALPHA,
+	})
+)
+
+type options struct {
+	kubeconfig     string
+	interval       time.Duration
+	gracePeriod    time.Duration
+	evictionBudget int
+	dryRun         bool
+	metricsAddr    string
+}
+
+type descheduler struct {
+	opts    options
+	client  kubernetes.Interface
+	dynamic dynamic.Interface
+	now     func() time.Time
+
+	// firstSeen is when a pod's current violation streak started; pods
+	// are only evicted once the streak outlives the grace period.
+	firstSeen map[types.UID]time.Time
+}
+
+func main() {
+	opts := options{}
+	flag.StringVar(&opts.kubeconfig, "kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	flag.DurationVar(&opts.interval, "interval", 5*time.Minute, "How often to evaluate running pods.")
+	flag.DurationVar(&opts.gracePeriod, "grace-period", 10*time.Minute, "How long a violation must persist before eviction.")
+	flag.IntVar(&opts.evictionBudget, "eviction-budget", 2, "Maximum evictions per cycle.")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "Log would-be evictions without performing them.")
+	flag.StringVar(&opts.metricsAddr, "metrics-addr", ":9090", "Address to serve /metrics on; empty disables.")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	config, err := buildConfig(opts.kubeconfig)
+	if err != nil {
+		klog.ErrorS(err, "Building client config")
+		os.Exit(1)
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		klog.ErrorS(err, "Building clientset")
+		os.Exit(1)
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		klog.ErrorS(err, "Building dynamic client")
+		os.Exit(1)
+	}
+
+	legacyregistry.MustRegister(violationsFoundTotal, evictionsTotal, evictionsBlockedTotal)
+	if opts.metricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", legacyregistry.Handler())
+			if err := http.ListenAndServe(opts.metricsAddr, mux); err != nil {
+				klog.ErrorS(err, "Metrics server failed")
+			}
+		}()
+	}
+
+	d := &descheduler{
+		opts:      opts,
+		client:    client,
+		dynamic:   dyn,
+		now:       time.Now,
+		firstSeen: map[types.UID]time.Time{},
+	}
+	ctx := context.Background()
+	for {
+		if err := d.runCycle(ctx); err != nil {
+			klog.ErrorS(err, "Descheduling cycle failed")
+		}
+		time.Sleep(opts.interval)
+	}
+}
+
+func buildConfig(kubeconfig string) (*rest.Config, error) {
+	if kubeconfig != "" {
+		return clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+	return rest.InClusterConfig()
+}
+
+// runCycle evaluates every annotated running pod and evicts persistent
+// violators up to the cycle's budget. Pods without network annotations are
+// never touched.
+func (d *descheduler) runCycle(ctx context.Context) error {
+	topo, err := d.getTopology(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching topology: %w", err)
+	}
+	if topo == nil {
+		// Without measurements, any verdict would rest on the
+		// conservative unknown-node defaults; never evict on those.
+		klog.V(2).InfoS("No NetworkTopology object, skipping cycle")
+		return nil
+	}
+	nodes, err := d.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("listing nodes: %w", err)
+	}
+	nodeByName := make(map[string]*v1.Node, len(nodes.Items))
+	for i := range nodes.Items {
+		nodeByName[nodes.Items[i].Name] = &nodes.Items[i]
+	}
+
+	pods, err := d.client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("listing pods: %w", err)
+	}
+
+	evicted := 0
+	seen := map[types.UID]bool{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.NodeName == "" || pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		if !requirements.HasAnnotations(pod) {
+			continue
+		}
+		reqs, err := requirements.Parse(pod)
+		if err != nil {
+			klog.V(2).InfoS("Skipping pod with malformed annotations", "pod", klog.KObj(pod), "err", err)
+			continue
+		}
+		if reqs.Empty() {
+			continue
+		}
+		node, ok := nodeByName[pod.Spec.NodeName]
+		if !ok {
+			continue
+		}
+
+		violations := requiredViolations(reqs, node, topo)
+		if len(violations) == 0 {
+			continue
+		}
+		seen[pod.UID] = true
+		for _, v := range violations {
+			violationsFoundTotal.WithLabelValues(v.Kind).Inc()
+		}
+
+		since, tracked := d.firstSeen[pod.UID]
+		if !tracked {
+			d.firstSeen[pod.UID] = d.now()
+			continue
+		}
+		if d.now().Sub(since) < d.opts.gracePeriod {
+			continue
+		}
+		if evicted >= d.opts.evictionBudget {
+			klog.V(2).InfoS("Eviction budget exhausted, deferring", "pod", klog.KObj(pod))
+			continue
+		}
+		if d.opts.dryRun {
+			klog.InfoS("Dry run: would evict pod", "pod", klog.KObj(pod),
+				"node", pod.Spec.NodeName, "reason", violations[0].Reason)
+			continue
+		}
+		if d.evict(ctx, pod, violations[0].Reason) {
+			evicted++
+			delete(d.firstSeen, pod.UID)
+		}
+	}
+
+	// Forget pods whose violation cleared (or which went away) so a later
+	// relapse starts a fresh grace period.
+	for uid := range d.firstSeen {
+		if !seen[uid] {
+			delete(d.firstSeen, uid)
+		}
+	}
+	return nil
+}
+
+// requiredViolations returns only the violations that would have rejected
+// the node in the scheduler's Filter; preferred-mode violations never evict.
+func requiredViolations(reqs *requirements.Requirements, node *v1.Node, topo *v1alpha1.NetworkTopology) []requirements.Violation {
+	var out []requirements.Violation
+	for _, v := range requirements.Evaluate(reqs, node, topo, requirements.DefaultUnknownPolicy()) {
+		if v.Mode == requirements.ModeRequired {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// evict issues an Eviction so PodDisruptionBudgets are honored server-side.
+func (d *descheduler) evict(ctx context.Context, pod *v1.Pod, reason string) bool {
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+	}
+	err := d.client.CoreV1().Pods(pod.Namespace).EvictV1(ctx, eviction)
+	if err != nil {
+		if apierrors.IsTooManyRequests(err) {
+			evictionsBlockedTotal.Inc()
+			klog.V(2).InfoS("Eviction blocked by PodDisruptionBudget", "pod", klog.KObj(pod))
+		} else {
+			klog.ErrorS(err, "Evicting pod", "pod", klog.KObj(pod))
+		}
+		return false
+	}
+	evictionsTotal.Inc()
+	klog.InfoS("Evicted pod violating its network requirements",
+		"pod", klog.KObj(pod), "node", pod.Spec.NodeName, "reason", reason)
+	return true
+}
+
+func (d *descheduler) getTopology(ctx context.Context) (*v1alpha1.NetworkTopology, error) {
+	obj, err := d.dynamic.Resource(topologyGVR).Get(ctx, topologyName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	topo := &v1alpha1.NetworkTopology{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, topo); err != nil {
+		return nil, fmt.Errorf("converting NetworkTopology: %w", err)
+	}
+	return topo, nil
+}
@@ -0,0 +1,265 @@
+// network-metrics-adapter serves the measured topology as Kubernetes external
+// metrics (external.metrics.k8s.io) so HPAs can scale on network conditions —
+// e.g. scaling a relay Deployment on inter-zone latency — without a Prometheus
+// pipeline in between. It is built on the custom-metrics-apiserver boilerplate
+// and reads the NetworkTopology object on every query.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+	basecmd "sigs.k8s.io/custom-metrics-apiserver/pkg/cmd"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+const topologyName = "cluster"
+
+var topologyGVR = v1alpha1.SchemeGroupVersion.WithResource("networktopologies")
+
+// Metric names served by the adapter.
+const (
+	metricLinkLatencyMs     = "network_link_latency_ms"
+	metricZoneBandwidthMbps = "network_zone_bandwidth_mbps"
+)
+
+type adapter struct {
+	basecmd.AdapterBase
+	printManifests bool
+}
+
+func main() {
+	cmd := &adapter{}
+	cmd.Name = "network-metrics-adapter"
+	cmd.Flags().BoolVar(&cmd.printManifests, "print-manifests",
+		false, "Print the APIService and RBAC manifests for the adapter and exit.")
+	logs := flag.NewFlagSet("logs", flag.ExitOnError)
+	klog.InitFlags(logs)
+	cmd.Flags().AddGoFlagSet(logs)
+	if err := cmd.Flags().Parse(os.Args[1:]); err != nil {
+		klog.ErrorS(err, "Parsing flags")
+		os.Exit(1)
+	}
+	if cmd.printManifests {
+		fmt.Print(wiringManifests)
+		return
+	}
+
+	config, err := cmd.ClientConfig()
+	if err != nil {
+		klog.ErrorS(err, "Building client config")
+		os.Exit(1)
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		klog.ErrorS(err, "Building dynamic client")
+		os.Exit(1)
+	}
+
+	cmd.WithExternalMetrics(&topologyMetrics{dynamic: dyn})
+	if err := cmd.Run(wait.NeverStop); err != nil {
+		klog.ErrorS(err, "Running adapter")
+		os.Exit(1)
+	}
+}
+
+// topologyMetrics implements provider.ExternalMetricsProvider over the
+// NetworkTopology object.
+type topologyMetrics struct {
+	dynamic dynamic.Interface
+}
+
+var _ provider.ExternalMetricsProvider = &topologyMetrics{}
+
+// ListAllExternalMetrics advertises the served metric names; label sets are
+// discovered by querying.
+func (m *topologyMetrics) ListAllExternalMetrics() []provider.ExternalMetricInfo {
+	return []provider.ExternalMetricInfo{
+		{Metric: metricLinkLatencyMs},
+		{Metric: metricZoneBandwidthMbps},
+	}
+}
+
+// GetExternalMetric returns the samples of the named metric whose labels
+// match the HPA's selector. Link latency carries source/target labels, zone
+// bandwidth a zone label.
+func (m *topologyMetrics) GetExternalMetric(ctx context.Context, namespace string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	topo, err := m.getTopology(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := &external_metrics.ExternalMetricValueList{}
+	if topo == nil {
+		return list, nil
+	}
+
+	switch info.Metric {
+	case metricLinkLatencyMs:
+		for source, spec := range topo.Spec.Nodes {
+			for target, latStr := range spec.Latency {
+				lat, ok := requirements.ParseLatencyMs(latStr)
+				if !ok {
+					continue
+				}
+				appendSample(list, info.Metric, metricSelector,
+					map[string]string{"source": source, "target": target}, lat)
+			}
+		}
+	case metricZoneBandwidthMbps:
+		for zone, mbps := range zoneBandwidthMbps(topo) {
+			appendSample(list, info.Metric, metricSelector,
+				map[string]string{"zone": zone}, mbps)
+		}
+	default:
+		return nil, apierrors.NewNotFound(
+			external_metrics.Resource("externalmetricvalues"), info.Metric)
+	}
+	return list, nil
+}
+
+// appendSample adds one labeled value when the selector matches its labels.
+func appendSample(list *external_metrics.ExternalMetricValueList, metric string, selector labels.Selector, metricLabels map[string]string, value int64) {
+	if !selector.Matches(labels.Set(metricLabels)) {
+		return
+	}
+	list.Items = append(list.Items, external_metrics.ExternalMetricValue{
+		MetricName:   metric,
+		MetricLabels: metricLabels,
+		Timestamp:    metav1.Now(),
+		Value:        *resource.NewQuantity(value, resource.DecimalSI),
+	})
+}
+
+// zoneBandwidthMbps reduces the topology to one bandwidth value per zone: the
+// best measured bandwidth from any of the zone's nodes, a proxy for the
+// zone's usable uplink capacity.
+func zoneBandwidthMbps(topo *v1alpha1.NetworkTopology) map[string]int64 {
+	zones := map[string]int64{}
+	for _, spec := range topo.Spec.Nodes {
+		if spec.Zone == "" {
+			continue
+		}
+		for _, bwStr := range spec.Bandwidth {
+			if bw, ok := requirements.ParseBandwidthMbps(bwStr); ok && bw > zones[spec.Zone] {
+				zones[spec.Zone] = bw
+			}
+		}
+	}
+	return zones
+}
+
+func (m *topologyMetrics) getTopology(ctx context.Context) (*v1alpha1.NetworkTopology, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	obj, err := m.dynamic.Resource(topologyGVR).Get(ctx, topologyName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	topo := &v1alpha1.NetworkTopology{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, topo); err != nil {
+		return nil, fmt.Errorf("converting NetworkTopology: %w", err)
+	}
+	return topo, nil
+}
+
+// wiringManifests is the APIService and RBAC plumbing the adapter needs,
+// printed by --print-manifests so deployments stay in step with the binary.
+const wiringManifests = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: network-metrics-adapter
+  namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: network-metrics-adapter
+rules:
+  - apiGroups: ["network.komarov.dev"]
+    resources: ["networktopologies"]
+    verbs: ["get"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: network-metrics-adapter
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: network-metrics-adapter
+subjects:
+  - kind: ServiceAccount
+    name: network-metrics-adapter
+    namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: network-metrics-adapter:system:auth-delegator
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: system:auth-delegator
+subjects:
+  - kind: ServiceAccount
+    name: network-metrics-adapter
+    namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: network-metrics-adapter-auth-reader
+  namespace: kube-system
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: extension-apiserver-authentication-reader
+subjects:
+  - kind: ServiceAccount
+    name: network-metrics-adapter
+    namespace: kube-system
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: network-metrics-adapter
+  namespace: kube-system
+spec:
+  selector:
+    app: network-metrics-adapter
+  ports:
+    - port: 443
+      targetPort: 6443
+---
+apiVersion: apiregistration.k8s.io/v1
+kind: APIService
+metadata:
+  name: v1beta1.external.metrics.k8s.io
+spec:
+  group: external.metrics.k8s.io
+  version: v1beta1
+  groupPriorityMinimum: 100
+  versionPriority: 100
+  insecureSkipTLSVerify: true
+  service:
+    name: network-metrics-adapter
+    namespace: kube-system
+`
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// adapterTopology spans two zones: edge's best bandwidth is pi-1's 900mbps,
+// core's is hetzner-1's 950mbps, and unzoned carries no zone at all.
+func adapterTopology() *v1alpha1.NetworkTopology {
+	return &v1alpha1.NetworkTopology{Spec: v1alpha1.NetworkTopologySpec{
+		Nodes: map[string]v1alpha1.NodeSpec{
+			"pi-1": {Zone: "edge",
+				Latency:   map[string]string{"pi-2": "2ms", "hetzner-1": "25ms"},
+				Bandwidth: map[string]string{"pi-2": "900mbps", "hetzner-1": "95mbps"}},
+			"pi-2": {Zone: "edge",
+				Latency:   map[string]string{"pi-1": "2ms"},
+				Bandwidth: map[string]string{"pi-1": "850mbps"}},
+			"hetzner-1": {Zone: "core",
+				Latency:   map[string]string{"pi-1": "25ms"},
+				Bandwidth: map[string]string{"pi-1": "950mbps"}},
+			"unzoned": {Bandwidth: map[string]string{"pi-1": "999mbps"}},
+		},
+	}}
+}
+
+// metricsOver serves the fixture (or no object at all) through a fake
+// dynamic client, the same path the apiserver wiring uses.
+func metricsOver(t *testing.T, topo *v1alpha1.NetworkTopology) *topologyMetrics {
+	t.Helper()
+	listKinds := map[schema.GroupVersionResource]string{topologyGVR: "NetworkTopologyList"}
+	var objects []runtime.Object
+	if topo != nil {
+		topo.APIVersion = v1alpha1.SchemeGroupVersion.String()
+		topo.Kind = "NetworkTopology"
+		topo.Name = topologyName
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(topo)
+		if err != nil {
+			t.Fatalf("converting fixture: %v", err)
+		}
+		objects = append(objects, &unstructured.Unstructured{Object: obj})
+	}
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+	return &topologyMetrics{dynamic: dyn}
+}
+
+func TestZoneBandwidthMbps(t *testing.T) {
+	zones := zoneBandwidthMbps(adapterTopology())
+	if got := zones["edge"]; got != 900 {
+		t.Errorf("edge = %dmbps, want the zone's best link at 900", got)
+	}
+	if got := zones["core"]; got != 950 {
+		t.Errorf("core = %dmbps, want 950", got)
+	}
+	if len(zones) != 2 {
+		t.Errorf("zoneless nodes must not produce a zone, got %v", zones)
+	}
+}
+
+func TestGetExternalMetricLinkLatency(t *testing.T) {
+	m := metricsOver(t, adapterTopology())
+	info := provider.ExternalMetricInfo{Metric: metricLinkLatencyMs}
+
+	list, err := m.GetExternalMetric(context.Background(), "", labels.Everything(), info)
+	if err != nil {
+		t.Fatalf("GetExternalMetric: %v", err)
+	}
+	if len(list.Items) != 4 {
+		t.Fatalf("got %d samples, want one per measured link (4)", len(list.Items))
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{"source": "pi-1", "target": "hetzner-1"})
+	list, err = m.GetExternalMetric(context.Background(), "", selector, info)
+	if err != nil {
+		t.Fatalf("GetExternalMetric with selector: %v", err)
+	}
+	if len(list.Items) != 1 {
+		t.Fatalf("selector matched %d samples, want 1", len(list.Items))
+	}
+	if got := list.Items[0].Value.Value(); got != 25 {
+		t.Errorf("pi-1->hetzner-1 latency = %d, want 25", got)
+	}
+}
+
+func TestGetExternalMetricZoneBandwidth(t *testing.T) {
+	m := metricsOver(t, adapterTopology())
+	selector := labels.SelectorFromSet(labels.Set{"zone": "core"})
+	list, err := m.GetExternalMetric(context.Background(), "",
+		selector, provider.ExternalMetricInfo{Metric: metricZoneBandwidthMbps})
+	if err != nil {
+		t.Fatalf("GetExternalMetric: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Value.Value() != 950 {
+		t.Fatalf("core zone samples = %+v, want one 950 value", list.Items)
+	}
+}
+
+func TestGetExternalMetricUnknownMetric(t *testing.T) {
+	m := metricsOver(t, adapterTopology())
+	_, err := m.GetExternalMetric(context.Background(), "",
+		labels.Everything(), provider.ExternalMetricInfo{Metric: "network_unheard_of"})
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("unknown metric returned %v, want NotFound", err)
+	}
+}
+
+func TestGetExternalMetricNoTopology(t *testing.T) {
+	m := metricsOver(t, nil)
+	list, err := m.GetExternalMetric(context.Background(), "",
+		labels.Everything(), provider.ExternalMetricInfo{Metric: metricLinkLatencyMs})
+	if err != nil {
+		t.Fatalf("missing topology must serve empty, got error %v", err)
+	}
+	if len(list.Items) != 0 {
+		t.Fatalf("missing topology produced %d samples", len(list.Items))
+	}
+}
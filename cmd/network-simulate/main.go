@@ -0,0 +1,168 @@
+// network-simulate answers "where would this pod land and why" by running
+// the NetworkAware plugin's filter and scoring logic against either the live
+// cluster or file-based fixtures, without scheduling anything.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/scheduler/networkaware"
+)
+
+const topologyName = "cluster"
+
+var topologyGVR = v1alpha1.SchemeGroupVersion.WithResource("networktopologies")
+
+type options struct {
+	kubeconfig   string
+	podFile      string
+	nodesFile    string
+	topologyFile string
+	argsFile     string
+}
+
+func main() {
+	opts := options{}
+	flag.StringVar(&opts.kubeconfig, "kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	flag.StringVar(&opts.podFile, "pod", "", "Pod manifest to simulate (YAML or JSON). Required.")
+	flag.StringVar(&opts.nodesFile, "nodes", "", "NodeList manifest to rank against; the live cluster's nodes when empty.")
+	flag.StringVar(&opts.topologyFile, "topology", "", "NetworkTopology manifest; the live object when empty.")
+	flag.StringVar(&opts.argsFile, "plugin-args", "", "NetworkAwareArgs file matching the scheduler config; defaults when empty.")
+	flag.Parse()
+
+	if opts.podFile == "" {
+		fmt.Fprintln(os.Stderr, "--pod is required")
+		os.Exit(2)
+	}
+	if err := run(opts); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(opts options) error {
+	pod := &v1.Pod{}
+	if err := decodeFile(opts.podFile, pod); err != nil {
+		return fmt.Errorf("reading pod: %w", err)
+	}
+	args := &networkaware.NetworkAwareArgs{}
+	if opts.argsFile != "" {
+		if err := decodeFile(opts.argsFile, args); err != nil {
+			return fmt.Errorf("reading plugin args: %w", err)
+		}
+	}
+
+	nodes, err := loadNodes(opts)
+	if err != nil {
+		return err
+	}
+	topo, err := loadTopology(opts)
+	if err != nil {
+		return err
+	}
+
+	results, err := networkaware.Simulate(args, pod, nodes, topo)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+func loadNodes(opts options) ([]*v1.Node, error) {
+	list := &v1.NodeList{}
+	if opts.nodesFile != "" {
+		if err := decodeFile(opts.nodesFile, list); err != nil {
+			return nil, fmt.Errorf("reading nodes: %w", err)
+		}
+	} else {
+		client, err := liveClient(opts.kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		live, err := client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing nodes: %w", err)
+		}
+		list = live
+	}
+	nodes := make([]*v1.Node, 0, len(list.Items))
+	for i := range list.Items {
+		nodes = append(nodes, &list.Items[i])
+	}
+	return nodes, nil
+}
+
+func loadTopology(opts options) (*v1alpha1.NetworkTopology, error) {
+	if opts.topologyFile != "" {
+		topo := &v1alpha1.NetworkTopology{}
+		if err := decodeFile(opts.topologyFile, topo); err != nil {
+			return nil, fmt.Errorf("reading topology: %w", err)
+		}
+		return topo, nil
+	}
+	config, err := buildConfig(opts.kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := dyn.Resource(topologyGVR).Get(context.Background(), topologyName, metav1.GetOptions{})
+	if err != nil {
+		// Simulation without a topology still shows the label-fallback
+		// behavior, which is exactly what the scheduler would do.
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fetching topology: %w", err)
+	}
+	topo := &v1alpha1.NetworkTopology{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, topo); err != nil {
+		return nil, fmt.Errorf("converting NetworkTopology: %w", err)
+	}
+	return topo, nil
+}
+
+func decodeFile(path string, into interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, into)
+}
+
+func liveClient(kubeconfig string) (kubernetes.Interface, error) {
+	config, err := buildConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+func buildConfig(kubeconfig string) (*rest.Config, error) {
+	if kubeconfig != "" {
+		return clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+	return rest.InClusterConfig()
+}
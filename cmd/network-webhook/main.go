@@ -0,0 +1,88 @@
+// network-webhook is the admission webhook that injects network.komarov.dev
+// annotations (and optionally the scheduler name) into pods, based on a
+// ConfigMap of label-selector rules, and validates that referenced
+// NetworkClass objects exist and namespace NetworkQuota budgets are
+// respected.
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/webhook"
+)
+
+type options struct {
+	kubeconfig     string
+	addr           string
+	certFile       string
+	keyFile        string
+	rulesNamespace string
+	rulesName      string
+	reloadInterval time.Duration
+	injectAffinity bool
+}
+
+func main() {
+	opts := options{}
+	flag.StringVar(&opts.kubeconfig, "kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	flag.StringVar(&opts.addr, "addr", ":8443", "Address to serve the webhook on.")
+	flag.StringVar(&opts.certFile, "tls-cert-file", "/etc/webhook/certs/tls.crt", "TLS certificate file.")
+	flag.StringVar(&opts.keyFile, "tls-key-file", "/etc/webhook/certs/tls.key", "TLS private key file.")
+	flag.StringVar(&opts.rulesNamespace, "rules-namespace", "kube-system", "Namespace of the rules ConfigMap.")
+	flag.StringVar(&opts.rulesName, "rules-configmap", "network-webhook-rules", "Name of the rules ConfigMap.")
+	flag.DurationVar(&opts.reloadInterval, "rules-reload-interval", time.Minute, "How often to re-read the rules ConfigMap.")
+	flag.BoolVar(&opts.injectAffinity, "inject-affinity", false, "Translate network annotations into nodeAffinity for pods not using the network-aware scheduler.")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	config, err := buildConfig(opts.kubeconfig)
+	if err != nil {
+		klog.ErrorS(err, "Building client config")
+		os.Exit(1)
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		klog.ErrorS(err, "Building clientset")
+		os.Exit(1)
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		klog.ErrorS(err, "Building dynamic client")
+		os.Exit(1)
+	}
+
+	server, err := webhook.NewServer(client, opts.rulesNamespace, opts.rulesName)
+	if err != nil {
+		klog.ErrorS(err, "Loading webhook rules")
+		os.Exit(1)
+	}
+	server.WithClassValidation(dyn).WithQuotaValidation(dyn)
+	if opts.injectAffinity {
+		server.WithAffinityInjection(dyn)
+	}
+	go server.Run(context.Background(), opts.reloadInterval)
+
+	klog.InfoS("Serving mutating webhook", "addr", opts.addr)
+	httpServer := &http.Server{Addr: opts.addr, Handler: server.Handler()}
+	if err := httpServer.ListenAndServeTLS(opts.certFile, opts.keyFile); err != nil {
+		klog.ErrorS(err, "Webhook server failed")
+		os.Exit(1)
+	}
+}
+
+func buildConfig(kubeconfig string) (*rest.Config, error) {
+	if kubeconfig != "" {
+		return clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+	return rest.InClusterConfig()
+}
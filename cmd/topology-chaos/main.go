@@ -0,0 +1,138 @@
+// topology-chaos perturbs the live NetworkTopology object according to a
+// declarative scenario file, for validating that the scheduler reacts to
+// degradation without hand-editing the CRD. Originals are remembered and
+// restored when each step's window ends, and on SIGTERM/SIGINT. Perturbation
+// operates on the per-node maps shape; run the controller without
+// --write-links (or stopped) while a scenario is active.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+const topologyName = "cluster"
+
+var topologyGVR = v1alpha1.SchemeGroupVersion.WithResource("networktopologies")
+
+type options struct {
+	kubeconfig string
+	scenario   string
+	interval   time.Duration
+}
+
+func main() {
+	opts := options{}
+	flag.StringVar(&opts.kubeconfig, "kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	flag.StringVar(&opts.scenario, "scenario", "", "Scenario YAML file. Required.")
+	flag.DurationVar(&opts.interval, "interval", 5*time.Second, "Time between perturbation ticks.")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if opts.scenario == "" {
+		fmt.Fprintln(os.Stderr, "--scenario is required")
+		os.Exit(2)
+	}
+	sc, err := loadScenario(opts.scenario)
+	if err != nil {
+		klog.ErrorS(err, "Loading scenario")
+		os.Exit(1)
+	}
+	config, err := buildConfig(opts.kubeconfig)
+	if err != nil {
+		klog.ErrorS(err, "Building client config")
+		os.Exit(1)
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		klog.ErrorS(err, "Building dynamic client")
+		os.Exit(1)
+	}
+
+	if err := run(dyn, sc, opts.interval); err != nil {
+		klog.ErrorS(err, "Scenario failed")
+		os.Exit(1)
+	}
+}
+
+func run(dyn dynamic.Interface, sc *scenario, interval time.Duration) error {
+	ctx := context.Background()
+	e := newEngine(sc)
+	start := time.Now()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
+
+	klog.InfoS("Starting scenario", "steps", len(sc.Steps))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sigs:
+			klog.InfoS("Signal received, restoring originals")
+			return applyTick(ctx, dyn, e.restoreAll)
+		case <-ticker.C:
+			elapsed := time.Since(start)
+			if e.done(elapsed) {
+				klog.InfoS("Scenario complete, restoring originals")
+				return applyTick(ctx, dyn, e.restoreAll)
+			}
+			err := applyTick(ctx, dyn, func(topo *v1alpha1.NetworkTopology) bool {
+				return e.tick(topo, elapsed)
+			})
+			if err != nil {
+				klog.ErrorS(err, "Perturbation tick failed")
+			}
+		}
+	}
+}
+
+// applyTick fetches the live topology, lets mutate change it, and writes it
+// back only when something changed.
+func applyTick(ctx context.Context, dyn dynamic.Interface, mutate func(*v1alpha1.NetworkTopology) bool) error {
+	obj, err := dyn.Resource(topologyGVR).Get(ctx, topologyName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("fetching topology: %w", err)
+	}
+	topo := &v1alpha1.NetworkTopology{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, topo); err != nil {
+		return fmt.Errorf("converting NetworkTopology: %w", err)
+	}
+	if !mutate(topo) {
+		return nil
+	}
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(topo)
+	if err != nil {
+		return fmt.Errorf("converting topology: %w", err)
+	}
+	u := &unstructured.Unstructured{Object: raw}
+	u.SetAPIVersion(v1alpha1.SchemeGroupVersion.String())
+	u.SetKind("NetworkTopology")
+	u.SetResourceVersion(obj.GetResourceVersion())
+	if _, err := dyn.Resource(topologyGVR).Update(ctx, u, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("writing topology: %w", err)
+	}
+	return nil
+}
+
+func buildConfig(kubeconfig string) (*rest.Config, error) {
+	if kubeconfig != "" {
+		return clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+	return rest.InClusterConfig()
+}
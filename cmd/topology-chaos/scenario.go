@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// scenario is a declarative perturbation plan: each step sets link fields or
+// drops a node capability for a window relative to the scenario start.
+type scenario struct {
+	Steps []step `json:"steps"`
+}
+
+// step is one timed perturbation. Link steps set any of latency, bandwidth
+// and cost on source→target; node steps drop one capability. Overlapping
+// steps compose; when two active steps touch the same field the later one in
+// the file wins.
+type step struct {
+	// At is the offset from scenario start; Duration is how long the
+	// perturbation holds before the original value is restored.
+	At       metav1.Duration `json:"at"`
+	Duration metav1.Duration `json:"duration"`
+
+	// Link perturbation.
+	Source    string   `json:"source,omitempty"`
+	Target    string   `json:"target,omitempty"`
+	Latency   string   `json:"latency,omitempty"`
+	Bandwidth string   `json:"bandwidth,omitempty"`
+	Cost      *float64 `json:"cost,omitempty"`
+
+	// Node perturbation.
+	Node           string `json:"node,omitempty"`
+	DropCapability string `json:"dropCapability,omitempty"`
+}
+
+func (s step) activeAt(elapsed time.Duration) bool {
+	return elapsed >= s.At.Duration && elapsed < s.At.Duration+s.Duration.Duration
+}
+
+func (s step) end() time.Duration {
+	return s.At.Duration + s.Duration.Duration
+}
+
+func loadScenario(path string) (*scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sc := &scenario{}
+	if err := yaml.Unmarshal(data, sc); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if len(sc.Steps) == 0 {
+		return nil, fmt.Errorf("%s: scenario has no steps", path)
+	}
+	for i, st := range sc.Steps {
+		if err := validateStep(st); err != nil {
+			return nil, fmt.Errorf("%s: step %d: %w", path, i, err)
+		}
+	}
+	return sc, nil
+}
+
+func validateStep(st step) error {
+	if st.Duration.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	link := st.Source != "" || st.Target != ""
+	node := st.Node != "" || st.DropCapability != ""
+	switch {
+	case link && node:
+		return fmt.Errorf("a step perturbs either a link or a node, not both")
+	case link:
+		if st.Source == "" || st.Target == "" {
+			return fmt.Errorf("link steps need both source and target")
+		}
+		if st.Latency == "" && st.Bandwidth == "" && st.Cost == nil {
+			return fmt.Errorf("link steps need at least one of latency, bandwidth, cost")
+		}
+		if st.Latency != "" {
+			if _, err := units.ParseLatency(st.Latency); err != nil {
+				return fmt.Errorf("invalid latency: %w", err)
+			}
+		}
+		if st.Bandwidth != "" {
+			if _, err := units.ParseBandwidth(st.Bandwidth); err != nil {
+				return fmt.Errorf("invalid bandwidth: %w", err)
+			}
+		}
+	case node:
+		if st.Node == "" || st.DropCapability == "" {
+			return fmt.Errorf("node steps need both node and dropCapability")
+		}
+	default:
+		return fmt.Errorf("empty step")
+	}
+	return nil
+}
+
+// fieldRef addresses one perturbable field of the topology: a link metric in
+// the per-node maps, or a capability's presence on a node.
+type fieldRef struct {
+	kind   string // "latency", "bandwidth", "cost", "capability"
+	node   string
+	target string // link target, or the capability name
+}
+
+func (f fieldRef) key() string { return f.kind + "/" + f.node + "/" + f.target }
+
+// fieldValue is the remembered state of one field; present=false means the
+// field did not exist (no map entry, capability absent).
+type fieldValue struct {
+	str     string
+	num     float64
+	present bool
+}
+
+func (f fieldRef) read(topo *v1alpha1.NetworkTopology) fieldValue {
+	spec, ok := topo.Spec.Nodes[f.node]
+	if !ok {
+		return fieldValue{}
+	}
+	switch f.kind {
+	case "latency":
+		v, ok := spec.Latency[f.target]
+		return fieldValue{str: v, present: ok}
+	case "bandwidth":
+		v, ok := spec.Bandwidth[f.target]
+		return fieldValue{str: v, present: ok}
+	case "cost":
+		v, ok := spec.Cost[f.target]
+		return fieldValue{num: v, present: ok}
+	case "capability":
+		for _, c := range spec.Capabilities {
+			if c == f.target {
+				return fieldValue{present: true}
+			}
+		}
+	}
+	return fieldValue{}
+}
+
+func (f fieldRef) write(topo *v1alpha1.NetworkTopology, v fieldValue) {
+	spec, ok := topo.Spec.Nodes[f.node]
+	if !ok {
+		return
+	}
+	switch f.kind {
+	case "latency":
+		spec.Latency = setStringEntry(spec.Latency, f.target, v)
+	case "bandwidth":
+		spec.Bandwidth = setStringEntry(spec.Bandwidth, f.target, v)
+	case "cost":
+		if v.present {
+			if spec.Cost == nil {
+				spec.Cost = map[string]float64{}
+			}
+			spec.Cost[f.target] = v.num
+		} else {
+			delete(spec.Cost, f.target)
+		}
+	case "capability":
+		caps := spec.Capabilities[:0]
+		for _, c := range spec.Capabilities {
+			if c != f.target {
+				caps = append(caps, c)
+			}
+		}
+		if v.present {
+			caps = append(caps, f.target)
+		}
+		spec.Capabilities = caps
+	}
+	topo.Spec.Nodes[f.node] = spec
+}
+
+func setStringEntry(m map[string]string, key string, v fieldValue) map[string]string {
+	if !v.present {
+		delete(m, key)
+		return m
+	}
+	if m == nil {
+		m = map[string]string{}
+	}
+	m[key] = v.str
+	return m
+}
+
+// desired returns the fields this step perturbs with their target values.
+func (s step) desired() []perturbation {
+	var out []perturbation
+	if s.DropCapability != "" {
+		out = append(out, perturbation{
+			ref:   fieldRef{kind: "capability", node: s.Node, target: s.DropCapability},
+			value: fieldValue{present: false},
+		})
+		return out
+	}
+	if s.Latency != "" {
+		out = append(out, perturbation{
+			ref:   fieldRef{kind: "latency", node: s.Source, target: s.Target},
+			value: fieldValue{str: s.Latency, present: true},
+		})
+	}
+	if s.Bandwidth != "" {
+		out = append(out, perturbation{
+			ref:   fieldRef{kind: "bandwidth", node: s.Source, target: s.Target},
+			value: fieldValue{str: s.Bandwidth, present: true},
+		})
+	}
+	if s.Cost != nil {
+		out = append(out, perturbation{
+			ref:   fieldRef{kind: "cost", node: s.Source, target: s.Target},
+			value: fieldValue{num: *s.Cost, present: true},
+		})
+	}
+	return out
+}
+
+type perturbation struct {
+	ref   fieldRef
+	value fieldValue
+}
+
+// engine tracks which fields are currently perturbed and what their original
+// values were, so every perturbation can be undone exactly once.
+type engine struct {
+	steps     []step
+	originals map[string]remembered
+}
+
+type remembered struct {
+	ref   fieldRef
+	value fieldValue
+}
+
+func newEngine(sc *scenario) *engine {
+	return &engine{steps: sc.Steps, originals: map[string]remembered{}}
+}
+
+// done reports whether every step's window has passed.
+func (e *engine) done(elapsed time.Duration) bool {
+	for _, s := range e.steps {
+		if elapsed < s.end() {
+			return false
+		}
+	}
+	return true
+}
+
+// tick mutates topo to match the scenario at the given elapsed time: newly
+// active perturbations record the original value before overwriting it, and
+// fields whose steps have all expired are restored. Returns whether the
+// topology changed.
+func (e *engine) tick(topo *v1alpha1.NetworkTopology, elapsed time.Duration) bool {
+	active := map[string]perturbation{}
+	for _, s := range e.steps {
+		if !s.activeAt(elapsed) {
+			continue
+		}
+		for _, p := range s.desired() {
+			active[p.ref.key()] = p
+		}
+	}
+
+	changed := false
+	for key, p := range active {
+		current := p.ref.read(topo)
+		if _, ok := e.originals[key]; !ok {
+			e.originals[key] = remembered{ref: p.ref, value: current}
+		}
+		if current != p.value {
+			p.ref.write(topo, p.value)
+			changed = true
+		}
+	}
+	for key, orig := range e.originals {
+		if _, ok := active[key]; ok {
+			continue
+		}
+		if orig.ref.read(topo) != orig.value {
+			orig.ref.write(topo, orig.value)
+			changed = true
+		}
+		delete(e.originals, key)
+	}
+	return changed
+}
+
+// restoreAll undoes every remembered perturbation; used on shutdown.
+func (e *engine) restoreAll(topo *v1alpha1.NetworkTopology) bool {
+	changed := false
+	for key, orig := range e.originals {
+		if orig.ref.read(topo) != orig.value {
+			orig.ref.write(topo, orig.value)
+			changed = true
+		}
+		delete(e.originals, key)
+	}
+	return changed
+}
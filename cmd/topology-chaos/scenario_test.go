@@ -0,0 +1,147 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+)
+
+func seconds(s int) metav1.Duration {
+	return metav1.Duration{Duration: time.Duration(s) * time.Second}
+}
+
+func chaosTopology() *topologytesting.Builder {
+	return topologytesting.NewTopology().
+		WithLink("a", "b", "2ms", "900mbps").
+		WithCapabilities("c", "internet", "gateway")
+}
+
+func TestStepActivationWindow(t *testing.T) {
+	s := step{At: seconds(10), Duration: seconds(20)}
+	for elapsed, want := range map[time.Duration]bool{
+		0 * time.Second:  false,
+		10 * time.Second: true,
+		29 * time.Second: true,
+		30 * time.Second: false,
+	} {
+		if got := s.activeAt(elapsed); got != want {
+			t.Errorf("activeAt(%v) = %v, want %v", elapsed, got, want)
+		}
+	}
+}
+
+func TestTickAppliesAndRestoresLinkFields(t *testing.T) {
+	topo := chaosTopology().Build()
+	e := newEngine(&scenario{Steps: []step{{
+		At: seconds(0), Duration: seconds(60),
+		Source: "a", Target: "b", Latency: "200ms",
+	}}})
+
+	if !e.tick(topo, 0) {
+		t.Fatal("active step did not change the topology")
+	}
+	if got := topo.Spec.Nodes["a"].Latency["b"]; got != "200ms" {
+		t.Fatalf("latency = %q, want 200ms", got)
+	}
+	if e.tick(topo, 30*time.Second) {
+		t.Error("unchanged tick reported a change")
+	}
+	if !e.tick(topo, 90*time.Second) {
+		t.Fatal("expired step was not restored")
+	}
+	if got := topo.Spec.Nodes["a"].Latency["b"]; got != "2ms" {
+		t.Errorf("latency after restore = %q, want 2ms", got)
+	}
+}
+
+func TestTickDropsAndRestoresCapability(t *testing.T) {
+	topo := chaosTopology().Build()
+	e := newEngine(&scenario{Steps: []step{{
+		At: seconds(0), Duration: seconds(60),
+		Node: "c", DropCapability: "internet",
+	}}})
+
+	e.tick(topo, 0)
+	if caps := topo.Spec.Nodes["c"].Capabilities; len(caps) != 1 || caps[0] != "gateway" {
+		t.Fatalf("capabilities during step = %v, want [gateway]", caps)
+	}
+	e.tick(topo, 90*time.Second)
+	if caps := topo.Spec.Nodes["c"].Capabilities; len(caps) != 2 {
+		t.Errorf("capabilities after restore = %v, want both back", caps)
+	}
+}
+
+func TestOverlappingStepsComposeAndLaterWins(t *testing.T) {
+	topo := chaosTopology().Build()
+	e := newEngine(&scenario{Steps: []step{
+		{At: seconds(0), Duration: seconds(120), Source: "a", Target: "b", Latency: "100ms"},
+		{At: seconds(30), Duration: seconds(30), Source: "a", Target: "b", Latency: "500ms", Bandwidth: "10mbps"},
+	}})
+
+	e.tick(topo, 40*time.Second)
+	if got := topo.Spec.Nodes["a"].Latency["b"]; got != "500ms" {
+		t.Errorf("overlapping latency = %q, want the later step's 500ms", got)
+	}
+	if got := topo.Spec.Nodes["a"].Bandwidth["b"]; got != "10mbps" {
+		t.Errorf("bandwidth = %q, want 10mbps", got)
+	}
+
+	// Second step expires: the first step's value returns, and bandwidth
+	// restores to the original.
+	e.tick(topo, 70*time.Second)
+	if got := topo.Spec.Nodes["a"].Latency["b"]; got != "100ms" {
+		t.Errorf("latency after overlap = %q, want 100ms", got)
+	}
+	if got := topo.Spec.Nodes["a"].Bandwidth["b"]; got != "900mbps" {
+		t.Errorf("bandwidth after overlap = %q, want 900mbps", got)
+	}
+
+	e.tick(topo, 200*time.Second)
+	if got := topo.Spec.Nodes["a"].Latency["b"]; got != "2ms" {
+		t.Errorf("latency after scenario = %q, want 2ms", got)
+	}
+}
+
+func TestRestoreAll(t *testing.T) {
+	topo := chaosTopology().Build()
+	e := newEngine(&scenario{Steps: []step{
+		{At: seconds(0), Duration: seconds(600), Source: "a", Target: "b", Latency: "100ms"},
+		{At: seconds(0), Duration: seconds(600), Node: "c", DropCapability: "internet"},
+	}})
+	e.tick(topo, 0)
+	if !e.restoreAll(topo) {
+		t.Fatal("restoreAll found nothing to undo")
+	}
+	if got := topo.Spec.Nodes["a"].Latency["b"]; got != "2ms" {
+		t.Errorf("latency = %q, want 2ms", got)
+	}
+	if caps := topo.Spec.Nodes["c"].Capabilities; len(caps) != 2 {
+		t.Errorf("capabilities = %v, want both back", caps)
+	}
+	if e.restoreAll(topo) {
+		t.Error("second restoreAll claimed to change something")
+	}
+}
+
+func TestValidateStepRejectsMalformed(t *testing.T) {
+	for name, st := range map[string]step{
+		"empty":              {Duration: seconds(10)},
+		"no duration":        {Source: "a", Target: "b", Latency: "5ms"},
+		"link and node":      {Duration: seconds(10), Source: "a", Target: "b", Latency: "5ms", Node: "c", DropCapability: "x"},
+		"missing target":     {Duration: seconds(10), Source: "a", Latency: "5ms"},
+		"no link fields":     {Duration: seconds(10), Source: "a", Target: "b"},
+		"bad latency":        {Duration: seconds(10), Source: "a", Target: "b", Latency: "fast"},
+		"missing capability": {Duration: seconds(10), Node: "c"},
+	} {
+		if err := validateStep(st); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+	cost := 0.9
+	if err := validateStep(step{Duration: seconds(10), Source: "a", Target: "b", Cost: &cost}); err != nil {
+		t.Errorf("valid step rejected: %v", err)
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// Address-selection classes. A policy is an ordered preference list of
+// these; the first class the node actually has an address for wins.
+const (
+	addrClassTailscale  = "tailscale"
+	addrClassInternalV4 = "internal-v4"
+	addrClassInternalV6 = "internal-v6"
+	addrClassExternal   = "external"
+)
+
+// Pseudo-classes reported when an annotation pins the selection directly
+// instead of going through a policy.
+const (
+	addrClassPinned    = "pinned"
+	addrClassInterface = "interface"
+)
+
+// Event reasons emitted when a probe-pinning annotation cannot be honored.
+// Failing loudly beats silently probing the wrong path: the whole point of
+// the annotations is that the default route is not the CNI path.
+const (
+	eventProbeAddressNotHeld    = "ProbeAddressNotHeld"
+	eventProbeInterfaceUnusable = "ProbeInterfaceUnusable"
+)
+
+// k3sInternalIPAnnotation carries the full comma-separated --node-ip list on
+// k3s nodes; Status.Addresses only surfaces the first address per family.
+const k3sInternalIPAnnotation = "k3s.io/internal-ip"
+
+// defaultAddressPolicy preserves the historical selection: the resolver's
+// tailnet address when the node is a known peer, otherwise the node's
+// InternalIP with IPv4 preferred.
+const defaultAddressPolicy = "tailscale,internal-v4,internal-v6"
+
+var defaultAddressClasses = []string{addrClassTailscale, addrClassInternalV4, addrClassInternalV6}
+
+// addressFailoverThreshold is how many consecutive unreachable probe
+// results the current address survives before the node rotates to its
+// next candidate.
+const addressFailoverThreshold = 3
+
+// candidateAddress is one usable probe address of a node.
+type candidateAddress struct {
+	class  string
+	addr   string
+	family string // "ipv4" or "ipv6", empty for non-IP addresses
+}
+
+// parseAddressPolicy validates a comma-separated class list.
+func parseAddressPolicy(s string) ([]string, error) {
+	var policy []string
+	for _, class := range strings.Split(s, ",") {
+		class = strings.TrimSpace(class)
+		switch class {
+		case addrClassTailscale, addrClassInternalV4, addrClassInternalV6, addrClassExternal:
+			policy = append(policy, class)
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown address class %q (known: %s, %s, %s, %s)",
+				class, addrClassTailscale, addrClassInternalV4, addrClassInternalV6, addrClassExternal)
+		}
+	}
+	if len(policy) == 0 {
+		return nil, fmt.Errorf("address policy %q lists no classes", s)
+	}
+	return policy, nil
+}
+
+// addressFamily classifies an IP string; non-IP strings (a resolver may
+// hand back a hostname) report no family.
+func addressFamily(addr string) string {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return ""
+	}
+	if ip.To4() != nil {
+		return "ipv4"
+	}
+	return "ipv6"
+}
+
+// candidateAddresses lists the node's usable probe addresses in policy
+// order: tailscale from the resolver, internal-v4/v6 as the first
+// InternalIP of that family, external as the first ExternalIP regardless
+// of family.
+func candidateAddresses(node *v1.Node, peerIPs map[string]string, policy []string) []candidateAddress {
+	var candidates []candidateAddress
+	for _, class := range policy {
+		switch class {
+		case addrClassTailscale:
+			if ip, ok := peerIPs[node.Name]; ok {
+				candidates = append(candidates, candidateAddress{class: class, addr: ip, family: addressFamily(ip)})
+			}
+		case addrClassInternalV4, addrClassInternalV6:
+			want := "ipv4"
+			if class == addrClassInternalV6 {
+				want = "ipv6"
+			}
+			for _, addr := range node.Status.Addresses {
+				if addr.Type == v1.NodeInternalIP && addressFamily(addr.Address) == want {
+					candidates = append(candidates, candidateAddress{class: class, addr: addr.Address, family: want})
+					break
+				}
+			}
+		case addrClassExternal:
+			for _, addr := range node.Status.Addresses {
+				if addr.Type == v1.NodeExternalIP {
+					candidates = append(candidates, candidateAddress{class: class, addr: addr.Address, family: addressFamily(addr.Address)})
+					break
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// heldAddresses is every address the node is known to hold: its status
+// addresses, the full --node-ip list from the k3s annotation, and its
+// tailnet address.
+func heldAddresses(node *v1.Node, peerIPs map[string]string) []string {
+	var held []string
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == v1.NodeInternalIP || addr.Type == v1.NodeExternalIP {
+			held = append(held, addr.Address)
+		}
+	}
+	for _, addr := range strings.Split(node.Annotations[k3sInternalIPAnnotation], ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			held = append(held, addr)
+		}
+	}
+	if ip, ok := peerIPs[node.Name]; ok {
+		held = append(held, ip)
+	}
+	return held
+}
+
+func holdsAddress(node *v1.Node, peerIPs map[string]string, addr string) bool {
+	for _, held := range heldAddresses(node, peerIPs) {
+		if held == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// interfaceNetworks returns the subnets configured on the named local
+// interface. Interface names are host-scoped, so the annotation resolves
+// from the controller's vantage; nodes on a shared L2 segment (the typical
+// flannel --iface setup) hold addresses inside the same subnets.
+func interfaceNetworks(name string) ([]*net.IPNet, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	var nets []*net.IPNet
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			nets = append(nets, ipNet)
+		}
+	}
+	if len(nets) == 0 {
+		return nil, fmt.Errorf("interface %q has no addresses", name)
+	}
+	return nets, nil
+}
+
+// addressOnNetworks picks the first held address inside any of the subnets.
+func addressOnNetworks(held []string, nets []*net.IPNet) (string, bool) {
+	for _, addr := range held {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			continue
+		}
+		for _, ipNet := range nets {
+			if ipNet.Contains(ip) {
+				return addr, true
+			}
+		}
+	}
+	return "", false
+}
+
+// interfaceAddress resolves the probe-interface annotation to the node's
+// held address on that interface's subnets.
+func (c *controller) interfaceAddress(node *v1.Node, peerIPs map[string]string, name string) (string, error) {
+	nets, err := interfaceNetworks(name)
+	if err != nil {
+		return "", err
+	}
+	addr, ok := addressOnNetworks(heldAddresses(node, peerIPs), nets)
+	if !ok {
+		return "", fmt.Errorf("node holds no address on interface %s's subnets", name)
+	}
+	return addr, nil
+}
+
+// noteInterface records (or clears) which interface a node's probe address
+// was selected for, surfaced per link on the written topology.
+func (c *controller) noteInterface(node, iface string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if iface == "" {
+		delete(c.addrIface, node)
+		return
+	}
+	if c.addrIface == nil {
+		c.addrIface = map[string]string{}
+	}
+	c.addrIface[node] = iface
+}
+
+// selectAddress picks the node's probe address for this cycle. The
+// probe-address and probe-interface annotations pin the selection outright —
+// they exist for multi-NIC nodes where no policy class names the CNI path —
+// with a warning Event when they cannot be honored. Otherwise the per-node
+// policy annotation or the global policy applies, skipping candidates the
+// failover state has rotated past. Rotation wraps back to the preferred
+// class: an address that failed an hour ago deserves another chance before
+// the node is written off.
+func (c *controller) selectAddress(node *v1.Node, peerIPs map[string]string) candidateAddress {
+	if pinned := node.Annotations[requirements.AnnotationProbeAddress]; pinned != "" {
+		if holdsAddress(node, peerIPs, pinned) {
+			c.noteInterface(node.Name, "")
+			return candidateAddress{class: addrClassPinned, addr: pinned, family: addressFamily(pinned)}
+		}
+		klog.ErrorS(nil, "Node does not hold its probe-address annotation; falling back to the address policy",
+			"node", node.Name, "address", pinned)
+		if c.recorder != nil {
+			c.recorder.Eventf(node, v1.EventTypeWarning, eventProbeAddressNotHeld,
+				"probe-address %q is not an address this node holds; probing via the address policy instead", pinned)
+		}
+	}
+	if ifaceName := node.Annotations[requirements.AnnotationProbeInterface]; ifaceName != "" {
+		addr, err := c.interfaceAddress(node, peerIPs, ifaceName)
+		if err == nil {
+			c.noteInterface(node.Name, ifaceName)
+			return candidateAddress{class: addrClassInterface, addr: addr, family: addressFamily(addr)}
+		}
+		klog.ErrorS(err, "Ignoring probe-interface annotation", "node", node.Name, "interface", ifaceName)
+		if c.recorder != nil {
+			c.recorder.Eventf(node, v1.EventTypeWarning, eventProbeInterfaceUnusable,
+				"probe-interface %q: %v; probing via the address policy instead", ifaceName, err)
+		}
+	}
+	c.noteInterface(node.Name, "")
+
+	policy := c.addrPolicy
+	if len(policy) == 0 {
+		policy = defaultAddressClasses
+	}
+	if override, ok := node.Annotations[requirements.AnnotationAddressPolicy]; ok {
+		parsed, err := parseAddressPolicy(override)
+		if err != nil {
+			klog.ErrorS(err, "Ignoring invalid address-policy annotation", "node", node.Name)
+		} else {
+			policy = parsed
+		}
+	}
+	candidates := candidateAddresses(node, peerIPs, policy)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.addrCandidates == nil {
+		c.addrCandidates = map[string]int{}
+	}
+	c.addrCandidates[node.Name] = len(candidates)
+	if len(candidates) == 0 {
+		return candidateAddress{}
+	}
+	return candidates[c.addrSkip[node.Name]%len(candidates)]
+}
+
+// recordAddressOutcome folds one probe result into the failover state:
+// success pins the current address, while addressFailoverThreshold
+// consecutive unreachable results rotate the node to its next candidate
+// starting with the following cycle. Nodes offering a single usable
+// address have nowhere to fail over to and keep it quietly.
+func (c *controller) recordAddressOutcome(node string, reachable bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if reachable {
+		delete(c.addrFailures, node)
+		return
+	}
+	if c.addrCandidates[node] <= 1 {
+		return
+	}
+	if c.addrFailures == nil {
+		c.addrFailures = map[string]int{}
+	}
+	c.addrFailures[node]++
+	if c.addrFailures[node] < addressFailoverThreshold {
+		return
+	}
+	delete(c.addrFailures, node)
+	if c.addrSkip == nil {
+		c.addrSkip = map[string]int{}
+	}
+	c.addrSkip[node]++
+	klog.InfoS("Probe address failed repeatedly, switching to the next address class",
+		"node", node, "consecutiveFailures", addressFailoverThreshold)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// addressedNode builds a node whose Status.Addresses hold the given
+// internal and external addresses, in that order.
+func addressedNode(name string, internal, external []string) *v1.Node {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	for _, addr := range internal {
+		node.Status.Addresses = append(node.Status.Addresses, v1.NodeAddress{Type: v1.NodeInternalIP, Address: addr})
+	}
+	for _, addr := range external {
+		node.Status.Addresses = append(node.Status.Addresses, v1.NodeAddress{Type: v1.NodeExternalIP, Address: addr})
+	}
+	return node
+}
+
+func TestParseAddressPolicy(t *testing.T) {
+	policy, err := parseAddressPolicy("tailscale, internal-v4,external")
+	if err != nil {
+		t.Fatalf("parseAddressPolicy: %v", err)
+	}
+	if len(policy) != 3 || policy[0] != addrClassTailscale || policy[2] != addrClassExternal {
+		t.Errorf("policy = %v, want [tailscale internal-v4 external]", policy)
+	}
+	for _, bad := range []string{"internal", "tailscale,derp", "", ","} {
+		if _, err := parseAddressPolicy(bad); err == nil {
+			t.Errorf("parseAddressPolicy(%q) accepted an invalid policy", bad)
+		}
+	}
+}
+
+func TestAddressFamily(t *testing.T) {
+	cases := map[string]string{
+		"10.0.0.1":     "ipv4",
+		"fd7a::12":     "ipv6",
+		"node-a.local": "",
+	}
+	for addr, want := range cases {
+		if got := addressFamily(addr); got != want {
+			t.Errorf("addressFamily(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
+
+// TestSelectAddressCombinations walks every combination of address
+// presence against the full preference order.
+func TestSelectAddressCombinations(t *testing.T) {
+	c := &controller{addrPolicy: []string{addrClassTailscale, addrClassInternalV4, addrClassInternalV6, addrClassExternal}}
+	peerIPs := map[string]string{"peered": "100.64.0.7"}
+	cases := []struct {
+		name       string
+		node       *v1.Node
+		wantClass  string
+		wantAddr   string
+		wantFamily string
+	}{
+		{
+			name:      "tailscale wins over everything",
+			node:      addressedNode("peered", []string{"10.0.0.1", "fd7a::1"}, []string{"203.0.113.5"}),
+			wantClass: addrClassTailscale, wantAddr: "100.64.0.7", wantFamily: "ipv4",
+		},
+		{
+			name:      "dual-stack internal prefers v4",
+			node:      addressedNode("dual", []string{"fd7a::1", "10.0.0.2"}, nil),
+			wantClass: addrClassInternalV4, wantAddr: "10.0.0.2", wantFamily: "ipv4",
+		},
+		{
+			name:      "v6-only internal",
+			node:      addressedNode("v6only", []string{"fd7a::2"}, nil),
+			wantClass: addrClassInternalV6, wantAddr: "fd7a::2", wantFamily: "ipv6",
+		},
+		{
+			name:      "v4-only internal",
+			node:      addressedNode("v4only", []string{"10.0.0.3"}, nil),
+			wantClass: addrClassInternalV4, wantAddr: "10.0.0.3", wantFamily: "ipv4",
+		},
+		{
+			name:      "external only",
+			node:      addressedNode("ext", nil, []string{"203.0.113.9"}),
+			wantClass: addrClassExternal, wantAddr: "203.0.113.9", wantFamily: "ipv4",
+		},
+		{
+			name:      "external v6",
+			node:      addressedNode("ext6", nil, []string{"2001:db8::9"}),
+			wantClass: addrClassExternal, wantAddr: "2001:db8::9", wantFamily: "ipv6",
+		},
+		{
+			name: "no addresses at all",
+			node: addressedNode("bare", nil, nil),
+		},
+	}
+	for _, tc := range cases {
+		got := c.selectAddress(tc.node, peerIPs)
+		if got.class != tc.wantClass || got.addr != tc.wantAddr || got.family != tc.wantFamily {
+			t.Errorf("%s: selectAddress = %+v, want class %q addr %q family %q",
+				tc.name, got, tc.wantClass, tc.wantAddr, tc.wantFamily)
+		}
+	}
+}
+
+func TestSelectAddressAnnotationOverride(t *testing.T) {
+	c := &controller{addrPolicy: []string{addrClassTailscale, addrClassInternalV4}}
+	node := addressedNode("dual", []string{"10.0.0.1"}, []string{"203.0.113.5"})
+	node.Annotations = map[string]string{requirements.AnnotationAddressPolicy: "external"}
+	if got := c.selectAddress(node, nil); got.class != addrClassExternal || got.addr != "203.0.113.5" {
+		t.Errorf("annotation override ignored, got %+v", got)
+	}
+
+	// An unparseable annotation falls back to the global policy.
+	node.Annotations[requirements.AnnotationAddressPolicy] = "carrier-pigeon"
+	if got := c.selectAddress(node, nil); got.class != addrClassInternalV4 {
+		t.Errorf("invalid annotation must fall back to the global policy, got %+v", got)
+	}
+}
+
+func TestAddressFailoverRotates(t *testing.T) {
+	c := &controller{addrPolicy: []string{addrClassInternalV4, addrClassExternal}}
+	node := addressedNode("flaky", []string{"10.0.0.1"}, []string{"203.0.113.5"})
+
+	if got := c.selectAddress(node, nil); got.class != addrClassInternalV4 {
+		t.Fatalf("initial selection = %+v, want internal-v4", got)
+	}
+	// Failures below the threshold keep the current address.
+	for i := 0; i < addressFailoverThreshold-1; i++ {
+		c.recordAddressOutcome("flaky", false)
+	}
+	if got := c.selectAddress(node, nil); got.class != addrClassInternalV4 {
+		t.Fatalf("selection rotated before the threshold, got %+v", got)
+	}
+	// The threshold failure switches to the next candidate.
+	c.recordAddressOutcome("flaky", false)
+	if got := c.selectAddress(node, nil); got.class != addrClassExternal {
+		t.Fatalf("selection did not rotate at the threshold, got %+v", got)
+	}
+	// Success pins it there; a single later failure does not rotate again.
+	c.recordAddressOutcome("flaky", true)
+	c.recordAddressOutcome("flaky", false)
+	if got := c.selectAddress(node, nil); got.class != addrClassExternal {
+		t.Fatalf("success did not reset the failure count, got %+v", got)
+	}
+	// Exhausting the second candidate wraps back to the preferred one.
+	for i := 0; i < addressFailoverThreshold; i++ {
+		c.recordAddressOutcome("flaky", false)
+	}
+	if got := c.selectAddress(node, nil); got.class != addrClassInternalV4 {
+		t.Fatalf("rotation did not wrap to the preferred class, got %+v", got)
+	}
+}
+
+func TestAddressFailoverSingleCandidateStays(t *testing.T) {
+	c := &controller{addrPolicy: []string{addrClassInternalV4}}
+	node := addressedNode("lonely", []string{"10.0.0.1"}, nil)
+	c.selectAddress(node, nil)
+	for i := 0; i < addressFailoverThreshold*2; i++ {
+		c.recordAddressOutcome("lonely", false)
+	}
+	if got := c.selectAddress(node, nil); got.addr != "10.0.0.1" {
+		t.Fatalf("single-candidate node lost its address, got %+v", got)
+	}
+}
+
+func TestProbeAddressAnnotationPinsSelection(t *testing.T) {
+	c := &controller{addrPolicy: []string{addrClassTailscale, addrClassInternalV4}}
+	node := addressedNode("multi", []string{"192.168.1.10"}, nil)
+	node.Annotations = map[string]string{
+		// Both the global policy and the per-node policy annotation would
+		// pick the management NIC; the pinned address must win over both.
+		requirements.AnnotationAddressPolicy: "internal-v4",
+		requirements.AnnotationProbeAddress:  "10.42.0.10",
+		k3sInternalIPAnnotation:              "192.168.1.10,10.42.0.10",
+	}
+	got := c.selectAddress(node, map[string]string{"multi": "100.64.0.9"})
+	if got.class != addrClassPinned || got.addr != "10.42.0.10" {
+		t.Errorf("selectAddress = %+v, want pinned 10.42.0.10", got)
+	}
+}
+
+func TestProbeAddressNotHeldFailsLoudly(t *testing.T) {
+	recorder := record.NewFakeRecorder(4)
+	c := &controller{addrPolicy: []string{addrClassInternalV4}, recorder: recorder}
+	node := addressedNode("multi", []string{"192.168.1.10"}, nil)
+	node.Annotations = map[string]string{requirements.AnnotationProbeAddress: "10.99.0.1"}
+	got := c.selectAddress(node, nil)
+	if got.class != addrClassInternalV4 || got.addr != "192.168.1.10" {
+		t.Fatalf("unheld pin must fall back to the policy, got %+v", got)
+	}
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, eventProbeAddressNotHeld) {
+			t.Errorf("event = %q, want reason %s", event, eventProbeAddressNotHeld)
+		}
+	default:
+		t.Error("no Event emitted for an unheld probe-address")
+	}
+}
+
+func TestHeldAddressesIncludesK3sNodeIPList(t *testing.T) {
+	node := addressedNode("multi", []string{"192.168.1.10"}, []string{"203.0.113.5"})
+	node.Annotations = map[string]string{k3sInternalIPAnnotation: "192.168.1.10, 10.42.0.10"}
+	held := heldAddresses(node, map[string]string{"multi": "100.64.0.9"})
+	want := map[string]bool{"192.168.1.10": true, "203.0.113.5": true, "10.42.0.10": true, "100.64.0.9": true}
+	for _, addr := range held {
+		delete(want, addr)
+	}
+	if len(want) != 0 {
+		t.Errorf("heldAddresses missed %v (got %v)", want, held)
+	}
+	if holdsAddress(node, nil, "10.99.0.1") {
+		t.Error("holdsAddress accepted an address the node does not hold")
+	}
+}
+
+func TestAddressOnNetworks(t *testing.T) {
+	_, fast, _ := net.ParseCIDR("10.42.0.0/24")
+	held := []string{"192.168.1.10", "not-an-ip", "10.42.0.10"}
+	if addr, ok := addressOnNetworks(held, []*net.IPNet{fast}); !ok || addr != "10.42.0.10" {
+		t.Errorf("addressOnNetworks = %q, %v; want the fast-NIC address", addr, ok)
+	}
+	if _, ok := addressOnNetworks([]string{"192.168.1.10"}, []*net.IPNet{fast}); ok {
+		t.Error("addressOnNetworks matched an address outside the subnets")
+	}
+}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// Metrics an alert rule can evaluate. Latency and bandwidth compare in
+// milliseconds and mbps; loss and health are plain fractions in [0,1].
+const (
+	alertMetricLatency   = "latency"
+	alertMetricBandwidth = "bandwidth"
+	alertMetricLoss      = "loss"
+	alertMetricHealth    = "health"
+)
+
+// Event reasons emitted on alert transitions.
+const (
+	eventAlertFiring   = "NetworkAlertFiring"
+	eventAlertResolved = "NetworkAlertResolved"
+)
+
+// alertRulesKey is the ConfigMap data key holding the YAML rule list.
+const alertRulesKey = "rules"
+
+// alertWebhookTimeout bounds one notification call; a slow receiver must
+// not stall measurement cycles.
+const alertWebhookTimeout = 5 * time.Second
+
+// alertSelector scopes a rule to one link ("source:target"), every link
+// sourced at one node, or every link sourced in one zone. For the health
+// metric a node selector picks that node's health score and an empty
+// selector the cluster-wide one.
+type alertSelector struct {
+	Link string `json:"link,omitempty"`
+	Node string `json:"node,omitempty"`
+	Zone string `json:"zone,omitempty"`
+}
+
+// alertRule is one entry of the rules ConfigMap, e.g.
+//
+//   - name: WanLatencyHigh
+//     selector: {link: "pi-1:hetzner-1"}
+//     metric: latency
+//     op: ">"
+//     threshold: 150ms
+//     for: 10m
+type alertRule struct {
+	Name      string          `json:"name"`
+	Selector  alertSelector   `json:"selector"`
+	Metric    string          `json:"metric"`
+	Op        string          `json:"op"`
+	Threshold string          `json:"threshold"`
+	For       metav1.Duration `json:"for"`
+}
+
+// parseAlertRules decodes and validates the YAML rule list. A malformed
+// ConfigMap rejects wholesale rather than evaluating half the rules: a rule
+// silently dropped is an alert that never fires.
+func parseAlertRules(data string) ([]alertRule, error) {
+	var rules []alertRule
+	if err := yaml.UnmarshalStrict([]byte(data), &rules); err != nil {
+		return nil, fmt.Errorf("decoding alert rules: %w", err)
+	}
+	seen := map[string]bool{}
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule %d has no name", i)
+		}
+		if seen[rule.Name] {
+			return nil, fmt.Errorf("duplicate rule name %q", rule.Name)
+		}
+		seen[rule.Name] = true
+		selectors := 0
+		for _, s := range []string{rule.Selector.Link, rule.Selector.Node, rule.Selector.Zone} {
+			if s != "" {
+				selectors++
+			}
+		}
+		if selectors > 1 {
+			return nil, fmt.Errorf("rule %q sets more than one selector", rule.Name)
+		}
+		if selectors == 0 && rule.Metric != alertMetricHealth {
+			return nil, fmt.Errorf("rule %q needs a link, node or zone selector", rule.Name)
+		}
+		if rule.Selector.Link != "" && len(strings.SplitN(rule.Selector.Link, ":", 2)) != 2 {
+			return nil, fmt.Errorf("rule %q link selector must be \"source:target\"", rule.Name)
+		}
+		switch rule.Op {
+		case ">", ">=", "<", "<=":
+		default:
+			return nil, fmt.Errorf("rule %q has unknown operator %q", rule.Name, rule.Op)
+		}
+		if _, err := parseAlertThreshold(rule.Metric, rule.Threshold); err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		if rule.For.Duration < 0 {
+			return nil, fmt.Errorf("rule %q has a negative for duration", rule.Name)
+		}
+	}
+	return rules, nil
+}
+
+// parseAlertThreshold converts a rule threshold into the metric's comparison
+// unit: milliseconds for latency, mbps for bandwidth, a plain fraction for
+// loss and health.
+func parseAlertThreshold(metric, threshold string) (float64, error) {
+	switch metric {
+	case alertMetricLatency:
+		d, err := units.ParseLatency(threshold)
+		if err != nil {
+			return 0, err
+		}
+		return float64(d) / float64(time.Millisecond), nil
+	case alertMetricBandwidth:
+		bps, err := units.ParseBandwidth(threshold)
+		if err != nil {
+			return 0, err
+		}
+		return float64(bps) / float64(units.Mbps), nil
+	case alertMetricLoss, alertMetricHealth:
+		value, err := strconv.ParseFloat(threshold, 64)
+		if err != nil || value < 0 || value > 1 {
+			return 0, fmt.Errorf("%s threshold %q is not a fraction in [0,1]", metric, threshold)
+		}
+		return value, nil
+	default:
+		return 0, fmt.Errorf("unknown metric %q (known: %s, %s, %s, %s)",
+			metric, alertMetricLatency, alertMetricBandwidth, alertMetricLoss, alertMetricHealth)
+	}
+}
+
+func alertBreached(rule alertRule, value float64) bool {
+	threshold, err := parseAlertThreshold(rule.Metric, rule.Threshold)
+	if err != nil {
+		return false
+	}
+	switch rule.Op {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	}
+	return false
+}
+
+// alertState tracks one rule/subject pair across evaluations.
+type alertState struct {
+	// pendingSince is when the condition first breached continuously;
+	// zero while within bounds.
+	pendingSince time.Time
+	firing       bool
+	value        float64
+}
+
+// alertEngine holds the firing state between cycles. Rules themselves are
+// re-read every evaluation, so edits to the ConfigMap apply on the next
+// cycle without a restart.
+type alertEngine struct {
+	mu     sync.Mutex
+	states map[string]*alertState
+}
+
+func alertStateKey(rule, subject string) string { return rule + "|" + subject }
+
+// observe folds one evaluation into the rule/subject state and reports
+// whether it is firing now and whether this observation transitioned it.
+// A breach must hold continuously for the rule's for duration before it
+// fires; recovery resolves immediately.
+func (e *alertEngine) observe(rule alertRule, subject string, value float64, breached bool, at time.Time) (firing, transitioned bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.states == nil {
+		e.states = map[string]*alertState{}
+	}
+	key := alertStateKey(rule.Name, subject)
+	state := e.states[key]
+	if state == nil {
+		state = &alertState{}
+		e.states[key] = state
+	}
+	state.value = value
+	if !breached {
+		state.pendingSince = time.Time{}
+		if state.firing {
+			state.firing = false
+			return false, true
+		}
+		return false, false
+	}
+	if state.firing {
+		return true, false
+	}
+	if state.pendingSince.IsZero() {
+		state.pendingSince = at
+	}
+	if at.Sub(state.pendingSince) >= rule.For.Duration {
+		state.firing = true
+		return true, true
+	}
+	return false, false
+}
+
+// prune drops state for rule/subject pairs the current rules no longer
+// produce, so a deleted rule's memory does not leak or resurrect.
+func (e *alertEngine) prune(seen map[string]bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for key := range e.states {
+		if !seen[key] {
+			delete(e.states, key)
+		}
+	}
+}
+
+// alertSamples collects the (subject, value) pairs one rule evaluates from
+// the cycle's assembled topology — the smoothed values about to be written.
+// Subjects are "source:target" for link metrics, node names for per-node
+// health, and "cluster" for the cluster health score.
+func alertSamples(rule alertRule, topo *v1alpha1.NetworkTopology, ix *topology.Index) map[string]float64 {
+	samples := map[string]float64{}
+	if rule.Metric == alertMetricHealth {
+		if rule.Selector.Node != "" {
+			if spec, ok := ix.Node(rule.Selector.Node); ok && spec.HealthScore != nil {
+				samples[rule.Selector.Node] = *spec.HealthScore
+			}
+			return samples
+		}
+		samples["cluster"] = topo.Status.HealthScore
+		return samples
+	}
+	for source, spec := range topo.Spec.Nodes {
+		if rule.Selector.Node != "" && source != rule.Selector.Node {
+			continue
+		}
+		if rule.Selector.Zone != "" && spec.Zone != rule.Selector.Zone {
+			continue
+		}
+		for target := range spec.Latency {
+			link := source + ":" + target
+			if rule.Selector.Link != "" && link != rule.Selector.Link {
+				continue
+			}
+			if value, ok := alertLinkValue(rule.Metric, source, target, ix); ok {
+				samples[link] = value
+			}
+		}
+	}
+	return samples
+}
+
+func alertLinkValue(metric, source, target string, ix *topology.Index) (float64, bool) {
+	spec, ok := ix.Node(source)
+	if !ok {
+		return 0, false
+	}
+	switch metric {
+	case alertMetricLatency:
+		d, err := units.ParseLatency(spec.Latency[target])
+		if err != nil {
+			return 0, false
+		}
+		return float64(d) / float64(time.Millisecond), true
+	case alertMetricBandwidth:
+		bps, err := units.ParseBandwidth(spec.Bandwidth[target])
+		if err != nil {
+			return 0, false
+		}
+		return float64(bps) / float64(units.Mbps), true
+	case alertMetricLoss:
+		// Loss only exists on explicit links; topologies this controller
+		// measures itself carry none, but imported ones may.
+		link, ok := ix.Link(source, target)
+		if !ok {
+			return 0, false
+		}
+		return link.Loss, true
+	}
+	return 0, false
+}
+
+// fetchAlertRules re-reads the rules ConfigMap; called every evaluation so
+// edits hot-reload. Fetch or parse failures keep the previous cycle's state
+// untouched and never fail the cycle.
+func (c *controller) fetchAlertRules(ctx context.Context) []alertRule {
+	namespace := os.Getenv("POD_NAMESPACE")
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	cm, err := c.client.CoreV1().ConfigMaps(namespace).Get(ctx, c.opts.alertRules, metav1.GetOptions{})
+	if err != nil {
+		klog.V(2).InfoS("Not evaluating alert rules", "configMap", c.opts.alertRules, "err", err)
+		return nil
+	}
+	rules, err := parseAlertRules(cm.Data[alertRulesKey])
+	if err != nil {
+		klog.ErrorS(err, "Ignoring alert rules ConfigMap", "configMap", c.opts.alertRules)
+		return nil
+	}
+	return rules
+}
+
+// evaluateAlerts runs every rule against the topology the cycle assembled,
+// appending one named condition per rule to its status before it is written.
+// Transitions emit an Event on the subject's source node and, when
+// configured, a webhook notification.
+func (c *controller) evaluateAlerts(ctx context.Context, topo *v1alpha1.NetworkTopology, nodes map[string]*v1.Node) {
+	if c.opts.alertRules == "" {
+		return
+	}
+	rules := c.fetchAlertRules(ctx)
+	if len(rules) == 0 {
+		return
+	}
+	ix := topology.NewIndex(topo)
+	now := time.Now()
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		firingSubjects := 0
+		subjects := 0
+		for subject, value := range alertSamples(rule, topo, ix) {
+			subjects++
+			seen[alertStateKey(rule.Name, subject)] = true
+			firing, transitioned := c.alerts.observe(rule, subject, value, alertBreached(rule, value), now)
+			if firing {
+				firingSubjects++
+			}
+			if transitioned {
+				c.notifyAlert(rule, subject, value, firing, nodes)
+			}
+		}
+		topo.Status.Conditions = append(topo.Status.Conditions, alertCondition(rule, firingSubjects, subjects, now))
+	}
+	c.alerts.prune(seen)
+}
+
+// alertCondition is the named per-rule condition flipped on the topology
+// status: True while any subject fires, False otherwise.
+func alertCondition(rule alertRule, firing, subjects int, now time.Time) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               "Alert" + rule.Name,
+		Status:             metav1.ConditionFalse,
+		Reason:             "WithinBounds",
+		Message:            fmt.Sprintf("%s %s %s holds on all %d subjects", rule.Metric, negateOp(rule.Op), rule.Threshold, subjects),
+		LastTransitionTime: metav1.NewTime(now),
+	}
+	if firing > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Firing"
+		condition.Message = fmt.Sprintf("%d of %d subjects breached %s %s %s for %s",
+			firing, subjects, rule.Metric, rule.Op, rule.Threshold, rule.For.Duration)
+	}
+	return condition
+}
+
+// negateOp renders the in-bounds reading of a rule's operator.
+func negateOp(op string) string {
+	switch op {
+	case ">":
+		return "<="
+	case ">=":
+		return "<"
+	case "<":
+		return ">="
+	case "<=":
+		return ">"
+	}
+	return op
+}
+
+// notifyAlert emits the Event and webhook call for one transition. The
+// Event lands on the subject's source node when it is one of ours; zone and
+// cluster subjects only have the condition and the webhook.
+func (c *controller) notifyAlert(rule alertRule, subject string, value float64, firing bool, nodes map[string]*v1.Node) {
+	reason, state := eventAlertResolved, "resolved"
+	eventType := v1.EventTypeNormal
+	if firing {
+		reason, state = eventAlertFiring, "firing"
+		eventType = v1.EventTypeWarning
+	}
+	klog.InfoS("Alert transition", "rule", rule.Name, "subject", subject, "state", state,
+		"value", fmt.Sprintf("%.2f", value), "threshold", rule.Threshold)
+	source := strings.SplitN(subject, ":", 2)[0]
+	if node := nodes[source]; node != nil && c.recorder != nil {
+		c.recorder.Eventf(node, eventType, reason, "alert %s %s on %s: %s %s %s (observed %.2f) for %s",
+			rule.Name, state, subject, rule.Metric, rule.Op, rule.Threshold, value, rule.For.Duration)
+	}
+	if c.opts.alertWebhook != "" {
+		go c.postAlertWebhook(rule, subject, value, state)
+	}
+}
+
+// alertNotification is the webhook payload for one transition.
+type alertNotification struct {
+	Rule      string    `json:"rule"`
+	Subject   string    `json:"subject"`
+	State     string    `json:"state"` // "firing" or "resolved"
+	Metric    string    `json:"metric"`
+	Op        string    `json:"op"`
+	Threshold string    `json:"threshold"`
+	Value     float64   `json:"value"`
+	Time      time.Time `json:"time"`
+}
+
+func (c *controller) postAlertWebhook(rule alertRule, subject string, value float64, state string) {
+	payload, err := json.Marshal(alertNotification{
+		Rule: rule.Name, Subject: subject, State: state,
+		Metric: rule.Metric, Op: rule.Op, Threshold: rule.Threshold,
+		Value: value, Time: time.Now().UTC(),
+	})
+	if err != nil {
+		return
+	}
+	client := &http.Client{Timeout: alertWebhookTimeout}
+	resp, err := client.Post(c.opts.alertWebhook, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		klog.ErrorS(err, "Posting alert webhook", "rule", rule.Name, "url", c.opts.alertWebhook)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		klog.ErrorS(nil, "Alert webhook rejected notification", "rule", rule.Name, "status", resp.StatusCode)
+	}
+}
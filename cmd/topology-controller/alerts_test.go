@@ -0,0 +1,133 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+)
+
+func TestParseAlertRules(t *testing.T) {
+	rules, err := parseAlertRules(`
+- name: WanLatencyHigh
+  selector: {link: "pi-1:hetzner-1"}
+  metric: latency
+  op: ">"
+  threshold: 150ms
+  for: 10m
+- name: ClusterUnhealthy
+  metric: health
+  op: "<"
+  threshold: "0.5"
+`)
+	if err != nil {
+		t.Fatalf("parseAlertRules: %v", err)
+	}
+	if len(rules) != 2 || rules[0].For.Duration != 10*time.Minute {
+		t.Errorf("parsed %+v", rules)
+	}
+
+	for name, bad := range map[string]string{
+		"unknown operator":       `[{name: a, selector: {node: n}, metric: latency, op: "~", threshold: 1ms}]`,
+		"two selectors":          `[{name: a, selector: {node: n, zone: z}, metric: latency, op: ">", threshold: 1ms}]`,
+		"no selector":            `[{name: a, metric: latency, op: ">", threshold: 1ms}]`,
+		"threshold wrong unit":   `[{name: a, selector: {node: n}, metric: bandwidth, op: "<", threshold: 5ms}]`,
+		"health out of range":    `[{name: a, metric: health, op: "<", threshold: "1.5"}]`,
+		"duplicate names":        `[{name: a, metric: health, op: "<", threshold: "0.5"}, {name: a, metric: health, op: "<", threshold: "0.4"}]`,
+		"malformed link subject": `[{name: a, selector: {link: "pi-1"}, metric: latency, op: ">", threshold: 1ms}]`,
+	} {
+		if _, err := parseAlertRules(bad); err == nil {
+			t.Errorf("%s: accepted %q", name, bad)
+		}
+	}
+}
+
+func TestAlertFiresAfterForDurationAndResolvesImmediately(t *testing.T) {
+	rule := alertRule{
+		Name: "WanLatencyHigh", Selector: alertSelector{Link: "pi-1:hetzner-1"},
+		Metric: alertMetricLatency, Op: ">", Threshold: "150ms",
+		For: metav1.Duration{Duration: 10 * time.Minute},
+	}
+	e := &alertEngine{}
+	t0 := time.Now()
+
+	if firing, transitioned := e.observe(rule, "pi-1:hetzner-1", 200, true, t0); firing || transitioned {
+		t.Fatal("fired on the first breach, before the for duration")
+	}
+	if firing, _ := e.observe(rule, "pi-1:hetzner-1", 210, true, t0.Add(5*time.Minute)); firing {
+		t.Fatal("fired halfway through the for duration")
+	}
+	firing, transitioned := e.observe(rule, "pi-1:hetzner-1", 220, true, t0.Add(10*time.Minute))
+	if !firing || !transitioned {
+		t.Fatalf("did not fire once the breach held for 10m: firing=%v transitioned=%v", firing, transitioned)
+	}
+	// Still breached: firing persists without re-announcing.
+	if _, transitioned := e.observe(rule, "pi-1:hetzner-1", 220, true, t0.Add(11*time.Minute)); transitioned {
+		t.Error("re-announced an already-firing alert")
+	}
+	// Recovery resolves immediately, no symmetric hold.
+	firing, transitioned = e.observe(rule, "pi-1:hetzner-1", 90, false, t0.Add(12*time.Minute))
+	if firing || !transitioned {
+		t.Fatalf("recovery did not resolve: firing=%v transitioned=%v", firing, transitioned)
+	}
+	// A new breach starts the clock over; the old pending time is gone.
+	e.observe(rule, "pi-1:hetzner-1", 200, true, t0.Add(13*time.Minute))
+	if firing, _ := e.observe(rule, "pi-1:hetzner-1", 200, true, t0.Add(14*time.Minute)); firing {
+		t.Error("re-fired from a stale pending timestamp after recovery")
+	}
+}
+
+func TestAlertInterruptedBreachResetsTheClock(t *testing.T) {
+	rule := alertRule{
+		Name: "Slow", Selector: alertSelector{Node: "pi-1"},
+		Metric: alertMetricBandwidth, Op: "<", Threshold: "100mbps",
+		For: metav1.Duration{Duration: 10 * time.Minute},
+	}
+	e := &alertEngine{}
+	t0 := time.Now()
+	e.observe(rule, "pi-1:pi-2", 50, true, t0)
+	e.observe(rule, "pi-1:pi-2", 500, false, t0.Add(5*time.Minute))
+	e.observe(rule, "pi-1:pi-2", 50, true, t0.Add(6*time.Minute))
+	if firing, _ := e.observe(rule, "pi-1:pi-2", 50, true, t0.Add(11*time.Minute)); firing {
+		t.Error("fired across an interrupted breach; the for clock must restart")
+	}
+	// A rule with no for duration fires on the first breach.
+	immediate := rule
+	immediate.For = metav1.Duration{}
+	if firing, _ := e.observe(immediate, "pi-1:pi-3", 50, true, t0); !firing {
+		t.Error("zero for duration did not fire immediately")
+	}
+}
+
+func TestAlertSamplesSelectors(t *testing.T) {
+	topo := &v1alpha1.NetworkTopology{
+		Spec: v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{
+			"pi-1": {Zone: "edge", Latency: map[string]string{"pi-2": "2ms", "hetzner-1": "180ms"},
+				Bandwidth: map[string]string{"pi-2": "940mbps", "hetzner-1": "95mbps"}},
+			"pi-2":      {Zone: "edge", Latency: map[string]string{"pi-1": "2ms"}},
+			"hetzner-1": {Zone: "cloud", Latency: map[string]string{"pi-1": "181ms"}},
+		}},
+		Status: v1alpha1.NetworkTopologyStatus{HealthScore: 0.42},
+	}
+	ix := topology.NewIndex(topo)
+
+	link := alertRule{Selector: alertSelector{Link: "pi-1:hetzner-1"}, Metric: alertMetricLatency}
+	if samples := alertSamples(link, topo, ix); len(samples) != 1 || samples["pi-1:hetzner-1"] != 180 {
+		t.Errorf("link selector samples = %v", samples)
+	}
+	node := alertRule{Selector: alertSelector{Node: "pi-1"}, Metric: alertMetricBandwidth}
+	if samples := alertSamples(node, topo, ix); len(samples) != 2 || samples["pi-1:pi-2"] != 940 {
+		t.Errorf("node selector samples = %v", samples)
+	}
+	zone := alertRule{Selector: alertSelector{Zone: "edge"}, Metric: alertMetricLatency}
+	if samples := alertSamples(zone, topo, ix); len(samples) != 3 {
+		t.Errorf("zone selector samples = %v, want pi-1's and pi-2's links", samples)
+	}
+	health := alertRule{Metric: alertMetricHealth}
+	if samples := alertSamples(health, topo, ix); samples["cluster"] != 0.42 {
+		t.Errorf("health samples = %v", samples)
+	}
+}
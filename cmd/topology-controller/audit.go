@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+)
+
+// Causes recorded on audit entries.
+const (
+	auditCauseMeasurement = "measurement"
+	auditCauseOverride    = "override"
+	auditCauseNodeEvent   = "node-event"
+)
+
+// auditEntry is one JSON line in the audit stream: a single field-level
+// topology change with when and why it happened.
+type auditEntry struct {
+	Time   time.Time `json:"time"`
+	Node   string    `json:"node"`
+	Target string    `json:"target,omitempty"`
+	Field  string    `json:"field"`
+	Old    string    `json:"old,omitempty"`
+	New    string    `json:"new,omitempty"`
+	Cause  string    `json:"cause"`
+}
+
+// auditor appends topology changes as JSON lines to a file or stdout,
+// rotating the file by size. Audit failures never fail a cycle: the record
+// is an operator aid, not a prerequisite for publishing measurements.
+type auditor struct {
+	path     string // "-" for stdout
+	maxBytes int64
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func newAuditor(path string, maxSizeMB int) *auditor {
+	if path == "" {
+		return nil
+	}
+	if maxSizeMB <= 0 {
+		maxSizeMB = 64
+	}
+	return &auditor{path: path, maxBytes: int64(maxSizeMB) * 1024 * 1024}
+}
+
+// record writes one entry per change. cause classifies a change given the
+// set of import-pinned links, letting overrides stand out from measurements.
+func (a *auditor) record(changes []topology.Change, pinned map[string]declaredNode) {
+	if a == nil || len(changes) == 0 {
+		return
+	}
+	now := time.Now().UTC()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, c := range changes {
+		entry := auditEntry{
+			Time: now, Node: c.Node, Target: c.Target,
+			Field: c.Field, Old: c.Old, New: c.New,
+			Cause: auditCause(c, pinned),
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			klog.ErrorS(err, "Encoding audit entry")
+			continue
+		}
+		if err := a.write(append(line, '\n')); err != nil {
+			klog.ErrorS(err, "Writing audit log", "path", a.path)
+			return
+		}
+	}
+}
+
+func auditCause(c topology.Change, pinned map[string]declaredNode) string {
+	if c.Field == "node" {
+		return auditCauseNodeEvent
+	}
+	if decl, ok := pinned[c.Node]; ok && c.Target != "" {
+		if _, ok := decl.Links[c.Target]; ok {
+			return auditCauseOverride
+		}
+	}
+	return auditCauseMeasurement
+}
+
+func (a *auditor) write(line []byte) error {
+	if a.path == "-" {
+		_, err := os.Stdout.Write(line)
+		return err
+	}
+	if a.file == nil {
+		if err := a.open(); err != nil {
+			return err
+		}
+	}
+	if a.size+int64(len(line)) > a.maxBytes {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := a.file.Write(line)
+	a.size += int64(n)
+	return err
+}
+
+func (a *auditor) open() error {
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	a.file = f
+	a.size = info.Size()
+	return nil
+}
+
+// rotate keeps exactly one predecessor file (<path>.1), which bounds disk
+// use at twice maxBytes.
+func (a *auditor) rotate() error {
+	a.file.Close()
+	a.file = nil
+	if err := os.Rename(a.path, a.path+".1"); err != nil {
+		return fmt.Errorf("rotating audit log: %w", err)
+	}
+	return a.open()
+}
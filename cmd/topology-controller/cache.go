@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// cachedLink is the on-disk form of one directed link's smoothed state.
+type cachedLink struct {
+	Source         string    `json:"source"`
+	Target         string    `json:"target"`
+	LatencyNs      int64     `json:"latencyNs,omitempty"`
+	BandwidthBps   int64     `json:"bandwidthBps,omitempty"`
+	BandwidthRxBps int64     `json:"bandwidthRxBps,omitempty"`
+	Path           string    `json:"path,omitempty"`
+	LastProbe      time.Time `json:"lastProbe"`
+}
+
+// measurementCache is the file written at the end of each cycle so a
+// controller restart does not reset every EWMA to its first sample.
+type measurementCache struct {
+	SavedAt time.Time    `json:"savedAt"`
+	Links   []cachedLink `json:"links"`
+}
+
+// saveMeasurementCache snapshots the smoothed link state to the cache file,
+// written to a temp file and renamed so a crash mid-write never leaves a
+// truncated cache. Failures are logged, never fatal: the cache is an
+// optimisation, not state of record.
+func (c *controller) saveMeasurementCache() {
+	c.mu.Lock()
+	cache := measurementCache{SavedAt: time.Now()}
+	for source, targets := range c.smoothed {
+		for target, state := range targets {
+			if !state.measured {
+				continue
+			}
+			cache.Links = append(cache.Links, cachedLink{
+				Source:         source,
+				Target:         target,
+				LatencyNs:      int64(state.latency),
+				BandwidthBps:   state.bandwidth,
+				BandwidthRxBps: state.bandwidthRx,
+				Path:           state.path,
+				LastProbe:      state.lastProbe,
+			})
+		}
+	}
+	c.mu.Unlock()
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		klog.ErrorS(err, "Encoding measurement cache")
+		return
+	}
+	tmp := c.opts.cacheFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		klog.ErrorS(err, "Writing measurement cache", "path", tmp)
+		return
+	}
+	if err := os.Rename(tmp, c.opts.cacheFile); err != nil {
+		klog.ErrorS(err, "Replacing measurement cache", "path", c.opts.cacheFile)
+	}
+}
+
+// readMeasurementCache parses the cache file. A missing file is the normal
+// first run; a corrupt one is warned about and ignored, never fatal.
+func readMeasurementCache(path string) (measurementCache, bool) {
+	cache := measurementCache{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, false
+	}
+	if err != nil {
+		klog.ErrorS(err, "Reading measurement cache, starting cold", "path", path)
+		return cache, false
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		klog.ErrorS(err, "Ignoring corrupt measurement cache", "path", path)
+		return cache, false
+	}
+	return cache, true
+}
+
+// loadMeasurementCache seeds the smoothed state from the previous run's cache
+// file at startup.
+func (c *controller) loadMeasurementCache(ctx context.Context) {
+	cache, ok := readMeasurementCache(c.opts.cacheFile)
+	if !ok {
+		return
+	}
+	nodes, err := c.listNodes(ctx)
+	if err != nil {
+		klog.ErrorS(err, "Listing nodes for cache restore, starting cold")
+		return
+	}
+	known := map[string]bool{}
+	for i := range nodes.Items {
+		known[nodes.Items[i].Name] = true
+	}
+	for _, member := range c.remoteNodes(ctx) {
+		known[member.node.Name] = true
+	}
+	restored := c.restoreSmoothed(cache, known, time.Now())
+	klog.InfoS("Restored measurement cache", "path", c.opts.cacheFile,
+		"links", restored, "discarded", len(cache.Links)-restored, "savedAt", cache.SavedAt)
+}
+
+// restoreSmoothed merges usable cache entries into the smoothed state,
+// discarding entries older than the TTL or naming nodes no longer in the
+// cluster, and returns how many survived. Entries never overwrite state from
+// an already-completed probe.
+func (c *controller) restoreSmoothed(cache measurementCache, known map[string]bool, now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	restored := 0
+	for _, link := range cache.Links {
+		if !known[link.Source] || !known[link.Target] {
+			continue
+		}
+		if now.Sub(link.LastProbe) > c.opts.cacheTTL {
+			continue
+		}
+		if c.smoothed[link.Source] == nil {
+			c.smoothed[link.Source] = map[string]*linkState{}
+		}
+		if c.smoothed[link.Source][link.Target] != nil {
+			continue
+		}
+		c.smoothed[link.Source][link.Target] = &linkState{
+			latency:     time.Duration(link.LatencyNs),
+			bandwidth:   link.BandwidthBps,
+			bandwidthRx: link.BandwidthRxBps,
+			measured:    true,
+			lastProbe:   link.LastProbe,
+			path:        link.Path,
+		}
+		restored++
+	}
+	return restored
+}
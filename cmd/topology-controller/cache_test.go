@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func cacheController(path string) *controller {
+	return &controller{
+		opts:     options{cacheFile: path, cacheTTL: time.Hour},
+		smoothed: map[string]map[string]*linkState{},
+	}
+}
+
+func TestMeasurementCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	probed := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	saved := cacheController(path)
+	saved.smoothed["pi-1"] = map[string]*linkState{
+		"pi-2": {latency: 12 * time.Millisecond, bandwidth: 850_000_000, measured: true, lastProbe: probed, path: "direct"},
+		"pi-3": {measured: false}, // never probed successfully; must not be persisted
+	}
+	saved.saveMeasurementCache()
+
+	cache, ok := readMeasurementCache(path)
+	if !ok {
+		t.Fatal("readMeasurementCache rejected a freshly written cache")
+	}
+	if len(cache.Links) != 1 {
+		t.Fatalf("cached links = %d, want 1 (unmeasured entries dropped): %+v", len(cache.Links), cache.Links)
+	}
+
+	restored := cacheController(path)
+	known := map[string]bool{"pi-1": true, "pi-2": true}
+	if got := restored.restoreSmoothed(cache, known, time.Now()); got != 1 {
+		t.Fatalf("restored %d links, want 1", got)
+	}
+	state := restored.smoothed["pi-1"]["pi-2"]
+	if state == nil || state.latency != 12*time.Millisecond || state.bandwidth != 850_000_000 {
+		t.Fatalf("restored state = %+v", state)
+	}
+	if !state.measured || state.path != "direct" || !state.lastProbe.Equal(probed) {
+		t.Fatalf("restored metadata = %+v", state)
+	}
+}
+
+func TestRestoreSmoothedDiscards(t *testing.T) {
+	now := time.Now()
+	cache := measurementCache{SavedAt: now, Links: []cachedLink{
+		{Source: "pi-1", Target: "pi-2", LatencyNs: int64(time.Millisecond), LastProbe: now.Add(-2 * time.Hour)},
+		{Source: "pi-1", Target: "gone", LatencyNs: int64(time.Millisecond), LastProbe: now},
+		{Source: "pi-1", Target: "pi-3", LatencyNs: int64(time.Millisecond), LastProbe: now},
+	}}
+	c := cacheController("")
+	known := map[string]bool{"pi-1": true, "pi-2": true, "pi-3": true}
+	if got := c.restoreSmoothed(cache, known, now); got != 1 {
+		t.Fatalf("restored %d links, want 1 (TTL and unknown-node entries dropped)", got)
+	}
+	if c.smoothed["pi-1"]["pi-2"] != nil {
+		t.Error("entry past the TTL was restored")
+	}
+	if c.smoothed["pi-1"]["gone"] != nil {
+		t.Error("entry for an unknown node was restored")
+	}
+	if c.smoothed["pi-1"]["pi-3"] == nil {
+		t.Error("fresh entry for a known node was dropped")
+	}
+}
+
+func TestRestoreSmoothedKeepsLiveState(t *testing.T) {
+	now := time.Now()
+	c := cacheController("")
+	c.smoothed["pi-1"] = map[string]*linkState{"pi-2": {latency: 5 * time.Millisecond, measured: true}}
+	cache := measurementCache{Links: []cachedLink{
+		{Source: "pi-1", Target: "pi-2", LatencyNs: int64(90 * time.Millisecond), LastProbe: now},
+	}}
+	if got := c.restoreSmoothed(cache, map[string]bool{"pi-1": true, "pi-2": true}, now); got != 0 {
+		t.Fatalf("restored %d links over live state, want 0", got)
+	}
+	if c.smoothed["pi-1"]["pi-2"].latency != 5*time.Millisecond {
+		t.Error("cache entry overwrote state from a completed probe")
+	}
+}
+
+func TestReadMeasurementCacheCorruptAndMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	if _, ok := readMeasurementCache(path); ok {
+		t.Error("missing cache file must read as absent")
+	}
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := readMeasurementCache(path); ok {
+		t.Error("corrupt cache file must be ignored")
+	}
+}
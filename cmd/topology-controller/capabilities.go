@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// capabilityRulesKey is the ConfigMap data key holding the YAML rule list.
+const capabilityRulesKey = "rules"
+
+// capabilityRule maps a node label pattern to a capability name, e.g.
+//
+//   - name: gpu-operator
+//     capability: gpu
+//     key: nvidia.com/gpu.present
+//     value: "true"
+//
+// Key may be a comma-separated precedence list like the label-key flags; the
+// first label set on the node supplies the value that is compared. An empty
+// value matches any non-empty label value, so presence-style labels work
+// without enumerating their values. The first rule producing a capability
+// wins and is recorded as its source in the topology.
+type capabilityRule struct {
+	Name       string `json:"name"`
+	Capability string `json:"capability"`
+	Key        string `json:"key"`
+	Value      string `json:"value,omitempty"`
+}
+
+// matches reports whether the node's labels satisfy the rule. Values are
+// normalized on both sides, matching how every other label comparison in the
+// controller works.
+func (r capabilityRule) matches(node *v1.Node) bool {
+	value := ""
+	for _, key := range strings.Split(r.Key, ",") {
+		if key = strings.TrimSpace(key); key == "" {
+			continue
+		}
+		if v := requirements.NodeLabel(node, key); v != "" {
+			value = v
+			break
+		}
+	}
+	if value == "" {
+		return false
+	}
+	return r.Value == "" || value == requirements.NormalizeLabelValue(r.Value)
+}
+
+// defaultCapabilityRules returns the built-in inference table: the bespoke
+// internet/gpu/role labels (read through the configured label keys, so
+// --gpu-label remapping keeps working), the GPU operator's presence label and
+// Longhorn's default-disk label.
+func defaultCapabilityRules(keys requirements.LabelKeys) []capabilityRule {
+	defaults := requirements.DefaultLabelKeys()
+	gpuKeys, roleKeys := keys.GPU, keys.Role
+	if gpuKeys == "" {
+		gpuKeys = defaults.GPU
+	}
+	if roleKeys == "" {
+		roleKeys = defaults.Role
+	}
+	return []capabilityRule{
+		{Name: "internet-label", Capability: "internet", Key: "internet", Value: "true"},
+		{Name: "gpu-label", Capability: "gpu", Key: gpuKeys},
+		{Name: "gpu-operator", Capability: "gpu", Key: "nvidia.com/gpu.present", Value: "true"},
+		{Name: "gateway-role", Capability: "gateway", Key: roleKeys, Value: "gateway"},
+		{Name: "longhorn-storage", Capability: "storage", Key: "node.longhorn.io/create-default-disk", Value: "true"},
+	}
+}
+
+// parseCapabilityRules decodes and validates the YAML rule list. Like alert
+// rules, a malformed ConfigMap rejects wholesale: a rule silently dropped is
+// a capability that silently disappears from every node.
+func parseCapabilityRules(data string) ([]capabilityRule, error) {
+	var rules []capabilityRule
+	if err := yaml.UnmarshalStrict([]byte(data), &rules); err != nil {
+		return nil, fmt.Errorf("decoding capability rules: %w", err)
+	}
+	seen := map[string]bool{}
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule %d has no name", i)
+		}
+		if seen[rule.Name] {
+			return nil, fmt.Errorf("duplicate rule name %q", rule.Name)
+		}
+		seen[rule.Name] = true
+		if rule.Capability == "" {
+			return nil, fmt.Errorf("rule %q has no capability", rule.Name)
+		}
+		if rule.Key == "" {
+			return nil, fmt.Errorf("rule %q has no label key", rule.Name)
+		}
+	}
+	return rules, nil
+}
+
+// applyCapabilityRules evaluates the rules against one node, returning the
+// inferred capabilities and, per capability, the name of the rule that
+// produced it. Rules are evaluated in order and the first one producing a
+// capability wins, so overlapping rules (the bespoke gpu label and the GPU
+// operator's) yield one entry with a deterministic source. Each cycle rebuilds
+// the node specs from the current rule set, so removing a rule removes the
+// capabilities only it produced.
+func applyCapabilityRules(node *v1.Node, rules []capabilityRule) ([]string, map[string]string) {
+	var caps []string
+	var sources map[string]string
+	for _, rule := range rules {
+		if !rule.matches(node) {
+			continue
+		}
+		if _, ok := sources[rule.Capability]; ok {
+			continue
+		}
+		if sources == nil {
+			sources = map[string]string{}
+		}
+		caps = append(caps, rule.Capability)
+		sources[rule.Capability] = rule.Name
+	}
+	return caps, sources
+}
+
+// fetchCapabilityRules resolves the active rule set for a cycle: the rules
+// ConfigMap when configured (re-read every cycle so edits hot-reload), the
+// built-in defaults otherwise or when the ConfigMap is missing or malformed —
+// a bad edit must not strip every node of its capabilities.
+func (c *controller) fetchCapabilityRules(ctx context.Context) []capabilityRule {
+	fallback := defaultCapabilityRules(c.opts.labelKeys())
+	if c.opts.capabilityRules == "" {
+		return fallback
+	}
+	namespace := os.Getenv("POD_NAMESPACE")
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	cm, err := c.client.CoreV1().ConfigMaps(namespace).Get(ctx, c.opts.capabilityRules, metav1.GetOptions{})
+	if err != nil {
+		klog.V(2).InfoS("Capability rules ConfigMap unavailable, using the defaults",
+			"configMap", c.opts.capabilityRules, "err", err)
+		return fallback
+	}
+	rules, err := parseCapabilityRules(cm.Data[capabilityRulesKey])
+	if err != nil {
+		klog.ErrorS(err, "Ignoring capability rules ConfigMap, using the defaults",
+			"configMap", c.opts.capabilityRules)
+		return fallback
+	}
+	return rules
+}
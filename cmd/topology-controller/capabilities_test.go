@@ -0,0 +1,123 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+func labeledNode(name string, labels map[string]string) *v1.Node {
+	return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestDefaultCapabilityRules(t *testing.T) {
+	rules := defaultCapabilityRules(requirements.LabelKeys{})
+
+	// The bespoke gpu label still infers gpu, sourced from its own rule.
+	caps, sources := applyCapabilityRules(labeledNode("pi-1", map[string]string{"gpu": "nvidia"}), rules)
+	if !reflect.DeepEqual(caps, []string{"gpu"}) || sources["gpu"] != "gpu-label" {
+		t.Errorf("bespoke gpu label: caps = %v, sources = %v", caps, sources)
+	}
+
+	// GPU-operator nodes without the bespoke label are covered too.
+	caps, sources = applyCapabilityRules(labeledNode("gpu-1", map[string]string{"nvidia.com/gpu.present": "true"}), rules)
+	if !reflect.DeepEqual(caps, []string{"gpu"}) || sources["gpu"] != "gpu-operator" {
+		t.Errorf("GPU operator label: caps = %v, sources = %v", caps, sources)
+	}
+
+	// Longhorn storage nodes gain the storage capability.
+	caps, sources = applyCapabilityRules(labeledNode("store-1", map[string]string{"node.longhorn.io/create-default-disk": "true"}), rules)
+	if !reflect.DeepEqual(caps, []string{"storage"}) || sources["storage"] != "longhorn-storage" {
+		t.Errorf("longhorn label: caps = %v, sources = %v", caps, sources)
+	}
+
+	// The historical internet and gateway inference is unchanged.
+	caps, _ = applyCapabilityRules(labeledNode("gw-1", map[string]string{"internet": "true", "role": "Gateway "}), rules)
+	if !reflect.DeepEqual(caps, []string{"internet", "gateway"}) {
+		t.Errorf("gateway node: caps = %v", caps)
+	}
+}
+
+func TestCapabilityRulesOverlap(t *testing.T) {
+	// A node matching both gpu rules gets the capability once, attributed to
+	// the first rule in table order.
+	node := labeledNode("gpu-1", map[string]string{
+		"gpu":                    "nvidia",
+		"nvidia.com/gpu.present": "true",
+	})
+	caps, sources := applyCapabilityRules(node, defaultCapabilityRules(requirements.LabelKeys{}))
+	if !reflect.DeepEqual(caps, []string{"gpu"}) {
+		t.Fatalf("overlapping rules produced %v, want one gpu entry", caps)
+	}
+	if sources["gpu"] != "gpu-label" {
+		t.Errorf("source = %q, want the first matching rule gpu-label", sources["gpu"])
+	}
+}
+
+func TestCapabilityRuleRemovalCleansUp(t *testing.T) {
+	node := labeledNode("store-1", map[string]string{"node.longhorn.io/create-default-disk": "true"})
+	withRule := []capabilityRule{
+		{Name: "longhorn-storage", Capability: "storage", Key: "node.longhorn.io/create-default-disk", Value: "true"},
+	}
+	caps, _ := applyCapabilityRules(node, withRule)
+	if !reflect.DeepEqual(caps, []string{"storage"}) {
+		t.Fatalf("caps = %v, want storage", caps)
+	}
+	// Each cycle rebuilds the spec from the active rule set, so dropping the
+	// rule drops the capability on the next apply.
+	caps, sources := applyCapabilityRules(node, nil)
+	if len(caps) != 0 || len(sources) != 0 {
+		t.Errorf("removed rule left caps = %v, sources = %v", caps, sources)
+	}
+}
+
+func TestCapabilityRuleKeyPrecedence(t *testing.T) {
+	// Like the label-key flags, the first key set on the node supplies the
+	// compared value: a remapped gpu key that is set but non-matching must
+	// not fall through to a later key.
+	rules := []capabilityRule{
+		{Name: "accel", Capability: "gpu", Key: "accelerator, gpu", Value: "nvidia"},
+	}
+	node := labeledNode("pi-1", map[string]string{"accelerator": "none", "gpu": "nvidia"})
+	if caps, _ := applyCapabilityRules(node, rules); len(caps) != 0 {
+		t.Errorf("first-set key should win: caps = %v", caps)
+	}
+	node = labeledNode("pi-2", map[string]string{"gpu": "nvidia"})
+	if caps, _ := applyCapabilityRules(node, rules); !reflect.DeepEqual(caps, []string{"gpu"}) {
+		t.Errorf("fallback key: caps = %v, want gpu", caps)
+	}
+}
+
+func TestParseCapabilityRules(t *testing.T) {
+	rules, err := parseCapabilityRules(`
+- name: gpu-operator
+  capability: gpu
+  key: nvidia.com/gpu.present
+  value: "true"
+- name: storage
+  capability: storage
+  key: node.longhorn.io/create-default-disk
+`)
+	if err != nil {
+		t.Fatalf("parseCapabilityRules: %v", err)
+	}
+	if len(rules) != 2 || rules[1].Value != "" {
+		t.Fatalf("parsed %+v", rules)
+	}
+
+	for name, data := range map[string]string{
+		"missing name":       "- capability: gpu\n  key: gpu\n",
+		"missing capability": "- name: r\n  key: gpu\n",
+		"missing key":        "- name: r\n  capability: gpu\n",
+		"duplicate names":    "- name: r\n  capability: gpu\n  key: gpu\n- name: r\n  capability: x\n  key: y\n",
+		"unknown field":      "- name: r\n  capability: gpu\n  key: gpu\n  pattern: x\n",
+	} {
+		if _, err := parseCapabilityRules(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+)
+
+// domainHysteresisCycles is how many consecutive cycles a labeled node must
+// resolve to a different domain before its label is rewritten. Spread
+// constraints key off the label, so letting one flapping link move nodes
+// between domains every cycle would churn placement decisions cluster-wide.
+const domainHysteresisCycles = 3
+
+// domainFieldManager owns the domain label in server-side apply, so disabling
+// the feature removes exactly the labels this controller wrote and never a
+// value an operator set by hand.
+const domainFieldManager = "topology-controller-domains"
+
+// desiredDomains computes this cycle's domain per local node and applies
+// hysteresis against the labels the nodes currently carry. Unlabeled nodes
+// adopt their domain immediately; labeled nodes keep their label until the
+// new domain has held for domainHysteresisCycles consecutive cycles. Nodes
+// absent from the topology keep whatever label they have. Only the cycle
+// goroutine touches the hysteresis state.
+func (c *controller) desiredDomains(topo *v1alpha1.NetworkTopology, nodes []*v1.Node) map[string]string {
+	computed := topology.Domains(topo, c.domainMs)
+	if c.domainStreak == nil {
+		c.domainStreak = map[string]int{}
+		c.domainCandidate = map[string]string{}
+	}
+	desired := map[string]string{}
+	for _, node := range nodes {
+		current := node.Labels[requirements.LabelDomain]
+		want, ok := computed[node.Name]
+		if !ok {
+			if current != "" {
+				desired[node.Name] = current
+			}
+			continue
+		}
+		switch {
+		case current == "" || current == want:
+			delete(c.domainStreak, node.Name)
+			delete(c.domainCandidate, node.Name)
+			desired[node.Name] = want
+		case c.domainCandidate[node.Name] == want:
+			c.domainStreak[node.Name]++
+			if c.domainStreak[node.Name] >= domainHysteresisCycles {
+				delete(c.domainStreak, node.Name)
+				delete(c.domainCandidate, node.Name)
+				desired[node.Name] = want
+			} else {
+				desired[node.Name] = current
+			}
+		default:
+			c.domainCandidate[node.Name] = want
+			c.domainStreak[node.Name] = 1
+			desired[node.Name] = current
+		}
+	}
+	return desired
+}
+
+// reconcileDomainLabels server-side-applies the domain label on local nodes.
+// With domain labeling disabled desired is nil, and nodes still carrying the
+// label receive an empty apply: server-side apply then drops the label if
+// this controller's field manager owns it, and leaves foreign values alone.
+func (c *controller) reconcileDomainLabels(ctx context.Context, desired map[string]string, nodes []*v1.Node) {
+	for _, node := range nodes {
+		want := desired[node.Name]
+		current := node.Labels[requirements.LabelDomain]
+		if want == current {
+			continue
+		}
+		cfg := applycorev1.Node(node.Name)
+		if want != "" {
+			cfg.WithLabels(map[string]string{requirements.LabelDomain: want})
+		}
+		_, err := c.client.CoreV1().Nodes().Apply(ctx, cfg,
+			metav1.ApplyOptions{FieldManager: domainFieldManager, Force: true})
+		if err != nil {
+			klog.ErrorS(err, "Applying domain label", "node", node.Name, "domain", want)
+			continue
+		}
+		if want == "" {
+			klog.V(2).InfoS("Removed domain label", "node", node.Name, "was", current)
+		} else {
+			klog.V(2).InfoS("Applied domain label", "node", node.Name, "domain", want, "was", current)
+		}
+	}
+}
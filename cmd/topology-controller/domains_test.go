@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// domainTopology is a fresh snapshot per call (the shared index caches by
+// pointer) with a2↔a3 at the given latency, so tests can flap that link
+// across cycles.
+func domainTopology(a2a3 string) *v1alpha1.NetworkTopology {
+	return &v1alpha1.NetworkTopology{Spec: v1alpha1.NetworkTopologySpec{
+		Nodes: map[string]v1alpha1.NodeSpec{
+			"a1": {Latency: map[string]string{"a2": "3ms"}},
+			"a2": {Latency: map[string]string{"a3": a2a3}},
+			"a3": {},
+		},
+	}}
+}
+
+func domainNode(name, domain string) *v1.Node {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}}}
+	if domain != "" {
+		node.Labels[requirements.LabelDomain] = domain
+	}
+	return node
+}
+
+func TestDesiredDomainsFirstAssignmentImmediate(t *testing.T) {
+	c := &controller{domainMs: 30}
+	nodes := []*v1.Node{domainNode("a1", ""), domainNode("a2", ""), domainNode("a3", "")}
+	desired := c.desiredDomains(domainTopology("4ms"), nodes)
+	for _, name := range []string{"a1", "a2", "a3"} {
+		if desired[name] != "a1" {
+			t.Errorf("unlabeled node %s = %q, want immediate assignment to a1", name, desired[name])
+		}
+	}
+}
+
+func TestDesiredDomainsHysteresis(t *testing.T) {
+	c := &controller{domainMs: 30}
+	nodes := []*v1.Node{domainNode("a1", "a1"), domainNode("a2", "a1"), domainNode("a3", "a1")}
+
+	// The a2-a3 link degrades: a3 now computes to its own domain, but the
+	// label must hold until the change survives three consecutive cycles.
+	for cycle := 1; cycle < domainHysteresisCycles; cycle++ {
+		if desired := c.desiredDomains(domainTopology("90ms"), nodes); desired["a3"] != "a1" {
+			t.Fatalf("cycle %d: a3 = %q, want a1 held by hysteresis", cycle, desired["a3"])
+		}
+	}
+	desired := c.desiredDomains(domainTopology("90ms"), nodes)
+	if desired["a3"] != "a3" {
+		t.Fatalf("after %d cycles a3 = %q, want a3", domainHysteresisCycles, desired["a3"])
+	}
+	if desired["a1"] != "a1" || desired["a2"] != "a1" {
+		t.Errorf("unaffected nodes moved: a1=%q a2=%q", desired["a1"], desired["a2"])
+	}
+}
+
+func TestDesiredDomainsFlapResetsStreak(t *testing.T) {
+	c := &controller{domainMs: 30}
+	nodes := []*v1.Node{domainNode("a1", "a1"), domainNode("a2", "a1"), domainNode("a3", "a1")}
+
+	// Two degraded cycles, one healthy one: the streak restarts, so two
+	// more degraded cycles still must not move the label.
+	c.desiredDomains(domainTopology("90ms"), nodes)
+	c.desiredDomains(domainTopology("90ms"), nodes)
+	c.desiredDomains(domainTopology("4ms"), nodes)
+	c.desiredDomains(domainTopology("90ms"), nodes)
+	if desired := c.desiredDomains(domainTopology("90ms"), nodes); desired["a3"] != "a1" {
+		t.Errorf("a3 = %q after a flap reset, want a1", desired["a3"])
+	}
+}
+
+func TestDesiredDomainsKeepsLabelForUnmeasuredNode(t *testing.T) {
+	c := &controller{domainMs: 30}
+	nodes := []*v1.Node{domainNode("a1", "a1"), domainNode("gone", "a1")}
+	desired := c.desiredDomains(domainTopology("4ms"), nodes)
+	if desired["gone"] != "a1" {
+		t.Errorf("node absent from the topology = %q, want its existing label kept", desired["gone"])
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/version"
+)
+
+// versionHandler reports the build metadata and enabled feature gates.
+func versionHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(version.Get()); err != nil {
+		klog.V(2).InfoS("Writing version info failed", "err", err)
+	}
+}
+
+// peerEndpoint is a peer's current NAT traversal state: either a direct
+// UDP endpoint or a DERP relay region.
+type peerEndpoint struct {
+	addr  string // ip:port of the direct path, empty when relayed
+	relay string // DERP region code, empty when direct
+}
+
+func (e peerEndpoint) String() string {
+	if e.relay != "" {
+		return "derp-" + e.relay
+	}
+	if e.addr != "" {
+		return e.addr
+	}
+	return "unknown"
+}
+
+// tailscaleEndpoints maps peer hostnames to their current endpoint or relay.
+func tailscaleEndpoints() map[string]peerEndpoint {
+	out, err := exec.Command("tailscale", "status", "--json").Output()
+	if err != nil {
+		klog.V(2).InfoS("tailscale status unavailable", "err", err)
+		return nil
+	}
+	var status struct {
+		Peer map[string]struct {
+			HostName string `json:"HostName"`
+			CurAddr  string `json:"CurAddr"`
+			Relay    string `json:"Relay"`
+		} `json:"Peer"`
+	}
+	if err := json.Unmarshal(out, &status); err != nil {
+		klog.V(2).InfoS("Parsing tailscale status failed", "err", err)
+		return nil
+	}
+	endpoints := map[string]peerEndpoint{}
+	for _, peer := range status.Peer {
+		endpoint := peerEndpoint{addr: peer.CurAddr}
+		if peer.CurAddr == "" {
+			endpoint.relay = peer.Relay
+		}
+		endpoints[peer.HostName] = endpoint
+	}
+	return endpoints
+}
+
+// trackEndpoints detects peers whose endpoint changed since the previous
+// cycle (laptop moved networks, CGNAT rebind). Old measurements for such a
+// peer describe a path that no longer exists, so its EWMA state is dropped in
+// both directions — the probes that follow in the same cycle then repopulate
+// the links from scratch instead of blending into stale history.
+func (c *controller) trackEndpoints(ctx context.Context) {
+	current := c.resolver.endpoints()
+	if current == nil {
+		return
+	}
+	c.mu.Lock()
+	previous := c.lastEndpoints
+	c.lastEndpoints = current
+	var moved []string
+	for node, endpoint := range current {
+		if prev, ok := previous[node]; ok && prev != endpoint {
+			moved = append(moved, node)
+			c.endpointChanges[node]++
+			delete(c.smoothed, node)
+			for _, targets := range c.smoothed {
+				delete(targets, node)
+			}
+			klog.InfoS("Peer endpoint changed, resetting measurements",
+				"node", node, "from", prev, "to", endpoint)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, node := range moved {
+		c.recordEndpointEvent(ctx, node, previous[node], current[node])
+	}
+}
+
+func (c *controller) recordEndpointEvent(ctx context.Context, node string, from, to peerEndpoint) {
+	now := metav1.Now()
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "networktopology-endpoint-",
+			Namespace:    metav1.NamespaceDefault,
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "NetworkTopology",
+			Name:       topologyName,
+		},
+		Reason: "EndpointChanged",
+		Message: fmt.Sprintf("peer %s moved from %s to %s; smoothed measurements reset",
+			node, from, to),
+		Type:           v1.EventTypeNormal,
+		Source:         v1.EventSource{Component: "topology-controller"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err := c.client.CoreV1().Events(event.Namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		klog.V(2).InfoS("Failed to record endpoint change event", "err", err)
+	}
+}
+
+// serveMetrics exposes controller counters in the Prometheus text format.
+// The format is simple enough that hand-writing it beats pulling the client
+// library into a binary that otherwise has no metrics machinery.
+func (c *controller) serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		c.mu.Lock()
+		nodes := make([]string, 0, len(c.endpointChanges))
+		for node := range c.endpointChanges {
+			nodes = append(nodes, node)
+		}
+		counts := make(map[string]int64, len(nodes))
+		for node, count := range c.endpointChanges {
+			counts[node] = count
+		}
+		c.mu.Unlock()
+		sort.Strings(nodes)
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		fmt.Fprintln(w, "# HELP endpoint_changes_total Times a peer's NAT traversal endpoint changed since controller start.")
+		fmt.Fprintln(w, "# TYPE endpoint_changes_total counter")
+		for _, node := range nodes {
+			fmt.Fprintf(w, "endpoint_changes_total{node=%q} %d\n", node, counts[node])
+		}
+		fmt.Fprintln(w, "# HELP build_info Constant 1, labeled with the controller's git SHA and build date.")
+		fmt.Fprintln(w, "# TYPE build_info gauge")
+		fmt.Fprintf(w, "build_info{gitSHA=%q,buildDate=%q} 1\n", version.GitSHA, version.BuildDate)
+	})
+	mux.HandleFunc("/version", versionHandler)
+	mux.HandleFunc("/debug/status", c.statusHandler)
+	mux.HandleFunc("/debug/zones", c.zonesHandler)
+	mux.HandleFunc("/debug/capabilities", c.capabilitiesHandler)
+	mux.HandleFunc("/debug/worst-links", c.worstLinksHandler)
+	mux.HandleFunc("/trigger", c.triggerHandler)
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	klog.InfoS("Starting metrics listener", "addr", addr)
+	if err := server.ListenAndServe(); err != nil {
+		klog.ErrorS(err, "Metrics listener failed")
+	}
+}
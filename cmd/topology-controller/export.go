@@ -0,0 +1,34 @@
+package main
+
+import (
+	"time"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/export"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// exportLinkSamples records one row per measured link after a cycle, using
+// the smoothed state the written topology is built from. The sink queues
+// rows asynchronously and drops on backlog, so this never delays a cycle.
+func (c *controller) exportLinkSamples(now time.Time) {
+	if c.export == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for source, targets := range c.smoothed {
+		for target, state := range targets {
+			if state == nil || !state.measured {
+				continue
+			}
+			c.export.Record(export.LinkSample{
+				Time:          now,
+				Source:        source,
+				Target:        target,
+				LatencyMs:     float64(state.latency) / float64(time.Millisecond),
+				BandwidthMbps: state.bandwidth / units.Mbps,
+				Cost:          c.linkCost(state),
+			})
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// externalEndpointsKey is the ConfigMap data key holding the YAML endpoint
+// list.
+const externalEndpointsKey = "endpoints"
+
+// externalEndpoint declares a network location that matters for placement
+// without being a cluster node — an NFS filer, an S3 gateway:
+//
+//   - name: nfs-1
+//     address: 10.0.0.40
+//     zone: local
+//
+// Each cycle probes every node→endpoint leg and publishes the latency under
+// the endpoint's "external/<name>" topology entry, so pods can bound their
+// distance to it with max-latency-to. Endpoints cannot run the probe agent,
+// so their links carry latency only.
+type externalEndpoint struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Zone    string `json:"zone,omitempty"`
+}
+
+// externalName is the topology entry the endpoint is published under.
+func externalName(name string) string {
+	return v1alpha1.ExternalPrefix + name
+}
+
+// parseExternalEndpoints decodes and validates the YAML endpoint list. A
+// malformed ConfigMap rejects wholesale, like the alert and capability rule
+// lists: a silently dropped endpoint is a constraint that silently stops
+// being enforced.
+func parseExternalEndpoints(data string) ([]externalEndpoint, error) {
+	var endpoints []externalEndpoint
+	if err := yaml.UnmarshalStrict([]byte(data), &endpoints); err != nil {
+		return nil, fmt.Errorf("decoding external endpoints: %w", err)
+	}
+	seen := map[string]bool{}
+	for i, ep := range endpoints {
+		if ep.Name == "" {
+			return nil, fmt.Errorf("endpoint %d has no name", i)
+		}
+		if strings.Contains(ep.Name, "/") {
+			return nil, fmt.Errorf("endpoint name %q must not contain a slash; the external/ prefix is added on publication", ep.Name)
+		}
+		if seen[ep.Name] {
+			return nil, fmt.Errorf("duplicate endpoint name %q", ep.Name)
+		}
+		seen[ep.Name] = true
+		if ep.Address == "" {
+			return nil, fmt.Errorf("endpoint %q has no address", ep.Name)
+		}
+	}
+	return endpoints, nil
+}
+
+// fetchExternalEndpoints resolves the cycle's endpoint set from the
+// configured ConfigMap, re-read every cycle so edits hot-reload. Unlike
+// capability rules there is no built-in fallback — no endpoints are declared
+// unless the operator declares them — but a transient fetch error keeps the
+// previous cycle's set, so an API hiccup does not prune every endpoint's
+// links and history for one cycle.
+func (c *controller) fetchExternalEndpoints(ctx context.Context) []externalEndpoint {
+	if c.opts.externalEndpoints == "" {
+		return nil
+	}
+	namespace := os.Getenv("POD_NAMESPACE")
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	cm, err := c.client.CoreV1().ConfigMaps(namespace).Get(ctx, c.opts.externalEndpoints, metav1.GetOptions{})
+	if err != nil {
+		klog.V(2).InfoS("External endpoints ConfigMap unavailable, keeping the previous set",
+			"configMap", c.opts.externalEndpoints, "err", err)
+		return c.lastExternal
+	}
+	endpoints, err := parseExternalEndpoints(cm.Data[externalEndpointsKey])
+	if err != nil {
+		klog.ErrorS(err, "Ignoring external endpoints ConfigMap, keeping the previous set",
+			"configMap", c.opts.externalEndpoints)
+		return c.lastExternal
+	}
+	return endpoints
+}
+
+// pruneRemovedEndpoints drops the smoothed history of every node→endpoint
+// leg whose endpoint is no longer declared, then records the active set. The
+// published links disappear on their own — specs are rebuilt each cycle —
+// but without this the EWMA state would silently resurrect a re-added
+// endpoint with stale values.
+func (c *controller) pruneRemovedEndpoints(endpoints []externalEndpoint) {
+	active := map[string]bool{}
+	for _, ep := range endpoints {
+		active[ep.Name] = true
+	}
+	for _, ep := range c.lastExternal {
+		if !active[ep.Name] {
+			klog.InfoS("External endpoint removed, pruning its links", "endpoint", ep.Name)
+			c.forgetNodeHistory(externalName(ep.Name))
+		}
+	}
+	c.lastExternal = endpoints
+}
+
+// measureEndpointLinks probes one source node's legs toward every declared
+// endpoint and records them in its spec, keyed by the endpoints' external
+// names. Endpoints never run the probe agent, so only latency (and the probe
+// time) is published; the returned counts feed the cycle totals.
+func (c *controller) measureEndpointLinks(ctx context.Context, spec *v1alpha1.NodeSpec, source string, endpoints []externalEndpoint) (attempted, measured int) {
+	for _, ep := range endpoints {
+		target := externalName(ep.Name)
+		attempted++
+		link := c.measurePair(ctx, source, target, ep.Address, false, false)
+		if link == nil || !link.measured {
+			continue
+		}
+		measured++
+		spec.Latency[target] = units.FormatLatency(link.latency)
+		spec.ProbeTimes[target] = metav1.NewTime(link.lastProbe)
+	}
+	return attempted, measured
+}
+
+// fillEndpointLinksFromSmoothed carries the previous smoothed endpoint legs
+// into a spec whose cycle did not probe them (scoped remeasurements, frozen
+// maintenance nodes without a republished spec).
+func (c *controller) fillEndpointLinksFromSmoothed(spec *v1alpha1.NodeSpec, source string, endpoints []externalEndpoint) {
+	for _, ep := range endpoints {
+		target := externalName(ep.Name)
+		c.mu.Lock()
+		state := c.smoothed[source][target]
+		c.mu.Unlock()
+		if state == nil || !state.measured {
+			continue
+		}
+		spec.Latency[target] = units.FormatLatency(state.latency)
+		spec.ProbeTimes[target] = metav1.NewTime(state.lastProbe)
+	}
+}
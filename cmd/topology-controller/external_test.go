@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseExternalEndpoints(t *testing.T) {
+	endpoints, err := parseExternalEndpoints(`
+- name: nfs-1
+  address: 10.0.0.40
+  zone: local
+- name: s3-gw
+  address: 192.168.8.2
+`)
+	if err != nil {
+		t.Fatalf("parseExternalEndpoints: %v", err)
+	}
+	if len(endpoints) != 2 || endpoints[0].Zone != "local" || endpoints[1].Zone != "" {
+		t.Fatalf("parsed %+v", endpoints)
+	}
+
+	for name, data := range map[string]string{
+		"missing name":    "- address: 10.0.0.40\n",
+		"missing address": "- name: nfs-1\n",
+		"duplicate names": "- name: nfs-1\n  address: 10.0.0.40\n- name: nfs-1\n  address: 10.0.0.41\n",
+		"slash in name":   "- name: external/nfs-1\n  address: 10.0.0.40\n",
+		"unknown field":   "- name: nfs-1\n  address: 10.0.0.40\n  port: 2049\n",
+	} {
+		if _, err := parseExternalEndpoints(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPruneRemovedEndpoints(t *testing.T) {
+	c := &controller{smoothed: map[string]map[string]*linkState{
+		"pi-1": {"pi-2": {}, "external/nfs-1": {}, "external/s3-gw": {}},
+		"pi-2": {"external/nfs-1": {}},
+	}}
+	c.lastExternal = []externalEndpoint{
+		{Name: "nfs-1", Address: "10.0.0.40"},
+		{Name: "s3-gw", Address: "192.168.8.2"},
+	}
+
+	// Dropping one endpoint prunes exactly its legs, from every source.
+	kept := []externalEndpoint{{Name: "s3-gw", Address: "192.168.8.2"}}
+	c.pruneRemovedEndpoints(kept)
+	if _, ok := c.smoothed["pi-1"]["external/nfs-1"]; ok {
+		t.Error("removed endpoint's leg from pi-1 survived")
+	}
+	if _, ok := c.smoothed["pi-2"]["external/nfs-1"]; ok {
+		t.Error("removed endpoint's leg from pi-2 survived")
+	}
+	if _, ok := c.smoothed["pi-1"]["external/s3-gw"]; !ok {
+		t.Error("surviving endpoint's leg was pruned")
+	}
+	if _, ok := c.smoothed["pi-1"]["pi-2"]; !ok {
+		t.Error("node pair history was pruned")
+	}
+	if len(c.lastExternal) != 1 || c.lastExternal[0].Name != "s3-gw" {
+		t.Errorf("lastExternal = %+v, want the surviving endpoint", c.lastExternal)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+)
+
+// remoteCluster is one federated peer whose nodes join the combined
+// topology. Members of the same tailnet are measured exactly like local
+// nodes: "tailscale status" already lists their peer IPs.
+type remoteCluster struct {
+	name   string
+	client kubernetes.Interface
+}
+
+// newRemoteClusters builds clients for a comma-separated list of remote
+// kubeconfig paths. The cluster name is the kubeconfig's base name without
+// extension; a bad kubeconfig fails startup rather than silently shrinking
+// the federation.
+func newRemoteClusters(paths string) ([]remoteCluster, error) {
+	var remotes []remoteCluster
+	for _, path := range strings.Split(paths, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		config, err := clientcmd.BuildConfigFromFlags("", path)
+		if err != nil {
+			return nil, fmt.Errorf("remote kubeconfig %s: %w", path, err)
+		}
+		client, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("remote kubeconfig %s: %w", path, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		remotes = append(remotes, remoteCluster{name: name, client: client})
+	}
+	return remotes, nil
+}
+
+// memberNode is one node of the federation with the cluster it belongs to.
+type memberNode struct {
+	node    *v1.Node
+	cluster string
+}
+
+// remoteNodes lists the nodes of every remote cluster. A failing peer is
+// logged and skipped so the topology degrades to the reachable clusters
+// rather than failing the cycle.
+func (c *controller) remoteNodes(ctx context.Context) []memberNode {
+	var members []memberNode
+	for _, remote := range c.remotes {
+		nodes, err := remote.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			klog.ErrorS(err, "Skipping unreachable remote cluster", "cluster", remote.name)
+			continue
+		}
+		for i := range nodes.Items {
+			members = append(members, memberNode{node: &nodes.Items[i], cluster: remote.name})
+		}
+	}
+	return members
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	topologyv1 "github.com/KomarovAI/k3s-network-aware-cluster/api/proto/topology/v1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+)
+
+// grpcTopologyServer serves the komarov.topology.v1 service: snapshot reads
+// from the controller's in-memory state and a watch stream fed one diff per
+// committed measurement cycle, so mesh controllers get pushes instead of
+// polling the CRD.
+type grpcTopologyServer struct {
+	c *controller
+
+	mu       sync.Mutex
+	watchers map[int]chan *topologyv1.TopologyDiff
+	nextID   int
+}
+
+func newGRPCTopologyServer(c *controller) *grpcTopologyServer {
+	return &grpcTopologyServer{c: c, watchers: map[int]chan *topologyv1.TopologyDiff{}}
+}
+
+// serve runs the gRPC listener; mutual TLS is mandatory — the topology is
+// cluster-internal operational data.
+func (s *grpcTopologyServer) serve(addr, certFile, keyFile, clientCAFile string) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		klog.ErrorS(err, "Loading gRPC server certificate")
+		os.Exit(1)
+	}
+	caPEM, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		klog.ErrorS(err, "Reading gRPC client CA bundle")
+		os.Exit(1)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		klog.ErrorS(fmt.Errorf("no certificates in %s", clientCAFile), "Parsing gRPC client CA bundle")
+		os.Exit(1)
+	}
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	})
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		klog.ErrorS(err, "Listening for gRPC", "addr", addr)
+		os.Exit(1)
+	}
+	server := grpc.NewServer(grpc.Creds(creds))
+	topologyv1.RegisterTopologyServer(server, s)
+	klog.InfoS("Serving topology gRPC service", "addr", addr)
+	if err := server.Serve(listener); err != nil {
+		klog.ErrorS(err, "Topology gRPC server failed")
+		os.Exit(1)
+	}
+}
+
+// publish fans one cycle's diff out to every watcher. Slow consumers drop
+// diffs rather than stall the measurement loop; a consumer that must not
+// miss updates should re-sync with GetTopology after a gap.
+func (s *grpcTopologyServer) publish(before, after *v1alpha1.NetworkTopology) {
+	changes := topology.Diff(before, after)
+	if len(changes) == 0 {
+		return
+	}
+	diff := &topologyv1.TopologyDiff{Changes: make([]topologyv1.Change, 0, len(changes))}
+	for _, change := range changes {
+		diff.Changes = append(diff.Changes, topologyv1.Change{
+			Node:   change.Node,
+			Target: change.Target,
+			Field:  change.Field,
+			Old:    change.Old,
+			New:    change.New,
+		})
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, ch := range s.watchers {
+		select {
+		case ch <- diff:
+		default:
+			klog.V(2).InfoS("Dropping topology diff for slow watcher", "watcher", id)
+		}
+	}
+}
+
+func (s *grpcTopologyServer) GetTopology(ctx context.Context, _ *topologyv1.GetTopologyRequest) (*topologyv1.GetTopologyResponse, error) {
+	links, topo, err := s.links()
+	if err != nil {
+		return nil, err
+	}
+	ix := topology.Shared(topo)
+	return &topologyv1.GetTopologyResponse{
+		Links:       links,
+		Epoch:       ix.Epoch(),
+		MixedEpochs: ix.MixedEpochs(),
+	}, nil
+}
+
+func (s *grpcTopologyServer) GetLink(ctx context.Context, req *topologyv1.GetLinkRequest) (*topologyv1.GetLinkResponse, error) {
+	links, _, err := s.links()
+	if err != nil {
+		return nil, err
+	}
+	for i := range links {
+		if links[i].Source == req.Source && links[i].Target == req.Target {
+			return &topologyv1.GetLinkResponse{Link: &links[i]}, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "link %s -> %s is not measured", req.Source, req.Target)
+}
+
+func (s *grpcTopologyServer) WatchTopology(_ *topologyv1.WatchTopologyRequest, stream topologyv1.TopologyService_WatchTopologyServer) error {
+	ch := make(chan *topologyv1.TopologyDiff, 16)
+	s.mu.Lock()
+	id := s.nextID
+	s.nextID++
+	s.watchers[id] = ch
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.watchers, id)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case diff := <-ch:
+			if err := stream.Send(diff); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// links flattens the latest topology into the wire shape, returning the
+// snapshot it read alongside so callers can derive snapshot-level facts.
+func (s *grpcTopologyServer) links() ([]topologyv1.Link, *v1alpha1.NetworkTopology, error) {
+	topo := s.c.latestTopology()
+	if topo == nil {
+		return nil, nil, status.Error(codes.Unavailable, "no measurement cycle has completed yet")
+	}
+	flat := topology.ToLinks(topo)
+	links := make([]topologyv1.Link, 0, len(flat.Spec.Links))
+	for _, link := range flat.Spec.Links {
+		links = append(links, topologyv1.Link{
+			Source:            link.Source,
+			Target:            link.Target,
+			Latency:           link.Latency,
+			Bandwidth:         link.Bandwidth,
+			Cost:              link.Cost,
+			Loss:              link.Loss,
+			Path:              link.Path,
+			Via:               link.Via,
+			MeasurementSource: link.MeasurementSource,
+		})
+	}
+	return links, topo, nil
+}
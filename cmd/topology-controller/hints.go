@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// Annotations steering the EndpointSlice hints reconciler.
+const (
+	// annotationRouteAware opts a Service into cost-derived topology hints.
+	annotationRouteAware = "network.komarov.dev/route-aware"
+	// annotationHintsManaged marks slices whose hints we wrote, so opt-out
+	// cleans up exactly what we touched.
+	annotationHintsManaged = "network.komarov.dev/hints-managed"
+
+	// The built-in TopologyAwareHints controller's switches; when either is
+	// active for a Service we must not fight it.
+	annotationTopologyMode  = "service.kubernetes.io/topology-mode"
+	annotationTopologyHints = "service.kubernetes.io/topology-aware-hints"
+
+	// serviceNameLabel ties an EndpointSlice to its Service.
+	serviceNameLabel = "kubernetes.io/service-name"
+)
+
+// runHintsLoop periodically reconciles hints for opted-in Services. Polling
+// covers both change sources — EndpointSlices move and the topology is
+// rewritten each measurement cycle — with staleness bounded by the interval,
+// matching the controller's poll-everything style.
+func (c *controller) runHintsLoop(ctx context.Context) {
+	klog.InfoS("Starting EndpointSlice hints reconciler", "interval", c.opts.hintsInterval)
+	for {
+		if err := c.reconcileHints(ctx); err != nil {
+			klog.ErrorS(err, "Reconciling EndpointSlice hints")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.opts.hintsInterval):
+		}
+	}
+}
+
+// reconcileHints applies cost-derived hints to the slices of every opted-in
+// Service and clears hints from slices we managed for Services that opted
+// back out.
+func (c *controller) reconcileHints(ctx context.Context) error {
+	topo := c.latestTopology()
+	if topo == nil {
+		// No measurement cycle has completed yet.
+		return nil
+	}
+	services, err := c.client.CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("listing services: %w", err)
+	}
+	for i := range services.Items {
+		svc := &services.Items[i]
+		optedIn := svc.Annotations[annotationRouteAware] == "true"
+		if optedIn && builtinHintsEnabled(svc.Annotations) {
+			klog.V(2).InfoS("Refusing to manage hints: built-in TopologyAwareHints is enabled",
+				"service", klog.KObj(svc))
+			optedIn = false
+		}
+
+		slices, err := c.client.DiscoveryV1().EndpointSlices(svc.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: serviceNameLabel + "=" + svc.Name,
+		})
+		if err != nil {
+			klog.ErrorS(err, "Listing EndpointSlices", "service", klog.KObj(svc))
+			continue
+		}
+		for j := range slices.Items {
+			slice := &slices.Items[j]
+			var changed bool
+			if optedIn {
+				changed = applyHints(slice, topo)
+			} else {
+				changed = clearManagedHints(slice)
+			}
+			if !changed {
+				continue
+			}
+			if _, err := c.client.DiscoveryV1().EndpointSlices(svc.Namespace).Update(ctx, slice, metav1.UpdateOptions{}); err != nil {
+				klog.ErrorS(err, "Updating EndpointSlice hints", "slice", klog.KObj(slice))
+			}
+		}
+	}
+	return nil
+}
+
+// builtinHintsEnabled reports whether kube's own hints controller is active
+// for the Service.
+func builtinHintsEnabled(annotations map[string]string) bool {
+	switch annotations[annotationTopologyMode] {
+	case "", "Disabled", "disabled":
+	default:
+		return true
+	}
+	switch annotations[annotationTopologyHints] {
+	case "Auto", "auto":
+		return true
+	}
+	return false
+}
+
+// applyHints rewrites the slice's per-endpoint ForZones from the measured
+// topology: each client zone is routed to the endpoint zone it reaches most
+// cheaply, so every zone resolves to at least one endpoint. Returns whether
+// the slice changed.
+func applyHints(slice *discoveryv1.EndpointSlice, topo *v1alpha1.NetworkTopology) bool {
+	endpointZones := map[string]bool{}
+	for i := range slice.Endpoints {
+		if zone := endpointZone(&slice.Endpoints[i], topo); zone != "" {
+			endpointZones[zone] = true
+		}
+	}
+	if len(endpointZones) == 0 {
+		return clearManagedHints(slice)
+	}
+	assignment := assignClientZones(topo, endpointZones)
+
+	changed := false
+	for i := range slice.Endpoints {
+		ep := &slice.Endpoints[i]
+		zone := endpointZone(ep, topo)
+		var forZones []discoveryv1.ForZone
+		for _, client := range assignment[zone] {
+			forZones = append(forZones, discoveryv1.ForZone{Name: client})
+		}
+		var hints *discoveryv1.EndpointHints
+		if len(forZones) > 0 {
+			hints = &discoveryv1.EndpointHints{ForZones: forZones}
+		}
+		if !reflect.DeepEqual(ep.Hints, hints) {
+			ep.Hints = hints
+			changed = true
+		}
+	}
+	if slice.Annotations[annotationHintsManaged] != "true" {
+		if slice.Annotations == nil {
+			slice.Annotations = map[string]string{}
+		}
+		slice.Annotations[annotationHintsManaged] = "true"
+		changed = true
+	}
+	return changed
+}
+
+// clearManagedHints removes hints we previously wrote; slices never touched
+// by us are left alone. Returns whether the slice changed.
+func clearManagedHints(slice *discoveryv1.EndpointSlice) bool {
+	if slice.Annotations[annotationHintsManaged] != "true" {
+		return false
+	}
+	for i := range slice.Endpoints {
+		slice.Endpoints[i].Hints = nil
+	}
+	delete(slice.Annotations, annotationHintsManaged)
+	return true
+}
+
+// endpointZone resolves an endpoint's zone, preferring the recorded zone and
+// falling back to its node's zone in the topology.
+func endpointZone(ep *discoveryv1.Endpoint, topo *v1alpha1.NetworkTopology) string {
+	if ep.Zone != nil && *ep.Zone != "" {
+		return *ep.Zone
+	}
+	if ep.NodeName != nil {
+		return topo.Spec.Nodes[*ep.NodeName].Zone
+	}
+	return ""
+}
+
+// assignClientZones maps each endpoint zone to the client zones it should
+// serve: every zone in the topology is assigned to the endpoint zone with the
+// lowest measured zone-to-zone cost, its own zone costing nothing.
+func assignClientZones(topo *v1alpha1.NetworkTopology, endpointZones map[string]bool) map[string][]string {
+	clientZones := map[string]bool{}
+	for _, spec := range topo.Spec.Nodes {
+		if spec.Zone != "" {
+			clientZones[spec.Zone] = true
+		}
+	}
+	assignment := map[string][]string{}
+	for client := range clientZones {
+		best, bestCost := "", 0.0
+		for target := range endpointZones {
+			cost := zoneCost(topo, client, target)
+			if best == "" || cost < bestCost {
+				best, bestCost = target, cost
+			}
+		}
+		assignment[best] = append(assignment[best], client)
+	}
+	for zone := range assignment {
+		sort.Strings(assignment[zone])
+	}
+	return assignment
+}
+
+// zoneCost averages the measured link costs between two zones' nodes; the
+// same zone costs 0 and unmeasured pairs cost the maximum.
+func zoneCost(topo *v1alpha1.NetworkTopology, from, to string) float64 {
+	if from == to {
+		return 0
+	}
+	var total float64
+	var count int
+	for _, source := range topo.Spec.Nodes {
+		if source.Zone != from {
+			continue
+		}
+		for target, cost := range source.Cost {
+			if topo.Spec.Nodes[target].Zone == to {
+				total += cost
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return 1
+	}
+	return total / float64(count)
+}
+
+// latestTopology returns the topology written by the most recent measurement
+// cycle, or nil before the first one.
+func (c *controller) latestTopology() *v1alpha1.NetworkTopology {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastTopo
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// An importer seeds the topology from an external source of truth. Fields the
+// source declares are pinned: measurement refines only what the source left
+// open, and conflicting measurements are recorded beside the declared value
+// instead of overwriting it.
+type importer interface {
+	// declared returns the externally declared topology, keyed by node
+	// name. Errors degrade to measurement-only operation for the cycle.
+	declared(ctx context.Context) (map[string]declaredNode, error)
+}
+
+// declaredNode is one node's externally declared view.
+type declaredNode struct {
+	// Zone and Capabilities seed the corresponding NodeSpec fields.
+	Zone         string   `json:"zone,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	// Links declares per-target link characteristics.
+	Links map[string]declaredLink `json:"links,omitempty"`
+}
+
+// declaredLink pins the fields it sets; empty fields stay measurement-owned.
+type declaredLink struct {
+	Bandwidth string `json:"bandwidth,omitempty"`
+	Latency   string `json:"latency,omitempty"`
+}
+
+// newImporter parses an --import-source value. The empty string disables
+// importing.
+func newImporter(source, mappingPath string) (importer, error) {
+	switch {
+	case source == "":
+		return nil, nil
+	case strings.HasPrefix(source, "file:"):
+		return &fileImporter{path: strings.TrimPrefix(source, "file:")}, nil
+	case strings.HasPrefix(source, "netbox:"):
+		imp := &netboxImporter{
+			url:   strings.TrimPrefix(source, "netbox:"),
+			token: os.Getenv("NETBOX_TOKEN"),
+		}
+		if mappingPath != "" {
+			data, err := os.ReadFile(mappingPath)
+			if err != nil {
+				return nil, fmt.Errorf("reading import mapping: %w", err)
+			}
+			if err := yaml.Unmarshal(data, &imp.deviceToNode); err != nil {
+				return nil, fmt.Errorf("parsing import mapping: %w", err)
+			}
+		}
+		return imp, nil
+	default:
+		return nil, fmt.Errorf("unsupported --import-source %q: want file:<path> or netbox:<url>", source)
+	}
+}
+
+// fileImporter reads a YAML inventory of declared nodes, re-parsing whenever
+// the file's modification time changes. Parse failures keep the previous
+// declaration so a half-written file cannot wipe pinned values mid-cycle.
+type fileImporter struct {
+	path string
+
+	mtime  time.Time
+	cached map[string]declaredNode
+}
+
+func (f *fileImporter) declared(context.Context) (map[string]declaredNode, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return f.cached, err
+	}
+	if f.cached != nil && info.ModTime().Equal(f.mtime) {
+		return f.cached, nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return f.cached, err
+	}
+	var parsed struct {
+		Nodes map[string]declaredNode `json:"nodes"`
+	}
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return f.cached, fmt.Errorf("parsing %s: %w", f.path, err)
+	}
+	f.cached = parsed.Nodes
+	f.mtime = info.ModTime()
+	klog.V(2).InfoS("Loaded declared topology", "path", f.path, "nodes", len(parsed.Nodes))
+	return f.cached, nil
+}
+
+// netboxImporter reads WAN circuits from a NetBox instance. Circuits carry
+// their contracted characteristics in the "bandwidth" and "latency" custom
+// fields; terminations name the devices, which the mapping translates to
+// node names (unmapped devices pass through unchanged).
+type netboxImporter struct {
+	url          string
+	token        string
+	deviceToNode map[string]string
+}
+
+type netboxTermination struct {
+	Device struct {
+		Name string `json:"name"`
+	} `json:"device"`
+}
+
+type netboxCircuit struct {
+	CID          string `json:"cid"`
+	CustomFields struct {
+		Bandwidth string `json:"bandwidth"`
+		Latency   string `json:"latency"`
+	} `json:"custom_fields"`
+	TerminationA *netboxTermination `json:"termination_a"`
+	TerminationZ *netboxTermination `json:"termination_z"`
+}
+
+func (n *netboxImporter) declared(ctx context.Context) (map[string]declaredNode, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(n.url, "/")+"/api/circuits/circuits/?status=active&limit=0", nil)
+	if err != nil {
+		return nil, err
+	}
+	if n.token != "" {
+		req.Header.Set("Authorization", "Token "+n.token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("netbox: %s", resp.Status)
+	}
+
+	var payload struct {
+		Results []netboxCircuit `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("netbox: decoding circuits: %w", err)
+	}
+
+	declared := map[string]declaredNode{}
+	for _, circuit := range payload.Results {
+		if circuit.TerminationA == nil || circuit.TerminationZ == nil {
+			continue
+		}
+		a := n.nodeName(circuit.TerminationA.Device.Name)
+		z := n.nodeName(circuit.TerminationZ.Device.Name)
+		if a == "" || z == "" || a == z {
+			continue
+		}
+		link := declaredLink{
+			Bandwidth: circuit.CustomFields.Bandwidth,
+			Latency:   circuit.CustomFields.Latency,
+		}
+		// Circuits are symmetric; declare both directions.
+		addDeclaredLink(declared, a, z, link)
+		addDeclaredLink(declared, z, a, link)
+	}
+	return declared, nil
+}
+
+func (n *netboxImporter) nodeName(device string) string {
+	if mapped, ok := n.deviceToNode[device]; ok {
+		return mapped
+	}
+	return device
+}
+
+func addDeclaredLink(declared map[string]declaredNode, source, target string, link declaredLink) {
+	node := declared[source]
+	if node.Links == nil {
+		node.Links = map[string]declaredLink{}
+	}
+	node.Links[target] = link
+	declared[source] = node
+}
+
+// importConflict is one pinned link whose measurement disagrees with the
+// declaration badly enough to report.
+type importConflict struct {
+	source, target string
+	field          string
+	declared       string
+	measured       string
+}
+
+// applyDeclared overlays one node's declaration onto its measured spec.
+// Declared values win; what measurement saw on pinned links moves into the
+// MeasuredBandwidth/MeasuredLatency side fields, and gross disagreements are
+// returned for Event reporting.
+func applyDeclared(spec *v1alpha1.NodeSpec, source string, decl declaredNode) []importConflict {
+	if decl.Zone != "" {
+		spec.Zone = decl.Zone
+	}
+	for _, capability := range decl.Capabilities {
+		spec.Capabilities = appendCapability(spec.Capabilities, capability)
+	}
+
+	var conflicts []importConflict
+	for target, link := range decl.Links {
+		if link.Bandwidth != "" {
+			if measured, ok := spec.Bandwidth[target]; ok {
+				if spec.MeasuredBandwidth == nil {
+					spec.MeasuredBandwidth = map[string]string{}
+				}
+				spec.MeasuredBandwidth[target] = measured
+				if bandwidthConflicts(link.Bandwidth, measured) {
+					conflicts = append(conflicts, importConflict{
+						source: source, target: target, field: "bandwidth",
+						declared: link.Bandwidth, measured: measured,
+					})
+				}
+			}
+			spec.Bandwidth[target] = link.Bandwidth
+		}
+		if link.Latency != "" {
+			if measured, ok := spec.Latency[target]; ok {
+				if spec.MeasuredLatency == nil {
+					spec.MeasuredLatency = map[string]string{}
+				}
+				spec.MeasuredLatency[target] = measured
+				if latencyConflicts(link.Latency, measured) {
+					conflicts = append(conflicts, importConflict{
+						source: source, target: target, field: "latency",
+						declared: link.Latency, measured: measured,
+					})
+				}
+			}
+			spec.Latency[target] = link.Latency
+		}
+	}
+	return conflicts
+}
+
+// bandwidthConflicts reports a link delivering less than half its declared
+// bandwidth; small shortfalls are expected measurement noise.
+func bandwidthConflicts(declared, measured string) bool {
+	d, errD := units.ParseBandwidth(declared)
+	m, errM := units.ParseBandwidth(measured)
+	return errD == nil && errM == nil && m < d/2
+}
+
+// latencyConflicts reports a link measuring more than twice its declared
+// latency.
+func latencyConflicts(declared, measured string) bool {
+	d, errD := units.ParseLatency(declared)
+	m, errM := units.ParseLatency(measured)
+	return errD == nil && errM == nil && d > 0 && m > 2*d
+}
+
+// reportImportConflicts emits a Warning Event per newly conflicting link;
+// links that stay in conflict are not re-reported every cycle.
+func (c *controller) reportImportConflicts(ctx context.Context, conflicts []importConflict) {
+	c.mu.Lock()
+	if c.reportedConflicts == nil {
+		c.reportedConflicts = map[string]bool{}
+	}
+	current := map[string]bool{}
+	var fresh []importConflict
+	for _, conflict := range conflicts {
+		key := conflict.source + "/" + conflict.target + "/" + conflict.field
+		current[key] = true
+		if !c.reportedConflicts[key] {
+			fresh = append(fresh, conflict)
+		}
+	}
+	c.reportedConflicts = current
+	c.mu.Unlock()
+
+	for _, conflict := range fresh {
+		klog.InfoS("Declared link conflicts with measurement",
+			"source", conflict.source, "target", conflict.target, "field", conflict.field,
+			"declared", conflict.declared, "measured", conflict.measured)
+		now := metav1.Now()
+		event := &v1.Event{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "networktopology-import-",
+				Namespace:    metav1.NamespaceDefault,
+			},
+			InvolvedObject: v1.ObjectReference{
+				APIVersion: v1alpha1.SchemeGroupVersion.String(),
+				Kind:       "NetworkTopology",
+				Name:       topologyName,
+			},
+			Reason: "ImportConflict",
+			Message: fmt.Sprintf("link %s->%s: declared %s %s but measured %s",
+				conflict.source, conflict.target, conflict.field, conflict.declared, conflict.measured),
+			Type:           v1.EventTypeWarning,
+			Source:         v1.EventSource{Component: "topology-controller"},
+			FirstTimestamp: now,
+			LastTimestamp:  now,
+			Count:          1,
+		}
+		if _, err := c.client.CoreV1().Events(event.Namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+			klog.V(2).InfoS("Failed to record import conflict event", "err", err)
+		}
+	}
+}
@@ -0,0 +1,1431 @@
+// Command topology-controller measures the network between cluster nodes and
+// publishes the result as the NetworkTopology object consumed by the
+// NetworkAware scheduler plugin. Latency is probed with ping (tailscale ping
+// for tailnet peers, so the WireGuard path itself is measured), bandwidth with
+// iperf3 against the per-node agents, and Tailscale peers are resolved via
+// "tailscale status" so the measured path matches inter-node pod traffic.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/conversion"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/export"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/features"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/logging"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/measure"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/tracing"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/version"
+)
+
+// tracer emits cycle → pair → probe spans; a no-op unless tracing is enabled.
+var tracer = otel.Tracer("topology-controller")
+
+const topologyName = "cluster"
+
+type options struct {
+	kubeconfig    string
+	interval      time.Duration
+	pingCount     int
+	latencyStats  bool
+	iperfSeconds  int
+	iperfStreams  int
+	iperfOmit     int
+	reverseBW     bool
+	once          bool
+	writeLinks    bool
+	hints         bool
+	hintsInterval time.Duration
+	quotaStatus   bool
+	quotaInterval time.Duration
+	importSource  string
+	importMapping string
+
+	clusterName       string
+	remoteKubeconfigs string
+	featureGates      string
+
+	tracingStdout bool
+	tracingSample float64
+
+	auditLog       string
+	auditMaxSizeMB int
+
+	exportFile      string
+	exportMaxSizeMB int
+	exportMaxAge    time.Duration
+
+	alertRules   string
+	alertWebhook string
+
+	peerResolver  string
+	addressPolicy string
+	latencyProber string
+	metricsAddr   string
+	pairSampler   string
+	cacheFile     string
+	cacheTTL      time.Duration
+
+	kubeAPIQPS   float64
+	kubeAPIBurst int
+
+	advertiseBandwidth bool
+	domainThreshold    string
+	offClusterWrites   string
+	probeVantage       string
+	overlayNetns       string
+	probeJobImage      string
+
+	conversionAddr     string
+	conversionCertFile string
+	conversionKeyFile  string
+
+	grpcAddr         string
+	grpcCertFile     string
+	grpcKeyFile      string
+	grpcClientCAFile string
+
+	healthLatencyAnchor   string
+	healthBandwidthAnchor string
+	healthLatencyWeight   float64
+	healthBandwidthWeight float64
+
+	costLatencyAnchor   string
+	costBandwidthAnchor string
+	costLatencyWeight   float64
+	costBandwidthWeight float64
+
+	zoneLabel   string
+	regionLabel string
+	roleLabel   string
+	gpuLabel    string
+	// instanceTypeCapability records the standard instance-type label as a
+	// capability so pods can require a machine shape.
+	instanceTypeCapability bool
+	// capabilityRules names a ConfigMap replacing the built-in capability
+	// inference table; empty keeps the defaults.
+	capabilityRules string
+	// externalEndpoints names a ConfigMap declaring non-node endpoints
+	// (NFS, S3) whose node→endpoint links are measured and published.
+	externalEndpoints string
+	// logFormat selects text or JSON log output; see pkg/logging.
+	logFormat string
+}
+
+// labelKeys bundles the label-key flags into the shared reading helpers.
+func (o options) labelKeys() requirements.LabelKeys {
+	return requirements.LabelKeys{Zone: o.zoneLabel, Region: o.regionLabel, Role: o.roleLabel, GPU: o.gpuLabel}
+}
+
+// healthModel parses and validates the health-model flags.
+func (o options) healthModel() (measure.HealthModel, error) {
+	model := measure.DefaultHealthModel()
+	if o.healthLatencyAnchor != "" {
+		d, err := units.ParseLatency(o.healthLatencyAnchor)
+		if err != nil {
+			return model, fmt.Errorf("invalid --health-latency-anchor: %w", err)
+		}
+		model.LatencyAnchor = d
+	}
+	if o.healthBandwidthAnchor != "" {
+		bps, err := units.ParseBandwidth(o.healthBandwidthAnchor)
+		if err != nil {
+			return model, fmt.Errorf("invalid --health-bandwidth-anchor: %w", err)
+		}
+		model.BandwidthAnchor = bps
+	}
+	if o.healthLatencyWeight != 0 {
+		model.LatencyWeight = o.healthLatencyWeight
+	}
+	if o.healthBandwidthWeight != 0 {
+		model.BandwidthWeight = o.healthBandwidthWeight
+	}
+	return model, model.Validate()
+}
+
+// costModel parses and validates the cost-model flags.
+func (o options) costModel() (measure.CostModel, error) {
+	model := measure.DefaultCostModel()
+	if o.costLatencyAnchor != "" {
+		d, err := units.ParseLatency(o.costLatencyAnchor)
+		if err != nil {
+			return model, fmt.Errorf("invalid --cost-latency-anchor: %w", err)
+		}
+		model.LatencyAnchor = d
+	}
+	if o.costBandwidthAnchor != "" {
+		bps, err := units.ParseBandwidth(o.costBandwidthAnchor)
+		if err != nil {
+			return model, fmt.Errorf("invalid --cost-bandwidth-anchor: %w", err)
+		}
+		model.BandwidthAnchor = bps
+	}
+	if o.costLatencyWeight != 0 {
+		model.LatencyWeight = o.costLatencyWeight
+	}
+	if o.costBandwidthWeight != 0 {
+		model.BandwidthWeight = o.costBandwidthWeight
+	}
+	return model, model.Validate()
+}
+
+// iperf bundles the bandwidth probe flags into the prober's parameter set.
+func (o options) iperf() measure.IperfParams {
+	return measure.IperfParams{Streams: o.iperfStreams, Seconds: o.iperfSeconds, OmitSeconds: o.iperfOmit}
+}
+
+// controller holds the long-lived measurement state.
+type controller struct {
+	opts    options
+	client  kubernetes.Interface
+	dynamic dynamic.Interface
+
+	// smoothed holds EWMA state per source/target pair; lastTopo is the
+	// topology written by the most recent cycle, consumed by the hints
+	// reconciler.
+	// importer, when set, seeds declared topology values each cycle.
+	importer importer
+	// remotes are the federated peer clusters whose nodes are merged into
+	// the combined topology.
+	remotes []remoteCluster
+	// audit, when set, records field-level topology changes per cycle.
+	audit *auditor
+	// export, when set, appends one link-sample row per measured link
+	// after each cycle for offline analysis.
+	export *export.Sink
+	// resolver maps node names to probe addresses; selected once at
+	// startup and reported in the controller's status output.
+	resolver peerResolver
+	// addrPolicy is the parsed global address-selection policy, fixed at
+	// startup; per-node annotations override it.
+	addrPolicy []string
+	// grpc, when set, streams cycle diffs to gRPC subscribers.
+	grpc *grpcTopologyServer
+	// sampler selects which node pairs each cycle measures.
+	sampler pairSampler
+	// trigger queues on-demand re-measure requests, buffered one deep so
+	// at most one trigger is outstanding.
+	trigger chan triggerRequest
+	// alerts carries alert firing state between cycles; the rules
+	// themselves are re-read from the ConfigMap every evaluation.
+	alerts alertEngine
+	// batchLatency holds the current cycle's fping sweep results by probe
+	// address; nil when the cycle pings per pair. Only the cycle goroutine
+	// touches it.
+	batchLatency map[string]time.Duration
+	// domainMs is the parsed --domain-label-threshold (0 disables domain
+	// labeling); domainCandidate and domainStreak track how many
+	// consecutive cycles each node has resolved to a new domain, the
+	// hysteresis before its label changes. Only the cycle goroutine
+	// touches them.
+	domainMs        int64
+	domainCandidate map[string]string
+	domainStreak    map[string]int
+	// lastExternal is the external endpoint set published by the previous
+	// cycle, kept to prune removed endpoints' links and to ride out a
+	// transient ConfigMap fetch failure. Only the cycle goroutine touches
+	// it.
+	lastExternal []externalEndpoint
+
+	// recorder emits Node events for maintenance transitions; nil in
+	// tests.
+	recorder record.EventRecorder
+	// health is the validated anchor/weight model scores are computed
+	// against, recorded in Status.HealthModel.
+	health measure.HealthModel
+	// cost is the validated anchor/weight model link costs are computed
+	// against, recorded in Status.CostModel.
+	cost measure.CostModel
+
+	mu                sync.Mutex
+	smoothed          map[string]map[string]*linkState
+	lastTopo          *v1alpha1.NetworkTopology
+	reportedConflicts map[string]bool
+	// lastMaintenance is the previous cycle's set of maintenance nodes,
+	// kept to detect transitions.
+	lastMaintenance map[string]bool
+	// nodeUIDs is each member node's object UID at the previous cycle. A
+	// name reappearing with a different UID is a recreated machine, not the
+	// node the smoothed history was measured against.
+	nodeUIDs map[string]types.UID
+	// lastEndpoints is each peer's NAT traversal state at the previous
+	// cycle; endpointChanges counts changes per node for /metrics.
+	lastEndpoints   map[string]peerEndpoint
+	endpointChanges map[string]int64
+	// transit maps nodes reached through a subnet router to that router.
+	transit map[string]string
+	// Address failover state: addrCandidates is how many usable addresses
+	// each node offered at its last selection, addrFailures counts
+	// consecutive unreachable probes on the current address, and addrSkip
+	// counts failover rotations applied on top of the policy order.
+	addrCandidates map[string]int
+	addrFailures   map[string]int
+	addrSkip       map[string]int
+	// addrIface names the interface each node's probe address was selected
+	// for, when the probe-interface annotation pinned it.
+	addrIface map[string]string
+	// stats is the last completed cycle's bookkeeping for /debug/status.
+	stats cycleStats
+	// remeasureScope, when set, restricts the next cycle to pairs touching
+	// these nodes.
+	remeasureScope []string
+	// epoch is the monotonic measurement-cycle counter published in
+	// Status.Epoch, touched only by the cycle loop; 0 until the first
+	// cycle resumes it from the last written object.
+	epoch int64
+
+	// Vantage detection, performed once on the first cycle: localNode is
+	// the cluster node the controller runs on, empty when it runs
+	// off-cluster (the dev-laptop flow).
+	vantageOnce sync.Once
+	localNode   string
+	offCluster  bool
+	// vantage is the network namespace probes run in ("host" or
+	// "overlay"), resolved once at startup and recorded in the spec.
+	vantage string
+	// probeJobLast is when each source node's last ad-hoc probe Job was
+	// created, enforcing the per-node cooldown.
+	probeJobLast map[string]time.Time
+}
+
+// linkState is the smoothed view of one directed link.
+type linkState struct {
+	latency   time.Duration
+	bandwidth int64 // transmit bits per second toward the target
+	// bandwidthRx is the receive direction (iperf3 -R); zero unless
+	// reverse measurement is enabled.
+	bandwidthRx int64
+	// bandwidthUnsupported marks targets that cannot run the iperf3 agent
+	// (a non-linux kubernetes.io/os label): no bandwidth exists by design,
+	// which is different from a probe that failed.
+	bandwidthUnsupported bool
+	measured             bool
+	lastProbe            time.Time // when a probe last succeeded for this link
+	// path is the route the tailscale prober reported ("direct" or
+	// "derp-<region>"); empty for ICMP-probed links.
+	path string
+	// overlayOverhead is the smoothed extra round-trip latency the CNI
+	// overlay adds over the host path; zero unless --overlay-netns is set.
+	overlayOverhead time.Duration
+	// stats is the last probe's raw sample distribution, deliberately
+	// unsmoothed: an EWMA over max would hide exactly the spikes the
+	// statistics exist to expose. Zero unless --latency-stats is set.
+	stats measure.LatencyStats
+}
+
+// statsPingCount replaces the default ping count when latency statistics are
+// enabled.
+const statsPingCount = 10
+
+func main() {
+	opts := options{}
+	flag.StringVar(&opts.kubeconfig, "kubeconfig", "", "Path to kubeconfig; uses in-cluster config when empty.")
+	flag.DurationVar(&opts.interval, "interval", 60*time.Second, "Time between measurement cycles.")
+	flag.IntVar(&opts.pingCount, "ping-count", 3, "ICMP echo requests per latency probe.")
+	flag.BoolVar(&opts.latencyStats, "latency-stats", false, "Record each link's min/avg/max/mdev latency distribution; raises the default --ping-count to 10 so the statistics mean something.")
+	flag.IntVar(&opts.iperfSeconds, "iperf-duration", 3, "Seconds per iperf3 bandwidth probe.")
+	flag.IntVar(&opts.iperfStreams, "iperf-streams", 1, "Parallel iperf3 streams per bandwidth probe; more than one saturates high-latency links a single TCP stream cannot.")
+	flag.IntVar(&opts.iperfOmit, "iperf-omit", 0, "Initial seconds of each iperf3 probe to ignore, skipping TCP slow start.")
+	flag.BoolVar(&opts.reverseBW, "measure-reverse-bandwidth", false, "Also measure receive throughput with iperf3 -R, recorded in the bandwidthRx maps; doubles bandwidth probe time.")
+	flag.BoolVar(&opts.once, "once", false, "Run a single measurement cycle and exit.")
+	flag.BoolVar(&opts.writeLinks, "write-links", false, "Write measurements as explicit spec.links entries instead of per-node maps.")
+	flag.StringVar(&opts.importSource, "import-source", "", "External source of truth seeding the topology: file:<path> or netbox:<url>; empty disables.")
+	flag.StringVar(&opts.importMapping, "import-mapping", "", "YAML file mapping external device names to node names (netbox source).")
+	flag.StringVar(&opts.clusterName, "cluster-name", "", "Name recorded as the local nodes' cluster in a federated topology; empty for single-cluster deployments.")
+	flag.StringVar(&opts.remoteKubeconfigs, "remote-kubeconfigs", "", "Comma-separated kubeconfig paths of remote clusters to federate into the topology.")
+	flag.BoolVar(&opts.tracingStdout, "tracing-stdout", false, "Print OpenTelemetry spans to stdout instead of exporting via OTLP.")
+	flag.Float64Var(&opts.tracingSample, "tracing-sample-ratio", 1.0, "Fraction of traces to sample when tracing is enabled.")
+	flag.StringVar(&opts.auditLog, "audit-log", "", `File to append JSON-lines topology change records to ("-" for stdout); empty disables.`)
+	flag.IntVar(&opts.auditMaxSizeMB, "audit-log-max-size", 64, "Rotate the audit log when it exceeds this many megabytes.")
+	flag.StringVar(&opts.exportFile, "export-file", "", "File to append JSON-lines link-sample rows to for offline analysis; empty disables. Read it back with \"topologyctl export\".")
+	flag.IntVar(&opts.exportMaxSizeMB, "export-max-size", 64, "Rotate the export file when it exceeds this many megabytes; one predecessor is kept.")
+	flag.DurationVar(&opts.exportMaxAge, "export-max-age", 7*24*time.Hour, "Remove export files untouched for longer than this on startup; 0 keeps everything.")
+	flag.StringVar(&opts.alertRules, "alert-rules", "", "ConfigMap (in the controller's namespace) whose \"rules\" key holds YAML alert rules, re-read every cycle; empty disables alerting.")
+	flag.StringVar(&opts.alertWebhook, "alert-webhook", "", "URL POSTed a JSON notification when an alert rule fires or resolves; empty disables.")
+	flag.StringVar(&opts.peerResolver, "peer-resolver", "auto", "How to resolve probe addresses: auto, tailscale, wireguard or none.")
+	flag.StringVar(&opts.addressPolicy, "address-policy", defaultAddressPolicy, "Ordered, comma-separated address classes to probe nodes at: tailscale, internal-v4, internal-v6, external. Overridable per node with the "+requirements.AnnotationAddressPolicy+" annotation.")
+	flag.StringVar(&opts.latencyProber, "latency-prober", "auto", "Latency probe implementation: auto (tailscale ping for tailnet peers, ICMP otherwise), icmp or tailscale.")
+	flag.StringVar(&opts.metricsAddr, "metrics-addr", "", "Address to serve Prometheus metrics on (e.g. :9090); empty disables.")
+	flag.StringVar(&opts.pairSampler, "pair-sampler", "full-mesh", "Which node pairs to measure: full-mesh, zone-representative, or hub-spoke.")
+	flag.StringVar(&opts.healthLatencyAnchor, "health-latency-anchor", "", `Latency that zeroes the health score's latency component (default "200ms"); raise it on all-WAN clusters so the score spans its useful range.`)
+	flag.StringVar(&opts.healthBandwidthAnchor, "health-bandwidth-anchor", "", `Bandwidth that maxes the health score's bandwidth component (default "1gbps").`)
+	flag.Float64Var(&opts.healthLatencyWeight, "health-latency-weight", 0, "Weight of the latency component in the health score (default 0.4); must sum to 1 with --health-bandwidth-weight.")
+	flag.Float64Var(&opts.healthBandwidthWeight, "health-bandwidth-weight", 0, "Weight of the bandwidth component in the health score (default 0.6).")
+	flag.StringVar(&opts.costLatencyAnchor, "cost-latency-anchor", "", `Latency at which a link's cost latency component saturates (default "200ms"); raise it on all-WAN clusters so costs span their useful range.`)
+	flag.StringVar(&opts.costBandwidthAnchor, "cost-bandwidth-anchor", "", `Bandwidth that zeroes a link's cost bandwidth component (default "1gbps").`)
+	flag.Float64Var(&opts.costLatencyWeight, "cost-latency-weight", 0, "Weight of the latency component in link costs (default 0.5); must sum to 1 with --cost-bandwidth-weight.")
+	flag.Float64Var(&opts.costBandwidthWeight, "cost-bandwidth-weight", 0, "Weight of the bandwidth component in link costs (default 0.5).")
+	flag.StringVar(&opts.zoneLabel, "zone-label", "", `Comma-separated precedence of node labels carrying the zone; defaults to "zone,topology.kubernetes.io/zone".`)
+	flag.StringVar(&opts.regionLabel, "region-label", "", `Comma-separated precedence of node labels carrying the region; defaults to "region,topology.kubernetes.io/region".`)
+	flag.StringVar(&opts.roleLabel, "role-label", "", `Comma-separated precedence of node labels carrying the role used for capability inference; defaults to "role".`)
+	flag.StringVar(&opts.gpuLabel, "gpu-label", "", `Comma-separated precedence of node labels marking GPU nodes for capability inference; defaults to "gpu".`)
+	flag.BoolVar(&opts.instanceTypeCapability, "instance-type-capability", false, "Record node.kubernetes.io/instance-type as an instance-type:<type> capability.")
+	flag.StringVar(&opts.capabilityRules, "capability-rules", "", "ConfigMap (in the controller's namespace) whose \"rules\" key holds YAML capability inference rules, re-read every cycle; empty uses the built-in table covering the bespoke labels, the GPU operator and Longhorn.")
+	flag.StringVar(&opts.externalEndpoints, "external-endpoints", "", "ConfigMap (in the controller's namespace) whose \"endpoints\" key holds a YAML list of external endpoints (name, address, zone) to measure node links against, published under external/<name>; empty declares none.")
+	flag.StringVar(&opts.cacheFile, "measurement-cache", "", "File to persist smoothed measurement state across restarts; empty disables.")
+	flag.DurationVar(&opts.cacheTTL, "measurement-cache-ttl", time.Hour, "Discard cached measurements older than this at startup.")
+	flag.Float64Var(&opts.kubeAPIQPS, "kube-api-qps", 0, "Sustained queries per second against the API server; 0 keeps the client default.")
+	flag.IntVar(&opts.kubeAPIBurst, "kube-api-burst", 0, "Burst allowance on top of --kube-api-qps; 0 keeps the client default.")
+	flag.BoolVar(&opts.advertiseBandwidth, "advertise-bandwidth-resource", false, "Advertise each node's bandwidth as the network.komarov.dev/bandwidth extended resource (in mbps).")
+	flag.StringVar(&opts.domainThreshold, "domain-label-threshold", "", `Latency (e.g. "30ms") under which nodes share a network domain: each local node is labeled `+requirements.LabelDomain+` with its domain's stable ID, usable in topologySpreadConstraints. Empty disables labeling and removes labels this controller applied.`)
+	flag.StringVar(&opts.probeVantage, "probe-vantage", "auto", `Network namespace probes run in, recorded in the topology: "host", "overlay", or "auto" to detect it from whether the hostname matches NODE_NAME (hostNetwork pods inherit the node's).`)
+	flag.StringVar(&opts.overlayNetns, "overlay-netns", "", "Network namespace path (e.g. /var/run/netns/cni-...) to additionally probe latency through via nsenter when running with hostNetwork, publishing each link's overlay overhead; empty disables. Requires CAP_SYS_ADMIN.")
+	flag.StringVar(&opts.probeJobImage, "probe-job-image", "", "Image for ad-hoc probe Jobs launched by the measure annotation (kubectl networktopology measure); needs ping in PATH, so the controller's own image works. Empty disables probe Jobs.")
+	flag.StringVar(&opts.offClusterWrites, "off-cluster-writes", offClusterWriteRefuse, `What to do with measurements when the controller is not running on a cluster node (NODE_NAME or the hostname matches no node): "refuse" publishes no measurements, "external" records them under a synthetic "`+externalSourceName+`" source entry clearly separated from real nodes.`)
+	flag.BoolVar(&opts.hints, "endpointslice-hints", false, "Write topology hints on EndpointSlices of route-aware Services.")
+	flag.DurationVar(&opts.hintsInterval, "hints-interval", 30*time.Second, "Time between EndpointSlice hint reconciliations.")
+	flag.BoolVar(&opts.quotaStatus, "quota-status", false, "Reconcile NetworkQuota status from the namespaces' pods.")
+	flag.DurationVar(&opts.quotaInterval, "quota-status-interval", 30*time.Second, "Time between NetworkQuota status reconciliations.")
+	flag.StringVar(&opts.conversionAddr, "conversion-webhook-addr", "", "Address to serve the CRD conversion webhook on (e.g. :9443); empty disables.")
+	flag.StringVar(&opts.conversionCertFile, "conversion-tls-cert-file", "", "TLS certificate for the conversion webhook.")
+	flag.StringVar(&opts.conversionKeyFile, "conversion-tls-key-file", "", "TLS key for the conversion webhook.")
+	flag.StringVar(&opts.grpcAddr, "grpc-addr", "", "Address to serve the topology gRPC service on (e.g. :9444); empty disables.")
+	flag.StringVar(&opts.grpcCertFile, "grpc-tls-cert-file", "", "TLS certificate for the gRPC service.")
+	flag.StringVar(&opts.grpcKeyFile, "grpc-tls-key-file", "", "TLS key for the gRPC service.")
+	flag.StringVar(&opts.grpcClientCAFile, "grpc-client-ca-file", "", "CA bundle gRPC client certificates must chain to (mutual TLS).")
+	flag.StringVar(&opts.featureGates, "feature-gates", "", "Comma-separated Name=bool overrides of the shared feature gates, e.g. BandwidthReservation=false. Empty keeps every gate at its default.")
+	flag.StringVar(&opts.logFormat, "log-format", logging.FormatText, `Log output format: "text" (klog's default) or "json", one JSON object per line with field names shared with the scheduler binary.`)
+	klog.InitFlags(nil)
+	flag.Parse()
+	if err := logging.Setup(opts.logFormat); err != nil {
+		klog.ErrorS(err, "Configuring log format")
+		os.Exit(1)
+	}
+	if err := features.SetDefaultFromFlag(opts.featureGates); err != nil {
+		klog.ErrorS(err, "Configuring feature gates")
+		os.Exit(1)
+	}
+	if opts.latencyStats {
+		// Three samples make poor statistics; raise the default count
+		// unless the operator chose one explicitly.
+		explicit := false
+		flag.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "ping-count" })
+		if !explicit {
+			opts.pingCount = statsPingCount
+		}
+	}
+
+	config, err := buildConfig(opts.kubeconfig)
+	if err != nil {
+		klog.ErrorS(err, "Building client config")
+		os.Exit(1)
+	}
+	// Rate-limit our own API traffic on large fleets; federated remotes
+	// have their own clients and are unaffected.
+	if opts.kubeAPIQPS > 0 {
+		config.QPS = float32(opts.kubeAPIQPS)
+	}
+	if opts.kubeAPIBurst > 0 {
+		config.Burst = opts.kubeAPIBurst
+	}
+	c := &controller{
+		opts:            opts,
+		smoothed:        map[string]map[string]*linkState{},
+		endpointChanges: map[string]int64{},
+		trigger:         make(chan triggerRequest, 1),
+	}
+	if c.client, err = kubernetes.NewForConfig(config); err != nil {
+		klog.ErrorS(err, "Building clientset")
+		os.Exit(1)
+	}
+	if c.dynamic, err = dynamic.NewForConfig(config); err != nil {
+		klog.ErrorS(err, "Building dynamic client")
+		os.Exit(1)
+	}
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: c.client.CoreV1().Events("")})
+	c.recorder = broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "topology-controller"})
+	if c.importer, err = newImporter(opts.importSource, opts.importMapping); err != nil {
+		klog.ErrorS(err, "Configuring import source")
+		os.Exit(1)
+	}
+	if c.remotes, err = newRemoteClusters(opts.remoteKubeconfigs); err != nil {
+		klog.ErrorS(err, "Configuring remote clusters")
+		os.Exit(1)
+	}
+	c.audit = newAuditor(opts.auditLog, opts.auditMaxSizeMB)
+	c.export = export.NewSink(opts.exportFile, opts.exportMaxSizeMB, opts.exportMaxAge)
+	if c.resolver, err = newPeerResolver(opts.peerResolver); err != nil {
+		klog.ErrorS(err, "Configuring peer resolver")
+		os.Exit(1)
+	}
+	if c.addrPolicy, err = parseAddressPolicy(opts.addressPolicy); err != nil {
+		klog.ErrorS(err, "Configuring address policy")
+		os.Exit(1)
+	}
+	if opts.domainThreshold != "" {
+		d, err := units.ParseLatency(opts.domainThreshold)
+		if err != nil {
+			klog.ErrorS(err, "Configuring domain label threshold")
+			os.Exit(1)
+		}
+		c.domainMs = d.Milliseconds()
+	}
+	switch opts.offClusterWrites {
+	case offClusterWriteRefuse, offClusterWriteExternal:
+	default:
+		klog.ErrorS(fmt.Errorf("unknown off-cluster write mode %q (want %s or %s)", opts.offClusterWrites, offClusterWriteRefuse, offClusterWriteExternal), "Configuring off-cluster writes")
+		os.Exit(1)
+	}
+	switch opts.probeVantage {
+	case "auto", v1alpha1.ProbeVantageHost, v1alpha1.ProbeVantageOverlay:
+	default:
+		klog.ErrorS(fmt.Errorf("unknown probe vantage %q (want auto, %s or %s)", opts.probeVantage, v1alpha1.ProbeVantageHost, v1alpha1.ProbeVantageOverlay), "Configuring probe vantage")
+		os.Exit(1)
+	}
+	c.vantage = detectProbeVantage(opts.probeVantage)
+	klog.InfoS("Probe vantage", "vantage", c.vantage, "detected", opts.probeVantage == "auto")
+	if c.sampler = newPairSampler(opts.pairSampler, opts.labelKeys()); c.sampler == nil {
+		klog.ErrorS(fmt.Errorf("unknown pair sampler %q (want full-mesh, zone-representative, or hub-spoke)", opts.pairSampler), "Configuring pair sampler")
+		os.Exit(1)
+	}
+	switch opts.latencyProber {
+	case "auto", "icmp", "tailscale":
+	default:
+		klog.ErrorS(fmt.Errorf("unknown latency prober %q (want auto, icmp or tailscale)", opts.latencyProber), "Configuring latency prober")
+		os.Exit(1)
+	}
+	if err := opts.iperf().Validate(); err != nil {
+		klog.ErrorS(err, "Configuring bandwidth probe")
+		os.Exit(1)
+	}
+	if c.health, err = opts.healthModel(); err != nil {
+		klog.ErrorS(err, "Configuring health model")
+		os.Exit(1)
+	}
+	if c.cost, err = opts.costModel(); err != nil {
+		klog.ErrorS(err, "Configuring cost model")
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	shutdownTracing, err := tracing.Setup(ctx, "topology-controller", tracing.Options{
+		Enabled:     tracing.EnabledFromEnv(),
+		Stdout:      opts.tracingStdout,
+		SampleRatio: opts.tracingSample,
+	})
+	if err != nil {
+		klog.ErrorS(err, "Configuring tracing")
+		os.Exit(1)
+	}
+	defer shutdownTracing(ctx)
+
+	if opts.cacheFile != "" {
+		c.loadMeasurementCache(ctx)
+	}
+	if opts.once {
+		if err := c.runCycle(ctx); err != nil {
+			klog.ErrorS(err, "Measurement cycle failed")
+			os.Exit(1)
+		}
+		return
+	}
+	klog.InfoS("Starting topology controller", "interval", opts.interval,
+		"gitSHA", version.GitSHA, "buildDate", version.BuildDate, "featureGates", features.Default.String())
+	if opts.metricsAddr != "" {
+		go c.serveMetrics(opts.metricsAddr)
+	}
+	if opts.hints {
+		go c.runHintsLoop(ctx)
+	}
+	if opts.quotaStatus {
+		go c.runQuotaLoop(ctx)
+	}
+	if opts.grpcAddr != "" {
+		if opts.grpcCertFile == "" || opts.grpcKeyFile == "" || opts.grpcClientCAFile == "" {
+			klog.ErrorS(fmt.Errorf("--grpc-addr requires --grpc-tls-cert-file, --grpc-tls-key-file and --grpc-client-ca-file"),
+				"Configuring gRPC service")
+			os.Exit(1)
+		}
+		c.grpc = newGRPCTopologyServer(c)
+		go c.grpc.serve(opts.grpcAddr, opts.grpcCertFile, opts.grpcKeyFile, opts.grpcClientCAFile)
+	}
+	if opts.conversionAddr != "" {
+		// Hosting the conversion webhook here avoids a dedicated
+		// Deployment for what is a pure function of the request.
+		go func() {
+			klog.InfoS("Starting conversion webhook", "addr", opts.conversionAddr)
+			err := http.ListenAndServeTLS(opts.conversionAddr,
+				opts.conversionCertFile, opts.conversionKeyFile, conversion.Handler())
+			klog.ErrorS(err, "Conversion webhook server failed")
+			os.Exit(1)
+		}()
+	}
+	go c.runTriggerLoop(ctx)
+	for {
+		if err := c.runCycle(ctx); err != nil {
+			klog.ErrorS(err, "Measurement cycle failed")
+		}
+		select {
+		case <-time.After(opts.interval):
+		case req := <-c.trigger:
+			c.setRemeasureScope(req.Nodes)
+		}
+	}
+}
+
+func buildConfig(kubeconfig string) (*rest.Config, error) {
+	if kubeconfig != "" {
+		return clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+	return rest.InClusterConfig()
+}
+
+// listPageSize bounds each List request; a 400-node fleet with fat Node
+// objects should never arrive as one response.
+const listPageSize = 200
+
+// listNodes pages through the cluster's nodes.
+func (c *controller) listNodes(ctx context.Context) (*v1.NodeList, error) {
+	nodes := &v1.NodeList{}
+	opts := metav1.ListOptions{Limit: listPageSize}
+	for {
+		page, err := c.client.CoreV1().Nodes().List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		nodes.Items = append(nodes.Items, page.Items...)
+		if page.Continue == "" {
+			return nodes, nil
+		}
+		opts.Continue = page.Continue
+	}
+}
+
+// runCycle measures every node pair and writes the topology object.
+// nextEpoch advances the monotonic measurement-cycle counter, resuming from
+// the last written topology on the first cycle after a restart so epochs
+// never move backwards. A fresh cluster (or an unreadable object) starts
+// at 1.
+func (c *controller) nextEpoch(ctx context.Context) int64 {
+	if c.epoch == 0 {
+		if obj, err := c.dynamic.Resource(topologyGVR).Get(ctx, topologyName, metav1.GetOptions{}); err == nil {
+			existing := &v1alpha1.NetworkTopology{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, existing); err == nil {
+				c.epoch = existing.Status.Epoch
+			}
+		}
+	}
+	c.epoch++
+	return c.epoch
+}
+
+// carryEpoch returns the epoch the prior publication recorded for the node,
+// or the current epoch when the node is new or predates epoch stamping.
+func carryEpoch(prior *v1alpha1.NetworkTopology, name string, epoch int64) int64 {
+	if prior != nil {
+		if spec, ok := prior.Spec.Nodes[name]; ok && spec.Epoch > 0 {
+			return spec.Epoch
+		}
+	}
+	return epoch
+}
+
+func (c *controller) runCycle(ctx context.Context) error {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "cycle")
+	defer span.End()
+	nodes, err := c.listNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("listing nodes: %w", err)
+	}
+	offCluster := c.detectVantage(nodes.Items)
+
+	peerIPs := c.resolver.peers()
+	c.trackEndpoints(ctx)
+	addresses := map[string]string{}
+	tailnet := map[string]bool{}
+	members := make([]memberNode, 0, len(nodes.Items))
+	for i := range nodes.Items {
+		members = append(members, memberNode{node: &nodes.Items[i], cluster: c.opts.clusterName})
+	}
+	members = append(members, c.remoteNodes(ctx)...)
+	chosen := map[string]candidateAddress{}
+	agent := map[string]bool{}
+	for _, member := range members {
+		selected := c.selectAddress(member.node, peerIPs)
+		chosen[member.node.Name] = selected
+		addresses[member.node.Name] = selected.addr
+		// Nodes probed at their tailnet address go through the tailscale
+		// prober; the prober selection below keys off this.
+		tailnet[member.node.Name] = selected.class == addrClassTailscale
+		agent[member.node.Name] = runsProbeAgent(member.node)
+	}
+	// Nodes without a tailnet address of their own may sit behind a subnet
+	// router: the probe targets their InternalIP, but the path transits
+	// the router node and shares its uplink.
+	transit := map[string]string{}
+	routes := c.resolver.routes()
+	for _, member := range members {
+		name := member.node.Name
+		if tailnet[name] {
+			continue
+		}
+		if router, ok := routeVia(routes, addresses[name]); ok && router != name {
+			transit[name] = router
+		}
+	}
+	c.mu.Lock()
+	c.transit = transit
+	c.mu.Unlock()
+
+	profiles := c.fetchProfiles(ctx)
+	c.reportOrphanProfiles(ctx, profiles, addresses)
+	capRules := c.fetchCapabilityRules(ctx)
+	endpoints := c.fetchExternalEndpoints(ctx)
+	c.pruneRemovedEndpoints(endpoints)
+
+	var declared map[string]declaredNode
+	if c.importer != nil {
+		if declared, err = c.importer.declared(ctx); err != nil {
+			klog.ErrorS(err, "Importing declared topology, continuing with measurements only")
+		}
+	}
+
+	sampled := eligiblePairs(c.sampler.sample(members), members, profiles)
+	scope := c.takeRemeasureScope()
+	if len(scope) > 0 {
+		sampled = restrictPairs(sampled, scope)
+	}
+	maint, maintEnded := c.maintenanceTransitions(members)
+	if len(maint) > 0 {
+		sampled = dropMaintenancePairs(sampled, maint)
+	}
+	for _, node := range maintEnded {
+		sampled = addPairsTouching(sampled, members, node, maint)
+	}
+	// A node recreated under the same name gets the same treatment as one
+	// leaving maintenance: history reset and an immediate re-probe, instead
+	// of smoothing new measurements into the old machine's EWMA state.
+	for _, node := range c.recreatedNodes(members) {
+		c.forgetNodeHistory(node)
+		sampled = addPairsTouching(sampled, members, node, maint)
+	}
+	activeOverrides, lapsedOverrides := c.fetchOverrides(ctx)
+	if down := overridePairSet(activeOverrides); len(down) > 0 {
+		sampled = dropOverriddenPairs(sampled, down)
+		c.forgetOverriddenLinks(down)
+	}
+	sampled = reprobeLapsedPairs(sampled, lapsedOverrides)
+	if offCluster {
+		// Probes from here describe this machine's connectivity, not any
+		// node's; never attribute them to real source nodes.
+		sampled = nil
+	}
+	c.batchLatency = c.sweepLatency(ctx, sampled, addresses, tailnet)
+	epoch := c.nextEpoch(ctx)
+	prior := c.latestTopology()
+	measured := 0
+	attempted, deferred := 0, 0
+	var timings []probeTiming
+	var conflicts []importConflict
+	topo := &v1alpha1.NetworkTopology{
+		ObjectMeta: metav1.ObjectMeta{Name: topologyName},
+		Spec: v1alpha1.NetworkTopologySpec{
+			Nodes:        map[string]v1alpha1.NodeSpec{},
+			ProbeVantage: c.vantage,
+		},
+	}
+	for _, sourceMember := range members {
+		source := sourceMember.node
+		if maint[source.Name] {
+			// Frozen: republish the last written spec untouched so the
+			// EWMA history and published values survive the maintenance
+			// window.
+			if frozen, ok := c.frozenNodeSpec(source.Name); ok {
+				topo.Spec.Nodes[source.Name] = frozen
+				continue
+			}
+		}
+		caps, capSources := inferCapabilities(source, capRules, c.opts.instanceTypeCapability)
+		spec := v1alpha1.NodeSpec{
+			UID:               string(source.UID),
+			Zone:              c.opts.labelKeys().ZoneOf(source),
+			Region:            c.opts.labelKeys().RegionOf(source),
+			Cluster:           sourceMember.cluster,
+			UplinkGroup:       source.Labels[requirements.LabelUplinkGroup],
+			Capabilities:      caps,
+			CapabilitySources: capSources,
+			Schedulable:       boolPtr(requirements.NodeSchedulable(source)),
+			Bandwidth:         map[string]string{},
+			Latency:           map[string]string{},
+			Cost:              map[string]float64{},
+			ProbeTimes:        map[string]metav1.Time{},
+		}
+		// Reached for a maintenance node only without history to freeze;
+		// the flag still tells consumers the node is being worked on.
+		spec.Maintenance = maint[source.Name]
+		if selected := chosen[source.Name]; selected.addr != "" {
+			spec.ProbeAddress = selected.addr
+			spec.ProbeAddressFamily = selected.family
+			spec.ProbeAddressClass = selected.class
+		}
+		if c.opts.reverseBW {
+			spec.BandwidthRx = map[string]string{}
+		}
+		if c.opts.latencyStats {
+			spec.LatencyStats = map[string]v1alpha1.LatencyStats{}
+		}
+		touched := false
+		for _, targetMember := range members {
+			target := targetMember.node
+			if source.Name == target.Name {
+				continue
+			}
+			if !sampled[source.Name][target.Name] {
+				deferred++
+				// Scoped cycles and links toward frozen nodes keep
+				// their previous smoothed values.
+				if len(scope) > 0 || maint[target.Name] {
+					c.fillFromSmoothed(&spec, source.Name, target.Name)
+				}
+				continue
+			}
+			attempted++
+			touched = true
+			probeStart := time.Now()
+			link := c.measurePair(ctx, source.Name, target.Name, addresses[target.Name], tailnet[target.Name], agent[target.Name])
+			timings = append(timings, probeTiming{Source: source.Name, Target: target.Name, elapsed: time.Since(probeStart)})
+			if addresses[target.Name] != "" {
+				c.recordAddressOutcome(target.Name, link != nil && link.measured)
+			}
+			if link == nil || !link.measured {
+				continue
+			}
+			measured++
+			latency := link.latency
+			if router, ok := transit[target.Name]; ok {
+				latency = c.flooredLatency(source.Name, router, latency)
+			}
+			spec.Latency[target.Name] = units.FormatLatency(latency)
+			if spec.LatencyStats != nil && link.stats.Max > 0 {
+				spec.LatencyStats[target.Name] = formatLatencyStats(link.stats)
+			}
+			// Targets without the agent publish latency alone; absent
+			// bandwidth and cost entries read as unsupported, not as a
+			// dead link.
+			if !link.bandwidthUnsupported {
+				spec.Bandwidth[target.Name] = units.FormatBandwidth(link.bandwidth)
+				if spec.BandwidthRx != nil && link.bandwidthRx > 0 {
+					spec.BandwidthRx[target.Name] = units.FormatBandwidth(link.bandwidthRx)
+				}
+				spec.Cost[target.Name] = c.linkCost(link)
+			}
+			spec.ProbeTimes[target.Name] = metav1.NewTime(link.lastProbe)
+		}
+		if len(endpoints) > 0 && !offCluster && !maint[source.Name] {
+			// External endpoint legs sit outside the pair sampler — the
+			// endpoints are few and cannot reciprocate probes — so every
+			// unscoped cycle re-measures them; scoped cycles carry the
+			// smoothed values forward like any other unsampled link.
+			if len(scope) > 0 {
+				c.fillEndpointLinksFromSmoothed(&spec, source.Name, endpoints)
+			} else {
+				a, m := c.measureEndpointLinks(ctx, &spec, source.Name, endpoints)
+				attempted += a
+				measured += m
+				if a > 0 {
+					touched = true
+				}
+			}
+		}
+		if decl, ok := declared[source.Name]; ok {
+			conflicts = append(conflicts, applyDeclared(&spec, source.Name, decl)...)
+		}
+		if profile, ok := profiles[source.Name]; ok {
+			applyProfile(&spec, profile)
+		}
+		if health, ok := c.nodeHealth(spec); ok {
+			spec.HealthScore = &health
+		}
+		// Nodes whose links were only filled from smoothed history keep
+		// the epoch that actually measured them, so a scoped cycle leaves
+		// an honest mixed-epoch trail.
+		if touched {
+			spec.Epoch = epoch
+		} else {
+			spec.Epoch = carryEpoch(prior, source.Name, epoch)
+		}
+		topo.Spec.Nodes[source.Name] = spec
+	}
+	if c.importer != nil {
+		c.reportImportConflicts(ctx, conflicts)
+	}
+	if offCluster && c.opts.offClusterWrites == offClusterWriteExternal {
+		spec, n := c.externalSpec(ctx, members, addresses, tailnet, agent)
+		spec.Epoch = epoch
+		topo.Spec.Nodes[externalSourceName] = spec
+		measured += n
+	}
+	// Endpoints get their own entries so consumers can resolve
+	// external/<name> to a zone; marked unschedulable because nothing can
+	// ever bind to them.
+	for _, ep := range endpoints {
+		topo.Spec.Nodes[externalName(ep.Name)] = v1alpha1.NodeSpec{
+			Zone:        ep.Zone,
+			Schedulable: boolPtr(false),
+			Epoch:       epoch,
+		}
+	}
+	topo.Spec.UplinkGroups = uplinkGroups(profiles)
+	topo.Spec.Overrides = activeOverrides
+
+	var derived map[string]map[string]bool
+	if deriver, ok := c.sampler.(linkDeriver); ok {
+		derived = deriver.derive(topo, members)
+	}
+
+	local := make([]*v1.Node, 0, len(nodes.Items))
+	for i := range nodes.Items {
+		local = append(local, &nodes.Items[i])
+	}
+	// Domains are computed here, after derived links but before a
+	// links-shape conversion empties the per-node latency maps; the labels
+	// are only applied once the topology write succeeds.
+	var domains map[string]string
+	if c.domainMs > 0 {
+		domains = c.desiredDomains(topo, local)
+	}
+
+	now := metav1.Now()
+	topo.Status = v1alpha1.NetworkTopologyStatus{
+		LastUpdated:      &now,
+		Epoch:            epoch,
+		NodeCount:        len(members),
+		MeasurementCount: measured,
+		HealthScore:      c.healthScore(topo),
+		HealthModel:      c.healthModelString(),
+		CostModel:        c.costModelString(),
+		Conditions: []metav1.Condition{{
+			Type:               "Ready",
+			Status:             metav1.ConditionTrue,
+			Reason:             "MeasurementComplete",
+			Message:            readyMessage(len(members), measured),
+			LastTransitionTime: now,
+		}},
+	}
+
+	nodesByName := make(map[string]*v1.Node, len(members))
+	for _, member := range members {
+		nodesByName[member.node.Name] = member.node
+	}
+	c.evaluateAlerts(ctx, topo, nodesByName)
+
+	if c.opts.writeLinks {
+		topo = topology.ToLinks(topo)
+		c.annotateLinkPaths(topo)
+		markDerivedLinks(topo, derived)
+	}
+	writeErr := c.writeTopology(ctx, topo)
+	if writeErr == nil {
+		c.mu.Lock()
+		previous := c.lastTopo
+		c.lastTopo = topo
+		c.mu.Unlock()
+		c.audit.record(topology.Diff(previous, topo), declared)
+		c.exportLinkSamples(now.Time)
+		if c.grpc != nil {
+			c.grpc.publish(previous, topo)
+		}
+		if c.opts.advertiseBandwidth {
+			c.advertiseBandwidth(ctx, topo, local)
+		}
+		c.reconcileDomainLabels(ctx, domains, local)
+		if c.opts.cacheFile != "" {
+			c.saveMeasurementCache()
+		}
+	}
+	c.recordCycleStats(start, time.Now(), attempted, measured, deferred, epoch, timings)
+	span.SetAttributes(attribute.Int("nodes", len(members)), attribute.Int("measurements", measured))
+	logCycleSummary(topo, prior, cycleCounts{
+		nodes: len(members), attempted: attempted, measured: measured, deferred: deferred,
+	}, time.Since(start), writeErr == nil)
+	return writeErr
+}
+
+// cycleCounts bundles the per-cycle counters for the summary record.
+type cycleCounts struct {
+	nodes     int
+	attempted int
+	measured  int
+	deferred  int
+}
+
+// logCycleSummary emits the one structured record per cycle operators can
+// ship and alert on: counts, duration, the cluster's worst link, the health
+// movement against the previous written object, and whether this cycle's
+// object actually reached the API server. Per-probe detail stays at V(4).
+func logCycleSummary(topo, prior *v1alpha1.NetworkTopology, counts cycleCounts, duration time.Duration, written bool) {
+	var priorHealth float64
+	if prior != nil {
+		priorHealth = prior.Status.HealthScore
+	}
+	fields := []interface{}{
+		"nodes", counts.nodes,
+		"attempted", counts.attempted,
+		"measured", counts.measured,
+		"deferred", counts.deferred,
+		"duration", duration.Round(time.Millisecond),
+		"epoch", topo.Status.Epoch,
+		"healthScore", topo.Status.HealthScore,
+		"healthDelta", measure.Round2(topo.Status.HealthScore - priorHealth),
+		"written", written,
+	}
+	if worst := topology.Shared(topo).WorstLinks(1); len(worst) == 1 {
+		fields = append(fields,
+			"worstLink", worst[0].Source+":"+worst[0].Target,
+			"worstLinkLatency", worst[0].Latency,
+			"worstLinkHealth", worst[0].Health)
+	}
+	klog.InfoS("Cycle summary", fields...)
+}
+
+// readyMessage phrases the Ready condition: a cluster without peers has
+// nothing to measure, which is not the same as measuring nothing.
+func readyMessage(nodes, measured int) string {
+	if nodes < 2 {
+		return "No peer nodes to measure"
+	}
+	return fmt.Sprintf("Successfully measured %d connections", measured)
+}
+
+// measurePair probes one directed link and folds the result into the EWMA
+// state, returning the smoothed link. tailnet marks targets whose address was
+// resolved through Tailscale; agent marks targets able to run the iperf3
+// agent — bandwidth against anything else is skipped as unsupported rather
+// than attempted and failed.
+func (c *controller) measurePair(ctx context.Context, source, target, addr string, tailnet, agent bool) *linkState {
+	if addr == "" {
+		return nil
+	}
+	ctx, span := tracer.Start(ctx, "pair", trace.WithAttributes(
+		attribute.String("source", source), attribute.String("target", target)))
+	defer span.End()
+
+	latStats, path, latErr := c.probeLatency(ctx, target, addr, tailnet)
+	var bandwidth int64
+	var bwErr error
+	if agent {
+		bandwidth, bwErr = c.probeBandwidth(ctx, target, addr)
+	} else {
+		span.SetAttributes(attribute.String("bandwidth", "unsupported"))
+	}
+	if latErr != nil && (bwErr != nil || !agent) {
+		span.SetAttributes(attribute.String("result", "unreachable"))
+		klog.V(2).InfoS("Pair unreachable", "source", source, "target", target,
+			"latencyErr", latErr, "bandwidthErr", bwErr)
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.smoothed[source] == nil {
+		c.smoothed[source] = map[string]*linkState{}
+	}
+	state := c.smoothed[source][target]
+	if state == nil {
+		state = &linkState{}
+		c.smoothed[source][target] = state
+	}
+	if latErr == nil {
+		state.latency = measure.EWMADuration(state.latency, latStats.Avg, state.measured)
+		state.path = path
+		if c.opts.latencyStats {
+			state.stats = latStats
+		}
+		if c.opts.overlayNetns != "" && c.vantage == v1alpha1.ProbeVantageHost && !tailnet {
+			if overlay, err := measure.PingLatencyInNetns(c.opts.overlayNetns, addr, c.opts.pingCount); err == nil {
+				if overhead := overlay - latStats.Avg; overhead > 0 {
+					state.overlayOverhead = measure.EWMADuration(state.overlayOverhead, overhead, state.measured)
+				}
+			} else {
+				klog.V(2).InfoS("Overlay vantage probe failed", "source", source, "target", target, "err", err)
+			}
+		}
+	} else {
+		klog.V(2).InfoS("Latency probe failed", "source", source, "target", target, "err", latErr)
+	}
+	switch {
+	case !agent:
+		// A node that gains the agent (relabeled, reinstalled) clears the
+		// flag on its next successful bandwidth probe below.
+		state.bandwidthUnsupported = true
+	case bwErr == nil:
+		state.bandwidth = measure.EWMAInt64(state.bandwidth, bandwidth, state.measured)
+		state.bandwidthUnsupported = false
+	default:
+		klog.V(2).InfoS("Bandwidth probe failed", "source", source, "target", target, "err", bwErr)
+	}
+	if c.opts.reverseBW && agent && bwErr == nil {
+		if rx, err := measure.IperfBandwidthReverse(addr, c.opts.iperf()); err == nil {
+			state.bandwidthRx = measure.EWMAInt64(state.bandwidthRx, rx, state.measured)
+		} else {
+			klog.V(2).InfoS("Reverse bandwidth probe failed", "source", source, "target", target, "err", err)
+		}
+	}
+	state.measured = true
+	state.lastProbe = time.Now()
+	return state
+}
+
+// batchPingThreshold is the number of distinct ICMP targets in a cycle above
+// which latency is swept with one fping process instead of one ping per pair.
+const batchPingThreshold = 10
+
+// sweepLatency batch-probes every ICMP target the cycle will touch with a
+// single fping run, keyed by probe address. It returns nil — leaving the
+// per-pair ping path unchanged — when the cycle is below the threshold, every
+// target is tailnet-probed, or fping is unavailable. Latency statistics also
+// disable it: fping reports no mdev, and per-pair ping carries the full
+// distribution.
+func (c *controller) sweepLatency(ctx context.Context, sampled map[string]map[string]bool, addresses map[string]string, tailnet map[string]bool) map[string]time.Duration {
+	if c.opts.latencyProber == "tailscale" || c.opts.latencyStats {
+		return nil
+	}
+	targets := map[string]bool{}
+	for _, pairs := range sampled {
+		for target := range pairs {
+			if addr := addresses[target]; addr != "" && (!tailnet[target] || c.opts.latencyProber == "icmp") {
+				targets[addr] = true
+			}
+		}
+	}
+	if len(targets) < batchPingThreshold {
+		return nil
+	}
+	addrs := make([]string, 0, len(targets))
+	for addr := range targets {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	_, span := tracer.Start(ctx, "probe", trace.WithAttributes(
+		attribute.String("method", "fping"), attribute.Int("targets", len(addrs))))
+	defer span.End()
+	latencies, err := measure.FpingLatency(addrs, c.opts.pingCount)
+	if err != nil {
+		span.RecordError(err)
+		klog.V(2).InfoS("Batched latency sweep failed, falling back to per-pair ping",
+			"targets", len(addrs), "err", err)
+		return nil
+	}
+	klog.V(2).InfoS("Batched latency sweep", "targets", len(addrs), "replied", len(latencies))
+	return latencies
+}
+
+// probeLatency and probeBandwidth wrap the raw probes in per-probe spans with
+// method, target and result attributes. probeLatency picks the prober per
+// target: "tailscale ping" measures the actual WireGuard path (and reports
+// whether it went via DERP) for tailnet peers, the system ping covers
+// everything else — served from the cycle's fping sweep when one ran.
+// --latency-prober overrides the automatic choice.
+func (c *controller) probeLatency(ctx context.Context, target, addr string, tailnet bool) (measure.LatencyStats, string, error) {
+	useTailscale := tailnet
+	switch c.opts.latencyProber {
+	case "icmp":
+		useTailscale = false
+	case "tailscale":
+		useTailscale = true
+	}
+	method := "ping"
+	if useTailscale {
+		method = "tailscale-ping"
+	} else if c.batchLatency != nil {
+		method = "fping"
+	}
+	_, span := tracer.Start(ctx, "probe", trace.WithAttributes(
+		attribute.String("method", method), attribute.String("target", target)))
+	defer span.End()
+
+	var stats measure.LatencyStats
+	var path string
+	var err error
+	switch {
+	case useTailscale:
+		stats, path, err = measure.TailscalePingStats(addr, c.opts.pingCount)
+	case c.batchLatency != nil:
+		// The sweep carries averages only; full statistics disable it.
+		var ok bool
+		if stats.Avg, ok = c.batchLatency[addr]; !ok {
+			err = fmt.Errorf("no reply in batched sweep")
+		}
+	default:
+		stats, err = measure.PingLatencyStats(addr, c.opts.pingCount)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetAttributes(attribute.String("result", "error"))
+	} else {
+		span.SetAttributes(attribute.String("result", "ok"))
+		if path != "" {
+			span.SetAttributes(attribute.String("path", path))
+		}
+	}
+	return stats, path, err
+}
+
+func (c *controller) probeBandwidth(ctx context.Context, target, addr string) (int64, error) {
+	_, span := tracer.Start(ctx, "probe", trace.WithAttributes(
+		attribute.String("method", "iperf3"), attribute.String("target", target)))
+	defer span.End()
+	bandwidth, err := measure.IperfBandwidth(addr, c.opts.iperf())
+	if err != nil {
+		span.RecordError(err)
+		span.SetAttributes(attribute.String("result", "error"))
+	} else {
+		span.SetAttributes(attribute.String("result", "ok"))
+	}
+	return bandwidth, err
+}
+
+// formatLatencyStats renders a probe's sample distribution into the CRD's
+// string form.
+func formatLatencyStats(stats measure.LatencyStats) v1alpha1.LatencyStats {
+	return v1alpha1.LatencyStats{
+		Min:  units.FormatLatency(stats.Min),
+		Avg:  units.FormatLatency(stats.Avg),
+		Max:  units.FormatLatency(stats.Max),
+		Mdev: units.FormatLatency(stats.Mdev),
+	}
+}
+
+// linkCost normalizes a smoothed link into the [0,1] cost recorded in the
+// topology, against the configured cost model.
+func (c *controller) linkCost(link *linkState) float64 {
+	return c.costModel().LinkCost(link.latency, link.bandwidth)
+}
+
+// healthScore aggregates all measured links into the cluster health value:
+// 1.0 means every link is at the bandwidth anchor with negligible latency.
+// Frozen maintenance nodes are excluded on both link ends; their values are
+// stale by design and must not drag the live cluster's score.
+func (c *controller) healthScore(topo *v1alpha1.NetworkTopology) float64 {
+	var total float64
+	var count int
+	for _, spec := range topo.Spec.Nodes {
+		if spec.Maintenance {
+			continue
+		}
+		for target, latStr := range spec.Latency {
+			if topo.Spec.Nodes[target].Maintenance {
+				continue
+			}
+			bwStr, ok := spec.Bandwidth[target]
+			if !ok {
+				// Latency-only links (bandwidth unsupported on the
+				// target) carry no comparable health signal; scoring
+				// them as zero-bandwidth would misread "cannot
+				// measure" as "terrible".
+				continue
+			}
+			score, ok := c.linkHealth(latStr, bwStr)
+			if !ok {
+				continue
+			}
+			total += score
+			count++
+		}
+	}
+	if count == 0 {
+		// No scoreable pairs — a single-node cluster, or nothing measured
+		// yet. Neutral rather than zero: "nothing to measure" is not
+		// "everything is down".
+		return 1
+	}
+	return measure.Round2(total / float64(count))
+}
+
+// nodeHealth averages the health of a single node's links.
+func (c *controller) nodeHealth(spec v1alpha1.NodeSpec) (float64, bool) {
+	var total float64
+	var count int
+	for target, latStr := range spec.Latency {
+		bwStr, ok := spec.Bandwidth[target]
+		if !ok {
+			// Same exclusion as healthScore: no bandwidth entry means
+			// unsupported, not zero.
+			continue
+		}
+		score, ok := c.linkHealth(latStr, bwStr)
+		if !ok {
+			continue
+		}
+		total += score
+		count++
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return measure.Round2(total / float64(count)), true
+}
+
+// linkHealth parses the recorded strings and scores the link against the
+// controller's health model.
+func (c *controller) linkHealth(latStr, bwStr string) (float64, bool) {
+	lat, err := units.ParseLatency(latStr)
+	if err != nil {
+		klog.InfoS("Warning: unparseable latency in topology", "value", latStr, "err", err)
+		return 0, false
+	}
+	var bw int64
+	if bwStr != "" {
+		if bw, err = units.ParseBandwidth(bwStr); err != nil {
+			klog.InfoS("Warning: unparseable bandwidth in topology", "value", bwStr, "err", err)
+			return 0, false
+		}
+	}
+	return c.healthModel().LinkHealth(lat, bw), true
+}
+
+// healthModel substitutes the defaults for the zero value, so tests and
+// callers predating the flags keep the historical behavior.
+func (c *controller) healthModel() measure.HealthModel {
+	if c.health == (measure.HealthModel{}) {
+		return measure.DefaultHealthModel()
+	}
+	return c.health
+}
+
+func (c *controller) healthModelString() string {
+	return c.healthModel().String()
+}
+
+// costModel substitutes the defaults for the zero value, so tests and
+// callers predating the flags keep the historical behavior.
+func (c *controller) costModel() measure.CostModel {
+	if c.cost == (measure.CostModel{}) {
+		return measure.DefaultCostModel()
+	}
+	return c.cost
+}
+
+func (c *controller) costModelString() string {
+	return c.costModel().String()
+}
+
+// flooredLatency raises a measured latency to the source→router latency when
+// the target sits behind a subnet router: the path cannot be faster than its
+// first hop, however a LAN-local ping to the InternalIP might look.
+func (c *controller) flooredLatency(source, router string, latency time.Duration) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if state := c.smoothed[source][router]; state != nil && state.measured && state.latency > latency {
+		return state.latency
+	}
+	return latency
+}
+
+// annotateLinkPaths copies the route observed by the tailscale prober and any
+// subnet-router transit onto the explicit links; the per-node maps shape has
+// no field for them. Bandwidth-carrying links also record the iperf3
+// parameters they were measured with, so values probed under different
+// stream counts or durations are never compared blindly.
+func (c *controller) annotateLinkPaths(topo *v1alpha1.NetworkTopology) {
+	source := c.opts.iperf().Source()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range topo.Spec.Links {
+		link := &topo.Spec.Links[i]
+		if state := c.smoothed[link.Source][link.Target]; state != nil {
+			if state.path != "" {
+				link.Path = state.path
+			}
+			if state.overlayOverhead > 0 {
+				link.OverlayOverhead = units.FormatLatency(state.overlayOverhead)
+			}
+		}
+		if router, ok := c.transit[link.Target]; ok {
+			link.Via = router
+		}
+		if iface, ok := c.addrIface[link.Target]; ok {
+			link.Interface = iface
+		}
+		if link.Bandwidth != "" {
+			link.MeasurementSource = source
+		}
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+// inferCapabilities derives capability names from node labels through the
+// active rule table, recording which rule produced each one. With
+// instanceType, the standard instance-type label additionally becomes an
+// "instance-type:<type>" capability pods can require; it is value-derived
+// rather than rule-derived, so it carries no source entry.
+func inferCapabilities(node *v1.Node, rules []capabilityRule, instanceType bool) ([]string, map[string]string) {
+	caps, sources := applyCapabilityRules(node, rules)
+	if instanceType {
+		if t := requirements.NodeLabel(node, requirements.LabelInstanceType); t != "" {
+			caps = append(caps, "instance-type:"+t)
+		}
+	}
+	return caps, sources
+}
+
+var topologyGVR = v1alpha1.SchemeGroupVersion.WithResource("networktopologies")
+
+// writeTopology creates or updates the singleton NetworkTopology object.
+func (c *controller) writeTopology(ctx context.Context, topo *v1alpha1.NetworkTopology) error {
+	topo, dropped, err := truncateTopology(topo, topologySoftSizeBytes, topologyHardSizeBytes)
+	if err != nil {
+		return err
+	}
+	if len(dropped) > 0 {
+		klog.InfoS("Warning: topology over the size limit, optional fields dropped", "dropped", dropped)
+		topo.Status.Conditions = append(topo.Status.Conditions, metav1.Condition{
+			Type:               "Truncated",
+			Status:             metav1.ConditionTrue,
+			Reason:             "SizeLimitExceeded",
+			Message:            fmt.Sprintf("dropped to fit the size limit: %v", dropped),
+			LastTransitionTime: metav1.Now(),
+		})
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(topo)
+	if err != nil {
+		return fmt.Errorf("converting topology: %w", err)
+	}
+	u := &unstructured.Unstructured{Object: obj}
+	u.SetAPIVersion(v1alpha1.SchemeGroupVersion.String())
+	u.SetKind("NetworkTopology")
+
+	existing, err := c.dynamic.Resource(topologyGVR).Get(ctx, topologyName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = c.dynamic.Resource(topologyGVR).Create(ctx, u, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("fetching topology: %w", err)
+	}
+	u.SetResourceVersion(existing.GetResourceVersion())
+	_, err = c.dynamic.Resource(topologyGVR).Update(ctx, u, metav1.UpdateOptions{})
+	return err
+}
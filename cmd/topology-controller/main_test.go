@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// smallTopology builds an n-node topology where every pair is measured with
+// the same healthy link, the shape runCycle assembles for tiny clusters.
+func smallTopology(n int) *v1alpha1.NetworkTopology {
+	names := []string{"pi-1", "pi-2", "pi-3"}[:n]
+	topo := &v1alpha1.NetworkTopology{
+		Spec: v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{}},
+	}
+	for _, source := range names {
+		spec := v1alpha1.NodeSpec{
+			Latency:   map[string]string{},
+			Bandwidth: map[string]string{},
+		}
+		for _, target := range names {
+			if source == target {
+				continue
+			}
+			spec.Latency[target] = "2ms"
+			spec.Bandwidth[target] = "940mbps"
+		}
+		topo.Spec.Nodes[source] = spec
+	}
+	return topo
+}
+
+// A cluster without pairs has nothing to be unhealthy about: the score must
+// read neutral, not zero, and grow out of that as soon as real pairs exist.
+func TestHealthScoreSmallClusters(t *testing.T) {
+	c := &controller{}
+	if got := c.healthScore(smallTopology(1)); got != 1 {
+		t.Errorf("single-node health = %v, want neutral 1", got)
+	}
+	for _, n := range []int{2, 3} {
+		got := c.healthScore(smallTopology(n))
+		if got <= 0 || got > 1 {
+			t.Errorf("%d-node health = %v, want a measured score in (0,1]", n, got)
+		}
+	}
+}
+
+func TestReadyMessage(t *testing.T) {
+	if got := readyMessage(1, 0); got != "No peer nodes to measure" {
+		t.Errorf("single-node message = %q", got)
+	}
+	if got := readyMessage(2, 2); got != "Successfully measured 2 connections" {
+		t.Errorf("two-node message = %q", got)
+	}
+	// Peers that all failed to measure are a real problem, not an empty
+	// cluster; the count stays.
+	if got := readyMessage(3, 0); got != "Successfully measured 0 connections" {
+		t.Errorf("failed-measurement message = %q", got)
+	}
+}
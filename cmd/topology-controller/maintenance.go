@@ -0,0 +1,117 @@
+package main
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+)
+
+// Event reasons marking the maintenance transitions on the Node object.
+const (
+	eventMaintenanceStarted = "NetworkMaintenanceStarted"
+	eventMaintenanceEnded   = "NetworkMaintenanceEnded"
+)
+
+// maintenanceTransitions reads the maintenance annotation off every member,
+// emits an event per transition, and returns this cycle's maintenance set
+// plus the nodes whose maintenance just ended, which get an immediate
+// re-probe of their links before their values unfreeze.
+func (c *controller) maintenanceTransitions(members []memberNode) (map[string]bool, []string) {
+	maint := map[string]bool{}
+	for _, member := range members {
+		if requirements.NodeInMaintenance(member.node) {
+			maint[member.node.Name] = true
+		}
+	}
+
+	c.mu.Lock()
+	previous := c.lastMaintenance
+	c.lastMaintenance = maint
+	c.mu.Unlock()
+
+	var ended []string
+	for _, member := range members {
+		name := member.node.Name
+		switch {
+		case maint[name] && !previous[name]:
+			klog.InfoS("Node entered maintenance, freezing its measurements", "node", name)
+			if c.recorder != nil {
+				c.recorder.Eventf(member.node, v1.EventTypeNormal, eventMaintenanceStarted,
+					"Topology measurements frozen; last pre-maintenance values retained")
+			}
+		case !maint[name] && previous[name]:
+			ended = append(ended, name)
+			klog.InfoS("Node left maintenance, re-probing its links", "node", name)
+			if c.recorder != nil {
+				c.recorder.Eventf(member.node, v1.EventTypeNormal, eventMaintenanceEnded,
+					"Re-probing links and unfreezing topology measurements")
+			}
+		}
+	}
+	return maint, ended
+}
+
+// dropMaintenancePairs removes sampled pairs touching a maintenance node, so
+// a node being worked on is neither probed nor probed against.
+func dropMaintenancePairs(pairs map[string]map[string]bool, maint map[string]bool) map[string]map[string]bool {
+	kept := map[string]map[string]bool{}
+	for source, targets := range pairs {
+		if maint[source] {
+			continue
+		}
+		for target := range targets {
+			if maint[target] {
+				continue
+			}
+			if kept[source] == nil {
+				kept[source] = map[string]bool{}
+			}
+			kept[source][target] = true
+		}
+	}
+	return kept
+}
+
+// addPairsTouching forces both directions between node and every member not
+// in maintenance into the sampled set, regardless of what the pair sampler
+// chose: a node returning from maintenance needs fresh values on all its
+// links before its frozen history can be trusted again.
+func addPairsTouching(pairs map[string]map[string]bool, members []memberNode, node string, maint map[string]bool) map[string]map[string]bool {
+	add := func(source, target string) {
+		if pairs[source] == nil {
+			pairs[source] = map[string]bool{}
+		}
+		pairs[source][target] = true
+	}
+	for _, member := range members {
+		peer := member.node.Name
+		if peer == node || maint[peer] {
+			continue
+		}
+		add(node, peer)
+		add(peer, node)
+	}
+	return pairs
+}
+
+// frozenNodeSpec rebuilds a maintenance node's spec from the last written
+// topology, flagged frozen; ok=false when the controller has no history for
+// it (fresh start), in which case the caller records a position-only spec.
+func (c *controller) frozenNodeSpec(name string) (v1alpha1.NodeSpec, bool) {
+	c.mu.Lock()
+	last := c.lastTopo
+	c.mu.Unlock()
+	if last == nil {
+		return v1alpha1.NodeSpec{}, false
+	}
+	spec, ok := topology.Shared(last).Node(name)
+	if !ok {
+		return v1alpha1.NodeSpec{}, false
+	}
+	frozen := *spec.DeepCopy()
+	frozen.Maintenance = true
+	return frozen, true
+}
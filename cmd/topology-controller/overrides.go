@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// overrideStateDown is the only administrative link state today: the pair is
+// not probed and publishes no measurements while the override holds.
+const overrideStateDown = "down"
+
+// fetchOverrides reads the live topology's administrative link overrides,
+// splitting them into the still-active set, republished with this cycle's
+// spec, and the lapsed set, which is dropped and re-probed immediately.
+func (c *controller) fetchOverrides(ctx context.Context) (active, lapsed []v1alpha1.LinkOverride) {
+	obj, err := c.dynamic.Resource(topologyGVR).Get(ctx, topologyName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "Fetching link overrides")
+		}
+		return nil, nil
+	}
+	topo := &v1alpha1.NetworkTopology{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, topo); err != nil {
+		klog.ErrorS(err, "Converting topology for link overrides")
+		return nil, nil
+	}
+	now := time.Now()
+	for _, o := range topo.Spec.Overrides {
+		if o.ExpiresAt != nil && o.ExpiresAt.Time.Before(now) {
+			klog.InfoS("Link override lapsed, re-probing pair", "source", o.Source, "target", o.Target)
+			lapsed = append(lapsed, o)
+			continue
+		}
+		active = append(active, o)
+	}
+	return active, lapsed
+}
+
+// overridePairSet expands the down overrides into a directed pair set, both
+// directions per override.
+func overridePairSet(overrides []v1alpha1.LinkOverride) map[string]map[string]bool {
+	set := map[string]map[string]bool{}
+	add := func(a, b string) {
+		if set[a] == nil {
+			set[a] = map[string]bool{}
+		}
+		set[a][b] = true
+	}
+	for _, o := range overrides {
+		if o.State != overrideStateDown {
+			continue
+		}
+		add(o.Source, o.Target)
+		add(o.Target, o.Source)
+	}
+	return set
+}
+
+// dropOverriddenPairs removes administratively-down pairs from the cycle's
+// sample set.
+func dropOverriddenPairs(pairs, down map[string]map[string]bool) map[string]map[string]bool {
+	kept := map[string]map[string]bool{}
+	for source, targets := range pairs {
+		for target := range targets {
+			if down[source][target] {
+				continue
+			}
+			if kept[source] == nil {
+				kept[source] = map[string]bool{}
+			}
+			kept[source][target] = true
+		}
+	}
+	return kept
+}
+
+// forgetOverriddenLinks drops the smoothed history of administratively-down
+// pairs: the link publishes nothing while down (reading as unreachable
+// rather than healthy-but-stale) and starts fresh when the override lifts.
+func (c *controller) forgetOverriddenLinks(down map[string]map[string]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for source, targets := range down {
+		for target := range targets {
+			if m := c.smoothed[source]; m != nil {
+				delete(m, target)
+			}
+		}
+	}
+}
+
+// reprobeLapsedPairs forces both directions of each lapsed override back
+// into the sample set, so the link gets fresh measurements the moment its
+// maintenance window closes instead of waiting for the sampler's rotation.
+func reprobeLapsedPairs(pairs map[string]map[string]bool, lapsed []v1alpha1.LinkOverride) map[string]map[string]bool {
+	add := func(a, b string) {
+		if pairs[a] == nil {
+			pairs[a] = map[string]bool{}
+		}
+		pairs[a][b] = true
+	}
+	for _, o := range lapsed {
+		add(o.Source, o.Target)
+		add(o.Target, o.Source)
+	}
+	return pairs
+}
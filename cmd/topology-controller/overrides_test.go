@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+func TestOverriddenPairsSkippedAndForgotten(t *testing.T) {
+	overrides := []v1alpha1.LinkOverride{{Source: "a", Target: "b", State: "down"}}
+	down := overridePairSet(overrides)
+	sampled := map[string]map[string]bool{
+		"a": {"b": true, "c": true},
+		"b": {"a": true},
+		"c": {"a": true},
+	}
+	kept := dropOverriddenPairs(sampled, down)
+	if kept["a"]["b"] || kept["b"]["a"] {
+		t.Error("overridden pair survived in the sample set, both directions must be dropped")
+	}
+	if !kept["a"]["c"] || !kept["c"]["a"] {
+		t.Error("pairs not under override were dropped")
+	}
+
+	c := &controller{smoothed: map[string]map[string]*linkState{
+		"a": {"b": {}, "c": {}},
+		"b": {"a": {}},
+	}}
+	c.forgetOverriddenLinks(down)
+	if _, ok := c.smoothed["a"]["b"]; ok {
+		t.Error("smoothed history of the overridden pair survived")
+	}
+	if _, ok := c.smoothed["b"]["a"]; ok {
+		t.Error("smoothed history of the reverse direction survived")
+	}
+	if _, ok := c.smoothed["a"]["c"]; !ok {
+		t.Error("unrelated smoothed history was dropped")
+	}
+}
+
+func TestLapsedOverridesReprobeImmediately(t *testing.T) {
+	lapsed := []v1alpha1.LinkOverride{{Source: "a", Target: "b", State: "down"}}
+	sampled := reprobeLapsedPairs(map[string]map[string]bool{}, lapsed)
+	if !sampled["a"]["b"] || !sampled["b"]["a"] {
+		t.Error("lapsed override's pair must re-enter the sample set in both directions")
+	}
+}
+
+func TestOverridePairSetIgnoresUnknownStates(t *testing.T) {
+	down := overridePairSet([]v1alpha1.LinkOverride{{Source: "a", Target: "b", State: "degraded"}})
+	if len(down) != 0 {
+		t.Errorf("unknown override state produced pairs: %v", down)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// labelNodeOS is the well-known kubelet-set label carrying the node's
+// operating system ("linux", "windows", ...).
+const labelNodeOS = "kubernetes.io/os"
+
+// nodeOS returns the node's operating system from its kubernetes.io/os
+// label. Unlabeled nodes read as linux: the label has been kubelet-set for
+// years, and every node predating it was one.
+func nodeOS(node *v1.Node) string {
+	if os := node.Labels[labelNodeOS]; os != "" {
+		return os
+	}
+	return "linux"
+}
+
+// runsProbeAgent reports whether the node can host the iperf3 probe agent.
+// The agent DaemonSet selects linux nodes only (iperf3 is not installed on
+// Windows k3s agents), so bandwidth probes toward anything else would fail
+// every cycle; skipping them up front keeps those failures out of the logs
+// and out of the address-failover accounting.
+func runsProbeAgent(node *v1.Node) bool {
+	return nodeOS(node) == "linux"
+}
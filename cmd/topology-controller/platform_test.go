@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+func osNode(name, os string) *v1.Node {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if os != "" {
+		node.Labels = map[string]string{labelNodeOS: os}
+	}
+	return node
+}
+
+func TestRunsProbeAgent(t *testing.T) {
+	cases := map[string]bool{
+		"linux":   true,
+		"":        true, // unlabeled nodes predate the label and were linux
+		"windows": false,
+		"darwin":  false,
+	}
+	for os, want := range cases {
+		if got := runsProbeAgent(osNode("n", os)); got != want {
+			t.Errorf("runsProbeAgent(os=%q) = %v, want %v", os, got, want)
+		}
+	}
+}
+
+// A latency-only link (bandwidth unsupported on the target) must not drag the
+// health score down as if the link had zero bandwidth — it carries no
+// bandwidth signal at all.
+func TestHealthScoreSkipsLatencyOnlyLinks(t *testing.T) {
+	c := &controller{}
+	topo := &v1alpha1.NetworkTopology{
+		Spec: v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{
+			"linux-1": {
+				Latency:   map[string]string{"linux-2": "10ms", "win-1": "10ms"},
+				Bandwidth: map[string]string{"linux-2": "940mbps"},
+			},
+			"linux-2": {},
+			"win-1":   {},
+		}},
+	}
+	withSkipped := c.healthScore(topo)
+	delete(topo.Spec.Nodes["linux-1"].Latency, "win-1")
+	if full := c.healthScore(topo); withSkipped != full {
+		t.Errorf("health with latency-only link = %v, want the measured link's %v alone", withSkipped, full)
+	}
+
+	spec := v1alpha1.NodeSpec{Latency: map[string]string{"win-1": "10ms"}}
+	if _, ok := c.nodeHealth(spec); ok {
+		t.Error("nodeHealth over only latency-only links must report no score")
+	}
+}
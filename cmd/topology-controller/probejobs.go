@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/measure"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// measureAnnotation on the NetworkTopology object requests an ad-hoc probe
+// from a specific source node (kubectl networktopology measure). Its value is
+// "<source>:<target>[,<target>...]"; the controller clears it when acting on
+// it. Unlike the remeasure annotation, which re-runs the controller's own
+// probes, this one launches a Job pinned to the source node so the
+// measurement genuinely originates there.
+const measureAnnotation = requirements.AnnotationPrefix + "measure"
+
+const (
+	// probeJobCooldown rate-limits Job creation per source node; probe
+	// Jobs are a diagnostic, not a measurement pipeline.
+	probeJobCooldown = time.Minute
+	// probeJobTimeout bounds how long the controller waits for a Job's
+	// result before giving up and cleaning it up. A Job that cannot
+	// schedule (cordoned node, image pull failure) hits this; the regular
+	// cycle never waits on it.
+	probeJobTimeout = 2 * time.Minute
+	// probeJobPollInterval is how often a pending Job's pod is checked.
+	probeJobPollInterval = 5 * time.Second
+)
+
+// checkMeasureAnnotation clears and acts on the ad-hoc measure annotation,
+// launching the probe Job in its own goroutine so a slow or stuck Job cannot
+// delay the trigger loop or the measurement cycle.
+func (c *controller) checkMeasureAnnotation(ctx context.Context) {
+	obj, err := c.dynamic.Resource(topologyGVR).Get(ctx, topologyName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.V(2).InfoS("Checking measure annotation failed", "err", err)
+		}
+		return
+	}
+	value, ok := obj.GetAnnotations()[measureAnnotation]
+	if !ok || value == "" {
+		return
+	}
+	annotations := obj.GetAnnotations()
+	delete(annotations, measureAnnotation)
+	obj.SetAnnotations(annotations)
+	if _, err := c.dynamic.Resource(topologyGVR).Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		klog.ErrorS(err, "Clearing measure annotation")
+		return
+	}
+	source, targets, err := parseMeasureRequest(value)
+	if err != nil {
+		klog.ErrorS(err, "Ignoring malformed measure annotation", "value", value)
+		return
+	}
+	if c.opts.probeJobImage == "" {
+		klog.InfoS("Ignoring measure request: probe Jobs are disabled (set --probe-job-image)", "source", source)
+		return
+	}
+	if !c.allowProbeJob(source) {
+		klog.InfoS("Ignoring measure request inside the per-node cooldown", "source", source, "cooldown", probeJobCooldown)
+		return
+	}
+	go c.runProbeJob(ctx, source, targets)
+}
+
+// parseMeasureRequest splits the "<source>:<target>[,<target>...]" form.
+func parseMeasureRequest(value string) (string, []string, error) {
+	source, rest, ok := strings.Cut(value, ":")
+	source = strings.TrimSpace(source)
+	if !ok || source == "" {
+		return "", nil, fmt.Errorf("want \"<source>:<target>[,<target>...]\", got %q", value)
+	}
+	var targets []string
+	for _, t := range strings.Split(rest, ",") {
+		if t = strings.TrimSpace(t); t != "" && t != source {
+			targets = append(targets, t)
+		}
+	}
+	if len(targets) == 0 {
+		return "", nil, fmt.Errorf("no targets in %q", value)
+	}
+	return source, targets, nil
+}
+
+// allowProbeJob enforces the per-source cooldown.
+func (c *controller) allowProbeJob(source string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.probeJobLast == nil {
+		c.probeJobLast = map[string]time.Time{}
+	}
+	if last, ok := c.probeJobLast[source]; ok && time.Since(last) < probeJobCooldown {
+		return false
+	}
+	c.probeJobLast[source] = time.Now()
+	return true
+}
+
+// runProbeJob creates a Job pinned to the source node that pings each target,
+// waits for the termination message carrying the results, folds them into the
+// smoothed link state (published with the next cycle), and cleans the Job up.
+// Every failure path logs and returns; nothing here blocks a cycle.
+func (c *controller) runProbeJob(ctx context.Context, source string, targets []string) {
+	namespace := os.Getenv("POD_NAMESPACE")
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	addresses := map[string]string{}
+	for _, target := range targets {
+		if addr := c.resolveProbeAddress(ctx, target); addr != "" {
+			addresses[target] = addr
+		} else {
+			klog.InfoS("Probe job skipping target with no resolvable address", "source", source, "target", target)
+		}
+	}
+	if len(addresses) == 0 {
+		klog.InfoS("Probe job has no reachable targets", "source", source)
+		return
+	}
+
+	job := probeJobSpec(source, addresses, c.opts.probeJobImage, c.opts.pingCount)
+	created, err := c.client.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		klog.ErrorS(err, "Creating probe job", "source", source)
+		return
+	}
+	klog.InfoS("Probe job created", "job", created.Name, "source", source, "targets", targets)
+	defer func() {
+		policy := metav1.DeletePropagationBackground
+		if err := c.client.BatchV1().Jobs(namespace).Delete(context.Background(), created.Name,
+			metav1.DeleteOptions{PropagationPolicy: &policy}); err != nil && !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "Cleaning up probe job", "job", created.Name)
+		}
+	}()
+
+	message, err := c.awaitProbeJob(ctx, namespace, created.Name)
+	if err != nil {
+		klog.ErrorS(err, "Probe job produced no result", "job", created.Name, "source", source)
+		return
+	}
+	recorded := 0
+	for target, latency := range parseProbeResults(message) {
+		if _, ok := addresses[target]; !ok {
+			continue
+		}
+		c.recordProbeResult(source, target, latency)
+		recorded++
+	}
+	klog.InfoS("Probe job results recorded", "job", created.Name, "source", source, "links", recorded)
+}
+
+// probeJobSpec builds the one-shot Job: pinned to the source node via
+// nodeName (bypassing scheduling constraints a diagnostic should ignore),
+// hostNetwork so it measures the node's own path, never retried, and cleaned
+// up by TTL even if the controller dies before its own cleanup runs.
+func probeJobSpec(source string, addresses map[string]string, image string, pingCount int) *batchv1.Job {
+	var pairs []string
+	for target, addr := range addresses {
+		pairs = append(pairs, target+"="+addr)
+	}
+	script := `for pair in ` + strings.Join(pairs, " ") + `; do
+name=${pair%%=*}; addr=${pair#*=}
+avg=$(ping -c ` + strconv.Itoa(pingCount) + ` -W 2 "$addr" | sed -n 's|^rtt [^=]*= *[0-9.]*/\([0-9.]*\)/.*|\1|p')
+echo "$name ${avg:-fail}" >> /dev/termination-log
+done`
+	backoff := int32(0)
+	ttl := int32(300)
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "netprobe-" + source + "-",
+			Labels:       map[string]string{"app": "topology-probe"},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoff,
+			TTLSecondsAfterFinished: &ttl,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "topology-probe"}},
+				Spec: v1.PodSpec{
+					NodeName:      source,
+					HostNetwork:   true,
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers: []v1.Container{{
+						Name:    "probe",
+						Image:   image,
+						Command: []string{"/bin/sh", "-c", script},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// awaitProbeJob polls the Job's pod until a container terminates, returning
+// its termination message.
+func (c *controller) awaitProbeJob(ctx context.Context, namespace, name string) (string, error) {
+	deadline := time.Now().Add(probeJobTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(probeJobPollInterval):
+		}
+		pods, err := c.client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: "job-name=" + name,
+		})
+		if err != nil {
+			continue
+		}
+		for i := range pods.Items {
+			for _, status := range pods.Items[i].Status.ContainerStatuses {
+				if status.State.Terminated != nil {
+					return status.State.Terminated.Message, nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("no terminated pod within %v", probeJobTimeout)
+}
+
+// parseProbeResults reads the termination message's "<target> <avg-ms>"
+// lines; failed targets report "fail" and are skipped.
+func parseProbeResults(message string) map[string]time.Duration {
+	results := map[string]time.Duration{}
+	for _, line := range strings.Split(message, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[1] == "fail" {
+			continue
+		}
+		ms, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || ms < 0 {
+			continue
+		}
+		results[fields[0]] = time.Duration(ms * float64(time.Millisecond))
+	}
+	return results
+}
+
+// recordProbeResult folds an ad-hoc measurement into the smoothed link state
+// exactly like the controller's own probes, so the next written topology
+// carries it.
+func (c *controller) recordProbeResult(source, target string, latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.smoothed[source] == nil {
+		c.smoothed[source] = map[string]*linkState{}
+	}
+	state := c.smoothed[source][target]
+	if state == nil {
+		state = &linkState{}
+		c.smoothed[source][target] = state
+	}
+	state.latency = measure.EWMADuration(state.latency, latency, state.measured)
+	state.measured = true
+	state.lastProbe = time.Now()
+}
+
+// resolveProbeAddress finds the address a probe Job should target: the
+// address the last cycle probed the node at, falling back to its InternalIP.
+func (c *controller) resolveProbeAddress(ctx context.Context, name string) string {
+	c.mu.Lock()
+	last := c.lastTopo
+	c.mu.Unlock()
+	if last != nil {
+		if spec, ok := last.Spec.Nodes[name]; ok && spec.ProbeAddress != "" {
+			return spec.ProbeAddress
+		}
+	}
+	node, err := c.client.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == v1.NodeInternalIP {
+			return addr.Address
+		}
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMeasureRequest(t *testing.T) {
+	source, targets, err := parseMeasureRequest("pi-1:pi-2, pi-3")
+	if err != nil {
+		t.Fatalf("parseMeasureRequest: %v", err)
+	}
+	if source != "pi-1" || len(targets) != 2 || targets[0] != "pi-2" || targets[1] != "pi-3" {
+		t.Errorf("parsed %q -> %v", source, targets)
+	}
+	// The source probing itself is meaningless and dropped.
+	if _, targets, err := parseMeasureRequest("pi-1:pi-1,pi-2"); err != nil || len(targets) != 1 {
+		t.Errorf("self-target not dropped: %v, %v", targets, err)
+	}
+	for _, malformed := range []string{"", "pi-1", "pi-1:", ":pi-2", "pi-1:pi-1"} {
+		if _, _, err := parseMeasureRequest(malformed); err == nil {
+			t.Errorf("parseMeasureRequest(%q) accepted", malformed)
+		}
+	}
+}
+
+func TestParseProbeResults(t *testing.T) {
+	results := parseProbeResults("pi-2 2.5\npi-3 fail\ngarbage\npi-4 12\n")
+	if len(results) != 2 {
+		t.Fatalf("parsed %d results, want 2: %v", len(results), results)
+	}
+	if results["pi-2"] != 2500*time.Microsecond {
+		t.Errorf("pi-2 latency = %v, want 2.5ms", results["pi-2"])
+	}
+	if results["pi-4"] != 12*time.Millisecond {
+		t.Errorf("pi-4 latency = %v, want 12ms", results["pi-4"])
+	}
+}
+
+func TestAllowProbeJobCooldown(t *testing.T) {
+	c := &controller{}
+	if !c.allowProbeJob("pi-1") {
+		t.Fatal("first probe job denied")
+	}
+	if c.allowProbeJob("pi-1") {
+		t.Error("second probe job allowed inside the cooldown")
+	}
+	// The cooldown is per source node.
+	if !c.allowProbeJob("pi-2") {
+		t.Error("another node's probe job denied by pi-1's cooldown")
+	}
+	c.probeJobLast["pi-1"] = time.Now().Add(-2 * probeJobCooldown)
+	if !c.allowProbeJob("pi-1") {
+		t.Error("probe job denied after the cooldown elapsed")
+	}
+}
+
+func TestProbeJobSpecPinsSourceNode(t *testing.T) {
+	job := probeJobSpec("pi-1", map[string]string{"pi-2": "10.0.0.2"}, "probe:latest", 3)
+	podSpec := job.Spec.Template.Spec
+	if podSpec.NodeName != "pi-1" {
+		t.Errorf("job not pinned: nodeName = %q", podSpec.NodeName)
+	}
+	if !podSpec.HostNetwork {
+		t.Error("probe job must use hostNetwork to measure the node's own path")
+	}
+	if *job.Spec.BackoffLimit != 0 {
+		t.Errorf("backoffLimit = %d, a diagnostic must not retry", *job.Spec.BackoffLimit)
+	}
+	if job.Spec.TTLSecondsAfterFinished == nil {
+		t.Error("missing TTL; an orphaned job must clean itself up")
+	}
+}
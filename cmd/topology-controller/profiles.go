@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+var profileGVR = v1alpha1.SchemeGroupVersion.WithResource("nodenetworkprofiles")
+
+// fetchProfiles lists all NodeNetworkProfile objects, keyed by node name (the
+// object name). A cluster without the CRD installed simply has no profiles;
+// any other error is only logged because declared facts are an overlay, not a
+// prerequisite for publishing measurements.
+func (c *controller) fetchProfiles(ctx context.Context) map[string]v1alpha1.NodeNetworkProfileSpec {
+	list, err := c.dynamic.Resource(profileGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.V(2).InfoS("Not applying node network profiles", "err", err)
+		return nil
+	}
+	profiles := make(map[string]v1alpha1.NodeNetworkProfileSpec, len(list.Items))
+	for i := range list.Items {
+		profile := &v1alpha1.NodeNetworkProfile{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].Object, profile); err != nil {
+			klog.InfoS("Skipping malformed NodeNetworkProfile", "name", list.Items[i].GetName(), "err", err)
+			continue
+		}
+		profiles[profile.Name] = profile.Spec
+	}
+	return profiles
+}
+
+// applyProfile merges one node's declared profile into its computed spec:
+// declared capabilities win over inferred ones, a metered uplink surfaces as
+// the "metered" capability, and the cost modifier shifts every measured link
+// cost within [0,1].
+func applyProfile(spec *v1alpha1.NodeSpec, profile v1alpha1.NodeNetworkProfileSpec) {
+	for _, capability := range profile.Capabilities {
+		spec.Capabilities = appendCapability(spec.Capabilities, capability)
+	}
+	if profile.Metered {
+		spec.Capabilities = appendCapability(spec.Capabilities, requirements.CapabilityMetered)
+	}
+	if profile.CostModifier != nil {
+		for target, cost := range spec.Cost {
+			spec.Cost[target] = clamp01(cost + *profile.CostModifier)
+		}
+	}
+	if profile.UplinkGroup != "" {
+		spec.UplinkGroup = profile.UplinkGroup
+	}
+}
+
+// uplinkGroups merges the uplink capacities declared across all profiles into
+// the group → capacity map published on the topology. When members of one
+// group declare different capacities the smallest wins, erring toward not
+// overcommitting the shared uplink; unparseable declarations are skipped with
+// a warning.
+func uplinkGroups(profiles map[string]v1alpha1.NodeNetworkProfileSpec) map[string]string {
+	groups := map[string]string{}
+	smallest := map[string]int64{}
+	for node, profile := range profiles {
+		if profile.UplinkGroup == "" || profile.UplinkCapacity == "" {
+			continue
+		}
+		bps, err := units.ParseBandwidth(profile.UplinkCapacity)
+		if err != nil {
+			klog.InfoS("Warning: unparseable uplink capacity in NodeNetworkProfile",
+				"node", node, "group", profile.UplinkGroup, "value", profile.UplinkCapacity, "err", err)
+			continue
+		}
+		if existing, ok := smallest[profile.UplinkGroup]; !ok || bps < existing {
+			smallest[profile.UplinkGroup] = bps
+			groups[profile.UplinkGroup] = units.FormatBandwidth(bps)
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	return groups
+}
+
+func appendCapability(capabilities []string, capability string) []string {
+	for _, c := range capabilities {
+		if c == capability {
+			return capabilities
+		}
+	}
+	return append(capabilities, capability)
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// reportOrphanProfiles emits a Warning Event for every profile that names a
+// node the cluster does not have, so typos surface in kubectl describe rather
+// than being silently ignored.
+func (c *controller) reportOrphanProfiles(ctx context.Context, profiles map[string]v1alpha1.NodeNetworkProfileSpec, nodes map[string]string) {
+	for name := range profiles {
+		if _, ok := nodes[name]; ok {
+			continue
+		}
+		klog.InfoS("NodeNetworkProfile references nonexistent node", "profile", name)
+		now := metav1.Now()
+		event := &v1.Event{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "nodenetworkprofile-" + name + "-",
+				Namespace:    metav1.NamespaceDefault,
+			},
+			InvolvedObject: v1.ObjectReference{
+				APIVersion: v1alpha1.SchemeGroupVersion.String(),
+				Kind:       "NodeNetworkProfile",
+				Name:       name,
+			},
+			Reason:         "UnknownNode",
+			Message:        fmt.Sprintf("NodeNetworkProfile %q does not match any cluster node and is ignored", name),
+			Type:           v1.EventTypeWarning,
+			Source:         v1.EventSource{Component: "topology-controller"},
+			FirstTimestamp: now,
+			LastTimestamp:  now,
+			Count:          1,
+		}
+		if _, err := c.client.CoreV1().Events(event.Namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+			klog.V(2).InfoS("Failed to record orphan profile event", "profile", name, "err", err)
+		}
+	}
+}
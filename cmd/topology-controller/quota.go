@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+var quotaGVR = v1alpha1.SchemeGroupVersion.WithResource("networkquotas")
+
+// runQuotaLoop periodically recomputes NetworkQuota usage. Like the hints
+// reconciler it polls: recomputing from the live pod list means deleted pods
+// return their budget without any bookkeeping to get out of sync.
+func (c *controller) runQuotaLoop(ctx context.Context) {
+	klog.InfoS("Starting NetworkQuota status reconciler", "interval", c.opts.quotaInterval)
+	for {
+		if err := c.reconcileQuotas(ctx); err != nil {
+			klog.ErrorS(err, "Reconciling NetworkQuota status")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.opts.quotaInterval):
+		}
+	}
+}
+
+// reconcileQuotas writes each NetworkQuota's status from the min-bandwidth
+// claims of its namespace's non-terminal pods.
+func (c *controller) reconcileQuotas(ctx context.Context) error {
+	list, err := c.dynamic.Resource(quotaGVR).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("listing NetworkQuotas: %w", err)
+	}
+	for i := range list.Items {
+		quota := &v1alpha1.NetworkQuota{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].Object, quota); err != nil {
+			klog.ErrorS(err, "Converting NetworkQuota", "name", list.Items[i].GetName())
+			continue
+		}
+		reserved, pods, err := c.namespaceReservedMbps(ctx, quota.Namespace)
+		if err != nil {
+			klog.ErrorS(err, "Computing NetworkQuota usage", "quota", klog.KObj(quota))
+			continue
+		}
+		if quota.Status.ReservedMbps == reserved && quota.Status.PodCount == pods {
+			continue
+		}
+		quota.Status.ReservedMbps = reserved
+		quota.Status.PodCount = pods
+		now := metav1.Now()
+		quota.Status.UpdatedAt = &now
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(quota)
+		if err != nil {
+			klog.ErrorS(err, "Converting NetworkQuota", "quota", klog.KObj(quota))
+			continue
+		}
+		u := &unstructured.Unstructured{Object: obj}
+		u.SetAPIVersion(v1alpha1.SchemeGroupVersion.String())
+		u.SetKind("NetworkQuota")
+		if _, err := c.dynamic.Resource(quotaGVR).Namespace(quota.Namespace).UpdateStatus(ctx,
+			u, metav1.UpdateOptions{}); err != nil {
+			klog.ErrorS(err, "Updating NetworkQuota status", "quota", klog.KObj(quota))
+			continue
+		}
+		klog.V(2).InfoS("Updated NetworkQuota status", "quota", klog.KObj(quota),
+			"reservedMbps", reserved, "pods", pods)
+	}
+	return nil
+}
+
+// namespaceReservedMbps sums the min-bandwidth claims of the namespace's
+// non-terminal pods. Pods with malformed annotations are skipped; admission
+// already rejected what it could, and a parse error must not wedge the
+// reconciler.
+func (c *controller) namespaceReservedMbps(ctx context.Context, namespace string) (int64, int, error) {
+	var reserved int64
+	var count int
+	opts := metav1.ListOptions{Limit: listPageSize}
+	for {
+		page, err := c.client.CoreV1().Pods(namespace).List(ctx, opts)
+		if err != nil {
+			return 0, 0, fmt.Errorf("listing pods in %s: %w", namespace, err)
+		}
+		for i := range page.Items {
+			pod := &page.Items[i]
+			if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+				continue
+			}
+			reqs, err := requirements.Parse(pod)
+			if err != nil || reqs.MinBandwidthMbps == 0 {
+				continue
+			}
+			reserved += reqs.MinBandwidthMbps
+			count++
+		}
+		if page.Continue == "" {
+			return reserved, count, nil
+		}
+		opts.Continue = page.Continue
+	}
+}
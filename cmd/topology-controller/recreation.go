@@ -0,0 +1,49 @@
+package main
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// recreatedNodes compares each member's object UID against the previous
+// cycle and returns the names whose UID changed: the node object was deleted
+// and recreated under the same name, so it is new hardware (or at least a
+// new machine identity) and none of the history measured against the old
+// node can be trusted. Names seen for the first time are recorded but not
+// reported — a fresh node has no history to reset.
+func (c *controller) recreatedNodes(members []memberNode) []string {
+	current := map[string]types.UID{}
+	for _, member := range members {
+		if member.node.UID != "" {
+			current[member.node.Name] = member.node.UID
+		}
+	}
+
+	c.mu.Lock()
+	previous := c.nodeUIDs
+	c.nodeUIDs = current
+	c.mu.Unlock()
+
+	var recreated []string
+	for name, uid := range current {
+		if old, ok := previous[name]; ok && old != uid {
+			klog.InfoS("Node recreated under the same name, resetting its measurement history",
+				"node", name, "oldUID", old, "newUID", uid)
+			recreated = append(recreated, name)
+		}
+	}
+	return recreated
+}
+
+// forgetNodeHistory drops the smoothed state of every link touching the
+// named node, in both directions, so the next measurements start the EWMA
+// from scratch instead of blending into values measured against a machine
+// that no longer exists.
+func (c *controller) forgetNodeHistory(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.smoothed, name)
+	for _, targets := range c.smoothed {
+		delete(targets, name)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func memberWithUID(name string, uid types.UID) memberNode {
+	return memberNode{node: &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, UID: uid}}}
+}
+
+func TestRecreatedNodes(t *testing.T) {
+	c := &controller{}
+	members := []memberNode{memberWithUID("pi-1", "uid-1"), memberWithUID("pi-2", "uid-2")}
+
+	// First sight records the UIDs without reporting anything: a fresh node
+	// has no history to reset.
+	if got := c.recreatedNodes(members); got != nil {
+		t.Fatalf("first cycle reported %v, want nothing", got)
+	}
+	// Stable UIDs stay quiet.
+	if got := c.recreatedNodes(members); got != nil {
+		t.Fatalf("unchanged UIDs reported %v, want nothing", got)
+	}
+
+	// pi-1 deleted and recreated under the same name.
+	members[0] = memberWithUID("pi-1", "uid-1b")
+	if got := c.recreatedNodes(members); !reflect.DeepEqual(got, []string{"pi-1"}) {
+		t.Fatalf("changed UID reported %v, want [pi-1]", got)
+	}
+	// The new UID is now the baseline.
+	if got := c.recreatedNodes(members); got != nil {
+		t.Fatalf("after reset, reported %v, want nothing", got)
+	}
+
+	// Nodes without a UID (remote members older federation peers may send)
+	// never trip the detector.
+	members = append(members, memberWithUID("remote-1", ""))
+	if got := c.recreatedNodes(members); got != nil {
+		t.Fatalf("UID-less member reported %v, want nothing", got)
+	}
+}
+
+func TestForgetNodeHistory(t *testing.T) {
+	c := &controller{smoothed: map[string]map[string]*linkState{
+		"pi-1": {"pi-2": {}, "pi-3": {}},
+		"pi-2": {"pi-1": {}, "pi-3": {}},
+	}}
+	c.forgetNodeHistory("pi-1")
+	if _, ok := c.smoothed["pi-1"]; ok {
+		t.Error("outgoing links of the recreated node survived")
+	}
+	if _, ok := c.smoothed["pi-2"]["pi-1"]; ok {
+		t.Error("incoming links toward the recreated node survived")
+	}
+	if _, ok := c.smoothed["pi-2"]["pi-3"]; !ok {
+		t.Error("unrelated link history was dropped")
+	}
+}
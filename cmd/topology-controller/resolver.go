@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/measure"
+)
+
+// peerResolver maps node names to the addresses probes should target when
+// they differ from the nodes' InternalIPs. On pure-LAN clusters there is
+// nothing to resolve and the controller must not burn an exec attempt (and an
+// error log) on a missing tailscale binary every cycle.
+type peerResolver interface {
+	// name identifies the resolver for logs and status reporting.
+	name() string
+	// peers returns node-name → probe-address overrides; nil means every
+	// node is probed at its InternalIP.
+	peers() map[string]string
+	// endpoints reports each peer's current NAT traversal state; nil when
+	// the resolver has no such notion (endpoint tracking is skipped).
+	endpoints() map[string]peerEndpoint
+	// routes lists subnets advertised by peers acting as subnet routers;
+	// nil when the resolver has no such notion.
+	routes() []advertisedRoute
+}
+
+// tailscaleResolver resolves peers through "tailscale status"; the measured
+// path then matches inter-node pod traffic over the tailnet.
+type tailscaleResolver struct{}
+
+func (tailscaleResolver) name() string { return "tailscale" }
+
+func (tailscaleResolver) peers() map[string]string {
+	peers, err := measure.TailscalePeers()
+	if err != nil {
+		klog.V(2).InfoS("tailscale status unavailable", "err", err)
+		return nil
+	}
+	return peers
+}
+
+func (tailscaleResolver) endpoints() map[string]peerEndpoint { return tailscaleEndpoints() }
+func (tailscaleResolver) routes() []advertisedRoute          { return tailscaleRoutes() }
+
+// wireguardResolver covers plain WireGuard meshes: node InternalIPs already
+// route over the wg interface, so there are no address overrides to apply.
+// It exists so operators can declare their environment explicitly instead of
+// relying on auto-detection.
+type wireguardResolver struct{}
+
+func (wireguardResolver) name() string                       { return "wireguard" }
+func (wireguardResolver) peers() map[string]string           { return nil }
+func (wireguardResolver) endpoints() map[string]peerEndpoint { return nil }
+func (wireguardResolver) routes() []advertisedRoute          { return nil }
+
+// noneResolver skips peer resolution entirely.
+type noneResolver struct{}
+
+func (noneResolver) name() string                       { return "none" }
+func (noneResolver) peers() map[string]string           { return nil }
+func (noneResolver) endpoints() map[string]peerEndpoint { return nil }
+func (noneResolver) routes() []advertisedRoute          { return nil }
+
+// newPeerResolver picks the resolver for the --peer-resolver flag value.
+// "auto" probes for the tailscale binary once at startup; the choice is
+// logged a single time instead of once per cycle.
+func newPeerResolver(mode string) (peerResolver, error) {
+	switch mode {
+	case "tailscale":
+		return tailscaleResolver{}, nil
+	case "wireguard":
+		return wireguardResolver{}, nil
+	case "none":
+		return noneResolver{}, nil
+	case "auto", "":
+		if _, err := exec.LookPath("tailscale"); err == nil {
+			klog.InfoS("Peer resolver selected", "resolver", "tailscale", "reason", "tailscale binary present")
+			return tailscaleResolver{}, nil
+		}
+		klog.InfoS("Peer resolver selected", "resolver", "none", "reason", "no tailscale binary; probing InternalIPs")
+		return noneResolver{}, nil
+	default:
+		return nil, fmt.Errorf("unknown peer resolver %q (want auto, tailscale, wireguard or none)", mode)
+	}
+}
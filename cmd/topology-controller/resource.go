@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// bandwidthResourcePatch builds the status patch advertising the node's
+// network bandwidth as the network.komarov.dev/bandwidth extended resource,
+// quantified in megabits per second. Returns nil when the node already
+// advertises the desired value, so unchanged nodes cost no API call.
+func bandwidthResourcePatch(node *v1.Node, mbps int64) []byte {
+	desired := fmt.Sprintf("%d", mbps)
+	if current, ok := node.Status.Capacity[requirements.ResourceBandwidth]; ok && current.String() == desired {
+		return nil
+	}
+	return []byte(fmt.Sprintf(`{"status":{"capacity":{%[1]q:%[2]q},"allocatable":{%[1]q:%[2]q}}}`,
+		requirements.ResourceBandwidth, desired))
+}
+
+// advertiseBandwidth patches each local node's status with its measured (or
+// declared) bandwidth so pods can request it via resources. Remote cluster
+// members are skipped: their nodes belong to another API server.
+func (c *controller) advertiseBandwidth(ctx context.Context, topo *v1alpha1.NetworkTopology, nodes []*v1.Node) {
+	for _, node := range nodes {
+		mbps, known := requirements.MeasuredBandwidthMbps(node, topo)
+		if !known {
+			continue
+		}
+		patch := bandwidthResourcePatch(node, mbps)
+		if patch == nil {
+			continue
+		}
+		_, err := c.client.CoreV1().Nodes().Patch(ctx, node.Name,
+			types.StrategicMergePatchType, patch, metav1.PatchOptions{}, "status")
+		if err != nil {
+			klog.ErrorS(err, "Advertising bandwidth resource", "node", node.Name)
+			continue
+		}
+		klog.V(2).InfoS("Advertised bandwidth resource", "node", node.Name, "mbps", mbps)
+	}
+}
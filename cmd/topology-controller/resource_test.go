@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+func TestBandwidthResourcePatch(t *testing.T) {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "pi-1"}}
+
+	patch := bandwidthResourcePatch(node, 850)
+	if patch == nil {
+		t.Fatal("expected a patch for a node without the resource")
+	}
+	var parsed struct {
+		Status struct {
+			Capacity    map[string]string `json:"capacity"`
+			Allocatable map[string]string `json:"allocatable"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(patch, &parsed); err != nil {
+		t.Fatalf("patch is not valid JSON: %v\n%s", err, patch)
+	}
+	if got := parsed.Status.Capacity[string(requirements.ResourceBandwidth)]; got != "850" {
+		t.Errorf("capacity = %q, want \"850\"", got)
+	}
+	if got := parsed.Status.Allocatable[string(requirements.ResourceBandwidth)]; got != "850" {
+		t.Errorf("allocatable = %q, want \"850\"", got)
+	}
+}
+
+func TestBandwidthResourcePatchSkipsUnchanged(t *testing.T) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "pi-1"},
+		Status: v1.NodeStatus{
+			Capacity: v1.ResourceList{
+				requirements.ResourceBandwidth: resource.MustParse("850"),
+			},
+		},
+	}
+	if patch := bandwidthResourcePatch(node, 850); patch != nil {
+		t.Errorf("expected no patch for an up-to-date node, got %s", patch)
+	}
+	if patch := bandwidthResourcePatch(node, 900); patch == nil {
+		t.Error("expected a patch when the measured value changed")
+	}
+}
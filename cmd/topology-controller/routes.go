@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"os/exec"
+
+	"k8s.io/klog/v2"
+)
+
+// advertisedRoute is one subnet a tailnet peer advertises as a subnet router.
+// Nodes whose InternalIP falls inside the prefix are reached through the
+// router, not over their own tailnet link.
+type advertisedRoute struct {
+	router string
+	prefix *net.IPNet
+}
+
+// tailscaleRoutes lists the subnets advertised by tailnet peers.
+func tailscaleRoutes() []advertisedRoute {
+	out, err := exec.Command("tailscale", "status", "--json").Output()
+	if err != nil {
+		klog.V(2).InfoS("tailscale status unavailable", "err", err)
+		return nil
+	}
+	var status struct {
+		Peer map[string]struct {
+			HostName      string   `json:"HostName"`
+			PrimaryRoutes []string `json:"PrimaryRoutes"`
+		} `json:"Peer"`
+	}
+	if err := json.Unmarshal(out, &status); err != nil {
+		klog.V(2).InfoS("Parsing tailscale status failed", "err", err)
+		return nil
+	}
+	var routes []advertisedRoute
+	for _, peer := range status.Peer {
+		for _, route := range peer.PrimaryRoutes {
+			_, prefix, err := net.ParseCIDR(route)
+			if err != nil {
+				klog.V(2).InfoS("Ignoring unparseable advertised route",
+					"router", peer.HostName, "route", route, "err", err)
+				continue
+			}
+			routes = append(routes, advertisedRoute{router: peer.HostName, prefix: prefix})
+		}
+	}
+	return routes
+}
+
+// routeVia returns the router advertising the most specific prefix containing
+// addr. Overlapping routes (a site route plus a narrower VLAN route) resolve
+// to the longer prefix, matching how the tailnet forwards the traffic.
+func routeVia(routes []advertisedRoute, addr string) (string, bool) {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return "", false
+	}
+	router := ""
+	bestBits := -1
+	for _, route := range routes {
+		if !route.prefix.Contains(ip) {
+			continue
+		}
+		if bits, _ := route.prefix.Mask.Size(); bits > bestBits {
+			bestBits = bits
+			router = route.router
+		}
+	}
+	return router, router != ""
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// pairSampler selects which directed node pairs a measurement cycle probes.
+// Full mesh is O(n²) and hopeless on large edge fleets; the sampled
+// strategies trade per-pair accuracy for a probe count that scales with the
+// node count.
+type pairSampler interface {
+	name() string
+	// sample returns the directed pairs to measure as a source → target
+	// set. Pairs absent from the set are skipped entirely, leaving their
+	// map entries unset (the scheduler treats absent edges as unknown).
+	sample(members []memberNode) map[string]map[string]bool
+}
+
+// fullMeshSampler measures every directed pair; the historical default.
+type fullMeshSampler struct{}
+
+func (fullMeshSampler) name() string { return "full-mesh" }
+
+func (fullMeshSampler) sample(members []memberNode) map[string]map[string]bool {
+	pairs := make(map[string]map[string]bool, len(members))
+	for _, source := range members {
+		targets := make(map[string]bool, len(members)-1)
+		for _, target := range members {
+			if target.node.Name != source.node.Name {
+				targets[target.node.Name] = true
+			}
+		}
+		pairs[source.node.Name] = targets
+	}
+	return pairs
+}
+
+// zoneRepresentativeSampler measures every node against one representative
+// per zone (both directions) plus the representatives against each other,
+// giving every node a measured path into every zone at O(n·zones) probes.
+// The representative is the lexicographically first node of its zone so the
+// choice is stable across cycles and controller restarts; nodes without a
+// zone label form an implicit "" zone and stay covered.
+type zoneRepresentativeSampler struct {
+	keys requirements.LabelKeys
+}
+
+func (zoneRepresentativeSampler) name() string { return "zone-representative" }
+
+func (s zoneRepresentativeSampler) sample(members []memberNode) map[string]map[string]bool {
+	representatives := map[string]string{}
+	for _, member := range members {
+		zone := s.keys.ZoneOf(member.node)
+		if current, ok := representatives[zone]; !ok || member.node.Name < current {
+			representatives[zone] = member.node.Name
+		}
+	}
+	reps := make([]string, 0, len(representatives))
+	for _, name := range representatives {
+		reps = append(reps, name)
+	}
+	sort.Strings(reps)
+
+	pairs := map[string]map[string]bool{}
+	add := func(source, target string) {
+		if source == target {
+			return
+		}
+		if pairs[source] == nil {
+			pairs[source] = map[string]bool{}
+		}
+		pairs[source][target] = true
+	}
+	for _, member := range members {
+		for _, rep := range reps {
+			add(member.node.Name, rep)
+			add(rep, member.node.Name)
+		}
+	}
+	for _, a := range reps {
+		for _, b := range reps {
+			add(a, b)
+		}
+	}
+	return pairs
+}
+
+// linkDeriver is implemented by samplers that can fill in pairs they chose
+// not to measure from the pairs they did. derive mutates the per-node maps in
+// place and returns the filled pairs as a source → target set so the
+// links-shape writer can mark them.
+type linkDeriver interface {
+	derive(topo *v1alpha1.NetworkTopology, members []memberNode) map[string]map[string]bool
+}
+
+// derivedMeasurementSource marks links whose values were computed from other
+// links rather than probed.
+const derivedMeasurementSource = "derived"
+
+// hubSpokeSampler measures every node against every hub (both directions)
+// plus the hubs against each other. Hubs are nodes carrying the
+// network.komarov.dev/hub=true label — typically gateways, storage, or GPU
+// boxes that spokes actually talk to. Spoke↔spoke pairs are never probed;
+// their costs are derived through the cheapest hub relay instead.
+type hubSpokeSampler struct{}
+
+func (hubSpokeSampler) name() string { return "hub-spoke" }
+
+func (hubSpokeSampler) sample(members []memberNode) map[string]map[string]bool {
+	hubs := hubNames(members)
+	if len(hubs) == 0 {
+		klog.InfoS("No nodes carry the hub label, falling back to a full mesh",
+			"label", requirements.LabelHub)
+		return fullMeshSampler{}.sample(members)
+	}
+	pairs := map[string]map[string]bool{}
+	add := func(source, target string) {
+		if source == target {
+			return
+		}
+		if pairs[source] == nil {
+			pairs[source] = map[string]bool{}
+		}
+		pairs[source][target] = true
+	}
+	for _, member := range members {
+		for _, hub := range hubs {
+			add(member.node.Name, hub)
+			add(hub, member.node.Name)
+		}
+	}
+	return pairs
+}
+
+// derive fills each unmeasured spoke↔spoke pair through the hub h minimising
+// cost(a→h)+cost(h→b): latencies add, bandwidth is the narrower leg, and the
+// cost is the capped sum. Pairs with no usable hub relay stay absent, which
+// the scheduler already treats as unknown.
+func (hubSpokeSampler) derive(topo *v1alpha1.NetworkTopology, members []memberNode) map[string]map[string]bool {
+	hubs := hubNames(members)
+	derived := map[string]map[string]bool{}
+	for source, spec := range topo.Spec.Nodes {
+		if isHub(hubs, source) {
+			continue
+		}
+		for target := range topo.Spec.Nodes {
+			if target == source || isHub(hubs, target) {
+				continue
+			}
+			if _, ok := spec.Cost[target]; ok {
+				continue
+			}
+			relay, cost, ok := cheapestRelay(topo, hubs, source, target)
+			if !ok {
+				continue
+			}
+			spec.Cost[target] = cost
+			if latency, ok := relayLatency(topo, source, relay, target); ok {
+				spec.Latency[target] = units.FormatLatency(latency)
+			}
+			if bandwidth, ok := relayBandwidth(topo, source, relay, target); ok {
+				spec.Bandwidth[target] = units.FormatBandwidth(bandwidth)
+			}
+			if derived[source] == nil {
+				derived[source] = map[string]bool{}
+			}
+			derived[source][target] = true
+		}
+		topo.Spec.Nodes[source] = spec
+	}
+	return derived
+}
+
+// cheapestRelay returns the hub minimising the two-leg cost from source to
+// target, capped at the maximum cost of 1.0 so a derived multi-hop path never
+// scores better than its worst possible measured equivalent.
+func cheapestRelay(topo *v1alpha1.NetworkTopology, hubs []string, source, target string) (string, float64, bool) {
+	best := math.MaxFloat64
+	relay := ""
+	for _, hub := range hubs {
+		toHub, ok := topo.Spec.Nodes[source].Cost[hub]
+		if !ok {
+			continue
+		}
+		fromHub, ok := topo.Spec.Nodes[hub].Cost[target]
+		if !ok {
+			continue
+		}
+		if total := toHub + fromHub; total < best {
+			best = total
+			relay = hub
+		}
+	}
+	if relay == "" {
+		return "", 0, false
+	}
+	return relay, math.Min(best, 1.0), true
+}
+
+// relayLatency sums the two measured legs; ok=false when either leg has no
+// parseable latency, leaving the derived pair's latency unset.
+func relayLatency(topo *v1alpha1.NetworkTopology, source, relay, target string) (time.Duration, bool) {
+	toHub, err := units.ParseLatency(topo.Spec.Nodes[source].Latency[relay])
+	if err != nil {
+		return 0, false
+	}
+	fromHub, err := units.ParseLatency(topo.Spec.Nodes[relay].Latency[target])
+	if err != nil {
+		return 0, false
+	}
+	return toHub + fromHub, true
+}
+
+// relayBandwidth takes the narrower of the two legs — the relay path cannot
+// carry more than its thinnest segment.
+func relayBandwidth(topo *v1alpha1.NetworkTopology, source, relay, target string) (int64, bool) {
+	toHub, err := units.ParseBandwidth(topo.Spec.Nodes[source].Bandwidth[relay])
+	if err != nil {
+		return 0, false
+	}
+	fromHub, err := units.ParseBandwidth(topo.Spec.Nodes[relay].Bandwidth[target])
+	if err != nil {
+		return 0, false
+	}
+	if fromHub < toHub {
+		return fromHub, true
+	}
+	return toHub, true
+}
+
+// markDerivedLinks stamps the links filled by a linkDeriver so consumers can
+// tell a measured value from a computed one; the per-node maps shape has no
+// source field, so only the explicit links representation carries the mark.
+func markDerivedLinks(topo *v1alpha1.NetworkTopology, derived map[string]map[string]bool) {
+	for i := range topo.Spec.Links {
+		link := &topo.Spec.Links[i]
+		if derived[link.Source][link.Target] {
+			link.MeasurementSource = derivedMeasurementSource
+		}
+	}
+}
+
+func hubNames(members []memberNode) []string {
+	var hubs []string
+	for _, member := range members {
+		if member.node.Labels[requirements.LabelHub] == "true" {
+			hubs = append(hubs, member.node.Name)
+		}
+	}
+	sort.Strings(hubs)
+	return hubs
+}
+
+func isHub(hubs []string, name string) bool {
+	for _, hub := range hubs {
+		if hub == name {
+			return true
+		}
+	}
+	return false
+}
+
+// newPairSampler maps the --pair-sampler flag value to a strategy; nil for
+// unknown values.
+func newPairSampler(name string, keys requirements.LabelKeys) pairSampler {
+	switch name {
+	case "full-mesh":
+		return fullMeshSampler{}
+	case "zone-representative":
+		return zoneRepresentativeSampler{keys: keys}
+	case "hub-spoke":
+		return hubSpokeSampler{}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// nodeSegments returns the network segments the node declares it can reach:
+// the profile's declaration wins over the comma-separated label. Nil means
+// the node declared nothing and sits on the implicit shared segment.
+func nodeSegments(node *v1.Node, profiles map[string]v1alpha1.NodeNetworkProfileSpec) []string {
+	if profile, ok := profiles[node.Name]; ok && len(profile.Segments) > 0 {
+		return profile.Segments
+	}
+	var segments []string
+	for _, s := range strings.Split(node.Labels[requirements.LabelSegments], ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+// eligiblePairs drops sampled pairs whose nodes cannot plausibly communicate:
+// a node declaring segments is probed only against nodes sharing one, and
+// nodes declaring none share an implicit segment with each other. With no
+// declarations anywhere every pair stays eligible, preserving the historical
+// behavior. Skipped pairs are omitted from the topology entirely, so their
+// absent edges read as unknown rather than unreachable.
+func eligiblePairs(pairs map[string]map[string]bool, members []memberNode, profiles map[string]v1alpha1.NodeNetworkProfileSpec) map[string]map[string]bool {
+	segments := map[string][]string{}
+	declared := false
+	for _, member := range members {
+		segs := nodeSegments(member.node, profiles)
+		segments[member.node.Name] = segs
+		declared = declared || len(segs) > 0
+	}
+	if !declared {
+		return pairs
+	}
+	eligible := map[string]map[string]bool{}
+	skipped := 0
+	for source, targets := range pairs {
+		for target := range targets {
+			if !shareSegment(segments[source], segments[target]) {
+				skipped++
+				continue
+			}
+			if eligible[source] == nil {
+				eligible[source] = map[string]bool{}
+			}
+			eligible[source][target] = true
+		}
+	}
+	if skipped > 0 {
+		klog.V(2).InfoS("Skipping pairs without a shared network segment", "pairs", skipped)
+	}
+	return eligible
+}
+
+// shareSegment reports whether two nodes' segment declarations permit a
+// probe. Two undeclared nodes share the implicit segment; a declared and an
+// undeclared node do not, so fencing off one VLAN only requires declaring
+// its members.
+func shareSegment(a, b []string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	for _, s := range a {
+		for _, t := range b {
+			if s == t {
+				return true
+			}
+		}
+	}
+	return false
+}
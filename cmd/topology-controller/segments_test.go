@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// segmentedMembers builds one node per entry, with the value as the segments
+// label (empty means no label).
+func segmentedMembers(labels map[string]string) []memberNode {
+	members := make([]memberNode, 0, len(labels))
+	for _, name := range []string{"edge-1", "edge-2", "storage-1", "plain-1", "plain-2"} {
+		node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if v, ok := labels[name]; ok {
+			node.Labels = map[string]string{requirements.LabelSegments: v}
+		}
+		members = append(members, memberNode{node: node})
+	}
+	return members
+}
+
+func TestEligiblePairsSegments(t *testing.T) {
+	members := segmentedMembers(map[string]string{
+		"edge-1":    "edge, lan",
+		"edge-2":    "edge",
+		"storage-1": "storage-vlan",
+	})
+	pairs := eligiblePairs(fullMeshSampler{}.sample(members), members, nil)
+
+	if !pairs["edge-1"]["edge-2"] || !pairs["edge-2"]["edge-1"] {
+		t.Error("nodes sharing the edge segment must stay eligible")
+	}
+	if pairs["edge-1"]["storage-1"] || pairs["storage-1"]["edge-2"] {
+		t.Error("disjoint segments must not be probed")
+	}
+	// Undeclared nodes share the implicit segment with each other but not
+	// with nodes fenced into explicit segments.
+	if !pairs["plain-1"]["plain-2"] {
+		t.Error("undeclared nodes must keep their implicit shared segment")
+	}
+	if pairs["plain-1"]["storage-1"] || pairs["storage-1"]["plain-1"] {
+		t.Error("a declared node must not be probed against undeclared nodes")
+	}
+}
+
+func TestEligiblePairsNoDeclarationsPassThrough(t *testing.T) {
+	members := segmentedMembers(nil)
+	sampled := fullMeshSampler{}.sample(members)
+	if got := pairCount(eligiblePairs(sampled, members, nil)); got != pairCount(sampled) {
+		t.Fatalf("pair count = %d, want the full mesh %d", got, pairCount(sampled))
+	}
+}
+
+func TestEligiblePairsProfileOverridesLabel(t *testing.T) {
+	members := segmentedMembers(map[string]string{
+		"edge-1": "edge",
+		"edge-2": "storage-vlan",
+	})
+	profiles := map[string]v1alpha1.NodeNetworkProfileSpec{
+		"edge-2": {Segments: []string{"edge"}},
+	}
+	pairs := eligiblePairs(fullMeshSampler{}.sample(members), members, profiles)
+	if !pairs["edge-1"]["edge-2"] {
+		t.Error("the profile's segment declaration must win over the label")
+	}
+}
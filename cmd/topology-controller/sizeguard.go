@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// Serialized-size limits for the written topology. The soft limit triggers
+// progressive dropping of optional fields; past the hard limit the write is
+// refused entirely, keeping the previous object intact instead of letting
+// every update bounce off the apiserver's request limits.
+const (
+	topologySoftSizeBytes = 1 << 20           // 1 MiB
+	topologyHardSizeBytes = 3 * (1 << 20) / 2 // 1.5 MiB, under etcd's default ceiling
+)
+
+// truncationStep is one degradation stage, cheapest-to-lose first.
+type truncationStep struct {
+	name string
+	drop func(*v1alpha1.NetworkTopology)
+}
+
+// truncationSteps is the degradation order: statistics first, then per-link
+// metadata, then the measured shadows of declared values. The core
+// latency/bandwidth/cost measurements are never dropped — a topology without
+// them is not worth writing.
+var truncationSteps = []truncationStep{
+	{name: "latencyStats", drop: func(topo *v1alpha1.NetworkTopology) {
+		for name, spec := range topo.Spec.Nodes {
+			spec.LatencyStats = nil
+			topo.Spec.Nodes[name] = spec
+		}
+		for i := range topo.Spec.Links {
+			topo.Spec.Links[i].LatencyStats = nil
+		}
+	}},
+	{name: "probeTimes", drop: func(topo *v1alpha1.NetworkTopology) {
+		for name, spec := range topo.Spec.Nodes {
+			spec.ProbeTimes = nil
+			topo.Spec.Nodes[name] = spec
+		}
+		for i := range topo.Spec.Links {
+			topo.Spec.Links[i].LastProbeTime = nil
+		}
+	}},
+	{name: "linkMetadata", drop: func(topo *v1alpha1.NetworkTopology) {
+		for i := range topo.Spec.Links {
+			topo.Spec.Links[i].Path = ""
+			topo.Spec.Links[i].Via = ""
+			topo.Spec.Links[i].MeasurementSource = ""
+		}
+	}},
+	{name: "measuredShadows", drop: func(topo *v1alpha1.NetworkTopology) {
+		for name, spec := range topo.Spec.Nodes {
+			spec.MeasuredBandwidth = nil
+			spec.MeasuredLatency = nil
+			topo.Spec.Nodes[name] = spec
+		}
+	}},
+}
+
+// truncateTopology enforces the size limits: under the soft limit the input
+// is returned untouched; over it, a copy is degraded step by step until it
+// fits, returning the names of the dropped field groups. An error means the
+// topology exceeds the hard limit even fully truncated and must not be
+// written.
+func truncateTopology(topo *v1alpha1.NetworkTopology, soft, hard int) (*v1alpha1.NetworkTopology, []string, error) {
+	size, err := serializedSize(topo)
+	if err != nil {
+		return nil, nil, err
+	}
+	if size <= soft {
+		return topo, nil, nil
+	}
+	truncated := topo.DeepCopy()
+	var dropped []string
+	for _, step := range truncationSteps {
+		step.drop(truncated)
+		dropped = append(dropped, step.name)
+		if size, err = serializedSize(truncated); err != nil {
+			return nil, nil, err
+		}
+		if size <= soft {
+			return truncated, dropped, nil
+		}
+	}
+	if size <= hard {
+		return truncated, dropped, nil
+	}
+	return nil, nil, fmt.Errorf("topology is %d bytes even with %v dropped, over the %d byte hard limit; keeping the previous object", size, dropped, hard)
+}
+
+func serializedSize(topo *v1alpha1.NetworkTopology) (int, error) {
+	data, err := json.Marshal(topo)
+	if err != nil {
+		return 0, fmt.Errorf("sizing topology: %w", err)
+	}
+	return len(data), nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// sizeguardTopology builds a maps-shape topology whose optional fields
+// dominate the serialized size, so each truncation step shrinks it
+// measurably.
+func sizeguardTopology() *v1alpha1.NetworkTopology {
+	now := metav1.Now()
+	topo := &v1alpha1.NetworkTopology{
+		Spec: v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{}},
+	}
+	stats := v1alpha1.LatencyStats{Min: "1ms", Avg: "2ms", Max: "80ms", Mdev: "3ms"}
+	for _, name := range []string{"pi-1", "pi-2", "hetzner-1"} {
+		topo.Spec.Nodes[name] = v1alpha1.NodeSpec{
+			Latency:           map[string]string{"peer": "2ms"},
+			Bandwidth:         map[string]string{"peer": "900mbps"},
+			LatencyStats:      map[string]v1alpha1.LatencyStats{"peer": stats},
+			ProbeTimes:        map[string]metav1.Time{"peer": now},
+			MeasuredBandwidth: map[string]string{"peer": "850mbps"},
+			MeasuredLatency:   map[string]string{"peer": "3ms"},
+		}
+	}
+	return topo
+}
+
+func TestTruncateTopologyUnderLimitUntouched(t *testing.T) {
+	topo := sizeguardTopology()
+	got, dropped, err := truncateTopology(topo, topologySoftSizeBytes, topologyHardSizeBytes)
+	if err != nil || dropped != nil {
+		t.Fatalf("truncateTopology = dropped %v, err %v; want untouched", dropped, err)
+	}
+	if got != topo {
+		t.Error("under the soft limit the input object itself must be returned")
+	}
+}
+
+func TestTruncateTopologyDegradationOrder(t *testing.T) {
+	topo := sizeguardTopology()
+	full, err := serializedSize(topo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A soft limit just below the full size forces only the first step.
+	statsOnly := topo.DeepCopy()
+	truncationSteps[0].drop(statsOnly)
+	afterStats, err := serializedSize(statsOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, dropped, err := truncateTopology(topo, afterStats, full*2)
+	if err != nil {
+		t.Fatalf("truncateTopology: %v", err)
+	}
+	if !reflect.DeepEqual(dropped, []string{"latencyStats"}) {
+		t.Fatalf("dropped = %v, want latency statistics alone", dropped)
+	}
+	spec := got.Spec.Nodes["pi-1"]
+	if spec.LatencyStats != nil {
+		t.Error("latency statistics survived truncation")
+	}
+	if spec.ProbeTimes == nil || spec.MeasuredBandwidth == nil {
+		t.Error("later steps ran though the first sufficed")
+	}
+	if topo.Spec.Nodes["pi-1"].LatencyStats == nil {
+		t.Error("truncation mutated the input topology")
+	}
+
+	// An unreachable soft limit walks every step in order.
+	_, dropped, err = truncateTopology(topo, 1, full*2)
+	if err != nil {
+		t.Fatalf("truncateTopology: %v", err)
+	}
+	want := []string{"latencyStats", "probeTimes", "linkMetadata", "measuredShadows"}
+	if !reflect.DeepEqual(dropped, want) {
+		t.Errorf("dropped = %v, want the full order %v", dropped, want)
+	}
+}
+
+func TestTruncateTopologyRefusesOverHardLimit(t *testing.T) {
+	topo := sizeguardTopology()
+	_, _, err := truncateTopology(topo, 1, 1)
+	if err == nil {
+		t.Fatal("expected an error over the hard limit")
+	}
+	if !strings.Contains(err.Error(), "hard limit") {
+		t.Errorf("error %q does not name the hard limit", err)
+	}
+}
+
+func TestTruncateTopologyCoversLinksShape(t *testing.T) {
+	now := metav1.Now()
+	topo := &v1alpha1.NetworkTopology{
+		Spec: v1alpha1.NetworkTopologySpec{Links: []v1alpha1.Link{{
+			Source: "pi-1", Target: "pi-2",
+			Latency: "2ms", Bandwidth: "900mbps",
+			LatencyStats:      &v1alpha1.LatencyStats{Min: "1ms", Max: "80ms"},
+			Path:              "derp-fra",
+			Via:               "gateway",
+			MeasurementSource: "iperf3",
+			LastProbeTime:     &now,
+		}}},
+	}
+	got, dropped, err := truncateTopology(topo, 1, 1<<20)
+	if err != nil {
+		t.Fatalf("truncateTopology: %v", err)
+	}
+	if len(dropped) == 0 {
+		t.Fatal("nothing was dropped")
+	}
+	link := got.Spec.Links[0]
+	if link.LatencyStats != nil || link.LastProbeTime != nil || link.Path != "" || link.Via != "" || link.MeasurementSource != "" {
+		t.Errorf("optional link fields survived truncation: %+v", link)
+	}
+	if link.Latency == "" || link.Bandwidth == "" {
+		t.Error("core measurements must never be dropped")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// probeTiming is one pair probe's wall time in the last cycle.
+type probeTiming struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Duration string `json:"duration"`
+
+	elapsed time.Duration
+}
+
+// cycleStats is the last completed cycle's bookkeeping, served as JSON at
+// /debug/status so "is the controller doing useful work" is answerable with
+// curl instead of metrics infrastructure.
+type cycleStats struct {
+	LastCycleStart *time.Time `json:"lastCycleStart,omitempty"`
+	LastCycleEnd   *time.Time `json:"lastCycleEnd,omitempty"`
+	// Epoch is the measurement-cycle counter the last cycle published.
+	Epoch          int64 `json:"epoch,omitempty"`
+	PairsAttempted int   `json:"pairsAttempted"`
+	PairsSucceeded int   `json:"pairsSucceeded"`
+	PairsFailed    int   `json:"pairsFailed"`
+	// PairsDeferred counts directed pairs the sampler left out of the
+	// cycle.
+	PairsDeferred int `json:"pairsDeferred"`
+	// EffectiveInterval is the delay before the next cycle starts.
+	EffectiveInterval string `json:"effectiveInterval"`
+	// Leader is always true today: the controller runs as a single
+	// replica. The field exists so dashboards keep working if leader
+	// election is ever added.
+	Leader bool `json:"leader"`
+	// Resolver names the peer resolver selected at startup.
+	Resolver string `json:"resolver"`
+	// SlowestProbes holds the five slowest pair probes of the last cycle,
+	// slowest first.
+	SlowestProbes []probeTiming `json:"slowestProbes,omitempty"`
+}
+
+// slowestProbeCount bounds the timing list in the status output.
+const slowestProbeCount = 5
+
+// recordCycleStats stores the finished cycle's bookkeeping for /debug/status.
+func (c *controller) recordCycleStats(start, end time.Time, attempted, succeeded, deferred int, epoch int64, timings []probeTiming) {
+	sort.Slice(timings, func(i, j int) bool { return timings[i].elapsed > timings[j].elapsed })
+	if len(timings) > slowestProbeCount {
+		timings = timings[:slowestProbeCount]
+	}
+	for i := range timings {
+		timings[i].Duration = timings[i].elapsed.Round(time.Millisecond).String()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats = cycleStats{
+		LastCycleStart: &start,
+		LastCycleEnd:   &end,
+		Epoch:          epoch,
+		PairsAttempted: attempted,
+		PairsSucceeded: succeeded,
+		PairsFailed:    attempted - succeeded,
+		PairsDeferred:  deferred,
+		SlowestProbes:  timings,
+	}
+}
+
+// statusHandler serves the last cycle's statistics as JSON.
+func (c *controller) statusHandler(w http.ResponseWriter, _ *http.Request) {
+	c.mu.Lock()
+	stats := c.stats
+	c.mu.Unlock()
+	stats.EffectiveInterval = c.opts.interval.String()
+	stats.Leader = true
+	if c.resolver != nil {
+		stats.Resolver = c.resolver.name()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		klog.V(2).InfoS("Writing /debug/status response failed", "err", err)
+	}
+}
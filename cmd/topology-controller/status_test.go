@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStatusHandler(t *testing.T) {
+	c := &controller{
+		opts:     options{interval: 45 * time.Second},
+		resolver: noneResolver{},
+	}
+	start := time.Now().Add(-10 * time.Second)
+	timings := []probeTiming{
+		{Source: "pi-1", Target: "pi-2", elapsed: 2 * time.Second},
+		{Source: "pi-1", Target: "pi-3", elapsed: 9 * time.Second},
+		{Source: "pi-2", Target: "pi-1", elapsed: time.Second},
+		{Source: "pi-2", Target: "pi-3", elapsed: 8 * time.Second},
+		{Source: "pi-3", Target: "pi-1", elapsed: 3 * time.Second},
+		{Source: "pi-3", Target: "pi-2", elapsed: 4 * time.Second},
+	}
+	c.recordCycleStats(start, time.Now(), 6, 4, 14, 7, timings)
+
+	recorder := httptest.NewRecorder()
+	c.statusHandler(recorder, httptest.NewRequest("GET", "/debug/status", nil))
+
+	if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	var status cycleStats
+	if err := json.Unmarshal(recorder.Body.Bytes(), &status); err != nil {
+		t.Fatalf("response is not valid JSON: %v\n%s", err, recorder.Body.String())
+	}
+	if status.PairsAttempted != 6 || status.PairsSucceeded != 4 || status.PairsFailed != 2 || status.PairsDeferred != 14 {
+		t.Errorf("pair counts = %+v", status)
+	}
+	if status.Epoch != 7 {
+		t.Errorf("epoch = %d, want 7", status.Epoch)
+	}
+	if status.EffectiveInterval != "45s" || !status.Leader || status.Resolver != "none" {
+		t.Errorf("interval/leader/resolver = %q/%v/%q", status.EffectiveInterval, status.Leader, status.Resolver)
+	}
+	if status.LastCycleStart == nil || status.LastCycleEnd == nil {
+		t.Fatal("cycle timestamps missing")
+	}
+	if len(status.SlowestProbes) != 5 {
+		t.Fatalf("slowest probes = %d entries, want 5", len(status.SlowestProbes))
+	}
+	if status.SlowestProbes[0].Target != "pi-3" || status.SlowestProbes[0].Duration != "9s" {
+		t.Errorf("slowest probe = %+v, want the 9s pi-1 -> pi-3 probe first", status.SlowestProbes[0])
+	}
+}
+
+func TestStatusHandlerBeforeFirstCycle(t *testing.T) {
+	c := &controller{opts: options{interval: time.Minute}, resolver: noneResolver{}}
+	recorder := httptest.NewRecorder()
+	c.statusHandler(recorder, httptest.NewRequest("GET", "/debug/status", nil))
+
+	var status cycleStats
+	if err := json.Unmarshal(recorder.Body.Bytes(), &status); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	if status.LastCycleStart != nil || status.PairsAttempted != 0 {
+		t.Errorf("pre-cycle status = %+v, want zero values", status)
+	}
+}
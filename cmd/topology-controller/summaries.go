@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+)
+
+// defaultWorstLinks bounds /debug/worst-links when no n parameter is given.
+const defaultWorstLinks = 10
+
+// summaryIndex returns the cached index over the last written topology; the
+// summary endpoints never walk the raw CRD maps themselves.
+func (c *controller) summaryIndex() *topology.Index {
+	c.mu.Lock()
+	last := c.lastTopo
+	c.mu.Unlock()
+	return topology.Shared(last)
+}
+
+// zonesHandler serves the zone×zone medians as JSON.
+func (c *controller) zonesHandler(w http.ResponseWriter, _ *http.Request) {
+	writeSummaryJSON(w, c.summaryIndex().ZoneSummaries())
+}
+
+// capabilitiesHandler serves the capability→nodes listing as JSON.
+func (c *controller) capabilitiesHandler(w http.ResponseWriter, _ *http.Request) {
+	writeSummaryJSON(w, c.summaryIndex().CapabilityNodes())
+}
+
+// worstLinksHandler serves the n unhealthiest links as JSON; ?n= overrides
+// the default of 10.
+func (c *controller) worstLinksHandler(w http.ResponseWriter, r *http.Request) {
+	n := defaultWorstLinks
+	if v := r.URL.Query().Get("n"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "n must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		n = parsed
+	}
+	writeSummaryJSON(w, c.summaryIndex().WorstLinks(n))
+}
+
+func writeSummaryJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		klog.V(2).InfoS("Writing summary response failed", "err", err)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+const (
+	// remeasureAnnotation on the NetworkTopology object requests an
+	// immediate cycle; the controller clears it when acting on it. "true"
+	// re-measures everything, a comma-separated node list restricts the
+	// cycle to pairs touching those nodes.
+	remeasureAnnotation = requirements.AnnotationPrefix + "remeasure"
+
+	// triggerPollInterval is how often the annotation is checked.
+	triggerPollInterval = 10 * time.Second
+
+	// triggerCooldown debounces triggers arriving right after a cycle:
+	// a just-written topology already reflects the network.
+	triggerCooldown = 10 * time.Second
+)
+
+// triggerRequest asks for an immediate measurement cycle, optionally
+// restricted to pairs touching the listed nodes.
+type triggerRequest struct {
+	Nodes []string `json:"nodes,omitempty"`
+}
+
+// requestRemeasure queues a trigger, reporting false when it was debounced:
+// either a cycle just finished or a trigger is already outstanding.
+func (c *controller) requestRemeasure(req triggerRequest, source string) bool {
+	c.mu.Lock()
+	last := c.stats.LastCycleEnd
+	c.mu.Unlock()
+	if last != nil && time.Since(*last) < triggerCooldown {
+		klog.V(2).InfoS("Ignoring re-measure trigger inside the cooldown", "source", source)
+		return false
+	}
+	select {
+	case c.trigger <- req:
+		klog.InfoS("Re-measure triggered", "source", source, "nodes", req.Nodes)
+		return true
+	default:
+		klog.V(2).InfoS("Ignoring re-measure trigger, one already outstanding", "source", source)
+		return false
+	}
+}
+
+// triggerHandler implements POST /trigger. An optional JSON body of the
+// triggerRequest shape restricts the cycle to specific nodes.
+func (c *controller) triggerHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		return
+	}
+	req := triggerRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "body must be JSON like {\"nodes\":[\"pi-1\"]}", http.StatusBadRequest)
+		return
+	}
+	if !c.requestRemeasure(req, "http") {
+		http.Error(w, "a re-measure is already pending or a cycle just completed", http.StatusTooManyRequests)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// runTriggerLoop polls the NetworkTopology object for the remeasure
+// annotation; a watch would be cheaper but the controller already owns the
+// object and the poll is one GET per interval.
+func (c *controller) runTriggerLoop(ctx context.Context) {
+	klog.InfoS("Watching for remeasure annotation", "annotation", remeasureAnnotation, "interval", triggerPollInterval)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(triggerPollInterval):
+		}
+		c.checkRemeasureAnnotation(ctx)
+		c.checkMeasureAnnotation(ctx)
+	}
+}
+
+// checkRemeasureAnnotation clears and acts on the remeasure annotation. The
+// annotation is cleared before triggering so a failed cycle cannot re-fire
+// every poll forever.
+func (c *controller) checkRemeasureAnnotation(ctx context.Context) {
+	obj, err := c.dynamic.Resource(topologyGVR).Get(ctx, topologyName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.V(2).InfoS("Checking remeasure annotation failed", "err", err)
+		}
+		return
+	}
+	value, ok := obj.GetAnnotations()[remeasureAnnotation]
+	if !ok || value == "" || value == "false" {
+		return
+	}
+	annotations := obj.GetAnnotations()
+	delete(annotations, remeasureAnnotation)
+	obj.SetAnnotations(annotations)
+	if _, err := c.dynamic.Resource(topologyGVR).Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		klog.ErrorS(err, "Clearing remeasure annotation")
+		return
+	}
+	req := triggerRequest{}
+	if value != "true" {
+		for _, node := range strings.Split(value, ",") {
+			if node = strings.TrimSpace(node); node != "" {
+				req.Nodes = append(req.Nodes, node)
+			}
+		}
+	}
+	c.requestRemeasure(req, "annotation")
+}
+
+// setRemeasureScope restricts the next cycle to pairs touching the listed
+// nodes; takeRemeasureScope consumes it so the restriction lasts one cycle.
+func (c *controller) setRemeasureScope(nodes []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remeasureScope = nodes
+}
+
+func (c *controller) takeRemeasureScope() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	scope := c.remeasureScope
+	c.remeasureScope = nil
+	return scope
+}
+
+// fillFromSmoothed carries a pair's previous smoothed values into a
+// restricted cycle's node spec, so an on-demand re-measure of one node does
+// not erase everyone else's links from the written topology.
+func (c *controller) fillFromSmoothed(spec *v1alpha1.NodeSpec, source, target string) {
+	c.mu.Lock()
+	state := c.smoothed[source][target]
+	c.mu.Unlock()
+	if state == nil || !state.measured {
+		return
+	}
+	spec.Latency[target] = units.FormatLatency(state.latency)
+	if spec.LatencyStats != nil && state.stats.Max > 0 {
+		spec.LatencyStats[target] = formatLatencyStats(state.stats)
+	}
+	spec.Bandwidth[target] = units.FormatBandwidth(state.bandwidth)
+	if spec.BandwidthRx != nil && state.bandwidthRx > 0 {
+		spec.BandwidthRx[target] = units.FormatBandwidth(state.bandwidthRx)
+	}
+	spec.Cost[target] = c.linkCost(state)
+	spec.ProbeTimes[target] = metav1.NewTime(state.lastProbe)
+}
+
+// restrictPairs drops sampled pairs touching none of the scoped nodes.
+func restrictPairs(pairs map[string]map[string]bool, scope []string) map[string]map[string]bool {
+	scoped := make(map[string]bool, len(scope))
+	for _, node := range scope {
+		scoped[node] = true
+	}
+	restricted := map[string]map[string]bool{}
+	for source, targets := range pairs {
+		for target := range targets {
+			if !scoped[source] && !scoped[target] {
+				continue
+			}
+			if restricted[source] == nil {
+				restricted[source] = map[string]bool{}
+			}
+			restricted[source][target] = true
+		}
+	}
+	return restricted
+}
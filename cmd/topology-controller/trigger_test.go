@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+func triggerController() *controller {
+	return &controller{trigger: make(chan triggerRequest, 1)}
+}
+
+func TestTriggerHandler(t *testing.T) {
+	c := triggerController()
+	recorder := httptest.NewRecorder()
+	c.triggerHandler(recorder, httptest.NewRequest("POST", "/trigger", nil))
+	if recorder.Code != 202 {
+		t.Fatalf("POST /trigger = %d, want 202", recorder.Code)
+	}
+	select {
+	case req := <-c.trigger:
+		if len(req.Nodes) != 0 {
+			t.Errorf("bodyless trigger carried nodes: %+v", req)
+		}
+	default:
+		t.Fatal("no trigger queued")
+	}
+
+	recorder = httptest.NewRecorder()
+	c.triggerHandler(recorder, httptest.NewRequest("GET", "/trigger", nil))
+	if recorder.Code != 405 {
+		t.Errorf("GET /trigger = %d, want 405", recorder.Code)
+	}
+}
+
+func TestTriggerHandlerNodeRestriction(t *testing.T) {
+	c := triggerController()
+	body := strings.NewReader(`{"nodes":["pi-1","pi-2"]}`)
+	recorder := httptest.NewRecorder()
+	c.triggerHandler(recorder, httptest.NewRequest("POST", "/trigger", body))
+	if recorder.Code != 202 {
+		t.Fatalf("POST /trigger = %d, want 202", recorder.Code)
+	}
+	req := <-c.trigger
+	if len(req.Nodes) != 2 || req.Nodes[0] != "pi-1" {
+		t.Errorf("trigger nodes = %+v", req.Nodes)
+	}
+
+	recorder = httptest.NewRecorder()
+	c.triggerHandler(recorder, httptest.NewRequest("POST", "/trigger", strings.NewReader("{bad")))
+	if recorder.Code != 400 {
+		t.Errorf("malformed body = %d, want 400", recorder.Code)
+	}
+}
+
+func TestTriggerDebounce(t *testing.T) {
+	c := triggerController()
+	if !c.requestRemeasure(triggerRequest{}, "test") {
+		t.Fatal("first trigger rejected")
+	}
+	if c.requestRemeasure(triggerRequest{}, "test") {
+		t.Error("second trigger accepted while one is outstanding")
+	}
+
+	// A cycle that just completed suppresses new triggers.
+	<-c.trigger
+	end := time.Now()
+	c.stats.LastCycleEnd = &end
+	if c.requestRemeasure(triggerRequest{}, "test") {
+		t.Error("trigger accepted inside the post-cycle cooldown")
+	}
+	old := end.Add(-2 * triggerCooldown)
+	c.stats.LastCycleEnd = &old
+	if !c.requestRemeasure(triggerRequest{}, "test") {
+		t.Error("trigger rejected after the cooldown expired")
+	}
+}
+
+func TestRestrictPairs(t *testing.T) {
+	members := zonedMembers(4, []string{"a"})
+	pairs := restrictPairs(fullMeshSampler{}.sample(members), []string{"node-01"})
+	if got, want := pairCount(pairs), 3*2; got != want {
+		t.Fatalf("restricted pair count = %d, want %d (edges touching node-01)", got, want)
+	}
+	if pairs["node-02"]["node-03"] {
+		t.Error("pair touching no scoped node survived the restriction")
+	}
+	if !pairs["node-02"]["node-01"] || !pairs["node-01"]["node-02"] {
+		t.Error("pairs touching the scoped node were dropped")
+	}
+}
+
+func TestCarryEpoch(t *testing.T) {
+	prior := &v1alpha1.NetworkTopology{
+		Spec: v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{
+			"pi-1": {Epoch: 3},
+			"pi-2": {},
+		}},
+	}
+	if got := carryEpoch(prior, "pi-1", 5); got != 3 {
+		t.Errorf("untouched node carried epoch %d, want its measured epoch 3", got)
+	}
+	// Nodes without a recorded epoch (pre-epoch controllers) and nodes the
+	// prior publication never saw take the current cycle's epoch.
+	if got := carryEpoch(prior, "pi-2", 5); got != 5 {
+		t.Errorf("pre-epoch node carried %d, want 5", got)
+	}
+	if got := carryEpoch(prior, "pi-3", 5); got != 5 {
+		t.Errorf("new node carried %d, want 5", got)
+	}
+	if got := carryEpoch(nil, "pi-1", 5); got != 5 {
+		t.Errorf("first cycle carried %d, want 5", got)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// Off-cluster write modes. When the controller runs somewhere that is not a
+// cluster node — the run-it-from-a-laptop dev flow — probes describe that
+// machine's connectivity, and attributing them to real source nodes would be
+// actively misleading.
+const (
+	// offClusterWriteRefuse publishes no measurements at all; node
+	// metadata, declared/imported values and status still flow.
+	offClusterWriteRefuse = "refuse"
+	// offClusterWriteExternal records measurements under a synthetic
+	// source entry so they are usable but unmistakably not node-to-node.
+	offClusterWriteExternal = "external"
+)
+
+// externalSourceName is the synthetic node the external write mode publishes
+// under. Its Cluster field carries the same marker so readers listing nodes
+// can separate it from real cluster members.
+const externalSourceName = "external"
+
+// detectProbeVantage resolves which network namespace probes run in. The
+// explicit modes trust the operator; auto compares the hostname with the
+// downward-API NODE_NAME, which hostNetwork pods inherit from the node while
+// pod-network pods get their pod name.
+func detectProbeVantage(mode string) string {
+	if mode != "auto" {
+		return mode
+	}
+	hostname, _ := os.Hostname()
+	if name := os.Getenv("NODE_NAME"); name != "" && name == hostname {
+		return v1alpha1.ProbeVantageHost
+	}
+	return v1alpha1.ProbeVantageOverlay
+}
+
+// detectVantage determines, once, whether the controller is running on one of
+// the cluster's nodes: the downward-API NODE_NAME wins, falling back to the
+// hostname, and either must match a node name. The verdict decides how every
+// subsequent measurement is written, so it is logged prominently.
+func (c *controller) detectVantage(nodes []v1.Node) bool {
+	c.vantageOnce.Do(func() {
+		name := os.Getenv("NODE_NAME")
+		if name == "" {
+			name, _ = os.Hostname()
+		}
+		for i := range nodes {
+			if nodes[i].Name == name {
+				c.localNode = name
+			}
+		}
+		if c.localNode != "" {
+			klog.InfoS("Controller is running on a cluster node; measurements are written per source node", "node", c.localNode)
+			return
+		}
+		c.offCluster = true
+		switch c.opts.offClusterWrites {
+		case offClusterWriteExternal:
+			klog.InfoS("Controller is NOT running on a cluster node; measurements will be written under the synthetic external source", "identity", name, "source", externalSourceName)
+		default:
+			klog.InfoS("Controller is NOT running on a cluster node; refusing to write measurements (see --off-cluster-writes)", "identity", name)
+		}
+	})
+	return c.offCluster
+}
+
+// externalSpec probes every member from the controller's own vantage and
+// returns the synthetic source entry for the external write mode, plus the
+// number of links measured.
+func (c *controller) externalSpec(ctx context.Context, members []memberNode, addresses map[string]string, tailnet, agent map[string]bool) (v1alpha1.NodeSpec, int) {
+	spec := v1alpha1.NodeSpec{
+		Cluster:    externalSourceName,
+		Bandwidth:  map[string]string{},
+		Latency:    map[string]string{},
+		Cost:       map[string]float64{},
+		ProbeTimes: map[string]metav1.Time{},
+	}
+	measured := 0
+	for _, member := range members {
+		target := member.node.Name
+		link := c.measurePair(ctx, externalSourceName, target, addresses[target], tailnet[target], agent[target])
+		if link == nil || !link.measured {
+			continue
+		}
+		measured++
+		spec.Latency[target] = units.FormatLatency(link.latency)
+		if !link.bandwidthUnsupported {
+			spec.Bandwidth[target] = units.FormatBandwidth(link.bandwidth)
+			spec.Cost[target] = c.linkCost(link)
+		}
+		spec.ProbeTimes[target] = metav1.NewTime(link.lastProbe)
+	}
+	return spec, measured
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func vantageNodes(names ...string) []v1.Node {
+	nodes := make([]v1.Node, 0, len(names))
+	for _, name := range names {
+		nodes = append(nodes, v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	return nodes
+}
+
+func TestDetectVantageOnCluster(t *testing.T) {
+	t.Setenv("NODE_NAME", "pi-2")
+	c := &controller{}
+	if c.detectVantage(vantageNodes("pi-1", "pi-2")) {
+		t.Fatal("reported off-cluster although NODE_NAME matches a node")
+	}
+	if c.localNode != "pi-2" {
+		t.Errorf("localNode = %q, want pi-2", c.localNode)
+	}
+}
+
+func TestDetectVantageOffCluster(t *testing.T) {
+	t.Setenv("NODE_NAME", "dev-laptop")
+	c := &controller{}
+	if !c.detectVantage(vantageNodes("pi-1", "pi-2")) {
+		t.Fatal("reported on-cluster although NODE_NAME matches no node")
+	}
+	if c.localNode != "" {
+		t.Errorf("localNode = %q, want empty off-cluster", c.localNode)
+	}
+}
+
+func TestDetectVantageIsSticky(t *testing.T) {
+	t.Setenv("NODE_NAME", "pi-1")
+	c := &controller{}
+	if c.detectVantage(vantageNodes("pi-1")) {
+		t.Fatal("reported off-cluster on the first cycle")
+	}
+	// A later cycle that lists different nodes must not flip the verdict:
+	// the controller's location does not change at runtime.
+	if c.detectVantage(vantageNodes("other")) {
+		t.Error("verdict flipped on a later cycle")
+	}
+}
+
+func TestDetectProbeVantage(t *testing.T) {
+	if got := detectProbeVantage("host"); got != "host" {
+		t.Errorf("explicit host mode resolved to %q", got)
+	}
+	if got := detectProbeVantage("overlay"); got != "overlay" {
+		t.Errorf("explicit overlay mode resolved to %q", got)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatalf("hostname: %v", err)
+	}
+	t.Setenv("NODE_NAME", hostname)
+	if got := detectProbeVantage("auto"); got != "host" {
+		t.Errorf("auto with NODE_NAME matching the hostname resolved to %q, want host", got)
+	}
+	t.Setenv("NODE_NAME", "pi-1")
+	if got := detectProbeVantage("auto"); got != "overlay" {
+		t.Errorf("auto with NODE_NAME differing from the hostname resolved to %q, want overlay", got)
+	}
+}
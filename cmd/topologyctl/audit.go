@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// auditEntry mirrors the controller's audit log line format.
+type auditEntry struct {
+	Time   time.Time `json:"time"`
+	Node   string    `json:"node"`
+	Target string    `json:"target,omitempty"`
+	Field  string    `json:"field"`
+	Old    string    `json:"old,omitempty"`
+	New    string    `json:"new,omitempty"`
+	Cause  string    `json:"cause"`
+}
+
+func runAudit(args []string) error {
+	if len(args) < 1 || args[0] != "tail" {
+		return fmt.Errorf("usage: topologyctl audit tail [-link a:b] [-f] [file]")
+	}
+	fs := flag.NewFlagSet("audit tail", flag.ExitOnError)
+	link := fs.String("link", "", "Only show entries for this link, formatted source:target.")
+	follow := fs.Bool("f", false, "Keep the file open and print entries as they are appended.")
+	fs.Parse(args[1:])
+
+	var source, target string
+	if *link != "" {
+		parts := strings.SplitN(*link, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("--link must be formatted source:target")
+		}
+		source, target = parts[0], parts[1]
+	}
+
+	in := os.Stdin
+	if path := fs.Arg(0); path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	reader := bufio.NewReader(in)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			printEntry(line, source, target)
+		}
+		if err == io.EOF {
+			if !*follow {
+				return nil
+			}
+			// Rotation swaps the file out from under us; entries
+			// written to the successor need a fresh invocation.
+			time.Sleep(500 * time.Millisecond)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func printEntry(line []byte, source, target string) {
+	entry := auditEntry{}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		// Pass unparseable lines through untouched; the stream may be
+		// interleaved with other output.
+		os.Stdout.Write(line)
+		return
+	}
+	if source != "" && (entry.Node != source || entry.Target != target) {
+		return
+	}
+	fmt.Printf("%s  %s -> %s  %s: %q -> %q (%s)\n",
+		entry.Time.Format(time.RFC3339), entry.Node, entry.Target,
+		entry.Field, entry.Old, entry.New, entry.Cause)
+}
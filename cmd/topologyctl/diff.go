@@ -0,0 +1,214 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+const (
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorReset = "\033[0m"
+)
+
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	threshold := fs.Float64("threshold", 0.1, "Minimum relative change in latency or bandwidth to report (0.1 = 10%).")
+	costThreshold := fs.Float64("cost-threshold", 0.05, "Minimum absolute cost change to report.")
+	noColor := fs.Bool("no-color", false, "Disable colored output.")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return fmt.Errorf("diff takes exactly two snapshot files")
+	}
+	before, err := loadSnapshot(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	after, err := loadSnapshot(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	d := differ{
+		before:        topology.NewIndex(before),
+		after:         topology.NewIndex(after),
+		beforeName:    fs.Arg(0),
+		afterName:     fs.Arg(1),
+		threshold:     *threshold,
+		costThreshold: *costThreshold,
+		color:         !*noColor && isTerminal(os.Stdout),
+	}
+	d.print()
+	return nil
+}
+
+// differ compares two topology snapshots through the pkg/topology index, so
+// snapshots in the maps shape and the links shape diff identically.
+type differ struct {
+	before, after         *topology.Index
+	beforeName, afterName string
+	threshold             float64
+	costThreshold         float64
+	color                 bool
+}
+
+func (d *differ) print() {
+	for _, name := range unionNames(d.before.NodeNames(), d.after.NodeNames()) {
+		beforeSpec, inBefore := d.before.Node(name)
+		afterSpec, inAfter := d.after.Node(name)
+		switch {
+		case !inBefore:
+			fmt.Printf("node %s: only in %s\n", name, d.afterName)
+		case !inAfter:
+			fmt.Printf("node %s: only in %s\n", name, d.beforeName)
+		default:
+			d.printNode(name, beforeSpec, afterSpec)
+		}
+	}
+}
+
+func (d *differ) printNode(name string, before, after v1alpha1.NodeSpec) {
+	for _, target := range unionNames(linkTargets(before), linkTargets(after)) {
+		d.printLatency(name, target, before.Latency[target], after.Latency[target])
+		d.printBandwidth(name, target, before.Bandwidth[target], after.Bandwidth[target])
+		d.printCost(name, target, before.Cost, after.Cost)
+	}
+}
+
+func (d *differ) printLatency(source, target, before, after string) {
+	if before == after {
+		return
+	}
+	switch {
+	case before == "":
+		fmt.Printf("%s -> %s  latency appeared: %s\n", source, target, after)
+	case after == "":
+		fmt.Printf("%s -> %s  latency disappeared (was %s)\n", source, target, before)
+	default:
+		b, errB := units.ParseLatency(before)
+		a, errA := units.ParseLatency(after)
+		if errB != nil || errA != nil {
+			fmt.Printf("%s -> %s  latency %s -> %s\n", source, target, before, after)
+			return
+		}
+		change := relativeChange(float64(b), float64(a))
+		if abs(change) < d.threshold {
+			return
+		}
+		// Higher latency is worse.
+		fmt.Printf("%s -> %s  latency %s -> %s (%s)\n",
+			source, target, before, after, d.colored(formatPercent(change), change > 0))
+	}
+}
+
+func (d *differ) printBandwidth(source, target, before, after string) {
+	if before == after {
+		return
+	}
+	switch {
+	case before == "":
+		fmt.Printf("%s -> %s  bandwidth appeared: %s\n", source, target, after)
+	case after == "":
+		fmt.Printf("%s -> %s  bandwidth disappeared (was %s)\n", source, target, before)
+	default:
+		b, errB := units.ParseBandwidth(before)
+		a, errA := units.ParseBandwidth(after)
+		if errB != nil || errA != nil {
+			fmt.Printf("%s -> %s  bandwidth %s -> %s\n", source, target, before, after)
+			return
+		}
+		change := relativeChange(float64(b), float64(a))
+		if abs(change) < d.threshold {
+			return
+		}
+		// Lower bandwidth is worse.
+		fmt.Printf("%s -> %s  bandwidth %s -> %s (%s)\n",
+			source, target, before, after, d.colored(formatPercent(change), change < 0))
+	}
+}
+
+func (d *differ) printCost(source, target string, before, after map[string]float64) {
+	b, inBefore := before[target]
+	a, inAfter := after[target]
+	if !inBefore && !inAfter {
+		return
+	}
+	if abs(a-b) < d.costThreshold {
+		return
+	}
+	fmt.Printf("%s -> %s  cost %.2f -> %.2f (%s)\n",
+		source, target, b, a, d.colored(fmt.Sprintf("%+.2f", a-b), a > b))
+}
+
+func (d *differ) colored(s string, worse bool) string {
+	if !d.color {
+		return s
+	}
+	if worse {
+		return colorRed + s + colorReset
+	}
+	return colorGreen + s + colorReset
+}
+
+// linkTargets returns every node this spec has any measurement toward.
+func linkTargets(spec v1alpha1.NodeSpec) []string {
+	seen := map[string]bool{}
+	for target := range spec.Latency {
+		seen[target] = true
+	}
+	for target := range spec.Bandwidth {
+		seen[target] = true
+	}
+	for target := range spec.Cost {
+		seen[target] = true
+	}
+	targets := make([]string, 0, len(seen))
+	for target := range seen {
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+func unionNames(a, b []string) []string {
+	seen := map[string]bool{}
+	for _, name := range a {
+		seen[name] = true
+	}
+	for _, name := range b {
+		seen[name] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func relativeChange(before, after float64) float64 {
+	if before == 0 {
+		return 0
+	}
+	return (after - before) / before
+}
+
+func formatPercent(change float64) string {
+	return fmt.Sprintf("%+.0f%%", change*100)
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/export"
+)
+
+// runExport reads the JSON-lines files written by the controller's
+// --export-file flag or the scheduler's exportFile arg and prints the rows
+// as CSV or JSON, filtered by age. It reads the rotated predecessor too, so
+// a rotation mid-window loses nothing.
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	since := fs.Duration("since", 24*time.Hour, "Only print rows newer than this; 0 prints everything.")
+	format := fs.String("format", "csv", `Output format: "csv" or "json".`)
+	table := fs.String("table", "links", `Which rows the file holds: "links" (controller link samples) or "decisions" (scheduler decisions).`)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: topologyctl export [-since 24h] [-format csv] [-table links] file")
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	path := fs.Arg(0)
+	cutoff := time.Time{}
+	if *since > 0 {
+		cutoff = time.Now().Add(-*since)
+	}
+
+	switch *table {
+	case "links":
+		rows, err := export.ReadLinkSamples(path, cutoff)
+		if err != nil {
+			return err
+		}
+		if *format == "json" {
+			return printJSON(rows)
+		}
+		return printLinkCSV(rows)
+	case "decisions":
+		rows, err := export.ReadDecisions(path, cutoff)
+		if err != nil {
+			return err
+		}
+		if *format == "json" {
+			return printJSON(rows)
+		}
+		return printDecisionCSV(rows)
+	default:
+		return fmt.Errorf("unknown table %q", *table)
+	}
+}
+
+func printJSON(rows interface{}) error {
+	data, err := json.MarshalIndent(rows, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func printLinkCSV(rows []export.LinkSample) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"time", "source", "target", "latency_ms", "bandwidth_mbps", "cost"})
+	for _, r := range rows {
+		w.Write([]string{
+			r.Time.Format(time.RFC3339),
+			r.Source,
+			r.Target,
+			strconv.FormatFloat(r.LatencyMs, 'f', 3, 64),
+			strconv.FormatInt(r.BandwidthMbps, 10),
+			strconv.FormatFloat(r.Cost, 'f', 4, 64),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func printDecisionCSV(rows []export.Decision) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"time", "namespace", "pod", "node", "score", "source"})
+	for _, r := range rows {
+		w.Write([]string{
+			r.Time.Format(time.RFC3339),
+			r.Namespace,
+			r.Pod,
+			r.Node,
+			strconv.FormatInt(r.Score, 10),
+			r.Source,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
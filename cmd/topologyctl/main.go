@@ -0,0 +1,200 @@
+// topologyctl captures and compares NetworkTopology state: "snapshot" saves
+// the live object to a timestamped JSON file, "diff" compares two snapshots
+// link by link, and "restore" applies a snapshot back to the cluster (useful
+// for synthetic topologies in test environments). "recommend" re-scores
+// running pods against the current topology and reports which would be
+// meaningfully better off on another node, and "export" prints the JSON-lines
+// analysis rows written by the controller and scheduler export sinks.
+// "verify-node" gives provisioning pipelines a one-shot verdict on whether a
+// node meets the link and capability expectations of its intended role.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+const topologyName = "cluster"
+
+var topologyGVR = v1alpha1.SchemeGroupVersion.WithResource("networktopologies")
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "snapshot":
+		err = runSnapshot(os.Args[2:])
+	case "diff":
+		err = runDiff(os.Args[2:])
+	case "restore":
+		err = runRestore(os.Args[2:])
+	case "audit":
+		err = runAudit(os.Args[2:])
+	case "recommend":
+		err = runRecommend(os.Args[2:])
+	case "export":
+		err = runExport(os.Args[2:])
+	case "verify-node":
+		err = runVerifyNode(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage:
+  topologyctl snapshot [-kubeconfig path] [-o file]   save the live topology ("-" for stdout)
+  topologyctl diff [flags] a.json b.json              print per-link deltas between two snapshots
+  topologyctl restore [-kubeconfig path] file         apply a snapshot back to the cluster
+  topologyctl audit tail [-link a:b] [-f] [file]      print controller audit log entries
+  topologyctl recommend [flags]                       report running pods that would score better elsewhere
+  topologyctl export [-since 24h] [-format csv] file  print exported link samples or scheduling decisions
+  topologyctl verify-node [flags] -profile name node  check a node against a role profile; non-zero exit on failure`)
+}
+
+func runSnapshot(args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	out := fs.String("o", "", `Output file; "-" for stdout. Defaults to topology-<timestamp>.json.`)
+	fs.Parse(args)
+
+	topo, err := fetchTopology(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(topo, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if *out == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	path := *out
+	if path == "" {
+		path = fmt.Sprintf("topology-%s.json", time.Now().Format("20060102-150405"))
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "wrote", path)
+	return nil
+}
+
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("restore takes exactly one snapshot file")
+	}
+	topo, err := loadSnapshot(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	// The snapshot's object bookkeeping belongs to the cluster it was
+	// taken from; the target cluster assigns its own.
+	topo.ResourceVersion = ""
+	topo.UID = ""
+	topo.ManagedFields = nil
+	if topo.Name == "" {
+		topo.Name = topologyName
+	}
+
+	dyn, err := dynamicClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(topo)
+	if err != nil {
+		return fmt.Errorf("converting topology: %w", err)
+	}
+	u := &unstructured.Unstructured{Object: obj}
+	u.SetAPIVersion(v1alpha1.SchemeGroupVersion.String())
+	u.SetKind("NetworkTopology")
+
+	ctx := context.Background()
+	existing, err := dyn.Resource(topologyGVR).Get(ctx, topo.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = dyn.Resource(topologyGVR).Create(ctx, u, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("fetching topology: %w", err)
+	}
+	u.SetResourceVersion(existing.GetResourceVersion())
+	if _, err := dyn.Resource(topologyGVR).Update(ctx, u, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "restored", topo.Name)
+	return nil
+}
+
+// loadSnapshot reads a snapshot file; YAML is accepted alongside JSON so
+// hand-written synthetic topologies work too.
+func loadSnapshot(path string) (*v1alpha1.NetworkTopology, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	topo := &v1alpha1.NetworkTopology{}
+	if err := yaml.Unmarshal(data, topo); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return topo, nil
+}
+
+func fetchTopology(kubeconfig string) (*v1alpha1.NetworkTopology, error) {
+	dyn, err := dynamicClient(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := dyn.Resource(topologyGVR).Get(context.Background(), topologyName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("fetching topology: %w", err)
+	}
+	topo := &v1alpha1.NetworkTopology{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, topo); err != nil {
+		return nil, fmt.Errorf("converting NetworkTopology: %w", err)
+	}
+	return topo, nil
+}
+
+func dynamicClient(kubeconfig string) (dynamic.Interface, error) {
+	config, err := buildConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfig(config)
+}
+
+func buildConfig(kubeconfig string) (*rest.Config, error) {
+	if kubeconfig != "" {
+		return clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+	return rest.InClusterConfig()
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/scheduler/networkaware"
+)
+
+// recommendation says one running pod would score meaningfully better
+// elsewhere. Scores are the scheduler's normalized 0-100 ranking; -1 marks
+// a current node that would not even pass Filter today.
+type recommendation struct {
+	Pod          string `json:"pod"`
+	CurrentNode  string `json:"currentNode"`
+	CurrentScore int64  `json:"currentScore"`
+	BestNode     string `json:"bestNode"`
+	BestScore    int64  `json:"bestScore"`
+	Gain         int64  `json:"gain"`
+}
+
+// recommendReport is the JSON output of the recommend subcommand.
+type recommendReport struct {
+	GeneratedAt     time.Time        `json:"generatedAt"`
+	Margin          int64            `json:"margin"`
+	PodsEvaluated   int              `json:"podsEvaluated"`
+	Recommendations []recommendation `json:"recommendations"`
+}
+
+// runRecommend re-scores every network-annotated running pod on all nodes
+// against the current topology, through the same simulation path the
+// scheduler's scoring uses, and reports pods whose best alternative beats
+// their current node by more than the margin. Read-only unless -apply is
+// given, which hands the worst offenders to the eviction API (honoring
+// PodDisruptionBudgets) up to the budget so their controllers reschedule
+// them.
+func runRecommend(args []string) error {
+	fs := flag.NewFlagSet("recommend", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	margin := fs.Int64("margin", 20, "Minimum normalized-score improvement (0-100 scale) before a move is recommended.")
+	out := fs.String("o", "-", `Output file for the JSON report; "-" for stdout.`)
+	apply := fs.Bool("apply", false, "Evict recommended pods (best gain first) so their controllers reschedule them.")
+	budget := fs.Int("eviction-budget", 2, "Maximum evictions with -apply.")
+	fs.Parse(args)
+
+	topo, err := fetchTopology(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	config, err := buildConfig(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	nodeList, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("listing nodes: %w", err)
+	}
+	nodes := make([]*v1.Node, 0, len(nodeList.Items))
+	for i := range nodeList.Items {
+		nodes = append(nodes, &nodeList.Items[i])
+	}
+	podList, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("listing pods: %w", err)
+	}
+
+	report := recommendReport{GeneratedAt: time.Now(), Margin: *margin}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if !recommendable(pod) {
+			continue
+		}
+		report.PodsEvaluated++
+		rec, ok, err := recommendPod(pod, nodes, topo, *margin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "skipping %s/%s: %v\n", pod.Namespace, pod.Name, err)
+			continue
+		}
+		if ok {
+			report.Recommendations = append(report.Recommendations, rec)
+		}
+	}
+	sort.Slice(report.Recommendations, func(i, j int) bool {
+		return report.Recommendations[i].Gain > report.Recommendations[j].Gain
+	})
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if *out == "-" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return err
+		}
+	} else if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return err
+	}
+
+	if !*apply {
+		return nil
+	}
+	return applyRecommendations(ctx, client, report.Recommendations, *budget)
+}
+
+// recommendable selects running pods that opted into network-aware
+// placement, via requirement annotations or preference labels; everything
+// else is invisible to the scheduler plugin and has nothing to gain.
+func recommendable(pod *v1.Pod) bool {
+	if pod.Spec.NodeName == "" || pod.Status.Phase != v1.PodRunning {
+		return false
+	}
+	return requirements.HasAnnotations(pod) || pod.Labels[networkaware.LabelColocateGroup] != ""
+}
+
+// recommendPod simulates the pod on every node and reports whether its
+// best feasible alternative beats the current node by more than the
+// margin. A current node that fails Filter outright recommends any
+// feasible node.
+func recommendPod(pod *v1.Pod, nodes []*v1.Node, topo *v1alpha1.NetworkTopology, margin int64) (recommendation, bool, error) {
+	results, err := networkaware.Simulate(nil, pod, nodes, topo)
+	if err != nil {
+		return recommendation{}, false, err
+	}
+	current := int64(-1)
+	for _, r := range results {
+		if r.Node == pod.Spec.NodeName {
+			if r.Feasible {
+				current = r.NormalizedScore
+			}
+			break
+		}
+	}
+	// Results are ranked feasible-first, best score first.
+	if len(results) == 0 || !results[0].Feasible || results[0].Node == pod.Spec.NodeName {
+		return recommendation{}, false, nil
+	}
+	best := results[0]
+	if current >= 0 && best.NormalizedScore-current <= margin {
+		return recommendation{}, false, nil
+	}
+	return recommendation{
+		Pod:          pod.Namespace + "/" + pod.Name,
+		CurrentNode:  pod.Spec.NodeName,
+		CurrentScore: current,
+		BestNode:     best.Node,
+		BestScore:    best.NormalizedScore,
+		Gain:         best.NormalizedScore - current,
+	}, true, nil
+}
+
+// applyRecommendations evicts the highest-gain pods up to the budget,
+// through the eviction API so PodDisruptionBudgets are honored
+// server-side — the same guardrail the descheduler's eviction path uses.
+func applyRecommendations(ctx context.Context, client kubernetes.Interface, recs []recommendation, budget int) error {
+	evicted := 0
+	for _, rec := range recs {
+		if evicted >= budget {
+			fmt.Fprintf(os.Stderr, "eviction budget (%d) exhausted, %d recommendations left unapplied\n",
+				budget, len(recs)-evicted)
+			break
+		}
+		namespace, name, err := splitPodRef(rec.Pod)
+		if err != nil {
+			return err
+		}
+		eviction := &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		if err := client.CoreV1().Pods(namespace).EvictV1(ctx, eviction); err != nil {
+			if apierrors.IsTooManyRequests(err) {
+				fmt.Fprintf(os.Stderr, "eviction of %s blocked by PodDisruptionBudget\n", rec.Pod)
+				continue
+			}
+			return fmt.Errorf("evicting %s: %w", rec.Pod, err)
+		}
+		evicted++
+		fmt.Fprintf(os.Stderr, "evicted %s (gain %d, %s -> %s)\n", rec.Pod, rec.Gain, rec.CurrentNode, rec.BestNode)
+	}
+	return nil
+}
+
+func splitPodRef(ref string) (string, string, error) {
+	for i := range ref {
+		if ref[i] == '/' {
+			return ref[:i], ref[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("pod reference %q is not <namespace>/<name>", ref)
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+)
+
+// remeasureAnnotation mirrors the controller's trigger annotation: writing a
+// node name to it scopes the next measurement cycle to that node's links.
+const remeasureAnnotation = requirements.AnnotationPrefix + "remeasure"
+
+// defaultProfilesConfigMap is where verify-node looks for profiles when no
+// local file is given.
+const defaultProfilesConfigMap = "default/topology-verify-profiles"
+
+// verifyProfile is one named role a node can be checked against: the
+// capabilities it must carry and the link quality it must reach its targets
+// with. Profiles live in a YAML file or a ConfigMap, not in the
+// NodeNetworkProfile CRD — that CRD declares facts about a node, while a
+// profile declares expectations of a role.
+type verifyProfile struct {
+	// Capabilities the node must expose in the topology (or via its
+	// well-known labels), e.g. "gpu", "internet".
+	Capabilities []string `json:"capabilities,omitempty"`
+	// Targets are per-link requirements against a named node or every node
+	// of a zone.
+	Targets []verifyTarget `json:"targets,omitempty"`
+}
+
+// verifyTarget bounds the link from the verified node toward one named node,
+// or toward every node of a zone. Exactly one of Node and Zone is set.
+type verifyTarget struct {
+	Node string `json:"node,omitempty"`
+	Zone string `json:"zone,omitempty"`
+	// MaxLatency and MinBandwidth use the topology's human units
+	// ("5ms", "500mbps"); either may be omitted.
+	MaxLatency   string `json:"maxLatency,omitempty"`
+	MinBandwidth string `json:"minBandwidth,omitempty"`
+}
+
+// runVerifyNode checks one node against a named profile: it asks the
+// controller for fresh measurements of the node's links via the remeasure
+// annotation, waits for them, and then evaluates every profile requirement,
+// exiting non-zero when any fails — the one-shot verdict a provisioning
+// pipeline gates on.
+func runVerifyNode(args []string) error {
+	fs := flag.NewFlagSet("verify-node", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig; in-cluster config is used when empty.")
+	profileName := fs.String("profile", "", "Name of the profile to verify against (required).")
+	profilesFile := fs.String("profiles-file", "", "Local YAML file mapping profile names to definitions; read instead of the ConfigMap when set.")
+	profilesConfigMap := fs.String("profiles-configmap", defaultProfilesConfigMap, "ConfigMap holding profiles as <namespace>/<name>; each data key is one profile.")
+	wait := fs.Duration("wait", 2*time.Minute, "How long to wait for the controller to refresh the node's measurements; 0 evaluates the current topology as-is.")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("verify-node takes exactly one node name")
+	}
+	if *profileName == "" {
+		return fmt.Errorf("-profile is required")
+	}
+	nodeName := fs.Arg(0)
+
+	config, err := buildConfig(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	profiles, err := loadProfiles(ctx, client, *profilesFile, *profilesConfigMap)
+	if err != nil {
+		return err
+	}
+	profile, ok := profiles[*profileName]
+	if !ok {
+		known := make([]string, 0, len(profiles))
+		for name := range profiles {
+			known = append(known, name)
+		}
+		sort.Strings(known)
+		return fmt.Errorf("profile %q not found (have: %s)", *profileName, strings.Join(known, ", "))
+	}
+
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("fetching node: %w", err)
+	}
+
+	if *wait > 0 {
+		if err := remeasureNode(ctx, *kubeconfig, nodeName, *wait); err != nil {
+			return err
+		}
+	}
+	topo, err := fetchTopology(*kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	failures, err := verifyReport(os.Stdout, topo, node, profile)
+	if err != nil {
+		return err
+	}
+	if failures > 0 {
+		return fmt.Errorf("node %q does not conform to profile %q: %d check(s) failed", nodeName, *profileName, failures)
+	}
+	fmt.Printf("node %q conforms to profile %q\n", nodeName, *profileName)
+	return nil
+}
+
+// loadProfiles reads profiles from the local file when one is given, else
+// from the ConfigMap, where each data key names one profile.
+func loadProfiles(ctx context.Context, client kubernetes.Interface, file, configMap string) (map[string]verifyProfile, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		profiles := map[string]verifyProfile{}
+		if err := yaml.Unmarshal(data, &profiles); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", file, err)
+		}
+		return profiles, nil
+	}
+	namespace, name, err := splitConfigMapRef(configMap)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("fetching profiles ConfigMap %s: %w", configMap, err)
+	}
+	profiles := make(map[string]verifyProfile, len(cm.Data))
+	for profileName, raw := range cm.Data {
+		profile := verifyProfile{}
+		if err := yaml.Unmarshal([]byte(raw), &profile); err != nil {
+			return nil, fmt.Errorf("parsing profile %q: %w", profileName, err)
+		}
+		profiles[profileName] = profile
+	}
+	return profiles, nil
+}
+
+func splitConfigMapRef(ref string) (string, string, error) {
+	for i := range ref {
+		if ref[i] == '/' {
+			return ref[:i], ref[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("ConfigMap reference %q is not <namespace>/<name>", ref)
+}
+
+// remeasureNode asks the controller for a targeted measurement cycle via the
+// remeasure annotation and waits until a probe of the node's links is newer
+// than the request, so the verdict rests on the link as it is now rather than
+// on whatever the last full cycle saw. A timeout is only a warning: the
+// evaluation still runs, on possibly stale data.
+func remeasureNode(ctx context.Context, kubeconfig, nodeName string, wait time.Duration) error {
+	dyn, err := dynamicClient(kubeconfig)
+	if err != nil {
+		return err
+	}
+	since := time.Now()
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{remeasureAnnotation: nodeName},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := dyn.Resource(topologyGVR).Patch(ctx, topologyName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("requesting remeasurement: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "requested remeasurement of %s, waiting up to %s\n", nodeName, wait)
+
+	deadline := time.Now().Add(wait)
+	for time.Now().Before(deadline) {
+		time.Sleep(5 * time.Second)
+		topo, err := fetchTopology(kubeconfig)
+		if err != nil {
+			return err
+		}
+		if probedSince(topo, nodeName, since) {
+			return nil
+		}
+	}
+	fmt.Fprintf(os.Stderr, "warning: measurements did not refresh within %s; evaluating current data\n", wait)
+	return nil
+}
+
+// probedSince reports whether any link touching the node carries a probe
+// timestamp newer than the given time, in either direction.
+func probedSince(topo *v1alpha1.NetworkTopology, nodeName string, since time.Time) bool {
+	ix := topology.Shared(topo)
+	for _, name := range ix.NodeNames() {
+		spec, ok := ix.Node(name)
+		if !ok {
+			continue
+		}
+		for target, probed := range spec.ProbeTimes {
+			if (name == nodeName || target == nodeName) && probed.Time.After(since) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verifyReport evaluates every profile requirement against the topology,
+// printing one verdict line per check, and returns how many failed. An
+// unmeasured link fails its checks: a conformance gate must not pass on
+// missing data.
+func verifyReport(out io.Writer, topo *v1alpha1.NetworkTopology, node *v1.Node, profile verifyProfile) (int, error) {
+	failures := 0
+	check := func(ok bool, format string, args ...interface{}) {
+		verdict := "ok  "
+		if !ok {
+			verdict = "FAIL"
+			failures++
+		}
+		fmt.Fprintf(out, "%s %s\n", verdict, fmt.Sprintf(format, args...))
+	}
+
+	for _, capability := range profile.Capabilities {
+		check(requirements.NodeHasCapability(node, topo, capability), "capability %s", capability)
+	}
+
+	ix := topology.Shared(topo)
+	spec, known := ix.Node(node.Name)
+	for _, target := range profile.Targets {
+		targets, label, err := resolveTargets(ix, node.Name, target)
+		if err != nil {
+			return failures, err
+		}
+		if len(targets) == 0 {
+			check(false, "%s: no nodes in the topology", label)
+			continue
+		}
+		for _, targetNode := range targets {
+			if target.MaxLatency != "" {
+				limit, ok := requirements.ParseLatencyMs(target.MaxLatency)
+				if !ok {
+					return failures, fmt.Errorf("profile has unparseable maxLatency %q", target.MaxLatency)
+				}
+				measured, ok := int64(0), false
+				if known {
+					measured, ok = requirements.ParseLatencyMs(spec.Latency[targetNode])
+				}
+				switch {
+				case !ok:
+					check(false, "latency to %s: not measured (limit %s)", targetNode, target.MaxLatency)
+				default:
+					check(measured <= limit, "latency to %s: %dms (limit %s)", targetNode, measured, target.MaxLatency)
+				}
+			}
+			if target.MinBandwidth != "" {
+				limit, ok := requirements.ParseBandwidthMbps(target.MinBandwidth)
+				if !ok {
+					return failures, fmt.Errorf("profile has unparseable minBandwidth %q", target.MinBandwidth)
+				}
+				measured, ok := int64(0), false
+				if known {
+					measured, ok = requirements.ParseBandwidthMbps(spec.Bandwidth[targetNode])
+				}
+				switch {
+				case !ok:
+					check(false, "bandwidth to %s: not measured (limit %s)", targetNode, target.MinBandwidth)
+				default:
+					check(measured >= limit, "bandwidth to %s: %dmbps (limit %s)", targetNode, measured, target.MinBandwidth)
+				}
+			}
+		}
+	}
+	return failures, nil
+}
+
+// resolveTargets expands one profile target into concrete node names: the
+// named node itself, or every topology node of the zone other than the
+// verified one.
+func resolveTargets(ix *topology.Index, nodeName string, target verifyTarget) ([]string, string, error) {
+	switch {
+	case target.Node != "" && target.Zone != "":
+		return nil, "", fmt.Errorf("profile target sets both node %q and zone %q", target.Node, target.Zone)
+	case target.Node != "":
+		return []string{target.Node}, "node " + target.Node, nil
+	case target.Zone != "":
+		var names []string
+		for _, name := range ix.NodeNames() {
+			if name == nodeName {
+				continue
+			}
+			if spec, ok := ix.Node(name); ok && spec.Zone == target.Zone {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		return names, "zone " + target.Zone, nil
+	}
+	return nil, "", fmt.Errorf("profile target names neither a node nor a zone")
+}
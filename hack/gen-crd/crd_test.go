@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// TestSchemaRejectsInvalidObjects applies objects against a real apiserver
+// via envtest; it is skipped when the envtest binaries are not installed
+// (KUBEBUILDER_ASSETS unset).
+func TestSchemaRejectsInvalidObjects(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS not set; skipping envtest-backed schema test")
+	}
+
+	// Serve only the storage version without conversion: the webhook is not
+	// running here, and conversion is covered by its own round-trip tests.
+	crd := crd()
+	crd.Spec.Conversion = nil
+	crd.Spec.Versions = crd.Spec.Versions[:1]
+
+	env := &envtest.Environment{
+		CRDs: []*apiextensionsv1.CustomResourceDefinition{crd},
+	}
+	config, err := env.Start()
+	if err != nil {
+		t.Fatalf("starting envtest: %v", err)
+	}
+	defer func() {
+		if err := env.Stop(); err != nil {
+			t.Errorf("stopping envtest: %v", err)
+		}
+	}()
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		t.Fatalf("building dynamic client: %v", err)
+	}
+	gvr := schema.GroupVersionResource{
+		Group: "network.komarov.dev", Version: "v1alpha1", Resource: "networktopologies",
+	}
+
+	topology := func(nodes map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "network.komarov.dev/v1alpha1",
+			"kind":       "NetworkTopology",
+			"metadata":   map[string]interface{}{"name": "cluster"},
+			"spec":       map[string]interface{}{"nodes": nodes},
+		}}
+	}
+
+	valid := topology(map[string]interface{}{
+		"node-a": map[string]interface{}{
+			"zone":        "local",
+			"bandwidth":   map[string]interface{}{"node-b": "850mbps"},
+			"latency":     map[string]interface{}{"node-b": "12ms"},
+			"cost":        map[string]interface{}{"node-b": 0.25},
+			"healthScore": 0.9,
+		},
+	})
+	ctx := context.Background()
+	if _, err := client.Resource(gvr).Create(ctx, valid, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("valid topology rejected: %v", err)
+	}
+	if err := client.Resource(gvr).Delete(ctx, "cluster", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("cleaning up valid topology: %v", err)
+	}
+
+	invalid := map[string]*unstructured.Unstructured{
+		"garbage bandwidth": topology(map[string]interface{}{
+			"node-a": map[string]interface{}{"bandwidth": map[string]interface{}{"node-b": "warp9"}},
+		}),
+		"garbage latency": topology(map[string]interface{}{
+			"node-a": map[string]interface{}{"latency": map[string]interface{}{"node-b": "soon"}},
+		}),
+		"cost above one": topology(map[string]interface{}{
+			"node-a": map[string]interface{}{"cost": map[string]interface{}{"node-b": 1.5}},
+		}),
+		"health score below zero": topology(map[string]interface{}{
+			"node-a": map[string]interface{}{"healthScore": -0.1},
+		}),
+		"non-DNS node name": topology(map[string]interface{}{
+			"Node_A": map[string]interface{}{"zone": "local"},
+		}),
+		"self link": topology(map[string]interface{}{
+			"node-a": map[string]interface{}{"bandwidth": map[string]interface{}{"node-a": "850mbps"}},
+		}),
+	}
+	for name, obj := range invalid {
+		if _, err := client.Resource(gvr).Create(ctx, obj, metav1.CreateOptions{}); err == nil {
+			t.Errorf("%s: expected rejection, object was accepted", name)
+			_ = client.Resource(gvr).Delete(ctx, "cluster", metav1.DeleteOptions{})
+		}
+	}
+}
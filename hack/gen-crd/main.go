@@ -0,0 +1,236 @@
+// Command gen-crd renders the NetworkTopology CRD manifest with a structural
+// schema and CEL validation rules, serving both API versions. The schema
+// lives here rather than in kubebuilder markers because most of our
+// constraints sit on map values and map keys, which markers cannot express
+// without changing the Go types; running this generator after editing the
+// types keeps the manifest from drifting ("make manifests" equivalent).
+//
+// Note: "LastUpdated must not be in the future" cannot be a CRD CEL rule —
+// the CRD validation environment deliberately has no clock — so staleness and
+// future-timestamp handling stay in the consumers' staleness checks.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// Value patterns for the canonical string formats written by the controller.
+const (
+	bandwidthPattern = `^[0-9]+(\.[0-9]+)?(bps|bit|kbps|kbit|kb|mbps|mbit|mb|gbps|gbit|gb)(/s)?$`
+	latencyPattern   = `^[0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h)$`
+	// dns1123Subdomain constrains map keys to node names.
+	dns1123Subdomain = `^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+)
+
+func main() {
+	output := flag.String("output", "manifests/crds/networktopology.yaml", "Path to write the CRD manifest to.")
+	flag.Parse()
+
+	data, err := yaml.Marshal(crd())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "marshaling CRD: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(filepath.Dir(*output), 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "creating output directory: %v\n", err)
+		os.Exit(1)
+	}
+	header := []byte("# Generated by hack/gen-crd. DO NOT EDIT.\n")
+	if err := os.WriteFile(*output, append(header, data...), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "writing %s: %v\n", *output, err)
+		os.Exit(1)
+	}
+	fmt.Printf("wrote %s\n", *output)
+}
+
+func crd() *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apiextensions.k8s.io/v1",
+			Kind:       "CustomResourceDefinition",
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: "networktopologies." + v1alpha1.GroupName},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: v1alpha1.GroupName,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Plural:   "networktopologies",
+				Singular: "networktopology",
+				Kind:     "NetworkTopology",
+				ListKind: "NetworkTopologyList",
+				ShortNames: []string{
+					"nettopo",
+				},
+			},
+			Scope: apiextensionsv1.ClusterScoped,
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{
+					Name:    "v1alpha1",
+					Served:  true,
+					Storage: true,
+					Schema:  &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: topologySchema(stringMeasurements())},
+				},
+				{
+					Name:    "v1alpha2",
+					Served:  true,
+					Storage: false,
+					Schema:  &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: topologySchema(typedMeasurements())},
+				},
+			},
+			Conversion: &apiextensionsv1.CustomResourceConversion{
+				Strategy: apiextensionsv1.WebhookConverter,
+				Webhook: &apiextensionsv1.WebhookConversion{
+					ConversionReviewVersions: []string{"v1"},
+					ClientConfig: &apiextensionsv1.WebhookClientConfig{
+						Service: &apiextensionsv1.ServiceReference{
+							Namespace: "kube-system",
+							Name:      "topology-controller",
+							Path:      ptr("/convert"),
+						},
+						// caBundle is injected by the deployment tooling
+						// (e.g. cert-manager's ca-injector).
+					},
+				},
+			},
+		},
+	}
+}
+
+// measurementProps is the per-version shape of the NodeSpec link maps.
+type measurementProps struct {
+	bandwidthValue apiextensionsv1.JSONSchemaProps
+	latencyValue   apiextensionsv1.JSONSchemaProps
+	jitter         bool
+}
+
+func stringMeasurements() measurementProps {
+	return measurementProps{
+		bandwidthValue: apiextensionsv1.JSONSchemaProps{Type: "string", Pattern: bandwidthPattern},
+		latencyValue:   apiextensionsv1.JSONSchemaProps{Type: "string", Pattern: latencyPattern},
+	}
+}
+
+func typedMeasurements() measurementProps {
+	return measurementProps{
+		// resource.Quantity and metav1.Duration serialize as scalars with
+		// their own parsing; the apiserver validates them structurally as
+		// int-or-string and string respectively.
+		bandwidthValue: apiextensionsv1.JSONSchemaProps{
+			XIntOrString: true,
+			AnyOf: []apiextensionsv1.JSONSchemaProps{
+				{Type: "integer"},
+				{Type: "string"},
+			},
+		},
+		latencyValue: apiextensionsv1.JSONSchemaProps{Type: "string"},
+		jitter:       true,
+	}
+}
+
+func topologySchema(m measurementProps) *apiextensionsv1.JSONSchemaProps {
+	nodeSpec := apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"zone":   {Type: "string"},
+			"region": {Type: "string"},
+			"capabilities": {
+				Type:  "array",
+				Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}},
+			},
+			"bandwidth":          nodeKeyedMap(m.bandwidthValue),
+			"latency":            nodeKeyedMap(m.latencyValue),
+			"cost":               nodeKeyedMap(unitInterval()),
+			"healthScore":        unitInterval(),
+			"utilization":        unitInterval(),
+			"utilizationUpdated": {Type: "string", Format: "date-time"},
+		},
+	}
+	if m.jitter {
+		nodeSpec.Properties["jitter"] = nodeKeyedMap(m.latencyValue)
+	}
+
+	return &apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"apiVersion": {Type: "string"},
+			"kind":       {Type: "string"},
+			"metadata":   {Type: "object"},
+			"spec": {
+				Type: "object",
+				Properties: map[string]apiextensionsv1.JSONSchemaProps{
+					"nodes": {
+						Type:                 "object",
+						AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{Schema: &nodeSpec},
+						XValidations: apiextensionsv1.ValidationRules{
+							{
+								Rule:    fmt.Sprintf("self.all(k, k.matches('%s'))", dns1123Subdomain),
+								Message: "node names must be DNS-1123 subdomains",
+							},
+							{
+								Rule:    "self.all(k, !has(self[k].bandwidth) || !(k in self[k].bandwidth))",
+								Message: "a node must not declare a link to itself",
+							},
+						},
+					},
+				},
+			},
+			"status": {
+				Type: "object",
+				Properties: map[string]apiextensionsv1.JSONSchemaProps{
+					"lastUpdated":      {Type: "string", Format: "date-time"},
+					"nodeCount":        {Type: "integer", Minimum: float64Ptr(0)},
+					"measurementCount": {Type: "integer", Minimum: float64Ptr(0)},
+					"healthScore":      unitInterval(),
+					"conditions": {
+						Type: "array",
+						Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{
+							Type:                   "object",
+							XPreserveUnknownFields: boolPtr(true),
+						}},
+					},
+				},
+				XValidations: apiextensionsv1.ValidationRules{
+					{
+						Rule:    "!has(self.nodeCount) || !has(self.measurementCount) || self.measurementCount <= self.nodeCount * (self.nodeCount - 1)",
+						Message: "measurementCount cannot exceed the number of directed node pairs",
+					},
+				},
+			},
+		},
+	}
+}
+
+// nodeKeyedMap is a map keyed by node name with the given value schema.
+func nodeKeyedMap(value apiextensionsv1.JSONSchemaProps) apiextensionsv1.JSONSchemaProps {
+	return apiextensionsv1.JSONSchemaProps{
+		Type:                 "object",
+		AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{Schema: &value},
+		XValidations: apiextensionsv1.ValidationRules{
+			{
+				Rule:    fmt.Sprintf("self.all(k, k.matches('%s'))", dns1123Subdomain),
+				Message: "map keys must be DNS-1123 node names",
+			},
+		},
+	}
+}
+
+// unitInterval is a number constrained to [0,1].
+func unitInterval() apiextensionsv1.JSONSchemaProps {
+	return apiextensionsv1.JSONSchemaProps{
+		Type:    "number",
+		Minimum: float64Ptr(0),
+		Maximum: float64Ptr(1),
+	}
+}
+
+func float64Ptr(v float64) *float64 { return &v }
+func boolPtr(v bool) *bool          { return &v }
+func ptr(s string) *string          { return &s }
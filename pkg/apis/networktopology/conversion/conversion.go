@@ -0,0 +1,411 @@
+// Package conversion translates NetworkTopology objects between v1alpha1,
+// where measurements are formatted strings, and v1alpha2, where they are
+// typed quantities and durations.
+//
+// Storage-version migration path:
+//
+//  1. Serve both versions from the CRD with v1alpha1 as the storage version
+//     and the conversion webhook wired in; existing consumers are unaffected.
+//  2. Flip the CRD's storage version to v1alpha2. New writes are stored
+//     typed; the singleton object is rewritten on the controller's next
+//     measurement cycle, which completes the data migration (with only one
+//     object there is no need for a bulk migrator).
+//  3. Keep v1alpha1 served for one release so out-of-tree readers can move,
+//     then drop it from served versions.
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha2"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// AnnotationSpellings records, on a converted v1alpha2 object, the original
+// v1alpha1 spellings that the typed quantities cannot carry (a Quantity only
+// keeps its input string when the mantissa is canonical, so "1000mbps" would
+// re-render as "1gbps"). ToV1Alpha1 restores these so the webhook does not
+// rewrite stored objects on every round-trip.
+const AnnotationSpellings = "network.komarov.dev/conversion-spellings"
+
+// ToV1Alpha2 converts a string-based topology to the typed form. Conversion
+// is lossless for valid values; entries that do not parse are dropped and
+// reported in the returned list so callers can surface them (the conversion
+// webhook records them in a status annotation).
+func ToV1Alpha2(in *v1alpha1.NetworkTopology) (*v1alpha2.NetworkTopology, []string) {
+	out := &v1alpha2.NetworkTopology{
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec:       v1alpha2.NetworkTopologySpec{},
+		Status: v1alpha2.NetworkTopologyStatus{
+			LastUpdated:      in.Status.LastUpdated.DeepCopy(),
+			NodeCount:        in.Status.NodeCount,
+			MeasurementCount: in.Status.MeasurementCount,
+			HealthScore:      in.Status.HealthScore,
+		},
+	}
+	out.APIVersion = v1alpha2.SchemeGroupVersion.String()
+	out.Kind = "NetworkTopology"
+	for _, c := range in.Status.Conditions {
+		out.Status.Conditions = append(out.Status.Conditions, *c.DeepCopy())
+	}
+
+	var lossy []string
+	spellings := map[string]string{}
+	if in.Spec.Nodes != nil {
+		out.Spec.Nodes = make(map[string]v1alpha2.NodeSpec, len(in.Spec.Nodes))
+	}
+	for name, spec := range in.Spec.Nodes {
+		node := v1alpha2.NodeSpec{
+			Zone:               spec.Zone,
+			Cluster:            spec.Cluster,
+			Region:             spec.Region,
+			UplinkGroup:        spec.UplinkGroup,
+			Capabilities:       append([]string(nil), spec.Capabilities...),
+			Cost:               copyCosts(spec.Cost),
+			ProbeTimes:         copyProbeTimes(spec.ProbeTimes),
+			HealthScore:        copyFloat(spec.HealthScore),
+			Utilization:        copyFloat(spec.Utilization),
+			UtilizationUpdated: spec.UtilizationUpdated.DeepCopy(),
+		}
+		if len(spec.Bandwidth) > 0 {
+			node.Bandwidth = make(map[string]resource.Quantity, len(spec.Bandwidth))
+			for target, value := range spec.Bandwidth {
+				bps, err := units.ParseBandwidth(value)
+				if err != nil {
+					lossy = append(lossy, fmt.Sprintf("nodes/%s/bandwidth/%s: %v", name, target, err))
+					continue
+				}
+				q := bandwidthQuantity(value, bps)
+				keepSpelling(spellings, fmt.Sprintf("nodes/%s/bandwidth/%s", name, target), value, q)
+				node.Bandwidth[target] = q
+			}
+		}
+		if len(spec.Latency) > 0 {
+			node.Latency = make(map[string]metav1.Duration, len(spec.Latency))
+			for target, value := range spec.Latency {
+				d, err := units.ParseLatency(value)
+				if err != nil {
+					lossy = append(lossy, fmt.Sprintf("nodes/%s/latency/%s: %v", name, target, err))
+					continue
+				}
+				node.Latency[target] = metav1.Duration{Duration: d}
+			}
+		}
+		if len(spec.MeasuredBandwidth) > 0 {
+			node.MeasuredBandwidth = make(map[string]resource.Quantity, len(spec.MeasuredBandwidth))
+			for target, value := range spec.MeasuredBandwidth {
+				bps, err := units.ParseBandwidth(value)
+				if err != nil {
+					lossy = append(lossy, fmt.Sprintf("nodes/%s/measuredBandwidth/%s: %v", name, target, err))
+					continue
+				}
+				q := bandwidthQuantity(value, bps)
+				keepSpelling(spellings, fmt.Sprintf("nodes/%s/measuredBandwidth/%s", name, target), value, q)
+				node.MeasuredBandwidth[target] = q
+			}
+		}
+		if len(spec.MeasuredLatency) > 0 {
+			node.MeasuredLatency = make(map[string]metav1.Duration, len(spec.MeasuredLatency))
+			for target, value := range spec.MeasuredLatency {
+				d, err := units.ParseLatency(value)
+				if err != nil {
+					lossy = append(lossy, fmt.Sprintf("nodes/%s/measuredLatency/%s: %v", name, target, err))
+					continue
+				}
+				node.MeasuredLatency[target] = metav1.Duration{Duration: d}
+			}
+		}
+		out.Spec.Nodes[name] = node
+	}
+	for _, link := range in.Spec.Links {
+		converted := v1alpha2.Link{
+			Source:            link.Source,
+			Target:            link.Target,
+			Cost:              link.Cost,
+			Loss:              link.Loss,
+			Path:              link.Path,
+			Via:               link.Via,
+			MeasurementSource: link.MeasurementSource,
+			LastProbeTime:     link.LastProbeTime.DeepCopy(),
+		}
+		if link.Latency != "" {
+			d, err := units.ParseLatency(link.Latency)
+			if err != nil {
+				lossy = append(lossy, fmt.Sprintf("links/%s-%s/latency: %v", link.Source, link.Target, err))
+			} else {
+				converted.Latency = metav1.Duration{Duration: d}
+			}
+		}
+		if link.Bandwidth != "" {
+			bps, err := units.ParseBandwidth(link.Bandwidth)
+			if err != nil {
+				lossy = append(lossy, fmt.Sprintf("links/%s-%s/bandwidth: %v", link.Source, link.Target, err))
+			} else {
+				converted.Bandwidth = bandwidthQuantity(link.Bandwidth, bps)
+				keepSpelling(spellings, fmt.Sprintf("links/%s-%s/bandwidth", link.Source, link.Target),
+					link.Bandwidth, converted.Bandwidth)
+			}
+		}
+		out.Spec.Links = append(out.Spec.Links, converted)
+	}
+	if len(in.Spec.UplinkGroups) > 0 {
+		out.Spec.UplinkGroups = make(map[string]resource.Quantity, len(in.Spec.UplinkGroups))
+		for group, value := range in.Spec.UplinkGroups {
+			bps, err := units.ParseBandwidth(value)
+			if err != nil {
+				lossy = append(lossy, fmt.Sprintf("uplinkGroups/%s: %v", group, err))
+				continue
+			}
+			q := bandwidthQuantity(value, bps)
+			keepSpelling(spellings, "uplinkGroups/"+group, value, q)
+			out.Spec.UplinkGroups[group] = q
+		}
+	}
+	setSpellingsAnnotation(&out.ObjectMeta, spellings)
+	sort.Strings(lossy)
+	return out, lossy
+}
+
+// ToV1Alpha1 converts a typed topology back to the string form. Bandwidth and
+// latency render in the canonical formats; jitter has no v1alpha1 field and
+// sub-formatting-precision durations round, both reported in the returned
+// list.
+func ToV1Alpha1(in *v1alpha2.NetworkTopology) (*v1alpha1.NetworkTopology, []string) {
+	out := &v1alpha1.NetworkTopology{
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec:       v1alpha1.NetworkTopologySpec{},
+		Status: v1alpha1.NetworkTopologyStatus{
+			LastUpdated:      in.Status.LastUpdated.DeepCopy(),
+			NodeCount:        in.Status.NodeCount,
+			MeasurementCount: in.Status.MeasurementCount,
+			HealthScore:      in.Status.HealthScore,
+		},
+	}
+	out.APIVersion = v1alpha1.SchemeGroupVersion.String()
+	out.Kind = "NetworkTopology"
+	for _, c := range in.Status.Conditions {
+		out.Status.Conditions = append(out.Status.Conditions, *c.DeepCopy())
+	}
+
+	var lossy []string
+	spellings := map[string]string{}
+	if raw, ok := in.Annotations[AnnotationSpellings]; ok {
+		// A malformed annotation just loses the spellings; the values
+		// themselves render canonically below.
+		_ = json.Unmarshal([]byte(raw), &spellings)
+		delete(out.Annotations, AnnotationSpellings)
+	}
+	if in.Spec.Nodes != nil {
+		out.Spec.Nodes = make(map[string]v1alpha1.NodeSpec, len(in.Spec.Nodes))
+	}
+	for name, spec := range in.Spec.Nodes {
+		node := v1alpha1.NodeSpec{
+			Zone:               spec.Zone,
+			Cluster:            spec.Cluster,
+			Region:             spec.Region,
+			UplinkGroup:        spec.UplinkGroup,
+			Capabilities:       append([]string(nil), spec.Capabilities...),
+			Cost:               copyCosts(spec.Cost),
+			ProbeTimes:         copyProbeTimes(spec.ProbeTimes),
+			HealthScore:        copyFloat(spec.HealthScore),
+			Utilization:        copyFloat(spec.Utilization),
+			UtilizationUpdated: spec.UtilizationUpdated.DeepCopy(),
+		}
+		if len(spec.Bandwidth) > 0 {
+			node.Bandwidth = make(map[string]string, len(spec.Bandwidth))
+			for target, q := range spec.Bandwidth {
+				node.Bandwidth[target] = renderBandwidth(q, spellings, fmt.Sprintf("nodes/%s/bandwidth/%s", name, target))
+			}
+		}
+		if len(spec.Latency) > 0 {
+			node.Latency = make(map[string]string, len(spec.Latency))
+			for target, d := range spec.Latency {
+				formatted := units.FormatLatency(d.Duration)
+				node.Latency[target] = formatted
+				if parsed, err := units.ParseLatency(formatted); err != nil || parsed != d.Duration {
+					lossy = append(lossy, fmt.Sprintf("nodes/%s/latency/%s: %v rounded to %s", name, target, d.Duration, formatted))
+				}
+			}
+		}
+		if len(spec.MeasuredBandwidth) > 0 {
+			node.MeasuredBandwidth = make(map[string]string, len(spec.MeasuredBandwidth))
+			for target, q := range spec.MeasuredBandwidth {
+				node.MeasuredBandwidth[target] = renderBandwidth(q, spellings, fmt.Sprintf("nodes/%s/measuredBandwidth/%s", name, target))
+			}
+		}
+		if len(spec.MeasuredLatency) > 0 {
+			node.MeasuredLatency = make(map[string]string, len(spec.MeasuredLatency))
+			for target, d := range spec.MeasuredLatency {
+				formatted := units.FormatLatency(d.Duration)
+				node.MeasuredLatency[target] = formatted
+				if parsed, err := units.ParseLatency(formatted); err != nil || parsed != d.Duration {
+					lossy = append(lossy, fmt.Sprintf("nodes/%s/measuredLatency/%s: %v rounded to %s", name, target, d.Duration, formatted))
+				}
+			}
+		}
+		for target := range spec.Jitter {
+			lossy = append(lossy, fmt.Sprintf("nodes/%s/jitter/%s: v1alpha1 has no jitter field", name, target))
+		}
+		out.Spec.Nodes[name] = node
+	}
+	for _, link := range in.Spec.Links {
+		converted := v1alpha1.Link{
+			Source:            link.Source,
+			Target:            link.Target,
+			Cost:              link.Cost,
+			Loss:              link.Loss,
+			Path:              link.Path,
+			Via:               link.Via,
+			MeasurementSource: link.MeasurementSource,
+			LastProbeTime:     link.LastProbeTime.DeepCopy(),
+		}
+		if link.Latency.Duration != 0 {
+			converted.Latency = units.FormatLatency(link.Latency.Duration)
+			if parsed, err := units.ParseLatency(converted.Latency); err != nil || parsed != link.Latency.Duration {
+				lossy = append(lossy, fmt.Sprintf("links/%s-%s/latency: %v rounded to %s",
+					link.Source, link.Target, link.Latency.Duration, converted.Latency))
+			}
+		}
+		if !link.Bandwidth.IsZero() {
+			converted.Bandwidth = renderBandwidth(link.Bandwidth, spellings,
+				fmt.Sprintf("links/%s-%s/bandwidth", link.Source, link.Target))
+		}
+		out.Spec.Links = append(out.Spec.Links, converted)
+	}
+	if len(in.Spec.UplinkGroups) > 0 {
+		out.Spec.UplinkGroups = make(map[string]string, len(in.Spec.UplinkGroups))
+		for group, q := range in.Spec.UplinkGroups {
+			out.Spec.UplinkGroups[group] = renderBandwidth(q, spellings, "uplinkGroups/"+group)
+		}
+	}
+	sort.Strings(lossy)
+	return out, lossy
+}
+
+// bandwidthSuffixes pairs the canonical bandwidth unit spellings with the
+// decimal-SI quantity suffix of the same scale, ordered so the longer units
+// match before their "bps" tail does.
+var bandwidthSuffixes = []struct{ unit, suffix string }{
+	{"gbps", "G"},
+	{"mbps", "M"},
+	{"kbps", "k"},
+	{"bps", ""},
+}
+
+// bandwidthQuantity builds the typed quantity for a parsed bandwidth string.
+// Building it from the raw bits-per-second alone loses the spelling — the
+// quantity renders canonically, so "1000mbps" comes back as "1gbps" and the
+// webhook rewrites stored objects on every round-trip. Instead the original
+// digits and scale are carried into the quantity's own string form, which
+// survives serialization, and only unrecognized spellings fall back to the
+// canonical value.
+func bandwidthQuantity(value string, bps int64) resource.Quantity {
+	normalized := strings.ToLower(strings.Join(strings.Fields(value), ""))
+	normalized = strings.TrimSuffix(normalized, "/s")
+	for _, s := range bandwidthSuffixes {
+		number, ok := strings.CutSuffix(normalized, s.unit)
+		if !ok || number == "" {
+			continue
+		}
+		if q, err := resource.ParseQuantity(number + s.suffix); err == nil && q.Value() == bps {
+			return q
+		}
+		break
+	}
+	return *resource.NewQuantity(bps, resource.DecimalSI)
+}
+
+// keepSpelling records the original spelling of a converted bandwidth when
+// rendering the quantity back would not reproduce it, keyed by the same path
+// grammar the lossy reports use.
+func keepSpelling(spellings map[string]string, path, value string, q resource.Quantity) {
+	if formatBandwidthQuantity(q) != value {
+		spellings[path] = value
+	}
+}
+
+// setSpellingsAnnotation stamps the preserved spellings on a converted
+// object, or clears a stale annotation when every value renders back exactly.
+func setSpellingsAnnotation(meta *metav1.ObjectMeta, spellings map[string]string) {
+	if len(spellings) == 0 {
+		delete(meta.Annotations, AnnotationSpellings)
+		return
+	}
+	raw, err := json.Marshal(spellings)
+	if err != nil {
+		return
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[AnnotationSpellings] = string(raw)
+}
+
+// renderBandwidth renders a typed bandwidth as a v1alpha1 string, restoring
+// the preserved original spelling when one was recorded and still matches the
+// value, so round-trips do not rewrite stored objects.
+func renderBandwidth(q resource.Quantity, spellings map[string]string, path string) string {
+	if original, ok := spellings[path]; ok {
+		if bps, err := units.ParseBandwidth(original); err == nil && bps == q.Value() {
+			return original
+		}
+	}
+	return formatBandwidthQuantity(q)
+}
+
+// formatBandwidthQuantity renders a typed bandwidth back to the v1alpha1
+// string form, recovering the spelling bandwidthQuantity preserved when the
+// quantity still carries it and falling back to the canonical format for
+// quantities written directly in v1alpha2.
+func formatBandwidthQuantity(q resource.Quantity) string {
+	s := q.String()
+	i := len(s)
+	for i > 0 && (s[i-1] < '0' || s[i-1] > '9') && s[i-1] != '.' {
+		i--
+	}
+	number, suffix := s[:i], s[i:]
+	if unit, ok := map[string]string{"": "bps", "k": "kbps", "M": "mbps", "G": "gbps"}[suffix]; ok && number != "" {
+		candidate := number + unit
+		if bps, err := units.ParseBandwidth(candidate); err == nil && bps == q.Value() {
+			return candidate
+		}
+	}
+	return units.FormatBandwidth(q.Value())
+}
+
+func copyCosts(in map[string]float64) map[string]float64 {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]float64, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+func copyProbeTimes(in map[string]metav1.Time) map[string]metav1.Time {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]metav1.Time, len(in))
+	for k, v := range in {
+		out[k] = *v.DeepCopy()
+	}
+	return out
+}
+
+func copyFloat(in *float64) *float64 {
+	if in == nil {
+		return nil
+	}
+	v := *in
+	return &v
+}
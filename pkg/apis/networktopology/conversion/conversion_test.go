@@ -0,0 +1,76 @@
+package conversion
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha2"
+)
+
+func TestRoundTripIsLossless(t *testing.T) {
+	original := topologytesting.NewTopology().
+		WithLink("a", "b", "12ms", "850mbps").
+		WithLink("a", "c", "150ms", "2gbps").
+		WithCost("a", "b", 0.25).
+		WithZone("a", "local").
+		WithCapabilities("a", "internet", "gateway").
+		WithHealth("b", 0.8).
+		Build()
+
+	typed, lossy := ToV1Alpha2(original)
+	if len(lossy) != 0 {
+		t.Fatalf("valid topology converted lossily: %v", lossy)
+	}
+	back, lossy := ToV1Alpha1(typed)
+	if len(lossy) != 0 {
+		t.Fatalf("round trip back was lossy: %v", lossy)
+	}
+
+	for name, spec := range original.Spec.Nodes {
+		got := back.Spec.Nodes[name]
+		for target, want := range spec.Bandwidth {
+			if got.Bandwidth[target] != want {
+				t.Errorf("nodes/%s/bandwidth/%s = %q, want %q", name, target, got.Bandwidth[target], want)
+			}
+		}
+		for target, want := range spec.Latency {
+			if got.Latency[target] != want {
+				t.Errorf("nodes/%s/latency/%s = %q, want %q", name, target, got.Latency[target], want)
+			}
+		}
+		if got.Zone != spec.Zone || len(got.Capabilities) != len(spec.Capabilities) {
+			t.Errorf("nodes/%s metadata did not survive the round trip", name)
+		}
+	}
+}
+
+func TestMalformedValuesAreReported(t *testing.T) {
+	topo := topologytesting.NewTopology().
+		WithLink("a", "b", "12ms", "fast").
+		Build()
+	typed, lossy := ToV1Alpha2(topo)
+	if len(lossy) == 0 {
+		t.Fatalf("expected a lossy report for an unparseable bandwidth")
+	}
+	if _, ok := typed.Spec.Nodes["a"].Bandwidth["b"]; ok {
+		t.Errorf("unparseable bandwidth must be dropped, not guessed")
+	}
+	// The valid latency entry still converts.
+	if got := typed.Spec.Nodes["a"].Latency["b"].Duration; got != 12*time.Millisecond {
+		t.Errorf("latency = %v, want 12ms", got)
+	}
+}
+
+func TestJitterIsLossyTowardV1Alpha1(t *testing.T) {
+	typed := &v1alpha2.NetworkTopology{
+		Spec: v1alpha2.NetworkTopologySpec{Nodes: map[string]v1alpha2.NodeSpec{
+			"a": {Jitter: map[string]metav1.Duration{"b": {Duration: time.Millisecond}}},
+		}},
+	}
+	if _, lossy := ToV1Alpha1(typed); len(lossy) == 0 {
+		t.Fatalf("dropping jitter must be reported as lossy")
+	}
+}
@@ -0,0 +1,119 @@
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha2"
+)
+
+// AnnotationLossy records, on a converted object, the entries the conversion
+// could not represent in the target version. Conversion itself stays
+// best-effort: a malformed value must not make the whole object unreadable.
+const AnnotationLossy = "network.komarov.dev/conversion-lossy"
+
+// Handler returns the HTTP handler serving the CRD conversion webhook on
+// /convert, plus /healthz.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/convert", serveConvert)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+// serveConvert handles one ConversionReview.
+func serveConvert(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "reading request body", http.StatusBadRequest)
+		return
+	}
+	review := &apiextensionsv1.ConversionReview{}
+	if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, "decoding ConversionReview", http.StatusBadRequest)
+		return
+	}
+
+	response := &apiextensionsv1.ConversionResponse{
+		UID:    review.Request.UID,
+		Result: metav1.Status{Status: metav1.StatusSuccess},
+	}
+	for _, raw := range review.Request.Objects {
+		converted, err := convertObject(raw, review.Request.DesiredAPIVersion)
+		if err != nil {
+			klog.ErrorS(err, "Converting NetworkTopology", "desiredAPIVersion", review.Request.DesiredAPIVersion)
+			response.Result = metav1.Status{
+				Status:  metav1.StatusFailure,
+				Message: err.Error(),
+			}
+			response.ConvertedObjects = nil
+			break
+		}
+		response.ConvertedObjects = append(response.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+
+	review.Response = response
+	review.Request = nil
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		klog.ErrorS(err, "Writing conversion response")
+	}
+}
+
+// convertObject converts one serialized topology to the desired version,
+// stamping the lossy annotation when entries were dropped or rounded and
+// clearing a stale one when the conversion was clean.
+func convertObject(raw runtime.RawExtension, desiredAPIVersion string) ([]byte, error) {
+	var meta metav1.TypeMeta
+	if err := json.Unmarshal(raw.Raw, &meta); err != nil {
+		return nil, fmt.Errorf("decoding object: %w", err)
+	}
+	if meta.APIVersion == desiredAPIVersion {
+		return raw.Raw, nil
+	}
+
+	switch {
+	case meta.APIVersion == v1alpha1.SchemeGroupVersion.String() &&
+		desiredAPIVersion == v1alpha2.SchemeGroupVersion.String():
+		in := &v1alpha1.NetworkTopology{}
+		if err := json.Unmarshal(raw.Raw, in); err != nil {
+			return nil, fmt.Errorf("decoding v1alpha1 topology: %w", err)
+		}
+		out, lossy := ToV1Alpha2(in)
+		setLossyAnnotation(&out.ObjectMeta, lossy)
+		return json.Marshal(out)
+	case meta.APIVersion == v1alpha2.SchemeGroupVersion.String() &&
+		desiredAPIVersion == v1alpha1.SchemeGroupVersion.String():
+		in := &v1alpha2.NetworkTopology{}
+		if err := json.Unmarshal(raw.Raw, in); err != nil {
+			return nil, fmt.Errorf("decoding v1alpha2 topology: %w", err)
+		}
+		out, lossy := ToV1Alpha1(in)
+		setLossyAnnotation(&out.ObjectMeta, lossy)
+		return json.Marshal(out)
+	default:
+		return nil, fmt.Errorf("unsupported conversion from %q to %q", meta.APIVersion, desiredAPIVersion)
+	}
+}
+
+func setLossyAnnotation(meta *metav1.ObjectMeta, lossy []string) {
+	if len(lossy) == 0 {
+		delete(meta.Annotations, AnnotationLossy)
+		return
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[AnnotationLossy] = strings.Join(lossy, "; ")
+}
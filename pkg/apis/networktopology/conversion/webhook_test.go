@@ -0,0 +1,146 @@
+package conversion
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha2"
+)
+
+// randomTopology generates a valid v1alpha1 topology with canonical value
+// formats, so conversions are expected to round-trip exactly.
+func randomTopology(rng *rand.Rand) *v1alpha1.NetworkTopology {
+	topo := &v1alpha1.NetworkTopology{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec:       v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{}},
+	}
+	topo.APIVersion = v1alpha1.SchemeGroupVersion.String()
+	topo.Kind = "NetworkTopology"
+
+	nodeCount := 2 + rng.Intn(5)
+	names := make([]string, nodeCount)
+	for i := range names {
+		names[i] = fmt.Sprintf("node-%d", i)
+	}
+	for i, name := range names {
+		spec := v1alpha1.NodeSpec{
+			Zone:      []string{"local", "remote", ""}[rng.Intn(3)],
+			Bandwidth: map[string]string{},
+			Latency:   map[string]string{},
+			Cost:      map[string]float64{},
+		}
+		for j, target := range names {
+			// Always link to the next node so no map is empty (empty
+			// maps do not survive JSON omitempty and would produce
+			// spurious diffs); extra links are random.
+			if target == name || (j != (i+1)%nodeCount && rng.Intn(2) == 0) {
+				continue
+			}
+			spec.Bandwidth[target] = fmt.Sprintf("%dmbps", 1+rng.Intn(2000))
+			spec.Latency[target] = fmt.Sprintf("%dms", rng.Intn(500))
+			spec.Cost[target] = float64(rng.Intn(101)) / 100
+		}
+		topo.Spec.Nodes[name] = spec
+	}
+	return topo
+}
+
+func TestFuzzRoundTripThroughWebhook(t *testing.T) {
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		original := randomTopology(rng)
+		typed := convertViaWebhook(t, server, mustMarshal(t, original), v1alpha2.SchemeGroupVersion.String())
+
+		back := &v1alpha1.NetworkTopology{}
+		raw := convertViaWebhook(t, server, typed, v1alpha1.SchemeGroupVersion.String())
+		if err := json.Unmarshal(raw, back); err != nil {
+			t.Fatalf("decoding round-tripped topology: %v", err)
+		}
+		if _, lossy := back.Annotations[AnnotationLossy]; lossy {
+			t.Fatalf("iteration %d: canonical topology converted lossily: %s", i, back.Annotations[AnnotationLossy])
+		}
+		back.TypeMeta = original.TypeMeta
+		if !reflect.DeepEqual(original.Spec, back.Spec) {
+			t.Fatalf("iteration %d: spec changed across the round trip:\n original: %+v\n back: %+v",
+				i, original.Spec, back.Spec)
+		}
+	}
+}
+
+func TestWebhookMarksLossyConversion(t *testing.T) {
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	topo := &v1alpha1.NetworkTopology{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{
+			"a": {Bandwidth: map[string]string{"b": "warp9"}},
+		}},
+	}
+	topo.APIVersion = v1alpha1.SchemeGroupVersion.String()
+	topo.Kind = "NetworkTopology"
+
+	converted := &v1alpha2.NetworkTopology{}
+	raw := convertViaWebhook(t, server, mustMarshal(t, topo), v1alpha2.SchemeGroupVersion.String())
+	if err := json.Unmarshal(raw, converted); err != nil {
+		t.Fatalf("decoding converted topology: %v", err)
+	}
+	if converted.Annotations[AnnotationLossy] == "" {
+		t.Fatalf("expected the lossy annotation for an unparseable bandwidth")
+	}
+}
+
+func mustMarshal(t *testing.T, obj interface{}) []byte {
+	t.Helper()
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("marshaling object: %v", err)
+	}
+	return raw
+}
+
+func convertViaWebhook(t *testing.T, server *httptest.Server, raw []byte, desired string) []byte {
+	t.Helper()
+	review := &apiextensionsv1.ConversionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "ConversionReview"},
+		Request: &apiextensionsv1.ConversionRequest{
+			UID:               "test",
+			DesiredAPIVersion: desired,
+			Objects:           []runtime.RawExtension{{Raw: raw}},
+		},
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatalf("marshaling review: %v", err)
+	}
+	resp, err := http.Post(server.URL+"/convert", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("posting conversion review: %v", err)
+	}
+	defer resp.Body.Close()
+	out := &apiextensionsv1.ConversionReview{}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("decoding conversion response: %v", err)
+	}
+	if out.Response == nil || out.Response.Result.Status != metav1.StatusSuccess {
+		t.Fatalf("conversion failed: %+v", out.Response)
+	}
+	if len(out.Response.ConvertedObjects) != 1 {
+		t.Fatalf("expected 1 converted object, got %d", len(out.Response.ConvertedObjects))
+	}
+	return out.Response.ConvertedObjects[0].Raw
+}
@@ -0,0 +1,48 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkClass names a reusable set of network requirements, analogous to
+// PriorityClass: instead of annotating every pod with raw numbers, teams
+// reference a class with the network.komarov.dev/class annotation and
+// operators maintain the numbers in one place. Pod-level annotations override
+// individual class fields.
+type NetworkClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NetworkClassSpec `json:"spec,omitempty"`
+}
+
+// NetworkClassSpec holds the requirements the class expands to. Values use
+// the same formats as the corresponding pod annotations.
+type NetworkClassSpec struct {
+	// MinBandwidth is the required bandwidth, e.g. "200mbps".
+	MinBandwidth string `json:"minBandwidth,omitempty"`
+	// MaxLatency is the allowed latency, e.g. "10ms".
+	MaxLatency string `json:"maxLatency,omitempty"`
+	// DataLocality set to "high" restricts pods to the local zone.
+	DataLocality string `json:"dataLocality,omitempty"`
+	// Capabilities lists required node capabilities.
+	Capabilities []string `json:"capabilities,omitempty"`
+	// RequirementMode is how violations of class-supplied requirements are
+	// handled: "required" (default) rejects nodes, "preferred" penalizes
+	// them in scoring.
+	RequirementMode string `json:"requirementMode,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkClassList is a list of NetworkClass objects.
+type NetworkClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NetworkClass `json:"items"`
+}
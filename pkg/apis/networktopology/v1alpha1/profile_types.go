@@ -0,0 +1,74 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeNetworkProfile declares network facts about one node that cannot be
+// measured: metered uplinks, shared NICs, egress pricing. Cluster-scoped,
+// one per node; the object name is the node name. The topology controller
+// merges declared values into the computed topology each cycle, with declared
+// capabilities winning over inferred ones.
+type NodeNetworkProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NodeNetworkProfileSpec `json:"spec,omitempty"`
+}
+
+// NodeNetworkProfileSpec is the operator-declared view of one node's network.
+type NodeNetworkProfileSpec struct {
+	// Metered marks the node's uplink as traffic-billed (e.g. a 4G plan).
+	// It surfaces as the "metered" capability in the topology, so pods can
+	// avoid such nodes with the avoid-metered annotation.
+	Metered bool `json:"metered,omitempty"`
+
+	// EgressCostPerGB is the declared egress price in USD per gigabyte,
+	// e.g. "0.09". Informational; nothing in the cluster bills by it.
+	EgressCostPerGB string `json:"egressCostPerGB,omitempty"`
+
+	// Capabilities are declared in addition to what the controller infers
+	// from node labels, e.g. "gateway" on a node whose role label lies.
+	Capabilities []string `json:"capabilities,omitempty"`
+
+	// Segments lists the network segments this node can reach, e.g.
+	// ["lan", "storage-vlan"]. The topology controller only probes node
+	// pairs sharing at least one segment; nodes declaring none share an
+	// implicit segment with each other. Overrides the
+	// network.komarov.dev/segments node label.
+	Segments []string `json:"segments,omitempty"`
+
+	// UplinkGroup assigns the node to a shared-bottleneck group: all
+	// members' traffic traverses the same physical uplink, so per-link
+	// measurements overstate what the group can carry in aggregate.
+	// Overrides the network.komarov.dev/uplink-group node label.
+	UplinkGroup string `json:"uplinkGroup,omitempty"`
+	// UplinkCapacity is the group's total uplink throughput, formatted
+	// like "100mbps". Declared on any member; when members disagree the
+	// smallest value wins, erring toward not overcommitting the uplink.
+	UplinkCapacity string `json:"uplinkCapacity,omitempty"`
+
+	// CostModifier is added to every measured link cost of this node and
+	// the result clamped to [0,1]. Use it to make a node less attractive
+	// for reasons measurement cannot see, such as a NIC shared with the
+	// storage network.
+	CostModifier *float64 `json:"costModifier,omitempty"`
+
+	// Attributes holds free-form declared facts, e.g. "nic":
+	// "shared-storage". Consumers ignore keys they do not understand.
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeNetworkProfileList is a list of NodeNetworkProfile objects.
+type NodeNetworkProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NodeNetworkProfile `json:"items"`
+}
@@ -0,0 +1,56 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkQuota budgets a namespace's bandwidth reservations, analogous to
+// ResourceQuota: once min-bandwidth claims are enforced, a team cannot starve
+// the cluster by requesting absurd values "just in case". Namespaced; the
+// admission webhook enforces it when pods are created and the scheduler's
+// Reserve step re-checks it to catch races. Usage is tracked in the status by
+// the topology controller's quota reconciler.
+type NetworkQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NetworkQuotaSpec   `json:"spec,omitempty"`
+	Status NetworkQuotaStatus `json:"status,omitempty"`
+}
+
+// NetworkQuotaSpec is the namespace's bandwidth budget.
+type NetworkQuotaSpec struct {
+	// TotalReservableMbps caps the sum of min-bandwidth claims (annotation
+	// or extended resource) across the namespace's pods, in megabits per
+	// second. Zero means unlimited.
+	// +kubebuilder:validation:Minimum=0
+	TotalReservableMbps int64 `json:"totalReservableMbps,omitempty"`
+	// MaxPodMbps caps a single pod's min-bandwidth claim. Zero means
+	// unlimited.
+	// +kubebuilder:validation:Minimum=0
+	MaxPodMbps int64 `json:"maxPodMbps,omitempty"`
+}
+
+// NetworkQuotaStatus reports the namespace's current usage.
+type NetworkQuotaStatus struct {
+	// ReservedMbps is the sum of min-bandwidth claims of the namespace's
+	// non-terminal pods.
+	ReservedMbps int64 `json:"reservedMbps,omitempty"`
+	// PodCount is how many pods contribute to ReservedMbps.
+	PodCount int `json:"podCount,omitempty"`
+	// UpdatedAt is when the reconciler last recomputed usage.
+	UpdatedAt *metav1.Time `json:"updatedAt,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkQuotaList is a list of NetworkQuota objects.
+type NetworkQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NetworkQuota `json:"items"`
+}
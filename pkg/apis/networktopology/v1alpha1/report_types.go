@@ -0,0 +1,64 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkSchedulingReport aggregates the pods currently blocked by the
+// NetworkAware scheduler plugin into one cluster-scoped object (named
+// "blocked", like the "cluster" topology singleton), so operators see which
+// workloads network constraints are holding up without grepping scheduler
+// logs. The plugin maintains it on a debounce and prunes entries as pods
+// schedule or disappear; it is read-only for everyone else.
+type NetworkSchedulingReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status NetworkSchedulingReportStatus `json:"status,omitempty"`
+}
+
+// NetworkSchedulingReportStatus is the current set of blocked pods, grouped
+// by the reason the plugin rejected them.
+type NetworkSchedulingReportStatus struct {
+	// Reasons groups blocked pods by rejection reason, sorted by reason.
+	Reasons []BlockedReasonGroup `json:"reasons,omitempty"`
+	// UpdatedAt is when the plugin last rewrote the report.
+	UpdatedAt *metav1.Time `json:"updatedAt,omitempty"`
+}
+
+// BlockedReasonGroup lists the pods blocked for one reason.
+type BlockedReasonGroup struct {
+	// Reason is the rejection kind, e.g. "bandwidth", "latency" or
+	// "infeasible".
+	Reason string `json:"reason"`
+	// Pods are the blocked pods, sorted by namespace/name.
+	Pods []BlockedPod `json:"pods,omitempty"`
+}
+
+// BlockedPod is one pod the plugin is currently rejecting.
+type BlockedPod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Message is the rejection message from the most recent attempt.
+	Message string `json:"message,omitempty"`
+	// FirstSeen is when the plugin first rejected the pod.
+	FirstSeen metav1.Time `json:"firstSeen"`
+	// LastSeen is when the plugin most recently rejected the pod.
+	LastSeen metav1.Time `json:"lastSeen"`
+	// Attempts counts the scheduling attempts the plugin has rejected.
+	Attempts int32 `json:"attempts"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkSchedulingReportList is a list of NetworkSchedulingReport objects.
+type NetworkSchedulingReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NetworkSchedulingReport `json:"items"`
+}
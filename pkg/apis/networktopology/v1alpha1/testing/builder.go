@@ -0,0 +1,146 @@
+// Package testing provides a fluent builder for NetworkTopology fixtures so
+// consumer tests can describe a cluster's network in a few lines instead of
+// hand-assembling the parallel spec maps.
+package testing
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// Builder accumulates topology state; obtain one with NewTopology and
+// finish with Build.
+type Builder struct {
+	topo *v1alpha1.NetworkTopology
+}
+
+// NewTopology starts a topology named "cluster" with a current LastUpdated,
+// so fixtures pass staleness checks unless aged explicitly.
+func NewTopology() *Builder {
+	now := metav1.Now()
+	return &Builder{topo: &v1alpha1.NetworkTopology{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec:       v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{}},
+		Status:     v1alpha1.NetworkTopologyStatus{LastUpdated: &now},
+	}}
+}
+
+// WithLink records a symmetric link between two nodes, e.g.
+// WithLink("a", "b", "5ms", "900mbps"). Both nodes are created on demand.
+func (b *Builder) WithLink(source, target, latency, bandwidth string) *Builder {
+	for _, pair := range [][2]string{{source, target}, {target, source}} {
+		spec := b.node(pair[0])
+		spec.Latency[pair[1]] = latency
+		spec.Bandwidth[pair[1]] = bandwidth
+	}
+	return b
+}
+
+// WithOneWayLink records a measurement on the source's outgoing maps only,
+// for fixtures expressing asymmetric links or nodes without measurements of
+// their own. The target node is created on demand.
+func (b *Builder) WithOneWayLink(source, target, latency, bandwidth string) *Builder {
+	spec := b.node(source)
+	spec.Latency[target] = latency
+	spec.Bandwidth[target] = bandwidth
+	b.node(target)
+	return b
+}
+
+// WithCost records a symmetric normalized link cost between two nodes.
+func (b *Builder) WithCost(source, target string, cost float64) *Builder {
+	b.node(source).Cost[target] = cost
+	b.node(target).Cost[source] = cost
+	return b
+}
+
+// WithOneWayCost records a cost on the source's outgoing map only.
+func (b *Builder) WithOneWayCost(source, target string, cost float64) *Builder {
+	b.node(source).Cost[target] = cost
+	b.node(target)
+	return b
+}
+
+// WithZone places a node in a zone.
+func (b *Builder) WithZone(node, zone string) *Builder {
+	spec := b.node(node)
+	spec.Zone = zone
+	b.topo.Spec.Nodes[node] = *spec
+	return b
+}
+
+// WithUID records the node object's UID, for node-recreation tests.
+func (b *Builder) WithUID(node, uid string) *Builder {
+	spec := b.node(node)
+	spec.UID = uid
+	b.topo.Spec.Nodes[node] = *spec
+	return b
+}
+
+// WithCapabilities adds capabilities to a node.
+func (b *Builder) WithCapabilities(node string, capabilities ...string) *Builder {
+	spec := b.node(node)
+	spec.Capabilities = append(spec.Capabilities, capabilities...)
+	b.topo.Spec.Nodes[node] = *spec
+	return b
+}
+
+// WithHealth sets a node's health score.
+func (b *Builder) WithHealth(node string, health float64) *Builder {
+	spec := b.node(node)
+	spec.HealthScore = &health
+	b.topo.Spec.Nodes[node] = *spec
+	return b
+}
+
+// WithUtilization sets a node's link utilization with a fresh measurement
+// time; age it with WithUtilizationAge when testing staleness.
+func (b *Builder) WithUtilization(node string, utilization float64) *Builder {
+	spec := b.node(node)
+	now := metav1.Now()
+	spec.Utilization = &utilization
+	spec.UtilizationUpdated = &now
+	b.topo.Spec.Nodes[node] = *spec
+	return b
+}
+
+// WithUtilizationAge pushes a node's utilization measurement into the past.
+func (b *Builder) WithUtilizationAge(node string, age time.Duration) *Builder {
+	spec := b.node(node)
+	updated := metav1.NewTime(time.Now().Add(-age))
+	spec.UtilizationUpdated = &updated
+	b.topo.Spec.Nodes[node] = *spec
+	return b
+}
+
+// AgedBy pushes LastUpdated into the past, for staleness tests.
+func (b *Builder) AgedBy(age time.Duration) *Builder {
+	updated := metav1.NewTime(time.Now().Add(-age))
+	b.topo.Status.LastUpdated = &updated
+	return b
+}
+
+// Build returns the assembled topology.
+func (b *Builder) Build() *v1alpha1.NetworkTopology {
+	return b.topo
+}
+
+// node returns a pointer-like view of the named node's spec, creating it with
+// initialized maps on first use. Map fields are shared with the stored spec,
+// so link edits persist without rewriting; scalar edits are written back by
+// the callers above.
+func (b *Builder) node(name string) *v1alpha1.NodeSpec {
+	spec, ok := b.topo.Spec.Nodes[name]
+	if !ok {
+		spec = v1alpha1.NodeSpec{
+			Bandwidth: map[string]string{},
+			Latency:   map[string]string{},
+			Cost:      map[string]float64{},
+		}
+		b.topo.Spec.Nodes[name] = spec
+	}
+	return &spec
+}
@@ -0,0 +1,303 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkTopology describes the measured network characteristics between the
+// nodes of the cluster. A single cluster-scoped object (named "cluster") is
+// maintained by the topology controller and consumed by the NetworkAware
+// scheduler plugin.
+type NetworkTopology struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NetworkTopologySpec   `json:"spec,omitempty"`
+	Status NetworkTopologyStatus `json:"status,omitempty"`
+}
+
+// NetworkTopologySpec holds the per-node view of the network.
+type NetworkTopologySpec struct {
+	// Nodes maps a node name to its measured characteristics.
+	Nodes map[string]NodeSpec `json:"nodes,omitempty"`
+
+	// Links is the explicit edge representation: one entry per measured
+	// directed link, replacing the per-node Bandwidth/Latency/Cost maps.
+	// The controller writes one shape or the other; consumers must read
+	// through pkg/topology so either works.
+	Links []Link `json:"links,omitempty"`
+
+	// UplinkGroups maps a shared-bottleneck group name to the group's
+	// total uplink capacity, formatted like "100mbps". Nodes join a group
+	// via the network.komarov.dev/uplink-group label or their
+	// NodeNetworkProfile; all traffic of a group's members shares this
+	// capacity regardless of what their individual links measure.
+	UplinkGroups map[string]string `json:"uplinkGroups,omitempty"`
+
+	// Overrides are administrative link-state declarations written by
+	// operators (kubectl networktopology set-link) rather than measured.
+	// The controller honors active overrides — it skips probing the pair
+	// and withholds its measurements so the link reads as down — removes
+	// lapsed ones, and re-probes their pairs in the same cycle.
+	Overrides []LinkOverride `json:"overrides,omitempty"`
+
+	// ProbeVantage records which network namespace the cycle's probes ran
+	// in: "host" when the controller shares the node's namespace
+	// (hostNetwork deployments) or "overlay" when they crossed the CNI.
+	// The two differ measurably; consumers evaluating latency for a pod
+	// on the other side of that line should correct by the links'
+	// OverlayOverhead where published. Absent when written by controllers
+	// predating vantage detection.
+	ProbeVantage string `json:"probeVantage,omitempty"`
+}
+
+// Probe vantage values recorded in NetworkTopologySpec.ProbeVantage.
+const (
+	ProbeVantageHost    = "host"
+	ProbeVantageOverlay = "overlay"
+)
+
+// ExternalPrefix prefixes Nodes entries that describe declared external
+// endpoints (an NFS filer, an S3 gateway) rather than cluster nodes. The
+// controller measures node→endpoint links and publishes them under
+// "external/<name>"; schedulers may evaluate placement relative to these
+// entries but must never treat them as candidates. The slash keeps the
+// namespace disjoint from real node names, which cannot contain one.
+const ExternalPrefix = "external/"
+
+// Link is one measured directed edge between two nodes.
+type Link struct {
+	// Source and Target are the node names of the edge.
+	Source string `json:"source"`
+	Target string `json:"target"`
+
+	// Latency is the measured round-trip latency, formatted like "12ms".
+	Latency string `json:"latency,omitempty"`
+	// Bandwidth is the measured transmit throughput from Source toward
+	// Target (the iperf3 default direction), formatted like "850mbps". On
+	// asymmetric links the reverse direction is the Target→Source entry or
+	// BandwidthRx, never this value.
+	Bandwidth string `json:"bandwidth,omitempty"`
+	// BandwidthRx is the measured receive throughput at Source from
+	// Target, probed with iperf3 -R. Absent unless the controller runs
+	// with reverse measurement enabled.
+	BandwidthRx string `json:"bandwidthRx,omitempty"`
+	// Cost is the normalized link cost in [0,1].
+	Cost float64 `json:"cost,omitempty"`
+	// Loss is the measured packet loss fraction in [0,1].
+	Loss float64 `json:"loss,omitempty"`
+
+	// LatencyStats is the distribution of the last latency probe's samples.
+	// Absent unless the controller runs with latency statistics enabled.
+	LatencyStats *LatencyStats `json:"latencyStats,omitempty"`
+
+	// Path describes the route the measurement took (e.g. "direct",
+	// "derp-fra").
+	Path string `json:"path,omitempty"`
+	// Via names an intermediate node the path transits, such as a
+	// Tailscale subnet router. Links sharing a Via share that node's
+	// uplink as a bottleneck.
+	Via string `json:"via,omitempty"`
+	// MeasurementSource names what produced the measurement (e.g.
+	// "iperf3", "ping", "declared").
+	MeasurementSource string `json:"measurementSource,omitempty"`
+	// OverlayOverhead is the derived extra round-trip latency the CNI
+	// overlay adds over the host path on this link, formatted like "2ms".
+	// Present only when the controller measures both vantages.
+	OverlayOverhead string `json:"overlayOverhead,omitempty"`
+	// Interface names the network interface the target's probe address
+	// was selected for. Present only when the target node pins probes to
+	// an interface via the probe-interface annotation.
+	Interface string `json:"interface,omitempty"`
+	// LastProbeTime is when this link was last measured.
+	LastProbeTime *metav1.Time `json:"lastProbeTime,omitempty"`
+}
+
+// LinkOverride declares a node pair's links administratively overridden,
+// typically ahead of a known maintenance window measurements have not caught
+// up with. It covers both directions between Source and Target.
+type LinkOverride struct {
+	// Source and Target name the node pair, in the order given on the
+	// command line.
+	Source string `json:"source"`
+	Target string `json:"target"`
+	// State is the administrative state; "down" is the only recognized
+	// value.
+	State string `json:"state"`
+	// ExpiresAt, when set, is when the override lapses; without it the
+	// override holds until cleared.
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+}
+
+// LatencyStats describes the spread of a link's round-trip samples, each
+// formatted like "12ms". A link can average 5ms yet spike to 80ms; the
+// smoothed Latency value hides that, Max and Mdev expose it.
+type LatencyStats struct {
+	// Min and Max bound the samples of the last probe.
+	Min string `json:"min,omitempty"`
+	Max string `json:"max,omitempty"`
+	// Avg is the last probe's unsmoothed average; the Latency map holds the
+	// EWMA-smoothed value instead.
+	Avg string `json:"avg,omitempty"`
+	// Mdev is the mean deviation of the samples, as reported by ping.
+	Mdev string `json:"mdev,omitempty"`
+}
+
+// NodeSpec describes one node's position in the network and its measured
+// links toward the other nodes.
+type NodeSpec struct {
+	// UID is the Kubernetes UID of the node object this entry was measured
+	// from. The map stays keyed by name for readability, but a name whose
+	// UID changed is a recreated machine: consumers must treat it as brand
+	// new rather than trust history accumulated under the old identity.
+	UID string `json:"uid,omitempty"`
+	// Zone is the coarse location of the node (e.g. "local", "remote").
+	Zone string `json:"zone,omitempty"`
+	// Region is an optional wider grouping than Zone.
+	Region string `json:"region,omitempty"`
+	// Cluster names the cluster the node belongs to when the controller
+	// federates multiple clusters into one topology. The scheduler never
+	// binds to nodes of other clusters (it only sees its own), but the
+	// combined object exposes cross-cluster costs to external placement
+	// tooling. Empty for single-cluster deployments.
+	Cluster string `json:"cluster,omitempty"`
+	// Capabilities lists network-relevant features of the node, such as
+	// "internet", "gateway" or "gpu-direct".
+	Capabilities []string `json:"capabilities,omitempty"`
+	// CapabilitySources records, per inferred capability, the name of the
+	// inference rule that produced it, so an unexpected capability can be
+	// traced back to the label rule that matched. Capabilities declared by
+	// NodeNetworkProfiles or imported sources have no entry.
+	CapabilitySources map[string]string `json:"capabilitySources,omitempty"`
+	// UplinkGroup names the shared-bottleneck group this node belongs to;
+	// see Spec.UplinkGroups. Empty when the node's uplink is its own.
+	UplinkGroup string `json:"uplinkGroup,omitempty"`
+	// Maintenance marks a node frozen for planned maintenance: its
+	// measurement maps hold the last pre-maintenance values and are not
+	// updated until the maintenance annotation is removed and the links
+	// have been re-probed. Frozen nodes are excluded from health scores.
+	Maintenance bool `json:"maintenance,omitempty"`
+	// Schedulable mirrors whether the node accepts new pods, derived from
+	// spec.unschedulable and NoSchedule taints, so external consumers of
+	// the topology see that a cordoned node's capabilities are not
+	// actually available. Absent when written by controllers predating
+	// this field; consumers treat absent as schedulable.
+	Schedulable *bool `json:"schedulable,omitempty"`
+	// ProbeAddress is the address this node was probed at during the last
+	// cycle that reached it. Every link toward this node within a cycle is
+	// measured against the same address, so recording it here rather than
+	// per link loses nothing. ProbeAddressFamily is "ipv4" or "ipv6";
+	// ProbeAddressClass names the address-selection class that supplied
+	// the address (e.g. "tailscale", "internal-v4"). Absent when written
+	// by controllers predating address-selection policies.
+	ProbeAddress       string `json:"probeAddress,omitempty"`
+	ProbeAddressFamily string `json:"probeAddressFamily,omitempty"`
+	ProbeAddressClass  string `json:"probeAddressClass,omitempty"`
+
+	// Epoch is the measurement cycle (Status.Epoch) that last refreshed
+	// this node's links. Scoped remeasurements and ad-hoc probes update
+	// only some nodes, so entries carrying different epochs mark a
+	// partially updated view; consumers wanting a consistent read compare
+	// epochs via pkg/topology. Absent (0) when written by controllers
+	// predating this field.
+	Epoch int64 `json:"epoch,omitempty"`
+
+	// Bandwidth maps a target node name to the measured transmit
+	// throughput toward it (the iperf3 default direction), formatted like
+	// "850mbps". On asymmetric links the value under node a, target b is
+	// a's upload toward b; b's upload toward a lives under b's entry, or
+	// under BandwidthRx when b runs no agent.
+	Bandwidth map[string]string `json:"bandwidth,omitempty"`
+	// BandwidthRx maps a target node name to the measured receive
+	// throughput from it (iperf3 -R). Populated only when the controller
+	// runs with reverse measurement enabled; consumers needing the
+	// download direction prefer this over assuming symmetry.
+	BandwidthRx map[string]string `json:"bandwidthRx,omitempty"`
+	// Latency maps a target node name to the measured round-trip latency
+	// toward it, formatted like "12ms".
+	Latency map[string]string `json:"latency,omitempty"`
+	// LatencyStats maps a target node name to the distribution of the last
+	// latency probe's samples. Populated only when the controller runs with
+	// latency statistics enabled; the plain Latency map stays authoritative
+	// for consumers that only need the average.
+	LatencyStats map[string]LatencyStats `json:"latencyStats,omitempty"`
+	// Cost maps a target node name to a normalized link cost in [0,1],
+	// where 0 is the cheapest link in the cluster.
+	Cost map[string]float64 `json:"cost,omitempty"`
+	// ProbeTimes maps a target node name to when that link was last
+	// successfully probed. With partial measurement cycles individual
+	// links can be much fresher than Status.LastUpdated; consumers apply
+	// staleness limits per link where this is present. Absent when
+	// written by controllers predating this field.
+	ProbeTimes map[string]metav1.Time `json:"probeTimes,omitempty"`
+
+	// MeasuredBandwidth and MeasuredLatency hold what probing actually
+	// saw on links whose Bandwidth/Latency values are pinned by an
+	// external import source. The declared value stays authoritative for
+	// scheduling; these exist so a 10G circuit delivering 90mbps is
+	// visible rather than papered over.
+	MeasuredBandwidth map[string]string `json:"measuredBandwidth,omitempty"`
+	MeasuredLatency   map[string]string `json:"measuredLatency,omitempty"`
+
+	// HealthScore is this node's aggregate network health in [0,1],
+	// computed from its links with the same model as the cluster-wide
+	// status score. Absent when the controller predates this field.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	HealthScore *float64 `json:"healthScore,omitempty"`
+
+	// Utilization is the fraction of this node's measured link capacity
+	// currently in use, in [0,1]. Absent when nothing reports it.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	Utilization *float64 `json:"utilization,omitempty"`
+	// UtilizationUpdated is when Utilization was last measured. Consumers
+	// must ignore utilization older than their staleness limit.
+	UtilizationUpdated *metav1.Time `json:"utilizationUpdated,omitempty"`
+}
+
+// NetworkTopologyStatus reports bookkeeping about the last measurement cycle.
+type NetworkTopologyStatus struct {
+	// LastUpdated is when the controller last wrote measurements.
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+	// Epoch is a monotonically increasing measurement-cycle counter. The
+	// controller resumes it from the last written object after a restart,
+	// so it never moves backwards; each node entry records the epoch that
+	// last refreshed it, letting consumers detect views assembled from a
+	// mix of cycles. 0 when written by controllers predating this field.
+	Epoch int64 `json:"epoch,omitempty"`
+	// NodeCount is the number of nodes covered by the last cycle.
+	NodeCount int `json:"nodeCount,omitempty"`
+	// MeasurementCount is the number of node pairs measured successfully.
+	MeasurementCount int `json:"measurementCount,omitempty"`
+	// HealthScore is an aggregate network health indicator in [0,1].
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	HealthScore float64 `json:"healthScore,omitempty"`
+	// HealthModel names the anchors and weights HealthScore was computed
+	// against (e.g. "0.40*latency(anchor=200ms) + 0.60*bandwidth(anchor=1gbps)"),
+	// so a score from a WAN-anchored controller is not misread against the
+	// defaults. Absent when written by controllers predating this field.
+	HealthModel string `json:"healthModel,omitempty"`
+	// CostModel names the anchors and weights the per-link Cost values were
+	// computed against, in the same form as HealthModel. A cost of 0.4 means
+	// nothing without it; schedulers quote it when rejecting a pod over a
+	// cost ceiling. Absent when written by controllers predating this field.
+	CostModel string `json:"costModel,omitempty"`
+	// Conditions describe the controller's view of the topology.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkTopologyList is a list of NetworkTopology objects.
+type NetworkTopologyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NetworkTopology `json:"items"`
+}
@@ -0,0 +1,164 @@
+// Package v1alpha2 is the typed successor of v1alpha1: bandwidth is a
+// resource.Quantity in bits per second and latency/jitter are metav1.Duration,
+// so consumers no longer parse formatted strings and the CRD schema can
+// validate values numerically.
+package v1alpha2
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkTopology describes the measured network characteristics between the
+// nodes of the cluster. A single cluster-scoped object (named "cluster") is
+// maintained by the topology controller and consumed by the NetworkAware
+// scheduler plugin.
+type NetworkTopology struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NetworkTopologySpec   `json:"spec,omitempty"`
+	Status NetworkTopologyStatus `json:"status,omitempty"`
+}
+
+// NetworkTopologySpec holds the per-node view of the network.
+type NetworkTopologySpec struct {
+	// Nodes maps a node name to its measured characteristics.
+	Nodes map[string]NodeSpec `json:"nodes,omitempty"`
+
+	// Links is the explicit edge representation: one entry per measured
+	// directed link, replacing the per-node Bandwidth/Latency/Cost maps.
+	// The controller writes one shape or the other; consumers must read
+	// through pkg/topology so either works.
+	Links []Link `json:"links,omitempty"`
+
+	// UplinkGroups maps a shared-bottleneck group name to the group's
+	// total uplink capacity in bits per second (e.g. "100M"). Nodes join
+	// a group via the network.komarov.dev/uplink-group label or their
+	// NodeNetworkProfile; all traffic of a group's members shares this
+	// capacity regardless of what their individual links measure.
+	UplinkGroups map[string]resource.Quantity `json:"uplinkGroups,omitempty"`
+}
+
+// Link is one measured directed edge between two nodes.
+type Link struct {
+	// Source and Target are the node names of the edge.
+	Source string `json:"source"`
+	Target string `json:"target"`
+
+	// Latency is the measured round-trip latency.
+	Latency metav1.Duration `json:"latency,omitempty"`
+	// Bandwidth is the measured throughput in bits per second.
+	Bandwidth resource.Quantity `json:"bandwidth,omitempty"`
+	// Cost is the normalized link cost in [0,1].
+	Cost float64 `json:"cost,omitempty"`
+	// Loss is the measured packet loss fraction in [0,1].
+	Loss float64 `json:"loss,omitempty"`
+
+	// Path describes the route the measurement took (e.g. "direct",
+	// "derp-fra").
+	Path string `json:"path,omitempty"`
+	// Via names an intermediate node the path transits, such as a
+	// Tailscale subnet router. Links sharing a Via share that node's
+	// uplink as a bottleneck.
+	Via string `json:"via,omitempty"`
+	// MeasurementSource names what produced the measurement (e.g.
+	// "iperf3", "ping", "declared").
+	MeasurementSource string `json:"measurementSource,omitempty"`
+	// LastProbeTime is when this link was last measured.
+	LastProbeTime *metav1.Time `json:"lastProbeTime,omitempty"`
+}
+
+// NodeSpec describes one node's position in the network and its measured
+// links toward the other nodes.
+type NodeSpec struct {
+	// Zone is the coarse location of the node (e.g. "local", "remote").
+	Zone string `json:"zone,omitempty"`
+	// Region is an optional wider grouping than Zone.
+	Region string `json:"region,omitempty"`
+	// Cluster names the cluster the node belongs to when the controller
+	// federates multiple clusters into one topology. The scheduler never
+	// binds to nodes of other clusters (it only sees its own), but the
+	// combined object exposes cross-cluster costs to external placement
+	// tooling. Empty for single-cluster deployments.
+	Cluster string `json:"cluster,omitempty"`
+	// Capabilities lists network-relevant features of the node, such as
+	// "internet", "gateway" or "gpu-direct".
+	Capabilities []string `json:"capabilities,omitempty"`
+	// UplinkGroup names the shared-bottleneck group this node belongs to;
+	// see Spec.UplinkGroups. Empty when the node's uplink is its own.
+	UplinkGroup string `json:"uplinkGroup,omitempty"`
+
+	// Bandwidth maps a target node name to the measured throughput toward
+	// it, in bits per second (e.g. "850M").
+	Bandwidth map[string]resource.Quantity `json:"bandwidth,omitempty"`
+	// Latency maps a target node name to the measured round-trip latency
+	// toward it.
+	Latency map[string]metav1.Duration `json:"latency,omitempty"`
+	// Jitter maps a target node name to the measured latency variance
+	// toward it. Absent when the controller does not probe jitter.
+	Jitter map[string]metav1.Duration `json:"jitter,omitempty"`
+	// Cost maps a target node name to a normalized link cost in [0,1],
+	// where 0 is the cheapest link in the cluster.
+	Cost map[string]float64 `json:"cost,omitempty"`
+	// ProbeTimes maps a target node name to when that link was last
+	// successfully probed. With partial measurement cycles individual
+	// links can be much fresher than Status.LastUpdated; consumers apply
+	// staleness limits per link where this is present. Absent when
+	// written by controllers predating this field.
+	ProbeTimes map[string]metav1.Time `json:"probeTimes,omitempty"`
+
+	// MeasuredBandwidth and MeasuredLatency hold what probing actually
+	// saw on links whose Bandwidth/Latency values are pinned by an
+	// external import source. The declared value stays authoritative for
+	// scheduling; these exist so a 10G circuit delivering 90mbps is
+	// visible rather than papered over.
+	MeasuredBandwidth map[string]resource.Quantity `json:"measuredBandwidth,omitempty"`
+	MeasuredLatency   map[string]metav1.Duration   `json:"measuredLatency,omitempty"`
+
+	// HealthScore is this node's aggregate network health in [0,1],
+	// computed from its links with the same model as the cluster-wide
+	// status score. Absent when the controller predates this field.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	HealthScore *float64 `json:"healthScore,omitempty"`
+
+	// Utilization is the fraction of this node's measured link capacity
+	// currently in use, in [0,1]. Absent when nothing reports it.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	Utilization *float64 `json:"utilization,omitempty"`
+	// UtilizationUpdated is when Utilization was last measured. Consumers
+	// must ignore utilization older than their staleness limit.
+	UtilizationUpdated *metav1.Time `json:"utilizationUpdated,omitempty"`
+}
+
+// NetworkTopologyStatus reports bookkeeping about the last measurement cycle.
+type NetworkTopologyStatus struct {
+	// LastUpdated is when the controller last wrote measurements.
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+	// NodeCount is the number of nodes covered by the last cycle.
+	NodeCount int `json:"nodeCount,omitempty"`
+	// MeasurementCount is the number of node pairs measured successfully.
+	MeasurementCount int `json:"measurementCount,omitempty"`
+	// HealthScore is an aggregate network health indicator in [0,1].
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	HealthScore float64 `json:"healthScore,omitempty"`
+	// Conditions describe the controller's view of the topology.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkTopologyList is a list of NetworkTopology objects.
+type NetworkTopologyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NetworkTopology `json:"items"`
+}
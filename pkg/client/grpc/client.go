@@ -0,0 +1,125 @@
+// Package grpcclient consumes the topology controller's gRPC service. It
+// wraps the hand-maintained topologyv1 stubs with connection setup (mutual
+// TLS, the JSON content-subtype) and a channel-based watch, so consumers
+// need no gRPC plumbing of their own.
+package grpcclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	topologyv1 "github.com/KomarovAI/k3s-network-aware-cluster/api/proto/topology/v1"
+)
+
+// Options configures the connection.
+type Options struct {
+	// CertFile and KeyFile identify the client to the server; CAFile is
+	// the bundle the server's certificate must chain to. All three are
+	// required unless DialOptions supplies its own transport credentials.
+	CertFile string
+	KeyFile  string
+	CAFile   string
+	// DialOptions are appended to the defaults; tests use this to dial
+	// in-memory listeners.
+	DialOptions []grpc.DialOption
+}
+
+// Client is a connected consumer of the topology service.
+type Client struct {
+	conn *grpc.ClientConn
+	svc  topologyv1.TopologyClient
+}
+
+// Dial connects to the controller's gRPC address.
+func Dial(ctx context.Context, addr string, opts Options) (*Client, error) {
+	dialOpts := []grpc.DialOption{
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(topologyv1.JSONCodecName)),
+	}
+	if opts.CertFile != "" {
+		creds, err := mtlsCredentials(opts)
+		if err != nil {
+			return nil, err
+		}
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(creds))
+	}
+	dialOpts = append(dialOpts, opts.DialOptions...)
+	conn, err := grpc.DialContext(ctx, addr, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("dialing %s: %w", addr, err)
+	}
+	return &Client{conn: conn, svc: topologyv1.NewTopologyClient(conn)}, nil
+}
+
+func mtlsCredentials(opts Options) (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading client certificate: %w", err)
+	}
+	caPEM, err := os.ReadFile(opts.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates in %s", opts.CAFile)
+	}
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}), nil
+}
+
+// Close tears down the connection and any open watches.
+func (c *Client) Close() error { return c.conn.Close() }
+
+// GetTopology returns the full measured link set of the latest cycle.
+func (c *Client) GetTopology(ctx context.Context) ([]topologyv1.Link, error) {
+	resp, err := c.svc.GetTopology(ctx, &topologyv1.GetTopologyRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Links, nil
+}
+
+// GetLink returns one directed link; a NotFound status means the pair is
+// not measured.
+func (c *Client) GetLink(ctx context.Context, source, target string) (*topologyv1.Link, error) {
+	resp, err := c.svc.GetLink(ctx, &topologyv1.GetLinkRequest{Source: source, Target: target})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Link, nil
+}
+
+// WatchTopology streams one diff per committed measurement cycle. The
+// channel closes when the stream ends or the context is cancelled; callers
+// that must not miss updates should re-sync with GetTopology after a close.
+func (c *Client) WatchTopology(ctx context.Context) (<-chan *topologyv1.TopologyDiff, error) {
+	stream, err := c.svc.WatchTopology(ctx, &topologyv1.WatchTopologyRequest{})
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan *topologyv1.TopologyDiff)
+	go func() {
+		defer close(ch)
+		for {
+			diff, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case ch <- diff:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
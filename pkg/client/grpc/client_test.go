@@ -0,0 +1,117 @@
+package grpcclient
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	topologyv1 "github.com/KomarovAI/k3s-network-aware-cluster/api/proto/topology/v1"
+)
+
+// fixtureServer serves two links and one diff per watch.
+type fixtureServer struct {
+	links []topologyv1.Link
+	diff  *topologyv1.TopologyDiff
+}
+
+func (s *fixtureServer) GetTopology(context.Context, *topologyv1.GetTopologyRequest) (*topologyv1.GetTopologyResponse, error) {
+	return &topologyv1.GetTopologyResponse{Links: s.links}, nil
+}
+
+func (s *fixtureServer) GetLink(_ context.Context, req *topologyv1.GetLinkRequest) (*topologyv1.GetLinkResponse, error) {
+	for i := range s.links {
+		if s.links[i].Source == req.Source && s.links[i].Target == req.Target {
+			return &topologyv1.GetLinkResponse{Link: &s.links[i]}, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "link %s -> %s is not measured", req.Source, req.Target)
+}
+
+func (s *fixtureServer) WatchTopology(_ *topologyv1.WatchTopologyRequest, stream topologyv1.TopologyService_WatchTopologyServer) error {
+	return stream.Send(s.diff)
+}
+
+func dialFixture(t *testing.T) *Client {
+	t.Helper()
+	fixture := &fixtureServer{
+		links: []topologyv1.Link{
+			{Source: "pi-1", Target: "pi-2", Latency: "12ms", Bandwidth: "850mbps", Cost: 0.1},
+			{Source: "pi-2", Target: "pi-1", Latency: "12ms", Bandwidth: "420mbps", Cost: 0.2},
+		},
+		diff: &topologyv1.TopologyDiff{Changes: []topologyv1.Change{
+			{Node: "pi-1", Target: "pi-2", Field: "latency", Old: "12ms", New: "80ms"},
+		}},
+	}
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	topologyv1.RegisterTopologyServer(server, fixture)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	client, err := Dial(context.Background(), "bufconn", Options{DialOptions: []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+	}})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestGetTopologyRoundTrip(t *testing.T) {
+	client := dialFixture(t)
+	links, err := client.GetTopology(context.Background())
+	if err != nil {
+		t.Fatalf("GetTopology: %v", err)
+	}
+	if len(links) != 2 || links[0].Latency != "12ms" || links[0].Bandwidth != "850mbps" {
+		t.Fatalf("unexpected links: %+v", links)
+	}
+}
+
+func TestGetLinkRoundTrip(t *testing.T) {
+	client := dialFixture(t)
+	link, err := client.GetLink(context.Background(), "pi-2", "pi-1")
+	if err != nil {
+		t.Fatalf("GetLink: %v", err)
+	}
+	if link.Bandwidth != "420mbps" || link.Cost != 0.2 {
+		t.Fatalf("unexpected link: %+v", link)
+	}
+
+	if _, err := client.GetLink(context.Background(), "pi-1", "ghost"); status.Code(err) != codes.NotFound {
+		t.Fatalf("unmeasured link: err = %v, want NotFound", err)
+	}
+}
+
+func TestWatchTopologyStreamsDiffs(t *testing.T) {
+	client := dialFixture(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ch, err := client.WatchTopology(ctx)
+	if err != nil {
+		t.Fatalf("WatchTopology: %v", err)
+	}
+	diff, ok := <-ch
+	if !ok {
+		t.Fatal("watch channel closed before delivering a diff")
+	}
+	if len(diff.Changes) != 1 || diff.Changes[0].Field != "latency" || diff.Changes[0].New != "80ms" {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+	// The fixture ends the stream after one diff; the channel must close.
+	if _, ok := <-ch; ok {
+		t.Fatal("watch channel did not close when the stream ended")
+	}
+}
@@ -0,0 +1,264 @@
+// Package export appends measurement and scheduling rows to local
+// JSON-lines files for offline analysis: the controller records one link
+// sample per measured link after each cycle, the scheduler one decision row
+// per bound pod. Rows are queued to a background writer and dropped when the
+// queue is full, so exporting can never block a measurement or scheduling
+// cycle. Files rotate by size keeping exactly one predecessor, and files
+// untouched for longer than the retention age are removed on startup.
+//
+// JSON-lines was chosen over the originally proposed SQLite database or
+// Parquet files deliberately: SQLite drags in cgo, which breaks the static
+// cross-compiled binaries this repo ships for its mixed amd64/arm64 nodes,
+// and the Go Parquet writers are heavyweight dependencies for what is a few
+// rows per measurement cycle. Append-only lines survive crashes mid-write (a
+// torn last line is skipped, not a corrupted table), and the data remains one
+// "topologyctl export --format csv" — or jq, or a pandas one-liner — away
+// from any analysis tool that wanted the other formats.
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// LinkSample is one measured directed link at one point in time.
+type LinkSample struct {
+	Time          time.Time `json:"time"`
+	Source        string    `json:"source"`
+	Target        string    `json:"target"`
+	LatencyMs     float64   `json:"latencyMs"`
+	BandwidthMbps int64     `json:"bandwidthMbps"`
+	Cost          float64   `json:"cost"`
+}
+
+// Decision is one scheduling decision, recorded from the scheduler's bind
+// rationale path.
+type Decision struct {
+	Time      time.Time `json:"time"`
+	Namespace string    `json:"namespace"`
+	Pod       string    `json:"pod"`
+	Node      string    `json:"node"`
+	Score     int64     `json:"score"`
+	// Source is "topology" or "labels", matching the decision-source
+	// annotation: which data the decision was based on.
+	Source string `json:"source"`
+}
+
+// queueDepth bounds how far the writer may fall behind before rows are
+// dropped. A full cycle on a large cluster is a few thousand rows at most,
+// so a sustained backlog means the disk is the problem, not the queue.
+const queueDepth = 1024
+
+// Sink appends rows as JSON lines to a file, rotating by size. All methods
+// are no-ops on a nil receiver, mirroring the controller's auditor: export
+// is an analysis aid, never a prerequisite for publishing or binding.
+type Sink struct {
+	path     string
+	maxBytes int64
+
+	rows chan interface{}
+	done chan struct{}
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	dropped int64
+}
+
+// NewSink opens a sink writing to path, or nil when path is empty. Files
+// older than maxAge are removed before writing begins; maxAge <= 0 keeps
+// everything.
+func NewSink(path string, maxSizeMB int, maxAge time.Duration) *Sink {
+	if path == "" {
+		return nil
+	}
+	if maxSizeMB <= 0 {
+		maxSizeMB = 64
+	}
+	s := &Sink{
+		path:     path,
+		maxBytes: int64(maxSizeMB) * 1024 * 1024,
+		rows:     make(chan interface{}, queueDepth),
+		done:     make(chan struct{}),
+	}
+	s.expire(maxAge)
+	go s.run()
+	return s
+}
+
+// Record queues one row without blocking; rows are dropped when the writer
+// has fallen behind.
+func (s *Sink) Record(row interface{}) {
+	if s == nil {
+		return
+	}
+	select {
+	case s.rows <- row:
+	default:
+		s.mu.Lock()
+		s.dropped++
+		n := s.dropped
+		s.mu.Unlock()
+		if n == 1 || n%1000 == 0 {
+			klog.InfoS("Export queue full, dropping rows", "path", s.path, "dropped", n)
+		}
+	}
+}
+
+// Dropped reports how many rows were discarded because the queue was full.
+func (s *Sink) Dropped() int64 {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Close flushes queued rows and closes the file. Call it once, during
+// shutdown, after all recording has stopped.
+func (s *Sink) Close() {
+	if s == nil {
+		return
+	}
+	close(s.rows)
+	<-s.done
+}
+
+func (s *Sink) run() {
+	defer close(s.done)
+	for row := range s.rows {
+		line, err := json.Marshal(row)
+		if err != nil {
+			klog.ErrorS(err, "Encoding export row")
+			continue
+		}
+		if err := s.write(append(line, '\n')); err != nil {
+			klog.ErrorS(err, "Writing export file", "path", s.path)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+}
+
+func (s *Sink) write(line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	if s.size+int64(len(line)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+func (s *Sink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file = f
+	s.size = info.Size()
+	return nil
+}
+
+// rotate keeps exactly one predecessor file (<path>.1), which bounds disk
+// use at twice maxBytes.
+func (s *Sink) rotate() error {
+	s.file.Close()
+	s.file = nil
+	if err := os.Rename(s.path, s.path+".1"); err != nil {
+		return fmt.Errorf("rotating export file: %w", err)
+	}
+	return s.open()
+}
+
+// expire applies age retention: a file untouched for longer than maxAge
+// describes a cluster state nobody is analyzing anymore.
+func (s *Sink) expire(maxAge time.Duration) {
+	if maxAge <= 0 {
+		return
+	}
+	for _, p := range []string{s.path + ".1", s.path} {
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		if time.Since(info.ModTime()) > maxAge {
+			os.Remove(p)
+		}
+	}
+}
+
+// ReadLinkSamples returns the link samples recorded at or after since from
+// path and its rotated predecessor, oldest file first. Lines that fail to
+// decode (for example one cut short by a crash) are skipped.
+func ReadLinkSamples(path string, since time.Time) ([]LinkSample, error) {
+	var rows []LinkSample
+	err := readLines(path, func(line []byte) {
+		var row LinkSample
+		if json.Unmarshal(line, &row) != nil || row.Time.Before(since) {
+			return
+		}
+		rows = append(rows, row)
+	})
+	return rows, err
+}
+
+// ReadDecisions is ReadLinkSamples for scheduling-decision rows.
+func ReadDecisions(path string, since time.Time) ([]Decision, error) {
+	var rows []Decision
+	err := readLines(path, func(line []byte) {
+		var row Decision
+		if json.Unmarshal(line, &row) != nil || row.Time.Before(since) {
+			return
+		}
+		rows = append(rows, row)
+	})
+	return rows, err
+}
+
+// readLines feeds every line of <path>.1 then <path> to fn. A missing
+// predecessor is normal; a missing primary file is an error so a mistyped
+// path is not reported as an empty export.
+func readLines(path string, fn func([]byte)) error {
+	if err := scanFile(path+".1", fn); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return scanFile(path, fn)
+}
+
+func scanFile(path string, fn func([]byte)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fn(scanner.Bytes())
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,134 @@
+// Package features holds the feature gates shared by the scheduler plugin
+// and the controller binaries, modeled on the Kubernetes component gates:
+// each binary accepts a comma-separated Name=bool flag and risky features
+// can be switched off without a rebuild. Every gate defaults to the
+// released behavior, so leaving the flag empty changes nothing across an
+// upgrade.
+package features
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Feature names one toggleable behavior.
+type Feature string
+
+const (
+	// BandwidthReservation tracks min-bandwidth claims between Reserve and
+	// bind so concurrent scheduling cycles cannot overcommit a link or a
+	// shared uplink group.
+	BandwidthReservation Feature = "BandwidthReservation"
+	// GPUGroupScheduling awards score bonuses pulling members of a
+	// multi-pod gpu-direct job onto low-latency links toward their
+	// siblings.
+	GPUGroupScheduling Feature = "GPUGroupScheduling"
+	// ScoreCaching reuses per-node base scores across pods with identical
+	// requirements until the topology changes.
+	ScoreCaching Feature = "ScoreCaching"
+)
+
+// defaults is the released behavior per gate.
+var defaults = map[Feature]bool{
+	BandwidthReservation: true,
+	GPUGroupScheduling:   true,
+	ScoreCaching:         true,
+}
+
+// Gates is one resolved set of gate values.
+type Gates struct {
+	enabled map[Feature]bool
+}
+
+// NewGates returns the default gate values.
+func NewGates() *Gates {
+	enabled := make(map[Feature]bool, len(defaults))
+	for feature, value := range defaults {
+		enabled[feature] = value
+	}
+	return &Gates{enabled: enabled}
+}
+
+// ParseGates applies a comma-separated Name=bool spec on top of the
+// defaults. Unknown gates and unparseable values are rejected rather than
+// ignored — a typo silently leaving a gate at its default is exactly the
+// surprise the flag exists to prevent.
+func ParseGates(spec string) (*Gates, error) {
+	gates := NewGates()
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("feature gate %q is not of the form Name=bool", entry)
+		}
+		feature := Feature(strings.TrimSpace(name))
+		if _, known := defaults[feature]; !known {
+			return nil, fmt.Errorf("unknown feature gate %q (known: %s)", name, knownGates())
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("feature gate %s has non-boolean value %q", feature, value)
+		}
+		gates.enabled[feature] = enabled
+	}
+	return gates, nil
+}
+
+// Enabled reports whether the feature is on in this set.
+func (g *Gates) Enabled(f Feature) bool {
+	return g.enabled[f]
+}
+
+// String lists every gate as Name=value, sorted, for startup logs.
+func (g *Gates) String() string {
+	entries := make([]string, 0, len(g.enabled))
+	for feature, value := range g.enabled {
+		entries = append(entries, fmt.Sprintf("%s=%t", feature, value))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// Map exposes the gate values for the /version endpoint.
+func (g *Gates) Map() map[string]bool {
+	out := make(map[string]bool, len(g.enabled))
+	for feature, value := range g.enabled {
+		out[string(feature)] = value
+	}
+	return out
+}
+
+func knownGates() string {
+	names := make([]string, 0, len(defaults))
+	for feature := range defaults {
+		names = append(names, string(feature))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Default is the process-wide gate set consulted by the package-level
+// Enabled. It is replaced once during startup, before any scheduling or
+// measurement work runs; later reads are deliberately unsynchronized.
+var Default = NewGates()
+
+// SetDefaultFromFlag parses the flag value and installs the result as the
+// process-wide set.
+func SetDefaultFromFlag(spec string) error {
+	gates, err := ParseGates(spec)
+	if err != nil {
+		return err
+	}
+	Default = gates
+	return nil
+}
+
+// Enabled reports whether the feature is on in the process-wide set.
+func Enabled(f Feature) bool {
+	return Default.Enabled(f)
+}
@@ -0,0 +1,67 @@
+package features
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultsPreserveReleasedBehavior(t *testing.T) {
+	gates := NewGates()
+	for _, feature := range []Feature{BandwidthReservation, GPUGroupScheduling, ScoreCaching} {
+		if !gates.Enabled(feature) {
+			t.Errorf("%s defaults to off; upgrades must keep the released behavior", feature)
+		}
+	}
+}
+
+func TestParseGatesOverrides(t *testing.T) {
+	gates, err := ParseGates("BandwidthReservation=false, ScoreCaching=true")
+	if err != nil {
+		t.Fatalf("ParseGates: %v", err)
+	}
+	if gates.Enabled(BandwidthReservation) {
+		t.Error("BandwidthReservation=false was not applied")
+	}
+	if !gates.Enabled(GPUGroupScheduling) {
+		t.Error("unmentioned gates must keep their default")
+	}
+}
+
+func TestParseGatesEmptySpec(t *testing.T) {
+	gates, err := ParseGates("")
+	if err != nil {
+		t.Fatalf("ParseGates(\"\"): %v", err)
+	}
+	if gates.String() != NewGates().String() {
+		t.Errorf("empty spec = %s, want the defaults %s", gates, NewGates())
+	}
+}
+
+func TestParseGatesRejectsUnknown(t *testing.T) {
+	_, err := ParseGates("TurboMode=true")
+	if err == nil {
+		t.Fatal("unknown gate accepted")
+	}
+	if !strings.Contains(err.Error(), "TurboMode") || !strings.Contains(err.Error(), string(ScoreCaching)) {
+		t.Errorf("error %q should name the bad gate and list the known ones", err)
+	}
+}
+
+func TestParseGatesRejectsMalformed(t *testing.T) {
+	for _, bad := range []string{"BandwidthReservation", "BandwidthReservation=maybe"} {
+		if _, err := ParseGates(bad); err == nil {
+			t.Errorf("ParseGates(%q) accepted a malformed entry", bad)
+		}
+	}
+}
+
+func TestGatesString(t *testing.T) {
+	want := "BandwidthReservation=true,GPUGroupScheduling=true,ScoreCaching=false"
+	gates, err := ParseGates("ScoreCaching=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := gates.String(); got != want {
+		t.Errorf("String() = %q, want sorted %q", got, want)
+	}
+}
@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+func TestSetupRejectsUnknownFormat(t *testing.T) {
+	if err := Setup("yaml"); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+	for _, format := range []string{"", FormatText} {
+		if err := Setup(format); err != nil {
+			t.Errorf("Setup(%q): %v", format, err)
+		}
+	}
+}
+
+func TestJSONSinkFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logr.New(newJSONSink(&buf)).WithName("cycle").WithValues("node", "pi-1")
+	logger.V(2).Info("Cycle summary", "measured", 12, "duration", 1500*time.Millisecond)
+	logger.Error(errors.New("boom"), "Probe failed")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("wrote %d lines, want 2: %q", len(lines), buf.String())
+	}
+
+	var info map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &info); err != nil {
+		t.Fatalf("info line is not JSON: %v", err)
+	}
+	if info["msg"] != "Cycle summary" || info["logger"] != "cycle" || info["node"] != "pi-1" {
+		t.Errorf("info record = %v", info)
+	}
+	if info["v"] != float64(2) || info["measured"] != float64(12) {
+		t.Errorf("numeric fields = %v", info)
+	}
+	// Durations render as their String form, matching klog's text output.
+	if info["duration"] != "1.5s" {
+		t.Errorf("duration = %v, want 1.5s", info["duration"])
+	}
+	if _, err := time.Parse(time.RFC3339Nano, info["ts"].(string)); err != nil {
+		t.Errorf("ts %v is not RFC3339: %v", info["ts"], err)
+	}
+
+	var errRecord map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &errRecord); err != nil {
+		t.Fatalf("error line is not JSON: %v", err)
+	}
+	if errRecord["err"] != "boom" || errRecord["msg"] != "Probe failed" {
+		t.Errorf("error record = %v", errRecord)
+	}
+}
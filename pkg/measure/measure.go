@@ -0,0 +1,202 @@
+// Package measure holds the network measurement primitives shared by the
+// topology controller and any other binary that probes links: command-line
+// probers, exponential smoothing, and the cost and health models that turn
+// raw latency/bandwidth samples into the scores recorded in the topology.
+// It depends only on the standard library and pkg/units, so it can be reused
+// outside a cluster context.
+package measure
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+const (
+	// Alpha is the EWMA smoothing factor: new samples move the smoothed
+	// value by 30% per cycle, damping one-off spikes.
+	Alpha = 0.3
+
+	// Health and cost anchors: a link at or above 1 Gbps with latency at
+	// or under 0ms scores 1.0; 200ms latency or 0 bandwidth score 0.
+	LatencyAnchor   = 200 * time.Millisecond
+	BandwidthAnchor = units.Gbps
+
+	healthLatencyWeight   = 0.4
+	healthBandwidthWeight = 0.6
+)
+
+// EWMADuration folds a new latency sample into the smoothed value. hasOld
+// reports whether old is a real previous value; the first sample is taken
+// as-is.
+func EWMADuration(old, new time.Duration, hasOld bool) time.Duration {
+	if !hasOld || old == 0 {
+		return new
+	}
+	return time.Duration(float64(old)*(1-Alpha) + float64(new)*Alpha)
+}
+
+// EWMAInt64 folds a new integer sample (typically bits per second) into the
+// smoothed value, with the same first-sample semantics as EWMADuration.
+func EWMAInt64(old, new int64, hasOld bool) int64 {
+	if !hasOld || old == 0 {
+		return new
+	}
+	return int64(float64(old)*(1-Alpha) + float64(new)*Alpha)
+}
+
+// LinkCost normalizes a link into [0,1]: 0 for a fast local link, 1 for a
+// slow distant one, using the default cost model.
+func LinkCost(latency time.Duration, bandwidthBps int64) float64 {
+	return DefaultCostModel().LinkCost(latency, bandwidthBps)
+}
+
+// CostModel holds the anchors and weights link costs are computed against.
+// Like HealthModel it exists so deployments can re-anchor the normalization —
+// an all-WAN cluster where every link costs 0.9 under the LAN defaults has no
+// spread left for placement to act on. The model in effect is recorded in
+// Status.CostModel so a published cost can be interpreted.
+type CostModel struct {
+	// LatencyAnchor is the latency at which the latency component saturates
+	// at full cost.
+	LatencyAnchor time.Duration
+	// BandwidthAnchor is the bandwidth in bits per second that zeroes the
+	// bandwidth component.
+	BandwidthAnchor int64
+	// LatencyWeight and BandwidthWeight blend the two components; they
+	// must sum to 1.
+	LatencyWeight   float64
+	BandwidthWeight float64
+}
+
+// DefaultCostModel returns the historical constants: 200ms/1Gbps anchors
+// weighted equally.
+func DefaultCostModel() CostModel {
+	return CostModel{
+		LatencyAnchor:   LatencyAnchor,
+		BandwidthAnchor: BandwidthAnchor,
+		LatencyWeight:   0.5,
+		BandwidthWeight: 0.5,
+	}
+}
+
+// Validate rejects models that would produce costs outside [0,1] or divide
+// by zero.
+func (m CostModel) Validate() error {
+	if m.LatencyAnchor <= 0 {
+		return fmt.Errorf("latency anchor must be positive, got %v", m.LatencyAnchor)
+	}
+	if m.BandwidthAnchor <= 0 {
+		return fmt.Errorf("bandwidth anchor must be positive, got %d", m.BandwidthAnchor)
+	}
+	if m.LatencyWeight <= 0 || m.BandwidthWeight <= 0 {
+		return fmt.Errorf("cost weights must be positive, got latency=%v bandwidth=%v", m.LatencyWeight, m.BandwidthWeight)
+	}
+	if sum := m.LatencyWeight + m.BandwidthWeight; math.Abs(sum-1) > 1e-9 {
+		return fmt.Errorf("cost weights must sum to 1, got %v", sum)
+	}
+	return nil
+}
+
+// LinkCost normalizes one link into [0,1] against the model's anchors.
+func (m CostModel) LinkCost(latency time.Duration, bandwidthBps int64) float64 {
+	latencyPart := float64(latency) / float64(m.LatencyAnchor)
+	if latencyPart > 1 {
+		latencyPart = 1
+	}
+	bandwidthPart := 1 - float64(bandwidthBps)/float64(m.BandwidthAnchor)
+	if bandwidthPart < 0 {
+		bandwidthPart = 0
+	}
+	cost := m.LatencyWeight*latencyPart + m.BandwidthWeight*bandwidthPart
+	if cost > 1 {
+		cost = 1
+	}
+	return Round2(cost)
+}
+
+// String renders the model in the form recorded in the topology status, so
+// consumers of published costs know which parameters produced them.
+func (m CostModel) String() string {
+	return fmt.Sprintf("%.2f*latency(anchor=%s) + %.2f*bandwidth(anchor=%s)",
+		m.LatencyWeight, m.LatencyAnchor, m.BandwidthWeight, units.FormatBandwidth(m.BandwidthAnchor))
+}
+
+// HealthModel holds the anchors and weights a health score is computed
+// against, so deployments whose "normal" is nowhere near a LAN (all-WAN edge
+// clusters) can anchor the score to their own baseline instead of living at
+// a permanent 0.1.
+type HealthModel struct {
+	// LatencyAnchor is the latency that zeroes the latency component.
+	LatencyAnchor time.Duration
+	// BandwidthAnchor is the bandwidth in bits per second that maxes the
+	// bandwidth component.
+	BandwidthAnchor int64
+	// LatencyWeight and BandwidthWeight blend the two components; they
+	// must sum to 1.
+	LatencyWeight   float64
+	BandwidthWeight float64
+}
+
+// DefaultHealthModel returns the historical constants: 200ms/1Gbps anchors
+// weighted 0.4/0.6.
+func DefaultHealthModel() HealthModel {
+	return HealthModel{
+		LatencyAnchor:   LatencyAnchor,
+		BandwidthAnchor: BandwidthAnchor,
+		LatencyWeight:   healthLatencyWeight,
+		BandwidthWeight: healthBandwidthWeight,
+	}
+}
+
+// Validate rejects models that would produce scores outside [0,1] or divide
+// by zero.
+func (m HealthModel) Validate() error {
+	if m.LatencyAnchor <= 0 {
+		return fmt.Errorf("latency anchor must be positive, got %v", m.LatencyAnchor)
+	}
+	if m.BandwidthAnchor <= 0 {
+		return fmt.Errorf("bandwidth anchor must be positive, got %d", m.BandwidthAnchor)
+	}
+	if m.LatencyWeight <= 0 || m.BandwidthWeight <= 0 {
+		return fmt.Errorf("health weights must be positive, got latency=%v bandwidth=%v", m.LatencyWeight, m.BandwidthWeight)
+	}
+	if sum := m.LatencyWeight + m.BandwidthWeight; math.Abs(sum-1) > 1e-9 {
+		return fmt.Errorf("health weights must sum to 1, got %v", sum)
+	}
+	return nil
+}
+
+// LinkHealth scores one link in [0,1] against the model's anchors.
+func (m HealthModel) LinkHealth(latency time.Duration, bandwidthBps int64) float64 {
+	latencyScore := 1 - float64(latency)/float64(m.LatencyAnchor)
+	if latencyScore < 0 {
+		latencyScore = 0
+	}
+	bandwidthScore := float64(bandwidthBps) / float64(m.BandwidthAnchor)
+	if bandwidthScore > 1 {
+		bandwidthScore = 1
+	}
+	return m.LatencyWeight*latencyScore + m.BandwidthWeight*bandwidthScore
+}
+
+// String renders the model in the form recorded in the topology status, so
+// consumers of the score know which parameters produced it.
+func (m HealthModel) String() string {
+	return fmt.Sprintf("%.2f*latency(anchor=%s) + %.2f*bandwidth(anchor=%s)",
+		m.LatencyWeight, m.LatencyAnchor, m.BandwidthWeight, units.FormatBandwidth(m.BandwidthAnchor))
+}
+
+// LinkHealth scores one link in [0,1] against the default anchors, weighting
+// bandwidth slightly over latency.
+func LinkHealth(latency time.Duration, bandwidthBps int64) float64 {
+	return DefaultHealthModel().LinkHealth(latency, bandwidthBps)
+}
+
+// Round2 rounds to two decimals, the precision health scores and costs are
+// recorded with.
+func Round2(v float64) float64 {
+	return float64(int(v*100+0.5)) / 100
+}
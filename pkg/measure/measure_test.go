@@ -0,0 +1,370 @@
+package measure
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEWMADurationFirstSample(t *testing.T) {
+	if got := EWMADuration(0, 40*time.Millisecond, false); got != 40*time.Millisecond {
+		t.Errorf("first sample = %v, want taken as-is", got)
+	}
+}
+
+func TestEWMADurationSmooths(t *testing.T) {
+	got := EWMADuration(10*time.Millisecond, 110*time.Millisecond, true)
+	want := 40 * time.Millisecond
+	if diff := got - want; diff < -time.Microsecond || diff > time.Microsecond {
+		t.Errorf("smoothed = %v, want ~%v (30%% toward the new sample)", got, want)
+	}
+}
+
+func TestEWMAInt64(t *testing.T) {
+	if got := EWMAInt64(0, 500, false); got != 500 {
+		t.Errorf("first sample = %d, want 500", got)
+	}
+	if got := EWMAInt64(1000, 2000, true); got < 1299 || got > 1301 {
+		t.Errorf("smoothed = %d, want ~1300", got)
+	}
+}
+
+func TestLinkCostBounds(t *testing.T) {
+	if got := LinkCost(0, BandwidthAnchor); got != 0 {
+		t.Errorf("ideal link cost = %v, want 0", got)
+	}
+	if got := LinkCost(LatencyAnchor, 0); got != 1 {
+		t.Errorf("worst link cost = %v, want 1", got)
+	}
+	if got := LinkCost(time.Hour, 0); got != 1 {
+		t.Errorf("cost beyond the anchors = %v, must stay capped at 1", got)
+	}
+}
+
+func TestCostModelValidate(t *testing.T) {
+	if err := DefaultCostModel().Validate(); err != nil {
+		t.Errorf("default model must validate: %v", err)
+	}
+	for _, bad := range []CostModel{
+		{LatencyAnchor: 0, BandwidthAnchor: BandwidthAnchor, LatencyWeight: 0.5, BandwidthWeight: 0.5},
+		{LatencyAnchor: LatencyAnchor, BandwidthAnchor: 0, LatencyWeight: 0.5, BandwidthWeight: 0.5},
+		{LatencyAnchor: LatencyAnchor, BandwidthAnchor: BandwidthAnchor, LatencyWeight: -0.1, BandwidthWeight: 1.1},
+		{LatencyAnchor: LatencyAnchor, BandwidthAnchor: BandwidthAnchor, LatencyWeight: 0.5, BandwidthWeight: 0.6},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("%+v must not validate", bad)
+		}
+	}
+}
+
+func TestCostModelParameters(t *testing.T) {
+	// A WAN-anchored model rates a 100ms/100mbps link as cheap where the
+	// defaults call it expensive.
+	wan := CostModel{
+		LatencyAnchor:   time.Second,
+		BandwidthAnchor: 100_000_000,
+		LatencyWeight:   0.5,
+		BandwidthWeight: 0.5,
+	}
+	lanView := LinkCost(100*time.Millisecond, 100_000_000)
+	wanView := wan.LinkCost(100*time.Millisecond, 100_000_000)
+	if wanView >= lanView {
+		t.Errorf("WAN-anchored cost = %v, must undercut default-anchored %v", wanView, lanView)
+	}
+	if want := Round2(0.5 * 0.1); wanView != want {
+		t.Errorf("WAN-anchored cost = %v, want %v", wanView, want)
+	}
+}
+
+func TestCostModelString(t *testing.T) {
+	got := DefaultCostModel().String()
+	want := "0.50*latency(anchor=200ms) + 0.50*bandwidth(anchor=1gbps)"
+	if got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+}
+
+func TestLinkHealthWeighting(t *testing.T) {
+	// Zero latency at the bandwidth anchor is perfect health.
+	if got := LinkHealth(0, BandwidthAnchor); got != 1 {
+		t.Errorf("ideal link health = %v, want 1", got)
+	}
+	// Latency at the anchor zeroes the latency part, leaving the 0.6
+	// bandwidth weight.
+	if got := LinkHealth(LatencyAnchor, BandwidthAnchor); got != 0.6 {
+		t.Errorf("anchor-latency health = %v, want 0.6", got)
+	}
+}
+
+func TestHealthModelValidate(t *testing.T) {
+	if err := DefaultHealthModel().Validate(); err != nil {
+		t.Errorf("default model must validate: %v", err)
+	}
+	for _, bad := range []HealthModel{
+		{LatencyAnchor: 0, BandwidthAnchor: BandwidthAnchor, LatencyWeight: 0.4, BandwidthWeight: 0.6},
+		{LatencyAnchor: LatencyAnchor, BandwidthAnchor: 0, LatencyWeight: 0.4, BandwidthWeight: 0.6},
+		{LatencyAnchor: LatencyAnchor, BandwidthAnchor: BandwidthAnchor, LatencyWeight: -0.1, BandwidthWeight: 1.1},
+		{LatencyAnchor: LatencyAnchor, BandwidthAnchor: BandwidthAnchor, LatencyWeight: 0.4, BandwidthWeight: 0.4},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("%+v must not validate", bad)
+		}
+	}
+}
+
+func TestHealthModelParameters(t *testing.T) {
+	// A WAN-anchored model rates a 100ms/100mbps link as healthy where the
+	// defaults call it poor.
+	wan := HealthModel{
+		LatencyAnchor:   time.Second,
+		BandwidthAnchor: 100_000_000,
+		LatencyWeight:   0.5,
+		BandwidthWeight: 0.5,
+	}
+	lanView := LinkHealth(100*time.Millisecond, 100_000_000)
+	wanView := wan.LinkHealth(100*time.Millisecond, 100_000_000)
+	if wanView <= lanView {
+		t.Errorf("WAN-anchored health = %v, must exceed default-anchored %v", wanView, lanView)
+	}
+	if want := 0.5*0.9 + 0.5*1.0; wanView != want {
+		t.Errorf("WAN-anchored health = %v, want %v", wanView, want)
+	}
+	// Shifting weight toward latency must lower the score of a
+	// high-latency, high-bandwidth link.
+	latencyHeavy := HealthModel{LatencyAnchor: LatencyAnchor, BandwidthAnchor: BandwidthAnchor, LatencyWeight: 0.9, BandwidthWeight: 0.1}
+	if got := latencyHeavy.LinkHealth(LatencyAnchor, BandwidthAnchor); got >= lanView {
+		t.Errorf("latency-heavy health = %v for an anchor-latency link, must drop below %v", got, lanView)
+	}
+}
+
+func TestHealthModelString(t *testing.T) {
+	got := DefaultHealthModel().String()
+	want := "0.40*latency(anchor=200ms) + 0.60*bandwidth(anchor=1gbps)"
+	if got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+}
+
+func TestRound2(t *testing.T) {
+	if got := Round2(0.14500001); got != 0.15 {
+		t.Errorf("Round2 = %v, want 0.15", got)
+	}
+}
+
+func TestParseTailscalePing(t *testing.T) {
+	out := []byte(`pong from pi-2 (100.64.0.2) via 192.168.1.5:41641 in 2ms
+pong from pi-2 (100.64.0.2) via DERP(fra) in 24ms
+`)
+	stats, path, err := parseTailscalePing(out)
+	if err != nil {
+		t.Fatalf("parseTailscalePing: %v", err)
+	}
+	if stats.Avg != 13*time.Millisecond {
+		t.Errorf("avg = %v, want 13ms", stats.Avg)
+	}
+	if stats.Min != 2*time.Millisecond || stats.Max != 24*time.Millisecond {
+		t.Errorf("min/max = %v/%v, want 2ms/24ms", stats.Min, stats.Max)
+	}
+	if stats.Mdev != 11*time.Millisecond {
+		t.Errorf("mdev = %v, want 11ms mean deviation", stats.Mdev)
+	}
+	if path != "derp-fra" {
+		t.Errorf("path = %q, want the last reply's relay", path)
+	}
+
+	if _, _, err := parseTailscalePing([]byte("timed out")); err == nil {
+		t.Error("output without pongs must be an error")
+	}
+}
+
+func TestParsePingRTT(t *testing.T) {
+	out := []byte("rtt min/avg/max/mdev = 1.123/12.456/80.2/3.4 ms\n")
+	stats, err := parsePingRTT(out)
+	if err != nil {
+		t.Fatalf("parsePingRTT: %v", err)
+	}
+	if want := time.Duration(12.456 * float64(time.Millisecond)); stats.Avg != want {
+		t.Errorf("avg = %v, want %v", stats.Avg, want)
+	}
+	if want := time.Duration(80.2 * float64(time.Millisecond)); stats.Max != want {
+		t.Errorf("max = %v, want %v", stats.Max, want)
+	}
+	if want := time.Duration(3.4 * float64(time.Millisecond)); stats.Mdev != want {
+		t.Errorf("mdev = %v, want %v", stats.Mdev, want)
+	}
+	if _, err := parsePingRTT([]byte("100% packet loss")); err == nil {
+		t.Error("output without an rtt summary must be an error")
+	}
+}
+
+func TestParsePingRTTWindows(t *testing.T) {
+	out := []byte("    Minimum = 1ms, Maximum = 4ms, Average = 2ms\n")
+	stats, err := parsePingRTT(out)
+	if err != nil {
+		t.Fatalf("parsePingRTT: %v", err)
+	}
+	if stats.Min != 1*time.Millisecond || stats.Max != 4*time.Millisecond || stats.Avg != 2*time.Millisecond {
+		t.Errorf("stats = %+v, want 1ms/4ms/2ms", stats)
+	}
+	if stats.Mdev != 0 {
+		t.Errorf("mdev = %v, want 0 (Windows ping reports no deviation)", stats.Mdev)
+	}
+}
+
+func TestPingArgs(t *testing.T) {
+	got := strings.Join(pingArgs("windows", "10.0.0.1", 3), " ")
+	if want := "-n 3 -w 2000 10.0.0.1"; got != want {
+		t.Errorf("windows args = %q, want %q", got, want)
+	}
+	got = strings.Join(pingArgs("linux", "10.0.0.1", 3), " ")
+	if want := "-c 3 -W 2 10.0.0.1"; got != want {
+		t.Errorf("linux args = %q, want %q", got, want)
+	}
+}
+
+func TestParseIperfOutput(t *testing.T) {
+	out := []byte(`{"end":{"sum_sent":{"bits_per_second":851000000.5}}}`)
+	bandwidth, err := parseIperfOutput(out)
+	if err != nil {
+		t.Fatalf("parseIperfOutput: %v", err)
+	}
+	if bandwidth != 851000000 {
+		t.Errorf("bandwidth = %d, want 851000000", bandwidth)
+	}
+	if _, err := parseIperfOutput([]byte("iperf3: error")); err == nil {
+		t.Error("non-JSON output must be an error")
+	}
+}
+
+func TestParseIperfReverseOutput(t *testing.T) {
+	// With -R the local side receives, so sum_received is the value that
+	// describes the link's download direction.
+	out := []byte(`{"end":{"sum_sent":{"bits_per_second":900000000},"sum_received":{"bits_per_second":42000000}}}`)
+	bandwidth, err := parseIperfReverseOutput(out)
+	if err != nil {
+		t.Fatalf("parseIperfReverseOutput: %v", err)
+	}
+	if bandwidth != 42000000 {
+		t.Errorf("bandwidth = %d, want the received 42000000, not the sent value", bandwidth)
+	}
+}
+
+func TestParseFpingOutput(t *testing.T) {
+	out := []byte(`100.64.0.2 : xmt/rcv/%loss = 3/3/0%, min/avg/max = 1.20/2.35/3.40
+100.64.0.3 : xmt/rcv/%loss = 3/2/33%, min/avg/max = 24.1/25.50/26.9
+100.64.0.9 : xmt/rcv/%loss = 3/0/100%
+`)
+	latencies, err := parseFpingOutput(out)
+	if err != nil {
+		t.Fatalf("parseFpingOutput: %v", err)
+	}
+	if want := time.Duration(2.35 * float64(time.Millisecond)); latencies["100.64.0.2"] != want {
+		t.Errorf("100.64.0.2 = %v, want %v", latencies["100.64.0.2"], want)
+	}
+	if want := time.Duration(25.5 * float64(time.Millisecond)); latencies["100.64.0.3"] != want {
+		t.Errorf("100.64.0.3 = %v, want %v (partial loss still averages the replies)", latencies["100.64.0.3"], want)
+	}
+	if _, ok := latencies["100.64.0.9"]; ok {
+		t.Error("a target that lost every echo must be absent, not zero")
+	}
+	if _, err := parseFpingOutput([]byte("fping: command not found")); err == nil {
+		t.Error("output without target summaries must be an error")
+	}
+}
+
+// The batched prober's real saving is one process per sweep instead of one
+// per target; what can be benchmarked without a network is the per-target
+// parsing cost, which must not erode that saving.
+func BenchmarkParseFpingOutput100Targets(b *testing.B) {
+	var out []byte
+	for i := 0; i < 100; i++ {
+		out = append(out, []byte(fmt.Sprintf("100.64.0.%d : xmt/rcv/%%loss = 3/3/0%%, min/avg/max = 1.20/2.35/3.40\n", i))...)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseFpingOutput(out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParsePingRTT100Targets(b *testing.B) {
+	out := []byte("rtt min/avg/max/mdev = 1.123/12.456/80.2/3.4 ms\n")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for n := 0; n < 100; n++ {
+			if _, err := parsePingRTT(out); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestIperfParamsValidate(t *testing.T) {
+	if err := DefaultIperfParams.Validate(); err != nil {
+		t.Errorf("default parameters must validate: %v", err)
+	}
+	for _, bad := range []IperfParams{
+		{Streams: 0, Seconds: 3},
+		{Streams: 65, Seconds: 3},
+		{Streams: 1, Seconds: 0},
+		{Streams: 1, Seconds: 61},
+		{Streams: 1, Seconds: 3, OmitSeconds: -1},
+		{Streams: 1, Seconds: 30, OmitSeconds: 11},
+		{Streams: 1, Seconds: 3, OmitSeconds: 3},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("%+v must not validate", bad)
+		}
+	}
+}
+
+func TestIperfParamsArgs(t *testing.T) {
+	got := IperfParams{Streams: 8, Seconds: 5, OmitSeconds: 2}.args("100.64.0.2", true)
+	want := []string{"-c", "100.64.0.2", "-t", "5", "-P", "8", "-O", "2", "-R", "-J"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("args = %v, want %v", got, want)
+	}
+	// The defaults keep the historical command line: no -P, -O or -R.
+	got = DefaultIperfParams.args("100.64.0.2", false)
+	want = []string{"-c", "100.64.0.2", "-t", "3", "-J"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("default args = %v, want %v", got, want)
+	}
+}
+
+func TestIperfParamsSource(t *testing.T) {
+	if got := (IperfParams{Streams: 8, Seconds: 5, OmitSeconds: 2}).Source(); got != "iperf3 -P8 -t5 -O2" {
+		t.Errorf("Source = %q, want %q", got, "iperf3 -P8 -t5 -O2")
+	}
+	if got := DefaultIperfParams.Source(); got != "iperf3 -P1 -t3" {
+		t.Errorf("default Source = %q, want %q", got, "iperf3 -P1 -t3")
+	}
+}
+
+func TestParseTailscaleStatus(t *testing.T) {
+	out := []byte(`{"Peer":{"key1":{"HostName":"pi-2","TailscaleIPs":["fd7a::1","100.64.0.2"]}}}`)
+	peers, err := parseTailscaleStatus(out)
+	if err != nil {
+		t.Fatalf("parseTailscaleStatus: %v", err)
+	}
+	if peers["pi-2"] != "100.64.0.2" {
+		t.Errorf("peers = %v, want the IPv4 address for pi-2", peers)
+	}
+}
+
+func ExampleEWMADuration() {
+	// The first sample seeds the series as-is; later ones move it by 30%.
+	fmt.Println(EWMADuration(0, 40*time.Millisecond, false))
+	// Output: 40ms
+}
+
+func ExampleLinkCost() {
+	// A 40ms, 800mbps link is cheap; a 160ms, 100mbps one is not.
+	fmt.Printf("%.2f\n", LinkCost(40*time.Millisecond, 800_000_000))
+	fmt.Printf("%.2f\n", LinkCost(160*time.Millisecond, 100_000_000))
+	// Output:
+	// 0.20
+	// 0.85
+}
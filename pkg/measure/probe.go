@@ -0,0 +1,378 @@
+package measure
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tailscalePong matches one "tailscale ping" reply line, e.g.
+//
+//	pong from hetzner-1 (100.64.0.3) via DERP(fra) in 23ms
+//	pong from pi-2 (100.64.0.2) via 192.168.1.5:41641 in 2ms
+var tailscalePong = regexp.MustCompile(`via (?:DERP\((\w+)\)|\S+) in ([\d.]+)ms`)
+
+// LatencyStats is the distribution of one probe's round-trip samples. An
+// average alone hides tail spikes; Max and Mdev are what latency-sensitive
+// consumers check against.
+type LatencyStats struct {
+	Min  time.Duration
+	Avg  time.Duration
+	Max  time.Duration
+	Mdev time.Duration
+}
+
+// statsFromSamples computes the distribution of individual reply times; mdev
+// is the mean absolute deviation, matching what ping reports.
+func statsFromSamples(samples []time.Duration) LatencyStats {
+	stats := LatencyStats{Min: samples[0], Max: samples[0]}
+	var total time.Duration
+	for _, s := range samples {
+		total += s
+		if s < stats.Min {
+			stats.Min = s
+		}
+		if s > stats.Max {
+			stats.Max = s
+		}
+	}
+	stats.Avg = total / time.Duration(len(samples))
+	var dev time.Duration
+	for _, s := range samples {
+		if s > stats.Avg {
+			dev += s - stats.Avg
+		} else {
+			dev += stats.Avg - s
+		}
+	}
+	stats.Mdev = dev / time.Duration(len(samples))
+	return stats
+}
+
+// TailscalePing measures latency over the WireGuard path itself using the
+// Tailscale LocalAPI ping, averaging the replies. The path return value is
+// "direct" or "derp-<region>" depending on how the last reply was relayed.
+func TailscalePing(addr string, count int) (time.Duration, string, error) {
+	stats, path, err := TailscalePingStats(addr, count)
+	return stats.Avg, path, err
+}
+
+// TailscalePingStats is TailscalePing with the full reply distribution.
+func TailscalePingStats(addr string, count int) (LatencyStats, string, error) {
+	out, err := exec.Command("tailscale", "ping", "-c", strconv.Itoa(count), "--until-direct=false", addr).Output()
+	if err != nil {
+		return LatencyStats{}, "", fmt.Errorf("tailscale ping %s: %w", addr, err)
+	}
+	stats, path, err := parseTailscalePing(out)
+	if err != nil {
+		return LatencyStats{}, "", fmt.Errorf("tailscale ping %s: %w", addr, err)
+	}
+	return stats, path, nil
+}
+
+func parseTailscalePing(out []byte) (LatencyStats, string, error) {
+	var samples []time.Duration
+	path := ""
+	for _, match := range tailscalePong.FindAllSubmatch(out, -1) {
+		ms, err := strconv.ParseFloat(string(match[2]), 64)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, time.Duration(ms*float64(time.Millisecond)))
+		if region := string(match[1]); region != "" {
+			path = "derp-" + strings.ToLower(region)
+		} else {
+			path = "direct"
+		}
+	}
+	if len(samples) == 0 {
+		return LatencyStats{}, "", fmt.Errorf("no pong in output")
+	}
+	return statsFromSamples(samples), path, nil
+}
+
+var pingRTT = regexp.MustCompile(`rtt [^=]*= *([\d.]+)/([\d.]+)/([\d.]+)/([\d.]+) *ms`)
+
+// windowsPingRTT matches the summary line of Windows ping, e.g.
+//
+//	Minimum = 1ms, Maximum = 4ms, Average = 2ms
+//
+// Windows reports whole milliseconds and no deviation.
+var windowsPingRTT = regexp.MustCompile(`Minimum = (\d+)ms, Maximum = (\d+)ms, Average = (\d+)ms`)
+
+// pingArgs builds the system ping command line for the OS the controller runs
+// on: Windows ping spells count and timeout -n/-w (milliseconds), everybody
+// else -c/-W (seconds).
+func pingArgs(goos, addr string, count int) []string {
+	if goos == "windows" {
+		return []string{"-n", strconv.Itoa(count), "-w", "2000", addr}
+	}
+	return []string{"-c", strconv.Itoa(count), "-W", "2", addr}
+}
+
+// PingLatency measures average round-trip latency with the system ping.
+func PingLatency(addr string, count int) (time.Duration, error) {
+	stats, err := PingLatencyStats(addr, count)
+	return stats.Avg, err
+}
+
+// PingLatencyStats is PingLatency with the min/avg/max/mdev distribution from
+// ping's rtt summary line.
+func PingLatencyStats(addr string, count int) (LatencyStats, error) {
+	out, err := exec.Command("ping", pingArgs(runtime.GOOS, addr, count)...).Output()
+	if err != nil {
+		return LatencyStats{}, fmt.Errorf("ping %s: %w", addr, err)
+	}
+	stats, err := parsePingRTT(out)
+	if err != nil {
+		return LatencyStats{}, fmt.Errorf("ping %s: %w", addr, err)
+	}
+	return stats, nil
+}
+
+// PingLatencyInNetns is PingLatency run inside another network namespace via
+// nsenter, used to compare the host and overlay probe vantages. Requires the
+// privileges nsenter needs (CAP_SYS_ADMIN).
+func PingLatencyInNetns(netns, addr string, count int) (time.Duration, error) {
+	out, err := exec.Command("nsenter", "--net="+netns, "ping", "-c", strconv.Itoa(count), "-W", "2", addr).Output()
+	if err != nil {
+		return 0, fmt.Errorf("nsenter ping %s: %w", addr, err)
+	}
+	stats, err := parsePingRTT(out)
+	if err != nil {
+		return 0, fmt.Errorf("nsenter ping %s: %w", addr, err)
+	}
+	return stats.Avg, nil
+}
+
+func parsePingRTT(out []byte) (LatencyStats, error) {
+	match := pingRTT.FindSubmatch(out)
+	if match == nil {
+		return parseWindowsPingRTT(out)
+	}
+	values := make([]time.Duration, 4)
+	for i := range values {
+		ms, err := strconv.ParseFloat(string(match[i+1]), 64)
+		if err != nil {
+			return LatencyStats{}, fmt.Errorf("parsing rtt: %w", err)
+		}
+		values[i] = time.Duration(ms * float64(time.Millisecond))
+	}
+	return LatencyStats{Min: values[0], Avg: values[1], Max: values[2], Mdev: values[3]}, nil
+}
+
+// parseWindowsPingRTT is the fallback for Windows ping output (English
+// locale), which carries min/max/avg in whole milliseconds and no deviation.
+func parseWindowsPingRTT(out []byte) (LatencyStats, error) {
+	match := windowsPingRTT.FindSubmatch(out)
+	if match == nil {
+		return LatencyStats{}, fmt.Errorf("no rtt summary in output")
+	}
+	values := make([]time.Duration, 3)
+	for i := range values {
+		ms, err := strconv.Atoi(string(match[i+1]))
+		if err != nil {
+			return LatencyStats{}, fmt.Errorf("parsing rtt: %w", err)
+		}
+		values[i] = time.Duration(ms) * time.Millisecond
+	}
+	return LatencyStats{Min: values[0], Max: values[1], Avg: values[2]}, nil
+}
+
+// fpingLine matches one per-target summary line of "fping -q -c", e.g.
+//
+//	100.64.0.2 : xmt/rcv/%loss = 3/3/0%, min/avg/max = 1.20/2.35/3.40
+//	100.64.0.9 : xmt/rcv/%loss = 3/0/100%
+var fpingLine = regexp.MustCompile(`(?m)^(\S+)\s*: xmt/rcv/%loss = \d+/(\d+)/[\d.]+%(?:, min/avg/max = [\d.]+/([\d.]+)/[\d.]+)?`)
+
+// FpingLatency measures average round-trip latency to many targets with a
+// single fping process. Sweeping a large fleet one ping at a time dominates
+// cycle wall time; fping interleaves the echoes itself. Per-target semantics
+// match PingLatency — the average of count echoes — and targets that lost
+// every echo are simply absent from the result.
+func FpingLatency(addrs []string, count int) (map[string]time.Duration, error) {
+	args := append([]string{"-q", "-c", strconv.Itoa(count), "-t", "2000"}, addrs...)
+	// fping exits nonzero whenever any target is unreachable, but the
+	// per-target summaries it prints are still complete; only missing
+	// output is treated as failure.
+	out, _ := exec.Command("fping", args...).CombinedOutput()
+	latencies, err := parseFpingOutput(out)
+	if err != nil {
+		return nil, fmt.Errorf("fping: %w", err)
+	}
+	return latencies, nil
+}
+
+func parseFpingOutput(out []byte) (map[string]time.Duration, error) {
+	matches := fpingLine.FindAllSubmatch(out, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no target summaries in output")
+	}
+	latencies := map[string]time.Duration{}
+	for _, match := range matches {
+		if string(match[2]) == "0" || len(match[3]) == 0 {
+			continue
+		}
+		avgMs, err := strconv.ParseFloat(string(match[3]), 64)
+		if err != nil {
+			continue
+		}
+		latencies[string(match[1])] = time.Duration(avgMs * float64(time.Millisecond))
+	}
+	return latencies, nil
+}
+
+// IperfParams configures the iperf3 bandwidth probe. Single-stream iperf3
+// underestimates throughput on high-latency links (TCP window limits), so
+// Streams above 1 matter on WAN paths; OmitSeconds discards the initial
+// TCP slow-start seconds from the result.
+type IperfParams struct {
+	// Streams is the number of parallel streams (-P). 1 to 64.
+	Streams int
+	// Seconds is the probe duration (-t). 1 to 60.
+	Seconds int
+	// OmitSeconds is how many initial seconds to ignore (-O). 0 to 10 and
+	// less than Seconds.
+	OmitSeconds int
+}
+
+// DefaultIperfParams is the historical single-stream, 3-second probe.
+var DefaultIperfParams = IperfParams{Streams: 1, Seconds: 3}
+
+// Validate bounds-checks the parameters; out-of-range values burn probe time
+// or hang cycles rather than failing loudly, so they are rejected up front.
+func (p IperfParams) Validate() error {
+	if p.Streams < 1 || p.Streams > 64 {
+		return fmt.Errorf("iperf streams %d out of range [1,64]", p.Streams)
+	}
+	if p.Seconds < 1 || p.Seconds > 60 {
+		return fmt.Errorf("iperf duration %ds out of range [1,60]", p.Seconds)
+	}
+	if p.OmitSeconds < 0 || p.OmitSeconds > 10 {
+		return fmt.Errorf("iperf omit %ds out of range [0,10]", p.OmitSeconds)
+	}
+	if p.OmitSeconds >= p.Seconds {
+		return fmt.Errorf("iperf omit %ds must be shorter than the %ds duration", p.OmitSeconds, p.Seconds)
+	}
+	return nil
+}
+
+// Source encodes the parameters for a link's measurementSource field, e.g.
+// "iperf3 -P8 -t5 -O2", so values measured with different settings are never
+// compared blindly.
+func (p IperfParams) Source() string {
+	source := fmt.Sprintf("iperf3 -P%d -t%d", p.Streams, p.Seconds)
+	if p.OmitSeconds > 0 {
+		source += fmt.Sprintf(" -O%d", p.OmitSeconds)
+	}
+	return source
+}
+
+// args builds the iperf3 command line for the target address.
+func (p IperfParams) args(addr string, reverse bool) []string {
+	args := []string{"-c", addr, "-t", strconv.Itoa(p.Seconds)}
+	if p.Streams > 1 {
+		args = append(args, "-P", strconv.Itoa(p.Streams))
+	}
+	if p.OmitSeconds > 0 {
+		args = append(args, "-O", strconv.Itoa(p.OmitSeconds))
+	}
+	if reverse {
+		args = append(args, "-R")
+	}
+	return append(args, "-J")
+}
+
+// IperfBandwidth measures transmit throughput toward the iperf3 agent on the
+// target, in bits per second: the iperf3 default direction, local send.
+func IperfBandwidth(addr string, params IperfParams) (int64, error) {
+	out, err := exec.Command("iperf3", params.args(addr, false)...).Output()
+	if err != nil {
+		return 0, fmt.Errorf("iperf3 %s: %w", addr, err)
+	}
+	bandwidth, err := parseIperfOutput(out)
+	if err != nil {
+		return 0, fmt.Errorf("iperf3 %s: %w", addr, err)
+	}
+	return bandwidth, nil
+}
+
+// IperfBandwidthReverse measures receive throughput from the target using
+// iperf3 -R (the target sends, we receive), in bits per second. On asymmetric
+// links this is the download direction that IperfBandwidth cannot see.
+func IperfBandwidthReverse(addr string, params IperfParams) (int64, error) {
+	out, err := exec.Command("iperf3", params.args(addr, true)...).Output()
+	if err != nil {
+		return 0, fmt.Errorf("iperf3 -R %s: %w", addr, err)
+	}
+	bandwidth, err := parseIperfReverseOutput(out)
+	if err != nil {
+		return 0, fmt.Errorf("iperf3 -R %s: %w", addr, err)
+	}
+	return bandwidth, nil
+}
+
+func parseIperfReverseOutput(out []byte) (int64, error) {
+	var result struct {
+		End struct {
+			SumReceived struct {
+				BitsPerSecond float64 `json:"bits_per_second"`
+			} `json:"sum_received"`
+		} `json:"end"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return 0, fmt.Errorf("parsing output: %w", err)
+	}
+	return int64(result.End.SumReceived.BitsPerSecond), nil
+}
+
+func parseIperfOutput(out []byte) (int64, error) {
+	var result struct {
+		End struct {
+			SumSent struct {
+				BitsPerSecond float64 `json:"bits_per_second"`
+			} `json:"sum_sent"`
+		} `json:"end"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return 0, fmt.Errorf("parsing output: %w", err)
+	}
+	return int64(result.End.SumSent.BitsPerSecond), nil
+}
+
+// TailscalePeers maps peer hostnames to their Tailscale IPv4 addresses by
+// querying the local tailscale daemon.
+func TailscalePeers() (map[string]string, error) {
+	out, err := exec.Command("tailscale", "status", "--json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("tailscale status: %w", err)
+	}
+	return parseTailscaleStatus(out)
+}
+
+func parseTailscaleStatus(out []byte) (map[string]string, error) {
+	var status struct {
+		Peer map[string]struct {
+			HostName     string   `json:"HostName"`
+			TailscaleIPs []string `json:"TailscaleIPs"`
+		} `json:"Peer"`
+	}
+	if err := json.Unmarshal(out, &status); err != nil {
+		return nil, fmt.Errorf("parsing tailscale status: %w", err)
+	}
+	peers := map[string]string{}
+	for _, peer := range status.Peer {
+		for _, ip := range peer.TailscaleIPs {
+			if strings.Count(ip, ".") == 3 {
+				peers[peer.HostName] = ip
+				break
+			}
+		}
+	}
+	return peers, nil
+}
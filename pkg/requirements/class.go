@@ -0,0 +1,65 @@
+package requirements
+
+import (
+	"fmt"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// Annotation keys for NetworkClass references.
+const (
+	// AnnotationClass names the NetworkClass whose requirements the pod
+	// inherits. Pod-level annotations override individual class fields.
+	AnnotationClass = AnnotationPrefix + "class"
+	// AnnotationDefaultClass, set on a Namespace, names the class applied
+	// to pods in that namespace that reference none themselves.
+	AnnotationDefaultClass = AnnotationPrefix + "default-class"
+)
+
+// ExpandClass fills every requirement field the pod left unset from the
+// class spec; fields the pod set itself are untouched. The class's
+// requirement mode applies only to the fields it supplied, and a pod-level
+// mode annotation for such a field still wins. Malformed class values are
+// errors: a broken class should surface on the pods using it, not schedule
+// them anywhere.
+func ExpandClass(reqs *Requirements, className string, spec *v1alpha1.NetworkClassSpec) error {
+	classMode := Mode(spec.RequirementMode)
+	applyMode := func(key string) {
+		if classMode != ModePreferred {
+			return
+		}
+		if reqs.Modes == nil {
+			reqs.Modes = map[string]Mode{}
+		}
+		if _, ok := reqs.Modes[key]; !ok {
+			reqs.Modes[key] = ModePreferred
+		}
+	}
+
+	if reqs.MinBandwidthMbps == 0 && spec.MinBandwidth != "" {
+		bps, err := units.ParseBandwidth(spec.MinBandwidth)
+		if err != nil {
+			return fmt.Errorf("NetworkClass %q: invalid minBandwidth: %w", className, err)
+		}
+		reqs.MinBandwidthMbps = units.ToMbps(bps)
+		applyMode(AnnotationMinBandwidth)
+	}
+	if reqs.MaxLatencyMs == 0 && spec.MaxLatency != "" {
+		d, err := units.ParseLatency(spec.MaxLatency)
+		if err != nil {
+			return fmt.Errorf("NetworkClass %q: invalid maxLatency: %w", className, err)
+		}
+		reqs.MaxLatencyMs = d.Milliseconds()
+		applyMode(AnnotationMaxLatency)
+	}
+	if reqs.DataLocality == "" && spec.DataLocality != "" {
+		reqs.DataLocality = spec.DataLocality
+		applyMode(AnnotationDataLocality)
+	}
+	if len(reqs.Capabilities) == 0 && len(spec.Capabilities) > 0 {
+		reqs.Capabilities = append([]string(nil), spec.Capabilities...)
+		applyMode(AnnotationRequiredCapabilities)
+	}
+	return nil
+}
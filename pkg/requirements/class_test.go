@@ -0,0 +1,60 @@
+package requirements
+
+import (
+	"testing"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+func TestExpandClassFillsUnsetFields(t *testing.T) {
+	reqs := mustParse(t, podWithAnnotations(map[string]string{
+		AnnotationMinBandwidth: "500mbps", // pod wins over the class's 100mbps
+	}))
+	spec := &v1alpha1.NetworkClassSpec{
+		MinBandwidth: "100mbps",
+		MaxLatency:   "10ms",
+		DataLocality: "high",
+		Capabilities: []string{"internet"},
+	}
+	if err := ExpandClass(reqs, "low-latency", spec); err != nil {
+		t.Fatalf("ExpandClass: %v", err)
+	}
+	if reqs.MinBandwidthMbps != 500 {
+		t.Errorf("pod annotation should win over class: got %d", reqs.MinBandwidthMbps)
+	}
+	if reqs.MaxLatencyMs != 10 || reqs.DataLocality != "high" || len(reqs.Capabilities) != 1 {
+		t.Errorf("class fields not expanded: %+v", reqs)
+	}
+}
+
+func TestExpandClassMode(t *testing.T) {
+	reqs := mustParse(t, podWithAnnotations(map[string]string{
+		AnnotationMaxLatency + ".mode": "required",
+	}))
+	spec := &v1alpha1.NetworkClassSpec{
+		MaxLatency:      "10ms",
+		DataLocality:    "high",
+		RequirementMode: "preferred",
+	}
+	if err := ExpandClass(reqs, "best-effort", spec); err != nil {
+		t.Fatalf("ExpandClass: %v", err)
+	}
+	if got := reqs.Mode(AnnotationDataLocality); got != ModePreferred {
+		t.Errorf("class mode should apply to class-supplied fields: got %q", got)
+	}
+	if got := reqs.Mode(AnnotationMaxLatency); got != ModeRequired {
+		t.Errorf("pod-level mode should win over the class mode: got %q", got)
+	}
+}
+
+func TestExpandClassRejectsMalformedValues(t *testing.T) {
+	for _, spec := range []*v1alpha1.NetworkClassSpec{
+		{MinBandwidth: "fast"},
+		{MaxLatency: "soon"},
+	} {
+		reqs := mustParse(t, podWithAnnotations(nil))
+		if err := ExpandClass(reqs, "broken", spec); err == nil {
+			t.Errorf("ExpandClass(%+v) should fail", spec)
+		}
+	}
+}
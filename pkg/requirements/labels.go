@@ -0,0 +1,155 @@
+package requirements
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// Well-known Kubernetes labels most provisioners set; consulted after the
+// short bespoke keys so existing labeling keeps winning.
+const (
+	LabelTopologyZone   = "topology.kubernetes.io/zone"
+	LabelTopologyRegion = "topology.kubernetes.io/region"
+	// LabelInstanceType is the standard instance-type label, usable as an
+	// optional capability hint.
+	LabelInstanceType = "node.kubernetes.io/instance-type"
+)
+
+// LabelKeys maps the label roles the scheduler and controller consult to the
+// concrete label keys a cluster uses, so a fleet already labeled with
+// topology.kubernetes.io/zone does not need relabeling to the short names.
+// Each field is a comma-separated precedence list; the first key set on a
+// node wins. Zero-value fields fall back to the defaults, so the empty
+// struct behaves like DefaultLabelKeys. Keys are matched verbatim —
+// Kubernetes label keys are case-sensitive — but every value read through
+// this type is normalized with NormalizeLabelValue.
+type LabelKeys struct {
+	// Zone is the precedence list for the zone; defaults to
+	// "zone,topology.kubernetes.io/zone".
+	Zone string `json:"zone,omitempty"`
+	// Region is the precedence list for the region; defaults to
+	// "region,topology.kubernetes.io/region".
+	Region string `json:"region,omitempty"`
+	// Role is the precedence list for the role; defaults to "role".
+	Role string `json:"role,omitempty"`
+	// GPU is the precedence list for the GPU marker; defaults to "gpu".
+	GPU string `json:"gpu,omitempty"`
+}
+
+// DefaultLabelKeys returns the default precedence: the historical short keys
+// first, then the well-known Kubernetes topology labels.
+func DefaultLabelKeys() LabelKeys {
+	return LabelKeys{
+		Zone:   LabelZone + "," + LabelTopologyZone,
+		Region: LabelRegion + "," + LabelTopologyRegion,
+		Role:   LabelRole,
+		GPU:    LabelGPU,
+	}
+}
+
+// withDefaults fills unset keys so the zero value reads the default labels.
+func (k LabelKeys) withDefaults() LabelKeys {
+	d := DefaultLabelKeys()
+	if k.Zone == "" {
+		k.Zone = d.Zone
+	}
+	if k.Region == "" {
+		k.Region = d.Region
+	}
+	if k.Role == "" {
+		k.Role = d.Role
+	}
+	if k.GPU == "" {
+		k.GPU = d.GPU
+	}
+	return k
+}
+
+// splitKeys parses a comma-separated precedence list, tolerating whitespace
+// around the keys.
+func splitKeys(list string) []string {
+	var keys []string
+	for _, key := range strings.Split(list, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// firstLabel returns the normalized value of the first key in the precedence
+// list set on the node; "" when none is.
+func firstLabel(node *v1.Node, list string) string {
+	for _, key := range splitKeys(list) {
+		if v := NodeLabel(node, key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// NormalizeLabelValue trims surrounding whitespace and lowercases a label
+// value, so a "Local " written by a config-management template compares equal
+// to "local".
+func NormalizeLabelValue(v string) string {
+	return strings.ToLower(strings.TrimSpace(v))
+}
+
+// NodeLabel returns the node's normalized value for key; "" when unset.
+func NodeLabel(node *v1.Node, key string) string {
+	return NormalizeLabelValue(node.Labels[key])
+}
+
+// ZoneOf returns the node's normalized zone from the first zone label set.
+func (k LabelKeys) ZoneOf(node *v1.Node) string {
+	return firstLabel(node, k.withDefaults().Zone)
+}
+
+// RegionOf returns the node's normalized region from the first region label
+// set.
+func (k LabelKeys) RegionOf(node *v1.Node) string {
+	return firstLabel(node, k.withDefaults().Region)
+}
+
+// RoleOf returns the node's normalized role from the first role label set.
+func (k LabelKeys) RoleOf(node *v1.Node) string {
+	return firstLabel(node, k.withDefaults().Role)
+}
+
+// GPUOf returns the node's normalized gpu marker from the first GPU label
+// set.
+func (k LabelKeys) GPUOf(node *v1.Node) string {
+	return firstLabel(node, k.withDefaults().GPU)
+}
+
+// NodeZone reads the node's zone from the topology, falling back to the
+// mapped zone label. Both sources are normalized so comparisons against
+// values like "local" hold regardless of how the label was typed.
+func (k LabelKeys) NodeZone(node *v1.Node, topo *v1alpha1.NetworkTopology) string {
+	if spec, ok := TopologyNode(node, topo); ok && spec.Zone != "" {
+		return NormalizeLabelValue(spec.Zone)
+	}
+	return k.ZoneOf(node)
+}
+
+// NodeHasCapability checks the topology capabilities, falling back to the
+// mapped node labels for the well-known ones.
+func (k LabelKeys) NodeHasCapability(node *v1.Node, topo *v1alpha1.NetworkTopology, capability string) bool {
+	if spec, ok := TopologyNode(node, topo); ok {
+		for _, c := range spec.Capabilities {
+			if c == capability {
+				return true
+			}
+		}
+	}
+	switch capability {
+	case "internet":
+		return NodeLabel(node, "internet") == "true"
+	case "gpu":
+		return k.GPUOf(node) != ""
+	}
+	return false
+}
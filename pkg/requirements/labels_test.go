@@ -0,0 +1,153 @@
+package requirements
+
+import (
+	"testing"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+func topoWithNode(name, zone string) *v1alpha1.NetworkTopology {
+	return &v1alpha1.NetworkTopology{
+		Spec: v1alpha1.NetworkTopologySpec{
+			Nodes: map[string]v1alpha1.NodeSpec{name: {Zone: zone}},
+		},
+	}
+}
+
+func TestNormalizeLabelValue(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"local", "local"},
+		{"Local", "local"},
+		{" Remote ", "remote"},
+		{"NVIDIA", "nvidia"},
+		{"", ""},
+		{"  ", ""},
+	}
+	for _, tc := range tests {
+		if got := NormalizeLabelValue(tc.in); got != tc.want {
+			t.Errorf("NormalizeLabelValue(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestLabelKeysMapping(t *testing.T) {
+	node := nodeWithLabels("n1", map[string]string{
+		"topology.kubernetes.io/zone":   "Zone-A",
+		"topology.kubernetes.io/region": "EU-West ",
+		"node-role.kubernetes.io/type":  "Gateway",
+		"accelerator":                   "NVIDIA",
+	})
+	keys := LabelKeys{
+		Zone:   "topology.kubernetes.io/zone",
+		Region: "topology.kubernetes.io/region",
+		Role:   "node-role.kubernetes.io/type",
+		GPU:    "accelerator",
+	}
+	if got := keys.ZoneOf(node); got != "zone-a" {
+		t.Errorf("ZoneOf = %q, want zone-a", got)
+	}
+	if got := keys.RegionOf(node); got != "eu-west" {
+		t.Errorf("RegionOf = %q, want eu-west", got)
+	}
+	if got := keys.RoleOf(node); got != "gateway" {
+		t.Errorf("RoleOf = %q, want gateway", got)
+	}
+	if !keys.NodeHasCapability(node, nil, "gpu") {
+		t.Error("mapped gpu label not recognized as gpu capability")
+	}
+}
+
+func TestLabelKeysZeroValueReadsDefaults(t *testing.T) {
+	node := nodeWithLabels("n1", map[string]string{"zone": "Local ", "gpu": "nvidia"})
+	var keys LabelKeys
+	if got := keys.ZoneOf(node); got != "local" {
+		t.Errorf("zero-value ZoneOf = %q, want local", got)
+	}
+	if !keys.NodeHasCapability(node, nil, "gpu") {
+		t.Error("zero-value keys miss the default gpu label")
+	}
+}
+
+func TestLabelKeysStandardFallbacks(t *testing.T) {
+	tests := []struct {
+		name       string
+		labels     map[string]string
+		keys       LabelKeys
+		wantZone   string
+		wantRegion string
+	}{
+		{
+			name: "standard labels only",
+			labels: map[string]string{
+				LabelTopologyZone:   "eu-central-1a",
+				LabelTopologyRegion: "eu-central-1",
+			},
+			wantZone:   "eu-central-1a",
+			wantRegion: "eu-central-1",
+		},
+		{
+			name:       "custom labels only",
+			labels:     map[string]string{"zone": "local", "region": "garage"},
+			wantZone:   "local",
+			wantRegion: "garage",
+		},
+		{
+			name: "both set, short keys win by default",
+			labels: map[string]string{
+				"zone":              "local",
+				"region":            "garage",
+				LabelTopologyZone:   "eu-central-1a",
+				LabelTopologyRegion: "eu-central-1",
+			},
+			wantZone:   "local",
+			wantRegion: "garage",
+		},
+		{
+			name: "both set, configured precedence prefers the standard keys",
+			labels: map[string]string{
+				"zone":            "local",
+				LabelTopologyZone: "eu-central-1a",
+			},
+			keys:     LabelKeys{Zone: LabelTopologyZone + ",zone"},
+			wantZone: "eu-central-1a",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			node := nodeWithLabels("n1", tc.labels)
+			if got := tc.keys.ZoneOf(node); got != tc.wantZone {
+				t.Errorf("ZoneOf = %q, want %q", got, tc.wantZone)
+			}
+			if tc.wantRegion != "" {
+				if got := tc.keys.RegionOf(node); got != tc.wantRegion {
+					t.Errorf("RegionOf = %q, want %q", got, tc.wantRegion)
+				}
+			}
+		})
+	}
+}
+
+func TestNodeZoneNormalizesBothSources(t *testing.T) {
+	// The label path: an Ansible-set "Local" must satisfy data-locality.
+	node := nodeWithLabels("n1", map[string]string{"zone": "Local"})
+	if got := NodeZone(node, nil); got != "local" {
+		t.Errorf("NodeZone from label = %q, want local", got)
+	}
+	// The topology path wins over the label and is normalized too.
+	topo := topoWithNode("n1", "Remote ")
+	if got := NodeZone(node, topo); got != "remote" {
+		t.Errorf("NodeZone from topology = %q, want remote", got)
+	}
+}
+
+func TestEvaluateDataLocalityToleratesLabelCase(t *testing.T) {
+	reqs := &Requirements{DataLocality: "high"}
+	node := nodeWithLabels("n1", map[string]string{"zone": "Local"})
+	for _, v := range Evaluate(reqs, node, nil, DefaultUnknownPolicy()) {
+		if v.Kind == KindLocality {
+			t.Fatalf("capitalized local zone rejected: %s", v.Reason)
+		}
+	}
+}
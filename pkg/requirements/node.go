@@ -0,0 +1,319 @@
+package requirements
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// Node label keys consulted when the topology has no data for a node.
+const (
+	LabelZone   = "zone"
+	LabelRegion = "region"
+	LabelRole   = "role"
+	LabelGPU    = "gpu"
+	// LabelNodeBandwidth and LabelNodeLatency let operators declare link
+	// characteristics on nodes not covered by the topology controller.
+	LabelNodeBandwidth = "network-bandwidth"
+	LabelNodeLatency   = "network-latency"
+
+	// LabelUplinkGroup assigns a node to a shared-bottleneck uplink group;
+	// all members' traffic shares one physical uplink. A NodeNetworkProfile
+	// UplinkGroup declaration overrides the label.
+	LabelUplinkGroup = AnnotationPrefix + "uplink-group"
+
+	// LabelHub marks a node as a measurement hub for the controller's
+	// hub-spoke pair sampler: spokes are only probed against hubs, and
+	// spoke↔spoke costs are derived from the hub legs.
+	LabelHub = AnnotationPrefix + "hub"
+
+	// LabelSegments is a comma-separated list of network segments the node
+	// can reach; the controller only probes pairs sharing a segment. A
+	// NodeNetworkProfile Segments declaration overrides the label.
+	LabelSegments = AnnotationPrefix + "segments"
+
+	// LabelDomain is the network-derived domain label the controller
+	// maintains on nodes when domain labeling is enabled: nodes in one
+	// connectivity island share a value, so stock topologySpreadConstraints
+	// can spread across measured network domains instead of declared zones.
+	LabelDomain = AnnotationPrefix + "domain"
+
+	// AnnotationMaintenance on a Node marks planned maintenance: the
+	// controller stops probing the node and freezes its last
+	// pre-maintenance measurements so the EWMA history is not polluted,
+	// and the scheduler filters (or penalizes) it. Removing the
+	// annotation re-probes the node's links before unfreezing.
+	AnnotationMaintenance = AnnotationPrefix + "maintenance"
+
+	// AnnotationAddressPolicy on a Node overrides the controller's global
+	// address-selection policy for that node: an ordered, comma-separated
+	// preference list of address classes, e.g. "tailscale,internal-v4,external".
+	AnnotationAddressPolicy = AnnotationPrefix + "address-policy"
+
+	// AnnotationProbeAddress on a Node pins the exact address the
+	// controller probes it at, taking precedence over every address
+	// policy — for k3s multi-NIC nodes where flannel is pinned to an
+	// interface the default route does not use. The node must actually
+	// hold the address; otherwise the controller emits a warning Event
+	// and falls back to the policy rather than probe the wrong path.
+	AnnotationProbeAddress = AnnotationPrefix + "probe-address"
+
+	// AnnotationProbeInterface on a Node names the network interface
+	// carrying the CNI traffic (e.g. flannel's --iface). The interface is
+	// looked up on the controller's host and its subnets select among the
+	// node's held addresses, so one annotation value works across nodes
+	// sharing an L2 segment.
+	AnnotationProbeInterface = AnnotationPrefix + "probe-interface"
+)
+
+// NodeInMaintenance reports whether the node carries the maintenance
+// annotation.
+func NodeInMaintenance(node *v1.Node) bool {
+	return node.Annotations[AnnotationMaintenance] == "true"
+}
+
+// MeasuredBandwidthMbps returns the node's best measured bandwidth toward any
+// peer, falling back to the network-bandwidth label. The second return
+// distinguishes "unknown" from a genuinely known value so callers can apply
+// an UnknownPolicy. Values come from the snapshot's pre-parsed numeric view,
+// so repeated calls during one scheduling cycle never re-parse strings.
+// When a blend policy is configured (topology.DefaultBlend), aged
+// measurements decay toward their declared figures before the best link is
+// picked; every consumer of this helper sees the blended value.
+func MeasuredBandwidthMbps(node *v1.Node, topo *v1alpha1.NetworkTopology) (int64, bool) {
+	if topo != nil {
+		if bw, ok := topology.Shared(topo).BestBlendedBandwidthMbps(node.Name, topology.DefaultBlend); ok {
+			return bw, true
+		}
+	}
+	if v, ok := node.Labels[LabelNodeBandwidth]; ok {
+		if bw, ok := ParseBandwidthMbps(v); ok {
+			return bw, true
+		}
+	}
+	return 0, false
+}
+
+// MeasuredLatencyMs returns the node's best measured latency toward any peer,
+// falling back to the network-latency label, with the second return
+// distinguishing unknown nodes.
+func MeasuredLatencyMs(node *v1.Node, topo *v1alpha1.NetworkTopology) (int64, bool) {
+	if topo != nil {
+		if lat, ok := topology.Shared(topo).BestLatencyMs(node.Name); ok {
+			return lat, true
+		}
+	}
+	if v, ok := node.Labels[LabelNodeLatency]; ok {
+		if lat, ok := ParseLatencyMs(v); ok {
+			return lat, true
+		}
+	}
+	return 0, false
+}
+
+// TargetLatencyMs returns the node's measured latency toward one specific
+// topology entry — another node or an "external/<name>" endpoint — with
+// ok=false when the pair is unmeasured. There is no label fallback: a
+// node-level latency label describes the node's uplink, not a particular
+// pair.
+func TargetLatencyMs(node *v1.Node, topo *v1alpha1.NetworkTopology, target string) (int64, bool) {
+	if topo == nil {
+		return 0, false
+	}
+	return topology.Shared(topo).LatencyMs(node.Name, target)
+}
+
+// VantageAdjustmentMs returns the latency correction for a pod whose network
+// namespace sits on the other side of the CNI overlay from where the
+// topology's probes ran: positive (add the overlay's published overhead) for
+// an overlay pod reading host-vantage measurements, negative for a
+// hostNetwork pod reading overlay-vantage ones. Zero when the vantages match,
+// the topology predates vantage recording, or the node publishes no overhead.
+func VantageAdjustmentMs(hostNetwork bool, node *v1.Node, topo *v1alpha1.NetworkTopology) int64 {
+	if topo == nil {
+		return 0
+	}
+	podVantage := v1alpha1.ProbeVantageOverlay
+	if hostNetwork {
+		podVantage = v1alpha1.ProbeVantageHost
+	}
+	vantage := topo.Spec.ProbeVantage
+	if vantage == "" || vantage == podVantage {
+		return 0
+	}
+	overhead, ok := topology.Shared(topo).MinOverlayOverheadMs(node.Name)
+	if !ok {
+		return 0
+	}
+	if podVantage == v1alpha1.ProbeVantageOverlay {
+		return overhead
+	}
+	return -overhead
+}
+
+// MeasuredTailLatencyMs returns the node's best tail-latency bound toward any
+// peer, derived from the published latency statistics: per link the larger of
+// the observed max and avg + 2·mdev (a p99-ish estimate), then the minimum
+// across links, mirroring MeasuredLatencyMs. ok=false when no link of the
+// node carries statistics.
+func MeasuredTailLatencyMs(node *v1.Node, topo *v1alpha1.NetworkTopology) (int64, bool) {
+	spec, ok := TopologyNode(node, topo)
+	if !ok {
+		return 0, false
+	}
+	best := int64(0)
+	found := false
+	for _, stats := range spec.LatencyStats {
+		tail, ok := tailLatencyMs(stats)
+		if !ok {
+			continue
+		}
+		if !found || tail < best {
+			best = tail
+			found = true
+		}
+	}
+	return best, found
+}
+
+// tailLatencyMs condenses one link's statistics into a single worst-case
+// bound.
+func tailLatencyMs(stats v1alpha1.LatencyStats) (int64, bool) {
+	maxMs, ok := ParseLatencyMs(stats.Max)
+	if !ok {
+		return 0, false
+	}
+	if avg, ok := ParseLatencyMs(stats.Avg); ok {
+		if mdev, ok := ParseLatencyMs(stats.Mdev); ok && avg+2*mdev > maxMs {
+			return avg + 2*mdev, true
+		}
+	}
+	return maxMs, true
+}
+
+// NodeSchedulable reports whether the node accepts new pods: not cordoned
+// and carrying no NoSchedule taint. The controller records this in NodeSpec
+// and the scheduler uses it to withhold preference bonuses, so a node down
+// for maintenance cannot distort score comparisons among the live ones.
+func NodeSchedulable(node *v1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == v1.TaintEffectNoSchedule {
+			return false
+		}
+	}
+	return true
+}
+
+// NodeMinCost returns the node's cheapest link cost, defaulting to the
+// maximum cost when nothing is known. A topology carrying no cost entries at
+// all — a single-node cluster, or one whose pairs have not measured yet —
+// reads as neutral instead: there are no peers to be expensive relative to,
+// and the maximum would just subtract a flat penalty from every node.
+func NodeMinCost(node *v1.Node, topo *v1alpha1.NetworkTopology) float64 {
+	if topo != nil {
+		ix := topology.Shared(topo)
+		if cost, ok := ix.MinCost(node.Name); ok {
+			return cost
+		}
+		if !ix.ClusterHasCosts() {
+			return 0
+		}
+	}
+	return 1.0
+}
+
+// NodeCost returns the node's link cost for ceiling checks: the link toward
+// the named target when one is given, otherwise the node's cheapest link.
+// ok=false means no cost is published — unlike NodeMinCost there is no
+// neutral substitute, because a ceiling on unknown data would reject nodes
+// for what the controller has not measured yet.
+func NodeCost(node *v1.Node, topo *v1alpha1.NetworkTopology, target string) (float64, bool) {
+	if topo == nil {
+		return 0, false
+	}
+	ix := topology.Shared(topo)
+	if target != "" {
+		return ix.Cost(node.Name, target)
+	}
+	return ix.MinCost(node.Name)
+}
+
+// NodeZone reads the node's zone from the topology, falling back to the zone
+// label, with the default label keys.
+func NodeZone(node *v1.Node, topo *v1alpha1.NetworkTopology) string {
+	return DefaultLabelKeys().NodeZone(node, topo)
+}
+
+// NodeHasCapability checks the topology capabilities, falling back to node
+// labels for the well-known ones, with the default label keys.
+func NodeHasCapability(node *v1.Node, topo *v1alpha1.NetworkTopology, capability string) bool {
+	return DefaultLabelKeys().NodeHasCapability(node, topo, capability)
+}
+
+// NodeUtilization returns the node's link utilization from the topology,
+// with ok=false when it is unpublished or its measurement is older than
+// maxAge: acting on stale saturation data is worse than ignoring it.
+func NodeUtilization(node *v1.Node, topo *v1alpha1.NetworkTopology, maxAge time.Duration) (float64, bool) {
+	if maxAge <= 0 {
+		maxAge = DefaultUtilizationMaxAge
+	}
+	spec, ok := TopologyNode(node, topo)
+	if !ok || spec.Utilization == nil {
+		return 0, false
+	}
+	if spec.UtilizationUpdated == nil || time.Since(spec.UtilizationUpdated.Time) > maxAge {
+		return 0, false
+	}
+	return *spec.Utilization, true
+}
+
+// NodeHealthScore returns the node's health score from the topology, with
+// ok=false when no health data is published for it.
+func NodeHealthScore(node *v1.Node, topo *v1alpha1.NetworkTopology) (float64, bool) {
+	spec, ok := TopologyNode(node, topo)
+	if !ok || spec.HealthScore == nil {
+		return 0, false
+	}
+	return *spec.HealthScore, true
+}
+
+// TopologyNode looks the node up in the topology spec. Lookups go through
+// pkg/topology so both the per-node maps and the explicit links
+// representation are handled.
+func TopologyNode(node *v1.Node, topo *v1alpha1.NetworkTopology) (v1alpha1.NodeSpec, bool) {
+	if topo == nil {
+		return v1alpha1.NodeSpec{}, false
+	}
+	return topology.Shared(topo).Node(node.Name)
+}
+
+// ParseBandwidthMbps parses a topology or label bandwidth value, returning
+// ok=false for unparseable strings so callers treat the value as unknown
+// rather than substituting a sentinel.
+func ParseBandwidthMbps(s string) (int64, bool) {
+	bps, err := units.ParseBandwidth(s)
+	if err != nil {
+		klog.V(4).InfoS("Ignoring unparseable bandwidth value", "value", s, "err", err)
+		return 0, false
+	}
+	return units.ToMbps(bps), true
+}
+
+// ParseLatencyMs parses a topology or label latency value, returning ok=false
+// for unparseable strings.
+func ParseLatencyMs(s string) (int64, bool) {
+	d, err := units.ParseLatency(s)
+	if err != nil {
+		klog.V(4).InfoS("Ignoring unparseable latency value", "value", s, "err", err)
+		return 0, false
+	}
+	return d.Milliseconds(), true
+}
@@ -0,0 +1,495 @@
+// Package requirements parses the network.komarov.dev pod annotations and
+// evaluates them against nodes and the measured topology. The scheduler
+// plugin's Filter and the descheduler share this logic so a pod is judged by
+// exactly the same rules at admission time and afterwards.
+package requirements
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// AnnotationPrefix is the namespace of all annotations understood here.
+const AnnotationPrefix = "network.komarov.dev/"
+
+// Annotation keys expressing a pod's network requirements.
+const (
+	// AnnotationMinBandwidth requires a minimum bandwidth, e.g. "200mbps".
+	AnnotationMinBandwidth = AnnotationPrefix + "min-bandwidth"
+	// AnnotationMinBandwidthInit requires the minimum bandwidth only while
+	// the pod's init containers run, e.g. "500mbps" for a model or dataset
+	// download. Consumers enforce the larger of this and min-bandwidth at
+	// placement time and release the init-phase share once the pod is
+	// Running.
+	AnnotationMinBandwidthInit = AnnotationMinBandwidth + ".init"
+	// AnnotationMaxLatency requires a maximum latency, e.g. "10ms".
+	AnnotationMaxLatency = AnnotationPrefix + "max-latency"
+	// AnnotationMaxLatencyTo names the reference point max-latency is
+	// measured to: another node, or an external endpoint published under
+	// "external/<name>". With it set, max-latency bounds the candidate
+	// node's measured latency toward that one target instead of its best
+	// link toward any peer. It modifies max-latency and is an error on its
+	// own.
+	AnnotationMaxLatencyTo = AnnotationMaxLatency + "-to"
+	// AnnotationMaxLatencyP99 bounds the link's tail latency instead of the
+	// average: it is checked against the max/mdev of the controller's
+	// latency statistics, so a link averaging 5ms but spiking to 80ms is
+	// rejected. Nodes without published statistics fall back to the plain
+	// measured latency.
+	AnnotationMaxLatencyP99 = AnnotationPrefix + "max-latency-p99"
+	// AnnotationMaxCost is a link cost ceiling in (0,1]: nodes whose
+	// cheapest published link costs more are rejected. Costs are relative to
+	// the controller's cost model, recorded in Status.CostModel; a ceiling
+	// tuned for one model does not transfer to another.
+	AnnotationMaxCost = AnnotationPrefix + "max-cost"
+	// AnnotationMaxCostTo names the reference point max-cost is measured
+	// to, with the same semantics as max-latency-to: the ceiling applies to
+	// the node's link toward that one topology entry. It modifies max-cost
+	// and is an error on its own.
+	AnnotationMaxCostTo = AnnotationMaxCost + "-to"
+	// AnnotationDataLocality set to "high" restricts the pod to the local zone.
+	AnnotationDataLocality = AnnotationPrefix + "data-locality"
+	// AnnotationRequiredCapabilities is a comma-separated capability list.
+	AnnotationRequiredCapabilities = AnnotationPrefix + "required-capabilities"
+	// AnnotationInternetRequired set to "true" requires the internet capability.
+	AnnotationInternetRequired = AnnotationPrefix + "internet-required"
+	// AnnotationMinNodeHealth is a per-pod node health floor in [0,1].
+	AnnotationMinNodeHealth = AnnotationPrefix + "min-node-health"
+	// AnnotationMaxUtilization rejects nodes whose links are more utilized
+	// than this fraction in (0,1], for pods that refuse saturated links.
+	AnnotationMaxUtilization = AnnotationPrefix + "max-utilization"
+	// AnnotationAvoidMetered set to "true" rejects nodes whose
+	// NodeNetworkProfile declares a metered uplink.
+	AnnotationAvoidMetered = AnnotationPrefix + "avoid-metered"
+	// AnnotationRequirementMode selects how violations are handled:
+	// "required" (default) rejects the node, "preferred" turns the
+	// violation into a score penalty. Individual requirements can override
+	// the pod-wide mode with a ".mode" suffix on their own key, e.g.
+	// "network.komarov.dev/max-latency.mode: preferred". The per-annotation
+	// suffix always wins over the pod-wide value.
+	AnnotationRequirementMode = AnnotationPrefix + "requirement-mode"
+)
+
+// ResourceBandwidth is the extended resource equivalent of the min-bandwidth
+// annotation: a pod may request network.komarov.dev/bandwidth in its
+// resources, quantified in megabits per second, so quota and kubectl describe
+// see the claim. The controller advertises node capacity for it when
+// --advertise-bandwidth-resource is set. Annotation and resource request may
+// coexist only when they agree.
+const ResourceBandwidth = v1.ResourceName(AnnotationPrefix + "bandwidth")
+
+// Mode is how a single requirement's violation is treated.
+type Mode string
+
+const (
+	// ModeRequired rejects violating nodes.
+	ModeRequired Mode = "required"
+	// ModePreferred penalizes violating nodes in scoring instead.
+	ModePreferred Mode = "preferred"
+)
+
+// Violation kinds, used as metric labels and log fields.
+const (
+	KindBandwidth   = "bandwidth"
+	KindLatency     = "latency"
+	KindLocality    = "locality"
+	KindInternet    = "internet"
+	KindCapability  = "capability"
+	KindHealth      = "health"
+	KindUtilization = "utilization"
+	KindMetered     = "metered"
+	KindCost        = "cost"
+)
+
+// CapabilityMetered is the topology capability the controller sets on nodes
+// whose NodeNetworkProfile declares Metered.
+const CapabilityMetered = "metered"
+
+// Requirements is the parsed form of a pod's network annotations.
+type Requirements struct {
+	MinBandwidthMbps int64
+	// InitBandwidthMbps is needed only while init containers run; see
+	// PeakBandwidthMbps for how it combines with the runtime requirement.
+	InitBandwidthMbps int64
+	MaxLatencyMs      int64
+	// LatencyTarget, when set, makes MaxLatencyMs target-relative: the
+	// bound applies to the node's measured latency toward this topology
+	// entry rather than its best link. Never set without MaxLatencyMs.
+	LatencyTarget    string
+	MaxLatencyP99Ms  int64
+	DataLocality     string
+	Capabilities     []string
+	InternetRequired bool
+	AvoidMetered     bool
+	MinNodeHealth    float64
+	MaxUtilization   float64
+	// MaxCost is a link cost ceiling in (0,1]; 0 means unset. CostTarget,
+	// when set, applies the ceiling to the node's link toward that one
+	// topology entry instead of its cheapest link. Never set without
+	// MaxCost.
+	MaxCost    float64
+	CostTarget string
+
+	// HostNetwork records whether the pod bypasses the CNI, captured at
+	// parse time so latency evaluation can correct for measurements taken
+	// from the other side of the overlay. It is context, not a
+	// requirement: Empty() ignores it.
+	HostNetwork bool
+
+	// Modes holds the per-requirement violation handling, keyed by the
+	// requirement's annotation key. Absent keys default to ModeRequired.
+	Modes map[string]Mode
+}
+
+// Mode returns the violation handling for the given requirement annotation.
+func (r *Requirements) Mode(key string) Mode {
+	if m, ok := r.Modes[key]; ok {
+		return m
+	}
+	return ModeRequired
+}
+
+// PeakBandwidthMbps returns the largest bandwidth the pod needs in any
+// phase: the runtime requirement or the init-phase requirement, whichever is
+// higher. initWeight in (0,1) discounts the init figure before comparing —
+// init transfers are transient, so an operator may choose not to hold full
+// capacity for them; any other value counts init at full weight.
+func (r *Requirements) PeakBandwidthMbps(initWeight float64) int64 {
+	init := r.InitBandwidthMbps
+	if initWeight > 0 && initWeight < 1 {
+		init = int64(float64(init) * initWeight)
+	}
+	if init > r.MinBandwidthMbps {
+		return init
+	}
+	return r.MinBandwidthMbps
+}
+
+// Empty reports whether the pod expressed no network requirements at all.
+func (r *Requirements) Empty() bool {
+	return r.MinBandwidthMbps == 0 && r.InitBandwidthMbps == 0 &&
+		r.MaxLatencyMs == 0 && r.MaxLatencyP99Ms == 0 && r.DataLocality == "" &&
+		len(r.Capabilities) == 0 && !r.InternetRequired && !r.AvoidMetered &&
+		r.MinNodeHealth == 0 && r.MaxUtilization == 0 && r.MaxCost == 0
+}
+
+// HasAnnotations reports whether the pod carries any network.komarov.dev
+// annotation, requirement or otherwise. Consumers that must never touch
+// unannotated pods check this before parsing.
+func HasAnnotations(pod *v1.Pod) bool {
+	for key := range pod.Annotations {
+		if strings.HasPrefix(key, AnnotationPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse extracts the pod's requirements. Malformed values are errors rather
+// than silently ignored: a typo in min-bandwidth should surface, not schedule
+// anywhere.
+func Parse(pod *v1.Pod) (*Requirements, error) {
+	reqs := &Requirements{HostNetwork: pod.Spec.HostNetwork}
+	ann := pod.Annotations
+	if ann == nil {
+		reqs.MinBandwidthMbps = bandwidthResourceMbps(pod)
+		return reqs, nil
+	}
+	if v, ok := ann[AnnotationMinBandwidth]; ok {
+		bps, err := units.ParseBandwidth(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", AnnotationMinBandwidth, err)
+		}
+		reqs.MinBandwidthMbps = units.ToMbps(bps)
+	}
+	if mbps := bandwidthResourceMbps(pod); mbps > 0 {
+		if reqs.MinBandwidthMbps > 0 && reqs.MinBandwidthMbps != mbps {
+			return nil, fmt.Errorf("%s annotation (%dmbps) conflicts with %s resource request (%dmbps)",
+				AnnotationMinBandwidth, reqs.MinBandwidthMbps, ResourceBandwidth, mbps)
+		}
+		reqs.MinBandwidthMbps = mbps
+	}
+	if v, ok := ann[AnnotationMinBandwidthInit]; ok {
+		bps, err := units.ParseBandwidth(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", AnnotationMinBandwidthInit, err)
+		}
+		reqs.InitBandwidthMbps = units.ToMbps(bps)
+	}
+	if v, ok := ann[AnnotationMaxLatency]; ok {
+		d, err := units.ParseLatency(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", AnnotationMaxLatency, err)
+		}
+		reqs.MaxLatencyMs = d.Milliseconds()
+	}
+	if v, ok := ann[AnnotationMaxLatencyTo]; ok {
+		if reqs.MaxLatencyMs == 0 {
+			return nil, fmt.Errorf("%s is set but %s is not; the target names what the bound is measured to",
+				AnnotationMaxLatencyTo, AnnotationMaxLatency)
+		}
+		reqs.LatencyTarget = strings.TrimSpace(v)
+	}
+	if v, ok := ann[AnnotationMaxLatencyP99]; ok {
+		d, err := units.ParseLatency(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", AnnotationMaxLatencyP99, err)
+		}
+		reqs.MaxLatencyP99Ms = d.Milliseconds()
+	}
+	reqs.DataLocality = ann[AnnotationDataLocality]
+	if v, ok := ann[AnnotationRequiredCapabilities]; ok {
+		for _, c := range strings.Split(v, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				reqs.Capabilities = append(reqs.Capabilities, c)
+			}
+		}
+	}
+	reqs.InternetRequired = ann[AnnotationInternetRequired] == "true"
+	reqs.AvoidMetered = ann[AnnotationAvoidMetered] == "true"
+	if v, ok := ann[AnnotationMinNodeHealth]; ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			return nil, fmt.Errorf("invalid %s: %q is not a value in [0,1]", AnnotationMinNodeHealth, v)
+		}
+		reqs.MinNodeHealth = f
+	}
+	if v, ok := ann[AnnotationMaxUtilization]; ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 1 {
+			return nil, fmt.Errorf("invalid %s: %q is not a value in (0,1]", AnnotationMaxUtilization, v)
+		}
+		reqs.MaxUtilization = f
+	}
+	if v, ok := ann[AnnotationMaxCost]; ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 1 {
+			return nil, fmt.Errorf("invalid %s: %q is not a value in (0,1]", AnnotationMaxCost, v)
+		}
+		reqs.MaxCost = f
+	}
+	if v, ok := ann[AnnotationMaxCostTo]; ok {
+		if reqs.MaxCost == 0 {
+			return nil, fmt.Errorf("%s is set but %s is not; the target names what the ceiling is measured to",
+				AnnotationMaxCostTo, AnnotationMaxCost)
+		}
+		reqs.CostTarget = strings.TrimSpace(v)
+	}
+
+	// Pod-wide mode first, then per-annotation ".mode" overrides.
+	podMode := Mode(ann[AnnotationRequirementMode])
+	reqs.Modes = map[string]Mode{}
+	for _, key := range []string{
+		AnnotationMinBandwidth, AnnotationMaxLatency, AnnotationMaxLatencyP99, AnnotationMaxCost,
+		AnnotationDataLocality, AnnotationRequiredCapabilities, AnnotationInternetRequired, AnnotationAvoidMetered,
+	} {
+		mode := podMode
+		explicit := false
+		if v, ok := ann[key+".mode"]; ok {
+			mode = Mode(v)
+			explicit = true
+		}
+		// An explicit required is recorded too, so later merges (class
+		// expansion) can tell it apart from the absent-key default.
+		if mode == ModePreferred || (explicit && mode == ModeRequired) {
+			reqs.Modes[key] = mode
+		}
+	}
+	return reqs, nil
+}
+
+// bandwidthResourceMbps sums the pod's network.komarov.dev/bandwidth resource
+// requests the way the scheduler accounts extended resources: regular
+// containers add up, and the largest init container raises the floor.
+func bandwidthResourceMbps(pod *v1.Pod) int64 {
+	var total int64
+	for _, container := range pod.Spec.Containers {
+		if q, ok := container.Resources.Requests[ResourceBandwidth]; ok {
+			total += q.Value()
+		}
+	}
+	for _, container := range pod.Spec.InitContainers {
+		if q, ok := container.Resources.Requests[ResourceBandwidth]; ok && q.Value() > total {
+			total = q.Value()
+		}
+	}
+	return total
+}
+
+// UnknownPolicy decides how nodes without any bandwidth/latency information
+// are treated, and how old optional measurements may be before they are
+// ignored.
+type UnknownPolicy struct {
+	// BandwidthMbps and LatencyMs substitute for missing measurements.
+	BandwidthMbps int64
+	LatencyMs     int64
+	// AssumeBest lets unknown nodes pass evaluation regardless of the
+	// substitutes, leaving consumers to score them neutrally-to-low.
+	AssumeBest bool
+	// UtilizationMaxAge is how old a node's utilization measurement may be
+	// before it is treated as absent. Zero means DefaultUtilizationMaxAge.
+	UtilizationMaxAge time.Duration
+}
+
+// Fallback values used when a node carries no measurement and no label.
+const (
+	DefaultBandwidthMbps = 10
+	DefaultLatencyMs     = 1000
+)
+
+// DefaultUtilizationMaxAge matches the scheduler's default topology
+// staleness limit.
+const DefaultUtilizationMaxAge = 10 * time.Minute
+
+// DefaultUnknownPolicy returns the historical conservative defaults.
+func DefaultUnknownPolicy() UnknownPolicy {
+	return UnknownPolicy{BandwidthMbps: DefaultBandwidthMbps, LatencyMs: DefaultLatencyMs}
+}
+
+func (p UnknownPolicy) utilizationMaxAge() time.Duration {
+	if p.UtilizationMaxAge > 0 {
+		return p.UtilizationMaxAge
+	}
+	return DefaultUtilizationMaxAge
+}
+
+// Violation is one unsatisfied network requirement on a particular node.
+type Violation struct {
+	Reason string
+	Kind   string
+	Mode   Mode
+	// Severity scales preferred-mode penalties; 0 means full weight.
+	Severity float64
+}
+
+// Evaluate returns every requirement the node fails to satisfy, each carrying
+// the mode deciding whether the consumer rejects or penalizes. Nodes with
+// unknown bandwidth/latency are checked against the policy's substitutes, or
+// pass outright when the policy assumes the best. Label fallbacks use the
+// default label keys.
+func Evaluate(reqs *Requirements, node *v1.Node, topo *v1alpha1.NetworkTopology, pol UnknownPolicy) []Violation {
+	return EvaluateWithKeys(reqs, node, topo, pol, LabelKeys{})
+}
+
+// EvaluateWithKeys is Evaluate with the zone and capability label fallbacks
+// read through remapped label keys, for clusters labeled with e.g.
+// topology.kubernetes.io/zone.
+func EvaluateWithKeys(reqs *Requirements, node *v1.Node, topo *v1alpha1.NetworkTopology, pol UnknownPolicy, keys LabelKeys) []Violation {
+	var violations []Violation
+	fail := func(key, kind, reason string) {
+		violations = append(violations, Violation{Reason: reason, Kind: kind, Mode: reqs.Mode(key)})
+	}
+
+	if reqs.MinBandwidthMbps > 0 {
+		bw, known := MeasuredBandwidthMbps(node, topo)
+		if !known {
+			bw = pol.BandwidthMbps
+		}
+		if (known || !pol.AssumeBest) && bw < reqs.MinBandwidthMbps {
+			fail(AnnotationMinBandwidth, KindBandwidth,
+				fmt.Sprintf("node bandwidth %dmbps below required %dmbps", bw, reqs.MinBandwidthMbps))
+		}
+	}
+	if reqs.MaxLatencyMs > 0 {
+		lat, known := MeasuredLatencyMs(node, topo)
+		if reqs.LatencyTarget != "" {
+			// Target-relative: the bound applies to the one link toward the
+			// named entry, not the node's best link. No label fallback — a
+			// label describes the node, not a specific pair.
+			lat, known = TargetLatencyMs(node, topo, reqs.LatencyTarget)
+		}
+		if !known {
+			lat = pol.LatencyMs
+		}
+		if known {
+			if lat += VantageAdjustmentMs(reqs.HostNetwork, node, topo); lat < 0 {
+				lat = 0
+			}
+		}
+		if (known || !pol.AssumeBest) && lat > reqs.MaxLatencyMs {
+			reason := fmt.Sprintf("node latency %dms above allowed %dms", lat, reqs.MaxLatencyMs)
+			if reqs.LatencyTarget != "" {
+				reason = fmt.Sprintf("node latency %dms to %s above allowed %dms", lat, reqs.LatencyTarget, reqs.MaxLatencyMs)
+			}
+			fail(AnnotationMaxLatency, KindLatency, reason)
+		}
+	}
+	if reqs.MaxLatencyP99Ms > 0 {
+		lat, known := MeasuredTailLatencyMs(node, topo)
+		if !known {
+			// Without published statistics the plain measurement is the
+			// best available bound.
+			lat, known = MeasuredLatencyMs(node, topo)
+		}
+		if !known {
+			lat = pol.LatencyMs
+		}
+		if known {
+			if lat += VantageAdjustmentMs(reqs.HostNetwork, node, topo); lat < 0 {
+				lat = 0
+			}
+		}
+		if (known || !pol.AssumeBest) && lat > reqs.MaxLatencyP99Ms {
+			fail(AnnotationMaxLatencyP99, KindLatency,
+				fmt.Sprintf("node tail latency %dms above allowed %dms", lat, reqs.MaxLatencyP99Ms))
+		}
+	}
+	if reqs.DataLocality == "high" && keys.NodeZone(node, topo) != "local" {
+		fail(AnnotationDataLocality, KindLocality, "data-locality=high requires a local-zone node")
+	}
+	if reqs.InternetRequired && !keys.NodeHasCapability(node, topo, "internet") {
+		fail(AnnotationInternetRequired, KindInternet, "node lacks internet capability")
+	}
+	for _, c := range reqs.Capabilities {
+		if !keys.NodeHasCapability(node, topo, c) {
+			fail(AnnotationRequiredCapabilities, KindCapability, fmt.Sprintf("node lacks required capability %q", c))
+		}
+	}
+	if reqs.AvoidMetered && keys.NodeHasCapability(node, topo, CapabilityMetered) {
+		fail(AnnotationAvoidMetered, KindMetered, "node uplink is declared metered")
+	}
+	if reqs.MaxUtilization > 0 {
+		// Like health, unknown utilization passes; known saturation fails.
+		if util, ok := NodeUtilization(node, topo, pol.utilizationMaxAge()); ok && util > reqs.MaxUtilization {
+			fail(AnnotationMaxUtilization, KindUtilization,
+				fmt.Sprintf("node utilization %.2f above allowed %.2f", util, reqs.MaxUtilization))
+		}
+	}
+	if reqs.MaxCost > 0 {
+		// Like health, unknown cost passes: a ceiling should act on what the
+		// controller measured, not on its absence.
+		if cost, ok := NodeCost(node, topo, reqs.CostTarget); ok && cost > reqs.MaxCost {
+			reason := fmt.Sprintf("node link cost %.2f above allowed %.2f", cost, reqs.MaxCost)
+			if reqs.CostTarget != "" {
+				reason = fmt.Sprintf("node link cost %.2f to %s above allowed %.2f", cost, reqs.CostTarget, reqs.MaxCost)
+			}
+			// Costs only mean something relative to the model that produced
+			// them, so name it in the rejection.
+			if topo != nil && topo.Status.CostModel != "" {
+				reason += fmt.Sprintf(" (cost model: %s)", topo.Status.CostModel)
+			}
+			fail(AnnotationMaxCost, KindCost, reason)
+		}
+	}
+	if reqs.MinNodeHealth > 0 {
+		// Nodes without health data pass: clusters running an older
+		// controller should not have every node filtered.
+		if health, ok := NodeHealthScore(node, topo); ok && health < reqs.MinNodeHealth {
+			violations = append(violations, Violation{
+				Reason: fmt.Sprintf("node health %.2f below floor %.2f", health, reqs.MinNodeHealth),
+				Kind:   KindHealth,
+				Mode:   reqs.Mode(AnnotationMinNodeHealth),
+				// Penalty proportional to how far below the floor.
+				Severity: (reqs.MinNodeHealth - health) / reqs.MinNodeHealth,
+			})
+		}
+	}
+	return violations
+}
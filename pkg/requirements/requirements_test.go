@@ -0,0 +1,538 @@
+package requirements
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+func podWithAnnotations(ann map[string]string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p", Annotations: ann}}
+}
+
+func mustParse(t *testing.T, pod *v1.Pod) *Requirements {
+	t.Helper()
+	reqs, err := Parse(pod)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return reqs
+}
+
+func nodeWithLabels(name string, labels map[string]string) *v1.Node {
+	return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestParseModes(t *testing.T) {
+	tests := []struct {
+		name string
+		ann  map[string]string
+		key  string
+		want Mode
+	}{
+		{
+			name: "default is required",
+			ann:  map[string]string{AnnotationMaxLatency: "10ms"},
+			key:  AnnotationMaxLatency,
+			want: ModeRequired,
+		},
+		{
+			name: "pod-wide preferred",
+			ann: map[string]string{
+				AnnotationMaxLatency:             "10ms",
+				AnnotationRequirementMode:        "preferred",
+				AnnotationMinBandwidth:           "100mbps",
+				AnnotationMinBandwidth + ".mode": "required",
+			},
+			key:  AnnotationMaxLatency,
+			want: ModePreferred,
+		},
+		{
+			name: "per-annotation suffix wins over pod-wide",
+			ann: map[string]string{
+				AnnotationRequirementMode:        "preferred",
+				AnnotationMinBandwidth:           "100mbps",
+				AnnotationMinBandwidth + ".mode": "required",
+			},
+			key:  AnnotationMinBandwidth,
+			want: ModeRequired,
+		},
+		{
+			name: "per-annotation preferred without pod-wide mode",
+			ann: map[string]string{
+				AnnotationMaxLatency:           "10ms",
+				AnnotationMaxLatency + ".mode": "preferred",
+			},
+			key:  AnnotationMaxLatency,
+			want: ModePreferred,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reqs := mustParse(t, podWithAnnotations(tc.ann))
+			if got := reqs.Mode(tc.key); got != tc.want {
+				t.Fatalf("Mode(%s) = %q, want %q", tc.key, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRequiredModeStillRejects(t *testing.T) {
+	reqs := mustParse(t, podWithAnnotations(map[string]string{
+		AnnotationMinBandwidth: "500mbps",
+	}))
+	node := nodeWithLabels("slow", map[string]string{LabelNodeBandwidth: "50mbps"})
+
+	violations := Evaluate(reqs, node, nil, DefaultUnknownPolicy())
+	if len(violations) != 1 || violations[0].Mode != ModeRequired {
+		t.Fatalf("expected a single required-mode violation, got %+v", violations)
+	}
+}
+
+func TestAvoidMetered(t *testing.T) {
+	reqs := mustParse(t, podWithAnnotations(map[string]string{
+		AnnotationAvoidMetered: "true",
+	}))
+	if reqs.Empty() {
+		t.Fatal("avoid-metered is a requirement")
+	}
+
+	topo := &v1alpha1.NetworkTopology{Spec: v1alpha1.NetworkTopologySpec{
+		Nodes: map[string]v1alpha1.NodeSpec{
+			"metered-node": {Capabilities: []string{CapabilityMetered}},
+			"wired-node":   {},
+		},
+	}}
+	violations := Evaluate(reqs, nodeWithLabels("metered-node", nil), topo, DefaultUnknownPolicy())
+	if len(violations) != 1 || violations[0].Kind != KindMetered {
+		t.Fatalf("expected a metered violation, got %+v", violations)
+	}
+	if v := Evaluate(reqs, nodeWithLabels("wired-node", nil), topo, DefaultUnknownPolicy()); len(v) != 0 {
+		t.Fatalf("unmetered node should pass, got %+v", v)
+	}
+	// Nodes without a profile have no metered capability and must pass.
+	if v := Evaluate(reqs, nodeWithLabels("unknown", nil), topo, DefaultUnknownPolicy()); len(v) != 0 {
+		t.Fatalf("unknown node should pass, got %+v", v)
+	}
+}
+
+func TestParseRejectsMalformedValues(t *testing.T) {
+	for _, ann := range []map[string]string{
+		{AnnotationMinBandwidth: "fast"},
+		{AnnotationMinBandwidth: "100"},
+		{AnnotationMaxLatency: "soon"},
+		{AnnotationMaxLatency: "-5ms"},
+	} {
+		if _, err := Parse(podWithAnnotations(ann)); err == nil {
+			t.Errorf("Parse(%v) should fail", ann)
+		}
+	}
+}
+
+func TestUnknownNodePolicy(t *testing.T) {
+	reqs := mustParse(t, podWithAnnotations(map[string]string{
+		AnnotationMinBandwidth: "100mbps",
+	}))
+	unknown := nodeWithLabels("unlabeled", nil)
+
+	// Default policy: the conservative substitute fails the check.
+	if v := Evaluate(reqs, unknown, nil, DefaultUnknownPolicy()); len(v) != 1 {
+		t.Fatalf("expected the default policy to reject the unknown node, got %+v", v)
+	}
+
+	// A raised default lets the node pass without labelling it.
+	generous := UnknownPolicy{BandwidthMbps: 1000, LatencyMs: 1}
+	if v := Evaluate(reqs, unknown, nil, generous); len(v) != 0 {
+		t.Fatalf("expected the raised default to pass the unknown node, got %+v", v)
+	}
+
+	// AssumeBest passes unknown nodes regardless of the substitutes.
+	optimistic := DefaultUnknownPolicy()
+	optimistic.AssumeBest = true
+	if v := Evaluate(reqs, unknown, nil, optimistic); len(v) != 0 {
+		t.Fatalf("expected AssumeBest to pass the unknown node, got %+v", v)
+	}
+
+	// A node with a known-bad measurement still fails under AssumeBest.
+	slow := nodeWithLabels("slow", map[string]string{LabelNodeBandwidth: "10mbps"})
+	if v := Evaluate(reqs, slow, nil, optimistic); len(v) != 1 {
+		t.Fatalf("expected the known-slow node to fail, got %+v", v)
+	}
+}
+
+func TestHealthFloor(t *testing.T) {
+	health := func(v float64) *float64 { return &v }
+	topo := &v1alpha1.NetworkTopology{
+		Spec: v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{
+			"sick":    {HealthScore: health(0.2)},
+			"healthy": {HealthScore: health(0.9)},
+			"unknown": {},
+		}},
+	}
+	reqs := mustParse(t, podWithAnnotations(map[string]string{
+		AnnotationMinNodeHealth: "0.5",
+	}))
+
+	if v := Evaluate(reqs, nodeWithLabels("sick", nil), topo, DefaultUnknownPolicy()); len(v) != 1 {
+		t.Fatalf("node below the floor should violate, got %+v", v)
+	} else if v[0].Severity <= 0 || v[0].Severity > 1 {
+		t.Fatalf("expected proportional severity in (0,1], got %v", v[0].Severity)
+	}
+	if v := Evaluate(reqs, nodeWithLabels("healthy", nil), topo, DefaultUnknownPolicy()); len(v) != 0 {
+		t.Fatalf("node above the floor should pass, got %+v", v)
+	}
+	// Nodes without health data pass rather than punishing clusters
+	// running an older controller.
+	if v := Evaluate(reqs, nodeWithLabels("unknown", nil), topo, DefaultUnknownPolicy()); len(v) != 0 {
+		t.Fatalf("node without health data should pass, got %+v", v)
+	}
+}
+
+func TestMaxLatencyP99(t *testing.T) {
+	topo := &v1alpha1.NetworkTopology{
+		Spec: v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{
+			"spiky": {
+				Latency: map[string]string{"core": "5ms"},
+				LatencyStats: map[string]v1alpha1.LatencyStats{
+					"core": {Min: "2ms", Avg: "5ms", Max: "80ms", Mdev: "12ms"},
+				},
+			},
+			"steady": {
+				Latency: map[string]string{"core": "5ms"},
+				LatencyStats: map[string]v1alpha1.LatencyStats{
+					"core": {Min: "4ms", Avg: "5ms", Max: "7ms", Mdev: "1ms"},
+				},
+			},
+			"no-stats": {Latency: map[string]string{"core": "5ms"}},
+		}},
+	}
+	reqs := mustParse(t, podWithAnnotations(map[string]string{
+		AnnotationMaxLatencyP99: "20ms",
+	}))
+	if reqs.Empty() {
+		t.Fatal("max-latency-p99 is a requirement")
+	}
+
+	// A 5ms average hides the 80ms spikes; the tail check does not.
+	if v := Evaluate(reqs, nodeWithLabels("spiky", nil), topo, DefaultUnknownPolicy()); len(v) != 1 {
+		t.Fatalf("spiky node should violate the tail bound, got %+v", v)
+	} else if v[0].Kind != KindLatency {
+		t.Errorf("violation kind = %q, want %q", v[0].Kind, KindLatency)
+	}
+	if v := Evaluate(reqs, nodeWithLabels("steady", nil), topo, DefaultUnknownPolicy()); len(v) != 0 {
+		t.Fatalf("steady node should pass, got %+v", v)
+	}
+	// Without statistics the plain measurement is the best available bound.
+	if v := Evaluate(reqs, nodeWithLabels("no-stats", nil), topo, DefaultUnknownPolicy()); len(v) != 0 {
+		t.Fatalf("node without statistics should pass on its 5ms average, got %+v", v)
+	}
+}
+
+func TestTailLatencyMs(t *testing.T) {
+	// avg + 2·mdev exceeds the observed max when the spread is wide.
+	if got, ok := tailLatencyMs(v1alpha1.LatencyStats{Avg: "10ms", Max: "20ms", Mdev: "8ms"}); !ok || got != 26 {
+		t.Errorf("tailLatencyMs = %d,%v, want 26,true", got, ok)
+	}
+	// Otherwise the max bounds the tail.
+	if got, ok := tailLatencyMs(v1alpha1.LatencyStats{Avg: "10ms", Max: "40ms", Mdev: "2ms"}); !ok || got != 40 {
+		t.Errorf("tailLatencyMs = %d,%v, want 40,true", got, ok)
+	}
+	if _, ok := tailLatencyMs(v1alpha1.LatencyStats{Avg: "10ms"}); ok {
+		t.Error("statistics without a max must be unusable")
+	}
+}
+
+func podWithBandwidthRequest(ann map[string]string, request string) *v1.Pod {
+	pod := podWithAnnotations(ann)
+	pod.Spec.Containers = []v1.Container{{
+		Name: "main",
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{ResourceBandwidth: resource.MustParse(request)},
+		},
+	}}
+	return pod
+}
+
+func TestParseBandwidthResource(t *testing.T) {
+	// The resource request alone is equivalent to the annotation.
+	reqs := mustParse(t, podWithBandwidthRequest(nil, "200"))
+	if reqs.MinBandwidthMbps != 200 {
+		t.Errorf("MinBandwidthMbps = %d, want 200", reqs.MinBandwidthMbps)
+	}
+
+	// Annotation and resource may coexist when they agree.
+	reqs = mustParse(t, podWithBandwidthRequest(map[string]string{AnnotationMinBandwidth: "200mbps"}, "200"))
+	if reqs.MinBandwidthMbps != 200 {
+		t.Errorf("MinBandwidthMbps = %d, want 200", reqs.MinBandwidthMbps)
+	}
+
+	// Disagreement is an error, not a silent pick.
+	pod := podWithBandwidthRequest(map[string]string{AnnotationMinBandwidth: "500mbps"}, "200")
+	if _, err := Parse(pod); err == nil {
+		t.Error("expected an error for conflicting annotation and resource request")
+	}
+
+	// Requests of several containers add up.
+	pod = podWithBandwidthRequest(nil, "200")
+	pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{
+		Name: "sidecar",
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{ResourceBandwidth: resource.MustParse("100")},
+		},
+	})
+	if reqs = mustParse(t, pod); reqs.MinBandwidthMbps != 300 {
+		t.Errorf("summed MinBandwidthMbps = %d, want 300", reqs.MinBandwidthMbps)
+	}
+}
+
+func TestBandwidthResourceRequestExceedingCapacity(t *testing.T) {
+	topo := &v1alpha1.NetworkTopology{
+		Spec: v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{
+			"edge": {Bandwidth: map[string]string{"core": "100mbps"}},
+		}},
+	}
+	reqs := mustParse(t, podWithBandwidthRequest(nil, "500"))
+	if v := Evaluate(reqs, nodeWithLabels("edge", nil), topo, DefaultUnknownPolicy()); len(v) != 1 {
+		t.Fatalf("request above node capacity should violate, got %+v", v)
+	} else if v[0].Kind != KindBandwidth {
+		t.Errorf("violation kind = %q, want %q", v[0].Kind, KindBandwidth)
+	}
+}
+
+func TestHasAnnotations(t *testing.T) {
+	if HasAnnotations(podWithAnnotations(map[string]string{"app": "db"})) {
+		t.Errorf("pod without network annotations reported as annotated")
+	}
+	if !HasAnnotations(podWithAnnotations(map[string]string{AnnotationMaxLatency: "10ms"})) {
+		t.Errorf("pod with a network annotation reported as unannotated")
+	}
+}
+
+func TestNodeSchedulable(t *testing.T) {
+	if !NodeSchedulable(nodeWithLabels("plain", nil)) {
+		t.Error("untainted node reported unschedulable")
+	}
+	cordoned := nodeWithLabels("cordoned", nil)
+	cordoned.Spec.Unschedulable = true
+	if NodeSchedulable(cordoned) {
+		t.Error("cordoned node reported schedulable")
+	}
+	tainted := nodeWithLabels("tainted", nil)
+	tainted.Spec.Taints = []v1.Taint{{Key: "maintenance", Effect: v1.TaintEffectNoSchedule}}
+	if NodeSchedulable(tainted) {
+		t.Error("NoSchedule-tainted node reported schedulable")
+	}
+	executed := nodeWithLabels("pressure", nil)
+	executed.Spec.Taints = []v1.Taint{{Key: "memory", Effect: v1.TaintEffectPreferNoSchedule}}
+	if !NodeSchedulable(executed) {
+		t.Error("PreferNoSchedule taint should not flip schedulability")
+	}
+}
+
+func TestVantageAdjustment(t *testing.T) {
+	topo := &v1alpha1.NetworkTopology{}
+	topo.Spec.ProbeVantage = v1alpha1.ProbeVantageHost
+	topo.Spec.Links = []v1alpha1.Link{
+		{Source: "pi-1", Target: "pi-2", Latency: "4ms", OverlayOverhead: "3ms"},
+	}
+	node := nodeWithLabels("pi-1", nil)
+
+	// An overlay pod crosses the CNI the host-vantage probe skipped: its
+	// effective latency is 4ms + 3ms, over the 5ms bound.
+	reqs := &Requirements{MaxLatencyMs: 5}
+	if v := Evaluate(reqs, node, topo, UnknownPolicy{}); len(v) == 0 {
+		t.Error("overlay pod passed although latency plus overlay overhead exceeds the bound")
+	}
+	reqs.HostNetwork = true
+	if v := Evaluate(reqs, node, topo, UnknownPolicy{}); len(v) != 0 {
+		t.Errorf("hostNetwork pod failed against matching-vantage measurements: %v", v)
+	}
+
+	// Overlay-vantage measurements over-state what a hostNetwork pod
+	// sees; the overhead is subtracted.
+	overlayTopo := topo.DeepCopy()
+	overlayTopo.Spec.ProbeVantage = v1alpha1.ProbeVantageOverlay
+	reqs = &Requirements{MaxLatencyMs: 2, HostNetwork: true}
+	if v := Evaluate(reqs, node, overlayTopo, UnknownPolicy{}); len(v) != 0 {
+		t.Errorf("hostNetwork pod failed although 4ms - 3ms overhead is within 2ms: %v", v)
+	}
+
+	// Topologies predating vantage recording apply no correction.
+	legacy := topo.DeepCopy()
+	legacy.Spec.ProbeVantage = ""
+	reqs = &Requirements{MaxLatencyMs: 5}
+	if v := Evaluate(reqs, node, legacy, UnknownPolicy{}); len(v) != 0 {
+		t.Errorf("legacy topology without a vantage was corrected: %v", v)
+	}
+}
+
+func TestParseCapturesHostNetwork(t *testing.T) {
+	pod := podWithAnnotations(nil)
+	pod.Spec.HostNetwork = true
+	if reqs := mustParse(t, pod); !reqs.HostNetwork {
+		t.Error("Parse dropped the pod's hostNetwork setting")
+	}
+	if !mustParse(t, pod).Empty() {
+		t.Error("hostNetwork alone must not count as a requirement")
+	}
+}
+
+func TestInitBandwidth(t *testing.T) {
+	initOnly := mustParse(t, podWithAnnotations(map[string]string{
+		AnnotationMinBandwidthInit: "500mbps",
+	}))
+	if initOnly.Empty() {
+		t.Fatal("an init-phase requirement is still a requirement")
+	}
+	if got := initOnly.PeakBandwidthMbps(1); got != 500 {
+		t.Errorf("init-only peak = %dmbps, want 500", got)
+	}
+
+	both := mustParse(t, podWithAnnotations(map[string]string{
+		AnnotationMinBandwidth:     "100mbps",
+		AnnotationMinBandwidthInit: "500mbps",
+	}))
+	if got := both.PeakBandwidthMbps(1); got != 500 {
+		t.Errorf("peak = %dmbps, want the init figure 500", got)
+	}
+	// A 0.5 weight halves the init figure before comparing; the runtime
+	// requirement is never discounted.
+	if got := both.PeakBandwidthMbps(0.5); got != 250 {
+		t.Errorf("weighted peak = %dmbps, want 250", got)
+	}
+	if got := both.PeakBandwidthMbps(0.1); got != 100 {
+		t.Errorf("peak discounted below runtime = %dmbps, want 100", got)
+	}
+
+	runtimeOnly := mustParse(t, podWithAnnotations(map[string]string{
+		AnnotationMinBandwidth: "100mbps",
+	}))
+	if got := runtimeOnly.PeakBandwidthMbps(0.5); got != 100 {
+		t.Errorf("runtime-only peak = %dmbps, want 100", got)
+	}
+
+	if _, err := Parse(podWithAnnotations(map[string]string{
+		AnnotationMinBandwidthInit: "lots",
+	})); err == nil {
+		t.Fatal("malformed min-bandwidth.init must be an error")
+	}
+}
+
+func TestMaxLatencyTo(t *testing.T) {
+	topo := &v1alpha1.NetworkTopology{
+		Spec: v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{
+			// near-nfs sits right next to the filer but far from its peers;
+			// far-nfs is the opposite.
+			"near-nfs": {Latency: map[string]string{"core": "50ms", "external/nfs-1": "2ms"}},
+			"far-nfs":  {Latency: map[string]string{"core": "1ms", "external/nfs-1": "30ms"}},
+		}},
+	}
+	reqs := mustParse(t, podWithAnnotations(map[string]string{
+		AnnotationMaxLatency:   "10ms",
+		AnnotationMaxLatencyTo: "external/nfs-1",
+	}))
+
+	// The bound applies to the one leg toward the endpoint, not the node's
+	// best link: near-nfs passes despite its 50ms peer link, far-nfs fails
+	// despite its 1ms one.
+	if v := Evaluate(reqs, nodeWithLabels("near-nfs", nil), topo, DefaultUnknownPolicy()); len(v) != 0 {
+		t.Fatalf("near-nfs should pass, got %+v", v)
+	}
+	v := Evaluate(reqs, nodeWithLabels("far-nfs", nil), topo, DefaultUnknownPolicy())
+	if len(v) != 1 || v[0].Kind != KindLatency {
+		t.Fatalf("far-nfs should violate the targeted bound, got %+v", v)
+	}
+	if !strings.Contains(v[0].Reason, "external/nfs-1") {
+		t.Errorf("violation %q does not name the target", v[0].Reason)
+	}
+
+	// Nodes without a measured leg fall back to the policy substitute; an
+	// assume-best policy lets them pass.
+	unknown := nodeWithLabels("unmeasured", nil)
+	if v := Evaluate(reqs, unknown, topo, DefaultUnknownPolicy()); len(v) != 1 {
+		t.Fatalf("unmeasured leg should fail under the conservative policy, got %+v", v)
+	}
+	if v := Evaluate(reqs, unknown, topo, UnknownPolicy{AssumeBest: true}); len(v) != 0 {
+		t.Fatalf("assume-best should pass the unmeasured leg, got %+v", v)
+	}
+
+	// The target names what the bound is measured to; alone it is a typo.
+	if _, err := Parse(podWithAnnotations(map[string]string{
+		AnnotationMaxLatencyTo: "external/nfs-1",
+	})); err == nil {
+		t.Error("expected an error for max-latency-to without max-latency")
+	}
+}
+
+func TestMaxCost(t *testing.T) {
+	topoFor := func(model string) *v1alpha1.NetworkTopology {
+		return &v1alpha1.NetworkTopology{
+			Spec: v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{
+				"cheap":  {Cost: map[string]float64{"core": 0.2}},
+				"pricey": {Cost: map[string]float64{"core": 0.7}},
+				"split":  {Cost: map[string]float64{"core": 0.1, "external/nfs-1": 0.6}},
+			}},
+			Status: v1alpha1.NetworkTopologyStatus{CostModel: model},
+		}
+	}
+	reqs := mustParse(t, podWithAnnotations(map[string]string{
+		AnnotationMaxCost: "0.4",
+	}))
+
+	topo := topoFor("0.50*latency(anchor=200ms) + 0.50*bandwidth(anchor=1gbps)")
+	if v := Evaluate(reqs, nodeWithLabels("cheap", nil), topo, DefaultUnknownPolicy()); len(v) != 0 {
+		t.Fatalf("cheap should pass, got %+v", v)
+	}
+	v := Evaluate(reqs, nodeWithLabels("pricey", nil), topo, DefaultUnknownPolicy())
+	if len(v) != 1 || v[0].Kind != KindCost {
+		t.Fatalf("pricey should violate the ceiling, got %+v", v)
+	}
+
+	// A cost is only interpretable against the model that produced it, so
+	// the violation names whichever model the topology was written under.
+	for _, model := range []string{
+		"0.50*latency(anchor=200ms) + 0.50*bandwidth(anchor=1gbps)",
+		"0.50*latency(anchor=1s) + 0.50*bandwidth(anchor=100mbps)",
+	} {
+		v := Evaluate(reqs, nodeWithLabels("pricey", nil), topoFor(model), DefaultUnknownPolicy())
+		if len(v) != 1 || !strings.Contains(v[0].Reason, model) {
+			t.Errorf("violation %+v does not name the cost model %q", v, model)
+		}
+	}
+
+	// Nodes without a published cost pass: the ceiling acts on measurements,
+	// not their absence.
+	if v := Evaluate(reqs, nodeWithLabels("unmeasured", nil), topo, DefaultUnknownPolicy()); len(v) != 0 {
+		t.Fatalf("unmeasured cost should pass, got %+v", v)
+	}
+
+	// The target variant judges the one leg toward the endpoint: a node
+	// under the ceiling on its cheapest link can still fail toward the
+	// pod's data source.
+	targeted := mustParse(t, podWithAnnotations(map[string]string{
+		AnnotationMaxCost:   "0.4",
+		AnnotationMaxCostTo: "external/nfs-1",
+	}))
+	v = Evaluate(targeted, nodeWithLabels("split", nil), topo, DefaultUnknownPolicy())
+	if len(v) != 1 || !strings.Contains(v[0].Reason, "external/nfs-1") {
+		t.Fatalf("targeted ceiling should fail naming the target, got %+v", v)
+	}
+	if v := Evaluate(reqs, nodeWithLabels("split", nil), topo, DefaultUnknownPolicy()); len(v) != 0 {
+		t.Fatalf("untargeted ceiling judges the cheapest link, got %+v", v)
+	}
+
+	for name, ann := range map[string]map[string]string{
+		"out of range":        {AnnotationMaxCost: "1.5"},
+		"not a number":        {AnnotationMaxCost: "cheap"},
+		"target without cost": {AnnotationMaxCostTo: "external/nfs-1"},
+	} {
+		if _, err := Parse(podWithAnnotations(ann)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,424 @@
+package networkaware
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// NetworkAwareArgs configures the NetworkAware plugin. All fields are
+// optional; the zero value reproduces the historical built-in behavior.
+type NetworkAwareArgs struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// BandwidthWeight scales the bandwidth component of the topology
+	// score. Defaults to 1.0. Must be non-negative.
+	BandwidthWeight *float64 `json:"bandwidthWeight,omitempty"`
+	// LatencyWeight scales the latency component of the topology score.
+	// Defaults to 1.0. Must be non-negative.
+	LatencyWeight *float64 `json:"latencyWeight,omitempty"`
+	// CostWeight scales the link-cost component of the topology score.
+	// Defaults to 1.0. Must be non-negative.
+	CostWeight *float64 `json:"costWeight,omitempty"`
+
+	// PreferenceRules replace the built-in workload-type preferences.
+	// When empty, DefaultPreferenceRules() is used.
+	PreferenceRules []PreferenceRule `json:"preferenceRules,omitempty"`
+
+	// PreferredViolationPenalty is the score penalty applied per violated
+	// requirement in "preferred" mode. Defaults to 200. Must be
+	// non-negative.
+	PreferredViolationPenalty *int64 `json:"preferredViolationPenalty,omitempty"`
+
+	// DefaultNodeBandwidth substitutes for nodes with no measurement and
+	// no network-bandwidth label, e.g. "1gbps". Defaults to "10mbps".
+	DefaultNodeBandwidth *string `json:"defaultNodeBandwidth,omitempty"`
+	// DefaultNodeLatency substitutes for nodes with no measurement and no
+	// network-latency label, e.g. "5ms". Defaults to "1000ms".
+	DefaultNodeLatency *string `json:"defaultNodeLatency,omitempty"`
+	// AssumeBestWhenUnknown lets nodes without any network data pass
+	// Filter regardless of the defaults; they receive a neutral-to-low
+	// score instead. Off by default.
+	AssumeBestWhenUnknown *bool `json:"assumeBestWhenUnknown,omitempty"`
+
+	// MinNodeHealth is a floor in [0,1] under which nodes with a
+	// topology health score are filtered out (or penalized for
+	// preferred-mode pods). 0 disables the floor. Pods may override it
+	// with the min-node-health annotation.
+	MinNodeHealth *float64 `json:"minNodeHealth,omitempty"`
+
+	// ShadowMode makes Filter always succeed while logging and counting
+	// the nodes it would have rejected, so the plugin's behavior can be
+	// observed before enforcement. Score runs normally so rankings stay
+	// visible. Flipping this back to false is the only change needed to
+	// enforce for real.
+	ShadowMode *bool `json:"shadowMode,omitempty"`
+
+	// RecordDecisions enables the PostBind step that annotates scheduled
+	// pods with the decision rationale. Off by default to avoid the extra
+	// pod writes.
+	RecordDecisions *bool `json:"recordDecisions,omitempty"`
+
+	// MaxTopologyAge is how old Status.LastUpdated may be before the
+	// plugin distrusts the topology and falls back to label-based
+	// scoring. Defaults to 10x the controller's measurement interval.
+	MaxTopologyAge *metav1.Duration `json:"maxTopologyAge,omitempty"`
+
+	// PodTimeBudget caps how much wall-clock time one pod's scheduling
+	// cycle may spend in this plugin, measured from PreFilter. Once spent,
+	// Score falls back to label-based scoring for the pod's remaining
+	// nodes so a pathological topology cannot stall the scheduler. Unset
+	// disables the budget.
+	PodTimeBudget *metav1.Duration `json:"podTimeBudget,omitempty"`
+
+	// InitBandwidthWeight discounts the init-phase min-bandwidth
+	// requirement (the min-bandwidth.init annotation) before it is compared
+	// with the runtime requirement: init transfers are transient, so an
+	// operator may accept briefly overcommitting a link rather than holding
+	// full capacity for them. Must be in (0,1]. Defaults to 1 (init counts
+	// at full weight).
+	InitBandwidthWeight *float64 `json:"initBandwidthWeight,omitempty"`
+
+	// BandwidthBlendHorizon enables age-weighted blending of measured and
+	// declared bandwidth: a link's measurement keeps full weight until it is
+	// this old, then decays linearly toward the imported declared figure
+	// (or toward unknown where nothing is declared), hitting zero at twice
+	// the horizon. Unset keeps measurements authoritative regardless of
+	// age. Must be non-negative.
+	BandwidthBlendHorizon *metav1.Duration `json:"bandwidthBlendHorizon,omitempty"`
+
+	// ExportFile, when set, appends one JSON-lines scheduling-decision row
+	// per bound pod to this file for offline analysis, rotating at 64MB
+	// with one predecessor kept. Rows are written asynchronously and never
+	// delay binding. Read the file back with "topologyctl export".
+	ExportFile *string `json:"exportFile,omitempty"`
+
+	// IslandLatencyThreshold groups nodes whose mutual latency is below
+	// this value (e.g. "30ms") into connectivity islands, and penalizes
+	// stacking replicas of one workload on a single island. Unset disables
+	// island spreading.
+	IslandLatencyThreshold *string `json:"islandLatencyThreshold,omitempty"`
+
+	// UtilizationThreshold is the link utilization fraction in (0,1]
+	// above which nodes are penalized in scoring, steering pods toward
+	// links with headroom. Defaults to 0.8. Utilization older than the
+	// topology staleness limit is ignored.
+	UtilizationThreshold *float64 `json:"utilizationThreshold,omitempty"`
+
+	// LabelKeys remaps the node label keys consulted for the zone, region,
+	// role and GPU fallbacks. Each field is a comma-separated precedence
+	// list; by default the short names win over the well-known
+	// topology.kubernetes.io labels. Values read through the mapping are
+	// trimmed and lowercased before comparison.
+	LabelKeys *requirements.LabelKeys `json:"labelKeys,omitempty"`
+
+	// MaintenancePolicy picks how nodes under network maintenance (the
+	// maintenance annotation or a frozen topology spec) are treated:
+	// "filter" (default) rejects them, "penalize" lets them pass Filter but
+	// subtracts a heavy score penalty so they only win when nothing else
+	// fits.
+	MaintenancePolicy *string `json:"maintenancePolicy,omitempty"`
+
+	// IngressControllerSelector is a label selector identifying the
+	// ingress-controller pods whose nodes count as the cluster's ingress
+	// points for proximity scoring of Ingress-exposed pods. Defaults to the
+	// labels used by the k3s-bundled Traefik and by ingress-nginx.
+	IngressControllerSelector *string `json:"ingressControllerSelector,omitempty"`
+
+	// BypassPriorityThreshold downgrades all hard network constraints to
+	// scoring penalties for pods whose priority is at or above this value,
+	// so cluster-critical pods always land somewhere — on the least-bad
+	// node. System-critical priority classes bypass regardless. Unset
+	// disables priority bypass.
+	BypassPriorityThreshold *int32 `json:"bypassPriorityThreshold,omitempty"`
+}
+
+// PreferenceRule awards Score extra points to nodes matching NodeSelector for
+// pods matching PodSelector. Selectors match labels; a nil selector matches
+// everything.
+type PreferenceRule struct {
+	// Name identifies the rule in logs and status messages.
+	Name string `json:"name,omitempty"`
+	// PodSelector selects pods the rule applies to.
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+	// NodeSelector selects nodes that receive the score delta.
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+	// Score is the delta applied to matching nodes. May be negative.
+	Score int64 `json:"score"`
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *NetworkAwareArgs) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkAwareArgs)
+	*out = *in
+	if in.BandwidthWeight != nil {
+		out.BandwidthWeight = new(float64)
+		*out.BandwidthWeight = *in.BandwidthWeight
+	}
+	if in.LatencyWeight != nil {
+		out.LatencyWeight = new(float64)
+		*out.LatencyWeight = *in.LatencyWeight
+	}
+	if in.CostWeight != nil {
+		out.CostWeight = new(float64)
+		*out.CostWeight = *in.CostWeight
+	}
+	if in.PreferredViolationPenalty != nil {
+		out.PreferredViolationPenalty = new(int64)
+		*out.PreferredViolationPenalty = *in.PreferredViolationPenalty
+	}
+	if in.DefaultNodeBandwidth != nil {
+		out.DefaultNodeBandwidth = new(string)
+		*out.DefaultNodeBandwidth = *in.DefaultNodeBandwidth
+	}
+	if in.DefaultNodeLatency != nil {
+		out.DefaultNodeLatency = new(string)
+		*out.DefaultNodeLatency = *in.DefaultNodeLatency
+	}
+	if in.AssumeBestWhenUnknown != nil {
+		out.AssumeBestWhenUnknown = new(bool)
+		*out.AssumeBestWhenUnknown = *in.AssumeBestWhenUnknown
+	}
+	if in.MinNodeHealth != nil {
+		out.MinNodeHealth = new(float64)
+		*out.MinNodeHealth = *in.MinNodeHealth
+	}
+	if in.ShadowMode != nil {
+		out.ShadowMode = new(bool)
+		*out.ShadowMode = *in.ShadowMode
+	}
+	if in.RecordDecisions != nil {
+		out.RecordDecisions = new(bool)
+		*out.RecordDecisions = *in.RecordDecisions
+	}
+	if in.MaxTopologyAge != nil {
+		out.MaxTopologyAge = new(metav1.Duration)
+		*out.MaxTopologyAge = *in.MaxTopologyAge
+	}
+	if in.PodTimeBudget != nil {
+		out.PodTimeBudget = new(metav1.Duration)
+		*out.PodTimeBudget = *in.PodTimeBudget
+	}
+	if in.InitBandwidthWeight != nil {
+		out.InitBandwidthWeight = new(float64)
+		*out.InitBandwidthWeight = *in.InitBandwidthWeight
+	}
+	if in.BandwidthBlendHorizon != nil {
+		out.BandwidthBlendHorizon = new(metav1.Duration)
+		*out.BandwidthBlendHorizon = *in.BandwidthBlendHorizon
+	}
+	if in.ExportFile != nil {
+		out.ExportFile = new(string)
+		*out.ExportFile = *in.ExportFile
+	}
+	if in.IslandLatencyThreshold != nil {
+		out.IslandLatencyThreshold = new(string)
+		*out.IslandLatencyThreshold = *in.IslandLatencyThreshold
+	}
+	if in.UtilizationThreshold != nil {
+		out.UtilizationThreshold = new(float64)
+		*out.UtilizationThreshold = *in.UtilizationThreshold
+	}
+	if in.MaintenancePolicy != nil {
+		out.MaintenancePolicy = new(string)
+		*out.MaintenancePolicy = *in.MaintenancePolicy
+	}
+	if in.IngressControllerSelector != nil {
+		out.IngressControllerSelector = new(string)
+		*out.IngressControllerSelector = *in.IngressControllerSelector
+	}
+	if in.BypassPriorityThreshold != nil {
+		out.BypassPriorityThreshold = new(int32)
+		*out.BypassPriorityThreshold = *in.BypassPriorityThreshold
+	}
+	if in.LabelKeys != nil {
+		out.LabelKeys = new(requirements.LabelKeys)
+		*out.LabelKeys = *in.LabelKeys
+	}
+	if in.PreferenceRules != nil {
+		out.PreferenceRules = make([]PreferenceRule, len(in.PreferenceRules))
+		for i := range in.PreferenceRules {
+			r := in.PreferenceRules[i]
+			if r.PodSelector != nil {
+				r.PodSelector = r.PodSelector.DeepCopy()
+			}
+			if r.NodeSelector != nil {
+				r.NodeSelector = r.NodeSelector.DeepCopy()
+			}
+			out.PreferenceRules[i] = r
+		}
+	}
+	return out
+}
+
+// DefaultPreferenceRules returns the rule set matching the historical
+// hard-coded behavior: AI workloads prefer dedicated AI workers and GPU
+// nodes, web workloads prefer the remote (gateway) zone.
+func DefaultPreferenceRules() []PreferenceRule {
+	return []PreferenceRule{
+		{
+			Name:         "ai-on-ai-worker",
+			PodSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{LabelWorkloadType: "ai"}},
+			NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{LabelRole: "ai-worker"}},
+			Score:        100,
+		},
+		{
+			Name:         "ai-on-gpu",
+			PodSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{LabelWorkloadType: "ai"}},
+			NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{LabelGPU: "nvidia"}},
+			Score:        50,
+		},
+		{
+			Name:         "web-on-remote",
+			PodSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{LabelWorkloadType: "web"}},
+			NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{LabelZone: "remote"}},
+			Score:        30,
+		},
+	}
+}
+
+// weight dereferences an optional weight, substituting 1.0 when unset.
+func weight(w *float64) float64 {
+	if w == nil {
+		return 1.0
+	}
+	return *w
+}
+
+// validateArgs checks weights and the unknown-node defaults and compiles the
+// preference rules, returning the effective rule set and unknown policy.
+func validateArgs(args *NetworkAwareArgs) ([]compiledRule, requirements.UnknownPolicy, error) {
+	policy := requirements.DefaultUnknownPolicy()
+	for name, w := range map[string]*float64{
+		"bandwidthWeight": args.BandwidthWeight,
+		"latencyWeight":   args.LatencyWeight,
+		"costWeight":      args.CostWeight,
+	} {
+		if w != nil && *w < 0 {
+			return nil, policy, fmt.Errorf("%s must be non-negative, got %v", name, *w)
+		}
+	}
+
+	if args.PreferredViolationPenalty != nil && *args.PreferredViolationPenalty < 0 {
+		return nil, policy, fmt.Errorf("preferredViolationPenalty must be non-negative, got %d", *args.PreferredViolationPenalty)
+	}
+	if args.MaxTopologyAge != nil && args.MaxTopologyAge.Duration < 0 {
+		return nil, policy, fmt.Errorf("maxTopologyAge must be non-negative, got %v", args.MaxTopologyAge.Duration)
+	}
+	if args.PodTimeBudget != nil && args.PodTimeBudget.Duration < 0 {
+		return nil, policy, fmt.Errorf("podTimeBudget must be non-negative, got %v", args.PodTimeBudget.Duration)
+	}
+	if args.BandwidthBlendHorizon != nil && args.BandwidthBlendHorizon.Duration < 0 {
+		return nil, policy, fmt.Errorf("bandwidthBlendHorizon must be non-negative, got %v", args.BandwidthBlendHorizon.Duration)
+	}
+
+	if args.MinNodeHealth != nil && (*args.MinNodeHealth < 0 || *args.MinNodeHealth > 1) {
+		return nil, policy, fmt.Errorf("minNodeHealth must be in [0,1], got %v", *args.MinNodeHealth)
+	}
+	if args.InitBandwidthWeight != nil && (*args.InitBandwidthWeight <= 0 || *args.InitBandwidthWeight > 1) {
+		return nil, policy, fmt.Errorf("initBandwidthWeight must be in (0,1], got %v", *args.InitBandwidthWeight)
+	}
+	if args.UtilizationThreshold != nil && (*args.UtilizationThreshold <= 0 || *args.UtilizationThreshold > 1) {
+		return nil, policy, fmt.Errorf("utilizationThreshold must be in (0,1], got %v", *args.UtilizationThreshold)
+	}
+	if args.MaintenancePolicy != nil {
+		switch *args.MaintenancePolicy {
+		case maintenancePolicyFilter, maintenancePolicyPenalize:
+		default:
+			return nil, policy, fmt.Errorf("maintenancePolicy must be %q or %q, got %q",
+				maintenancePolicyFilter, maintenancePolicyPenalize, *args.MaintenancePolicy)
+		}
+	}
+	if args.MaxTopologyAge != nil {
+		policy.UtilizationMaxAge = args.MaxTopologyAge.Duration
+	}
+	if args.DefaultNodeBandwidth != nil {
+		bps, err := units.ParseBandwidth(*args.DefaultNodeBandwidth)
+		if err != nil {
+			return nil, policy, fmt.Errorf("invalid defaultNodeBandwidth: %w", err)
+		}
+		policy.BandwidthMbps = units.ToMbps(bps)
+	}
+	if args.DefaultNodeLatency != nil {
+		d, err := units.ParseLatency(*args.DefaultNodeLatency)
+		if err != nil {
+			return nil, policy, fmt.Errorf("invalid defaultNodeLatency: %w", err)
+		}
+		policy.LatencyMs = d.Milliseconds()
+	}
+	if args.AssumeBestWhenUnknown != nil {
+		policy.AssumeBest = *args.AssumeBestWhenUnknown
+	}
+
+	rules := args.PreferenceRules
+	if len(rules) == 0 {
+		rules = DefaultPreferenceRules()
+	}
+	compiled := make([]compiledRule, 0, len(rules))
+	for i, rule := range rules {
+		c := compiledRule{name: rule.Name, score: rule.Score}
+		if c.name == "" {
+			c.name = fmt.Sprintf("rule-%d", i)
+		}
+		var err error
+		if c.pod, err = selectorFor(rule.PodSelector); err != nil {
+			return nil, policy, fmt.Errorf("preference rule %q: invalid podSelector: %w", c.name, err)
+		}
+		if c.node, err = selectorFor(rule.NodeSelector); err != nil {
+			return nil, policy, fmt.Errorf("preference rule %q: invalid nodeSelector: %w", c.name, err)
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, policy, nil
+}
+
+// labelKeysFor resolves the optional label-key mapping; the zero value reads
+// the default keys.
+func labelKeysFor(args *NetworkAwareArgs) requirements.LabelKeys {
+	if args.LabelKeys == nil {
+		return requirements.LabelKeys{}
+	}
+	return *args.LabelKeys
+}
+
+// islandThresholdMs parses the optional island latency threshold, returning 0
+// (islands disabled) when unset.
+func islandThresholdMs(args *NetworkAwareArgs) (int64, error) {
+	if args.IslandLatencyThreshold == nil {
+		return 0, nil
+	}
+	d, err := units.ParseLatency(*args.IslandLatencyThreshold)
+	if err != nil {
+		return 0, fmt.Errorf("invalid islandLatencyThreshold: %w", err)
+	}
+	return d.Milliseconds(), nil
+}
+
+// ingressSelectorFor compiles the optional ingress-controller pod selector,
+// substituting the k3s-common default when unset.
+func ingressSelectorFor(args *NetworkAwareArgs) (labels.Selector, error) {
+	expr := defaultIngressControllerSelector
+	if args.IngressControllerSelector != nil {
+		expr = *args.IngressControllerSelector
+	}
+	sel, err := labels.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ingressControllerSelector: %w", err)
+	}
+	return sel, nil
+}
+
+func selectorFor(ls *metav1.LabelSelector) (labels.Selector, error) {
+	if ls == nil {
+		return labels.Everything(), nil
+	}
+	return metav1.LabelSelectorAsSelector(ls)
+}
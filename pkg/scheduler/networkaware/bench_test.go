@@ -0,0 +1,147 @@
+package networkaware
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// benchTopology and benchNodeName live in selftest.go, shared with the
+// "bench" self-test command.
+
+func benchNodes(n int) []*v1.Node {
+	nodes := make([]*v1.Node, n)
+	for i := range nodes {
+		nodes[i] = nodeWithLabels(benchNodeName(i), map[string]string{"zone": fmt.Sprintf("zone-%d", i%5)})
+	}
+	return nodes
+}
+
+func benchPlugin(b *testing.B, topo *v1alpha1.NetworkTopology) *NetworkAware {
+	b.Helper()
+	na, err := newNetworkAware(&NetworkAwareArgs{}, nil, staticTopology{topo: topo})
+	if err != nil {
+		b.Fatalf("newNetworkAware: %v", err)
+	}
+	return na
+}
+
+var benchSizes = []int{50, 150, 500}
+
+func BenchmarkPreFilter(b *testing.B) {
+	pod := podWithAnnotations(map[string]string{
+		AnnotationMinBandwidth: "200mbps",
+		AnnotationMaxLatency:   "50ms",
+	})
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("nodes-%d", n), func(b *testing.B) {
+			na := benchPlugin(b, benchTopology(n))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				state := framework.NewCycleState()
+				if _, status := na.PreFilter(context.Background(), state, pod); status != nil {
+					b.Fatal(status.Message())
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFilter(b *testing.B) {
+	pod := podWithAnnotations(map[string]string{
+		AnnotationMinBandwidth: "200mbps",
+		AnnotationMaxLatency:   "50ms",
+	})
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("nodes-%d", n), func(b *testing.B) {
+			na := benchPlugin(b, benchTopology(n))
+			nodes := benchNodes(n)
+			state := framework.NewCycleState()
+			if _, status := na.PreFilter(context.Background(), state, pod); status != nil {
+				b.Fatal(status.Message())
+			}
+			infos := make([]*framework.NodeInfo, len(nodes))
+			for i, node := range nodes {
+				infos[i] = framework.NewNodeInfo()
+				infos[i].SetNode(node)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				na.Filter(context.Background(), state, pod, infos[i%len(infos)])
+			}
+		})
+	}
+}
+
+// BenchmarkScoreBurst scores a 50-pod Deployment burst against 100 nodes,
+// comparing the scoring cache against cold computation (forced by stripping
+// the resourceVersion, which bypasses the cache).
+func BenchmarkScoreBurst(b *testing.B) {
+	const pods, nodeCount = 50, 100
+	pod := podWithAnnotations(map[string]string{
+		AnnotationMinBandwidth:    "200mbps",
+		AnnotationMaxLatency:      "50ms",
+		AnnotationRequirementMode: "preferred",
+	})
+	for name, rv := range map[string]string{"cached": "1", "uncached": ""} {
+		b.Run(name, func(b *testing.B) {
+			topo := benchTopology(nodeCount)
+			topo.ResourceVersion = rv
+			na := benchPlugin(b, topo)
+			nodes := benchNodes(nodeCount)
+			state := framework.NewCycleState()
+			if _, status := na.PreFilter(context.Background(), state, pod); status != nil {
+				b.Fatal(status.Message())
+			}
+			reqs, fingerprint := stateFromCycle(state)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for p := 0; p < pods; p++ {
+					for _, node := range nodes {
+						_ = na.baseScore(reqs, fingerprint, node, topo)
+					}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkScore exercises the scoring internals Score sums per node (the
+// handle-based lister fetch is the framework's cost, not ours). One iteration
+// scores every node once, i.e. one pod's full scoring pass.
+func BenchmarkScore(b *testing.B) {
+	pod := podWithAnnotations(map[string]string{
+		AnnotationMinBandwidth:    "200mbps",
+		AnnotationMaxLatency:      "50ms",
+		AnnotationRequirementMode: "preferred",
+	})
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("nodes-%d", n), func(b *testing.B) {
+			topo := benchTopology(n)
+			na := benchPlugin(b, topo)
+			nodes := benchNodes(n)
+			state := framework.NewCycleState()
+			if _, status := na.PreFilter(context.Background(), state, pod); status != nil {
+				b.Fatal(status.Message())
+			}
+			reqs := requirementsFromState(state)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, node := range nodes {
+					score := na.scoreFromTopology(node, topo)
+					score += na.matchRules(pod, node)
+					score -= na.preferredPenalty(reqs, node, topo)
+					_ = score
+				}
+			}
+		})
+	}
+}
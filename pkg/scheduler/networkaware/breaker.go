@@ -0,0 +1,85 @@
+package networkaware
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/klog/v2"
+)
+
+// Breaker defaults. The negative cache stops a single apiserver hiccup from
+// turning into one failed GET per scored node within a scheduling cycle; the
+// breaker handles sustained outages.
+const (
+	// breakerNegativeTTL is how long after any failed fetch the plugin
+	// skips retrying.
+	breakerNegativeTTL = 3 * time.Second
+	// breakerFailureThreshold is how many consecutive failures open the
+	// breaker.
+	breakerFailureThreshold = 5
+	// breakerCooldown is how long an open breaker suppresses fetches.
+	breakerCooldown = 30 * time.Second
+)
+
+var topologyBreakerTripsTotal = metrics.NewCounter(&metrics.CounterOpts{
+	Name:           "networkaware_topology_breaker_trips_total",
+	Help:           "Number of times consecutive topology fetch failures opened the circuit breaker.",
+	StabilityLevel: metrics.ALPHA,
+})
+
+// fetchBreaker rate-limits topology fetches after failures so apiserver
+// pressure is not amplified by the scheduler exactly when it can least
+// afford it. A successful fetch resets it immediately. Safe for the parallel
+// cycles that share it: every method takes the mutex.
+type fetchBreaker struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	retryAt   time.Time
+	openUntil time.Time
+}
+
+func newFetchBreaker() *fetchBreaker {
+	return &fetchBreaker{now: time.Now}
+}
+
+// allow reports whether a fetch should be attempted now.
+func (b *fetchBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	return !now.Before(b.retryAt) && !now.Before(b.openUntil)
+}
+
+// onSuccess closes the breaker and clears the negative cache.
+func (b *fetchBreaker) onSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures >= breakerFailureThreshold {
+		klog.InfoS("Topology fetches recovered, closing circuit breaker")
+	}
+	b.failures = 0
+	b.retryAt = time.Time{}
+	b.openUntil = time.Time{}
+}
+
+// onFailure records a failed fetch, arming the negative cache and, past the
+// threshold, opening the breaker with a single warning.
+func (b *fetchBreaker) onFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	b.failures++
+	b.retryAt = now.Add(breakerNegativeTTL)
+	if b.failures == breakerFailureThreshold {
+		b.openUntil = now.Add(breakerCooldown)
+		topologyBreakerTripsTotal.Inc()
+		klog.InfoS("Opening topology fetch circuit breaker, using label fallback",
+			"failures", b.failures, "cooldown", breakerCooldown)
+	} else if b.failures > breakerFailureThreshold {
+		// Still failing after the cooldown expired: re-open quietly.
+		b.openUntil = now.Add(breakerCooldown)
+	}
+}
@@ -0,0 +1,49 @@
+package networkaware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerNegativeCache(t *testing.T) {
+	now := time.Now()
+	b := newFetchBreaker()
+	b.now = func() time.Time { return now }
+
+	if !b.allow() {
+		t.Fatalf("fresh breaker should allow fetches")
+	}
+	b.onFailure()
+	if b.allow() {
+		t.Fatalf("a failed fetch should suppress immediate retries")
+	}
+	now = now.Add(breakerNegativeTTL + time.Second)
+	if !b.allow() {
+		t.Fatalf("the negative cache should expire after %v", breakerNegativeTTL)
+	}
+}
+
+func TestBreakerOpensAndRecovers(t *testing.T) {
+	now := time.Now()
+	b := newFetchBreaker()
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < breakerFailureThreshold; i++ {
+		b.onFailure()
+		now = now.Add(breakerNegativeTTL + time.Second)
+	}
+	if b.allow() {
+		t.Fatalf("breaker should be open after %d consecutive failures", breakerFailureThreshold)
+	}
+	now = now.Add(breakerCooldown)
+	if !b.allow() {
+		t.Fatalf("breaker should allow a probe after the cooldown")
+	}
+
+	// A success closes it immediately.
+	b.onFailure()
+	b.onSuccess()
+	if !b.allow() {
+		t.Fatalf("breaker should reset immediately on a successful fetch")
+	}
+}
@@ -0,0 +1,120 @@
+package networkaware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// classGetter abstracts NetworkClass lookup so tests can substitute fixtures.
+// A nil class with nil error means the class does not exist.
+type classGetter interface {
+	Get(ctx context.Context, name string) (*v1alpha1.NetworkClass, error)
+}
+
+// classTTL is how long a resolved class (or its absence) is served from
+// cache. Classes change rarely; re-fetching one per pod would be waste.
+const classTTL = 30 * time.Second
+
+var classGVR = v1alpha1.SchemeGroupVersion.WithResource("networkclasses")
+
+// classClient fetches NetworkClass objects through the dynamic client with a
+// short per-name cache.
+type classClient struct {
+	client dynamic.Interface
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]classCacheEntry
+}
+
+type classCacheEntry struct {
+	class   *v1alpha1.NetworkClass
+	expires time.Time
+}
+
+func newClassClient(client dynamic.Interface) *classClient {
+	return &classClient{client: client, now: time.Now, cache: map[string]classCacheEntry{}}
+}
+
+func (c *classClient) Get(ctx context.Context, name string) (*v1alpha1.NetworkClass, error) {
+	c.mu.Lock()
+	entry, ok := c.cache[name]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.class, nil
+	}
+
+	var class *v1alpha1.NetworkClass
+	obj, err := c.client.Resource(classGVR).Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		// Cache the absence too: a misspelled class on a crashlooping
+		// deployment would otherwise hammer the API server.
+	case err != nil:
+		return nil, err
+	default:
+		class = &v1alpha1.NetworkClass{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, class); err != nil {
+			return nil, fmt.Errorf("converting NetworkClass %q: %w", name, err)
+		}
+	}
+
+	c.mu.Lock()
+	c.cache[name] = classCacheEntry{class: class, expires: c.now().Add(classTTL)}
+	c.mu.Unlock()
+	return class, nil
+}
+
+// applyClass expands the pod's NetworkClass, or its namespace's default
+// class, into the parsed requirements. A reference to a class that does not
+// exist is an error so the pod surfaces it instead of silently scheduling
+// without its class's constraints; lookup failures are only logged because a
+// flaky API server must not reject pods.
+func (na *NetworkAware) applyClass(ctx context.Context, pod *v1.Pod, reqs *requirements.Requirements) error {
+	if na.classes == nil {
+		return nil
+	}
+	className := pod.Annotations[AnnotationClass]
+	if className == "" {
+		className = na.namespaceDefaultClass(pod.Namespace)
+	}
+	if className == "" {
+		return nil
+	}
+
+	class, err := na.classes.Get(ctx, className)
+	if err != nil {
+		klog.V(2).InfoS("NetworkClass lookup failed, scheduling without it",
+			"pod", klog.KObj(pod), "class", className, "err", err)
+		return nil
+	}
+	if class == nil {
+		return fmt.Errorf("NetworkClass %q does not exist", className)
+	}
+	return requirements.ExpandClass(reqs, className, &class.Spec)
+}
+
+// namespaceDefaultClass returns the class the pod's namespace declares as its
+// default, or "" when there is none or the lister is unavailable.
+func (na *NetworkAware) namespaceDefaultClass(namespace string) string {
+	if na.namespaceLister == nil {
+		return ""
+	}
+	ns, err := na.namespaceLister.Get(namespace)
+	if err != nil {
+		return ""
+	}
+	return ns.Annotations[AnnotationDefaultClass]
+}
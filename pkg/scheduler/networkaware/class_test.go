@@ -0,0 +1,96 @@
+package networkaware
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+)
+
+// staticClasses satisfies classGetter with a fixture map.
+type staticClasses map[string]*v1alpha1.NetworkClass
+
+func (s staticClasses) Get(_ context.Context, name string) (*v1alpha1.NetworkClass, error) {
+	return s[name], nil
+}
+
+func lowLatencyClass() *v1alpha1.NetworkClass {
+	return &v1alpha1.NetworkClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "low-latency"},
+		Spec:       v1alpha1.NetworkClassSpec{MaxLatency: "10ms"},
+	}
+}
+
+func classTestPlugin(t *testing.T) *NetworkAware {
+	t.Helper()
+	topo := topologytesting.NewTopology().
+		WithLink("fast", "peer", "5ms", "900mbps").
+		WithLink("slow", "peer", "120ms", "30mbps").
+		Build()
+	na := newTestPlugin(t, nil, topo)
+	na.classes = staticClasses{"low-latency": lowLatencyClass()}
+	return na
+}
+
+func TestClassExpandsIntoRequirements(t *testing.T) {
+	na := classTestPlugin(t)
+	pod := podWithAnnotations(map[string]string{AnnotationClass: "low-latency"})
+
+	if status := runFilter(t, na, pod, nodeWithLabels("fast", nil)); status != nil {
+		t.Fatalf("fast node should pass the class's max-latency: %v", status)
+	}
+	if status := runFilter(t, na, pod, nodeWithLabels("slow", nil)); status == nil {
+		t.Fatal("slow node should violate the class's max-latency")
+	}
+}
+
+func TestPodAnnotationOverridesClass(t *testing.T) {
+	na := classTestPlugin(t)
+	pod := podWithAnnotations(map[string]string{
+		AnnotationClass:      "low-latency",
+		AnnotationMaxLatency: "200ms",
+	})
+	if status := runFilter(t, na, pod, nodeWithLabels("slow", nil)); status != nil {
+		t.Fatalf("pod-level max-latency should override the class: %v", status)
+	}
+}
+
+func TestNonexistentClassRejectsPod(t *testing.T) {
+	na := classTestPlugin(t)
+	pod := podWithAnnotations(map[string]string{AnnotationClass: "no-such-class"})
+
+	_, status := na.PreFilter(context.Background(), framework.NewCycleState(), pod)
+	if status == nil || status.Code() != framework.UnschedulableAndUnresolvable {
+		t.Fatalf("expected UnschedulableAndUnresolvable, got %v", status)
+	}
+}
+
+func TestNamespaceDefaultClass(t *testing.T) {
+	na := classTestPlugin(t)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "team-a",
+		Annotations: map[string]string{AnnotationDefaultClass: "low-latency"},
+	}}); err != nil {
+		t.Fatalf("adding namespace: %v", err)
+	}
+	na.namespaceLister = corelisters.NewNamespaceLister(indexer)
+
+	pod := podWithAnnotations(nil)
+	pod.Namespace = "team-a"
+	if status := runFilter(t, na, pod, nodeWithLabels("slow", nil)); status == nil {
+		t.Fatal("namespace default class should apply to unannotated pods")
+	}
+
+	pod.Namespace = "other"
+	if status := runFilter(t, na, pod, nodeWithLabels("slow", nil)); status != nil {
+		t.Fatalf("namespaces without a default class must be unaffected: %v", status)
+	}
+}
@@ -0,0 +1,67 @@
+package networkaware
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// LabelColocateGroup names a set of chatty pods, typically stamped on a
+// Deployment's pod template. Members pull toward each other's zones purely
+// through scoring: unlike a latency-bound pod group there is no Permit gate
+// and members schedule independently.
+const LabelColocateGroup = annotationPrefix + "colocate-group"
+
+// Per-member rewards, with same-zone dominating the low-cost-link fallback,
+// and a cap keeping colocation well below the hard capability and requirement
+// signals.
+const (
+	colocateSameZoneBonus = 15
+	colocateLinkBonusMax  = 8
+	colocateBonusMax      = 45
+)
+
+// colocateBonus rewards candidates sharing a zone with already-running
+// members of the pod's colocate-group, and secondarily candidates on cheap
+// links toward them. Members are resolved across all namespaces the lister
+// can see; pods outside any group score neutrally.
+func (na *NetworkAware) colocateBonus(pod *v1.Pod, node *v1.Node, topo *v1alpha1.NetworkTopology) int64 {
+	group := pod.Labels[LabelColocateGroup]
+	if group == "" || topo == nil || na.podLister == nil {
+		return 0
+	}
+	members, err := na.podLister.List(labels.SelectorFromSet(labels.Set{LabelColocateGroup: group}))
+	if err != nil {
+		klog.V(4).InfoS("Colocate group: listing members failed", "pod", klog.KObj(pod), "group", group, "err", err)
+		return 0
+	}
+
+	candidateZone := na.keys.NodeZone(node, topo)
+	var bonus int64
+	for _, member := range members {
+		if member.UID == pod.UID || member.Spec.NodeName == "" {
+			continue
+		}
+		memberZone := topologyZone(topo, member.Spec.NodeName)
+		if candidateZone != "" && candidateZone == memberZone {
+			bonus += colocateSameZoneBonus
+			continue
+		}
+		if cost, ok := topologyCost(topo, node.Name, member.Spec.NodeName); ok {
+			bonus += int64((1 - cost) * colocateLinkBonusMax)
+		}
+	}
+	if bonus > colocateBonusMax {
+		bonus = colocateBonusMax
+	}
+	return bonus
+}
+
+// topologyZone reads a node's zone straight from the topology spec, for nodes
+// we only know by name, normalized like every other zone comparison.
+func topologyZone(topo *v1alpha1.NetworkTopology, nodeName string) string {
+	return requirements.NormalizeLabelValue(topo.Spec.Nodes[nodeName].Zone)
+}
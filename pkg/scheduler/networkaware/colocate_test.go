@@ -0,0 +1,60 @@
+package networkaware
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+)
+
+func colocateMember(name, namespace, group, nodeName string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       types.UID(namespace + "/" + name),
+			Labels:    map[string]string{LabelColocateGroup: group},
+		},
+		Spec: v1.PodSpec{NodeName: nodeName},
+	}
+}
+
+func TestColocateGroupPrefersMemberZone(t *testing.T) {
+	topo := topologytesting.NewTopology().
+		WithLink("a1", "b1", "20ms", "500mbps").
+		WithZone("a1", "zone-a").
+		WithZone("a2", "zone-a").
+		WithZone("b1", "zone-b").
+		WithCost("a2", "a1", 0.1).
+		WithCost("b1", "a1", 0.5).
+		Build()
+
+	na := newTestPlugin(t, nil, topo)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	// Members in other namespaces still count.
+	if err := indexer.Add(colocateMember("cache-0", "other", "chatty", "a1")); err != nil {
+		t.Fatalf("seeding pod lister: %v", err)
+	}
+	na.podLister = corelisters.NewPodLister(indexer)
+
+	pending := colocateMember("cache-1", "default", "chatty", "")
+	sameZone := na.colocateBonus(pending, nodeWithLabels("a2", nil), topo)
+	otherZone := na.colocateBonus(pending, nodeWithLabels("b1", nil), topo)
+	if sameZone <= otherZone {
+		t.Fatalf("same-zone candidate should outrank cross-zone: a2=%d b1=%d", sameZone, otherZone)
+	}
+	if otherZone <= 0 {
+		t.Errorf("cheap cross-zone link should still earn a small bonus, got %d", otherZone)
+	}
+
+	// Pods outside any group score neutrally.
+	plain := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "default"}}
+	if got := na.colocateBonus(plain, nodeWithLabels("a2", nil), topo); got != 0 {
+		t.Errorf("ungrouped pod got colocate bonus %d, want 0", got)
+	}
+}
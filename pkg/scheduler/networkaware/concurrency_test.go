@@ -0,0 +1,110 @@
+package networkaware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// TestConcurrentCyclesWithInformerChurn hammers the extension points from
+// many goroutines while a churn goroutine plays the informer: swapping the
+// observed topology snapshot and syncing/releasing pod reservations. It
+// asserts nothing beyond "no status errors and an empty ledger at the end" —
+// its real assertion is the race detector.
+func TestConcurrentCyclesWithInformerChurn(t *testing.T) {
+	const nodeCount = 16
+	topoA := benchTopology(nodeCount)
+	topoB := benchTopology(nodeCount)
+	topoB.ResourceVersion = "2"
+	na := newTestPlugin(t, nil, topoA)
+
+	nodes := make([]*v1.Node, nodeCount)
+	infos := make([]*framework.NodeInfo, nodeCount)
+	for i := range nodes {
+		nodes[i] = nodeWithLabels(benchNodeName(i), map[string]string{"zone": fmt.Sprintf("zone-%d", i%5)})
+		infos[i] = framework.NewNodeInfo()
+		infos[i].SetNode(nodes[i])
+	}
+
+	ctx := context.Background()
+	stop := make(chan struct{})
+	var churn sync.WaitGroup
+	churn.Add(1)
+	go func() {
+		defer churn.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if i%2 == 0 {
+				na.rememberTopology(topoA)
+			} else {
+				na.rememberTopology(topoB)
+			}
+			running := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("running-%d", i), Namespace: "default",
+					UID:         types.UID(fmt.Sprintf("informer-%d", i)),
+					Annotations: map[string]string{AnnotationMinBandwidth: "100mbps"},
+				},
+				Spec: v1.PodSpec{NodeName: benchNodeName(i % nodeCount)},
+			}
+			na.syncPodReservation(running)
+			na.releasePodReservation(running)
+		}
+	}()
+
+	const workers, iterations = 8, 40
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("pod-%d-%d", w, i), Namespace: "default",
+					UID: types.UID(fmt.Sprintf("worker-%d-%d", w, i)),
+					// Within benchTopology's 100-999mbps / 1-120ms ranges, so
+					// the PreFilter feasibility gate passes the pods instead
+					// of short-circuiting the cycle under test.
+					Annotations: map[string]string{AnnotationMinBandwidth: "200mbps", AnnotationMaxLatency: "150ms"},
+				}}
+				state := framework.NewCycleState()
+				if _, status := na.PreFilter(ctx, state, pod); status != nil {
+					t.Errorf("PreFilter: %v", status)
+					return
+				}
+				reqs, fingerprint := stateFromCycle(state)
+				topo := na.currentTopology(ctx)
+				for j, info := range infos {
+					na.Filter(ctx, state, pod, info)
+					_ = na.baseScore(reqs, fingerprint, nodes[j], topo)
+				}
+				target := benchNodeName((w + i) % nodeCount)
+				if status := na.Reserve(ctx, state, pod, target); status != nil {
+					t.Errorf("Reserve: %v", status)
+					return
+				}
+				na.Unreserve(ctx, state, pod, target)
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(stop)
+	churn.Wait()
+
+	// Every claim was released, so the ledger must be empty again.
+	for i := 0; i < nodeCount; i++ {
+		if got := na.ledger.reservedOnNode(benchNodeName(i)); got != 0 {
+			t.Errorf("node %s still has %dmbps reserved after all releases", benchNodeName(i), got)
+		}
+	}
+}
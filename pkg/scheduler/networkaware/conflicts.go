@@ -0,0 +1,181 @@
+package networkaware
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// warnSelectorConflict cross-checks the pod's network requirements against
+// its own nodeSelector and required node affinity. A pod demanding
+// data-locality while its selector pins it to a remote zone goes Pending
+// with per-node messages that never mention the selector; diagnosing the
+// combination here names the actual conflict in a pod Event. Purely
+// advisory — the normal unschedulable status still comes from Filter.
+func (na *NetworkAware) warnSelectorConflict(ctx context.Context, pod *v1.Pod, reqs *requirements.Requirements) {
+	if na.nodeLister == nil || !podSelectsNodes(pod) {
+		return
+	}
+	topo := na.currentTopology(ctx)
+	if topo == nil {
+		return
+	}
+	nodes, err := na.nodeLister.List(labels.Everything())
+	if err != nil {
+		return
+	}
+	msg, conflict := selectorConflictMessage(pod, nodes, reqs, topo, na.policy, na.keys)
+	if !conflict {
+		return
+	}
+	selectorConflictsTotal.Inc()
+	klog.V(2).InfoS("Pod's node selection conflicts with its network requirements",
+		"pod", klog.KObj(pod), "conflict", msg)
+	if na.handle != nil {
+		na.handle.EventRecorder().Eventf(pod, nil, v1.EventTypeWarning,
+			"NetworkRequirementsConflict", "Scheduling", msg)
+	}
+}
+
+// podSelectsNodes reports whether the pod constrains its placement at all;
+// without a selector or required affinity there is nothing to conflict with.
+func podSelectsNodes(pod *v1.Pod) bool {
+	if len(pod.Spec.NodeSelector) > 0 {
+		return true
+	}
+	return pod.Spec.Affinity != nil && pod.Spec.Affinity.NodeAffinity != nil &&
+		pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil
+}
+
+// selectorConflictMessage decides whether the pod's node selection and its
+// hard network requirements are mutually unsatisfiable: the selector admits
+// nodes, none of them passes the network requirements, and at least one
+// excluded node would. Both sides individually satisfiable but jointly not is
+// exactly the case neither the core scheduler's message nor ours explains on
+// its own.
+func selectorConflictMessage(pod *v1.Pod, nodes []*v1.Node, reqs *requirements.Requirements,
+	topo *v1alpha1.NetworkTopology, policy requirements.UnknownPolicy, keys requirements.LabelKeys) (string, bool) {
+	allowed, allowedPass, excludedPass := 0, 0, 0
+	reason := ""
+	for _, node := range nodes {
+		violation := firstHardViolation(reqs, node, topo, policy, keys)
+		if matchesNodeSelection(pod, node) {
+			allowed++
+			if violation == "" {
+				allowedPass++
+			} else if reason == "" {
+				reason = violation
+			}
+		} else if violation == "" {
+			excludedPass++
+		}
+	}
+	if allowed == 0 || allowedPass > 0 || excludedPass == 0 {
+		return "", false
+	}
+	return fmt.Sprintf(
+		"nodeSelector/affinity restrict the pod to %d node(s), but its network requirements exclude all of them (%s); %d node(s) outside the selection would satisfy them",
+		allowed, reason, excludedPass), true
+}
+
+// firstHardViolation returns the first required-mode violation's reason, or
+// "" when the node satisfies every hard network requirement.
+func firstHardViolation(reqs *requirements.Requirements, node *v1.Node,
+	topo *v1alpha1.NetworkTopology, policy requirements.UnknownPolicy, keys requirements.LabelKeys) string {
+	for _, v := range requirements.EvaluateWithKeys(reqs, node, topo, policy, keys) {
+		if v.Mode == requirements.ModeRequired {
+			return v.Reason
+		}
+	}
+	return ""
+}
+
+// matchesNodeSelection evaluates the pod's nodeSelector and required node
+// affinity against the node, mirroring the core scheduler's semantics:
+// selector entries AND together, affinity terms OR, and the expressions
+// within one term AND.
+func matchesNodeSelection(pod *v1.Pod, node *v1.Node) bool {
+	for key, value := range pod.Spec.NodeSelector {
+		if node.Labels[key] != value {
+			return false
+		}
+	}
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil ||
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true
+	}
+	for _, term := range affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		if nodeSelectorTermMatches(term, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeSelectorTermMatches evaluates one affinity term. An empty term matches
+// nothing, as upstream defines it.
+func nodeSelectorTermMatches(term v1.NodeSelectorTerm, node *v1.Node) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	for _, req := range term.MatchExpressions {
+		value, exists := node.Labels[req.Key]
+		if !matchNodeSelectorRequirement(req, value, exists) {
+			return false
+		}
+	}
+	for _, req := range term.MatchFields {
+		// metadata.name is the only node field selector the API accepts.
+		if req.Key != "metadata.name" || !matchNodeSelectorRequirement(req, node.Name, true) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchNodeSelectorRequirement evaluates one expression with the label
+// selector semantics the core scheduler applies: NotIn and DoesNotExist
+// match nodes missing the key, the others require it.
+func matchNodeSelectorRequirement(req v1.NodeSelectorRequirement, value string, exists bool) bool {
+	switch req.Operator {
+	case v1.NodeSelectorOpIn:
+		return exists && containsString(req.Values, value)
+	case v1.NodeSelectorOpNotIn:
+		return !exists || !containsString(req.Values, value)
+	case v1.NodeSelectorOpExists:
+		return exists
+	case v1.NodeSelectorOpDoesNotExist:
+		return !exists
+	case v1.NodeSelectorOpGt, v1.NodeSelectorOpLt:
+		if !exists || len(req.Values) != 1 {
+			return false
+		}
+		have, err1 := strconv.ParseInt(value, 10, 64)
+		want, err2 := strconv.ParseInt(req.Values[0], 10, 64)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		if req.Operator == v1.NodeSelectorOpGt {
+			return have > want
+		}
+		return have < want
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package networkaware
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+)
+
+func TestSelectorConflictMessage(t *testing.T) {
+	topo := topologytesting.NewTopology().
+		WithLink("fast", "peer", "2ms", "900mbps").
+		WithLink("slow", "peer", "80ms", "40mbps").
+		WithZone("fast", "local").
+		WithZone("slow", "remote").
+		Build()
+	nodes := []*v1.Node{
+		nodeWithLabels("fast", map[string]string{"zone": "local"}),
+		nodeWithLabels("slow", map[string]string{"zone": "remote"}),
+	}
+	na := newTestPlugin(t, nil, topo)
+
+	// The selector pins the pod to the remote node; max-latency excludes it.
+	pod := podWithAnnotations(map[string]string{AnnotationMaxLatency: "5ms"})
+	pod.Spec.NodeSelector = map[string]string{"zone": "remote"}
+	reqs := mustParseRequirements(t, pod)
+	msg, conflict := selectorConflictMessage(pod, nodes, reqs, topo, na.policy, na.keys)
+	if !conflict {
+		t.Fatal("expected a conflict between the zone=remote selector and max-latency=5ms")
+	}
+	if msg == "" {
+		t.Fatal("conflict without a message")
+	}
+
+	// Widening the selector to a satisfying node clears the conflict.
+	pod.Spec.NodeSelector = nil
+	if _, conflict := selectorConflictMessage(pod, nodes, reqs, topo, na.policy, na.keys); conflict {
+		t.Error("unrestricted pod must not report a selector conflict")
+	}
+
+	// Requirements nothing satisfies are infeasibility, not a conflict.
+	impossible := mustParseRequirements(t, podWithAnnotations(map[string]string{AnnotationMaxLatency: "1ms"}))
+	pod.Spec.NodeSelector = map[string]string{"zone": "remote"}
+	if _, conflict := selectorConflictMessage(pod, nodes, impossible, topo, na.policy, na.keys); conflict {
+		t.Error("globally unsatisfiable requirements must not read as a selector conflict")
+	}
+}
+
+func TestMatchesNodeSelection(t *testing.T) {
+	node := nodeWithLabels("pi-1", map[string]string{"zone": "local", "cpus": "4"})
+
+	selector := func(sel map[string]string) *v1.Pod {
+		pod := podWithAnnotations(nil)
+		pod.Spec.NodeSelector = sel
+		return pod
+	}
+	if !matchesNodeSelection(selector(map[string]string{"zone": "local"}), node) {
+		t.Error("matching nodeSelector rejected")
+	}
+	if matchesNodeSelection(selector(map[string]string{"zone": "remote"}), node) {
+		t.Error("mismatching nodeSelector accepted")
+	}
+
+	affinity := func(exprs ...v1.NodeSelectorRequirement) *v1.Pod {
+		pod := podWithAnnotations(nil)
+		pod.Spec.Affinity = &v1.Affinity{NodeAffinity: &v1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+				NodeSelectorTerms: []v1.NodeSelectorTerm{{MatchExpressions: exprs}},
+			},
+		}}
+		return pod
+	}
+	cases := []struct {
+		name string
+		req  v1.NodeSelectorRequirement
+		want bool
+	}{
+		{"In matches", v1.NodeSelectorRequirement{Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"local", "edge"}}, true},
+		{"In rejects", v1.NodeSelectorRequirement{Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"remote"}}, false},
+		{"NotIn rejects present value", v1.NodeSelectorRequirement{Key: "zone", Operator: v1.NodeSelectorOpNotIn, Values: []string{"local"}}, false},
+		{"NotIn matches missing label", v1.NodeSelectorRequirement{Key: "gpu", Operator: v1.NodeSelectorOpNotIn, Values: []string{"nvidia"}}, true},
+		{"Exists", v1.NodeSelectorRequirement{Key: "zone", Operator: v1.NodeSelectorOpExists}, true},
+		{"DoesNotExist", v1.NodeSelectorRequirement{Key: "gpu", Operator: v1.NodeSelectorOpDoesNotExist}, true},
+		{"Gt matches", v1.NodeSelectorRequirement{Key: "cpus", Operator: v1.NodeSelectorOpGt, Values: []string{"2"}}, true},
+		{"Lt rejects", v1.NodeSelectorRequirement{Key: "cpus", Operator: v1.NodeSelectorOpLt, Values: []string{"2"}}, false},
+		{"Gt on non-numeric label", v1.NodeSelectorRequirement{Key: "zone", Operator: v1.NodeSelectorOpGt, Values: []string{"2"}}, false},
+	}
+	for _, tc := range cases {
+		if got := matchesNodeSelection(affinity(tc.req), node); got != tc.want {
+			t.Errorf("%s: matches = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// Terms OR together: one failing term does not reject when another matches.
+	pod := podWithAnnotations(nil)
+	pod.Spec.Affinity = &v1.Affinity{NodeAffinity: &v1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+			NodeSelectorTerms: []v1.NodeSelectorTerm{
+				{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"remote"}}}},
+				{MatchFields: []v1.NodeSelectorRequirement{{Key: "metadata.name", Operator: v1.NodeSelectorOpIn, Values: []string{"pi-1"}}}},
+			},
+		},
+	}}
+	if !matchesNodeSelection(pod, node) {
+		t.Error("ORed affinity terms: matching second term rejected")
+	}
+
+	// An empty term matches nothing.
+	pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms = []v1.NodeSelectorTerm{{}}
+	if matchesNodeSelection(pod, node) {
+		t.Error("empty affinity term must match nothing")
+	}
+}
@@ -0,0 +1,186 @@
+package networkaware
+
+import (
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+)
+
+// AnnotationDomain pins the pod to a named network domain: the candidate
+// node's domain (its controller-maintained label, or island membership
+// computed from the topology) must equal the value. The key is the same
+// string the controller writes as a node label.
+const AnnotationDomain = requirements.LabelDomain
+
+// AnnotationSameDomainAs holds a pod label selector; the candidate node must
+// share a domain with every running pod the selector matches in the pod's
+// namespace. This keeps chatty components on one LAN segment without
+// hand-written affinity rules.
+const AnnotationSameDomainAs = annotationPrefix + "same-domain-as"
+
+// domainReason is the blocked-report and metric label for domain rejections.
+const domainReason = "domain"
+
+// domainIndex caches the topology-derived domain partition per topology
+// generation, mirroring islandIndex — including its locking. It is the
+// fallback for nodes that do not carry the controller-maintained domain
+// label.
+type domainIndex struct {
+	thresholdMs int64
+
+	mu         sync.Mutex
+	generation string
+	domains    map[string]string
+}
+
+func newDomainIndex(thresholdMs int64) *domainIndex {
+	return &domainIndex{thresholdMs: thresholdMs}
+}
+
+// domain returns the node's topology-derived domain, with ok=false when no
+// island threshold is configured or the node is not in the topology.
+func (dx *domainIndex) domain(topo *v1alpha1.NetworkTopology, nodeName string) (string, bool) {
+	if dx.thresholdMs <= 0 || topo == nil {
+		return "", false
+	}
+	dx.mu.Lock()
+	defer dx.mu.Unlock()
+	if gen := topologyGeneration(topo); gen != dx.generation {
+		dx.domains = topology.Domains(topo, dx.thresholdMs)
+		dx.generation = gen
+	}
+	d, ok := dx.domains[nodeName]
+	return d, ok
+}
+
+// nodeDomain resolves the candidate's domain: the controller-maintained
+// label wins, then island membership from the topology.
+func (na *NetworkAware) nodeDomain(node *v1.Node, topo *v1alpha1.NetworkTopology) (string, bool) {
+	if d := node.Labels[requirements.LabelDomain]; d != "" {
+		return d, true
+	}
+	return na.domains.domain(topo, node.Name)
+}
+
+// peerDomain resolves a peer pod's node by name through the scheduling
+// snapshot, falling back to the topology when the snapshot is unavailable
+// (tests) or the node is unlabeled.
+func (na *NetworkAware) peerDomain(nodeName string, topo *v1alpha1.NetworkTopology) (string, bool) {
+	if na.handle != nil {
+		if info, err := na.handle.SnapshotSharedLister().NodeInfos().Get(nodeName); err == nil {
+			if d := info.Node().Labels[requirements.LabelDomain]; d != "" {
+				return d, true
+			}
+		}
+	}
+	return na.domains.domain(topo, nodeName)
+}
+
+// domainMode returns how domain violations are treated: the per-annotation
+// ".mode" override wins over the pod-wide requirement mode, and required is
+// the default, matching the shared requirements convention.
+func domainMode(pod *v1.Pod) requirements.Mode {
+	if v, ok := pod.Annotations[AnnotationDomain+".mode"]; ok {
+		return requirements.Mode(v)
+	}
+	if v := pod.Annotations[requirements.AnnotationRequirementMode]; v != "" {
+		return requirements.Mode(v)
+	}
+	return requirements.ModeRequired
+}
+
+// domainViolation checks both domain annotations against the candidate. A
+// node (or peer) whose domain cannot be determined counts as a violation:
+// the caller decides whether that rejects or merely penalizes, per the mode.
+func (na *NetworkAware) domainViolation(pod *v1.Pod, node *v1.Node, topo *v1alpha1.NetworkTopology) (bool, string) {
+	want := pod.Annotations[AnnotationDomain]
+	selector := pod.Annotations[AnnotationSameDomainAs]
+	if want == "" && selector == "" {
+		return false, ""
+	}
+	domain, known := na.nodeDomain(node, topo)
+	if !known {
+		return true, "node " + node.Name + " has no known network domain"
+	}
+	if want != "" && domain != want {
+		return true, "node " + node.Name + " is in domain " + domain + ", pod requires " + want
+	}
+	if selector == "" {
+		return false, ""
+	}
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		klog.V(4).InfoS("Ignoring malformed same-domain-as selector", "pod", klog.KObj(pod),
+			"value", selector, "err", err)
+		return false, ""
+	}
+	if na.podLister == nil {
+		return false, ""
+	}
+	peers, err := na.podLister.Pods(pod.Namespace).List(sel)
+	if err != nil {
+		klog.V(4).InfoS("Domain check: listing peer pods failed", "pod", klog.KObj(pod), "err", err)
+		return false, ""
+	}
+	for _, peer := range peers {
+		if peer.UID == pod.UID || peer.Spec.NodeName == "" || peer.Status.Phase != v1.PodRunning {
+			continue
+		}
+		peerDom, ok := na.peerDomain(peer.Spec.NodeName, topo)
+		if !ok {
+			return true, "domain of peer pod " + peer.Name + " on node " + peer.Spec.NodeName + " is unknown"
+		}
+		if peerDom != domain {
+			return true, "node " + node.Name + " is in domain " + domain +
+				", peer pod " + peer.Name + " runs in " + peerDom
+		}
+	}
+	return false, ""
+}
+
+// domainStatus rejects (or shadow-logs) the node on a required-mode domain
+// violation; preferred-mode pods pass Filter and lose score instead.
+func (na *NetworkAware) domainStatus(pod *v1.Pod, node *v1.Node, topo *v1alpha1.NetworkTopology) *framework.Status {
+	if domainMode(pod) == requirements.ModePreferred {
+		return nil
+	}
+	violated, msg := na.domainViolation(pod, node, topo)
+	if !violated {
+		return nil
+	}
+	if na.shadowMode() {
+		shadowRejectionsTotal.WithLabelValues(domainReason).Inc()
+		klog.V(2).InfoS("Shadow mode: would reject node", "pod", klog.KObj(pod),
+			"node", node.Name, "reason", msg)
+		return nil
+	}
+	filterRejectionsTotal.WithLabelValues(domainReason).Inc()
+	na.blocked.record(pod, domainReason, msg)
+	return framework.NewStatus(framework.Unschedulable, msg)
+}
+
+// domainPenalty is the scoring arm of preferred-mode domain checks, charging
+// the standard preferred-violation penalty so violating nodes only win when
+// every node violates.
+func (na *NetworkAware) domainPenalty(pod *v1.Pod, node *v1.Node, topo *v1alpha1.NetworkTopology) int64 {
+	if domainMode(pod) != requirements.ModePreferred {
+		return 0
+	}
+	if violated, _ := na.domainViolation(pod, node, topo); violated {
+		return na.violationPenalty()
+	}
+	return 0
+}
+
+// hasDomainAnnotations reports whether the pod opted into domain checks,
+// sparing unannotated pods the topology fetch on the Filter fast path.
+func hasDomainAnnotations(pod *v1.Pod) bool {
+	return pod.Annotations[AnnotationDomain] != "" || pod.Annotations[AnnotationSameDomainAs] != ""
+}
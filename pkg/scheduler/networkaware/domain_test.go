@@ -0,0 +1,123 @@
+package networkaware
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// domainPlugin builds a plugin with islands enabled at 30ms over two tight
+// groups (a1+a2, b1+b2) joined by a slow WAN link, so the topology-derived
+// domains are "a1" and "b1".
+func domainPlugin(t *testing.T, extra *NetworkAwareArgs) *NetworkAware {
+	t.Helper()
+	topo := topologytesting.NewTopology().
+		WithLink("a1", "a2", "3ms", "900mbps").
+		WithLink("b1", "b2", "2ms", "900mbps").
+		WithLink("a1", "b1", "80ms", "90mbps").
+		Build()
+	args := extra
+	if args == nil {
+		args = &NetworkAwareArgs{}
+	}
+	threshold := "30ms"
+	args.IslandLatencyThreshold = &threshold
+	return newTestPlugin(t, args, topo)
+}
+
+func domainPeer(name, nodeName string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "default", UID: types.UID(name),
+			Labels: map[string]string{"app": "db"},
+		},
+		Spec:   v1.PodSpec{NodeName: nodeName},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}
+}
+
+func TestDomainAnnotationFilter(t *testing.T) {
+	na := domainPlugin(t, nil)
+	pod := podWithAnnotations(map[string]string{AnnotationDomain: "a1"})
+
+	// The controller-maintained node label wins where present.
+	if status := runFilter(t, na, pod, nodeWithLabels("x", map[string]string{requirements.LabelDomain: "a1"})); status != nil {
+		t.Errorf("labeled matching node rejected: %v", status)
+	}
+	status := runFilter(t, na, pod, nodeWithLabels("x", map[string]string{requirements.LabelDomain: "b1"}))
+	if status == nil || status.Code() != framework.Unschedulable {
+		t.Errorf("labeled mismatching node passed, status %v", status)
+	}
+
+	// Unlabeled nodes fall back to island membership from the topology.
+	if status := runFilter(t, na, pod, nodeWithLabels("a2", nil)); status != nil {
+		t.Errorf("island member rejected: %v", status)
+	}
+	if status := runFilter(t, na, pod, nodeWithLabels("b2", nil)); status == nil {
+		t.Error("node in the other island passed")
+	}
+
+	// No label and no topology data: required mode fails closed.
+	status = runFilter(t, na, pod, nodeWithLabels("stranger", nil))
+	if status == nil || !strings.Contains(status.Message(), "no known network domain") {
+		t.Errorf("domainless node status = %v, want unknown-domain rejection", status)
+	}
+}
+
+func TestDomainPreferredModePenalizesInstead(t *testing.T) {
+	na := domainPlugin(t, nil)
+	pod := podWithAnnotations(map[string]string{
+		AnnotationDomain:                       "a1",
+		requirements.AnnotationRequirementMode: "preferred",
+	})
+
+	// Preferred mode passes Filter everywhere, including domainless nodes.
+	for _, node := range []string{"b2", "stranger"} {
+		if status := runFilter(t, na, pod, nodeWithLabels(node, nil)); status != nil {
+			t.Errorf("preferred mode rejected %s: %v", node, status)
+		}
+	}
+	if got := na.domainPenalty(pod, nodeWithLabels("b2", nil), na.currentTopology(context.Background())); got != na.violationPenalty() {
+		t.Errorf("violating node penalty = %d, want %d", got, na.violationPenalty())
+	}
+	if got := na.domainPenalty(pod, nodeWithLabels("a2", nil), na.currentTopology(context.Background())); got != 0 {
+		t.Errorf("matching node penalty = %d, want 0", got)
+	}
+}
+
+func TestSameDomainAsFilter(t *testing.T) {
+	na := domainPlugin(t, nil)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(domainPeer("db-0", "b1")); err != nil {
+		t.Fatalf("seeding pod lister: %v", err)
+	}
+	na.podLister = corelisters.NewPodLister(indexer)
+
+	pod := podWithAnnotations(map[string]string{AnnotationSameDomainAs: "app=db"})
+	pod.Namespace = "default"
+
+	if status := runFilter(t, na, pod, nodeWithLabels("b2", nil)); status != nil {
+		t.Errorf("node sharing the peer's domain rejected: %v", status)
+	}
+	status := runFilter(t, na, pod, nodeWithLabels("a2", nil))
+	if status == nil || status.Code() != framework.Unschedulable {
+		t.Errorf("node in another domain passed, status %v", status)
+	}
+
+	// A selector matching nothing constrains nothing.
+	loner := podWithAnnotations(map[string]string{AnnotationSameDomainAs: "app=absent"})
+	loner.Namespace = "default"
+	if status := runFilter(t, na, loner, nodeWithLabels("a2", nil)); status != nil {
+		t.Errorf("selector without matches rejected the node: %v", status)
+	}
+}
@@ -0,0 +1,60 @@
+package networkaware
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+)
+
+// rejectInfeasible compares the pod's hard requirements against the best any
+// node in the cluster offers. When nothing could ever satisfy them — a
+// min-bandwidth of 10gbps on a cluster whose fastest link measures 950mbps —
+// rejecting once here beats burning a Filter call per node, and the message
+// tells the user which number to fix. The maxima come from the shared
+// per-snapshot index, so they refresh with every topology update at no
+// per-pod cost.
+func (na *NetworkAware) rejectInfeasible(ctx context.Context, pod *v1.Pod, reqs *requirements.Requirements) *framework.Status {
+	topo := na.currentTopology(ctx)
+	if topo == nil {
+		// Label fallback has no cluster-wide view; let Filter judge
+		// node by node.
+		return nil
+	}
+	ix := topology.Shared(topo)
+	msg := ""
+	if reqs.MinBandwidthMbps > 0 && reqs.Mode(AnnotationMinBandwidth) == requirements.ModeRequired {
+		if best, ok := ix.ClusterBestBandwidthMbps(); ok && reqs.MinBandwidthMbps > best {
+			msg = fmt.Sprintf("min-bandwidth %dmbps exceeds best available %dmbps", reqs.MinBandwidthMbps, best)
+		}
+	}
+	// Target-relative latency is judged per pair in Filter; the cluster's
+	// best link says nothing about any node's link to that one target.
+	if msg == "" && reqs.MaxLatencyMs > 0 && reqs.LatencyTarget == "" &&
+		reqs.Mode(AnnotationMaxLatency) == requirements.ModeRequired {
+		if best, ok := ix.ClusterBestLatencyMs(); ok && reqs.MaxLatencyMs < best {
+			msg = fmt.Sprintf("max-latency %dms is below the best available %dms", reqs.MaxLatencyMs, best)
+		}
+	}
+	if msg == "" {
+		return nil
+	}
+	if na.shadowMode() {
+		shadowRejectionsTotal.WithLabelValues("infeasible").Inc()
+		klog.V(2).InfoS("Shadow mode: would reject pod as infeasible", "pod", klog.KObj(pod), "reason", msg)
+		return nil
+	}
+	infeasibleRejectionsTotal.Inc()
+	na.blocked.record(pod, "infeasible", msg)
+	klog.V(2).InfoS("Rejecting pod: requirements exceed cluster maxima", "pod", klog.KObj(pod), "reason", msg)
+	if na.handle != nil {
+		na.handle.EventRecorder().Eventf(pod, nil, v1.EventTypeWarning,
+			"NetworkRequirementsInfeasible", "Scheduling", msg)
+	}
+	return framework.NewStatus(framework.UnschedulableAndUnresolvable, msg)
+}
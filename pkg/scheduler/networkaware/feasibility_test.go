@@ -0,0 +1,93 @@
+package networkaware
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+)
+
+func TestPreFilterRejectsInfeasibleRequirements(t *testing.T) {
+	// The cluster's best link is 900mbps at 5ms.
+	topo := topologytesting.NewTopology().
+		WithLink("fast", "peer", "5ms", "900mbps").
+		WithLink("slow", "peer", "120ms", "30mbps").
+		Build()
+	na := newTestPlugin(t, nil, topo)
+
+	tests := []struct {
+		name       string
+		ann        map[string]string
+		wantReject bool
+		wantIn     string
+	}{
+		{
+			name:       "bandwidth beyond the best link",
+			ann:        map[string]string{AnnotationMinBandwidth: "10gbps"},
+			wantReject: true,
+			wantIn:     "exceeds best available 900mbps",
+		},
+		{
+			name:       "latency below the best link",
+			ann:        map[string]string{AnnotationMaxLatency: "1ms"},
+			wantReject: true,
+			wantIn:     "below the best available 5ms",
+		},
+		{
+			name: "satisfiable somewhere passes PreFilter",
+			ann:  map[string]string{AnnotationMinBandwidth: "500mbps", AnnotationMaxLatency: "10ms"},
+		},
+		{
+			name: "preferred mode is never infeasible",
+			ann: map[string]string{
+				AnnotationMinBandwidth:    "10gbps",
+				AnnotationRequirementMode: "preferred",
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			state := framework.NewCycleState()
+			_, status := na.PreFilter(context.Background(), state, podWithAnnotations(tc.ann))
+			if !tc.wantReject {
+				if status != nil {
+					t.Fatalf("PreFilter = %v, want pass", status)
+				}
+				return
+			}
+			if status == nil || status.Code() != framework.UnschedulableAndUnresolvable {
+				t.Fatalf("PreFilter = %v, want UnschedulableAndUnresolvable", status)
+			}
+			if !strings.Contains(status.Message(), tc.wantIn) {
+				t.Errorf("message %q does not name the cluster maximum %q", status.Message(), tc.wantIn)
+			}
+		})
+	}
+}
+
+func TestInfeasibleChecksNeedATopology(t *testing.T) {
+	// Without a topology there is no cluster-wide view; the per-node Filter
+	// and its label fallback stay in charge.
+	na := newTestPlugin(t, nil, nil)
+	state := framework.NewCycleState()
+	pod := podWithAnnotations(map[string]string{AnnotationMinBandwidth: "10gbps"})
+	if _, status := na.PreFilter(context.Background(), state, pod); status != nil {
+		t.Fatalf("PreFilter without a topology = %v, want pass", status)
+	}
+}
+
+func TestInfeasibleShadowModeOnlyObserves(t *testing.T) {
+	topo := topologytesting.NewTopology().
+		WithLink("fast", "peer", "5ms", "900mbps").
+		Build()
+	shadow := true
+	na := newTestPlugin(t, &NetworkAwareArgs{ShadowMode: &shadow}, topo)
+	state := framework.NewCycleState()
+	pod := podWithAnnotations(map[string]string{AnnotationMinBandwidth: "10gbps"})
+	if _, status := na.PreFilter(context.Background(), state, pod); status != nil {
+		t.Fatalf("shadow mode PreFilter = %v, want pass", status)
+	}
+}
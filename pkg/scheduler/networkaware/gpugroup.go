@@ -0,0 +1,112 @@
+package networkaware
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// gpuDirectCapability marks nodes with an RDMA/NVLink-class interconnect.
+// Multi-pod jobs requiring it need the whole group on interconnected nodes,
+// not just each member on any capable node.
+const gpuDirectCapability = "gpu-direct"
+
+// Group scoring constants: the bonus must dominate the island spread penalty
+// (which otherwise scatters replicas) so gpu-direct groups pack, and the
+// latency budget defines "interconnected" for an RDMA-class fabric.
+const (
+	gpuGroupBonusMax        = 120
+	gpuGroupLatencyBudgetMs = 10
+)
+
+// gpuGroupBonus pulls members of a multi-pod gpu-direct job toward the
+// lowest-latency subset of capable nodes. Filtering is already per-member:
+// every pod in the group carries the capability annotation, so nodes lacking
+// gpu-direct are rejected for each of them. Scoring here handles the set:
+// a candidate earns up to gpuGroupBonusMax depending on its worst measured
+// latency toward the nodes already running siblings. Single-pod jobs have no
+// siblings and keep the plain preference-rule bonus.
+func (na *NetworkAware) gpuGroupBonus(pod *v1.Pod, node *v1.Node, topo *v1alpha1.NetworkTopology) int64 {
+	if topo == nil || na.podLister == nil || !requiresGPUDirect(pod) {
+		return 0
+	}
+	if !requirements.NodeHasCapability(node, topo, gpuDirectCapability) {
+		return 0
+	}
+	siblingNodes := na.gpuGroupSiblingNodes(pod)
+	if len(siblingNodes) == 0 {
+		return 0
+	}
+
+	// The candidate's reward tracks its worst link into the group: outside
+	// the interconnected subset the latency budget is blown and the bonus
+	// drops to zero.
+	var worst int64
+	for _, sibling := range siblingNodes {
+		lat, ok := groupLatencyMs(topo, node.Name, sibling)
+		if !ok || lat >= gpuGroupLatencyBudgetMs {
+			return 0
+		}
+		if lat > worst {
+			worst = lat
+		}
+	}
+	return int64((1 - float64(worst)/gpuGroupLatencyBudgetMs) * gpuGroupBonusMax)
+}
+
+// gpuGroupSiblingNodes lists the nodes already running sibling pods of the
+// same controller that also require gpu-direct.
+func (na *NetworkAware) gpuGroupSiblingNodes(pod *v1.Pod) []string {
+	owner := controllerOwnerUID(pod)
+	if owner == "" {
+		return nil
+	}
+	siblings, err := na.podLister.Pods(pod.Namespace).List(labels.Everything())
+	if err != nil {
+		klog.V(4).InfoS("GPU group: listing sibling pods failed", "pod", klog.KObj(pod), "err", err)
+		return nil
+	}
+	var nodes []string
+	for _, sibling := range siblings {
+		if sibling.UID == pod.UID || sibling.Spec.NodeName == "" {
+			continue
+		}
+		if controllerOwnerUID(sibling) != owner || !requiresGPUDirect(sibling) {
+			continue
+		}
+		nodes = append(nodes, sibling.Spec.NodeName)
+	}
+	return nodes
+}
+
+// requiresGPUDirect reports whether the pod's required capabilities include
+// gpu-direct.
+func requiresGPUDirect(pod *v1.Pod) bool {
+	for _, c := range strings.Split(pod.Annotations[AnnotationRequiredCapabilities], ",") {
+		if strings.TrimSpace(c) == gpuDirectCapability {
+			return true
+		}
+	}
+	return false
+}
+
+// groupLatencyMs returns the measured latency between two nodes, trying both
+// link directions.
+func groupLatencyMs(topo *v1alpha1.NetworkTopology, from, to string) (int64, bool) {
+	if spec, ok := topo.Spec.Nodes[from]; ok {
+		if lat, ok := requirements.ParseLatencyMs(spec.Latency[to]); ok {
+			return lat, true
+		}
+	}
+	if spec, ok := topo.Spec.Nodes[to]; ok {
+		if lat, ok := requirements.ParseLatencyMs(spec.Latency[from]); ok {
+			return lat, true
+		}
+	}
+	return 0, false
+}
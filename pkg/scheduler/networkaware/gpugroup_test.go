@@ -0,0 +1,79 @@
+package networkaware
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+)
+
+// groupMember builds a pod of the given gpu-direct job, optionally already
+// running on a node.
+func groupMember(name, nodeName string) *v1.Pod {
+	controller := true
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID(name),
+			Annotations: map[string]string{
+				AnnotationRequiredCapabilities: gpuDirectCapability,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "Job", Name: "train", UID: "job-uid", Controller: &controller},
+			},
+		},
+		Spec: v1.PodSpec{NodeName: nodeName},
+	}
+}
+
+func TestGPUGroupPrefersInterconnectedSubset(t *testing.T) {
+	// Five gpu-direct nodes; only g1-g3 share a low-latency interconnect.
+	b := topologytesting.NewTopology().
+		WithLink("g1", "g2", "1ms", "10000mbps").
+		WithLink("g1", "g3", "1ms", "10000mbps").
+		WithLink("g2", "g3", "1ms", "10000mbps").
+		WithLink("g4", "g1", "50ms", "1000mbps").
+		WithLink("g4", "g2", "50ms", "1000mbps").
+		WithLink("g4", "g3", "50ms", "1000mbps").
+		WithLink("g5", "g1", "50ms", "1000mbps").
+		WithLink("g5", "g2", "50ms", "1000mbps").
+		WithLink("g5", "g3", "50ms", "1000mbps").
+		WithLink("g4", "g5", "1ms", "10000mbps")
+	for _, n := range []string{"g1", "g2", "g3", "g4", "g5"} {
+		b = b.WithCapabilities(n, gpuDirectCapability)
+	}
+	topo := b.Build()
+
+	na := newTestPlugin(t, nil, topo)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, p := range []*v1.Pod{groupMember("train-0", "g1"), groupMember("train-1", "g2")} {
+		if err := indexer.Add(p); err != nil {
+			t.Fatalf("seeding pod lister: %v", err)
+		}
+	}
+	na.podLister = corelisters.NewPodLister(indexer)
+
+	pending := groupMember("train-2", "")
+	inSubset := na.gpuGroupBonus(pending, nodeWithLabels("g3", nil), topo)
+	outOfSubset := na.gpuGroupBonus(pending, nodeWithLabels("g4", nil), topo)
+	if inSubset <= outOfSubset {
+		t.Fatalf("interconnected node should outrank the distant one: g3=%d g4=%d", inSubset, outOfSubset)
+	}
+	if outOfSubset != 0 {
+		t.Errorf("node outside the latency budget scored %d, want 0", outOfSubset)
+	}
+
+	// A single-pod job has no siblings and gets no group bonus; the plain
+	// preference-rule bonus still applies through matchRules.
+	solo := groupMember("solo", "")
+	solo.OwnerReferences = nil
+	if got := na.gpuGroupBonus(solo, nodeWithLabels("g3", nil), topo); got != 0 {
+		t.Errorf("single-pod job got group bonus %d, want 0", got)
+	}
+}
@@ -0,0 +1,125 @@
+package networkaware
+
+import (
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// defaultIngressControllerSelector identifies the ingress-controller pods
+// whose nodes count as the cluster's ingress points. It matches the Traefik
+// deployment k3s bundles as well as ingress-nginx; both stacks label their
+// controller pods with app.kubernetes.io/name.
+const defaultIngressControllerSelector = "app.kubernetes.io/name in (traefik, ingress-nginx)"
+
+// ingressProximityMax is the maximum bonus for sitting on a cheap link toward
+// an ingress node. Every external request to an Ingress-exposed pod passes
+// through an ingress node first, so a poorly placed backend adds a full extra
+// WAN hop per request.
+const ingressProximityMax = 40
+
+// ingressProximityBonus rewards candidate nodes that are network-close to the
+// node(s) running the ingress controller, for pods referenced by an Ingress
+// backend Service. Pods not exposed through any Ingress score neutrally, as do
+// clusters where no ingress-controller pod can be located.
+func (na *NetworkAware) ingressProximityBonus(pod *v1.Pod, node *v1.Node, topo *v1alpha1.NetworkTopology) int64 {
+	if topo == nil || na.ingressLister == nil || na.serviceLister == nil || na.podLister == nil {
+		return 0
+	}
+	if !na.ingressExposed(pod) {
+		return 0
+	}
+	ingressNodes := na.ingressNodes()
+	if len(ingressNodes) == 0 {
+		return 0
+	}
+
+	// With several ingress nodes the cheapest one wins: traffic enters
+	// wherever the load balancer or DNS sends it, and the best case is what
+	// the candidate is rewarded for.
+	bestCost, found := 1.0, false
+	for _, ingress := range ingressNodes {
+		if ingress == node.Name {
+			bestCost, found = 0, true
+			break
+		}
+		if cost, ok := topologyCost(topo, node.Name, ingress); ok && (!found || cost < bestCost) {
+			bestCost, found = cost, true
+		}
+	}
+	if !found {
+		return 0
+	}
+	return int64((1 - bestCost) * ingressProximityMax)
+}
+
+// ingressExposed reports whether some Ingress in the pod's namespace names a
+// backend Service whose selector matches the pod.
+func (na *NetworkAware) ingressExposed(pod *v1.Pod) bool {
+	ingresses, err := na.ingressLister.Ingresses(pod.Namespace).List(labels.Everything())
+	if err != nil {
+		klog.V(4).InfoS("Ingress proximity: listing Ingresses failed", "pod", klog.KObj(pod), "err", err)
+		return false
+	}
+	podLabels := labels.Set(pod.Labels)
+	checked := map[string]bool{}
+	for _, ingress := range ingresses {
+		for _, name := range ingressBackendServices(ingress) {
+			if checked[name] {
+				continue
+			}
+			checked[name] = true
+			svc, err := na.serviceLister.Services(pod.Namespace).Get(name)
+			if err != nil || len(svc.Spec.Selector) == 0 {
+				continue
+			}
+			if labels.SelectorFromSet(svc.Spec.Selector).Matches(podLabels) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ingressNodes lists the nodes currently running an ingress-controller pod,
+// per the configured (or default) controller selector.
+func (na *NetworkAware) ingressNodes() []string {
+	pods, err := na.podLister.List(na.ingressSel)
+	if err != nil {
+		klog.V(4).InfoS("Ingress proximity: listing controller pods failed", "err", err)
+		return nil
+	}
+	var nodes []string
+	seen := map[string]bool{}
+	for _, p := range pods {
+		if p.Spec.NodeName == "" || p.Status.Phase != v1.PodRunning || seen[p.Spec.NodeName] {
+			continue
+		}
+		seen[p.Spec.NodeName] = true
+		nodes = append(nodes, p.Spec.NodeName)
+	}
+	return nodes
+}
+
+// ingressBackendServices collects the backend Service names an Ingress routes
+// to, across the default backend and every rule path.
+func ingressBackendServices(ingress *networkingv1.Ingress) []string {
+	var names []string
+	if ingress.Spec.DefaultBackend != nil && ingress.Spec.DefaultBackend.Service != nil {
+		names = append(names, ingress.Spec.DefaultBackend.Service.Name)
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service != nil {
+				names = append(names, path.Backend.Service.Name)
+			}
+		}
+	}
+	return names
+}
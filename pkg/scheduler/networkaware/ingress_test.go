@@ -0,0 +1,138 @@
+package networkaware
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	networkinglisters "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/client-go/tools/cache"
+
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+)
+
+// seedIngressListers fakes the informer chain behind ingress-proximity
+// scoring: a running controller pod per ingress node, a backend Service
+// selecting app=web, and an Ingress routing to it.
+func seedIngressListers(t *testing.T, na *NetworkAware, ingressNodes ...string) {
+	t.Helper()
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, node := range ingressNodes {
+		if err := pods.Add(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "traefik-" + node,
+				Namespace: "kube-system",
+				Labels:    map[string]string{"app.kubernetes.io/name": "traefik"},
+			},
+			Spec:   v1.PodSpec{NodeName: node},
+			Status: v1.PodStatus{Phase: v1.PodRunning},
+		}); err != nil {
+			t.Fatalf("seeding pod lister: %v", err)
+		}
+	}
+	na.podLister = corelisters.NewPodLister(pods)
+
+	services := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := services.Add(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       v1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+	}); err != nil {
+		t.Fatalf("seeding service lister: %v", err)
+	}
+	na.serviceLister = corelisters.NewServiceLister(services)
+
+	pathType := networkingv1.PathTypePrefix
+	ingresses := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := ingresses.Add(&networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: "app.example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{Name: "web"},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}); err != nil {
+		t.Fatalf("seeding ingress lister: %v", err)
+	}
+	na.ingressLister = networkinglisters.NewIngressLister(ingresses)
+}
+
+func webPod(app string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      app + "-0",
+		Namespace: "default",
+		Labels:    map[string]string{"app": app},
+	}}
+}
+
+func TestIngressProximityBonus(t *testing.T) {
+	topo := topologytesting.NewTopology().
+		WithLink("near", "edge", "2ms", "1000mbps").WithCost("near", "edge", 0.1).
+		WithLink("far", "edge", "40ms", "100mbps").WithCost("far", "edge", 0.8).
+		Build()
+	na := newTestPlugin(t, nil, topo)
+	seedIngressListers(t, na, "edge")
+
+	pod := webPod("web")
+	onIngress := na.ingressProximityBonus(pod, nodeWithLabels("edge", nil), topo)
+	near := na.ingressProximityBonus(pod, nodeWithLabels("near", nil), topo)
+	far := na.ingressProximityBonus(pod, nodeWithLabels("far", nil), topo)
+	if onIngress != ingressProximityMax {
+		t.Errorf("ingress node scored %d, want the full %d", onIngress, ingressProximityMax)
+	}
+	if near <= far {
+		t.Errorf("cheap link toward the ingress must outrank the expensive one: near=%d far=%d", near, far)
+	}
+
+	// Pods no Ingress routes to are unaffected.
+	if got := na.ingressProximityBonus(webPod("batch"), nodeWithLabels("near", nil), topo); got != 0 {
+		t.Errorf("unexposed pod got bonus %d, want 0", got)
+	}
+}
+
+func TestIngressProximityMultipleIngressNodes(t *testing.T) {
+	// Two ingress nodes; the candidate's cheaper link (toward edge-b) wins.
+	topo := topologytesting.NewTopology().
+		WithLink("near", "edge-a", "40ms", "100mbps").WithCost("near", "edge-a", 0.6).
+		WithLink("near", "edge-b", "2ms", "1000mbps").WithCost("near", "edge-b", 0.2).
+		Build()
+	na := newTestPlugin(t, nil, topo)
+	seedIngressListers(t, na, "edge-a", "edge-b")
+
+	got := na.ingressProximityBonus(webPod("web"), nodeWithLabels("near", nil), topo)
+	want := int64((1 - 0.2) * ingressProximityMax)
+	if got != want {
+		t.Errorf("bonus = %d, want %d (minimum cost across ingress nodes)", got, want)
+	}
+}
+
+func TestIngressControllerSelectorOverride(t *testing.T) {
+	topo := topologytesting.NewTopology().
+		WithLink("near", "edge", "2ms", "1000mbps").WithCost("near", "edge", 0.1).
+		Build()
+	selector := "app=custom-ingress"
+	na := newTestPlugin(t, &NetworkAwareArgs{IngressControllerSelector: &selector}, topo)
+	seedIngressListers(t, na, "edge")
+
+	// The default traefik pods no longer match, so no ingress node is found.
+	if got := na.ingressProximityBonus(webPod("web"), nodeWithLabels("near", nil), topo); got != 0 {
+		t.Errorf("overridden selector still matched default controller pods, bonus %d", got)
+	}
+
+	bad := "app=in valid=="
+	if _, err := newNetworkAware(&NetworkAwareArgs{IngressControllerSelector: &bad}, nil, staticTopology{topo: topo}); err == nil {
+		t.Error("malformed ingressControllerSelector accepted")
+	}
+}
@@ -0,0 +1,179 @@
+package networkaware
+
+import (
+	"sort"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// Penalty per already-placed replica of the same owner on the candidate's
+// island, and the cap keeping island spreading from overpowering the hard
+// capability and preference signals.
+const (
+	islandReplicaPenalty    = 25
+	islandReplicaPenaltyMax = 75
+)
+
+// islandIndex assigns each node to a connectivity island: the set of nodes
+// mutually reachable over links faster than the configured latency threshold.
+// Two nodes behind a single slow uplink land in different islands even when
+// they share a zone label. Recomputed only when the topology generation moves;
+// the mutex makes the check-and-recompute atomic under parallel scoring.
+type islandIndex struct {
+	thresholdMs int64
+
+	mu         sync.Mutex
+	generation string
+	islands    map[string]int
+}
+
+func newIslandIndex(thresholdMs int64) *islandIndex {
+	return &islandIndex{thresholdMs: thresholdMs}
+}
+
+// island returns the node's island ID, with ok=false when islands are
+// disabled or the node is not in the topology.
+func (ix *islandIndex) island(topo *v1alpha1.NetworkTopology, nodeName string) (int, bool) {
+	if ix.thresholdMs <= 0 || topo == nil {
+		return 0, false
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if gen := topologyGeneration(topo); gen != ix.generation {
+		ix.islands = computeIslands(topo, ix.thresholdMs)
+		ix.generation = gen
+	}
+	id, ok := ix.islands[nodeName]
+	return id, ok
+}
+
+// topologyGeneration identifies a topology revision cheaply so the island
+// partition is recomputed once per controller write, not once per Score call.
+func topologyGeneration(topo *v1alpha1.NetworkTopology) string {
+	if topo.Status.LastUpdated != nil {
+		return topo.ResourceVersion + "/" + topo.Status.LastUpdated.String()
+	}
+	return topo.ResourceVersion
+}
+
+// computeIslands partitions the topology's nodes with union-find, merging two
+// nodes when either direction of their link measures under the threshold.
+// Island IDs are assigned in sorted-name order so they are deterministic for
+// a given topology.
+func computeIslands(topo *v1alpha1.NetworkTopology, thresholdMs int64) map[string]int {
+	names := make([]string, 0, len(topo.Spec.Nodes))
+	for name := range topo.Spec.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parent := make(map[string]string, len(names))
+	for _, n := range names {
+		parent[n] = n
+	}
+	var find func(string) string
+	find = func(n string) string {
+		if parent[n] != n {
+			parent[n] = find(parent[n])
+		}
+		return parent[n]
+	}
+	union := func(a, b string) {
+		ra, rb := find(a), find(b)
+		if ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	for _, source := range names {
+		for target, latStr := range topo.Spec.Nodes[source].Latency {
+			if _, ok := parent[target]; !ok {
+				continue
+			}
+			if lat, ok := requirements.ParseLatencyMs(latStr); ok && lat < thresholdMs {
+				union(source, target)
+			}
+		}
+	}
+
+	islands := make(map[string]int, len(names))
+	nextID := 0
+	rootID := map[string]int{}
+	for _, n := range names {
+		root := find(n)
+		id, ok := rootID[root]
+		if !ok {
+			id = nextID
+			rootID[root] = id
+			nextID++
+		}
+		islands[n] = id
+	}
+	return islands
+}
+
+// Islands exposes the island partition for read-only tooling such as what-if
+// analysis. The scheduler itself goes through the cached islandIndex; nil is
+// returned when islands are disabled or there is no topology.
+func Islands(topo *v1alpha1.NetworkTopology, thresholdMs int64) map[string]int {
+	if topo == nil || thresholdMs <= 0 {
+		return nil
+	}
+	return computeIslands(topo, thresholdMs)
+}
+
+// islandSpreadPenalty discourages stacking replicas of one owner on a single
+// island. It counts already-assigned siblings (same controller owner) per
+// island via the pod lister and charges the candidate for each sibling on its
+// island, capped so spreading stays a preference. Pods without a controller,
+// and nodes outside the topology, score neutrally.
+func (na *NetworkAware) islandSpreadPenalty(pod *v1.Pod, node *v1.Node, topo *v1alpha1.NetworkTopology) int64 {
+	owner := controllerOwnerUID(pod)
+	if owner == "" || na.islands == nil || na.podLister == nil {
+		return 0
+	}
+	island, ok := na.islands.island(topo, node.Name)
+	if !ok {
+		return 0
+	}
+
+	siblings, err := na.podLister.Pods(pod.Namespace).List(labels.Everything())
+	if err != nil {
+		klog.V(4).InfoS("Island spread: listing sibling pods failed", "pod", klog.KObj(pod), "err", err)
+		return 0
+	}
+	var onIsland int64
+	for _, sibling := range siblings {
+		if sibling.UID == pod.UID || sibling.Spec.NodeName == "" {
+			continue
+		}
+		if controllerOwnerUID(sibling) != owner {
+			continue
+		}
+		if sibID, ok := na.islands.island(topo, sibling.Spec.NodeName); ok && sibID == island {
+			onIsland++
+		}
+	}
+	penalty := onIsland * islandReplicaPenalty
+	if penalty > islandReplicaPenaltyMax {
+		penalty = islandReplicaPenaltyMax
+	}
+	return penalty
+}
+
+// controllerOwnerUID returns the UID of the pod's controller owner (typically
+// a ReplicaSet), or "" for unowned pods.
+func controllerOwnerUID(pod *v1.Pod) string {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller {
+			return string(ref.UID)
+		}
+	}
+	return ""
+}
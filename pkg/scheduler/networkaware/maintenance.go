@@ -0,0 +1,61 @@
+package networkaware
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// Values of the maintenancePolicy arg.
+const (
+	maintenancePolicyFilter   = "filter"
+	maintenancePolicyPenalize = "penalize"
+)
+
+// maintenancePenalty outweighs every positive scoring component, mirroring
+// the preferred-violation penalty scale: a maintenance node only wins when
+// every node is under maintenance.
+const maintenancePenalty = 300
+
+// maintenanceReason is the blocked-report and metric label for maintenance
+// rejections.
+const maintenanceReason = "maintenance"
+
+func (na *NetworkAware) maintenancePolicyName() string {
+	if na.args.MaintenancePolicy != nil {
+		return *na.args.MaintenancePolicy
+	}
+	return maintenancePolicyFilter
+}
+
+// nodeUnderMaintenance checks both the live annotation and the frozen flag in
+// the topology, so the scheduler reacts even when one of the two sources lags
+// the other by a cycle.
+func nodeUnderMaintenance(node *v1.Node, topo *v1alpha1.NetworkTopology) bool {
+	if requirements.NodeInMaintenance(node) {
+		return true
+	}
+	spec, ok := requirements.TopologyNode(node, topo)
+	return ok && spec.Maintenance
+}
+
+// maintenanceStatus rejects (or shadow-logs) the node when the filter policy
+// applies and the node is under maintenance; nil otherwise.
+func (na *NetworkAware) maintenanceStatus(pod *v1.Pod, node *v1.Node, topo *v1alpha1.NetworkTopology) *framework.Status {
+	if na.maintenancePolicyName() != maintenancePolicyFilter || !nodeUnderMaintenance(node, topo) {
+		return nil
+	}
+	msg := "node " + node.Name + " is under network maintenance"
+	if na.shadowMode() {
+		shadowRejectionsTotal.WithLabelValues(maintenanceReason).Inc()
+		klog.V(2).InfoS("Shadow mode: would reject node", "pod", klog.KObj(pod),
+			"node", node.Name, "reason", msg)
+		return nil
+	}
+	filterRejectionsTotal.WithLabelValues(maintenanceReason).Inc()
+	na.blocked.record(pod, maintenanceReason, msg)
+	return framework.NewStatus(framework.Unschedulable, msg)
+}
@@ -0,0 +1,94 @@
+package networkaware
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+func maintenanceNode(name string) *v1.Node {
+	return &v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Annotations: map[string]string{requirements.AnnotationMaintenance: "true"},
+	}}
+}
+
+func TestMaintenanceFilterRejectsAnnotatedNode(t *testing.T) {
+	topo := topologytesting.NewTopology().
+		WithLink("worker", "peer", "5ms", "900mbps").
+		Build()
+	na := newTestPlugin(t, nil, topo)
+
+	node := maintenanceNode("worker")
+	// Pods without network annotations are kept off too.
+	if status := runFilter(t, na, podWithAnnotations(nil), node); status.Code() != framework.Unschedulable {
+		t.Fatalf("plain pod on maintenance node: got %v, want Unschedulable", status)
+	}
+	status := runFilter(t, na, podWithAnnotations(map[string]string{
+		AnnotationMinBandwidth: "10mbps",
+	}), node)
+	if status.Code() != framework.Unschedulable {
+		t.Fatalf("annotated pod on maintenance node: got %v, want Unschedulable", status)
+	}
+	if !strings.Contains(status.Message(), "maintenance") {
+		t.Errorf("rejection message %q does not mention maintenance", status.Message())
+	}
+}
+
+func TestMaintenanceFilterReadsTopologyFlag(t *testing.T) {
+	topo := topologytesting.NewTopology().
+		WithLink("worker", "peer", "5ms", "900mbps").
+		Build()
+	spec := topo.Spec.Nodes["worker"]
+	spec.Maintenance = true
+	topo.Spec.Nodes["worker"] = spec
+	na := newTestPlugin(t, nil, topo)
+
+	pod := podWithAnnotations(map[string]string{AnnotationMinBandwidth: "10mbps"})
+	node := nodeWithLabels("worker", nil)
+	if status := runFilter(t, na, pod, node); status.Code() != framework.Unschedulable {
+		t.Fatalf("frozen topology spec without annotation: got %v, want Unschedulable", status)
+	}
+}
+
+func TestMaintenanceShadowModePasses(t *testing.T) {
+	shadow := true
+	na := newTestPlugin(t, &NetworkAwareArgs{ShadowMode: &shadow}, topologytesting.NewTopology().
+		WithLink("worker", "peer", "5ms", "900mbps").
+		Build())
+	if status := runFilter(t, na, podWithAnnotations(nil), maintenanceNode("worker")); status != nil {
+		t.Fatalf("shadow mode rejected maintenance node: %v", status)
+	}
+}
+
+func TestMaintenancePenalizePolicy(t *testing.T) {
+	topo := topologytesting.NewTopology().
+		WithLink("worker", "peer", "5ms", "900mbps").
+		Build()
+	policy := maintenancePolicyPenalize
+	na := newTestPlugin(t, &NetworkAwareArgs{MaintenancePolicy: &policy}, topo)
+
+	node := maintenanceNode("worker")
+	if status := runFilter(t, na, podWithAnnotations(nil), node); status != nil {
+		t.Fatalf("penalize policy rejected in Filter: %v", status)
+	}
+	if !nodeUnderMaintenance(node, topo) {
+		t.Fatal("nodeUnderMaintenance = false for annotated node")
+	}
+	if nodeUnderMaintenance(nodeWithLabels("peer", nil), topo) {
+		t.Fatal("nodeUnderMaintenance = true for plain node")
+	}
+}
+
+func TestMaintenancePolicyValidated(t *testing.T) {
+	policy := "evacuate"
+	if _, err := newNetworkAware(&NetworkAwareArgs{MaintenancePolicy: &policy}, nil, staticTopology{}); err == nil {
+		t.Fatal("expected error for unknown maintenancePolicy")
+	}
+}
@@ -0,0 +1,135 @@
+package networkaware
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/version"
+)
+
+var (
+	filterRejectionsTotal = metrics.NewCounterVec(&metrics.CounterOpts{
+		Name:           "networkaware_filter_rejections_total",
+		Help:           "Number of nodes rejected by the NetworkAware filter, by reason.",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"reason"})
+
+	rawScore = metrics.NewHistogram(&metrics.HistogramOpts{
+		Name:           "networkaware_raw_score",
+		Help:           "Distribution of raw (pre-normalization) node scores.",
+		Buckets:        []float64{-200, -100, -50, 0, 25, 50, 75, 100, 150, 200},
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	normalizedScore = metrics.NewHistogram(&metrics.HistogramOpts{
+		Name:           "networkaware_normalized_score",
+		Help:           "Distribution of normalized node scores.",
+		Buckets:        []float64{0, 10, 25, 50, 75, 90, 100},
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	shadowRejectionsTotal = metrics.NewCounterVec(&metrics.CounterOpts{
+		Name:           "networkaware_shadow_rejections_total",
+		Help:           "Number of nodes the filter would have rejected while running in shadow mode, by reason.",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"reason"})
+
+	infeasibleRejectionsTotal = metrics.NewCounter(&metrics.CounterOpts{
+		Name:           "networkaware_infeasible_rejections_total",
+		Help:           "Number of pods rejected in PreFilter because their requirements exceed the cluster-wide maxima.",
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	topologyFetchErrorsTotal = metrics.NewCounter(&metrics.CounterOpts{
+		Name:           "networkaware_topology_fetch_errors_total",
+		Help:           "Number of failed NetworkTopology fetches.",
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	selectorConflictsTotal = metrics.NewCounter(&metrics.CounterOpts{
+		Name:           "networkaware_selector_conflicts_total",
+		Help:           "Number of pods whose nodeSelector/affinity and network requirements were diagnosed as mutually unsatisfiable.",
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	priorityBypassTotal = metrics.NewCounter(&metrics.CounterOpts{
+		Name:           "networkaware_priority_bypass_total",
+		Help:           "Number of scheduling cycles where pod priority downgraded hard network constraints to score penalties.",
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	scoreCacheHitsTotal = metrics.NewCounter(&metrics.CounterOpts{
+		Name:           "networkaware_score_cache_hits_total",
+		Help:           "Number of per-node base scores served from the scoring cache.",
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	scoreCacheMissesTotal = metrics.NewCounter(&metrics.CounterOpts{
+		Name:           "networkaware_score_cache_misses_total",
+		Help:           "Number of per-node base scores computed because the scoring cache had no usable entry.",
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	labelFallbackMode = metrics.NewGauge(&metrics.GaugeOpts{
+		Name:           "networkaware_label_fallback_mode",
+		Help:           "1 when the plugin is scoring from node labels, 0 when topology data is in use.",
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	topologyEntriesIgnored = metrics.NewGauge(&metrics.GaugeOpts{
+		Name:           "networkaware_topology_entries_ignored",
+		Help:           "Number of measurement entries in the current topology snapshot dropped by the index's per-node size bounds. Non-zero means the object is oversized or corrupted.",
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	timeBudgetExceededTotal = metrics.NewCounter(&metrics.CounterOpts{
+		Name:           "networkaware_time_budget_exceeded_total",
+		Help:           "Number of Score calls degraded to label scoring because the pod's time budget was already spent.",
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	extensionDuration = metrics.NewHistogramVec(&metrics.HistogramOpts{
+		Name:           "networkaware_extension_duration_seconds",
+		Help:           "Time spent per invocation of each NetworkAware extension point.",
+		Buckets:        metrics.ExponentialBuckets(0.00001, 4, 10),
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"extension_point"})
+
+	buildInfo = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Name:           "networkaware_build_info",
+		Help:           "Constant 1, labeled with the plugin's git SHA and build date.",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"gitSHA", "buildDate"})
+)
+
+var registerMetricsOnce sync.Once
+
+// registerMetrics registers the plugin metrics with the scheduler's registry.
+// Guarded by sync.Once so a second profile enabling the plugin (or a second
+// New call in tests) cannot trigger a duplicate-registration panic.
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(
+			filterRejectionsTotal,
+			shadowRejectionsTotal,
+			infeasibleRejectionsTotal,
+			selectorConflictsTotal,
+			rawScore,
+			normalizedScore,
+			topologyFetchErrorsTotal,
+			topologyBreakerTripsTotal,
+			priorityBypassTotal,
+			scoreCacheHitsTotal,
+			scoreCacheMissesTotal,
+			labelFallbackMode,
+			topologyEntriesIgnored,
+			timeBudgetExceededTotal,
+			topologyStaleTotal,
+			extensionDuration,
+			buildInfo,
+		)
+		buildInfo.WithLabelValues(version.GitSHA, version.BuildDate).Set(1)
+	})
+}
@@ -0,0 +1,128 @@
+package networkaware
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+)
+
+// AnnotationDirectPathRequired set to "true" makes Filter reject candidate
+// nodes whose relevant links are relayed through DERP rather than direct.
+// Links without recorded path information pass: the annotation demands
+// "not known to be relayed", because refusing to schedule on ICMP-probed
+// links would punish clusters that never record paths at all.
+const AnnotationDirectPathRequired = annotationPrefix + "direct-path-required"
+
+// relayedPathPenalty is the default score handicap for candidates whose
+// relevant links are relayed: a DERP round-trip through a regional relay
+// costs tens of milliseconds over the direct path, but relays also heal,
+// so this stays a preference rather than a hard bar.
+const relayedPathPenalty = 30
+
+// directPathReason is the blocked-report and metric label for relayed-path
+// rejections.
+const directPathReason = "relayed-path"
+
+// linkPathRelayed classifies one directed link's recorded path. known is
+// false when the topology has no explicit link or the link carries no Path
+// (ICMP-probed edges, maps-shape topologies).
+func linkPathRelayed(topo *v1alpha1.NetworkTopology, source, target string) (relayed, known bool) {
+	link, ok := topology.Shared(topo).Link(source, target)
+	if !ok || link.Path == "" {
+		return false, false
+	}
+	return strings.HasPrefix(link.Path, "derp-"), true
+}
+
+// pathPeers resolves the nodes the pod has declared it will talk to,
+// reusing the existing placement-hint resolutions: gpu-group siblings and
+// running colocate-group members. Empty when the pod declares neither, in
+// which case path checks judge the candidate's best link instead.
+func (na *NetworkAware) pathPeers(pod *v1.Pod) []string {
+	if na.podLister == nil {
+		return nil
+	}
+	peers := na.gpuGroupSiblingNodes(pod)
+	if group := pod.Labels[LabelColocateGroup]; group != "" {
+		members, err := na.podLister.List(labels.SelectorFromSet(labels.Set{LabelColocateGroup: group}))
+		if err != nil {
+			klog.V(4).InfoS("Path check: listing colocate members failed", "pod", klog.KObj(pod), "err", err)
+		}
+		for _, member := range members {
+			if member.UID != pod.UID && member.Spec.NodeName != "" {
+				peers = append(peers, member.Spec.NodeName)
+			}
+		}
+	}
+	return peers
+}
+
+// nodeRelayed reports whether the candidate's relevant links are relayed:
+// with declared peers, any known-relayed link toward a peer counts;
+// without peers the candidate's lowest-latency link stands in for "the
+// traffic this node will carry". Unknown paths never count as relayed.
+func (na *NetworkAware) nodeRelayed(pod *v1.Pod, node *v1.Node, topo *v1alpha1.NetworkTopology) bool {
+	if topo == nil {
+		return false
+	}
+	if peers := na.pathPeers(pod); len(peers) > 0 {
+		for _, peer := range peers {
+			if relayed, known := linkPathRelayed(topo, node.Name, peer); known && relayed {
+				return true
+			}
+		}
+		return false
+	}
+	best, ok := bestLatencyTarget(topo, node.Name)
+	if !ok {
+		return false
+	}
+	relayed, known := linkPathRelayed(topo, node.Name, best)
+	return known && relayed
+}
+
+// bestLatencyTarget returns the target of the node's lowest-latency
+// outgoing link.
+func bestLatencyTarget(topo *v1alpha1.NetworkTopology, nodeName string) (string, bool) {
+	spec, ok := topology.Shared(topo).Node(nodeName)
+	if !ok {
+		return "", false
+	}
+	best, bestMs, found := "", int64(0), false
+	for target, value := range spec.Latency {
+		ms, ok := requirements.ParseLatencyMs(value)
+		if !ok {
+			continue
+		}
+		if !found || ms < bestMs || (ms == bestMs && target < best) {
+			best, bestMs, found = target, ms, true
+		}
+	}
+	return best, found
+}
+
+// directPathStatus rejects (or shadow-logs) the node when the pod requires
+// direct paths and the candidate's relevant links are relayed; nil
+// otherwise.
+func (na *NetworkAware) directPathStatus(pod *v1.Pod, node *v1.Node, topo *v1alpha1.NetworkTopology) *framework.Status {
+	if pod.Annotations[AnnotationDirectPathRequired] != "true" || !na.nodeRelayed(pod, node, topo) {
+		return nil
+	}
+	msg := "links from node " + node.Name + " are relayed, pod requires direct paths"
+	if na.shadowMode() {
+		shadowRejectionsTotal.WithLabelValues(directPathReason).Inc()
+		klog.V(2).InfoS("Shadow mode: would reject node", "pod", klog.KObj(pod),
+			"node", node.Name, "reason", msg)
+		return nil
+	}
+	filterRejectionsTotal.WithLabelValues(directPathReason).Inc()
+	na.blocked.record(pod, directPathReason, msg)
+	return framework.NewStatus(framework.Unschedulable, msg)
+}
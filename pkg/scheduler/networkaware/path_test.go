@@ -0,0 +1,108 @@
+package networkaware
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// pathTopology mixes direct, relayed and path-less edges in the links
+// shape, where per-edge Path metadata lives. The three candidates toward
+// hub carry identical measurements so only the path differs; mixed has a
+// direct best link plus a slower relayed one toward far.
+func pathTopology() *v1alpha1.NetworkTopology {
+	now := metav1.Now()
+	return &v1alpha1.NetworkTopology{Status: v1alpha1.NetworkTopologyStatus{LastUpdated: &now}, Spec: v1alpha1.NetworkTopologySpec{
+		Nodes: map[string]v1alpha1.NodeSpec{
+			"via-direct": {}, "via-relay": {}, "via-unknown": {}, "mixed": {}, "hub": {}, "far": {},
+		},
+		Links: []v1alpha1.Link{
+			{Source: "via-direct", Target: "hub", Latency: "5ms", Bandwidth: "900mbps", Cost: 0.1, Path: "direct"},
+			{Source: "via-relay", Target: "hub", Latency: "5ms", Bandwidth: "900mbps", Cost: 0.1, Path: "derp-fra"},
+			{Source: "via-unknown", Target: "hub", Latency: "5ms", Bandwidth: "900mbps", Cost: 0.1},
+			{Source: "mixed", Target: "hub", Latency: "5ms", Bandwidth: "900mbps", Cost: 0.1, Path: "direct"},
+			{Source: "mixed", Target: "far", Latency: "50ms", Bandwidth: "100mbps", Cost: 0.5, Path: "derp-nyc"},
+		},
+	}}
+}
+
+func TestDirectPathRequiredFilter(t *testing.T) {
+	na := newTestPlugin(t, nil, pathTopology())
+	pod := podWithAnnotations(map[string]string{AnnotationDirectPathRequired: "true"})
+
+	status := runFilter(t, na, pod, nodeWithLabels("via-relay", nil))
+	if status == nil || status.Code() != framework.Unschedulable {
+		t.Fatalf("relayed node passed, status %v", status)
+	}
+	if !strings.Contains(status.Message(), "relayed") {
+		t.Errorf("rejection %q does not name the relayed path", status.Message())
+	}
+	if status := runFilter(t, na, pod, nodeWithLabels("via-direct", nil)); status != nil {
+		t.Errorf("direct node rejected: %v", status)
+	}
+	// Links without Path information are unknown and pass.
+	if status := runFilter(t, na, pod, nodeWithLabels("via-unknown", nil)); status != nil {
+		t.Errorf("path-less node rejected: %v", status)
+	}
+	// Without the annotation the relayed node stays schedulable.
+	plain := podWithAnnotations(map[string]string{AnnotationMinBandwidth: "500mbps"})
+	if status := runFilter(t, na, plain, nodeWithLabels("via-relay", nil)); status != nil {
+		t.Errorf("relayed node rejected without the annotation: %v", status)
+	}
+}
+
+func TestDirectPathRequiredShadowMode(t *testing.T) {
+	shadow := true
+	na := newTestPlugin(t, &NetworkAwareArgs{ShadowMode: &shadow}, pathTopology())
+	pod := podWithAnnotations(map[string]string{AnnotationDirectPathRequired: "true"})
+	if status := runFilter(t, na, pod, nodeWithLabels("via-relay", nil)); status != nil {
+		t.Fatalf("shadow mode must pass the relayed node, got %v", status)
+	}
+}
+
+func TestNodeRelayedBestLink(t *testing.T) {
+	topo := pathTopology()
+	na := newTestPlugin(t, nil, topo)
+	pod := podWithAnnotations(nil)
+
+	if !na.nodeRelayed(pod, nodeWithLabels("via-relay", nil), topo) {
+		t.Error("via-relay's only link is relayed; nodeRelayed = false")
+	}
+	if na.nodeRelayed(pod, nodeWithLabels("via-direct", nil), topo) {
+		t.Error("via-direct's only link is direct; nodeRelayed = true")
+	}
+	if na.nodeRelayed(pod, nodeWithLabels("via-unknown", nil), topo) {
+		t.Error("links without path information must not count as relayed")
+	}
+	// Without declared peers only the best (lowest-latency) link is
+	// judged; mixed's relayed link toward far is not it.
+	if na.nodeRelayed(pod, nodeWithLabels("mixed", nil), topo) {
+		t.Error("mixed's best link is direct; the slower relayed edge must not count")
+	}
+}
+
+func TestNodeRelayedDeclaredPeers(t *testing.T) {
+	topo := pathTopology()
+	na := newTestPlugin(t, nil, topo)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(colocateMember("peer-0", "default", "chatty", "far")); err != nil {
+		t.Fatalf("seeding pod lister: %v", err)
+	}
+	na.podLister = corelisters.NewPodLister(indexer)
+
+	// The colocate member sits on far, so mixed's relayed edge toward it
+	// becomes the relevant link despite the direct best link.
+	pod := colocateMember("peer-1", "default", "chatty", "")
+	if !na.nodeRelayed(pod, nodeWithLabels("mixed", nil), topo) {
+		t.Error("relayed link toward a declared peer must count")
+	}
+	if na.nodeRelayed(pod, nodeWithLabels("via-direct", nil), topo) {
+		t.Error("candidate without a known-relayed link toward the peer must pass")
+	}
+}
@@ -0,0 +1,515 @@
+// Package networkaware implements a scheduler plugin that filters and scores
+// nodes using the network topology measured by the topology controller. Pods
+// opt in with network.komarov.dev annotations; when no topology object is
+// available the plugin falls back to node labels.
+package networkaware
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	networkinglisters "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/export"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/features"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+)
+
+// Name is the plugin name used in the scheduler configuration.
+const Name = "NetworkAware"
+
+// Annotation keys understood by the plugin, re-exported from the shared
+// requirements package so scheduler configs and docs can keep referencing
+// them here.
+const (
+	annotationPrefix = requirements.AnnotationPrefix
+
+	AnnotationMinBandwidth         = requirements.AnnotationMinBandwidth
+	AnnotationMinBandwidthInit     = requirements.AnnotationMinBandwidthInit
+	AnnotationMaxLatency           = requirements.AnnotationMaxLatency
+	AnnotationMaxLatencyTo         = requirements.AnnotationMaxLatencyTo
+	AnnotationMaxLatencyP99        = requirements.AnnotationMaxLatencyP99
+	AnnotationMaxCost              = requirements.AnnotationMaxCost
+	AnnotationMaxCostTo            = requirements.AnnotationMaxCostTo
+	AnnotationDataLocality         = requirements.AnnotationDataLocality
+	AnnotationRequiredCapabilities = requirements.AnnotationRequiredCapabilities
+	AnnotationInternetRequired     = requirements.AnnotationInternetRequired
+	AnnotationAvoidMetered         = requirements.AnnotationAvoidMetered
+	AnnotationMinNodeHealth        = requirements.AnnotationMinNodeHealth
+	AnnotationMaxUtilization       = requirements.AnnotationMaxUtilization
+	AnnotationRequirementMode      = requirements.AnnotationRequirementMode
+	AnnotationClass                = requirements.AnnotationClass
+	AnnotationDefaultClass         = requirements.AnnotationDefaultClass
+)
+
+// Node and pod label keys the plugin consults. The fallback labels shared
+// with the requirements package are re-exported for the same reason as the
+// annotations.
+const (
+	LabelWorkloadType = "workload-type"
+
+	LabelRole          = requirements.LabelRole
+	LabelZone          = requirements.LabelZone
+	LabelGPU           = requirements.LabelGPU
+	LabelNodeBandwidth = requirements.LabelNodeBandwidth
+	LabelNodeLatency   = requirements.LabelNodeLatency
+)
+
+const (
+	// topologyName is the name of the singleton NetworkTopology object.
+	topologyName = "cluster"
+	// preFilterStateKey stores parsed pod requirements in CycleState.
+	preFilterStateKey = "PreFilter" + Name
+)
+
+// topologyGetter abstracts access to the NetworkTopology object so tests can
+// substitute a fixture.
+type topologyGetter interface {
+	Get(ctx context.Context) (*v1alpha1.NetworkTopology, error)
+}
+
+// NetworkAware implements PreFilter, Filter and Score.
+//
+// Concurrency: one instance serves every parallel scheduling cycle plus the
+// informer callbacks. The fields assembled in newNetworkAware are read-only
+// after construction; all mutable state lives in the ledger, trackers and
+// caches below, each of which guards itself. Topology objects are treated as
+// immutable snapshots shared by pointer — the observed snapshot is swapped
+// atomically, never mutated in place.
+type NetworkAware struct {
+	handle framework.Handle
+	args   *NetworkAwareArgs
+	rules  []compiledRule
+	stale  *staleTracker
+	policy requirements.UnknownPolicy
+	keys   requirements.LabelKeys
+	// ingressSel selects the ingress-controller pods whose nodes anchor
+	// ingress-proximity scoring.
+	ingressSel labels.Selector
+	breaker    *fetchBreaker
+	ledger     *bandwidthLedger
+	// rejections feeds the pod-deletion queueing hint; observed is the
+	// last usable topology snapshot, kept for informer callbacks that run
+	// outside a scheduling cycle.
+	rejections *bandwidthRejections
+	observed   atomic.Pointer[v1alpha1.NetworkTopology]
+	// blocked feeds the NetworkSchedulingReport; reports writes it, and is
+	// nil in tests so rejections are tracked but never flushed.
+	blocked *blockedTracker
+	reports *reportClient
+	// scores memoizes per-node base scores across the pods of a burst.
+	scores *scoreCache
+	// export, when set, records one decision row per bound pod for
+	// offline analysis.
+	export *export.Sink
+
+	topologies      topologyGetter
+	classes         classGetter
+	quotas          quotaGetter
+	serviceLister   corelisters.ServiceLister
+	podLister       corelisters.PodLister
+	pvcLister       corelisters.PersistentVolumeClaimLister
+	namespaceLister corelisters.NamespaceLister
+	nodeLister      corelisters.NodeLister
+	ingressLister   networkinglisters.IngressLister
+	islands         *islandIndex
+	domains         *domainIndex
+}
+
+var (
+	_ framework.PreFilterPlugin = &NetworkAware{}
+	_ framework.FilterPlugin    = &NetworkAware{}
+	_ framework.ScorePlugin     = &NetworkAware{}
+	_ framework.PostBindPlugin  = &NetworkAware{}
+)
+
+// New creates the plugin from its configured args. It only assembles the
+// real-cluster dependencies; everything else lives in newNetworkAware so
+// tests can inject a topology fixture instead.
+func New(_ context.Context, obj runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+	args := &NetworkAwareArgs{}
+	if obj != nil {
+		if err := frameworkruntime.DecodeInto(obj, args); err != nil {
+			return nil, fmt.Errorf("decoding %s args: %w", Name, err)
+		}
+	}
+
+	config := handle.KubeConfig()
+	if config == nil {
+		var err error
+		if config, err = rest.InClusterConfig(); err != nil {
+			return nil, fmt.Errorf("building topology client config: %w", err)
+		}
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("building topology client: %w", err)
+	}
+
+	registerMetrics()
+	na, err := newNetworkAware(args, handle, &topologyClient{client: dyn})
+	if err != nil {
+		return nil, err
+	}
+	na.classes = newClassClient(dyn)
+	na.quotas = newQuotaClient(dyn)
+	na.enableBlockedReport(dyn)
+	return na, nil
+}
+
+// newNetworkAware wires the plugin from its dependencies. handle may be nil
+// in tests, which leaves the lister-backed scoring terms inert.
+func newNetworkAware(args *NetworkAwareArgs, handle framework.Handle, topologies topologyGetter) (*NetworkAware, error) {
+	rules, policy, err := validateArgs(args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s args: %w", Name, err)
+	}
+	var maxAge time.Duration
+	if args.MaxTopologyAge != nil {
+		maxAge = args.MaxTopologyAge.Duration
+	}
+	islandMs, err := islandThresholdMs(args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s args: %w", Name, err)
+	}
+	ingressSel, err := ingressSelectorFor(args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s args: %w", Name, err)
+	}
+
+	na := &NetworkAware{
+		handle:     handle,
+		args:       args,
+		rules:      rules,
+		policy:     policy,
+		keys:       labelKeysFor(args),
+		ingressSel: ingressSel,
+		stale:      newStaleTracker(maxAge),
+		breaker:    newFetchBreaker(),
+		ledger:     newBandwidthLedger(),
+		rejections: newBandwidthRejections(),
+		blocked:    newBlockedTracker(),
+		scores:     newScoreCache(),
+		topologies: topologies,
+		islands:    newIslandIndex(islandMs),
+		domains:    newDomainIndex(islandMs),
+	}
+	if args.ExportFile != nil {
+		na.export = export.NewSink(*args.ExportFile, 64, 0)
+	}
+	if args.BandwidthBlendHorizon != nil && args.BandwidthBlendHorizon.Duration > 0 {
+		// Set once here, before any scheduling cycle runs; every bandwidth
+		// read through the requirements helpers sees the blended values.
+		topology.DefaultBlend = topology.BlendPolicy{Horizon: args.BandwidthBlendHorizon.Duration}
+	}
+	if handle != nil {
+		na.serviceLister = handle.SharedInformerFactory().Core().V1().Services().Lister()
+		na.podLister = handle.SharedInformerFactory().Core().V1().Pods().Lister()
+		na.pvcLister = handle.SharedInformerFactory().Core().V1().PersistentVolumeClaims().Lister()
+		na.namespaceLister = handle.SharedInformerFactory().Core().V1().Namespaces().Lister()
+		na.nodeLister = handle.SharedInformerFactory().Core().V1().Nodes().Lister()
+		na.ingressLister = handle.SharedInformerFactory().Networking().V1().Ingresses().Lister()
+		na.registerPodHandlers(handle.SharedInformerFactory().Core().V1().Pods().Informer())
+	}
+	return na, nil
+}
+
+// currentTopology fetches the topology and applies the circuit breaker and
+// staleness policies, returning nil when callers should use label fallback.
+func (na *NetworkAware) currentTopology(ctx context.Context) *v1alpha1.NetworkTopology {
+	if na.breaker != nil && !na.breaker.allow() {
+		labelFallbackMode.Set(1)
+		return nil
+	}
+	topo, err := na.topologies.Get(ctx)
+	if err != nil {
+		if na.breaker != nil {
+			na.breaker.onFailure()
+		}
+		topologyFetchErrorsTotal.Inc()
+		labelFallbackMode.Set(1)
+		return nil
+	}
+	if na.breaker != nil {
+		na.breaker.onSuccess()
+	}
+	if usable := na.stale.usable(topo); usable != nil {
+		labelFallbackMode.Set(0)
+		topologyEntriesIgnored.Set(float64(topology.Shared(usable).Ignored()))
+		na.rememberTopology(usable)
+		return usable
+	}
+	labelFallbackMode.Set(1)
+	return nil
+}
+
+// Name returns the plugin name.
+func (na *NetworkAware) Name() string { return Name }
+
+// preFilterState carries the parsed requirements through CycleState, with
+// their fingerprint computed once per cycle for the scoring cache and the
+// cycle's start time for the optional pod time budget.
+type preFilterState struct {
+	reqs        *requirements.Requirements
+	fingerprint string
+	started     time.Time
+	// pluginNanos accumulates the time this pod's cycle has spent inside
+	// the plugin across all extension points; a pointer updated atomically
+	// because Score runs in parallel across nodes.
+	pluginNanos *int64
+}
+
+// Clone implements framework.StateData.
+func (s *preFilterState) Clone() framework.StateData { return s }
+
+// PreFilter parses the pod's network annotations once per scheduling cycle.
+// Malformed annotation values reject the pod outright: a typo in
+// min-bandwidth should surface as an error, not silently schedule anywhere.
+func (na *NetworkAware) PreFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod) (*framework.PreFilterResult, *framework.Status) {
+	ctx, span := startSpan(ctx, "networkaware.PreFilter",
+		attribute.String("pod", pod.Namespace+"/"+pod.Name))
+	defer span.End()
+	defer na.observeExtension(state, extPointPreFilter, time.Now())
+	reqs, err := requirements.Parse(pod)
+	if err != nil {
+		return nil, framework.NewStatus(framework.UnschedulableAndUnresolvable, err.Error())
+	}
+	if err := na.applyClass(ctx, pod, reqs); err != nil {
+		return nil, framework.NewStatus(framework.UnschedulableAndUnresolvable, err.Error())
+	}
+	if reqs.MinNodeHealth == 0 && na.args.MinNodeHealth != nil {
+		reqs.MinNodeHealth = *na.args.MinNodeHealth
+	}
+	if reqs.InitBandwidthMbps > 0 {
+		// The cycle enforces the pod's peak need across phases: a node that
+		// can serve the runtime requirement but not the init download must
+		// not pass Filter either. The informer drops the reservation back to
+		// the runtime figure once the pod is Running.
+		reqs.MinBandwidthMbps = reqs.PeakBandwidthMbps(na.initBandwidthWeight())
+	}
+	if !reqs.Empty() && na.bypassesPriority(pod) {
+		downgradeToPreferred(reqs)
+		priorityBypassTotal.Inc()
+		klog.V(2).InfoS("Priority bypass: downgrading hard network constraints to score penalties",
+			"pod", klog.KObj(pod), "priorityClass", pod.Spec.PriorityClassName)
+	}
+	if !reqs.Empty() {
+		if status := na.rejectInfeasible(ctx, pod, reqs); status != nil {
+			return nil, status
+		}
+		na.warnSelectorConflict(ctx, pod, reqs)
+	}
+	state.Write(preFilterStateKey, &preFilterState{
+		reqs: reqs, fingerprint: requirementsFingerprint(reqs),
+		started: time.Now(), pluginNanos: new(int64),
+	})
+	return nil, nil
+}
+
+// bypassesPriority reports whether the pod is important enough that the
+// network Filter must never block it. System-critical classes always bypass;
+// other pods bypass at or above the configured priority threshold.
+func (na *NetworkAware) bypassesPriority(pod *v1.Pod) bool {
+	switch pod.Spec.PriorityClassName {
+	case "system-cluster-critical", "system-node-critical":
+		return true
+	}
+	return na.args.BypassPriorityThreshold != nil &&
+		pod.Spec.Priority != nil && *pod.Spec.Priority >= *na.args.BypassPriorityThreshold
+}
+
+// downgradeToPreferred rewrites every requirement's mode so violations
+// penalize scoring instead of rejecting nodes; the pod then lands on the
+// least-bad node even when all nodes violate.
+func downgradeToPreferred(reqs *requirements.Requirements) {
+	if reqs.Modes == nil {
+		reqs.Modes = map[string]requirements.Mode{}
+	}
+	for _, key := range []string{
+		AnnotationMinBandwidth, AnnotationMaxLatency, AnnotationMaxLatencyP99, AnnotationMaxCost,
+		AnnotationDataLocality, AnnotationRequiredCapabilities, AnnotationInternetRequired, AnnotationAvoidMetered,
+		AnnotationMinNodeHealth, AnnotationMaxUtilization,
+	} {
+		reqs.Modes[key] = requirements.ModePreferred
+	}
+}
+
+// PreFilterExtensions returns nil; the plugin does not support incremental updates.
+func (na *NetworkAware) PreFilterExtensions() framework.PreFilterExtensions { return nil }
+
+func requirementsFromState(state *framework.CycleState) *requirements.Requirements {
+	reqs, _ := stateFromCycle(state)
+	return reqs
+}
+
+func stateFromCycle(state *framework.CycleState) (*requirements.Requirements, string) {
+	data, err := state.Read(preFilterStateKey)
+	if err != nil {
+		return &requirements.Requirements{}, ""
+	}
+	s, ok := data.(*preFilterState)
+	if !ok {
+		return &requirements.Requirements{}, ""
+	}
+	return s.reqs, s.fingerprint
+}
+
+// budgetSpent reports whether the pod's configured time budget has elapsed
+// since PreFilter, in which case the remainder of its cycle scores from node
+// labels instead of the topology.
+func (na *NetworkAware) budgetSpent(state *framework.CycleState) bool {
+	if na.args.PodTimeBudget == nil || na.args.PodTimeBudget.Duration <= 0 {
+		return false
+	}
+	data, err := state.Read(preFilterStateKey)
+	if err != nil {
+		return false
+	}
+	s, ok := data.(*preFilterState)
+	if !ok || s.started.IsZero() {
+		return false
+	}
+	return time.Since(s.started) > na.args.PodTimeBudget.Duration
+}
+
+// Filter rejects nodes that cannot satisfy the pod's hard network requirements.
+func (na *NetworkAware) Filter(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	defer na.observeExtension(state, extPointFilter, time.Now())
+	reqs := requirementsFromState(state)
+	node := nodeInfo.Node()
+	if node == nil {
+		return framework.NewStatus(framework.Error, "node not found")
+	}
+	if reqs.Empty() {
+		// Direct-path enforcement is its own annotation, outside the
+		// requirement set, so it must be checked even here; the topology
+		// fetch is only paid when the pod asks for it.
+		if pod.Annotations[AnnotationDirectPathRequired] == "true" {
+			if status := na.directPathStatus(pod, node, na.currentTopology(ctx)); status != nil {
+				return status
+			}
+		}
+		// Domain pinning is likewise annotation-driven.
+		if hasDomainAnnotations(pod) {
+			if status := na.domainStatus(pod, node, na.currentTopology(ctx)); status != nil {
+				return status
+			}
+		}
+		// Maintenance applies to every pod, not just annotated ones; the
+		// node annotation alone decides here to spare a topology fetch.
+		return na.maintenanceStatus(pod, node, nil)
+	}
+	ctx, span := startSpan(ctx, "networkaware.Filter", attribute.String("node", node.Name))
+	defer span.End()
+
+	topo := na.currentTopology(ctx)
+	if status := na.maintenanceStatus(pod, node, topo); status != nil {
+		return status
+	}
+	if status := na.directPathStatus(pod, node, topo); status != nil {
+		return status
+	}
+	if status := na.domainStatus(pod, node, topo); status != nil {
+		return status
+	}
+	for _, v := range requirements.EvaluateWithKeys(reqs, node, topo, na.policy, na.keys) {
+		if v.Mode != requirements.ModeRequired {
+			continue
+		}
+		if na.shadowMode() {
+			shadowRejectionsTotal.WithLabelValues(v.Kind).Inc()
+			klog.V(2).InfoS("Shadow mode: would reject node", "pod", klog.KObj(pod),
+				"node", node.Name, "reason", v.Reason)
+			continue
+		}
+		filterRejectionsTotal.WithLabelValues(v.Kind).Inc()
+		na.blocked.record(pod, v.Kind, v.Reason)
+		return framework.NewStatus(framework.Unschedulable, v.Reason)
+	}
+	// Raw capacity passed; also require unreserved headroom on the link
+	// and on any shared uplink group the node belongs to.
+	if reqs.MinBandwidthMbps > 0 && reqs.Mode(AnnotationMinBandwidth) == requirements.ModeRequired &&
+		features.Enabled(features.BandwidthReservation) {
+		if status := na.checkBandwidthHeadroom(node, topo, reqs.MinBandwidthMbps); status != nil {
+			if na.shadowMode() {
+				shadowRejectionsTotal.WithLabelValues(requirements.KindBandwidth).Inc()
+				klog.V(2).InfoS("Shadow mode: would reject node", "pod", klog.KObj(pod),
+					"node", node.Name, "reason", status.Message())
+				return nil
+			}
+			filterRejectionsTotal.WithLabelValues(requirements.KindBandwidth).Inc()
+			na.rejections.record(pod.UID, node.Name, uplinkGroupOf(topo, node))
+			na.blocked.record(pod, requirements.KindBandwidth, status.Message())
+			return status
+		}
+	}
+	return nil
+}
+
+func (na *NetworkAware) shadowMode() bool {
+	return na.args.ShadowMode != nil && *na.args.ShadowMode
+}
+
+// initBandwidthWeight returns the configured init-phase discount, 1 when unset.
+func (na *NetworkAware) initBandwidthWeight() float64 {
+	if na.args.InitBandwidthWeight != nil {
+		return *na.args.InitBandwidthWeight
+	}
+	return 1
+}
+
+// matchRules sums the score deltas of all preference rules matching the pod
+// and node pair.
+func (na *NetworkAware) matchRules(pod *v1.Pod, node *v1.Node) int64 {
+	podLabels := labels.Set(pod.Labels)
+	nodeLabels := labels.Set(node.Labels)
+	var total int64
+	for _, rule := range na.rules {
+		if rule.pod.Matches(podLabels) && rule.node.Matches(nodeLabels) {
+			total += rule.score
+		}
+	}
+	return total
+}
+
+// compiledRule is a PreferenceRule with its selectors parsed.
+type compiledRule struct {
+	name  string
+	pod   labels.Selector
+	node  labels.Selector
+	score int64
+}
+
+// topologyClient fetches the NetworkTopology through the dynamic client.
+type topologyClient struct {
+	client dynamic.Interface
+}
+
+var topologyGVR = v1alpha1.SchemeGroupVersion.WithResource("networktopologies")
+
+func (c *topologyClient) Get(ctx context.Context) (*v1alpha1.NetworkTopology, error) {
+	obj, err := c.client.Resource(topologyGVR).Get(ctx, topologyName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	topo := &v1alpha1.NetworkTopology{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, topo); err != nil {
+		return nil, fmt.Errorf("converting NetworkTopology: %w", err)
+	}
+	return topo, nil
+}
@@ -0,0 +1,394 @@
+package networkaware
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+)
+
+// staticTopology satisfies topologyGetter with a fixture.
+type staticTopology struct {
+	topo *v1alpha1.NetworkTopology
+	err  error
+}
+
+func (s staticTopology) Get(context.Context) (*v1alpha1.NetworkTopology, error) {
+	return s.topo, s.err
+}
+
+func newTestPlugin(t *testing.T, args *NetworkAwareArgs, topo *v1alpha1.NetworkTopology) *NetworkAware {
+	t.Helper()
+	if args == nil {
+		args = &NetworkAwareArgs{}
+	}
+	na, err := newNetworkAware(args, nil, staticTopology{topo: topo})
+	if err != nil {
+		t.Fatalf("newNetworkAware: %v", err)
+	}
+	return na
+}
+
+func runFilter(t *testing.T, na *NetworkAware, pod *v1.Pod, node *v1.Node) *framework.Status {
+	t.Helper()
+	state := framework.NewCycleState()
+	if _, status := na.PreFilter(context.Background(), state, pod); status != nil {
+		return status
+	}
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(node)
+	return na.Filter(context.Background(), state, pod, nodeInfo)
+}
+
+func TestFilterAnnotationPaths(t *testing.T) {
+	topo := topologytesting.NewTopology().
+		WithLink("fast", "peer", "5ms", "900mbps").
+		WithLink("slow", "peer", "120ms", "30mbps").
+		WithZone("fast", "local").
+		WithZone("slow", "remote").
+		WithCapabilities("fast", "internet", "gpu-direct").
+		WithHealth("slow", 0.3).
+		Build()
+
+	tests := []struct {
+		name       string
+		ann        map[string]string
+		node       string
+		wantReject bool
+	}{
+		{
+			name: "min-bandwidth satisfied",
+			ann:  map[string]string{AnnotationMinBandwidth: "500mbps"},
+			node: "fast",
+		},
+		{
+			name:       "min-bandwidth violated",
+			ann:        map[string]string{AnnotationMinBandwidth: "500mbps"},
+			node:       "slow",
+			wantReject: true,
+		},
+		{
+			name: "max-latency satisfied",
+			ann:  map[string]string{AnnotationMaxLatency: "10ms"},
+			node: "fast",
+		},
+		{
+			name:       "max-latency violated",
+			ann:        map[string]string{AnnotationMaxLatency: "10ms"},
+			node:       "slow",
+			wantReject: true,
+		},
+		{
+			name: "data-locality on local node",
+			ann:  map[string]string{AnnotationDataLocality: "high"},
+			node: "fast",
+		},
+		{
+			name:       "data-locality on remote node",
+			ann:        map[string]string{AnnotationDataLocality: "high"},
+			node:       "slow",
+			wantReject: true,
+		},
+		{
+			name: "required capability present",
+			ann:  map[string]string{AnnotationRequiredCapabilities: "gpu-direct"},
+			node: "fast",
+		},
+		{
+			name:       "required capability missing",
+			ann:        map[string]string{AnnotationRequiredCapabilities: "gpu-direct"},
+			node:       "slow",
+			wantReject: true,
+		},
+		{
+			name: "internet required and present",
+			ann:  map[string]string{AnnotationInternetRequired: "true"},
+			node: "fast",
+		},
+		{
+			name:       "internet required and missing",
+			ann:        map[string]string{AnnotationInternetRequired: "true"},
+			node:       "slow",
+			wantReject: true,
+		},
+		{
+			name:       "node health below floor",
+			ann:        map[string]string{AnnotationMinNodeHealth: "0.5"},
+			node:       "slow",
+			wantReject: true,
+		},
+		{
+			name: "no annotations never filters",
+			ann:  nil,
+			node: "slow",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			na := newTestPlugin(t, nil, topo)
+			status := runFilter(t, na, podWithAnnotations(tc.ann), nodeWithLabels(tc.node, nil))
+			if rejected := !status.IsSuccess(); rejected != tc.wantReject {
+				t.Fatalf("Filter rejected=%v, want %v (status: %v)", rejected, tc.wantReject, status)
+			}
+		})
+	}
+}
+
+func TestFilterMalformedAnnotationRejectsPod(t *testing.T) {
+	na := newTestPlugin(t, nil, nil)
+	state := framework.NewCycleState()
+	_, status := na.PreFilter(context.Background(), state,
+		podWithAnnotations(map[string]string{AnnotationMinBandwidth: "warp9"}))
+	if status.Code() != framework.UnschedulableAndUnresolvable {
+		t.Fatalf("expected UnschedulableAndUnresolvable for a malformed annotation, got %v", status)
+	}
+}
+
+func TestScoreTopologyVersusLabelFallback(t *testing.T) {
+	topo := topologytesting.NewTopology().
+		WithLink("fast", "peer", "2ms", "950mbps").
+		WithLink("slow", "peer", "80ms", "40mbps").
+		Build()
+	na := newTestPlugin(t, nil, topo)
+
+	// With topology data the measured values decide the ranking.
+	fast := na.scoreFromTopology(nodeWithLabels("fast", nil), topo)
+	slow := na.scoreFromTopology(nodeWithLabels("slow", nil), topo)
+	if fast <= slow {
+		t.Fatalf("topology scoring: fast=%d should outrank slow=%d", fast, slow)
+	}
+
+	// Without a topology the node labels decide instead.
+	labeledFast := nodeWithLabels("a", map[string]string{
+		LabelNodeBandwidth: "950mbps", LabelNodeLatency: "2ms",
+	})
+	labeledSlow := nodeWithLabels("b", map[string]string{
+		LabelNodeBandwidth: "40mbps", LabelNodeLatency: "80ms",
+	})
+	if f, s := na.scoreFromTopology(labeledFast, nil), na.scoreFromTopology(labeledSlow, nil); f <= s {
+		t.Fatalf("label fallback scoring: fast=%d should outrank slow=%d", f, s)
+	}
+}
+
+// Small clusters publish topologies without any cost entries; those must not
+// read as "every link is maximally expensive" the way a truly unknown node in
+// a measured cluster does.
+func TestScoreSmallClusters(t *testing.T) {
+	node := nodeWithLabels("pi-1", nil)
+	one := topologytesting.NewTopology().WithZone("pi-1", "local").Build()
+	two := topologytesting.NewTopology().WithZone("pi-1", "local").WithZone("pi-2", "local").Build()
+	// Three nodes with measured costs: a node absent from the cost maps is
+	// genuinely unknown and keeps the conservative default.
+	three := topologytesting.NewTopology().
+		WithLink("pi-2", "pi-3", "2ms", "900mbps").
+		WithCost("pi-2", "pi-3", 0.1).
+		WithZone("pi-1", "local").
+		Build()
+	na := newTestPlugin(t, nil, one)
+
+	baseline := na.scoreFromTopology(node, nil)
+	if got := na.scoreFromTopology(node, one); got <= baseline {
+		t.Errorf("single-node topology score = %d, want above the no-topology %d (no cost penalty applies)", got, baseline)
+	}
+	if got := na.scoreFromTopology(node, two); got <= baseline {
+		t.Errorf("unmeasured two-node topology score = %d, want above the no-topology %d", got, baseline)
+	}
+	if got := na.scoreFromTopology(node, three); got != baseline {
+		t.Errorf("uncosted node in a measured cluster = %d, want the conservative %d", got, baseline)
+	}
+}
+
+func TestPreferenceRules(t *testing.T) {
+	args := &NetworkAwareArgs{PreferenceRules: []PreferenceRule{
+		{
+			Name:         "ai-on-gpu",
+			PodSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{LabelWorkloadType: "ai"}},
+			NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{LabelGPU: "nvidia"}},
+			Score:        50,
+		},
+		{
+			Name:         "avoid-metered",
+			NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"metered": "true"}},
+			Score:        -20,
+		},
+	}}
+	na := newTestPlugin(t, args, nil)
+
+	aiPod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{LabelWorkloadType: "ai"}}}
+	gpuNode := nodeWithLabels("gpu", map[string]string{LabelGPU: "nvidia"})
+	meteredGPUNode := nodeWithLabels("metered-gpu", map[string]string{LabelGPU: "nvidia", "metered": "true"})
+	plainNode := nodeWithLabels("plain", nil)
+
+	if got := na.matchRules(aiPod, gpuNode); got != 50 {
+		t.Errorf("ai pod on gpu node scored %d, want 50", got)
+	}
+	// Rules stack: the metered penalty applies on top of the gpu bonus.
+	if got := na.matchRules(aiPod, meteredGPUNode); got != 30 {
+		t.Errorf("ai pod on metered gpu node scored %d, want 30", got)
+	}
+	if got := na.matchRules(aiPod, plainNode); got != 0 {
+		t.Errorf("ai pod on plain node scored %d, want 0", got)
+	}
+	// A pod outside the pod selector gets only pod-agnostic rules.
+	webPod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{LabelWorkloadType: "web"}}}
+	if got := na.matchRules(webPod, meteredGPUNode); got != -20 {
+		t.Errorf("web pod on metered gpu node scored %d, want -20", got)
+	}
+}
+
+func TestUtilizationFlipsRanking(t *testing.T) {
+	topo := topologytesting.NewTopology().
+		WithLink("busy", "peer", "5ms", "900mbps").
+		WithLink("idle", "peer", "5ms", "900mbps").
+		WithUtilization("busy", 0.95).
+		WithUtilization("idle", 0.10).
+		Build()
+	na := newTestPlugin(t, nil, topo)
+
+	busy := na.scoreFromTopology(nodeWithLabels("busy", nil), topo)
+	idle := na.scoreFromTopology(nodeWithLabels("idle", nil), topo)
+	if idle <= busy {
+		t.Fatalf("otherwise identical nodes: idle=%d should outrank busy=%d", idle, busy)
+	}
+
+	// Stale utilization is ignored, so the nodes tie again.
+	stale := topologytesting.NewTopology().
+		WithLink("busy", "peer", "5ms", "900mbps").
+		WithLink("idle", "peer", "5ms", "900mbps").
+		WithUtilization("busy", 0.95).
+		WithUtilizationAge("busy", time.Hour).
+		WithUtilization("idle", 0.10).
+		WithUtilizationAge("idle", time.Hour).
+		Build()
+	if b, i := na.scoreFromTopology(nodeWithLabels("busy", nil), stale), na.scoreFromTopology(nodeWithLabels("idle", nil), stale); b != i {
+		t.Fatalf("stale utilization must be ignored: busy=%d idle=%d", b, i)
+	}
+}
+
+func TestMaxUtilizationFilter(t *testing.T) {
+	topo := topologytesting.NewTopology().
+		WithLink("busy", "peer", "5ms", "900mbps").
+		WithUtilization("busy", 0.95).
+		Build()
+	na := newTestPlugin(t, nil, topo)
+
+	pod := podWithAnnotations(map[string]string{AnnotationMaxUtilization: "0.7"})
+	if status := runFilter(t, na, pod, nodeWithLabels("busy", nil)); status.IsSuccess() {
+		t.Fatalf("saturated node should be rejected for a max-utilization pod")
+	}
+	// Nodes without utilization data pass.
+	if status := runFilter(t, na, pod, nodeWithLabels("peer", nil)); !status.IsSuccess() {
+		t.Fatalf("node without utilization data should pass, got %v", status)
+	}
+}
+
+func TestMaxCostFilterNamesCostModel(t *testing.T) {
+	pod := podWithAnnotations(map[string]string{AnnotationMaxCost: "0.4"})
+
+	// The same node is judged under two differently-anchored cost models;
+	// each rejection names the model its cost was computed against, so the
+	// number in the message can be interpreted.
+	for _, model := range []string{
+		"0.50*latency(anchor=200ms) + 0.50*bandwidth(anchor=1gbps)",
+		"0.50*latency(anchor=1s) + 0.50*bandwidth(anchor=100mbps)",
+	} {
+		topo := topologytesting.NewTopology().
+			WithLink("pricey", "peer", "5ms", "900mbps").
+			WithCost("pricey", "peer", 0.7).
+			Build()
+		topo.Status.CostModel = model
+		na := newTestPlugin(t, nil, topo)
+
+		status := runFilter(t, na, pod, nodeWithLabels("pricey", nil))
+		if status.IsSuccess() {
+			t.Fatalf("node over the cost ceiling should be rejected")
+		}
+		if msg := status.Message(); !strings.Contains(msg, model) {
+			t.Errorf("rejection %q does not name the cost model %q", msg, model)
+		}
+	}
+}
+
+func TestMaxCostGradesScore(t *testing.T) {
+	// Both nodes sit under the ceiling on identical links; the graded
+	// penalty still ranks the cheaper one first.
+	topo := topologytesting.NewTopology().
+		WithLink("near", "peer", "5ms", "900mbps").
+		WithCost("near", "peer", 0.05).
+		WithLink("edge", "peer", "5ms", "900mbps").
+		WithCost("edge", "peer", 0.35).
+		Build()
+	na := newTestPlugin(t, nil, topo)
+	reqs := mustParseRequirements(t, podWithAnnotations(map[string]string{AnnotationMaxCost: "0.4"}))
+
+	near := na.costCeilingPenalty(reqs, nodeWithLabels("near", nil), topo)
+	edge := na.costCeilingPenalty(reqs, nodeWithLabels("edge", nil), topo)
+	if near >= edge {
+		t.Fatalf("penalty must grow toward the ceiling: near=%d edge=%d", near, edge)
+	}
+	if edge > costCeilingPenaltyMax {
+		t.Fatalf("penalty %d exceeds the cap %d", edge, costCeilingPenaltyMax)
+	}
+
+	// Pods without a ceiling pay nothing, whatever the node costs.
+	unbounded := mustParseRequirements(t, podWithAnnotations(nil))
+	if got := na.costCeilingPenalty(unbounded, nodeWithLabels("edge", nil), topo); got != 0 {
+		t.Fatalf("penalty without a ceiling = %d, want 0", got)
+	}
+}
+
+func TestPodTimeBudget(t *testing.T) {
+	budget := metav1.Duration{Duration: 50 * time.Millisecond}
+	na := newTestPlugin(t, &NetworkAwareArgs{PodTimeBudget: &budget}, nil)
+
+	state := framework.NewCycleState()
+	state.Write(preFilterStateKey, &preFilterState{started: time.Now()})
+	if na.budgetSpent(state) {
+		t.Error("budget reported spent immediately after PreFilter")
+	}
+
+	state.Write(preFilterStateKey, &preFilterState{started: time.Now().Add(-time.Second)})
+	if !na.budgetSpent(state) {
+		t.Error("budget not reported spent one second into a 50ms allowance")
+	}
+
+	// Unset budget never trips, whatever the cycle's age.
+	na = newTestPlugin(t, &NetworkAwareArgs{}, nil)
+	if na.budgetSpent(state) {
+		t.Error("budget reported spent with no podTimeBudget configured")
+	}
+}
+
+func TestPreFilterFoldsInitBandwidth(t *testing.T) {
+	pod := podWithAnnotations(map[string]string{
+		AnnotationMinBandwidth:     "50mbps",
+		AnnotationMinBandwidthInit: "400mbps",
+	})
+
+	na := newTestPlugin(t, nil, nil)
+	state := framework.NewCycleState()
+	if _, status := na.PreFilter(context.Background(), state, pod); status != nil {
+		t.Fatalf("PreFilter: %v", status.Message())
+	}
+	if got := requirementsFromState(state).MinBandwidthMbps; got != 400 {
+		t.Errorf("enforced bandwidth = %dmbps, want the 400mbps init peak", got)
+	}
+
+	// The configured weight discounts the init figure before the fold.
+	weight := 0.5
+	na = newTestPlugin(t, &NetworkAwareArgs{InitBandwidthWeight: &weight}, nil)
+	state = framework.NewCycleState()
+	if _, status := na.PreFilter(context.Background(), state, pod); status != nil {
+		t.Fatalf("PreFilter with weight: %v", status.Message())
+	}
+	if got := requirementsFromState(state).MinBandwidthMbps; got != 200 {
+		t.Errorf("weighted enforced bandwidth = %dmbps, want 200", got)
+	}
+}
@@ -0,0 +1,71 @@
+package networkaware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// quotaGetter abstracts NetworkQuota lookup so tests can substitute fixtures.
+type quotaGetter interface {
+	List(ctx context.Context, namespace string) ([]v1alpha1.NetworkQuota, error)
+}
+
+// quotaTTL is how long a namespace's quota list is served from cache; the
+// same trade-off as classTTL.
+const quotaTTL = 30 * time.Second
+
+var quotaGVR = v1alpha1.SchemeGroupVersion.WithResource("networkquotas")
+
+// quotaClient fetches NetworkQuota objects through the dynamic client with a
+// short per-namespace cache.
+type quotaClient struct {
+	client dynamic.Interface
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]quotaCacheEntry
+}
+
+type quotaCacheEntry struct {
+	quotas  []v1alpha1.NetworkQuota
+	expires time.Time
+}
+
+func newQuotaClient(client dynamic.Interface) *quotaClient {
+	return &quotaClient{client: client, now: time.Now, cache: map[string]quotaCacheEntry{}}
+}
+
+func (c *quotaClient) List(ctx context.Context, namespace string) ([]v1alpha1.NetworkQuota, error) {
+	c.mu.Lock()
+	entry, ok := c.cache[namespace]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.quotas, nil
+	}
+
+	list, err := c.client.Resource(quotaGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	quotas := make([]v1alpha1.NetworkQuota, 0, len(list.Items))
+	for i := range list.Items {
+		quota := v1alpha1.NetworkQuota{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].Object, &quota); err != nil {
+			return nil, fmt.Errorf("converting NetworkQuota %q: %w", list.Items[i].GetName(), err)
+		}
+		quotas = append(quotas, quota)
+	}
+
+	c.mu.Lock()
+	c.cache[namespace] = quotaCacheEntry{quotas: quotas, expires: c.now().Add(quotaTTL)}
+	c.mu.Unlock()
+	return quotas, nil
+}
@@ -0,0 +1,178 @@
+package networkaware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/export"
+)
+
+// Annotations written on scheduled pods when recordDecisions is enabled, so
+// "why did this pod land here" is answerable without scheduler logs.
+const (
+	// AnnotationDecisionScore is the node's normalized network score.
+	AnnotationDecisionScore = annotationPrefix + "decision-score"
+	// AnnotationDecisionBandwidth is the best bandwidth the topology
+	// showed for the chosen node.
+	AnnotationDecisionBandwidth = annotationPrefix + "decision-bandwidth"
+	// AnnotationDecisionLatency is the best latency the topology showed
+	// for the chosen node.
+	AnnotationDecisionLatency = annotationPrefix + "decision-latency"
+	// AnnotationDecisionConstraints lists the requirement annotations that
+	// were evaluated for the pod.
+	AnnotationDecisionConstraints = annotationPrefix + "decision-constraints"
+	// AnnotationDecisionSource is "topology" or "labels" depending on
+	// which data the decision was based on.
+	AnnotationDecisionSource = annotationPrefix + "decision-source"
+	// AnnotationDecisionShadow marks decisions taken while the plugin ran
+	// in shadow mode, where Filter verdicts were not enforced.
+	AnnotationDecisionShadow = annotationPrefix + "decision-shadow"
+	// AnnotationDecisionNode is the node the pod was bound to, consulted by
+	// sticky placement when no PVC records the previous node.
+	AnnotationDecisionNode = annotationPrefix + "decision-node"
+	// AnnotationDecisionPluginTime is the total wall-clock time the pod's
+	// scheduling cycle spent inside this plugin, summed across extension
+	// points — the plugin's contribution to the pod's scheduling latency.
+	AnnotationDecisionPluginTime = annotationPrefix + "decision-plugin-time"
+)
+
+// rationaleStateKey stores normalized scores in CycleState for PostBind.
+const rationaleStateKey = "Rationale" + Name
+
+// rationaleState carries the normalized per-node scores from NormalizeScore
+// to PostBind.
+type rationaleState struct {
+	scores map[string]int64
+}
+
+// Clone implements framework.StateData.
+func (r *rationaleState) Clone() framework.StateData { return r }
+
+func (na *NetworkAware) recordDecisions() bool {
+	return na.args.RecordDecisions != nil && *na.args.RecordDecisions
+}
+
+// rememberScores stashes the normalized scores so PostBind can annotate the
+// pod with the chosen node's score.
+func (na *NetworkAware) rememberScores(state *framework.CycleState, scores framework.NodeScoreList) {
+	if !na.recordDecisions() && na.export == nil {
+		return
+	}
+	m := make(map[string]int64, len(scores))
+	for _, s := range scores {
+		m[s.Name] = s.Score
+	}
+	state.Write(rationaleStateKey, &rationaleState{scores: m})
+}
+
+// PostBind patches the scheduled pod with a summary of the decision. It is
+// strictly best-effort: binding already succeeded, so failures only log.
+func (na *NetworkAware) PostBind(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) {
+	ctx, span := startSpan(ctx, "networkaware.PostBind", attribute.String("node", nodeName))
+	defer span.End()
+	defer na.observeExtension(state, extPointPostBind, time.Now())
+	if !na.recordDecisions() && na.export == nil {
+		return
+	}
+
+	topo := na.currentTopology(ctx)
+	na.exportDecision(state, pod, nodeName, topo)
+	if !na.recordDecisions() {
+		return
+	}
+
+	ann := map[string]string{
+		AnnotationDecisionConstraints: evaluatedConstraints(pod),
+		AnnotationDecisionNode:        nodeName,
+	}
+	if na.shadowMode() {
+		ann[AnnotationDecisionShadow] = "true"
+	}
+
+	if topo != nil {
+		ann[AnnotationDecisionSource] = "topology"
+	} else {
+		ann[AnnotationDecisionSource] = "labels"
+	}
+	nodeInfo, err := na.handle.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	if err == nil && nodeInfo.Node() != nil {
+		node := nodeInfo.Node()
+		ann[AnnotationDecisionBandwidth] = fmt.Sprintf("%dmbps", na.nodeBandwidthMbps(node, topo))
+		ann[AnnotationDecisionLatency] = fmt.Sprintf("%dms", na.nodeLatencyMs(node, topo))
+	}
+	if data, err := state.Read(rationaleStateKey); err == nil {
+		if rs, ok := data.(*rationaleState); ok {
+			if score, ok := rs.scores[nodeName]; ok {
+				ann[AnnotationDecisionScore] = fmt.Sprintf("%d", score)
+			}
+		}
+	}
+	if spent := pluginTimeFromState(state); spent > 0 {
+		ann[AnnotationDecisionPluginTime] = spent.Round(10 * time.Microsecond).String()
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": ann},
+	})
+	if err != nil {
+		return
+	}
+	_, err = na.handle.ClientSet().CoreV1().Pods(pod.Namespace).Patch(
+		ctx, pod.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		klog.V(2).InfoS("Failed to record scheduling rationale", "pod", klog.KObj(pod), "err", err)
+	}
+}
+
+// exportDecision appends one decision row to the export sink. The sink
+// queues rows asynchronously and drops on backlog, so binding is never
+// delayed by the export file.
+func (na *NetworkAware) exportDecision(state *framework.CycleState, pod *v1.Pod, nodeName string, topo *v1alpha1.NetworkTopology) {
+	if na.export == nil {
+		return
+	}
+	row := export.Decision{
+		Time:      time.Now(),
+		Namespace: pod.Namespace,
+		Pod:       pod.Name,
+		Node:      nodeName,
+		Source:    "labels",
+	}
+	if topo != nil {
+		row.Source = "topology"
+	}
+	if data, err := state.Read(rationaleStateKey); err == nil {
+		if rs, ok := data.(*rationaleState); ok {
+			row.Score = rs.scores[nodeName]
+		}
+	}
+	na.export.Record(row)
+}
+
+// evaluatedConstraints lists the requirement annotations present on the pod.
+func evaluatedConstraints(pod *v1.Pod) string {
+	var keys []string
+	for _, key := range []string{
+		AnnotationMinBandwidth, AnnotationMaxLatency, AnnotationDataLocality,
+		AnnotationRequiredCapabilities, AnnotationInternetRequired,
+	} {
+		if _, ok := pod.Annotations[key]; ok {
+			keys = append(keys, strings.TrimPrefix(key, annotationPrefix))
+		}
+	}
+	if len(keys) == 0 {
+		return "none"
+	}
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,192 @@
+package networkaware
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+const (
+	// reportName is the name of the singleton NetworkSchedulingReport.
+	reportName = "blocked"
+	// reportDebounce is how long the tracker waits after a change before
+	// rewriting the report, so a burst of rejected scheduling attempts
+	// costs one write instead of fifty.
+	reportDebounce = 15 * time.Second
+	// reportWriteTimeout bounds the flush's API call; the flush runs on a
+	// timer goroutine with no scheduling cycle to inherit a deadline from.
+	reportWriteTimeout = 10 * time.Second
+)
+
+var reportGVR = v1alpha1.SchemeGroupVersion.WithResource("networkschedulingreports")
+
+// blockedTracker remembers the pods the plugin is currently rejecting for
+// network reasons, so the NetworkSchedulingReport can list them grouped by
+// reason. Filter and PreFilter record rejections; the pod informer prunes
+// entries when pods schedule or disappear. Every change arms the debounced
+// flush at most once; the pending flag resets when snapshot drains the state.
+type blockedTracker struct {
+	now func() time.Time
+	// schedule arms the debounced flush; nil when no report writer is
+	// configured (tests, or a plugin built without a dynamic client).
+	schedule func()
+
+	mu      sync.Mutex
+	pending bool
+	pods    map[types.UID]*blockedEntry
+}
+
+type blockedEntry struct {
+	namespace string
+	name      string
+	reason    string
+	message   string
+	firstSeen time.Time
+	lastSeen  time.Time
+	attempts  int32
+}
+
+func newBlockedTracker() *blockedTracker {
+	return &blockedTracker{now: time.Now, pods: map[types.UID]*blockedEntry{}}
+}
+
+// record notes a rejection. Repeated rejections of the same pod coalesce into
+// one entry carrying the latest reason and an attempt count.
+func (b *blockedTracker) record(pod *v1.Pod, reason, message string) {
+	b.mu.Lock()
+	e, ok := b.pods[pod.UID]
+	if !ok {
+		e = &blockedEntry{namespace: pod.Namespace, name: pod.Name, firstSeen: b.now()}
+		b.pods[pod.UID] = e
+	}
+	e.reason = reason
+	e.message = message
+	e.lastSeen = b.now()
+	e.attempts++
+	b.markDirty()
+	b.mu.Unlock()
+}
+
+// forget drops the pod's entry; a no-op (and no write) when the pod was never
+// blocked, so routine pod churn does not touch the report.
+func (b *blockedTracker) forget(uid types.UID) {
+	b.mu.Lock()
+	if _, ok := b.pods[uid]; ok {
+		delete(b.pods, uid)
+		b.markDirty()
+	}
+	b.mu.Unlock()
+}
+
+// markDirty arms the debounced flush unless one is already pending. Callers
+// hold b.mu.
+func (b *blockedTracker) markDirty() {
+	if b.pending || b.schedule == nil {
+		return
+	}
+	b.pending = true
+	b.schedule()
+}
+
+// snapshot builds the report status from the current entries, grouped by
+// reason and sorted for stable diffs, and clears the pending flag so the next
+// change re-arms the flush.
+func (b *blockedTracker) snapshot() v1alpha1.NetworkSchedulingReportStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = false
+
+	byReason := map[string][]v1alpha1.BlockedPod{}
+	for _, e := range b.pods {
+		byReason[e.reason] = append(byReason[e.reason], v1alpha1.BlockedPod{
+			Namespace: e.namespace,
+			Name:      e.name,
+			Message:   e.message,
+			FirstSeen: metav1.NewTime(e.firstSeen),
+			LastSeen:  metav1.NewTime(e.lastSeen),
+			Attempts:  e.attempts,
+		})
+	}
+	status := v1alpha1.NetworkSchedulingReportStatus{}
+	for reason, pods := range byReason {
+		sort.Slice(pods, func(i, j int) bool {
+			if pods[i].Namespace != pods[j].Namespace {
+				return pods[i].Namespace < pods[j].Namespace
+			}
+			return pods[i].Name < pods[j].Name
+		})
+		status.Reasons = append(status.Reasons, v1alpha1.BlockedReasonGroup{Reason: reason, Pods: pods})
+	}
+	sort.Slice(status.Reasons, func(i, j int) bool {
+		return status.Reasons[i].Reason < status.Reasons[j].Reason
+	})
+	return status
+}
+
+// enableBlockedReport wires the tracker to a report writer. Called from New;
+// tests leave the tracker unwired so Filter rejections never spawn timers.
+func (na *NetworkAware) enableBlockedReport(client dynamic.Interface) {
+	na.reports = &reportClient{client: client}
+	na.blocked.schedule = func() {
+		time.AfterFunc(reportDebounce, na.flushBlockedReport)
+	}
+}
+
+// flushBlockedReport rewrites the report from the tracker's current state. A
+// failed write is only logged; the state is still in the tracker and the next
+// change re-arms the flush.
+func (na *NetworkAware) flushBlockedReport() {
+	status := na.blocked.snapshot()
+	ctx, cancel := context.WithTimeout(context.Background(), reportWriteTimeout)
+	defer cancel()
+	if err := na.reports.write(ctx, status); err != nil {
+		klog.V(2).InfoS("Writing NetworkSchedulingReport failed", "err", err)
+	}
+}
+
+// reportClient creates or updates the singleton NetworkSchedulingReport
+// through the dynamic client, mirroring the controller's topology writer.
+type reportClient struct {
+	client dynamic.Interface
+}
+
+func (c *reportClient) write(ctx context.Context, status v1alpha1.NetworkSchedulingReportStatus) error {
+	now := metav1.Now()
+	status.UpdatedAt = &now
+	report := &v1alpha1.NetworkSchedulingReport{
+		ObjectMeta: metav1.ObjectMeta{Name: reportName},
+		Status:     status,
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(report)
+	if err != nil {
+		return fmt.Errorf("converting report: %w", err)
+	}
+	u := &unstructured.Unstructured{Object: obj}
+	u.SetAPIVersion(v1alpha1.SchemeGroupVersion.String())
+	u.SetKind("NetworkSchedulingReport")
+
+	existing, err := c.client.Resource(reportGVR).Get(ctx, reportName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = c.client.Resource(reportGVR).Create(ctx, u, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("fetching report: %w", err)
+	}
+	u.SetResourceVersion(existing.GetResourceVersion())
+	_, err = c.client.Resource(reportGVR).Update(ctx, u, metav1.UpdateOptions{})
+	return err
+}
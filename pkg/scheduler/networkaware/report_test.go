@@ -0,0 +1,100 @@
+package networkaware
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func blockedPod(namespace, name string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: namespace,
+		Name:      name,
+		UID:       types.UID(namespace + "/" + name),
+	}}
+}
+
+func TestBlockedTrackerGroupsByReason(t *testing.T) {
+	b := newBlockedTracker()
+	b.record(blockedPod("media", "transcoder"), "bandwidth", "needs 900mbps")
+	b.record(blockedPod("db", "postgres"), "latency", "node latency 80ms above allowed 10ms")
+	b.record(blockedPod("media", "archiver"), "bandwidth", "needs 500mbps")
+	// Repeated rejections coalesce into one entry with an attempt count.
+	b.record(blockedPod("media", "transcoder"), "bandwidth", "needs 900mbps")
+
+	status := b.snapshot()
+	if len(status.Reasons) != 2 {
+		t.Fatalf("got %d reason groups, want 2", len(status.Reasons))
+	}
+	bw := status.Reasons[0]
+	if bw.Reason != "bandwidth" || len(bw.Pods) != 2 {
+		t.Fatalf("first group = %q with %d pods, want bandwidth with 2", bw.Reason, len(bw.Pods))
+	}
+	// Pods sort by namespace/name within the group.
+	if bw.Pods[0].Name != "archiver" || bw.Pods[1].Name != "transcoder" {
+		t.Errorf("bandwidth pods = %s, %s; want archiver, transcoder", bw.Pods[0].Name, bw.Pods[1].Name)
+	}
+	if bw.Pods[1].Attempts != 2 {
+		t.Errorf("transcoder attempts = %d, want 2", bw.Pods[1].Attempts)
+	}
+	if status.Reasons[1].Reason != "latency" {
+		t.Errorf("second group = %q, want latency", status.Reasons[1].Reason)
+	}
+}
+
+func TestBlockedTrackerForgetPrunes(t *testing.T) {
+	b := newBlockedTracker()
+	pod := blockedPod("default", "web")
+	b.record(pod, "latency", "too slow")
+	b.forget(pod.UID)
+	if status := b.snapshot(); len(status.Reasons) != 0 {
+		t.Fatalf("forgot pod still reported: %+v", status.Reasons)
+	}
+}
+
+func TestBlockedTrackerTracksSeenTimes(t *testing.T) {
+	b := newBlockedTracker()
+	now := time.Unix(1000, 0)
+	b.now = func() time.Time { return now }
+	pod := blockedPod("default", "web")
+	b.record(pod, "latency", "too slow")
+	now = now.Add(time.Minute)
+	b.record(pod, "latency", "too slow")
+
+	entry := b.snapshot().Reasons[0].Pods[0]
+	if !entry.FirstSeen.Time.Equal(time.Unix(1000, 0)) {
+		t.Errorf("FirstSeen = %v, want the first rejection time", entry.FirstSeen)
+	}
+	if !entry.LastSeen.Time.Equal(time.Unix(1060, 0)) {
+		t.Errorf("LastSeen = %v, want the latest rejection time", entry.LastSeen)
+	}
+}
+
+func TestBlockedTrackerDebouncesFlushes(t *testing.T) {
+	b := newBlockedTracker()
+	flushes := 0
+	b.schedule = func() { flushes++ }
+
+	// A burst of changes arms the flush once.
+	b.record(blockedPod("default", "a"), "bandwidth", "")
+	b.record(blockedPod("default", "b"), "bandwidth", "")
+	b.record(blockedPod("default", "a"), "bandwidth", "")
+	if flushes != 1 {
+		t.Fatalf("flushes after burst = %d, want 1", flushes)
+	}
+
+	// Draining the snapshot re-arms on the next change; forgetting a pod
+	// that was never blocked does not.
+	b.snapshot()
+	b.forget(types.UID("unknown"))
+	if flushes != 1 {
+		t.Fatalf("flushes after unknown forget = %d, want still 1", flushes)
+	}
+	b.forget(blockedPod("default", "a").UID)
+	if flushes != 2 {
+		t.Fatalf("flushes after real forget = %d, want 2", flushes)
+	}
+}
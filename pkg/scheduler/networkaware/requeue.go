@@ -0,0 +1,210 @@
+package networkaware
+
+import (
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+)
+
+var _ framework.EnqueueExtensions = &NetworkAware{}
+
+// bandwidthRejections remembers, per pending pod, the nodes and uplink groups
+// Filter rejected for insufficient unreserved bandwidth, so the queueing hint
+// can re-queue exactly the pods a deletion may help. Filter, Reserve and the
+// informer's deletion handler all touch it concurrently; the mutex guards the
+// map and every record inside it.
+type bandwidthRejections struct {
+	mu   sync.Mutex
+	pods map[types.UID]*rejectionRecord
+}
+
+type rejectionRecord struct {
+	nodes  map[string]bool
+	groups map[string]bool
+}
+
+func newBandwidthRejections() *bandwidthRejections {
+	return &bandwidthRejections{pods: map[types.UID]*rejectionRecord{}}
+}
+
+func (b *bandwidthRejections) record(uid types.UID, node, group string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r, ok := b.pods[uid]
+	if !ok {
+		r = &rejectionRecord{nodes: map[string]bool{}, groups: map[string]bool{}}
+		b.pods[uid] = r
+	}
+	r.nodes[node] = true
+	if group != "" {
+		r.groups[group] = true
+	}
+}
+
+func (b *bandwidthRejections) forget(uid types.UID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.pods, uid)
+}
+
+// mayHelp reports whether freed capacity on the node or group could unblock
+// the pending pod.
+func (b *bandwidthRejections) mayHelp(uid types.UID, node, group string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r, ok := b.pods[uid]
+	if !ok {
+		return false
+	}
+	if r.nodes[node] {
+		return true
+	}
+	return group != "" && r.groups[group]
+}
+
+// EventsToRegister re-queues bandwidth-rejected pods when a pod deletion
+// frees capacity; everything else keeps the framework's default events.
+func (na *NetworkAware) EventsToRegister() []framework.ClusterEventWithHint {
+	return []framework.ClusterEventWithHint{{
+		Event:          framework.ClusterEvent{Resource: framework.Pod, ActionType: framework.Delete},
+		QueueingHintFn: na.podDeletionHint,
+	}}
+}
+
+// podDeletionHint queues the pending pod only when the deleted pod held a
+// min-bandwidth claim on a node (or uplink group) the pending pod was
+// rejected for; unrelated deletions are skipped so churny clusters do not
+// thrash the queue.
+func (na *NetworkAware) podDeletionHint(logger klog.Logger, pod *v1.Pod, oldObj, newObj interface{}) (framework.QueueingHint, error) {
+	deleted, ok := oldObj.(*v1.Pod)
+	if !ok {
+		return framework.Queue, nil
+	}
+	reqs, err := requirements.Parse(deleted)
+	if err != nil || reqs.PeakBandwidthMbps(na.initBandwidthWeight()) == 0 || deleted.Spec.NodeName == "" {
+		return framework.QueueSkip, nil
+	}
+	if na.rejections.mayHelp(pod.UID, deleted.Spec.NodeName, na.groupOfNode(deleted.Spec.NodeName)) {
+		logger.V(4).Info("Pod deletion freed bandwidth, queueing",
+			"pod", klog.KObj(pod), "deleted", klog.KObj(deleted), "node", deleted.Spec.NodeName)
+		return framework.Queue, nil
+	}
+	return framework.QueueSkip, nil
+}
+
+// registerPodHandlers keeps the ledger synchronized with the informer's view
+// of running pods. The initial sync rebuilds the ledger after a scheduler
+// restart from the pods that actually run, so entries cannot leak across
+// restarts; deletions release capacity the moment it frees up.
+func (na *NetworkAware) registerPodHandlers(informer cache.SharedIndexInformer) {
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    na.syncPodReservation,
+		UpdateFunc: func(_, obj interface{}) { na.syncPodReservation(obj) },
+		DeleteFunc: na.releasePodReservation,
+	})
+}
+
+// syncPodReservation records the claim of a running pod with a min-bandwidth
+// requirement and drops the claim of a terminated one.
+func (na *NetworkAware) syncPodReservation(obj interface{}) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok || pod.Spec.NodeName == "" {
+		return
+	}
+	// A pod with a node is no longer blocked; drop it from the report.
+	na.blocked.forget(pod.UID)
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		na.ledger.unreserve(pod.UID)
+		return
+	}
+	reqs, err := requirements.Parse(pod)
+	if err != nil {
+		return
+	}
+	// Until the pod is Running the claim covers its peak need across phases;
+	// once init containers have finished, only the runtime figure stays
+	// reserved and the init-phase share frees up for other pods.
+	mbps := reqs.PeakBandwidthMbps(na.initBandwidthWeight())
+	if pod.Status.Phase == v1.PodRunning {
+		mbps = reqs.MinBandwidthMbps
+	}
+	if mbps == 0 {
+		na.ledger.unreserve(pod.UID)
+		return
+	}
+	na.ledger.reserve(pod.UID, pod.Namespace, pod.Spec.NodeName, na.groupOfNode(pod.Spec.NodeName), mbps)
+}
+
+func (na *NetworkAware) releasePodReservation(obj interface{}) {
+	if unknown, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = unknown.Obj
+	}
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return
+	}
+	na.ledger.unreserve(pod.UID)
+	na.rejections.forget(pod.UID)
+	na.blocked.forget(pod.UID)
+}
+
+// rememberTopology keeps the last usable topology for callbacks that run
+// outside a scheduling cycle and must not hit the API server. The snapshot is
+// swapped whole: readers holding the previous pointer keep a consistent view,
+// they are just one fetch behind. On swap, nodes whose published UID changed
+// have their bandwidth reservations rebuilt: the claims were made against a
+// machine that no longer exists.
+func (na *NetworkAware) rememberTopology(topo *v1alpha1.NetworkTopology) {
+	previous := na.observed.Swap(topo)
+	if previous == nil || topo == nil || previous.ResourceVersion == topo.ResourceVersion {
+		return
+	}
+	for _, node := range recreatedNodes(previous, topo) {
+		if dropped := na.ledger.forgetNode(node); dropped > 0 {
+			klog.V(2).InfoS("Node recreated under the same name, dropped its bandwidth reservations",
+				"node", node, "reservations", dropped)
+		}
+	}
+}
+
+// recreatedNodes returns the nodes whose published UID differs between two
+// topology snapshots. Nodes missing a UID in either snapshot (older
+// controllers, imported sources) are never reported.
+func recreatedNodes(previous, current *v1alpha1.NetworkTopology) []string {
+	before := topology.Shared(previous)
+	after := topology.Shared(current)
+	var recreated []string
+	for _, name := range after.NodeNames() {
+		spec, _ := after.Node(name)
+		old, ok := before.Node(name)
+		if ok && spec.UID != "" && old.UID != "" && spec.UID != old.UID {
+			recreated = append(recreated, name)
+		}
+	}
+	return recreated
+}
+
+func (na *NetworkAware) observedTopology() *v1alpha1.NetworkTopology {
+	return na.observed.Load()
+}
+
+// groupOfNode resolves a node's uplink group from the most recently observed
+// topology; "" when none has been seen yet or the node has no group.
+func (na *NetworkAware) groupOfNode(node string) string {
+	topo := na.observedTopology()
+	if topo == nil {
+		return ""
+	}
+	if spec, ok := topology.Shared(topo).Node(node); ok {
+		return spec.UplinkGroup
+	}
+	return ""
+}
@@ -0,0 +1,196 @@
+package networkaware
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+func runningPod(uid, node, bandwidth string) *v1.Pod {
+	pod := podWithAnnotations(map[string]string{AnnotationMinBandwidth: bandwidth})
+	pod.UID = types.UID(uid)
+	pod.Spec.NodeName = node
+	return pod
+}
+
+func TestSyncPodReservationRebuildsLedger(t *testing.T) {
+	na := newTestPlugin(t, nil, uplinkTopology())
+	na.rememberTopology(uplinkTopology())
+
+	// The informer's initial sync replays running pods as adds.
+	na.syncPodReservation(runningPod("a", "edge-1", "60mbps"))
+	na.syncPodReservation(runningPod("b", "edge-2", "30mbps"))
+	if got := na.ledger.reservedOnNode("edge-1"); got != 60 {
+		t.Errorf("reservedOnNode(edge-1) = %d, want 60", got)
+	}
+	if got := na.ledger.reservedInGroup("site-a"); got != 90 {
+		t.Errorf("reservedInGroup(site-a) = %d, want 90", got)
+	}
+
+	// Pods without a claim or a node contribute nothing.
+	plain := podWithAnnotations(nil)
+	plain.UID = types.UID("plain")
+	plain.Spec.NodeName = "edge-1"
+	na.syncPodReservation(plain)
+	if got := na.ledger.reservedOnNode("edge-1"); got != 60 {
+		t.Errorf("pod without a claim changed the ledger: %d", got)
+	}
+
+	// A terminated pod releases its claim.
+	done := runningPod("a", "edge-1", "60mbps")
+	done.Status.Phase = v1.PodSucceeded
+	na.syncPodReservation(done)
+	if got := na.ledger.reservedOnNode("edge-1"); got != 0 {
+		t.Errorf("terminated pod kept %dmbps reserved", got)
+	}
+
+	na.releasePodReservation(runningPod("b", "edge-2", "30mbps"))
+	if got := na.ledger.reservedInGroup("site-a"); got != 0 {
+		t.Errorf("deleted pod kept %dmbps reserved in the group", got)
+	}
+}
+
+func TestPodDeletionHint(t *testing.T) {
+	na := newTestPlugin(t, nil, uplinkTopology())
+	na.rememberTopology(uplinkTopology())
+	logger := klog.Background()
+
+	pending := podWithAnnotations(map[string]string{AnnotationMinBandwidth: "50mbps"})
+	pending.UID = types.UID("pending")
+	na.rejections.record(pending.UID, "edge-1", "site-a")
+
+	// Deleting a pod that held bandwidth on the rejected node queues.
+	hint, err := na.podDeletionHint(logger, pending, runningPod("victim", "edge-1", "60mbps"), nil)
+	if err != nil || hint != framework.Queue {
+		t.Fatalf("deletion on the rejected node: hint = %v, err = %v, want Queue", hint, err)
+	}
+
+	// A deletion elsewhere in the same uplink group also queues.
+	hint, _ = na.podDeletionHint(logger, pending, runningPod("victim", "edge-2", "60mbps"), nil)
+	if hint != framework.Queue {
+		t.Fatalf("deletion in the rejected group: hint = %v, want Queue", hint)
+	}
+
+	// Deleted pods without a bandwidth claim cannot help.
+	unrelated := podWithAnnotations(nil)
+	unrelated.UID = types.UID("unrelated")
+	unrelated.Spec.NodeName = "edge-1"
+	hint, _ = na.podDeletionHint(logger, pending, unrelated, nil)
+	if hint != framework.QueueSkip {
+		t.Fatalf("deletion without a claim: hint = %v, want QueueSkip", hint)
+	}
+
+	// A pod never rejected for bandwidth is skipped too.
+	other := podWithAnnotations(nil)
+	other.UID = types.UID("other")
+	hint, _ = na.podDeletionHint(logger, other, runningPod("victim", "edge-1", "60mbps"), nil)
+	if hint != framework.QueueSkip {
+		t.Fatalf("pod without rejections: hint = %v, want QueueSkip", hint)
+	}
+
+	// Reserving the pending pod clears its rejection record.
+	na.rejections.forget(pending.UID)
+	hint, _ = na.podDeletionHint(logger, pending, runningPod("victim", "edge-1", "60mbps"), nil)
+	if hint != framework.QueueSkip {
+		t.Fatalf("after forget: hint = %v, want QueueSkip", hint)
+	}
+}
+
+func TestSyncPodReservationReleasesInitShare(t *testing.T) {
+	na := newTestPlugin(t, nil, uplinkTopology())
+	na.rememberTopology(uplinkTopology())
+
+	// Before Running, an init-only pod holds its init-phase figure.
+	pod := podWithAnnotations(map[string]string{AnnotationMinBandwidthInit: "80mbps"})
+	pod.UID = types.UID("init-only")
+	pod.Spec.NodeName = "edge-1"
+	na.syncPodReservation(pod)
+	if got := na.ledger.reservedOnNode("edge-1"); got != 80 {
+		t.Errorf("init-only pod before Running reserved %dmbps, want 80", got)
+	}
+
+	// Running releases the whole claim: there is no runtime requirement.
+	pod.Status.Phase = v1.PodRunning
+	na.syncPodReservation(pod)
+	if got := na.ledger.reservedOnNode("edge-1"); got != 0 {
+		t.Errorf("init-only pod kept %dmbps reserved after Running", got)
+	}
+
+	// With both phases the claim drops to the runtime figure at Running.
+	both := runningPod("both", "edge-2", "30mbps")
+	both.Annotations[AnnotationMinBandwidthInit] = "90mbps"
+	na.syncPodReservation(both)
+	if got := na.ledger.reservedOnNode("edge-2"); got != 90 {
+		t.Errorf("pod with both phases reserved %dmbps before Running, want 90", got)
+	}
+	both.Status.Phase = v1.PodRunning
+	na.syncPodReservation(both)
+	if got := na.ledger.reservedOnNode("edge-2"); got != 30 {
+		t.Errorf("pod with both phases kept %dmbps after Running, want 30", got)
+	}
+
+	// A runtime-only claim is unchanged by the phase transition.
+	solo := runningPod("runtime-only", "edge-1", "40mbps")
+	na.syncPodReservation(solo)
+	solo.Status.Phase = v1.PodRunning
+	na.syncPodReservation(solo)
+	if got := na.ledger.reservedOnNode("edge-1"); got != 40 {
+		t.Errorf("runtime-only pod reserved %dmbps after Running, want 40", got)
+	}
+}
+
+func TestInitBandwidthWeightDiscountsReservation(t *testing.T) {
+	weight := 0.5
+	na := newTestPlugin(t, &NetworkAwareArgs{InitBandwidthWeight: &weight}, uplinkTopology())
+	na.rememberTopology(uplinkTopology())
+
+	pod := podWithAnnotations(map[string]string{AnnotationMinBandwidthInit: "80mbps"})
+	pod.UID = types.UID("weighted")
+	pod.Spec.NodeName = "edge-1"
+	na.syncPodReservation(pod)
+	if got := na.ledger.reservedOnNode("edge-1"); got != 40 {
+		t.Errorf("weighted init reservation = %dmbps, want 40", got)
+	}
+}
+
+func TestRecreatedNodeDropsReservations(t *testing.T) {
+	withUIDs := func(rv, edge1UID string) *v1alpha1.NetworkTopology {
+		topo := uplinkTopology()
+		for name, uid := range map[string]string{"edge-1": edge1UID, "edge-2": "uid-2"} {
+			spec := topo.Spec.Nodes[name]
+			spec.UID = uid
+			topo.Spec.Nodes[name] = spec
+		}
+		topo.ResourceVersion = rv
+		return topo
+	}
+
+	na := newTestPlugin(t, nil, nil)
+	na.rememberTopology(withUIDs("1", "uid-1"))
+	na.ledger.reserve(types.UID("a"), "default", "edge-1", "site-a", 60)
+	na.ledger.reserve(types.UID("b"), "default", "edge-2", "site-a", 30)
+
+	// A refresh with unchanged UIDs keeps every claim.
+	na.rememberTopology(withUIDs("2", "uid-1"))
+	if got := na.ledger.reservedOnNode("edge-1"); got != 60 {
+		t.Fatalf("unchanged UID dropped reservations: reservedOnNode(edge-1) = %d", got)
+	}
+
+	// edge-1 deleted and recreated under the same name: its claims were made
+	// against the old machine and are dropped; edge-2's survive.
+	na.rememberTopology(withUIDs("3", "uid-1b"))
+	if got := na.ledger.reservedOnNode("edge-1"); got != 0 {
+		t.Errorf("recreated node kept %dmbps reserved", got)
+	}
+	if got := na.ledger.reservedOnNode("edge-2"); got != 30 {
+		t.Errorf("unrelated node's reservation changed: %d, want 30", got)
+	}
+	if got := na.ledger.reservedInGroup("site-a"); got != 30 {
+		t.Errorf("group total = %d, want 30", got)
+	}
+}
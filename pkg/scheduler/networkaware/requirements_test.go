@@ -0,0 +1,73 @@
+package networkaware
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+func podWithAnnotations(ann map[string]string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p", Annotations: ann}}
+}
+
+func mustParseRequirements(t *testing.T, pod *v1.Pod) *requirements.Requirements {
+	t.Helper()
+	reqs, err := requirements.Parse(pod)
+	if err != nil {
+		t.Fatalf("requirements.Parse: %v", err)
+	}
+	return reqs
+}
+
+func nodeWithLabels(name string, labels map[string]string) *v1.Node {
+	return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestPreferredModeLandsOnLeastBadNode(t *testing.T) {
+	reqs := mustParseRequirements(t, podWithAnnotations(map[string]string{
+		AnnotationMinBandwidth:    "500mbps",
+		AnnotationMaxLatency:      "10ms",
+		AnnotationRequirementMode: "preferred",
+	}))
+
+	// No node satisfies everything: "slow" violates both requirements,
+	// "near" violates only bandwidth.
+	slow := nodeWithLabels("slow", map[string]string{
+		LabelNodeBandwidth: "50mbps", LabelNodeLatency: "90ms",
+	})
+	near := nodeWithLabels("near", map[string]string{
+		LabelNodeBandwidth: "100mbps", LabelNodeLatency: "2ms",
+	})
+
+	na := &NetworkAware{args: &NetworkAwareArgs{}}
+	for _, node := range []*v1.Node{slow, near} {
+		for _, v := range requirements.Evaluate(reqs, node, nil, requirements.DefaultUnknownPolicy()) {
+			if v.Mode != requirements.ModePreferred {
+				t.Fatalf("node %s: expected preferred-mode violation, got %+v", node.Name, v)
+			}
+		}
+	}
+
+	slowPenalty := na.preferredPenalty(reqs, slow, nil)
+	nearPenalty := na.preferredPenalty(reqs, near, nil)
+	if nearPenalty >= slowPenalty {
+		t.Fatalf("least-bad node should get the smaller penalty: near=%d slow=%d", nearPenalty, slowPenalty)
+	}
+}
+
+func TestUnknownNodeScoresNeutralToLow(t *testing.T) {
+	optimistic := requirements.DefaultUnknownPolicy()
+	optimistic.AssumeBest = true
+	na := &NetworkAware{args: &NetworkAwareArgs{}, policy: optimistic}
+
+	unknown := nodeWithLabels("unlabeled", nil)
+	fast := nodeWithLabels("fast", map[string]string{
+		LabelNodeBandwidth: "1gbps", LabelNodeLatency: "1ms",
+	})
+	if u, f := na.scoreFromTopology(unknown, nil), na.scoreFromTopology(fast, nil); u >= f {
+		t.Fatalf("unknown node should score below a known-fast node: unknown=%d fast=%d", u, f)
+	}
+}
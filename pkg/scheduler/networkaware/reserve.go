@@ -0,0 +1,257 @@
+package networkaware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/features"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/topology"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+var _ framework.ReservePlugin = &NetworkAware{}
+
+// bandwidthReservation is one pod's claim on link and uplink-group capacity.
+type bandwidthReservation struct {
+	namespace string
+	node      string
+	group     string
+	mbps      int64
+}
+
+// bandwidthLedger tracks min-bandwidth claims of pods between Reserve and
+// Unreserve so concurrent scheduling cycles cannot collectively overcommit a
+// link — or, worse, an uplink shared by several nodes that each look fine in
+// isolation.
+//
+// One lock deliberately covers all four maps: a claim must move the pod,
+// node, group and namespace totals together or a concurrent reader could see
+// it half-applied. Writers come from Reserve/Unreserve and the pod informer;
+// the headroom checks of parallel Filter calls share the read lock.
+type bandwidthLedger struct {
+	mu         sync.RWMutex
+	pods       map[types.UID]bandwidthReservation
+	nodes      map[string]int64 // mbps reserved per node
+	groups     map[string]int64 // mbps reserved per uplink group
+	namespaces map[string]int64 // mbps reserved per namespace, for quota
+}
+
+func newBandwidthLedger() *bandwidthLedger {
+	return &bandwidthLedger{
+		pods:       map[types.UID]bandwidthReservation{},
+		nodes:      map[string]int64{},
+		groups:     map[string]int64{},
+		namespaces: map[string]int64{},
+	}
+}
+
+func (l *bandwidthLedger) reserve(uid types.UID, namespace, node, group string, mbps int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if existing, ok := l.pods[uid]; ok {
+		l.releaseLocked(existing)
+	}
+	r := bandwidthReservation{namespace: namespace, node: node, group: group, mbps: mbps}
+	l.pods[uid] = r
+	l.nodes[node] += mbps
+	l.namespaces[namespace] += mbps
+	if group != "" {
+		l.groups[group] += mbps
+	}
+}
+
+func (l *bandwidthLedger) unreserve(uid types.UID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if r, ok := l.pods[uid]; ok {
+		l.releaseLocked(r)
+		delete(l.pods, uid)
+	}
+}
+
+// forgetNode drops every reservation held against the named node. Used when
+// the node is recreated under the same name: the claims were made against
+// hardware that no longer exists, and the informer re-adds the pods that
+// actually run on the replacement.
+func (l *bandwidthLedger) forgetNode(node string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	dropped := 0
+	for uid, r := range l.pods {
+		if r.node != node {
+			continue
+		}
+		l.releaseLocked(r)
+		delete(l.pods, uid)
+		dropped++
+	}
+	return dropped
+}
+
+func (l *bandwidthLedger) releaseLocked(r bandwidthReservation) {
+	if l.nodes[r.node] -= r.mbps; l.nodes[r.node] <= 0 {
+		delete(l.nodes, r.node)
+	}
+	if l.namespaces[r.namespace] -= r.mbps; l.namespaces[r.namespace] <= 0 {
+		delete(l.namespaces, r.namespace)
+	}
+	if r.group != "" {
+		if l.groups[r.group] -= r.mbps; l.groups[r.group] <= 0 {
+			delete(l.groups, r.group)
+		}
+	}
+}
+
+func (l *bandwidthLedger) reservedOnNode(node string) int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.nodes[node]
+}
+
+func (l *bandwidthLedger) reservedInGroup(group string) int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.groups[group]
+}
+
+func (l *bandwidthLedger) reservedInNamespace(namespace string) int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.namespaces[namespace]
+}
+
+// uplinkGroupOf returns the shared-bottleneck group of a node, preferring the
+// topology's published membership over the node label.
+func uplinkGroupOf(topo *v1alpha1.NetworkTopology, node *v1.Node) string {
+	if topo != nil {
+		if spec, ok := topology.Shared(topo).Node(node.Name); ok && spec.UplinkGroup != "" {
+			return spec.UplinkGroup
+		}
+	}
+	return node.Labels[requirements.LabelUplinkGroup]
+}
+
+// nodeUplinkGroup resolves the group for a node picked by name, through the
+// same topology-then-label precedence the Filter headroom check applies;
+// resolving the two sides differently would let a claim land in a group the
+// headroom check never charged, or vice versa. Without a node lister only the
+// topology's membership is available.
+func (na *NetworkAware) nodeUplinkGroup(topo *v1alpha1.NetworkTopology, nodeName string) string {
+	if na.nodeLister != nil {
+		if node, err := na.nodeLister.Get(nodeName); err == nil {
+			return uplinkGroupOf(topo, node)
+		}
+	}
+	if topo != nil {
+		if spec, ok := topology.Shared(topo).Node(nodeName); ok {
+			return spec.UplinkGroup
+		}
+	}
+	return ""
+}
+
+// uplinkGroupCapacityMbps returns the declared total capacity of a group.
+func uplinkGroupCapacityMbps(topo *v1alpha1.NetworkTopology, group string) (int64, bool) {
+	if topo == nil || group == "" {
+		return 0, false
+	}
+	value, ok := topo.Spec.UplinkGroups[group]
+	if !ok {
+		return 0, false
+	}
+	bps, err := units.ParseBandwidth(value)
+	if err != nil {
+		return 0, false
+	}
+	return units.ToMbps(bps), true
+}
+
+// checkBandwidthHeadroom rejects a node whose link or uplink group no longer
+// has enough unreserved capacity for the pod. The message names which of the
+// two constraints failed: a full link and an exhausted shared uplink need
+// different operator responses.
+func (na *NetworkAware) checkBandwidthHeadroom(node *v1.Node, topo *v1alpha1.NetworkTopology, requiredMbps int64) *framework.Status {
+	if capacity, known := requirements.MeasuredBandwidthMbps(node, topo); known {
+		if headroom := capacity - na.ledger.reservedOnNode(node.Name); headroom < requiredMbps {
+			return framework.NewStatus(framework.Unschedulable, fmt.Sprintf(
+				"link to %s has %dmbps unreserved of %dmbps capacity, pod needs %dmbps",
+				node.Name, headroom, capacity, requiredMbps))
+		}
+	}
+	group := uplinkGroupOf(topo, node)
+	if capacity, ok := uplinkGroupCapacityMbps(topo, group); ok {
+		if headroom := capacity - na.ledger.reservedInGroup(group); headroom < requiredMbps {
+			return framework.NewStatus(framework.Unschedulable, fmt.Sprintf(
+				"uplink group %q has %dmbps unreserved of %dmbps capacity, pod needs %dmbps",
+				group, headroom, capacity, requiredMbps))
+		}
+	}
+	return nil
+}
+
+// checkQuota re-checks the namespace's NetworkQuota at Reserve time. The
+// webhook already enforced it at admission, but pods admitted concurrently
+// all saw the same status; counting our own in-flight reservations closes
+// that window. A claim the reconciler has since folded into the status may
+// briefly count twice — like uplink capacity, this errs toward not
+// overcommitting. Lookup failures reserve without the check.
+func (na *NetworkAware) checkQuota(ctx context.Context, pod *v1.Pod, mbps int64) *framework.Status {
+	if na.quotas == nil {
+		return nil
+	}
+	quotas, err := na.quotas.List(ctx, pod.Namespace)
+	if err != nil {
+		klog.V(2).InfoS("NetworkQuota lookup failed, reserving without it",
+			"pod", klog.KObj(pod), "err", err)
+		return nil
+	}
+	inFlight := na.ledger.reservedInNamespace(pod.Namespace)
+	for i := range quotas {
+		quota := &quotas[i]
+		if quota.Spec.MaxPodMbps > 0 && mbps > quota.Spec.MaxPodMbps {
+			return framework.NewStatus(framework.Unschedulable, fmt.Sprintf(
+				"pod requests %dmbps, above the %dmbps per-pod limit of NetworkQuota %q",
+				mbps, quota.Spec.MaxPodMbps, quota.Name))
+		}
+		if quota.Spec.TotalReservableMbps > 0 &&
+			quota.Status.ReservedMbps+inFlight+mbps > quota.Spec.TotalReservableMbps {
+			return framework.NewStatus(framework.Unschedulable, fmt.Sprintf(
+				"pod requests %dmbps, but NetworkQuota %q has %dmbps reserved of its %dmbps budget",
+				mbps, quota.Name, quota.Status.ReservedMbps+inFlight, quota.Spec.TotalReservableMbps))
+		}
+	}
+	return nil
+}
+
+// Reserve records the pod's min-bandwidth claim against the chosen node and
+// its uplink group, after re-checking the namespace's quota.
+func (na *NetworkAware) Reserve(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) *framework.Status {
+	defer na.observeExtension(state, extPointReserve, time.Now())
+	reqs := requirementsFromState(state)
+	if reqs.MinBandwidthMbps == 0 || !features.Enabled(features.BandwidthReservation) {
+		return nil
+	}
+	if status := na.checkQuota(ctx, pod, reqs.MinBandwidthMbps); status != nil {
+		return status
+	}
+	topo := na.currentTopology(ctx)
+	group := na.nodeUplinkGroup(topo, nodeName)
+	na.ledger.reserve(pod.UID, pod.Namespace, nodeName, group, reqs.MinBandwidthMbps)
+	na.rejections.forget(pod.UID)
+	return nil
+}
+
+// Unreserve releases the pod's claim when binding fails or is aborted.
+func (na *NetworkAware) Unreserve(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) {
+	defer na.observeExtension(state, extPointUnreserve, time.Now())
+	na.ledger.unreserve(pod.UID)
+}
@@ -0,0 +1,181 @@
+package networkaware
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+)
+
+// uplinkTopology builds two edge nodes sharing the "site-a" uplink group with
+// 100mbps of total capacity, each individually measuring 100mbps to core.
+func uplinkTopology() *v1alpha1.NetworkTopology {
+	topo := topologytesting.NewTopology().
+		WithLink("edge-1", "core", "10ms", "100mbps").
+		WithLink("edge-2", "core", "10ms", "100mbps").
+		Build()
+	for _, name := range []string{"edge-1", "edge-2"} {
+		spec := topo.Spec.Nodes[name]
+		spec.UplinkGroup = "site-a"
+		topo.Spec.Nodes[name] = spec
+	}
+	topo.Spec.UplinkGroups = map[string]string{"site-a": "100mbps"}
+	return topo
+}
+
+func TestLedgerAccounting(t *testing.T) {
+	ledger := newBandwidthLedger()
+	ledger.reserve(types.UID("a"), "default", "edge-1", "site-a", 60)
+	ledger.reserve(types.UID("b"), "default", "edge-2", "site-a", 30)
+	if got := ledger.reservedOnNode("edge-1"); got != 60 {
+		t.Errorf("reservedOnNode(edge-1) = %d, want 60", got)
+	}
+	if got := ledger.reservedInGroup("site-a"); got != 90 {
+		t.Errorf("reservedInGroup(site-a) = %d, want 90", got)
+	}
+
+	// Re-reserving the same pod replaces, not accumulates.
+	ledger.reserve(types.UID("a"), "default", "edge-1", "site-a", 40)
+	if got := ledger.reservedInGroup("site-a"); got != 70 {
+		t.Errorf("after re-reserve, reservedInGroup(site-a) = %d, want 70", got)
+	}
+
+	ledger.unreserve(types.UID("a"))
+	ledger.unreserve(types.UID("b"))
+	if got := ledger.reservedOnNode("edge-1"); got != 0 {
+		t.Errorf("after unreserve, reservedOnNode(edge-1) = %d, want 0", got)
+	}
+	if got := ledger.reservedInGroup("site-a"); got != 0 {
+		t.Errorf("after unreserve, reservedInGroup(site-a) = %d, want 0", got)
+	}
+	// Unreserving an unknown pod is a no-op, not a panic.
+	ledger.unreserve(types.UID("ghost"))
+}
+
+func TestFilterRejectsExhaustedLink(t *testing.T) {
+	na := newTestPlugin(t, nil, uplinkTopology())
+	na.ledger.reserve(types.UID("other"), "default", "edge-1", "site-a", 60)
+
+	pod := podWithAnnotations(map[string]string{AnnotationMinBandwidth: "50mbps"})
+	pod.UID = types.UID("pending")
+	status := runFilter(t, na, pod, nodeWithLabels("edge-1", nil))
+	if status.IsSuccess() {
+		t.Fatal("Filter accepted a node without unreserved link headroom")
+	}
+	if !strings.Contains(status.Message(), "link to edge-1") {
+		t.Errorf("rejection message %q does not name the link constraint", status.Message())
+	}
+}
+
+func TestFilterRejectsExhaustedUplinkGroup(t *testing.T) {
+	na := newTestPlugin(t, nil, uplinkTopology())
+	// edge-2's own link has full headroom; only the shared uplink is tight.
+	na.ledger.reserve(types.UID("other"), "default", "edge-1", "site-a", 60)
+
+	pod := podWithAnnotations(map[string]string{AnnotationMinBandwidth: "50mbps"})
+	pod.UID = types.UID("pending")
+	status := runFilter(t, na, pod, nodeWithLabels("edge-2", nil))
+	if status.IsSuccess() {
+		t.Fatal("Filter accepted a node whose uplink group is exhausted")
+	}
+	if !strings.Contains(status.Message(), `uplink group "site-a"`) {
+		t.Errorf("rejection message %q does not name the group constraint", status.Message())
+	}
+}
+
+func TestFilterPassesWithHeadroom(t *testing.T) {
+	na := newTestPlugin(t, nil, uplinkTopology())
+	na.ledger.reserve(types.UID("other"), "default", "edge-1", "site-a", 40)
+
+	pod := podWithAnnotations(map[string]string{AnnotationMinBandwidth: "50mbps"})
+	pod.UID = types.UID("pending")
+	if status := runFilter(t, na, pod, nodeWithLabels("edge-2", nil)); !status.IsSuccess() {
+		t.Fatalf("Filter rejected a node with link and group headroom: %v", status)
+	}
+}
+
+func TestReserveAndUnreserveMaintainLedger(t *testing.T) {
+	na := newTestPlugin(t, nil, uplinkTopology())
+	pod := podWithAnnotations(map[string]string{AnnotationMinBandwidth: "50mbps"})
+	pod.UID = types.UID("bound")
+
+	state := framework.NewCycleState()
+	if _, status := na.PreFilter(context.Background(), state, pod); status != nil {
+		t.Fatalf("PreFilter: %v", status)
+	}
+	if status := na.Reserve(context.Background(), state, pod, "edge-1"); !status.IsSuccess() {
+		t.Fatalf("Reserve: %v", status)
+	}
+	if got := na.ledger.reservedInGroup("site-a"); got != 50 {
+		t.Errorf("after Reserve, reservedInGroup(site-a) = %d, want 50", got)
+	}
+	na.Unreserve(context.Background(), state, pod, "edge-1")
+	if got := na.ledger.reservedOnNode("edge-1"); got != 0 {
+		t.Errorf("after Unreserve, reservedOnNode(edge-1) = %d, want 0", got)
+	}
+}
+
+// staticQuotas satisfies quotaGetter with a fixture slice.
+type staticQuotas []v1alpha1.NetworkQuota
+
+func (q staticQuotas) List(context.Context, string) ([]v1alpha1.NetworkQuota, error) {
+	return q, nil
+}
+
+func TestReserveEnforcesQuota(t *testing.T) {
+	na := newTestPlugin(t, nil, uplinkTopology())
+	na.quotas = staticQuotas{{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-budget"},
+		Spec:       v1alpha1.NetworkQuotaSpec{TotalReservableMbps: 100},
+		Status:     v1alpha1.NetworkQuotaStatus{ReservedMbps: 40},
+	}}
+
+	pod := podWithAnnotations(map[string]string{AnnotationMinBandwidth: "50mbps"})
+	pod.UID = types.UID("first")
+	state := framework.NewCycleState()
+	if _, status := na.PreFilter(context.Background(), state, pod); status != nil {
+		t.Fatalf("PreFilter: %v", status)
+	}
+	if status := na.Reserve(context.Background(), state, pod, "edge-1"); !status.IsSuccess() {
+		t.Fatalf("Reserve within budget: %v", status)
+	}
+
+	// A second pod admitted against the same stale status is caught by the
+	// in-flight reservation of the first.
+	second := podWithAnnotations(map[string]string{AnnotationMinBandwidth: "50mbps"})
+	second.UID = types.UID("second")
+	state = framework.NewCycleState()
+	if _, status := na.PreFilter(context.Background(), state, second); status != nil {
+		t.Fatalf("PreFilter: %v", status)
+	}
+	status := na.Reserve(context.Background(), state, second, "edge-2")
+	if status.IsSuccess() {
+		t.Fatal("Reserve accepted a pod beyond the namespace budget")
+	}
+	if !strings.Contains(status.Message(), "team-budget") {
+		t.Errorf("rejection message %q does not name the quota", status.Message())
+	}
+}
+
+func TestReserveIgnoresPodsWithoutBandwidth(t *testing.T) {
+	na := newTestPlugin(t, nil, uplinkTopology())
+	pod := podWithAnnotations(nil)
+	pod.UID = types.UID("plain")
+
+	state := framework.NewCycleState()
+	if _, status := na.PreFilter(context.Background(), state, pod); status != nil {
+		t.Fatalf("PreFilter: %v", status)
+	}
+	if status := na.Reserve(context.Background(), state, pod, "edge-1"); !status.IsSuccess() {
+		t.Fatalf("Reserve: %v", status)
+	}
+	if got := na.ledger.reservedOnNode("edge-1"); got != 0 {
+		t.Errorf("pod without min-bandwidth reserved %dmbps, want 0", got)
+	}
+}
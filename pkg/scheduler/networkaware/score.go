@@ -0,0 +1,250 @@
+package networkaware
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/features"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// Neutral-to-low component scores used for unknown nodes when
+// assumeBestWhenUnknown is enabled: the node passes Filter but never
+// outranks a node with real measurements.
+const (
+	unknownBandwidthScore = 12
+	unknownLatencyPenalty = 8
+)
+
+// Score rates a node by its network characteristics plus any matching
+// preference rules. Raw scores are normalized by NormalizeScore.
+func (na *NetworkAware) Score(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) (int64, *framework.Status) {
+	ctx, span := startSpan(ctx, "networkaware.Score", attribute.String("node", nodeName))
+	defer span.End()
+	defer na.observeExtension(state, extPointScore, time.Now())
+	nodeInfo, err := na.handle.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	if err != nil {
+		return 0, framework.AsStatus(err)
+	}
+	node := nodeInfo.Node()
+
+	topo := na.currentTopology(ctx)
+	if topo != nil && na.budgetSpent(state) {
+		// The pod's budget is gone; label scoring keeps the cycle moving
+		// at the cost of topology precision for its remaining nodes.
+		timeBudgetExceededTotal.Inc()
+		topo = nil
+	}
+	reqs, fingerprint := stateFromCycle(state)
+	score := na.baseScore(reqs, fingerprint, node, topo)
+	// Preference bonuses are withheld from cordoned or NoSchedule-tainted
+	// nodes: the default plugins filter them out anyway, but in profiles
+	// mixing plugins the bonus would distort the comparison among the
+	// nodes that remain.
+	if requirements.NodeSchedulable(node) {
+		score += na.matchRules(pod, node)
+		score += na.servesBonus(pod, node, topo)
+		score += na.ingressProximityBonus(pod, node, topo)
+		score += na.stickyBonus(pod, node, topo)
+		if features.Enabled(features.GPUGroupScheduling) {
+			score += na.gpuGroupBonus(pod, node, topo)
+		}
+		score += na.colocateBonus(pod, node, topo)
+	}
+	score -= na.islandSpreadPenalty(pod, node, topo)
+	if na.nodeRelayed(pod, node, topo) {
+		score -= relayedPathPenalty
+	}
+	score -= na.domainPenalty(pod, node, topo)
+	if na.maintenancePolicyName() == maintenancePolicyPenalize && nodeUnderMaintenance(node, topo) {
+		score -= maintenancePenalty
+	}
+	rawScore.Observe(float64(score))
+	return score, nil
+}
+
+// preferredPenalty sums the configured penalty for every preferred-mode
+// requirement the node violates, so such pods still schedule but land on the
+// least-bad node.
+func (na *NetworkAware) preferredPenalty(reqs *requirements.Requirements, node *v1.Node, topo *v1alpha1.NetworkTopology) int64 {
+	if reqs.Empty() {
+		return 0
+	}
+	var penalty int64
+	for _, v := range requirements.EvaluateWithKeys(reqs, node, topo, na.policy, na.keys) {
+		if v.Mode != requirements.ModePreferred {
+			continue
+		}
+		scale := v.Severity
+		if scale <= 0 {
+			scale = 1
+		}
+		penalty += int64(float64(na.violationPenalty()) * scale)
+	}
+	return penalty
+}
+
+// defaultPreferredViolationPenalty outweighs every positive scoring component
+// so violating nodes only win when all nodes violate.
+const defaultPreferredViolationPenalty = 200
+
+func (na *NetworkAware) violationPenalty() int64 {
+	if na.args.PreferredViolationPenalty != nil {
+		return *na.args.PreferredViolationPenalty
+	}
+	return defaultPreferredViolationPenalty
+}
+
+// scoreFromTopology combines the node's best bandwidth, best latency and
+// cheapest link cost, each scaled by its configured weight.
+func (na *NetworkAware) scoreFromTopology(node *v1.Node, topo *v1alpha1.NetworkTopology) int64 {
+	bandwidthScore, latencyPenalty, costPenalty := na.scoreComponents(node, topo)
+	total := weight(na.args.BandwidthWeight)*bandwidthScore -
+		weight(na.args.LatencyWeight)*latencyPenalty -
+		weight(na.args.CostWeight)*costPenalty
+	return int64(total) - na.utilizationPenalty(node, topo)
+}
+
+// Utilization scoring: nodes above the threshold lose up to
+// utilizationPenaltyMax points as their links approach saturation, so equal
+// nodes tie-break toward the one with headroom.
+const (
+	defaultUtilizationThreshold = 0.8
+	utilizationPenaltyMax       = 40
+)
+
+func (na *NetworkAware) utilizationPenalty(node *v1.Node, topo *v1alpha1.NetworkTopology) int64 {
+	threshold := defaultUtilizationThreshold
+	if na.args.UtilizationThreshold != nil {
+		threshold = *na.args.UtilizationThreshold
+	}
+	util, ok := requirements.NodeUtilization(node, topo, na.policy.UtilizationMaxAge)
+	if !ok || util <= threshold {
+		return 0
+	}
+	// Scale the overshoot onto [0, utilizationPenaltyMax].
+	if threshold >= 1 {
+		return utilizationPenaltyMax
+	}
+	return int64((util - threshold) / (1 - threshold) * utilizationPenaltyMax)
+}
+
+// Cost ceiling scoring: a pod with a max-cost ceiling loses up to
+// costCeilingPenaltyMax points as a node's cost approaches the ceiling, so
+// among feasible nodes the pod drifts toward the cheapest links instead of
+// landing just under its budget.
+const costCeilingPenaltyMax = 40
+
+func (na *NetworkAware) costCeilingPenalty(reqs *requirements.Requirements, node *v1.Node, topo *v1alpha1.NetworkTopology) int64 {
+	if reqs.MaxCost <= 0 {
+		return 0
+	}
+	cost, ok := requirements.NodeCost(node, topo, reqs.CostTarget)
+	if !ok || cost > reqs.MaxCost {
+		// Unknown costs score neutrally; nodes over the ceiling are
+		// Filter's (or the preferred penalty's) business.
+		return 0
+	}
+	// Scale the fraction of the budget consumed onto [0, costCeilingPenaltyMax].
+	return int64(cost / reqs.MaxCost * costCeilingPenaltyMax)
+}
+
+// scoreComponents computes the unweighted score components, shared between
+// Score and the simulation endpoint so breakdowns match real decisions.
+func (na *NetworkAware) scoreComponents(node *v1.Node, topo *v1alpha1.NetworkTopology) (float64, float64, float64) {
+	bw, bwKnown := requirements.MeasuredBandwidthMbps(node, topo)
+	lat, latKnown := requirements.MeasuredLatencyMs(node, topo)
+	cost := requirements.NodeMinCost(node, topo)
+
+	var bandwidthScore float64
+	switch {
+	case bwKnown:
+		bandwidthScore = float64(bw) / 20
+		if bandwidthScore > 50 {
+			bandwidthScore = 50
+		}
+	case na.policy.AssumeBest:
+		bandwidthScore = unknownBandwidthScore
+	default:
+		bandwidthScore = float64(na.policy.BandwidthMbps) / 20
+		if bandwidthScore > 50 {
+			bandwidthScore = 50
+		}
+	}
+
+	var latencyPenalty float64
+	switch {
+	case latKnown:
+		latencyPenalty = float64(lat) / 10
+		if latencyPenalty > 30 {
+			latencyPenalty = 30
+		}
+	case na.policy.AssumeBest:
+		latencyPenalty = unknownLatencyPenalty
+	default:
+		latencyPenalty = float64(na.policy.LatencyMs) / 10
+		if latencyPenalty > 30 {
+			latencyPenalty = 30
+		}
+	}
+	costPenalty := 50 * cost
+
+	return bandwidthScore, latencyPenalty, costPenalty
+}
+
+// ScoreExtensions returns the normalizer.
+func (na *NetworkAware) ScoreExtensions() framework.ScoreExtensions { return na }
+
+// NormalizeScore maps the raw scores onto [0, framework.MaxNodeScore].
+func (na *NetworkAware) NormalizeScore(ctx context.Context, state *framework.CycleState, pod *v1.Pod, scores framework.NodeScoreList) *framework.Status {
+	_, span := startSpan(ctx, "networkaware.NormalizeScore",
+		attribute.String("pod", pod.Namespace+"/"+pod.Name))
+	defer span.End()
+	defer na.observeExtension(state, extPointNormalizeScore, time.Now())
+	if len(scores) == 0 {
+		return nil
+	}
+	lo, hi := scores[0].Score, scores[0].Score
+	for _, s := range scores {
+		if s.Score < lo {
+			lo = s.Score
+		}
+		if s.Score > hi {
+			hi = s.Score
+		}
+	}
+	if lo == hi {
+		for i := range scores {
+			scores[i].Score = framework.MaxNodeScore / 2
+		}
+		na.rememberScores(state, scores)
+		return nil
+	}
+	for i := range scores {
+		scores[i].Score = (scores[i].Score - lo) * framework.MaxNodeScore / (hi - lo)
+		normalizedScore.Observe(float64(scores[i].Score))
+	}
+	na.rememberScores(state, scores)
+	return nil
+}
+
+// nodeBandwidthMbps resolves the bandwidth with the unknown policy applied.
+func (na *NetworkAware) nodeBandwidthMbps(node *v1.Node, topo *v1alpha1.NetworkTopology) int64 {
+	if bw, known := requirements.MeasuredBandwidthMbps(node, topo); known {
+		return bw
+	}
+	return na.policy.BandwidthMbps
+}
+
+// nodeLatencyMs resolves the latency with the unknown policy applied.
+func (na *NetworkAware) nodeLatencyMs(node *v1.Node, topo *v1alpha1.NetworkTopology) int64 {
+	if lat, known := requirements.MeasuredLatencyMs(node, topo); known {
+		return lat
+	}
+	return na.policy.LatencyMs
+}
@@ -0,0 +1,130 @@
+package networkaware
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/features"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// scoreCacheMaxFingerprints bounds the inner cache level. Deployments burst
+// identical pods, so a handful of distinct fingerprints is the normal case;
+// hitting the cap means something is generating unique requirements per pod
+// and caching them would only grow memory.
+const scoreCacheMaxFingerprints = 128
+
+// scoreCache memoizes the per-node base score — the topology components plus
+// the preferred-violation penalty — across the pods of a burst. The outer
+// level is the topology resourceVersion: any change observed on a fetched
+// topology drops everything, so informer-visible updates invalidate the cache
+// the moment Score sees the new object. The inner level keys on the pod's
+// requirements fingerprint, so the fifty pods of one Deployment share entries
+// while a pod with different annotations computes its own.
+//
+// The cached components read node labels only as measurement fallbacks;
+// labels are assumed stable across the lifetime of one topology version.
+// Score runs in parallel across nodes and pods; the mutex guards both cache
+// levels, and a store racing a version change is simply dropped.
+type scoreCache struct {
+	mu              sync.Mutex
+	resourceVersion string
+	entries         map[string]map[string]int64
+}
+
+func newScoreCache() *scoreCache {
+	return &scoreCache{entries: map[string]map[string]int64{}}
+}
+
+// lookup returns the cached base score for the node under the given topology
+// version and fingerprint. Topologies without a resourceVersion (label
+// fallback, test fixtures) bypass the cache entirely.
+func (c *scoreCache) lookup(topo *v1alpha1.NetworkTopology, fingerprint, nodeName string) (int64, bool) {
+	if topo == nil || topo.ResourceVersion == "" {
+		return 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if topo.ResourceVersion != c.resourceVersion {
+		c.resourceVersion = topo.ResourceVersion
+		c.entries = map[string]map[string]int64{}
+		scoreCacheMissesTotal.Inc()
+		return 0, false
+	}
+	score, ok := c.entries[fingerprint][nodeName]
+	if ok {
+		scoreCacheHitsTotal.Inc()
+	} else {
+		scoreCacheMissesTotal.Inc()
+	}
+	return score, ok
+}
+
+// store records a computed base score under the topology version lookup last
+// synced to; stores racing a version change are dropped by the next lookup.
+func (c *scoreCache) store(topo *v1alpha1.NetworkTopology, fingerprint, nodeName string, score int64) {
+	if topo == nil || topo.ResourceVersion == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if topo.ResourceVersion != c.resourceVersion {
+		return
+	}
+	nodes, ok := c.entries[fingerprint]
+	if !ok {
+		if len(c.entries) >= scoreCacheMaxFingerprints {
+			c.entries = map[string]map[string]int64{}
+		}
+		nodes = map[string]int64{}
+		c.entries[fingerprint] = nodes
+	}
+	nodes[nodeName] = score
+}
+
+// requirementsFingerprint condenses the fields the cached score components
+// depend on into a map key. Capabilities and modes are sorted so pods whose
+// annotations differ only in ordering share entries.
+func requirementsFingerprint(r *requirements.Requirements) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "bw=%d|lat=%d|to=%s|p99=%d|loc=%s|inet=%t|metered=%t|health=%g|util=%g|cost=%g|costto=%s",
+		r.MinBandwidthMbps, r.MaxLatencyMs, r.LatencyTarget, r.MaxLatencyP99Ms, r.DataLocality,
+		r.InternetRequired, r.AvoidMetered, r.MinNodeHealth, r.MaxUtilization, r.MaxCost, r.CostTarget)
+	if len(r.Capabilities) > 0 {
+		caps := append([]string(nil), r.Capabilities...)
+		sort.Strings(caps)
+		b.WriteString("|caps=" + strings.Join(caps, ","))
+	}
+	if len(r.Modes) > 0 {
+		keys := make([]string, 0, len(r.Modes))
+		for key := range r.Modes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "|mode:%s=%s", key, r.Modes[key])
+		}
+	}
+	return b.String()
+}
+
+// baseScore returns the cacheable portion of a node's score: everything fully
+// determined by the topology version and the pod's parsed requirements. The
+// cluster-state terms (preference rules, peer affinity, island spreading,
+// maintenance) stay outside so they always reflect the live cluster.
+func (na *NetworkAware) baseScore(reqs *requirements.Requirements, fingerprint string, node *v1.Node, topo *v1alpha1.NetworkTopology) int64 {
+	if !features.Enabled(features.ScoreCaching) {
+		return na.scoreFromTopology(node, topo) - na.preferredPenalty(reqs, node, topo) - na.costCeilingPenalty(reqs, node, topo)
+	}
+	if score, ok := na.scores.lookup(topo, fingerprint, node.Name); ok {
+		return score
+	}
+	score := na.scoreFromTopology(node, topo) - na.preferredPenalty(reqs, node, topo) - na.costCeilingPenalty(reqs, node, topo)
+	na.scores.store(topo, fingerprint, node.Name, score)
+	return score
+}
@@ -0,0 +1,103 @@
+package networkaware
+
+import (
+	"testing"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+func scoreCacheTopology(rv string) *v1alpha1.NetworkTopology {
+	topo := topologytesting.NewTopology().
+		WithLink("fast", "peer", "5ms", "900mbps").
+		Build()
+	topo.ResourceVersion = rv
+	return topo
+}
+
+// degrade returns a copy of the topology with much worse measurements, as a
+// new fetched object the way informer updates deliver them.
+func degrade(topo *v1alpha1.NetworkTopology, rv string) *v1alpha1.NetworkTopology {
+	out := topo.DeepCopy()
+	out.ResourceVersion = rv
+	spec := out.Spec.Nodes["fast"]
+	spec.Bandwidth["peer"] = "10mbps"
+	spec.Latency["peer"] = "500ms"
+	return out
+}
+
+func TestScoreCacheReusesBaseScores(t *testing.T) {
+	topo := scoreCacheTopology("10")
+	na := newTestPlugin(t, nil, topo)
+	reqs := &requirements.Requirements{MinBandwidthMbps: 100}
+	fp := requirementsFingerprint(reqs)
+	node := nodeWithLabels("fast", nil)
+
+	first := na.baseScore(reqs, fp, node, topo)
+	// A different object carrying the same resourceVersion must serve the
+	// cached value: the cache owns this version.
+	if got := na.baseScore(reqs, fp, node, degrade(topo, "10")); got != first {
+		t.Fatalf("cached score changed within one topology version: %d then %d", first, got)
+	}
+}
+
+func TestScoreCacheInvalidatesMidBurst(t *testing.T) {
+	topo := scoreCacheTopology("10")
+	na := newTestPlugin(t, nil, topo)
+	reqs := &requirements.Requirements{MinBandwidthMbps: 100}
+	fp := requirementsFingerprint(reqs)
+	node := nodeWithLabels("fast", nil)
+
+	before := na.baseScore(reqs, fp, node, topo)
+	updated := degrade(topo, "11")
+	after := na.baseScore(reqs, fp, node, updated)
+	if after == before {
+		t.Fatalf("score did not change after topology update: %d", after)
+	}
+	// The recomputed value must match what a cold plugin computes, i.e. the
+	// cache never serves entries across versions.
+	fresh := newTestPlugin(t, nil, updated)
+	if want := fresh.baseScore(reqs, fp, node, updated); after != want {
+		t.Fatalf("post-update score %d, cold computation %d", after, want)
+	}
+}
+
+func TestScoreCacheBypassesWithoutResourceVersion(t *testing.T) {
+	topo := scoreCacheTopology("")
+	na := newTestPlugin(t, nil, topo)
+	reqs := &requirements.Requirements{}
+	fp := requirementsFingerprint(reqs)
+	node := nodeWithLabels("fast", nil)
+
+	before := na.baseScore(reqs, fp, node, topo)
+	if got := na.baseScore(reqs, fp, node, degrade(topo, "")); got == before {
+		t.Fatalf("fixture without resourceVersion was cached: %d", got)
+	}
+}
+
+func TestRequirementsFingerprintIgnoresOrdering(t *testing.T) {
+	a := &requirements.Requirements{
+		MinBandwidthMbps: 100,
+		Capabilities:     []string{"gpu-direct", "internet"},
+		Modes: map[string]requirements.Mode{
+			AnnotationMinBandwidth: requirements.ModePreferred,
+			AnnotationMaxLatency:   requirements.ModeRequired,
+		},
+	}
+	b := &requirements.Requirements{
+		MinBandwidthMbps: 100,
+		Capabilities:     []string{"internet", "gpu-direct"},
+		Modes: map[string]requirements.Mode{
+			AnnotationMaxLatency:   requirements.ModeRequired,
+			AnnotationMinBandwidth: requirements.ModePreferred,
+		},
+	}
+	if requirementsFingerprint(a) != requirementsFingerprint(b) {
+		t.Fatal("fingerprint depends on capability or mode ordering")
+	}
+	c := &requirements.Requirements{MinBandwidthMbps: 200, Capabilities: []string{"internet", "gpu-direct"}}
+	if requirementsFingerprint(a) == requirementsFingerprint(c) {
+		t.Fatal("fingerprint collision for different requirements")
+	}
+}
@@ -0,0 +1,167 @@
+package networkaware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// benchTopology generates a full-mesh maps-shape topology of n nodes, the
+// worst case for consumers that walk the per-node measurement maps. Shared
+// with the package benchmarks.
+func benchTopology(n int) *v1alpha1.NetworkTopology {
+	topo := &v1alpha1.NetworkTopology{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", ResourceVersion: "1"},
+		Spec:       v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{}},
+	}
+	now := metav1.Now()
+	topo.Status.LastUpdated = &now
+	for i := 0; i < n; i++ {
+		spec := v1alpha1.NodeSpec{
+			Zone:      fmt.Sprintf("zone-%d", i%5),
+			Bandwidth: make(map[string]string, n-1),
+			Latency:   make(map[string]string, n-1),
+			Cost:      make(map[string]float64, n-1),
+		}
+		for j := 0; j < n; j++ {
+			if i == j {
+				continue
+			}
+			target := benchNodeName(j)
+			// The multiplier spreads bandwidths across 100-999mbps even for
+			// small runs, so the generated profiles stay feasible against
+			// the PreFilter gate whatever the node count.
+			spec.Bandwidth[target] = fmt.Sprintf("%dmbps", 100+(i+j)*113%900)
+			spec.Latency[target] = fmt.Sprintf("%dms", 1+(i*j)%120)
+			spec.Cost[target] = float64((i+j)%100) / 100
+		}
+		topo.Spec.Nodes[benchNodeName(i)] = spec
+	}
+	return topo
+}
+
+func benchNodeName(i int) string { return fmt.Sprintf("node-%03d", i) }
+
+// fixedTopology satisfies topologyGetter with a pre-generated object.
+type fixedTopology struct {
+	topo *v1alpha1.NetworkTopology
+}
+
+func (f fixedTopology) Get(context.Context) (*v1alpha1.NetworkTopology, error) {
+	return f.topo, nil
+}
+
+// BenchOptions sizes the self-test run.
+type BenchOptions struct {
+	Nodes int
+	Pods  int
+}
+
+// benchPodProfiles are the annotation sets the generated pods cycle through,
+// covering the unannotated fast path, plain hard requirements, and the
+// heavier preferred-mode scoring path.
+var benchPodProfiles = []map[string]string{
+	nil,
+	{AnnotationMinBandwidth: "200mbps", AnnotationMaxLatency: "50ms"},
+	{
+		AnnotationMinBandwidth:    "200mbps",
+		AnnotationMaxLatency:      "50ms",
+		AnnotationRequirementMode: "preferred",
+	},
+}
+
+// RunBench schedules generated pods against a generated full-mesh topology
+// through the plugin's extension points in isolation — no API server, no
+// scheduler framework — and writes a p50/p95/p99 latency table per extension
+// point. It exists so plugin-cost regressions are catchable without a
+// cluster; absolute numbers include none of the framework's own overhead.
+// Score is exercised through the scoring internals the real Score sums per
+// node, since the node lister lookup belongs to the framework.
+func RunBench(opts BenchOptions, w io.Writer) error {
+	if opts.Nodes < 1 || opts.Pods < 1 {
+		return fmt.Errorf("bench needs at least one node and one pod")
+	}
+	topo := benchTopology(opts.Nodes)
+	na, err := newNetworkAware(&NetworkAwareArgs{}, nil, fixedTopology{topo: topo})
+	if err != nil {
+		return err
+	}
+
+	nodes := make([]*v1.Node, opts.Nodes)
+	infos := make([]*framework.NodeInfo, opts.Nodes)
+	for i := range nodes {
+		nodes[i] = &v1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name:   benchNodeName(i),
+			Labels: map[string]string{"zone": fmt.Sprintf("zone-%d", i%5)},
+		}}
+		infos[i] = framework.NewNodeInfo()
+		infos[i].SetNode(nodes[i])
+	}
+
+	durations := map[string][]time.Duration{}
+	observe := func(point string, start time.Time) {
+		durations[point] = append(durations[point], time.Since(start))
+	}
+	ctx := context.Background()
+	for p := 0; p < opts.Pods; p++ {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("bench-%d", p),
+			Namespace:   metav1.NamespaceDefault,
+			Annotations: benchPodProfiles[p%len(benchPodProfiles)],
+		}}
+		state := framework.NewCycleState()
+		start := time.Now()
+		_, status := na.PreFilter(ctx, state, pod)
+		observe(extPointPreFilter, start)
+		if status != nil {
+			return fmt.Errorf("PreFilter: %s", status.Message())
+		}
+
+		scores := make(framework.NodeScoreList, 0, len(nodes))
+		reqs, fingerprint := stateFromCycle(state)
+		for i, info := range infos {
+			start = time.Now()
+			na.Filter(ctx, state, pod, info)
+			observe(extPointFilter, start)
+
+			start = time.Now()
+			score := na.baseScore(reqs, fingerprint, nodes[i], topo)
+			score += na.matchRules(pod, nodes[i])
+			observe(extPointScore, start)
+			scores = append(scores, framework.NodeScore{Name: nodes[i].Name, Score: score})
+		}
+
+		start = time.Now()
+		na.NormalizeScore(ctx, state, pod, scores)
+		observe(extPointNormalizeScore, start)
+	}
+
+	fmt.Fprintf(w, "nodes=%d pods=%d\n", opts.Nodes, opts.Pods)
+	fmt.Fprintf(w, "%-16s %8s %10s %10s %10s\n", "extension point", "calls", "p50", "p95", "p99")
+	for _, point := range []string{extPointPreFilter, extPointFilter, extPointScore, extPointNormalizeScore} {
+		samples := durations[point]
+		fmt.Fprintf(w, "%-16s %8d %10s %10s %10s\n", point, len(samples),
+			percentile(samples, 0.50), percentile(samples, 0.95), percentile(samples, 0.99))
+	}
+	return nil
+}
+
+// percentile returns the q-quantile of the samples, rounded for display.
+func percentile(samples []time.Duration, q float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(q * float64(len(sorted)-1))
+	return sorted[idx].Round(100 * time.Nanosecond)
+}
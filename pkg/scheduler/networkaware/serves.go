@@ -0,0 +1,118 @@
+package networkaware
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// AnnotationServes names the Service ("<namespace>/<name>") whose traffic the
+// pod will handle, letting Score pull the pod toward the network position of
+// that Service's clients.
+const AnnotationServes = annotationPrefix + "serves"
+
+// Maximum bonus for sitting on a cheap link toward the Service's ingress
+// node, and the extra reward for gateway nodes when the Service pins traffic
+// locally with externalTrafficPolicy: Local.
+const (
+	servesBonusMax        = 40
+	servesLocalGatewayMax = 40
+)
+
+// servesBonus rewards candidate nodes that are network-close to the ingress
+// point serving the pod's declared Service. Unknown or missing Services score
+// neutrally: placement hints must never make a pod harder to schedule.
+func (na *NetworkAware) servesBonus(pod *v1.Pod, node *v1.Node, topo *v1alpha1.NetworkTopology) int64 {
+	ref, ok := pod.Annotations[AnnotationServes]
+	if !ok || topo == nil || na.serviceLister == nil {
+		return 0
+	}
+	namespace, name, err := splitServiceRef(ref, pod.Namespace)
+	if err != nil {
+		klog.V(4).InfoS("Ignoring malformed serves annotation", "pod", klog.KObj(pod), "value", ref, "err", err)
+		return 0
+	}
+	svc, err := na.serviceLister.Services(namespace).Get(name)
+	if err != nil {
+		klog.V(4).InfoS("Serves annotation references unknown Service", "pod", klog.KObj(pod),
+			"service", namespace+"/"+name)
+		return 0
+	}
+
+	gateways := gatewayNodes(topo)
+	if len(gateways) == 0 {
+		return 0
+	}
+
+	// The candidate's reward tracks its cheapest link toward any gateway:
+	// cost 0 earns the full bonus, cost 1 earns nothing.
+	bestCost, found := 1.0, false
+	onGateway := false
+	for _, gw := range gateways {
+		if gw == node.Name {
+			bestCost, found, onGateway = 0, true, true
+			break
+		}
+		if cost, ok := topologyCost(topo, node.Name, gw); ok && (!found || cost < bestCost) {
+			bestCost, found = cost, true
+		}
+	}
+	if !found {
+		return 0
+	}
+	bonus := int64((1 - bestCost) * servesBonusMax)
+
+	// With externalTrafficPolicy Local only gateway-resident endpoints
+	// receive external traffic, so being on the gateway matters much more.
+	if svc.Spec.ExternalTrafficPolicy == v1.ServiceExternalTrafficPolicyLocal && onGateway {
+		bonus += servesLocalGatewayMax
+	}
+	return bonus
+}
+
+// gatewayNodes lists the nodes advertising the gateway capability.
+func gatewayNodes(topo *v1alpha1.NetworkTopology) []string {
+	var gateways []string
+	for name, spec := range topo.Spec.Nodes {
+		for _, c := range spec.Capabilities {
+			if c == "gateway" {
+				gateways = append(gateways, name)
+				break
+			}
+		}
+	}
+	return gateways
+}
+
+// topologyCost looks up the directed link cost between two nodes.
+func topologyCost(topo *v1alpha1.NetworkTopology, from, to string) (float64, bool) {
+	spec, ok := topo.Spec.Nodes[from]
+	if !ok {
+		return 0, false
+	}
+	cost, ok := spec.Cost[to]
+	return cost, ok
+}
+
+// splitServiceRef parses "<namespace>/<name>" or "<name>" (pod's namespace).
+func splitServiceRef(ref, defaultNamespace string) (string, string, error) {
+	parts := strings.Split(ref, "/")
+	switch len(parts) {
+	case 1:
+		if parts[0] == "" {
+			return "", "", fmt.Errorf("empty service reference")
+		}
+		return defaultNamespace, parts[0], nil
+	case 2:
+		if parts[0] == "" || parts[1] == "" {
+			return "", "", fmt.Errorf("service reference %q has empty components", ref)
+		}
+		return parts[0], parts[1], nil
+	default:
+		return "", "", fmt.Errorf("service reference %q is not <namespace>/<name>", ref)
+	}
+}
@@ -0,0 +1,115 @@
+package networkaware
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// NodeSimulation is one node's verdict and score breakdown from Simulate,
+// answering "where would this pod land and why" without scheduling it.
+type NodeSimulation struct {
+	Node     string `json:"node"`
+	Feasible bool   `json:"feasible"`
+	// FilterVerdicts lists the required-mode violations that would have
+	// rejected the node in Filter.
+	FilterVerdicts []string `json:"filterVerdicts,omitempty"`
+
+	BandwidthBonus   float64 `json:"bandwidthBonus"`
+	LatencyPenalty   float64 `json:"latencyPenalty"`
+	CostPenalty      float64 `json:"costPenalty"`
+	PreferenceBonus  int64   `json:"preferenceBonus"`
+	PreferredPenalty int64   `json:"preferredPenalty"`
+
+	RawScore int64 `json:"rawScore"`
+	// NormalizedScore is min-max normalized over the feasible nodes, the
+	// same way NormalizeScore ranks them; -1 for infeasible nodes.
+	NormalizedScore int64 `json:"normalizedScore"`
+}
+
+// Simulate runs the plugin's requirement evaluation and scoring against the
+// given nodes and topology, returning results ranked the way the scheduler
+// would rank them. It is strictly read-only and works equally with a live
+// snapshot or a synthetic topology, which is what makes it usable in CI.
+func Simulate(args *NetworkAwareArgs, pod *v1.Pod, nodes []*v1.Node, topo *v1alpha1.NetworkTopology) ([]NodeSimulation, error) {
+	if args == nil {
+		args = &NetworkAwareArgs{}
+	}
+	rules, policy, err := validateArgs(args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid args: %w", err)
+	}
+	na := &NetworkAware{args: args, rules: rules, policy: policy, keys: labelKeysFor(args)}
+
+	reqs, err := requirements.Parse(pod)
+	if err != nil {
+		return nil, fmt.Errorf("parsing pod requirements: %w", err)
+	}
+	if reqs.MinNodeHealth == 0 && args.MinNodeHealth != nil {
+		reqs.MinNodeHealth = *args.MinNodeHealth
+	}
+
+	results := make([]NodeSimulation, 0, len(nodes))
+	for _, node := range nodes {
+		r := NodeSimulation{Node: node.Name, Feasible: true}
+		for _, v := range requirements.EvaluateWithKeys(reqs, node, topo, policy, na.keys) {
+			if v.Mode == requirements.ModeRequired {
+				r.Feasible = false
+				r.FilterVerdicts = append(r.FilterVerdicts, v.Reason)
+			}
+		}
+
+		r.BandwidthBonus, r.LatencyPenalty, r.CostPenalty = na.scoreComponents(node, topo)
+		r.PreferenceBonus = na.matchRules(pod, node)
+		r.PreferredPenalty = na.preferredPenalty(reqs, node, topo)
+		r.RawScore = na.scoreFromTopology(node, topo) + r.PreferenceBonus - r.PreferredPenalty
+		r.NormalizedScore = -1
+		results = append(results, r)
+	}
+
+	normalizeSimulation(results)
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Feasible != results[j].Feasible {
+			return results[i].Feasible
+		}
+		return results[i].NormalizedScore > results[j].NormalizedScore
+	})
+	return results, nil
+}
+
+// normalizeSimulation mirrors NormalizeScore's min-max mapping over the
+// feasible nodes only, since infeasible nodes never reach scoring.
+func normalizeSimulation(results []NodeSimulation) {
+	lo, hi := int64(0), int64(0)
+	first := true
+	for _, r := range results {
+		if !r.Feasible {
+			continue
+		}
+		if first || r.RawScore < lo {
+			lo = r.RawScore
+		}
+		if first || r.RawScore > hi {
+			hi = r.RawScore
+		}
+		first = false
+	}
+	if first {
+		return
+	}
+	for i := range results {
+		if !results[i].Feasible {
+			continue
+		}
+		if lo == hi {
+			results[i].NormalizedScore = framework.MaxNodeScore / 2
+			continue
+		}
+		results[i].NormalizedScore = (results[i].RawScore - lo) * framework.MaxNodeScore / (hi - lo)
+	}
+}
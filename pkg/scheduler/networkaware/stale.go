@@ -0,0 +1,206 @@
+package networkaware
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// defaultMaxTopologyAge is 10x the controller's default measurement interval,
+// so a few missed cycles are tolerated before measurements are distrusted.
+const defaultMaxTopologyAge = 10 * 60 * time.Second
+
+var topologyStaleTotal = metrics.NewCounter(&metrics.CounterOpts{
+	Name:           "networkaware_topology_stale_total",
+	Help:           "Number of times the plugin switched to label-based scoring because the topology was stale.",
+	StabilityLevel: metrics.ALPHA,
+})
+
+// staleTracker decides whether topology measurements are fresh enough to
+// trust, logging and counting each fresh<->stale transition exactly once.
+// Topologies carrying per-link probe timestamps are judged link by link;
+// older ones fall back to the object-level Status.LastUpdated check.
+// Parallel cycles call usable concurrently; the mutex guards the transition
+// flag and the pruned-snapshot cache, and the pruned output itself is never
+// mutated after it is built.
+type staleTracker struct {
+	maxAge time.Duration
+	now    func() time.Time
+
+	mu    sync.Mutex
+	stale bool
+
+	// Pruned view of the last per-link topology, reused until the earliest
+	// kept link can expire or a new snapshot arrives. Rebuilding on every
+	// call would also defeat pointer-identity caches downstream.
+	prunedIn    *v1alpha1.NetworkTopology
+	prunedOut   *v1alpha1.NetworkTopology
+	prunedUntil time.Time
+}
+
+func newStaleTracker(maxAge time.Duration) *staleTracker {
+	if maxAge <= 0 {
+		maxAge = defaultMaxTopologyAge
+	}
+	return &staleTracker{maxAge: maxAge, now: time.Now}
+}
+
+// usable returns the topology when its measurements are fresh, or nil to make
+// callers fall back to label-based decisions. A missing LastUpdated is
+// treated as stale: the object exists but was never successfully measured.
+func (t *staleTracker) usable(topo *v1alpha1.NetworkTopology) *v1alpha1.NetworkTopology {
+	if topo == nil {
+		return nil
+	}
+	if hasProbeTimes(topo) {
+		return t.usablePerLink(topo)
+	}
+	fresh := topo.Status.LastUpdated != nil &&
+		t.now().Sub(topo.Status.LastUpdated.Time) <= t.maxAge
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if fresh {
+		if t.stale {
+			t.stale = false
+			klog.InfoS("Topology data fresh again, resuming topology-based scoring")
+		}
+		return topo
+	}
+	if !t.stale {
+		t.stale = true
+		topologyStaleTotal.Inc()
+		// Never-measured topologies have no LastUpdated, and formatting the
+		// nil pointer panics inside klog.
+		lastUpdated := "never"
+		if topo.Status.LastUpdated != nil {
+			lastUpdated = topo.Status.LastUpdated.String()
+		}
+		klog.InfoS("Topology data stale, falling back to label-based scoring",
+			"maxTopologyAge", t.maxAge, "lastUpdated", lastUpdated)
+	}
+	return nil
+}
+
+// usablePerLink applies the staleness limit to each link individually: stale
+// measurements are pruned from a copy and the fresh remainder stays trusted.
+// Only when no fresh link remains does the plugin fall back to labels.
+func (t *staleTracker) usablePerLink(topo *v1alpha1.NetworkTopology) *v1alpha1.NetworkTopology {
+	now := t.now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if topo == t.prunedIn && now.Before(t.prunedUntil) {
+		return t.prunedOut
+	}
+
+	out, kept, pruned, next := t.pruneStaleLinks(topo, now)
+	if kept == 0 {
+		out = nil
+		if !t.stale {
+			t.stale = true
+			topologyStaleTotal.Inc()
+			klog.InfoS("All topology links stale, falling back to label-based scoring",
+				"maxTopologyAge", t.maxAge, "pruned", pruned)
+		}
+	} else {
+		if pruned > 0 {
+			klog.V(4).InfoS("Pruned stale topology links", "kept", kept, "pruned", pruned,
+				"maxTopologyAge", t.maxAge)
+		}
+		if t.stale {
+			t.stale = false
+			klog.InfoS("Topology data fresh again, resuming topology-based scoring")
+		}
+	}
+	t.prunedIn = topo
+	t.prunedOut = out
+	t.prunedUntil = next
+	return out
+}
+
+// pruneStaleLinks copies the topology without measurements older than maxAge,
+// judging each link by its own probe time and falling back to the object's
+// LastUpdated for links that carry none. It returns the pruned copy (or the
+// input unchanged when nothing was stale), the kept and pruned link counts,
+// and when the result must be recomputed because the earliest kept link can
+// expire.
+func (t *staleTracker) pruneStaleLinks(topo *v1alpha1.NetworkTopology, now time.Time) (*v1alpha1.NetworkTopology, int, int, time.Time) {
+	objExpiry := time.Time{} // zero: entries without any timestamp are stale
+	if topo.Status.LastUpdated != nil {
+		objExpiry = topo.Status.LastUpdated.Time.Add(t.maxAge)
+	}
+	// No kept link can outlive its probe time plus maxAge, so this bounds
+	// the revalidation interval even before any link is examined.
+	next := now.Add(t.maxAge)
+
+	out := topo.DeepCopy()
+	kept, pruned := 0, 0
+	keep := func(expiry time.Time) bool {
+		if now.After(expiry) {
+			pruned++
+			return false
+		}
+		kept++
+		if expiry.Before(next) {
+			next = expiry
+		}
+		return true
+	}
+
+	for name, spec := range out.Spec.Nodes {
+		for target := range spec.Latency {
+			if keep(linkExpiry(spec, target, objExpiry, t.maxAge)) {
+				continue
+			}
+			delete(spec.Latency, target)
+			delete(spec.Bandwidth, target)
+			delete(spec.Cost, target)
+			delete(spec.ProbeTimes, target)
+		}
+		out.Spec.Nodes[name] = spec
+	}
+	links := out.Spec.Links[:0]
+	for _, link := range out.Spec.Links {
+		expiry := objExpiry
+		if link.LastProbeTime != nil {
+			expiry = link.LastProbeTime.Time.Add(t.maxAge)
+		}
+		if keep(expiry) {
+			links = append(links, link)
+		}
+	}
+	out.Spec.Links = links
+
+	if pruned == 0 {
+		return topo, kept, 0, next
+	}
+	return out, kept, pruned, next
+}
+
+// linkExpiry is when one maps-shape measurement goes stale.
+func linkExpiry(spec v1alpha1.NodeSpec, target string, objExpiry time.Time, maxAge time.Duration) time.Time {
+	if probed, ok := spec.ProbeTimes[target]; ok {
+		return probed.Time.Add(maxAge)
+	}
+	return objExpiry
+}
+
+// hasProbeTimes reports whether any link in the topology carries its own
+// probe timestamp; old controllers write none.
+func hasProbeTimes(topo *v1alpha1.NetworkTopology) bool {
+	for _, spec := range topo.Spec.Nodes {
+		if len(spec.ProbeTimes) > 0 {
+			return true
+		}
+	}
+	for _, link := range topo.Spec.Links {
+		if link.LastProbeTime != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package networkaware
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+func topologyUpdatedAt(t time.Time) *v1alpha1.NetworkTopology {
+	return &v1alpha1.NetworkTopology{
+		Status: v1alpha1.NetworkTopologyStatus{
+			LastUpdated: &metav1.Time{Time: t},
+		},
+	}
+}
+
+func TestStaleTrackerTransitions(t *testing.T) {
+	now := time.Now()
+	tracker := newStaleTracker(10 * time.Minute)
+	tracker.now = func() time.Time { return now }
+
+	fresh := topologyUpdatedAt(now.Add(-time.Minute))
+	if tracker.usable(fresh) == nil {
+		t.Fatal("fresh topology should be usable")
+	}
+
+	// Fresh -> stale: fall back to labels.
+	stale := topologyUpdatedAt(now.Add(-time.Hour))
+	if tracker.usable(stale) != nil {
+		t.Fatal("stale topology should not be usable")
+	}
+	if !tracker.stale {
+		t.Fatal("tracker should record the stale state")
+	}
+	// A second stale read must not flip state again.
+	if tracker.usable(stale) != nil {
+		t.Fatal("stale topology should stay unusable")
+	}
+
+	// Stale -> fresh: switch back automatically.
+	if tracker.usable(fresh) == nil {
+		t.Fatal("fresh topology should be usable again")
+	}
+	if tracker.stale {
+		t.Fatal("tracker should have cleared the stale state")
+	}
+}
+
+func TestStaleTrackerNilLastUpdated(t *testing.T) {
+	tracker := newStaleTracker(10 * time.Minute)
+	topo := &v1alpha1.NetworkTopology{}
+	// The first stale read logs the transition; it must tolerate the nil
+	// LastUpdated instead of handing klog a pointer it cannot format.
+	if tracker.usable(topo) != nil {
+		t.Fatal("topology without LastUpdated should be treated as stale")
+	}
+	if !tracker.stale {
+		t.Fatal("tracker should record the stale state")
+	}
+}
+
+func TestStaleTrackerBoundary(t *testing.T) {
+	now := time.Now()
+	tracker := newStaleTracker(10 * time.Minute)
+	tracker.now = func() time.Time { return now }
+
+	// Exactly at the limit still counts as fresh.
+	atLimit := topologyUpdatedAt(now.Add(-10 * time.Minute))
+	if tracker.usable(atLimit) == nil {
+		t.Fatal("topology exactly maxAge old should still be usable")
+	}
+	beyond := topologyUpdatedAt(now.Add(-10*time.Minute - time.Second))
+	if tracker.usable(beyond) != nil {
+		t.Fatal("topology older than maxAge should not be usable")
+	}
+}
+
+// topologyWithProbeTimes builds a maps-shape topology where n1's links toward
+// n2 and n3 were probed at the given times; the object-level LastUpdated is an
+// hour old so only the per-link timestamps can keep links alive.
+func topologyWithProbeTimes(now time.Time, probed map[string]time.Time) *v1alpha1.NetworkTopology {
+	spec := v1alpha1.NodeSpec{
+		Latency:    map[string]string{},
+		Bandwidth:  map[string]string{},
+		Cost:       map[string]float64{},
+		ProbeTimes: map[string]metav1.Time{},
+	}
+	for target, at := range probed {
+		spec.Latency[target] = "2ms"
+		spec.Bandwidth[target] = "900mbps"
+		spec.Cost[target] = 0.1
+		spec.ProbeTimes[target] = metav1.Time{Time: at}
+	}
+	topo := topologyUpdatedAt(now.Add(-time.Hour))
+	topo.Spec.Nodes = map[string]v1alpha1.NodeSpec{"n1": spec}
+	return topo
+}
+
+func TestStaleTrackerPrunesPerLink(t *testing.T) {
+	now := time.Now()
+	tracker := newStaleTracker(10 * time.Minute)
+	tracker.now = func() time.Time { return now }
+
+	topo := topologyWithProbeTimes(now, map[string]time.Time{
+		"n2": now.Add(-time.Minute),
+		"n3": now.Add(-time.Hour),
+	})
+	usable := tracker.usable(topo)
+	if usable == nil {
+		t.Fatal("topology with a fresh link should stay usable")
+	}
+	spec := usable.Spec.Nodes["n1"]
+	if _, ok := spec.Latency["n2"]; !ok {
+		t.Error("fresh link n1->n2 should survive pruning")
+	}
+	if _, ok := spec.Latency["n3"]; ok {
+		t.Error("stale link n1->n3 should be pruned")
+	}
+	if _, ok := spec.Bandwidth["n3"]; ok {
+		t.Error("pruning must drop the link's bandwidth too")
+	}
+	if tracker.stale {
+		t.Error("a partially fresh topology is not stale")
+	}
+	// The input must not be mutated: the informer cache owns it.
+	if _, ok := topo.Spec.Nodes["n1"].Latency["n3"]; !ok {
+		t.Error("usable mutated the cached topology")
+	}
+}
+
+func TestStaleTrackerAllLinksStale(t *testing.T) {
+	now := time.Now()
+	tracker := newStaleTracker(10 * time.Minute)
+	tracker.now = func() time.Time { return now }
+
+	topo := topologyWithProbeTimes(now, map[string]time.Time{
+		"n2": now.Add(-time.Hour),
+	})
+	if tracker.usable(topo) != nil {
+		t.Fatal("topology with only stale links should not be usable")
+	}
+	if !tracker.stale {
+		t.Fatal("tracker should record the stale state")
+	}
+}
+
+func TestStaleTrackerPerLinkReusesPrunedCopy(t *testing.T) {
+	now := time.Now()
+	tracker := newStaleTracker(10 * time.Minute)
+	tracker.now = func() time.Time { return now }
+
+	topo := topologyWithProbeTimes(now, map[string]time.Time{
+		"n2": now.Add(-time.Minute),
+		"n3": now.Add(-time.Hour),
+	})
+	first := tracker.usable(topo)
+	if second := tracker.usable(topo); second != first {
+		t.Error("same snapshot should reuse the pruned copy")
+	}
+	// Once the kept link expires, the cached copy must be recomputed.
+	now = now.Add(15 * time.Minute)
+	if tracker.usable(topo) != nil {
+		t.Error("expired link should not be served from the cached copy")
+	}
+}
+
+func TestStaleTrackerNilTopology(t *testing.T) {
+	tracker := newStaleTracker(0)
+	if tracker.maxAge != defaultMaxTopologyAge {
+		t.Fatalf("expected default maxAge, got %v", tracker.maxAge)
+	}
+	if tracker.usable(nil) != nil {
+		t.Fatal("nil topology should stay nil")
+	}
+}
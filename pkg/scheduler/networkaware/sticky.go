@@ -0,0 +1,71 @@
+package networkaware
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// AnnotationSticky opts a StatefulSet pod into sticky placement: when the pod
+// restarts, candidates network-close to its previous node are rewarded so
+// warmed caches and replication peers stay nearby.
+const AnnotationSticky = annotationPrefix + "sticky"
+
+// selectedNodeAnnotation is written on PVCs by the volume scheduler for
+// WaitForFirstConsumer volumes and records where the claim was first bound.
+const selectedNodeAnnotation = "volume.kubernetes.io/selected-node"
+
+// stickyBonusMax is earned by the previous node itself; other candidates earn
+// a share proportional to their link cost toward it.
+const stickyBonusMax = 40
+
+// stickyBonus rewards candidates close to where a sticky StatefulSet pod's
+// ordinal previously ran. When the previous node cannot be determined the
+// term is neutral: stickiness must never make a pod harder to schedule.
+func (na *NetworkAware) stickyBonus(pod *v1.Pod, node *v1.Node, topo *v1alpha1.NetworkTopology) int64 {
+	if pod.Annotations[AnnotationSticky] != "true" {
+		return 0
+	}
+	if owner := metav1.GetControllerOf(pod); owner == nil || owner.Kind != "StatefulSet" {
+		return 0
+	}
+	previous := na.previousNode(pod)
+	if previous == "" {
+		return 0
+	}
+	if node.Name == previous {
+		return stickyBonusMax
+	}
+	if topo == nil {
+		return 0
+	}
+	if cost, ok := topologyCost(topo, node.Name, previous); ok {
+		return int64((1 - cost) * stickyBonusMax)
+	}
+	return 0
+}
+
+// previousNode recovers where the pod's ordinal last ran, preferring the
+// selected-node annotation on its PVCs and falling back to the node recorded
+// by the rationale feature on the pod itself.
+func (na *NetworkAware) previousNode(pod *v1.Pod) string {
+	if na.pvcLister != nil {
+		for _, vol := range pod.Spec.Volumes {
+			if vol.PersistentVolumeClaim == nil {
+				continue
+			}
+			pvc, err := na.pvcLister.PersistentVolumeClaims(pod.Namespace).Get(vol.PersistentVolumeClaim.ClaimName)
+			if err != nil {
+				klog.V(4).InfoS("Sticky placement: PVC not found", "pod", klog.KObj(pod),
+					"pvc", vol.PersistentVolumeClaim.ClaimName)
+				continue
+			}
+			if node := pvc.Annotations[selectedNodeAnnotation]; node != "" {
+				return node
+			}
+		}
+	}
+	return pod.Annotations[AnnotationDecisionNode]
+}
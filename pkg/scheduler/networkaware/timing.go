@@ -0,0 +1,57 @@
+package networkaware
+
+import (
+	"sync/atomic"
+	"time"
+
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// Extension-point labels used by the duration histogram and the bench
+// report.
+const (
+	extPointPreFilter      = "PreFilter"
+	extPointFilter         = "Filter"
+	extPointScore          = "Score"
+	extPointNormalizeScore = "NormalizeScore"
+	extPointReserve        = "Reserve"
+	extPointUnreserve      = "Unreserve"
+	extPointPostBind       = "PostBind"
+)
+
+// observeExtension records one extension-point invocation in the duration
+// histogram and folds it into the pod's per-cycle total, so the rationale
+// annotation can answer "how much scheduling latency did this plugin add".
+// Meant to be deferred with the start time evaluated at entry:
+//
+//	defer na.observeExtension(state, extPointFilter, time.Now())
+func (na *NetworkAware) observeExtension(state *framework.CycleState, point string, start time.Time) {
+	elapsed := time.Since(start)
+	extensionDuration.WithLabelValues(point).Observe(elapsed.Seconds())
+	if state == nil {
+		return
+	}
+	data, err := state.Read(preFilterStateKey)
+	if err != nil {
+		return
+	}
+	if s, ok := data.(*preFilterState); ok && s.pluginNanos != nil {
+		atomic.AddInt64(s.pluginNanos, elapsed.Nanoseconds())
+	}
+}
+
+// pluginTimeFromState returns the plugin time the pod's cycle has
+// accumulated so far; zero when PreFilter never ran.
+func pluginTimeFromState(state *framework.CycleState) time.Duration {
+	if state == nil {
+		return 0
+	}
+	data, err := state.Read(preFilterStateKey)
+	if err != nil {
+		return 0
+	}
+	if s, ok := data.(*preFilterState); ok && s.pluginNanos != nil {
+		return time.Duration(atomic.LoadInt64(s.pluginNanos))
+	}
+	return 0
+}
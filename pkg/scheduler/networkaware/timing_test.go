@@ -0,0 +1,64 @@
+package networkaware
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestPluginTimeAccumulatesAcrossExtensionPoints(t *testing.T) {
+	na := newTestPlugin(t, nil, nil)
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "default"}}
+	state := framework.NewCycleState()
+	if _, status := na.PreFilter(context.Background(), state, pod); status != nil {
+		t.Fatalf("PreFilter: %v", status)
+	}
+	base := pluginTimeFromState(state)
+	// Backdated starts stand in for slow extension points.
+	na.observeExtension(state, extPointFilter, time.Now().Add(-5*time.Millisecond))
+	na.observeExtension(state, extPointScore, time.Now().Add(-3*time.Millisecond))
+	if got := pluginTimeFromState(state); got < base+8*time.Millisecond {
+		t.Errorf("accumulated plugin time = %v, want at least %v more than PreFilter's %v", got, 8*time.Millisecond, base)
+	}
+	// A cycle that never ran PreFilter reports zero rather than lying.
+	if got := pluginTimeFromState(framework.NewCycleState()); got != 0 {
+		t.Errorf("plugin time without PreFilter = %v, want 0", got)
+	}
+}
+
+func TestRunBenchProducesPercentiles(t *testing.T) {
+	var buf strings.Builder
+	if err := RunBench(BenchOptions{Nodes: 5, Pods: 6}, &buf); err != nil {
+		t.Fatalf("RunBench: %v", err)
+	}
+	out := buf.String()
+	for _, point := range []string{extPointPreFilter, extPointFilter, extPointScore, extPointNormalizeScore} {
+		if !strings.Contains(out, point) {
+			t.Errorf("bench report missing %s:\n%s", point, out)
+		}
+	}
+	if err := RunBench(BenchOptions{}, &buf); err == nil {
+		t.Error("RunBench accepted a zero-sized run")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	samples := make([]time.Duration, 100)
+	for i := range samples {
+		samples[i] = time.Duration(i+1) * time.Millisecond
+	}
+	if got := percentile(samples, 0.50); got != 50*time.Millisecond {
+		t.Errorf("p50 = %v", got)
+	}
+	if got := percentile(samples, 0.99); got != 99*time.Millisecond {
+		t.Errorf("p99 = %v", got)
+	}
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("percentile(nil) = %v", got)
+	}
+}
@@ -0,0 +1,18 @@
+package networkaware
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer emits one span per extension-point invocation. Without a globally
+// installed provider (see pkg/tracing) this is otel's no-op tracer, so the
+// instrumentation costs nothing on untraced clusters.
+var tracer = otel.Tracer("github.com/KomarovAI/k3s-network-aware-cluster/pkg/scheduler/networkaware")
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,138 @@
+package topology
+
+import (
+	"time"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// BlendPolicy controls how a link's measured bandwidth is weighted against
+// the declared (imported) figure as the measurement ages. Fresh measurements
+// are trusted outright; once a measurement is older than Horizon its weight
+// decays linearly, reaching zero — the declared value alone, or unknown when
+// nothing is declared — at twice the Horizon. The zero value disables
+// blending entirely: measurements never decay.
+type BlendPolicy struct {
+	// Horizon is the measurement age up to which the measured value keeps
+	// full weight. 0 disables blending.
+	Horizon time.Duration
+	// Now substitutes the clock in tests; nil means time.Now.
+	Now func() time.Time
+}
+
+func (p BlendPolicy) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+// Weight returns the measured value's share in [0,1] for a measurement of
+// the given age: 1 up to the horizon, then linearly down to 0 at twice the
+// horizon.
+func (p BlendPolicy) Weight(age time.Duration) float64 {
+	if p.Horizon <= 0 || age <= p.Horizon {
+		return 1
+	}
+	if age >= 2*p.Horizon {
+		return 0
+	}
+	return 1 - float64(age-p.Horizon)/float64(p.Horizon)
+}
+
+// DefaultBlend is the policy the package-level read helpers (and through
+// them the scheduler) apply. It is configured once at startup — the
+// scheduler sets it from its args before serving — and read concurrently
+// afterwards; the zero value keeps the historical measurements-only
+// behavior.
+var DefaultBlend BlendPolicy
+
+// BlendedBandwidth is a bandwidth value with its provenance: how much of it
+// came from the measurement as opposed to the declared figure.
+type BlendedBandwidth struct {
+	Mbps int64
+	// MeasuredWeight is the measured component's share in [0,1]: 1 for a
+	// fresh measurement, 0 when the value is entirely the declared figure.
+	MeasuredWeight float64
+}
+
+// BlendedBandwidthMbps returns the age-blended bandwidth of the directed
+// link source→target. Links whose Bandwidth is pinned by an import source
+// blend the declared value with the probed one from MeasuredBandwidth; links
+// without a declared figure keep the measurement until its weight decays to
+// zero, at which point the value reads as unknown. Measurements without a
+// probe timestamp never decay — there is nothing to age them against.
+func (ix *Index) BlendedBandwidthMbps(source, target string, policy BlendPolicy) (BlendedBandwidth, bool) {
+	spec, ok := ix.nodes[source]
+	if !ok {
+		return BlendedBandwidth{}, false
+	}
+	measuredStr := spec.Bandwidth[target]
+	declaredStr := ""
+	if probed, ok := spec.MeasuredBandwidth[target]; ok {
+		// An import source pinned this link: the authoritative map holds
+		// the declared figure and the probe result lives alongside it.
+		declaredStr, measuredStr = measuredStr, probed
+	}
+	measured, declared := parseMbps(measuredStr), parseMbps(declaredStr)
+
+	weight := 1.0
+	if policy.Horizon > 0 && measured >= 0 {
+		if probed, ok := spec.ProbeTimes[target]; ok {
+			weight = policy.Weight(policy.now().Sub(probed.Time))
+		}
+	}
+	switch {
+	case measured >= 0 && declared >= 0:
+		return BlendedBandwidth{
+			Mbps:           int64(weight*float64(measured) + (1-weight)*float64(declared)),
+			MeasuredWeight: weight,
+		}, true
+	case measured >= 0:
+		if weight == 0 {
+			// Fully decayed with nothing declared to fall back on.
+			return BlendedBandwidth{}, false
+		}
+		return BlendedBandwidth{Mbps: measured, MeasuredWeight: weight}, true
+	case declared >= 0:
+		return BlendedBandwidth{Mbps: declared}, true
+	}
+	return BlendedBandwidth{}, false
+}
+
+// BestBlendedBandwidthMbps is BestBandwidthMbps with the blending policy
+// applied per link: the node's best outgoing bandwidth after each link has
+// decayed toward its declared figure. The zero policy short-circuits to the
+// pre-parsed numeric view.
+func (ix *Index) BestBlendedBandwidthMbps(name string, policy BlendPolicy) (int64, bool) {
+	if policy.Horizon <= 0 {
+		return ix.BestBandwidthMbps(name)
+	}
+	spec, ok := ix.nodes[name]
+	if !ok {
+		return 0, false
+	}
+	best := int64(-1)
+	for target := range spec.Bandwidth {
+		if blended, ok := ix.BlendedBandwidthMbps(name, target, policy); ok && blended.Mbps > best {
+			best = blended.Mbps
+		}
+	}
+	if best < 0 {
+		return 0, false
+	}
+	return best, true
+}
+
+// parseMbps parses a formatted bandwidth into mbps, -1 for empty or
+// unparseable values.
+func parseMbps(v string) int64 {
+	if v == "" {
+		return -1
+	}
+	bps, err := units.ParseBandwidth(v)
+	if err != nil {
+		return -1
+	}
+	return units.ToMbps(bps)
+}
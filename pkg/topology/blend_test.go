@@ -0,0 +1,127 @@
+package topology
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+func TestBlendWeightCurve(t *testing.T) {
+	p := BlendPolicy{Horizon: time.Hour}
+	cases := []struct {
+		age  time.Duration
+		want float64
+	}{
+		{0, 1},
+		{30 * time.Minute, 1},
+		{time.Hour, 1},
+		{90 * time.Minute, 0.5},
+		{2 * time.Hour, 0},
+		{3 * time.Hour, 0},
+	}
+	for _, tc := range cases {
+		if got := p.Weight(tc.age); got != tc.want {
+			t.Errorf("Weight(%v) = %v, want %v", tc.age, got, tc.want)
+		}
+	}
+	// The zero policy never decays anything.
+	if got := (BlendPolicy{}).Weight(365 * 24 * time.Hour); got != 1 {
+		t.Errorf("zero policy Weight = %v, want 1", got)
+	}
+}
+
+// blendTopology builds one pinned link a→b: 1gbps declared, 100mbps probed at
+// the given time.
+func blendTopology(probed time.Time) *v1alpha1.NetworkTopology {
+	return &v1alpha1.NetworkTopology{
+		Spec: v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{
+			"a": {
+				Bandwidth:         map[string]string{"b": "1gbps"},
+				MeasuredBandwidth: map[string]string{"b": "100mbps"},
+				ProbeTimes:        map[string]metav1.Time{"b": metav1.NewTime(probed)},
+			},
+			"b": {},
+		}},
+	}
+}
+
+func TestBlendedBandwidthDecaysTowardDeclared(t *testing.T) {
+	now := time.Now()
+	policy := BlendPolicy{Horizon: time.Hour, Now: func() time.Time { return now }}
+	cases := []struct {
+		name       string
+		age        time.Duration
+		wantMbps   int64
+		wantWeight float64
+	}{
+		{"fresh measurement dominates", 0, 100, 1},
+		{"at the horizon still measured", time.Hour, 100, 1},
+		{"halfway decayed blends", 90 * time.Minute, 550, 0.5},
+		{"fully decayed reads declared", 2 * time.Hour, 1000, 0},
+	}
+	for _, tc := range cases {
+		ix := NewIndex(blendTopology(now.Add(-tc.age)))
+		blended, ok := ix.BlendedBandwidthMbps("a", "b", policy)
+		if !ok {
+			t.Fatalf("%s: link not found", tc.name)
+		}
+		if blended.Mbps != tc.wantMbps || blended.MeasuredWeight != tc.wantWeight {
+			t.Errorf("%s: got %dmbps weight %v, want %dmbps weight %v",
+				tc.name, blended.Mbps, blended.MeasuredWeight, tc.wantMbps, tc.wantWeight)
+		}
+	}
+}
+
+func TestBlendedBandwidthWithoutDeclaredDecaysToUnknown(t *testing.T) {
+	now := time.Now()
+	policy := BlendPolicy{Horizon: time.Hour, Now: func() time.Time { return now }}
+	topo := &v1alpha1.NetworkTopology{
+		Spec: v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{
+			"a": {
+				Bandwidth:  map[string]string{"b": "200mbps"},
+				ProbeTimes: map[string]metav1.Time{"b": metav1.NewTime(now.Add(-3 * time.Hour))},
+			},
+		}},
+	}
+	if _, ok := NewIndex(topo).BlendedBandwidthMbps("a", "b", policy); ok {
+		t.Error("a fully decayed measurement with nothing declared must read as unknown")
+	}
+
+	// Without a probe timestamp there is no age to decay against.
+	topo.Spec.Nodes["a"] = v1alpha1.NodeSpec{Bandwidth: map[string]string{"b": "200mbps"}}
+	blended, ok := NewIndex(topo).BlendedBandwidthMbps("a", "b", policy)
+	if !ok || blended.Mbps != 200 || blended.MeasuredWeight != 1 {
+		t.Errorf("timestamp-less measurement = %+v ok=%t, want 200mbps at full weight", blended, ok)
+	}
+}
+
+func TestBestBlendedBandwidthMbps(t *testing.T) {
+	now := time.Now()
+	policy := BlendPolicy{Horizon: time.Hour, Now: func() time.Time { return now }}
+	topo := &v1alpha1.NetworkTopology{
+		Spec: v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{
+			"a": {
+				Bandwidth:         map[string]string{"b": "300mbps", "c": "1gbps"},
+				MeasuredBandwidth: map[string]string{"c": "100mbps"},
+				ProbeTimes: map[string]metav1.Time{
+					"b": metav1.NewTime(now),
+					"c": metav1.NewTime(now.Add(-2 * time.Hour)),
+				},
+			},
+		}},
+	}
+	ix := NewIndex(topo)
+	// The decayed c link reads as its 1gbps declared figure, beating the
+	// fresh 300mbps measurement toward b.
+	if best, ok := ix.BestBlendedBandwidthMbps("a", policy); !ok || best != 1000 {
+		t.Errorf("best blended = %d ok=%t, want 1000", best, ok)
+	}
+	// The zero policy must match the raw numeric view.
+	raw, _ := ix.BestBandwidthMbps("a")
+	if best, _ := ix.BestBlendedBandwidthMbps("a", BlendPolicy{}); best != raw {
+		t.Errorf("zero policy best = %d, want the raw %d", best, raw)
+	}
+}
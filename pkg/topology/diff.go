@@ -0,0 +1,124 @@
+package topology
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// Change is one field-level difference between two topology snapshots.
+// Status bookkeeping and probe timestamps are deliberately excluded: they
+// move every cycle and would drown the meaningful changes.
+type Change struct {
+	// Node is the source node; Target is set for link-level fields.
+	Node   string `json:"node"`
+	Target string `json:"target,omitempty"`
+	// Field names what changed: "latency", "bandwidth", "cost", "zone",
+	// "cluster", "capabilities", or "node" for node appearance/removal.
+	Field string `json:"field"`
+	// Old and New are the formatted values; empty means absent.
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// Diff returns the meaningful spec-level differences between two snapshots in
+// deterministic order, reading both through the index so the two spec shapes
+// compare identically. It backs the audit log and lets callers skip writes
+// when nothing changed (an empty diff).
+func Diff(before, after *v1alpha1.NetworkTopology) []Change {
+	b, a := NewIndex(before), NewIndex(after)
+	var changes []Change
+	for _, name := range unionSorted(b.NodeNames(), a.NodeNames()) {
+		beforeSpec, inBefore := b.Node(name)
+		afterSpec, inAfter := a.Node(name)
+		switch {
+		case !inBefore:
+			changes = append(changes, Change{Node: name, Field: "node", New: "present"})
+			changes = append(changes, diffNode(name, v1alpha1.NodeSpec{}, afterSpec)...)
+		case !inAfter:
+			changes = append(changes, Change{Node: name, Field: "node", Old: "present"})
+		default:
+			changes = append(changes, diffNode(name, beforeSpec, afterSpec)...)
+		}
+	}
+	return changes
+}
+
+func diffNode(name string, before, after v1alpha1.NodeSpec) []Change {
+	var changes []Change
+	if before.Zone != after.Zone {
+		changes = append(changes, Change{Node: name, Field: "zone", Old: before.Zone, New: after.Zone})
+	}
+	if before.Cluster != after.Cluster {
+		changes = append(changes, Change{Node: name, Field: "cluster", Old: before.Cluster, New: after.Cluster})
+	}
+	if bc, ac := capabilityList(before), capabilityList(after); bc != ac {
+		changes = append(changes, Change{Node: name, Field: "capabilities", Old: bc, New: ac})
+	}
+	for _, target := range unionSorted(linkTargets(before), linkTargets(after)) {
+		if o, n := before.Latency[target], after.Latency[target]; o != n {
+			changes = append(changes, Change{Node: name, Target: target, Field: "latency", Old: o, New: n})
+		}
+		if o, n := before.Bandwidth[target], after.Bandwidth[target]; o != n {
+			changes = append(changes, Change{Node: name, Target: target, Field: "bandwidth", Old: o, New: n})
+		}
+		if o, n := formatCost(before.Cost, target), formatCost(after.Cost, target); o != n {
+			changes = append(changes, Change{Node: name, Target: target, Field: "cost", Old: o, New: n})
+		}
+	}
+	return changes
+}
+
+func capabilityList(spec v1alpha1.NodeSpec) string {
+	if len(spec.Capabilities) == 0 {
+		return ""
+	}
+	caps := append([]string(nil), spec.Capabilities...)
+	sort.Strings(caps)
+	return strings.Join(caps, ",")
+}
+
+func formatCost(costs map[string]float64, target string) string {
+	c, ok := costs[target]
+	if !ok {
+		return ""
+	}
+	return strconv.FormatFloat(c, 'f', 2, 64)
+}
+
+// linkTargets returns every node this spec has any measurement toward.
+func linkTargets(spec v1alpha1.NodeSpec) []string {
+	seen := map[string]bool{}
+	for target := range spec.Latency {
+		seen[target] = true
+	}
+	for target := range spec.Bandwidth {
+		seen[target] = true
+	}
+	for target := range spec.Cost {
+		seen[target] = true
+	}
+	targets := make([]string, 0, len(seen))
+	for target := range seen {
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+func unionSorted(a, b []string) []string {
+	seen := map[string]bool{}
+	for _, name := range a {
+		seen[name] = true
+	}
+	for _, name := range b {
+		seen[name] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,52 @@
+package topology
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffReportsFieldLevelChanges(t *testing.T) {
+	before := mapsTopology()
+	after := mapsTopology()
+	spec := after.Spec.Nodes["pi-1"]
+	spec.Latency["pi-2"] = "40ms"
+	delete(spec.Bandwidth, "hetzner-1")
+	after.Spec.Nodes["pi-1"] = spec
+
+	changes := Diff(before, after)
+	want := []Change{
+		{Node: "pi-1", Target: "hetzner-1", Field: "bandwidth", Old: "95mbps"},
+		{Node: "pi-1", Target: "pi-2", Field: "latency", Old: "2ms", New: "40ms"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Diff = %+v, want %+v", changes, want)
+	}
+}
+
+func TestDiffEmptyForIdenticalSnapshots(t *testing.T) {
+	if changes := Diff(mapsTopology(), mapsTopology()); len(changes) != 0 {
+		t.Errorf("identical snapshots diffed: %+v", changes)
+	}
+	// The links shape must compare equal to its maps-shape source.
+	if changes := Diff(mapsTopology(), ToLinks(mapsTopology())); len(changes) != 0 {
+		t.Errorf("shape conversion diffed: %+v", changes)
+	}
+}
+
+func TestDiffReportsNodeAppearanceAndRemoval(t *testing.T) {
+	changes := Diff(nil, mapsTopology())
+	if len(changes) == 0 || changes[0].Field != "node" || changes[0].New != "present" {
+		t.Fatalf("first-cycle diff should open with a node event, got %+v", changes)
+	}
+
+	removed := Diff(mapsTopology(), RemoveNode(mapsTopology(), "hetzner-1"))
+	found := false
+	for _, c := range removed {
+		if c.Field == "node" && c.Node == "hetzner-1" && c.Old == "present" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("removal diff missing node event: %+v", removed)
+	}
+}
@@ -0,0 +1,67 @@
+package topology
+
+import (
+	"sort"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// Domains partitions the topology's nodes into network domains: the connected
+// components over links measuring under thresholdMs in either direction. Each
+// domain is identified by its lexicographically first member rather than a
+// positional number, so IDs are stable under small perturbations: when a
+// flapping link splits a component, the half keeping that anchor node keeps
+// its ID, only the split-off half gets a new one, and unrelated domains are
+// never renamed. Nil when the threshold is unset or there is no topology.
+func Domains(topo *v1alpha1.NetworkTopology, thresholdMs int64) map[string]string {
+	if topo == nil || thresholdMs <= 0 {
+		return nil
+	}
+	ix := Shared(topo)
+	names := ix.NodeNames()
+	sort.Strings(names)
+
+	parent := make(map[string]string, len(names))
+	for _, n := range names {
+		parent[n] = n
+	}
+	var find func(string) string
+	find = func(n string) string {
+		if parent[n] != n {
+			parent[n] = find(parent[n])
+		}
+		return parent[n]
+	}
+	union := func(a, b string) {
+		ra, rb := find(a), find(b)
+		if ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	for _, source := range names {
+		spec, _ := ix.Node(source)
+		for target, value := range spec.Latency {
+			if _, ok := parent[target]; !ok {
+				continue
+			}
+			if d, err := units.ParseLatency(value); err == nil && d.Milliseconds() < thresholdMs {
+				union(source, target)
+			}
+		}
+	}
+
+	// Names are visited in sorted order, so the first member seen per root
+	// is the smallest: the domain's anchor and ID.
+	anchors := map[string]string{}
+	domains := make(map[string]string, len(names))
+	for _, n := range names {
+		root := find(n)
+		if _, ok := anchors[root]; !ok {
+			anchors[root] = n
+		}
+		domains[n] = anchors[root]
+	}
+	return domains
+}
@@ -0,0 +1,64 @@
+package topology
+
+import (
+	"reflect"
+	"testing"
+
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+)
+
+func TestDomainsPartitionAndAnchors(t *testing.T) {
+	topo := topologytesting.NewTopology().
+		WithLink("a1", "a2", "3ms", "900mbps").
+		WithLink("a2", "a3", "4ms", "900mbps").
+		WithLink("b1", "b2", "2ms", "900mbps").
+		WithLink("a1", "b1", "80ms", "90mbps").
+		Build()
+
+	got := Domains(topo, 30)
+	want := map[string]string{"a1": "a1", "a2": "a1", "a3": "a1", "b1": "b1", "b2": "b1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Domains = %v, want %v", got, want)
+	}
+
+	// Both spec shapes partition identically.
+	if got := Domains(ToLinks(topo), 30); !reflect.DeepEqual(got, want) {
+		t.Errorf("Domains(links shape) = %v, want %v", got, want)
+	}
+
+	if Domains(topo, 0) != nil {
+		t.Error("threshold 0 must disable domains")
+	}
+	if Domains(nil, 30) != nil {
+		t.Error("nil topology must yield nil domains")
+	}
+}
+
+func TestDomainsStableUnderPerturbation(t *testing.T) {
+	// One link flap splits a3 off: the anchored half keeps its ID, only
+	// the split-off node is renamed, and the b domain is untouched.
+	perturbed := topologytesting.NewTopology().
+		WithLink("a1", "a2", "3ms", "900mbps").
+		WithLink("a2", "a3", "90ms", "900mbps").
+		WithLink("b1", "b2", "2ms", "900mbps").
+		WithLink("a1", "b1", "80ms", "90mbps").
+		Build()
+
+	got := Domains(perturbed, 30)
+	want := map[string]string{"a1": "a1", "a2": "a1", "a3": "a3", "b1": "b1", "b2": "b1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Domains after split = %v, want %v", got, want)
+	}
+
+	// Losing the anchor itself renames only its own domain.
+	headless := topologytesting.NewTopology().
+		WithLink("a2", "a3", "4ms", "900mbps").
+		WithLink("b1", "b2", "2ms", "900mbps").
+		WithLink("a2", "b1", "80ms", "90mbps").
+		Build()
+	got = Domains(headless, 30)
+	want = map[string]string{"a2": "a2", "a3": "a2", "b1": "b1", "b2": "b1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Domains without anchor = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,44 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// FuzzNewIndex feeds arbitrary strings through both spec shapes and the full
+// read path (build, numeric view, lookups) to assert that a malformed or
+// hostile topology object can make the index return unknowns but never panic
+// or hang. Run with `go test -fuzz FuzzNewIndex ./pkg/topology`.
+func FuzzNewIndex(f *testing.F) {
+	f.Add("pi-1", "pi-2", "2ms", "900mbps", 0.1)
+	f.Add("", "", "", "", 0.0)
+	f.Add("a", "a", "not-a-latency", "40MB base64 blob", -3.5)
+	f.Fuzz(func(t *testing.T, source, target, latency, bandwidth string, cost float64) {
+		maps := &v1alpha1.NetworkTopology{}
+		maps.Spec.Nodes = map[string]v1alpha1.NodeSpec{
+			source: {
+				Latency:   map[string]string{target: latency},
+				Bandwidth: map[string]string{target: bandwidth},
+				Cost:      map[string]float64{target: cost},
+			},
+		}
+		links := &v1alpha1.NetworkTopology{}
+		links.Spec.Links = []v1alpha1.Link{
+			{Source: source, Target: target, Latency: latency, Bandwidth: bandwidth, Cost: cost},
+		}
+
+		for _, topo := range []*v1alpha1.NetworkTopology{maps, links, ToLinks(maps)} {
+			ix := NewIndex(topo)
+			for _, name := range []string{source, target} {
+				ix.Node(name)
+				ix.BestBandwidthMbps(name)
+				ix.BestLatencyMs(name)
+				ix.MinCost(name)
+			}
+			ix.Link(source, target)
+			ix.ClusterBestBandwidthMbps()
+			ix.ClusterBestLatencyMs()
+		}
+	})
+}
@@ -0,0 +1,433 @@
+// Package topology gives consumers a single read path over the two
+// NetworkTopology spec shapes: the original per-node Bandwidth/Latency/Cost
+// maps and the explicit Spec.Links list. Readers go through an Index and stay
+// agnostic to which shape the controller wrote.
+package topology
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// Index provides O(1) lookups over a topology snapshot regardless of its
+// spec representation. Build one with NewIndex, or use Shared for the
+// common fetch-then-read pattern. An Index is immutable once built and safe
+// for any number of concurrent readers; the lazily computed metrics view is
+// the only deferred work, guarded by its sync.Once.
+type Index struct {
+	nodes map[string]v1alpha1.NodeSpec
+	links map[string]map[string]v1alpha1.Link
+
+	// metrics is the lazily-built numeric view backing the Best*/MinCost
+	// accessors; see metrics.go.
+	metricsOnce sync.Once
+	metrics     *metrics
+
+	// ignored counts measurement entries dropped by the per-node size
+	// bounds; see maxLinksPerNode.
+	ignored int
+
+	// epoch and mixedEpochs summarize the measurement-cycle epochs stamped
+	// on the node entries; see Epoch and MixedEpochs.
+	epoch       int64
+	mixedEpochs bool
+}
+
+// maxLinksPerNode bounds how many measurement entries the index accepts per
+// source node. A healthy controller writes one entry per peer, so anything
+// past this size is a corrupted or hostile object; the excess is dropped
+// deterministically (lexicographically-first targets win) and counted so the
+// scheduler can surface it as a metric instead of burning a core walking it.
+const maxLinksPerNode = 512
+
+// NewIndex normalizes the topology into per-node maps. When Spec.Links is
+// populated its entries take precedence over the per-node maps; the input is
+// never mutated.
+func NewIndex(topo *v1alpha1.NetworkTopology) *Index {
+	ix := &Index{
+		nodes: map[string]v1alpha1.NodeSpec{},
+		links: map[string]map[string]v1alpha1.Link{},
+	}
+	if topo == nil {
+		return ix
+	}
+	for name, spec := range topo.Spec.Nodes {
+		ix.nodes[name] = ix.boundedSpec(spec)
+	}
+	for _, link := range topo.Spec.Links {
+		if len(ix.links[link.Source]) >= maxLinksPerNode {
+			ix.ignored++
+			continue
+		}
+		spec := ix.nodes[link.Source]
+		spec = withLink(spec, link)
+		ix.nodes[link.Source] = spec
+		// A link's target participates in the topology even when it has
+		// no outgoing measurements of its own.
+		if _, ok := ix.nodes[link.Target]; !ok {
+			ix.nodes[link.Target] = v1alpha1.NodeSpec{}
+		}
+		if ix.links[link.Source] == nil {
+			ix.links[link.Source] = map[string]v1alpha1.Link{}
+		}
+		ix.links[link.Source][link.Target] = link
+	}
+	for _, spec := range ix.nodes {
+		if spec.Epoch == 0 {
+			continue
+		}
+		if ix.epoch != 0 && spec.Epoch != ix.epoch {
+			ix.mixedEpochs = true
+		}
+		if spec.Epoch > ix.epoch {
+			ix.epoch = spec.Epoch
+		}
+	}
+	if topo.Status.Epoch > ix.epoch {
+		ix.epoch = topo.Status.Epoch
+	}
+	return ix
+}
+
+// boundedSpec caps the spec's measurement maps — the ones the numeric view
+// walks — at maxLinksPerNode entries each, counting what it drops. Specs
+// within bounds pass through untouched.
+func (ix *Index) boundedSpec(spec v1alpha1.NodeSpec) v1alpha1.NodeSpec {
+	spec.Bandwidth = ix.boundedStringMap(spec.Bandwidth)
+	spec.BandwidthRx = ix.boundedStringMap(spec.BandwidthRx)
+	spec.Latency = ix.boundedStringMap(spec.Latency)
+	spec.Cost = ix.boundedFloatMap(spec.Cost)
+	return spec
+}
+
+func (ix *Index) boundedStringMap(in map[string]string) map[string]string {
+	if len(in) <= maxLinksPerNode {
+		return in
+	}
+	out := make(map[string]string, maxLinksPerNode)
+	keys := make([]string, 0, len(in))
+	for k := range in {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys[:maxLinksPerNode] {
+		out[k] = in[k]
+	}
+	ix.ignored += len(in) - maxLinksPerNode
+	return out
+}
+
+func (ix *Index) boundedFloatMap(in map[string]float64) map[string]float64 {
+	if len(in) <= maxLinksPerNode {
+		return in
+	}
+	out := make(map[string]float64, maxLinksPerNode)
+	keys := make([]string, 0, len(in))
+	for k := range in {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys[:maxLinksPerNode] {
+		out[k] = in[k]
+	}
+	ix.ignored += len(in) - maxLinksPerNode
+	return out
+}
+
+// Ignored reports how many measurement entries the builder dropped to honor
+// the per-node size bounds. Non-zero means the snapshot is oversized —
+// almost certainly a corrupted write, since the controller never produces
+// one — and consumers should alert on it.
+func (ix *Index) Ignored() int { return ix.ignored }
+
+// Epoch returns the newest measurement-cycle epoch appearing in the
+// snapshot — the set consumers preferring fresh data should treat as
+// current. 0 when the controller predates epoch stamping.
+func (ix *Index) Epoch() int64 { return ix.epoch }
+
+// MixedEpochs reports whether the snapshot's node entries carry different
+// epochs: a scoped remeasurement or an ad-hoc probe refreshed some nodes
+// while the rest still show an older cycle. Mixed views are usable — the
+// per-link ProbeTimes stay the staleness source of truth — but consumers
+// needing a consistent cut (diffing, auditing) should re-read once the flag
+// clears.
+func (ix *Index) MixedEpochs() bool { return ix.mixedEpochs }
+
+// withLink folds one link into a copy of the node spec's maps.
+func withLink(spec v1alpha1.NodeSpec, link v1alpha1.Link) v1alpha1.NodeSpec {
+	spec.Bandwidth = copyStringMap(spec.Bandwidth)
+	spec.BandwidthRx = copyStringMap(spec.BandwidthRx)
+	spec.Latency = copyStringMap(spec.Latency)
+	spec.LatencyStats = copyStatsMap(spec.LatencyStats)
+	spec.Cost = copyFloatMap(spec.Cost)
+	spec.ProbeTimes = copyTimeMap(spec.ProbeTimes)
+	if link.Bandwidth != "" {
+		spec.Bandwidth[link.Target] = link.Bandwidth
+	}
+	if link.BandwidthRx != "" {
+		spec.BandwidthRx[link.Target] = link.BandwidthRx
+	}
+	if link.Latency != "" {
+		spec.Latency[link.Target] = link.Latency
+	}
+	if link.LatencyStats != nil {
+		spec.LatencyStats[link.Target] = *link.LatencyStats
+	}
+	if link.Cost != 0 {
+		spec.Cost[link.Target] = link.Cost
+	}
+	if link.LastProbeTime != nil {
+		spec.ProbeTimes[link.Target] = *link.LastProbeTime
+	}
+	return spec
+}
+
+// Node returns the normalized spec for the named node: its declared fields
+// plus the measurements of any explicit links originating there.
+func (ix *Index) Node(name string) (v1alpha1.NodeSpec, bool) {
+	spec, ok := ix.nodes[name]
+	return spec, ok
+}
+
+// External reports whether a topology entry names a declared external
+// endpoint (published under the "external/" prefix) rather than a cluster
+// node.
+func External(name string) bool {
+	return strings.HasPrefix(name, v1alpha1.ExternalPrefix)
+}
+
+// LatencyMs returns the measured latency from source toward one specific
+// target — another node or an external endpoint — with ok=false when the
+// link is unmeasured or unparseable. Unlike the Best* accessors this parses
+// on demand: target-relative lookups run once per (pod, node), not hot
+// enough to justify a pre-parsed pair matrix.
+func (ix *Index) LatencyMs(source, target string) (int64, bool) {
+	spec, ok := ix.nodes[source]
+	if !ok {
+		return 0, false
+	}
+	v, ok := spec.Latency[target]
+	if !ok {
+		return 0, false
+	}
+	d, err := units.ParseLatency(v)
+	if err != nil {
+		return 0, false
+	}
+	return d.Milliseconds(), true
+}
+
+// Cost returns the published cost of the link from source toward one
+// specific target, with ok=false when the link carries no cost — unmeasured,
+// or probed without an agent on the far end.
+func (ix *Index) Cost(source, target string) (float64, bool) {
+	spec, ok := ix.nodes[source]
+	if !ok {
+		return 0, false
+	}
+	v, ok := spec.Cost[target]
+	return v, ok
+}
+
+// Link returns the explicit link between two nodes, with its per-edge
+// metadata (Loss, Path, MeasurementSource, LastProbeTime). Topologies in the
+// map representation have no explicit links.
+func (ix *Index) Link(source, target string) (v1alpha1.Link, bool) {
+	link, ok := ix.links[source][target]
+	return link, ok
+}
+
+// MinOverlayOverheadMs returns the smallest published overlay overhead among
+// the node's outgoing links, the correction matching the best-latency
+// semantics of the other accessors. ok=false when no link carries one, which
+// is the common case: the controller only publishes overheads when it
+// measures both vantages.
+func (ix *Index) MinOverlayOverheadMs(name string) (int64, bool) {
+	best := int64(-1)
+	for _, link := range ix.links[name] {
+		if link.OverlayOverhead == "" {
+			continue
+		}
+		if d, err := units.ParseLatency(link.OverlayOverhead); err == nil {
+			if ms := d.Milliseconds(); best < 0 || ms < best {
+				best = ms
+			}
+		}
+	}
+	if best < 0 {
+		return 0, false
+	}
+	return best, true
+}
+
+// NodeNames returns the names of all nodes known to the topology.
+func (ix *Index) NodeNames() []string {
+	names := make([]string, 0, len(ix.nodes))
+	for name := range ix.nodes {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Shared returns a cached index for the given snapshot. Fetched topologies
+// are treated as immutable, so pointer identity is a safe cache key; repeated
+// reads against the same snapshot (one scheduling cycle, one descheduler
+// pass) build the index once.
+func Shared(topo *v1alpha1.NetworkTopology) *Index {
+	if topo == nil {
+		return emptyIndex
+	}
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if topo == sharedTopo {
+		return sharedIndex
+	}
+	sharedTopo = topo
+	sharedIndex = NewIndex(topo)
+	return sharedIndex
+}
+
+var (
+	sharedMu    sync.Mutex
+	sharedTopo  *v1alpha1.NetworkTopology
+	sharedIndex *Index
+
+	emptyIndex = NewIndex(nil)
+)
+
+// ToLinks rewrites a map-shaped topology into the explicit links shape,
+// moving every edge from the per-node maps into Spec.Links. Node-level fields
+// (zone, capabilities, health, utilization) stay on the Nodes map. The input
+// is not mutated.
+func ToLinks(topo *v1alpha1.NetworkTopology) *v1alpha1.NetworkTopology {
+	out := topo.DeepCopy()
+	for _, source := range sortedNodeNames(out.Spec.Nodes) {
+		spec := out.Spec.Nodes[source]
+		for _, target := range sortedKeys(spec.Latency, spec.Bandwidth, spec.Cost) {
+			link := v1alpha1.Link{
+				Source:      source,
+				Target:      target,
+				Latency:     spec.Latency[target],
+				Bandwidth:   spec.Bandwidth[target],
+				BandwidthRx: spec.BandwidthRx[target],
+				Cost:        spec.Cost[target],
+			}
+			if stats, ok := spec.LatencyStats[target]; ok {
+				link.LatencyStats = &stats
+			}
+			if probed, ok := spec.ProbeTimes[target]; ok {
+				link.LastProbeTime = &probed
+			}
+			out.Spec.Links = append(out.Spec.Links, link)
+		}
+		spec.Bandwidth = nil
+		spec.BandwidthRx = nil
+		spec.Latency = nil
+		spec.LatencyStats = nil
+		spec.Cost = nil
+		spec.ProbeTimes = nil
+		out.Spec.Nodes[source] = spec
+	}
+	return out
+}
+
+// RemoveNode returns a copy of the topology with the named node and every
+// link touching it removed, in whichever representation the input uses. The
+// input is not mutated; this backs what-if analysis, not live updates.
+func RemoveNode(topo *v1alpha1.NetworkTopology, name string) *v1alpha1.NetworkTopology {
+	if topo == nil {
+		return nil
+	}
+	out := topo.DeepCopy()
+	delete(out.Spec.Nodes, name)
+	for _, spec := range out.Spec.Nodes {
+		delete(spec.Latency, name)
+		delete(spec.LatencyStats, name)
+		delete(spec.Bandwidth, name)
+		delete(spec.BandwidthRx, name)
+		delete(spec.Cost, name)
+		delete(spec.ProbeTimes, name)
+		delete(spec.MeasuredBandwidth, name)
+		delete(spec.MeasuredLatency, name)
+	}
+	if len(out.Spec.Links) > 0 {
+		links := out.Spec.Links[:0]
+		for _, link := range out.Spec.Links {
+			if link.Source != name && link.Target != name {
+				links = append(links, link)
+			}
+		}
+		out.Spec.Links = links
+	}
+	return out
+}
+
+// sortedNodeNames returns the node names in deterministic order so the
+// generated links list is stable across writes.
+func sortedNodeNames(nodes map[string]v1alpha1.NodeSpec) []string {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedKeys returns the union of the targets appearing in the measurement
+// maps, sorted.
+func sortedKeys(latency, bandwidth map[string]string, cost map[string]float64) []string {
+	seen := map[string]bool{}
+	for target := range latency {
+		seen[target] = true
+	}
+	for target := range bandwidth {
+		seen[target] = true
+	}
+	for target := range cost {
+		seen[target] = true
+	}
+	targets := make([]string, 0, len(seen))
+	for target := range seen {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func copyStringMap(in map[string]string) map[string]string {
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+func copyStatsMap(in map[string]v1alpha1.LatencyStats) map[string]v1alpha1.LatencyStats {
+	out := make(map[string]v1alpha1.LatencyStats, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+func copyTimeMap(in map[string]metav1.Time) map[string]metav1.Time {
+	out := make(map[string]metav1.Time, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+func copyFloatMap(in map[string]float64) map[string]float64 {
+	out := make(map[string]float64, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,321 @@
+package topology
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+)
+
+// mapsTopology is the fixture in the original per-node maps representation.
+func mapsTopology() *v1alpha1.NetworkTopology {
+	return topologytesting.NewTopology().
+		WithOneWayLink("pi-1", "pi-2", "2ms", "900mbps").
+		WithOneWayLink("pi-2", "pi-1", "2ms", "850mbps").
+		WithOneWayLink("pi-1", "hetzner-1", "25ms", "95mbps").
+		WithOneWayCost("pi-1", "pi-2", 0.1).
+		WithOneWayCost("pi-1", "hetzner-1", 0.8).
+		WithZone("pi-1", "local").
+		WithZone("hetzner-1", "remote").
+		WithCapabilities("hetzner-1", "internet").
+		Build()
+}
+
+func TestBothShapesGiveIdenticalLookups(t *testing.T) {
+	maps := mapsTopology()
+	links := ToLinks(maps)
+
+	if len(links.Spec.Links) == 0 {
+		t.Fatal("ToLinks produced no links")
+	}
+	for _, link := range links.Spec.Links {
+		spec := links.Spec.Nodes[link.Source]
+		if len(spec.Bandwidth) != 0 || len(spec.Latency) != 0 || len(spec.Cost) != 0 {
+			t.Fatalf("node %s still carries measurement maps after ToLinks", link.Source)
+		}
+	}
+
+	mapsIx := NewIndex(maps)
+	linksIx := NewIndex(links)
+	for _, name := range []string{"pi-1", "pi-2", "hetzner-1"} {
+		fromMaps, okMaps := mapsIx.Node(name)
+		fromLinks, okLinks := linksIx.Node(name)
+		if okMaps != okLinks {
+			t.Fatalf("node %s: maps found=%v, links found=%v", name, okMaps, okLinks)
+		}
+		if !reflect.DeepEqual(normalize(fromMaps), normalize(fromLinks)) {
+			t.Errorf("node %s differs between shapes:\nmaps:  %+v\nlinks: %+v", name, fromMaps, fromLinks)
+		}
+	}
+}
+
+// normalize replaces empty maps with nil so the two shapes compare equal:
+// folding links into a spec allocates maps the builder leaves nil.
+func normalize(spec v1alpha1.NodeSpec) v1alpha1.NodeSpec {
+	if len(spec.Bandwidth) == 0 {
+		spec.Bandwidth = nil
+	}
+	if len(spec.BandwidthRx) == 0 {
+		spec.BandwidthRx = nil
+	}
+	if len(spec.Latency) == 0 {
+		spec.Latency = nil
+	}
+	if len(spec.LatencyStats) == 0 {
+		spec.LatencyStats = nil
+	}
+	if len(spec.Cost) == 0 {
+		spec.Cost = nil
+	}
+	if len(spec.ProbeTimes) == 0 {
+		spec.ProbeTimes = nil
+	}
+	return spec
+}
+
+func TestBandwidthRxSurvivesBothShapes(t *testing.T) {
+	maps := mapsTopology()
+	spec := maps.Spec.Nodes["pi-1"]
+	spec.BandwidthRx = map[string]string{"pi-2": "120mbps"}
+	maps.Spec.Nodes["pi-1"] = spec
+
+	links := ToLinks(maps)
+	found := false
+	for _, link := range links.Spec.Links {
+		if link.Source == "pi-1" && link.Target == "pi-2" {
+			found = link.BandwidthRx == "120mbps"
+		}
+	}
+	if !found {
+		t.Fatal("ToLinks dropped the receive-direction bandwidth")
+	}
+	if len(links.Spec.Nodes["pi-1"].BandwidthRx) != 0 {
+		t.Error("node still carries the bandwidthRx map after ToLinks")
+	}
+
+	node, ok := NewIndex(links).Node("pi-1")
+	if !ok || node.BandwidthRx["pi-2"] != "120mbps" {
+		t.Errorf("index over the links shape lost bandwidthRx: %+v", node.BandwidthRx)
+	}
+}
+
+func TestLatencyStatsSurviveBothShapes(t *testing.T) {
+	maps := mapsTopology()
+	spec := maps.Spec.Nodes["pi-1"]
+	stats := v1alpha1.LatencyStats{Min: "1ms", Avg: "5ms", Max: "80ms", Mdev: "12ms"}
+	spec.LatencyStats = map[string]v1alpha1.LatencyStats{"pi-2": stats}
+	maps.Spec.Nodes["pi-1"] = spec
+
+	links := ToLinks(maps)
+	found := false
+	for _, link := range links.Spec.Links {
+		if link.Source == "pi-1" && link.Target == "pi-2" {
+			found = link.LatencyStats != nil && *link.LatencyStats == stats
+		}
+	}
+	if !found {
+		t.Fatal("ToLinks dropped the latency statistics")
+	}
+	if len(links.Spec.Nodes["pi-1"].LatencyStats) != 0 {
+		t.Error("node still carries the latencyStats map after ToLinks")
+	}
+
+	node, ok := NewIndex(links).Node("pi-1")
+	if !ok || node.LatencyStats["pi-2"] != stats {
+		t.Errorf("index over the links shape lost latency statistics: %+v", node.LatencyStats)
+	}
+}
+
+func TestLinksShapeExposesEdgeMetadata(t *testing.T) {
+	topo := ToLinks(mapsTopology())
+	for i := range topo.Spec.Links {
+		if topo.Spec.Links[i].Source == "pi-1" && topo.Spec.Links[i].Target == "hetzner-1" {
+			topo.Spec.Links[i].Loss = 0.02
+			topo.Spec.Links[i].Path = "derp-fra"
+			topo.Spec.Links[i].MeasurementSource = "iperf3"
+		}
+	}
+
+	ix := NewIndex(topo)
+	link, ok := ix.Link("pi-1", "hetzner-1")
+	if !ok {
+		t.Fatal("expected explicit link pi-1 -> hetzner-1")
+	}
+	if link.Loss != 0.02 || link.Path != "derp-fra" || link.MeasurementSource != "iperf3" {
+		t.Errorf("link metadata not preserved: %+v", link)
+	}
+	if _, ok := ix.Link("pi-2", "hetzner-1"); ok {
+		t.Error("unexpected link pi-2 -> hetzner-1")
+	}
+}
+
+func TestToLinksDoesNotMutateInput(t *testing.T) {
+	topo := mapsTopology()
+	before := topo.DeepCopy()
+	ToLinks(topo)
+	if !reflect.DeepEqual(topo, before) {
+		t.Error("ToLinks mutated its input")
+	}
+}
+
+func TestIndexHandlesNilAndUnknownNodes(t *testing.T) {
+	if _, ok := NewIndex(nil).Node("pi-1"); ok {
+		t.Error("nil topology should know no nodes")
+	}
+	if _, ok := Shared(nil).Node("pi-1"); ok {
+		t.Error("nil topology should know no nodes")
+	}
+	if _, ok := NewIndex(mapsTopology()).Node("missing"); ok {
+		t.Error("unknown node should not be found")
+	}
+}
+
+func TestRemoveNodeDropsNodeAndLinksInBothShapes(t *testing.T) {
+	for name, topo := range map[string]*v1alpha1.NetworkTopology{
+		"maps":  mapsTopology(),
+		"links": ToLinks(mapsTopology()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			before := topo.DeepCopy()
+			ix := NewIndex(RemoveNode(topo, "pi-2"))
+			if _, ok := ix.Node("pi-2"); ok {
+				t.Error("removed node still present")
+			}
+			if spec, ok := ix.Node("pi-1"); !ok {
+				t.Fatal("surviving node disappeared")
+			} else if _, ok := spec.Latency["pi-2"]; ok {
+				t.Error("surviving node still has a link toward the removed node")
+			} else if _, ok := spec.Latency["hetzner-1"]; !ok {
+				t.Error("unrelated link was dropped")
+			}
+			if !reflect.DeepEqual(topo, before) {
+				t.Error("RemoveNode mutated its input")
+			}
+		})
+	}
+}
+
+func TestSharedCachesPerSnapshot(t *testing.T) {
+	topo := mapsTopology()
+	if Shared(topo) != Shared(topo) {
+		t.Error("Shared rebuilt the index for the same snapshot")
+	}
+	other := mapsTopology()
+	if Shared(topo) == Shared(other) {
+		t.Error("Shared reused the index for a different snapshot")
+	}
+}
+
+func TestNewIndexBoundsOversizedMaps(t *testing.T) {
+	excess := 40
+	topo := mapsTopology()
+	spec := topo.Spec.Nodes["pi-1"]
+	spec.Latency = map[string]string{}
+	for i := 0; i < maxLinksPerNode+excess; i++ {
+		spec.Latency[fmt.Sprintf("ghost-%05d", i)] = "1ms"
+	}
+	topo.Spec.Nodes["pi-1"] = spec
+
+	ix := NewIndex(topo)
+	node, _ := ix.Node("pi-1")
+	if len(node.Latency) != maxLinksPerNode {
+		t.Fatalf("indexed %d latency entries, want the %d-entry bound", len(node.Latency), maxLinksPerNode)
+	}
+	if ix.Ignored() != excess {
+		t.Errorf("Ignored() = %d, want %d", ix.Ignored(), excess)
+	}
+	// Truncation is deterministic: the lexicographically-first targets stay.
+	if _, ok := node.Latency["ghost-00000"]; !ok {
+		t.Error("lexicographically-first target was dropped")
+	}
+
+	if ix := NewIndex(mapsTopology()); ix.Ignored() != 0 {
+		t.Errorf("in-bounds topology reports %d ignored entries", ix.Ignored())
+	}
+}
+
+func TestNewIndexBoundsOversizedLinksList(t *testing.T) {
+	excess := 25
+	topo := &v1alpha1.NetworkTopology{}
+	for i := 0; i < maxLinksPerNode+excess; i++ {
+		topo.Spec.Links = append(topo.Spec.Links, v1alpha1.Link{
+			Source:  "pi-1",
+			Target:  fmt.Sprintf("ghost-%05d", i),
+			Latency: "1ms",
+		})
+	}
+
+	ix := NewIndex(topo)
+	node, _ := ix.Node("pi-1")
+	if len(node.Latency) != maxLinksPerNode {
+		t.Fatalf("indexed %d links, want the %d-entry bound", len(node.Latency), maxLinksPerNode)
+	}
+	if ix.Ignored() != excess {
+		t.Errorf("Ignored() = %d, want %d", ix.Ignored(), excess)
+	}
+}
+
+// epochTopology builds a three-node topology with the given per-node epochs
+// and a matching status epoch of the largest value.
+func epochTopology(epochs map[string]int64) *v1alpha1.NetworkTopology {
+	topo := mapsTopology()
+	for name, epoch := range epochs {
+		spec := topo.Spec.Nodes[name]
+		spec.Epoch = epoch
+		topo.Spec.Nodes[name] = spec
+		if epoch > topo.Status.Epoch {
+			topo.Status.Epoch = epoch
+		}
+	}
+	return topo
+}
+
+func TestIndexEpochs(t *testing.T) {
+	uniform := NewIndex(epochTopology(map[string]int64{"pi-1": 4, "pi-2": 4, "hetzner-1": 4}))
+	if uniform.Epoch() != 4 || uniform.MixedEpochs() {
+		t.Errorf("uniform snapshot: epoch %d mixed %v, want 4/false", uniform.Epoch(), uniform.MixedEpochs())
+	}
+
+	mixed := NewIndex(epochTopology(map[string]int64{"pi-1": 5, "pi-2": 4, "hetzner-1": 4}))
+	if mixed.Epoch() != 5 || !mixed.MixedEpochs() {
+		t.Errorf("mixed snapshot: epoch %d mixed %v, want 5/true", mixed.Epoch(), mixed.MixedEpochs())
+	}
+
+	// Epochs survive the links-shape conversion: they live on the node
+	// entries, which ToLinks keeps.
+	links := NewIndex(ToLinks(epochTopology(map[string]int64{"pi-1": 5, "pi-2": 4, "hetzner-1": 4})))
+	if links.Epoch() != 5 || !links.MixedEpochs() {
+		t.Errorf("links shape: epoch %d mixed %v, want 5/true", links.Epoch(), links.MixedEpochs())
+	}
+
+	// Pre-epoch controllers write no epochs at all; that is not "mixed".
+	legacy := NewIndex(mapsTopology())
+	if legacy.Epoch() != 0 || legacy.MixedEpochs() {
+		t.Errorf("legacy snapshot: epoch %d mixed %v, want 0/false", legacy.Epoch(), legacy.MixedEpochs())
+	}
+}
+
+// TestEpochDetectsInterleavedUpdates walks a writer updating the topology one
+// node at a time, the way a scoped remeasurement lands, asserting that every
+// read taken mid-update is flagged and the final read is clean.
+func TestEpochDetectsInterleavedUpdates(t *testing.T) {
+	topo := epochTopology(map[string]int64{"pi-1": 1, "pi-2": 1, "hetzner-1": 1})
+	names := []string{"pi-1", "pi-2", "hetzner-1"}
+	for i, name := range names {
+		spec := topo.Spec.Nodes[name]
+		spec.Epoch = 2
+		topo.Spec.Nodes[name] = spec
+		topo.Status.Epoch = 2
+
+		ix := NewIndex(topo.DeepCopy())
+		partial := i < len(names)-1
+		if ix.MixedEpochs() != partial {
+			t.Errorf("after updating %d node(s): mixed = %v, want %v", i+1, ix.MixedEpochs(), partial)
+		}
+		if ix.Epoch() != 2 {
+			t.Errorf("after updating %d node(s): epoch = %d, want the newest cycle 2", i+1, ix.Epoch())
+		}
+	}
+}
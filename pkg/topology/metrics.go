@@ -0,0 +1,169 @@
+package topology
+
+import (
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// metrics is the parsed numeric view of a snapshot: a node-name→index map
+// over flat int64 slices, built once per snapshot so the scheduler's scoring
+// hot path never parses formatted strings or walks the measurement maps.
+// Unknown values are -1 so callers keep the known/unknown distinction.
+type metrics struct {
+	index         map[string]int
+	bandwidthMbps []int64
+	latencyMs     []int64
+	minCost       []float64
+	hasCost       []bool
+
+	// clusterBestBw and clusterBestLat are the cluster-wide maxima: the
+	// highest bandwidth and lowest latency any node offers. -1 when no
+	// node has a parseable value.
+	clusterBestBw  int64
+	clusterBestLat int64
+
+	// clusterHasCost records whether any node carries a cost entry at
+	// all; a snapshot without one has no pairs to be expensive relative
+	// to.
+	clusterHasCost bool
+}
+
+// numeric builds (once) and returns the parsed view.
+func (ix *Index) numeric() *metrics {
+	ix.metricsOnce.Do(func() {
+		m := &metrics{
+			index:         make(map[string]int, len(ix.nodes)),
+			bandwidthMbps: make([]int64, 0, len(ix.nodes)),
+			latencyMs:     make([]int64, 0, len(ix.nodes)),
+			minCost:       make([]float64, 0, len(ix.nodes)),
+			hasCost:       make([]bool, 0, len(ix.nodes)),
+
+			clusterBestBw:  -1,
+			clusterBestLat: -1,
+		}
+		for name, spec := range ix.nodes {
+			// External endpoint entries are not candidate nodes: whatever
+			// measurements they carry, they must not contribute to the
+			// per-node view or the cluster-wide maxima.
+			if External(name) {
+				continue
+			}
+			// Links toward external endpoints stay out of the best-link
+			// figures: "best latency toward any peer" means cluster peers,
+			// and a node whose only fast link is its NFS filer must not
+			// pass a plain max-latency on that basis. Target-relative
+			// lookups read those links through LatencyMs instead.
+			bestBw := int64(-1)
+			for target, v := range spec.Bandwidth {
+				if External(target) {
+					continue
+				}
+				if bps, err := units.ParseBandwidth(v); err == nil {
+					if mbps := units.ToMbps(bps); mbps > bestBw {
+						bestBw = mbps
+					}
+				}
+			}
+			bestLat := int64(-1)
+			for target, v := range spec.Latency {
+				if External(target) {
+					continue
+				}
+				if d, err := units.ParseLatency(v); err == nil {
+					if ms := d.Milliseconds(); bestLat < 0 || ms < bestLat {
+						bestLat = ms
+					}
+				}
+			}
+			minCost := 1.0
+			hasCost := false
+			for target, c := range spec.Cost {
+				if External(target) {
+					continue
+				}
+				if !hasCost || c < minCost {
+					minCost = c
+					hasCost = true
+				}
+			}
+			m.index[name] = len(m.bandwidthMbps)
+			m.bandwidthMbps = append(m.bandwidthMbps, bestBw)
+			m.latencyMs = append(m.latencyMs, bestLat)
+			m.minCost = append(m.minCost, minCost)
+			m.hasCost = append(m.hasCost, hasCost)
+			if hasCost {
+				m.clusterHasCost = true
+			}
+			if bestBw > m.clusterBestBw {
+				m.clusterBestBw = bestBw
+			}
+			if bestLat >= 0 && (m.clusterBestLat < 0 || bestLat < m.clusterBestLat) {
+				m.clusterBestLat = bestLat
+			}
+		}
+		ix.metrics = m
+	})
+	return ix.metrics
+}
+
+// BestBandwidthMbps returns the node's best parseable outgoing bandwidth in
+// mbps, with ok=false when the node is unknown or has no parseable value.
+func (ix *Index) BestBandwidthMbps(name string) (int64, bool) {
+	m := ix.numeric()
+	i, ok := m.index[name]
+	if !ok || m.bandwidthMbps[i] < 0 {
+		return 0, false
+	}
+	return m.bandwidthMbps[i], true
+}
+
+// BestLatencyMs returns the node's best parseable outgoing latency in
+// milliseconds, with ok=false when the node is unknown or unmeasured.
+func (ix *Index) BestLatencyMs(name string) (int64, bool) {
+	m := ix.numeric()
+	i, ok := m.index[name]
+	if !ok || m.latencyMs[i] < 0 {
+		return 0, false
+	}
+	return m.latencyMs[i], true
+}
+
+// ClusterBestBandwidthMbps returns the highest measured bandwidth any node
+// offers, with ok=false when nothing in the topology is measured. Because the
+// index is cached per snapshot, callers get refreshed maxima with every
+// topology update at no per-call cost.
+func (ix *Index) ClusterBestBandwidthMbps() (int64, bool) {
+	m := ix.numeric()
+	if m.clusterBestBw < 0 {
+		return 0, false
+	}
+	return m.clusterBestBw, true
+}
+
+// ClusterBestLatencyMs returns the lowest measured latency any node offers,
+// with ok=false when nothing in the topology is measured.
+func (ix *Index) ClusterBestLatencyMs() (int64, bool) {
+	m := ix.numeric()
+	if m.clusterBestLat < 0 {
+		return 0, false
+	}
+	return m.clusterBestLat, true
+}
+
+// ClusterHasCosts reports whether any node in the snapshot carries a cost
+// entry. A single-node cluster (or one whose pairs all failed to measure)
+// has none, and cost penalties are meaningless without peers to compare
+// against.
+func (ix *Index) ClusterHasCosts() bool {
+	return ix.numeric().clusterHasCost
+}
+
+// MinCost returns the node's cheapest outgoing link cost, with ok=false when
+// the node has no cost entries.
+func (ix *Index) MinCost(name string) (float64, bool) {
+	m := ix.numeric()
+	i, ok := m.index[name]
+	if !ok || !m.hasCost[i] {
+		return 0, false
+	}
+	return m.minCost[i], true
+}
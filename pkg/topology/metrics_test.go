@@ -0,0 +1,67 @@
+package topology
+
+import (
+	"testing"
+
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+)
+
+func TestNumericViewMatchesStringMaps(t *testing.T) {
+	ix := NewIndex(mapsTopology())
+
+	if bw, ok := ix.BestBandwidthMbps("pi-1"); !ok || bw != 900 {
+		t.Errorf("BestBandwidthMbps(pi-1) = %d, %v; want 900, true", bw, ok)
+	}
+	if lat, ok := ix.BestLatencyMs("pi-1"); !ok || lat != 2 {
+		t.Errorf("BestLatencyMs(pi-1) = %d, %v; want 2, true", lat, ok)
+	}
+	if cost, ok := ix.MinCost("pi-1"); !ok || cost != 0.1 {
+		t.Errorf("MinCost(pi-1) = %v, %v; want 0.1, true", cost, ok)
+	}
+
+	// hetzner-1 has no outgoing measurements in the fixture.
+	if _, ok := ix.BestBandwidthMbps("hetzner-1"); ok {
+		t.Error("node without measurements reported a bandwidth")
+	}
+	if _, ok := ix.MinCost("hetzner-1"); ok {
+		t.Error("node without cost entries reported a cost")
+	}
+	if _, ok := ix.BestLatencyMs("missing"); ok {
+		t.Error("unknown node reported a latency")
+	}
+}
+
+func TestNumericViewCoversLinksShape(t *testing.T) {
+	ix := NewIndex(ToLinks(mapsTopology()))
+	if bw, ok := ix.BestBandwidthMbps("pi-1"); !ok || bw != 900 {
+		t.Errorf("BestBandwidthMbps(pi-1) = %d, %v; want 900, true", bw, ok)
+	}
+}
+
+func TestExternalEndpointsStayOutOfBestLinks(t *testing.T) {
+	// edge-1's only fast link is its NFS filer; the best-link figures must
+	// still describe its cluster peers.
+	ix := NewIndex(topologytesting.NewTopology().
+		WithLink("edge-1", "core", "40ms", "50mbps").
+		WithLink("edge-1", "external/nfs-1", "1ms", "900mbps").
+		WithCost("edge-1", "external/nfs-1", 0.05).
+		Build())
+
+	if lat, ok := ix.BestLatencyMs("edge-1"); !ok || lat != 40 {
+		t.Errorf("BestLatencyMs(edge-1) = %d, %v; want the peer link's 40", lat, ok)
+	}
+	if bw, ok := ix.BestBandwidthMbps("edge-1"); !ok || bw != 50 {
+		t.Errorf("BestBandwidthMbps(edge-1) = %d, %v; want the peer link's 50", bw, ok)
+	}
+	if best, ok := ix.ClusterBestLatencyMs(); !ok || best != 40 {
+		t.Errorf("ClusterBestLatencyMs() = %d, %v; want 40", best, ok)
+	}
+
+	// The target-relative accessor is how the endpoint leg is read.
+	if lat, ok := ix.LatencyMs("edge-1", "external/nfs-1"); !ok || lat != 1 {
+		t.Errorf("LatencyMs(edge-1, external/nfs-1) = %d, %v; want 1, true", lat, ok)
+	}
+	if _, ok := ix.LatencyMs("edge-1", "external/ghost"); ok {
+		t.Error("unmeasured endpoint leg reported a latency")
+	}
+}
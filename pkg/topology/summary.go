@@ -0,0 +1,148 @@
+package topology
+
+import (
+	"sort"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/measure"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// ZoneSummary aggregates the directed links from one zone toward another:
+// the triage view for "how is the local zone talking to the remote zone"
+// without walking individual links. Medians are -1 when no link between the
+// zones carries a parseable value.
+type ZoneSummary struct {
+	Source              string `json:"source"`
+	Target              string `json:"target"`
+	Links               int    `json:"links"`
+	MedianLatencyMs     int64  `json:"medianLatencyMs"`
+	MedianBandwidthMbps int64  `json:"medianBandwidthMbps"`
+}
+
+// zonePair accumulates one zone pair's samples while summarizing.
+type zonePair struct {
+	links       int
+	latencies   []int64
+	bandwidths  []int64
+	source, dst string
+}
+
+// ZoneSummaries computes the zone×zone medians over all measured links,
+// sorted by source then target zone. Nodes without a zone group under "".
+func (ix *Index) ZoneSummaries() []ZoneSummary {
+	pairs := map[[2]string]*zonePair{}
+	for _, source := range ix.NodeNames() {
+		spec := ix.nodes[source]
+		for target, latStr := range spec.Latency {
+			key := [2]string{spec.Zone, ix.nodes[target].Zone}
+			pair := pairs[key]
+			if pair == nil {
+				pair = &zonePair{source: key[0], dst: key[1]}
+				pairs[key] = pair
+			}
+			pair.links++
+			if d, err := units.ParseLatency(latStr); err == nil {
+				pair.latencies = append(pair.latencies, d.Milliseconds())
+			}
+			if bps, err := units.ParseBandwidth(spec.Bandwidth[target]); err == nil {
+				pair.bandwidths = append(pair.bandwidths, units.ToMbps(bps))
+			}
+		}
+	}
+	out := make([]ZoneSummary, 0, len(pairs))
+	for _, pair := range pairs {
+		out = append(out, ZoneSummary{
+			Source:              pair.source,
+			Target:              pair.dst,
+			Links:               pair.links,
+			MedianLatencyMs:     median(pair.latencies),
+			MedianBandwidthMbps: median(pair.bandwidths),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Source != out[j].Source {
+			return out[i].Source < out[j].Source
+		}
+		return out[i].Target < out[j].Target
+	})
+	return out
+}
+
+// median returns the middle value of the samples (the mean of the two middle
+// ones for even counts), or -1 for an empty set.
+func median(samples []int64) int64 {
+	if len(samples) == 0 {
+		return -1
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	mid := len(samples) / 2
+	if len(samples)%2 == 1 {
+		return samples[mid]
+	}
+	return (samples[mid-1] + samples[mid]) / 2
+}
+
+// CapabilityNodes maps each capability to the sorted list of nodes carrying
+// it.
+func (ix *Index) CapabilityNodes() map[string][]string {
+	out := map[string][]string{}
+	for _, name := range ix.NodeNames() {
+		for _, capability := range ix.nodes[name].Capabilities {
+			out[capability] = append(out[capability], name)
+		}
+	}
+	for _, nodes := range out {
+		sort.Strings(nodes)
+	}
+	return out
+}
+
+// RankedLink is one directed link with its recorded values and the health it
+// scores under the default model.
+type RankedLink struct {
+	Source    string  `json:"source"`
+	Target    string  `json:"target"`
+	Latency   string  `json:"latency,omitempty"`
+	Bandwidth string  `json:"bandwidth,omitempty"`
+	Health    float64 `json:"health"`
+}
+
+// WorstLinks returns the n unhealthiest measured links cluster-wide,
+// unhealthiest first. Links without a parseable latency are skipped: they
+// cannot be scored, and "unmeasured" is a different question than "bad".
+func (ix *Index) WorstLinks(n int) []RankedLink {
+	var ranked []RankedLink
+	for _, source := range ix.NodeNames() {
+		spec := ix.nodes[source]
+		for target, latStr := range spec.Latency {
+			lat, err := units.ParseLatency(latStr)
+			if err != nil {
+				continue
+			}
+			var bw int64
+			if bps, err := units.ParseBandwidth(spec.Bandwidth[target]); err == nil {
+				bw = bps
+			}
+			ranked = append(ranked, RankedLink{
+				Source:    source,
+				Target:    target,
+				Latency:   latStr,
+				Bandwidth: spec.Bandwidth[target],
+				Health:    measure.Round2(measure.LinkHealth(lat, bw)),
+			})
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Health != ranked[j].Health {
+			return ranked[i].Health < ranked[j].Health
+		}
+		if ranked[i].Source != ranked[j].Source {
+			return ranked[i].Source < ranked[j].Source
+		}
+		return ranked[i].Target < ranked[j].Target
+	})
+	if n > 0 && len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
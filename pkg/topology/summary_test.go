@@ -0,0 +1,105 @@
+package topology
+
+import (
+	"reflect"
+	"testing"
+
+	topologytesting "github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1/testing"
+)
+
+func summaryTopology() *Index {
+	return NewIndex(topologytesting.NewTopology().
+		WithOneWayLink("pi-1", "pi-2", "2ms", "900mbps").
+		WithOneWayLink("pi-2", "pi-1", "4ms", "850mbps").
+		WithOneWayLink("pi-1", "hetzner-1", "25ms", "95mbps").
+		WithOneWayLink("pi-2", "hetzner-1", "35ms", "80mbps").
+		WithZone("pi-1", "local").
+		WithZone("pi-2", "local").
+		WithZone("hetzner-1", "remote").
+		WithCapabilities("hetzner-1", "internet", "gateway").
+		WithCapabilities("pi-1", "gpu-direct").
+		Build())
+}
+
+func TestZoneSummariesMedians(t *testing.T) {
+	summaries := summaryTopology().ZoneSummaries()
+	byPair := map[[2]string]ZoneSummary{}
+	for _, s := range summaries {
+		byPair[[2]string{s.Source, s.Target}] = s
+	}
+
+	// local→remote has two links; even counts average the middle pair.
+	lr, ok := byPair[[2]string{"local", "remote"}]
+	if !ok {
+		t.Fatalf("no local->remote summary in %v", summaries)
+	}
+	if lr.Links != 2 || lr.MedianLatencyMs != 30 || lr.MedianBandwidthMbps != 87 {
+		t.Errorf("local->remote = %+v, want 2 links, 30ms, 87mbps", lr)
+	}
+	// local→local also has two links (one per direction).
+	ll := byPair[[2]string{"local", "local"}]
+	if ll.Links != 2 || ll.MedianLatencyMs != 3 {
+		t.Errorf("local->local = %+v, want 2 links with 3ms median", ll)
+	}
+	// remote has no outgoing measurements, so no remote→* summary exists.
+	if _, ok := byPair[[2]string{"remote", "local"}]; ok {
+		t.Error("remote->local summary exists without any measured link")
+	}
+}
+
+func TestZoneSummariesCoverLinksShape(t *testing.T) {
+	maps := summaryTopology().ZoneSummaries()
+	links := NewIndex(ToLinks(topologytesting.NewTopology().
+		WithOneWayLink("pi-1", "pi-2", "2ms", "900mbps").
+		WithOneWayLink("pi-2", "pi-1", "4ms", "850mbps").
+		WithOneWayLink("pi-1", "hetzner-1", "25ms", "95mbps").
+		WithOneWayLink("pi-2", "hetzner-1", "35ms", "80mbps").
+		WithZone("pi-1", "local").
+		WithZone("pi-2", "local").
+		WithZone("hetzner-1", "remote").
+		Build())).ZoneSummaries()
+	if !reflect.DeepEqual(maps, links) {
+		t.Errorf("summaries differ between shapes:\nmaps:  %v\nlinks: %v", maps, links)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	if got := median(nil); got != -1 {
+		t.Errorf("median(nil) = %d, want -1", got)
+	}
+	if got := median([]int64{30, 10, 20}); got != 20 {
+		t.Errorf("odd median = %d, want 20", got)
+	}
+	if got := median([]int64{40, 10, 20, 30}); got != 25 {
+		t.Errorf("even median = %d, want 25", got)
+	}
+}
+
+func TestCapabilityNodes(t *testing.T) {
+	got := summaryTopology().CapabilityNodes()
+	want := map[string][]string{
+		"internet":   {"hetzner-1"},
+		"gateway":    {"hetzner-1"},
+		"gpu-direct": {"pi-1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CapabilityNodes = %v, want %v", got, want)
+	}
+}
+
+func TestWorstLinksOrdering(t *testing.T) {
+	links := summaryTopology().WorstLinks(2)
+	if len(links) != 2 {
+		t.Fatalf("WorstLinks(2) returned %d links", len(links))
+	}
+	// The slowest, thinnest link must rank worst.
+	if links[0].Source != "pi-2" || links[0].Target != "hetzner-1" {
+		t.Errorf("worst link = %s->%s, want pi-2->hetzner-1", links[0].Source, links[0].Target)
+	}
+	if links[0].Health > links[1].Health {
+		t.Errorf("links not ordered unhealthiest first: %v", links)
+	}
+	if all := summaryTopology().WorstLinks(0); len(all) != 4 {
+		t.Errorf("WorstLinks(0) returned %d links, want all 4", len(all))
+	}
+}
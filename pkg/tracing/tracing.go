@@ -0,0 +1,89 @@
+// Package tracing wires optional OpenTelemetry tracing for the controller
+// and scheduler binaries. Tracing is off by default: it activates only when
+// the standard OTLP environment variables are set or a stdout exporter is
+// requested explicitly, and an unset provider leaves the instrumentation
+// sites on otel's no-op tracer, which costs nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// Options selects the exporter and sampling for one process.
+type Options struct {
+	// Enabled turns on the OTLP exporter, configured entirely through the
+	// standard OTEL_EXPORTER_OTLP_* environment variables.
+	Enabled bool
+	// Stdout swaps the exporter for a pretty-printed stdout one, the
+	// docker-compose-less way to verify spans locally.
+	Stdout bool
+	// SampleRatio is the fraction of new traces to sample; values outside
+	// (0, 1] mean "sample everything".
+	SampleRatio float64
+}
+
+// EnabledFromEnv reports whether the standard OTLP endpoint variables are
+// set, the signal that an operator wants traces exported.
+func EnabledFromEnv() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// SampleRatioFromEnv reads the standard OTEL_TRACES_SAMPLER_ARG variable,
+// returning 0 (meaning "sample everything" to Setup) when unset or invalid.
+func SampleRatioFromEnv() float64 {
+	v := os.Getenv("OTEL_TRACES_SAMPLER_ARG")
+	if v == "" {
+		return 0
+	}
+	ratio, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0
+	}
+	return ratio
+}
+
+// Setup installs the global tracer provider and returns a shutdown function
+// that flushes pending spans. With tracing disabled it is a no-op returning a
+// no-op shutdown, so callers wire it unconditionally.
+func Setup(ctx context.Context, service string, opts Options) (func(context.Context) error, error) {
+	if !opts.Enabled && !opts.Stdout {
+		return func(context.Context) error { return nil }, nil
+	}
+	var exporter sdktrace.SpanExporter
+	var err error
+	if opts.Stdout {
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	} else {
+		exporter, err = otlptracegrpc.New(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("building span exporter: %w", err)
+	}
+	ratio := opts.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(service)))
+	if err != nil {
+		return nil, fmt.Errorf("building resource: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
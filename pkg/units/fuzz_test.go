@@ -0,0 +1,51 @@
+package units
+
+import (
+	"testing"
+)
+
+// The fuzz targets assert the parsers' safety contract: any input either
+// parses to a non-negative value or returns an error — never a panic, a hang
+// or a negative result. Run with `go test -fuzz FuzzParseBandwidth ./pkg/units`.
+
+func FuzzParseBandwidth(f *testing.F) {
+	for _, seed := range []string{
+		"850mbps", "1.5 Gbps", "2Gbit/s", "500 kbit", "9600bps",
+		"", "mbps", "-5mbps", "1..5mbps", "100tbps", "0mbps",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		bps, err := ParseBandwidth(s)
+		if err != nil {
+			return
+		}
+		if bps < 0 {
+			t.Errorf("ParseBandwidth(%q) = %d, negative without error", s, bps)
+		}
+		if len(s) > MaxValueLength {
+			t.Errorf("ParseBandwidth accepted %d bytes, limit is %d", len(s), MaxValueLength)
+		}
+	})
+}
+
+func FuzzParseLatency(f *testing.F) {
+	for _, seed := range []string{
+		"12ms", "0.5s", "800us", "300µs", " 5 ms ", "10MS",
+		"", "ms", "-3ms", "10", "slow",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		d, err := ParseLatency(s)
+		if err != nil {
+			return
+		}
+		if d < 0 {
+			t.Errorf("ParseLatency(%q) = %v, negative without error", s, d)
+		}
+		if len(s) > MaxValueLength {
+			t.Errorf("ParseLatency accepted %d bytes, limit is %d", len(s), MaxValueLength)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+// Package units parses and formats the bandwidth and latency strings used
+// throughout the topology ("850mbps", "12ms"). It is shared by the scheduler
+// plugin and the topology controller so both sides agree on the accepted
+// spellings.
+package units
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BitsPerSecond multipliers.
+const (
+	Kbps int64 = 1000
+	Mbps int64 = 1000 * 1000
+	Gbps int64 = 1000 * 1000 * 1000
+)
+
+// MaxValueLength bounds the input accepted by the parsers. Legitimate values
+// are a dozen characters; the limit exists so a corrupted topology object
+// (a multi-megabyte blob pasted into a bandwidth field) costs one length
+// check per scheduling cycle instead of a normalization pass over the blob.
+const MaxValueLength = 64
+
+// bandwidthUnits maps a normalized unit suffix to its bits-per-second
+// multiplier. Normalization lowercases and strips whitespace and a trailing
+// "/s", so "Mbit/s", "mb/s" and "mbps" all resolve to the same entry.
+var bandwidthUnits = map[string]int64{
+	"bps":  1,
+	"bit":  1,
+	"kbps": Kbps,
+	"kbit": Kbps,
+	"kb":   Kbps,
+	"mbps": Mbps,
+	"mbit": Mbps,
+	"mb":   Mbps,
+	"gbps": Gbps,
+	"gbit": Gbps,
+	"gb":   Gbps,
+}
+
+// ParseBandwidth converts strings like "850mbps", "1.5 Gbps" or "2Gbit/s"
+// into bits per second. It accepts decimal values, surrounding and internal
+// whitespace, and the common unit spellings in any case.
+func ParseBandwidth(s string) (int64, error) {
+	if len(s) > MaxValueLength {
+		return 0, fmt.Errorf("bandwidth value is %d bytes, limit is %d", len(s), MaxValueLength)
+	}
+	normalized := strings.ToLower(strings.Join(strings.Fields(s), ""))
+	if normalized == "" {
+		return 0, fmt.Errorf("empty bandwidth value")
+	}
+	normalized = strings.TrimSuffix(normalized, "/s")
+
+	i := len(normalized)
+	for i > 0 && !isDigit(normalized[i-1]) && normalized[i-1] != '.' {
+		i--
+	}
+	number, unit := normalized[:i], normalized[i:]
+	if number == "" {
+		return 0, fmt.Errorf("bandwidth %q has no numeric value", s)
+	}
+	multiplier, ok := bandwidthUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("bandwidth %q has unknown unit %q", s, unit)
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bandwidth %q: %w", s, err)
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("bandwidth %q is negative", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// ParseLatency converts strings like "12ms", "0.5s", "800us" or "300µs" into
+// a duration, tolerating surrounding and internal whitespace and any case.
+func ParseLatency(s string) (time.Duration, error) {
+	if len(s) > MaxValueLength {
+		return 0, fmt.Errorf("latency value is %d bytes, limit is %d", len(s), MaxValueLength)
+	}
+	normalized := strings.ToLower(strings.Join(strings.Fields(s), ""))
+	if normalized == "" {
+		return 0, fmt.Errorf("empty latency value")
+	}
+	d, err := time.ParseDuration(normalized)
+	if err != nil {
+		return 0, fmt.Errorf("latency %q: %w", s, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("latency %q is negative", s)
+	}
+	return d, nil
+}
+
+// FormatBandwidth renders bits per second in the topology's canonical
+// "<n>mbps" form, using gbps for round gigabit values.
+func FormatBandwidth(bps int64) string {
+	if bps >= Gbps && bps%Gbps == 0 {
+		return fmt.Sprintf("%dgbps", bps/Gbps)
+	}
+	if bps%Mbps == 0 {
+		return fmt.Sprintf("%dmbps", bps/Mbps)
+	}
+	return fmt.Sprintf("%.1fmbps", float64(bps)/float64(Mbps))
+}
+
+// FormatLatency renders a duration in the topology's canonical "<n>ms" form.
+func FormatLatency(d time.Duration) string {
+	ms := float64(d) / float64(time.Millisecond)
+	if ms == float64(int64(ms)) {
+		return fmt.Sprintf("%dms", int64(ms))
+	}
+	return fmt.Sprintf("%.2fms", ms)
+}
+
+// ToMbps converts bits per second to whole megabits per second, rounding to
+// the nearest value.
+func ToMbps(bps int64) int64 {
+	return (bps + Mbps/2) / Mbps
+}
+
+func isDigit(b byte) bool { return b >= '0' && b <= '9' }
@@ -0,0 +1,141 @@
+package units
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "850mbps", want: 850 * Mbps},
+		{in: "1.5mbps", want: 1500 * Kbps},
+		{in: "100 Mbps", want: 100 * Mbps},
+		{in: "2Gbit/s", want: 2 * Gbps},
+		{in: "2gbps", want: 2 * Gbps},
+		{in: "  10gbps  ", want: 10 * Gbps},
+		{in: "0.5gbps", want: 500 * Mbps},
+		{in: "500kbps", want: 500 * Kbps},
+		{in: "500 kbit", want: 500 * Kbps},
+		{in: "1.25 GB", want: 1250 * Mbps},
+		{in: "9600bps", want: 9600},
+		{in: "100Mb/s", want: 100 * Mbps},
+		{in: "0mbps", want: 0},
+		{in: "", wantErr: true},
+		{in: "mbps", wantErr: true},
+		{in: "100", wantErr: true},
+		{in: "100tbps", wantErr: true},
+		{in: "-5mbps", wantErr: true},
+		{in: "fast", wantErr: true},
+		{in: "1..5mbps", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseBandwidth(tc.in)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("ParseBandwidth(%q) = %d, want error", tc.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseBandwidth(%q): %v", tc.in, err)
+			}
+			if got != tc.want {
+				t.Fatalf("ParseBandwidth(%q) = %d, want %d", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseLatency(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "12ms", want: 12 * time.Millisecond},
+		{in: "0.5s", want: 500 * time.Millisecond},
+		{in: "800us", want: 800 * time.Microsecond},
+		{in: "300µs", want: 300 * time.Microsecond},
+		{in: " 5 ms ", want: 5 * time.Millisecond},
+		{in: "1.5ms", want: 1500 * time.Microsecond},
+		{in: "2s", want: 2 * time.Second},
+		{in: "0ms", want: 0},
+		{in: "10MS", want: 10 * time.Millisecond},
+		{in: "", wantErr: true},
+		{in: "ms", wantErr: true},
+		{in: "10", wantErr: true},
+		{in: "-3ms", wantErr: true},
+		{in: "slow", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseLatency(tc.in)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("ParseLatency(%q) = %v, want error", tc.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLatency(%q): %v", tc.in, err)
+			}
+			if got != tc.want {
+				t.Fatalf("ParseLatency(%q) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseRejectsOversizedInput(t *testing.T) {
+	blob := strings.Repeat("9", MaxValueLength) + "mbps"
+	if _, err := ParseBandwidth(blob); err == nil {
+		t.Fatalf("ParseBandwidth accepted a %d-byte value", len(blob))
+	}
+	if _, err := ParseLatency(blob); err == nil {
+		t.Fatalf("ParseLatency accepted a %d-byte value", len(blob))
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	for _, bps := range []int64{10 * Mbps, 850 * Mbps, 2 * Gbps, 1500 * Kbps} {
+		got, err := ParseBandwidth(FormatBandwidth(bps))
+		if err != nil {
+			t.Fatalf("round-trip %d: %v", bps, err)
+		}
+		if got != bps {
+			t.Fatalf("round-trip %d came back as %d", bps, got)
+		}
+	}
+	for _, d := range []time.Duration{time.Millisecond, 12 * time.Millisecond, 2500 * time.Microsecond} {
+		got, err := ParseLatency(FormatLatency(d))
+		if err != nil {
+			t.Fatalf("round-trip %v: %v", d, err)
+		}
+		if got != d {
+			t.Fatalf("round-trip %v came back as %v", d, got)
+		}
+	}
+}
+
+func TestToMbps(t *testing.T) {
+	tests := []struct {
+		bps  int64
+		want int64
+	}{
+		{bps: 850 * Mbps, want: 850},
+		{bps: 1500 * Kbps, want: 2}, // rounds to nearest
+		{bps: 1400 * Kbps, want: 1},
+		{bps: 0, want: 0},
+	}
+	for _, tc := range tests {
+		if got := ToMbps(tc.bps); got != tc.want {
+			t.Fatalf("ToMbps(%d) = %d, want %d", tc.bps, got, tc.want)
+		}
+	}
+}
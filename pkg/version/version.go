@@ -0,0 +1,40 @@
+// Package version exposes build metadata stamped into the binaries at
+// build time via
+//
+//	-ldflags "-X github.com/KomarovAI/k3s-network-aware-cluster/pkg/version.GitSHA=$(git rev-parse --short HEAD) \
+//	          -X github.com/KomarovAI/k3s-network-aware-cluster/pkg/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Binaries built without the flags report "unknown".
+package version
+
+import (
+	"runtime"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/features"
+)
+
+var (
+	// GitSHA is the commit the binary was built from.
+	GitSHA = "unknown"
+	// BuildDate is the UTC build timestamp.
+	BuildDate = "unknown"
+)
+
+// Info is the /version endpoint payload: what the binary is and which
+// feature gates it is running with.
+type Info struct {
+	GitSHA       string          `json:"gitSHA"`
+	BuildDate    string          `json:"buildDate"`
+	GoVersion    string          `json:"goVersion"`
+	FeatureGates map[string]bool `json:"featureGates"`
+}
+
+// Get snapshots the build metadata and the process-wide feature gates.
+func Get() Info {
+	return Info{
+		GitSHA:       GitSHA,
+		BuildDate:    BuildDate,
+		GoVersion:    runtime.Version(),
+		FeatureGates: features.Default.Map(),
+	}
+}
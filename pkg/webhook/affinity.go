@@ -0,0 +1,252 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// AnnotationAffinityInjected marks pods whose nodeAffinity was written by the
+// webhook from their network annotations, so a later admission can refresh
+// the terms instead of treating them as user intent.
+const AnnotationAffinityInjected = "network.komarov.dev/affinity-injected"
+
+// networkAwareSchedulerName is the scheduler that evaluates the annotations
+// natively; pods targeting it need no affinity translation.
+const networkAwareSchedulerName = "network-aware-scheduler"
+
+// affinityCacheTTL bounds how stale the injector's view of the topology and
+// node list may be. Admission is latency-sensitive; a fetch per pod is not an
+// option.
+const affinityCacheTTL = 30 * time.Second
+
+var affinityTopologyGVR = v1alpha1.SchemeGroupVersion.WithResource("networktopologies")
+
+// affinityInjector translates a pod's network annotations into native
+// nodeAffinity for clusters that cannot run a second scheduler: hard
+// requirements become a required term excluding the nodes that currently
+// fail them, soft ones become preferred terms. The translation is a
+// point-in-time snapshot — unlike the plugin it cannot react to topology
+// changes after admission — which is why injected terms are marked for
+// refresh.
+type affinityInjector struct {
+	topology func(ctx context.Context) (*v1alpha1.NetworkTopology, error)
+	nodes    func(ctx context.Context) ([]v1.Node, error)
+	policy   requirements.UnknownPolicy
+
+	mu          sync.Mutex
+	cachedTopo  *v1alpha1.NetworkTopology
+	cachedNodes []v1.Node
+	expires     time.Time
+}
+
+// WithAffinityInjection enables the nodeAffinity translation mode.
+func (s *Server) WithAffinityInjection(dyn dynamic.Interface) *Server {
+	s.affinity = &affinityInjector{
+		topology: func(ctx context.Context) (*v1alpha1.NetworkTopology, error) {
+			obj, err := dyn.Resource(affinityTopologyGVR).Get(ctx, "cluster", metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			topo := &v1alpha1.NetworkTopology{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, topo); err != nil {
+				return nil, fmt.Errorf("converting NetworkTopology: %w", err)
+			}
+			return topo, nil
+		},
+		nodes: func(ctx context.Context) ([]v1.Node, error) {
+			list, err := s.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		},
+		policy: requirements.DefaultUnknownPolicy(),
+	}
+	return s
+}
+
+// snapshot returns the cached topology and node list, refreshing both when
+// the TTL has expired. A refresh failure serves the previous snapshot.
+func (a *affinityInjector) snapshot(ctx context.Context) (*v1alpha1.NetworkTopology, []v1.Node, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if time.Now().Before(a.expires) {
+		return a.cachedTopo, a.cachedNodes, nil
+	}
+	topo, err := a.topology(ctx)
+	if err != nil {
+		if a.cachedTopo != nil {
+			return a.cachedTopo, a.cachedNodes, nil
+		}
+		return nil, nil, err
+	}
+	nodes, err := a.nodes(ctx)
+	if err != nil {
+		if a.cachedTopo != nil {
+			return a.cachedTopo, a.cachedNodes, nil
+		}
+		return nil, nil, err
+	}
+	a.cachedTopo, a.cachedNodes = topo, nodes
+	a.expires = time.Now().Add(affinityCacheTTL)
+	return topo, nodes, nil
+}
+
+// inject computes the patch ops adding nodeAffinity derived from the pod's
+// annotations, or nil when the pod needs none: no network annotations, the
+// network-aware scheduler will handle it, or it carries its own affinity that
+// we did not write. Every failure path injects nothing.
+func (a *affinityInjector) inject(ctx context.Context, pod *v1.Pod) []PatchOp {
+	if pod.Spec.SchedulerName == networkAwareSchedulerName {
+		return nil
+	}
+	if pod.Spec.Affinity != nil && pod.Spec.Affinity.NodeAffinity != nil &&
+		pod.Annotations[AnnotationAffinityInjected] != "true" {
+		// Hand-written affinity wins; merging with it would be a lottery.
+		return nil
+	}
+	reqs, err := requirements.Parse(pod)
+	if err != nil || reqs.Empty() {
+		return nil
+	}
+	topo, nodes, err := a.snapshot(ctx)
+	if err != nil {
+		klog.V(2).InfoS("Topology unavailable, skipping affinity injection",
+			"pod", pod.GenerateName+pod.Name, "err", err)
+		return nil
+	}
+
+	affinity := a.buildNodeAffinity(reqs, topo, nodes)
+	if affinity == nil {
+		return nil
+	}
+
+	var patch []PatchOp
+	switch {
+	case pod.Spec.Affinity == nil:
+		patch = append(patch, PatchOp{Op: "add", Path: "/spec/affinity",
+			Value: &v1.Affinity{NodeAffinity: affinity}})
+	case pod.Spec.Affinity.NodeAffinity == nil:
+		patch = append(patch, PatchOp{Op: "add", Path: "/spec/affinity/nodeAffinity", Value: affinity})
+	default:
+		// Refresh terms we injected on a previous admission.
+		patch = append(patch, PatchOp{Op: "replace", Path: "/spec/affinity/nodeAffinity", Value: affinity})
+	}
+	if pod.Annotations == nil {
+		patch = append(patch, PatchOp{Op: "add", Path: "/metadata/annotations", Value: map[string]string{}})
+	}
+	patch = append(patch, PatchOp{
+		Op:    "add",
+		Path:  "/metadata/annotations/" + escapePatchPath(AnnotationAffinityInjected),
+		Value: "true",
+	})
+	return patch
+}
+
+// buildNodeAffinity evaluates the requirements against every node, excluding
+// hard failures via a required term and down-weighting soft ones via
+// preferred terms, mirroring the plugin's filter/score split.
+func (a *affinityInjector) buildNodeAffinity(reqs *requirements.Requirements, topo *v1alpha1.NetworkTopology, nodes []v1.Node) *v1.NodeAffinity {
+	var excluded []string
+	eligible := 0
+	// Nodes sharing a preference weight are grouped into one term.
+	byWeight := map[int32][]string{}
+	for i := range nodes {
+		node := &nodes[i]
+		hard := false
+		var softSeverity float64
+		for _, v := range requirements.Evaluate(reqs, node, topo, a.policy) {
+			if v.Mode == requirements.ModeRequired {
+				hard = true
+				break
+			}
+			// Severity 0 means full weight, same as the plugin's penalty
+			// scaling; without this a violating node ties a clean one.
+			if v.Severity > 0 {
+				softSeverity += v.Severity
+			} else {
+				softSeverity++
+			}
+		}
+		if hard {
+			excluded = append(excluded, node.Name)
+			continue
+		}
+		eligible++
+		if weight := preferenceWeight(softSeverity); weight > 0 {
+			byWeight[weight] = append(byWeight[weight], node.Name)
+		}
+	}
+
+	affinity := &v1.NodeAffinity{}
+	if len(excluded) > 0 {
+		sort.Strings(excluded)
+		affinity.RequiredDuringSchedulingIgnoredDuringExecution = &v1.NodeSelector{
+			NodeSelectorTerms: []v1.NodeSelectorTerm{{
+				MatchFields: []v1.NodeSelectorRequirement{{
+					Key:      "metadata.name",
+					Operator: v1.NodeSelectorOpNotIn,
+					Values:   excluded,
+				}},
+			}},
+		}
+	}
+	if len(byWeight) == 1 {
+		// A preference only ranks when it separates nodes: drop it when
+		// every eligible node landed in the single weight bucket.
+		for _, names := range byWeight {
+			if len(names) == eligible {
+				byWeight = nil
+			}
+		}
+	}
+	weights := make([]int32, 0, len(byWeight))
+	for weight := range byWeight {
+		weights = append(weights, weight)
+	}
+	sort.Slice(weights, func(i, j int) bool { return weights[i] > weights[j] })
+	for _, weight := range weights {
+		names := byWeight[weight]
+		sort.Strings(names)
+		affinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+			affinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			v1.PreferredSchedulingTerm{
+				Weight: weight,
+				Preference: v1.NodeSelectorTerm{
+					MatchFields: []v1.NodeSelectorRequirement{{
+						Key:      "metadata.name",
+						Operator: v1.NodeSelectorOpIn,
+						Values:   names,
+					}},
+				},
+			})
+	}
+	if affinity.RequiredDuringSchedulingIgnoredDuringExecution == nil &&
+		len(affinity.PreferredDuringSchedulingIgnoredDuringExecution) == 0 {
+		return nil
+	}
+	return affinity
+}
+
+// preferenceWeight maps accumulated soft-violation severity (0 = perfect) to
+// a preferred-term weight in [1, 100], the same direction the plugin's score
+// moves: a clean node gets the full weight, a node at total severity 1 or
+// above is not preferred at all.
+func preferenceWeight(severity float64) int32 {
+	if severity >= 1 {
+		return 0
+	}
+	return int32(100 * (1 - severity))
+}
@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+// testInjector serves a fixed two-node topology where edge-slow measures
+// 50mbps and edge-fast 500mbps toward core.
+func testInjector() *affinityInjector {
+	topo := &v1alpha1.NetworkTopology{
+		Spec: v1alpha1.NetworkTopologySpec{Nodes: map[string]v1alpha1.NodeSpec{
+			"edge-slow": {Bandwidth: map[string]string{"core": "50mbps"}},
+			"edge-fast": {Bandwidth: map[string]string{"core": "500mbps"}},
+		}},
+	}
+	nodes := []v1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "edge-slow"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "edge-fast"}},
+	}
+	return &affinityInjector{
+		topology: func(context.Context) (*v1alpha1.NetworkTopology, error) { return topo, nil },
+		nodes:    func(context.Context) ([]v1.Node, error) { return nodes, nil },
+		policy:   requirements.DefaultUnknownPolicy(),
+	}
+}
+
+func annotatedPod(ann map[string]string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p", Annotations: ann}}
+}
+
+func findOp(patch []PatchOp, path string) *PatchOp {
+	for i := range patch {
+		if patch[i].Path == path {
+			return &patch[i]
+		}
+	}
+	return nil
+}
+
+func TestInjectExcludesHardFailures(t *testing.T) {
+	a := testInjector()
+	pod := annotatedPod(map[string]string{requirements.AnnotationMinBandwidth: "100mbps"})
+
+	patch := a.inject(context.Background(), pod)
+	op := findOp(patch, "/spec/affinity")
+	if op == nil {
+		t.Fatalf("expected a /spec/affinity op, got %+v", patch)
+	}
+	affinity, ok := op.Value.(*v1.Affinity)
+	if !ok || affinity.NodeAffinity == nil ||
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		t.Fatalf("expected a required node affinity, got %+v", op.Value)
+	}
+	terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 || len(terms[0].MatchFields) != 1 {
+		t.Fatalf("expected one NotIn term, got %+v", terms)
+	}
+	req := terms[0].MatchFields[0]
+	if req.Operator != v1.NodeSelectorOpNotIn || len(req.Values) != 1 || req.Values[0] != "edge-slow" {
+		t.Errorf("required term = %+v, want NotIn [edge-slow]", req)
+	}
+	if findOp(patch, "/metadata/annotations/"+escapePatchPath(AnnotationAffinityInjected)) == nil {
+		t.Error("injected affinity is not marked with the annotation")
+	}
+}
+
+func TestInjectSkips(t *testing.T) {
+	a := testInjector()
+
+	// The network-aware scheduler evaluates the annotations itself.
+	pod := annotatedPod(map[string]string{requirements.AnnotationMinBandwidth: "100mbps"})
+	pod.Spec.SchedulerName = networkAwareSchedulerName
+	if patch := a.inject(context.Background(), pod); patch != nil {
+		t.Errorf("pod on the network-aware scheduler got a patch: %+v", patch)
+	}
+
+	// Pods without network annotations need nothing.
+	if patch := a.inject(context.Background(), annotatedPod(nil)); patch != nil {
+		t.Errorf("unannotated pod got a patch: %+v", patch)
+	}
+
+	// Hand-written node affinity is left alone.
+	pod = annotatedPod(map[string]string{requirements.AnnotationMinBandwidth: "100mbps"})
+	pod.Spec.Affinity = &v1.Affinity{NodeAffinity: &v1.NodeAffinity{}}
+	if patch := a.inject(context.Background(), pod); patch != nil {
+		t.Errorf("pod with its own affinity got a patch: %+v", patch)
+	}
+}
+
+func TestInjectRefreshesOwnTerms(t *testing.T) {
+	a := testInjector()
+	pod := annotatedPod(map[string]string{
+		requirements.AnnotationMinBandwidth: "100mbps",
+		AnnotationAffinityInjected:          "true",
+	})
+	pod.Spec.Affinity = &v1.Affinity{NodeAffinity: &v1.NodeAffinity{}}
+
+	patch := a.inject(context.Background(), pod)
+	op := findOp(patch, "/spec/affinity/nodeAffinity")
+	if op == nil || op.Op != "replace" {
+		t.Fatalf("expected a replace of the injected affinity, got %+v", patch)
+	}
+}
+
+func TestInjectPreferredTerms(t *testing.T) {
+	a := testInjector()
+	pod := annotatedPod(map[string]string{
+		requirements.AnnotationMinBandwidth:           "100mbps",
+		requirements.AnnotationMinBandwidth + ".mode": "preferred",
+	})
+
+	patch := a.inject(context.Background(), pod)
+	op := findOp(patch, "/spec/affinity")
+	if op == nil {
+		t.Fatalf("expected a /spec/affinity op, got %+v", patch)
+	}
+	affinity := op.Value.(*v1.Affinity)
+	if affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		t.Errorf("preferred-mode requirement produced a required term: %+v",
+			affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+	}
+	preferred := affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	if len(preferred) == 0 {
+		t.Fatal("expected preferred terms for the soft requirement")
+	}
+	// The clean node outranks the one violating the soft requirement.
+	top := preferred[0]
+	if len(top.Preference.MatchFields) != 1 || len(top.Preference.MatchFields[0].Values) != 1 ||
+		top.Preference.MatchFields[0].Values[0] != "edge-fast" {
+		t.Errorf("highest-weight term = %+v, want [edge-fast]", top)
+	}
+	for _, term := range preferred[1:] {
+		if term.Weight >= top.Weight {
+			t.Errorf("terms not ordered by weight: %+v", preferred)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// CNI bandwidth annotations understood by the bandwidth plugin and Cilium's
+// bandwidth manager. Values are bits-per-second quantities, e.g. "400M".
+const (
+	annotationEgressBandwidth  = "kubernetes.io/egress-bandwidth"
+	annotationIngressBandwidth = "kubernetes.io/ingress-bandwidth"
+)
+
+// defaultBandwidthMultiplier sizes the enforced limit relative to the
+// scheduled request: pods get headroom but can no longer starve neighbors.
+const defaultBandwidthMultiplier = 2.0
+
+// BandwidthLimits configures the opt-in translation of min-bandwidth
+// requests into CNI bandwidth limit annotations.
+type BandwidthLimits struct {
+	// Enabled turns the translation on; it is off by default because
+	// enforcement is a behavior change for existing workloads.
+	Enabled bool `json:"enabled,omitempty"`
+	// Multiplier scales the request into the limit; 0 means the default.
+	Multiplier float64 `json:"multiplier,omitempty"`
+}
+
+// ParseBandwidthLimits decodes the configuration from the ConfigMap's
+// "bandwidthLimits" key (YAML or JSON); an empty document disables it.
+func ParseBandwidthLimits(data string) (BandwidthLimits, error) {
+	cfg := BandwidthLimits{}
+	if data == "" {
+		return cfg, nil
+	}
+	if err := yaml.Unmarshal([]byte(data), &cfg); err != nil {
+		return cfg, fmt.Errorf("parsing bandwidth limits config: %w", err)
+	}
+	if cfg.Multiplier < 0 {
+		return cfg, fmt.Errorf("bandwidth limit multiplier must be non-negative, got %v", cfg.Multiplier)
+	}
+	return cfg, nil
+}
+
+func (b BandwidthLimits) multiplier() float64 {
+	if b.Multiplier > 0 {
+		return b.Multiplier
+	}
+	return defaultBandwidthMultiplier
+}
+
+// cniBandwidthValue converts one of our bandwidth strings ("200mbps") into
+// the CNI plugin's bits-per-second quantity form ("400M"), scaled by the
+// multiplier.
+func cniBandwidthValue(value string, multiplier float64) (string, error) {
+	bps, err := units.ParseBandwidth(value)
+	if err != nil {
+		return "", err
+	}
+	limit := int64(float64(bps) * multiplier)
+	return resource.NewQuantity(limit, resource.DecimalSI).String(), nil
+}
+
+// bandwidthLimitAnnotations derives the CNI limit annotations for a pod whose
+// effective min-bandwidth is known. Pods already carrying either explicit
+// kubernetes.io bandwidth annotation are left entirely untouched: an operator
+// override beats the derived limit.
+func (m *Mutator) bandwidthLimitAnnotations(existing map[string]string, toAdd map[string]string) {
+	if !m.limits.Enabled {
+		return
+	}
+	if _, ok := existing[annotationEgressBandwidth]; ok {
+		return
+	}
+	if _, ok := existing[annotationIngressBandwidth]; ok {
+		return
+	}
+	request, ok := existing[requirements.AnnotationMinBandwidth]
+	if !ok {
+		request, ok = toAdd[requirements.AnnotationMinBandwidth]
+	}
+	if !ok {
+		return
+	}
+	limit, err := cniBandwidthValue(request, m.limits.multiplier())
+	if err != nil {
+		// A malformed request is the scheduler's problem to reject; the
+		// limit step just declines to derive anything from it.
+		return
+	}
+	toAdd[annotationEgressBandwidth] = limit
+	toAdd[annotationIngressBandwidth] = limit
+}
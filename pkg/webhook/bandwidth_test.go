@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCNIBandwidthValueConversion(t *testing.T) {
+	tests := []struct {
+		value      string
+		multiplier float64
+		want       string
+	}{
+		{"200mbps", 2, "400M"},
+		{"1gbps", 2, "2G"},
+		{"10mbps", 1.5, "15M"},
+		{"500kbps", 1, "500k"},
+		{"100mbps", 1, "100M"},
+	}
+	for _, tc := range tests {
+		got, err := cniBandwidthValue(tc.value, tc.multiplier)
+		if err != nil {
+			t.Errorf("cniBandwidthValue(%q, %v): %v", tc.value, tc.multiplier, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("cniBandwidthValue(%q, %v) = %q, want %q", tc.value, tc.multiplier, got, tc.want)
+		}
+	}
+	if _, err := cniBandwidthValue("warp9", 2); err == nil {
+		t.Errorf("expected an error for an unparseable bandwidth value")
+	}
+}
+
+func TestBandwidthLimitInjection(t *testing.T) {
+	m := aiRules(t).WithBandwidthLimits(BandwidthLimits{Enabled: true})
+
+	// The rule sets min-bandwidth 200mbps; the default 2x multiplier derives
+	// symmetric 400M limits.
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"workload-type": "ai"}}}
+	values := patchValues(m.Mutate(pod))
+	if got := values["/metadata/annotations/kubernetes.io~1egress-bandwidth"]; got != "400M" {
+		t.Errorf("egress-bandwidth = %v, want 400M", got)
+	}
+	if got := values["/metadata/annotations/kubernetes.io~1ingress-bandwidth"]; got != "400M" {
+		t.Errorf("ingress-bandwidth = %v, want 400M", got)
+	}
+
+	// A pod-level min-bandwidth works without any rule match.
+	annotated := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{"network.komarov.dev/min-bandwidth": "50mbps"},
+	}}
+	values = patchValues(m.Mutate(annotated))
+	if got := values["/metadata/annotations/kubernetes.io~1egress-bandwidth"]; got != "100M" {
+		t.Errorf("egress-bandwidth = %v, want 100M", got)
+	}
+}
+
+func TestBandwidthLimitRespectsExplicitAnnotations(t *testing.T) {
+	m := aiRules(t).WithBandwidthLimits(BandwidthLimits{Enabled: true})
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Labels: map[string]string{"workload-type": "ai"},
+		Annotations: map[string]string{
+			"kubernetes.io/egress-bandwidth": "1G",
+		},
+	}}
+	values := patchValues(m.Mutate(pod))
+	if _, ok := values["/metadata/annotations/kubernetes.io~1egress-bandwidth"]; ok {
+		t.Errorf("explicit egress-bandwidth must not be overridden")
+	}
+	if _, ok := values["/metadata/annotations/kubernetes.io~1ingress-bandwidth"]; ok {
+		t.Errorf("a pod with any explicit kubernetes.io bandwidth annotation must be left untouched")
+	}
+}
+
+func TestBandwidthLimitDisabledByDefault(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"workload-type": "ai"}}}
+	values := patchValues(aiRules(t).Mutate(pod))
+	if _, ok := values["/metadata/annotations/kubernetes.io~1egress-bandwidth"]; ok {
+		t.Errorf("bandwidth limits must be opt-in")
+	}
+}
+
+func TestParseBandwidthLimits(t *testing.T) {
+	cfg, err := ParseBandwidthLimits("enabled: true\nmultiplier: 1.5\n")
+	if err != nil {
+		t.Fatalf("ParseBandwidthLimits: %v", err)
+	}
+	if !cfg.Enabled || cfg.multiplier() != 1.5 {
+		t.Errorf("cfg = %+v, want enabled with multiplier 1.5", cfg)
+	}
+	if cfg, err := ParseBandwidthLimits(""); err != nil || cfg.Enabled {
+		t.Errorf("empty config should parse as disabled, got %+v, %v", cfg, err)
+	}
+	if _, err := ParseBandwidthLimits("multiplier: -1\n"); err == nil {
+		t.Errorf("expected an error for a negative multiplier")
+	}
+}
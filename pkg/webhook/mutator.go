@@ -0,0 +1,193 @@
+// Package webhook implements the mutating admission logic that translates
+// high-level workload labels into the network.komarov.dev annotations the
+// scheduler plugin acts on. The rule set comes from a ConfigMap so operators
+// can adjust mappings without redeploying the webhook.
+package webhook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+// Rule maps pods matching a label selector to a set of network annotations
+// and, optionally, a scheduler name. Rules are applied in order; the first
+// writer of an annotation wins, and values already present on the pod are
+// never overridden.
+type Rule struct {
+	// Name identifies the rule in logs.
+	Name string `json:"name,omitempty"`
+	// PodSelector selects pods this rule applies to. Nil matches all pods.
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+	// Annotations are added to matching pods when not already set.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// SchedulerName, when non-empty, is written to spec.schedulerName for
+	// matching pods that still use the default scheduler.
+	SchedulerName string `json:"schedulerName,omitempty"`
+}
+
+// defaultSchedulerName is the scheduler pods run with when nothing sets
+// spec.schedulerName explicitly; only pods in this state are retargeted.
+const defaultSchedulerName = "default-scheduler"
+
+type compiledWebhookRule struct {
+	name          string
+	selector      labels.Selector
+	annotations   map[string]string
+	schedulerName string
+}
+
+// Mutator applies the compiled rule set to pods.
+type Mutator struct {
+	rules  []compiledWebhookRule
+	limits BandwidthLimits
+}
+
+// WithBandwidthLimits enables the CNI bandwidth limit translation.
+func (m *Mutator) WithBandwidthLimits(cfg BandwidthLimits) *Mutator {
+	m.limits = cfg
+	return m
+}
+
+// NewMutator compiles the rules, rejecting invalid selectors up front so a
+// bad ConfigMap fails loudly at load time instead of silently skipping pods.
+func NewMutator(rules []Rule) (*Mutator, error) {
+	compiled := make([]compiledWebhookRule, 0, len(rules))
+	for i, rule := range rules {
+		c := compiledWebhookRule{
+			name:          rule.Name,
+			annotations:   rule.Annotations,
+			schedulerName: rule.SchedulerName,
+		}
+		if c.name == "" {
+			c.name = fmt.Sprintf("rule-%d", i)
+		}
+		c.selector = labels.Everything()
+		if rule.PodSelector != nil {
+			sel, err := metav1.LabelSelectorAsSelector(rule.PodSelector)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: invalid podSelector: %w", c.name, err)
+			}
+			c.selector = sel
+		}
+		compiled = append(compiled, c)
+	}
+	return &Mutator{rules: compiled}, nil
+}
+
+// ParseRules decodes the rule list from the ConfigMap's "rules" key (YAML or
+// JSON).
+func ParseRules(data string) ([]Rule, error) {
+	var rules []Rule
+	if err := yaml.Unmarshal([]byte(data), &rules); err != nil {
+		return nil, fmt.Errorf("parsing webhook rules: %w", err)
+	}
+	return rules, nil
+}
+
+// PatchOp is a single JSON patch operation.
+type PatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Mutate computes the JSON patch for one pod. The result is empty when no
+// rule matches or every value a matching rule would set is already present,
+// which also makes the webhook idempotent: re-admitting a mutated pod
+// produces no further changes.
+func (m *Mutator) Mutate(pod *v1.Pod) []PatchOp {
+	podLabels := labels.Set(pod.Labels)
+	toAdd := map[string]string{}
+	schedulerName := ""
+	for _, rule := range m.rules {
+		if !rule.selector.Matches(podLabels) {
+			continue
+		}
+		for key, value := range rule.annotations {
+			if _, exists := pod.Annotations[key]; exists {
+				continue
+			}
+			if _, queued := toAdd[key]; queued {
+				continue
+			}
+			toAdd[key] = value
+		}
+		if schedulerName == "" {
+			schedulerName = rule.schedulerName
+		}
+	}
+	m.bandwidthLimitAnnotations(pod.Annotations, toAdd)
+
+	var patch []PatchOp
+	if len(toAdd) > 0 && pod.Annotations == nil {
+		patch = append(patch, PatchOp{Op: "add", Path: "/metadata/annotations", Value: map[string]string{}})
+	}
+	keys := make([]string, 0, len(toAdd))
+	for key := range toAdd {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		patch = append(patch, PatchOp{
+			Op:    "add",
+			Path:  "/metadata/annotations/" + escapePatchPath(key),
+			Value: toAdd[key],
+		})
+	}
+
+	// Retarget only pods still on the default scheduler: an explicit
+	// spec.schedulerName, including a previous run of this webhook, wins.
+	if schedulerName != "" &&
+		(pod.Spec.SchedulerName == "" || pod.Spec.SchedulerName == defaultSchedulerName) &&
+		pod.Spec.SchedulerName != schedulerName {
+		patch = append(patch, PatchOp{Op: "add", Path: "/spec/schedulerName", Value: schedulerName})
+	}
+	return patch
+}
+
+// escapePatchPath escapes a map key for use in a JSON patch path (RFC 6901).
+func escapePatchPath(key string) string {
+	key = strings.ReplaceAll(key, "~", "~0")
+	return strings.ReplaceAll(key, "/", "~1")
+}
+
+// unescapePatchPath is the inverse of escapePatchPath.
+func unescapePatchPath(key string) string {
+	key = strings.ReplaceAll(key, "~1", "/")
+	return strings.ReplaceAll(key, "~0", "~")
+}
+
+// applyPatch returns a copy of the pod with the annotation and schedulerName
+// ops Mutate produces applied, so later mutation stages see the pod as it
+// will be admitted. Ops of other shapes are ignored.
+func applyPatch(pod *v1.Pod, patch []PatchOp) *v1.Pod {
+	if len(patch) == 0 {
+		return pod
+	}
+	out := pod.DeepCopy()
+	for _, op := range patch {
+		switch {
+		case op.Path == "/spec/schedulerName":
+			if name, ok := op.Value.(string); ok {
+				out.Spec.SchedulerName = name
+			}
+		case strings.HasPrefix(op.Path, "/metadata/annotations/"):
+			value, ok := op.Value.(string)
+			if !ok {
+				continue
+			}
+			if out.Annotations == nil {
+				out.Annotations = map[string]string{}
+			}
+			key := unescapePatchPath(strings.TrimPrefix(op.Path, "/metadata/annotations/"))
+			out.Annotations[key] = value
+		}
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func aiRules(t *testing.T) *Mutator {
+	t.Helper()
+	m, err := NewMutator([]Rule{
+		{
+			Name:        "ai-workloads",
+			PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"workload-type": "ai"}},
+			Annotations: map[string]string{
+				"network.komarov.dev/min-bandwidth": "200mbps",
+				"network.komarov.dev/data-locality": "high",
+			},
+			SchedulerName: "network-aware-scheduler",
+		},
+		{
+			Name:        "web-workloads",
+			PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"workload-type": "web"}},
+			Annotations: map[string]string{
+				"network.komarov.dev/internet-required": "true",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewMutator: %v", err)
+	}
+	return m
+}
+
+func patchValues(patch []PatchOp) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, op := range patch {
+		out[op.Path] = op.Value
+	}
+	return out
+}
+
+func TestMutateInjectsAnnotationsAndScheduler(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"workload-type": "ai"}}}
+	patch := aiRules(t).Mutate(pod)
+
+	values := patchValues(patch)
+	if _, ok := values["/metadata/annotations"]; !ok {
+		t.Errorf("expected the annotations map to be created for a pod without annotations")
+	}
+	if got := values["/metadata/annotations/network.komarov.dev~1min-bandwidth"]; got != "200mbps" {
+		t.Errorf("min-bandwidth = %v, want 200mbps", got)
+	}
+	if got := values["/spec/schedulerName"]; got != "network-aware-scheduler" {
+		t.Errorf("schedulerName = %v, want network-aware-scheduler", got)
+	}
+}
+
+func TestMutateNeverOverridesExplicitValues(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      map[string]string{"workload-type": "ai"},
+			Annotations: map[string]string{"network.komarov.dev/min-bandwidth": "50mbps"},
+		},
+		Spec: v1.PodSpec{SchedulerName: "my-own-scheduler"},
+	}
+	values := patchValues(aiRules(t).Mutate(pod))
+
+	if _, ok := values["/metadata/annotations/network.komarov.dev~1min-bandwidth"]; ok {
+		t.Errorf("explicitly set min-bandwidth annotation must not be overridden")
+	}
+	if _, ok := values["/spec/schedulerName"]; ok {
+		t.Errorf("explicitly set schedulerName must not be overridden")
+	}
+	if got := values["/metadata/annotations/network.komarov.dev~1data-locality"]; got != "high" {
+		t.Errorf("missing annotations should still be added, data-locality = %v", got)
+	}
+}
+
+func TestMutateIsIdempotent(t *testing.T) {
+	m := aiRules(t)
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"workload-type": "ai"}}}
+
+	// Apply the first patch by hand, then verify a second admission of the
+	// mutated pod produces nothing.
+	pod.Annotations = map[string]string{
+		"network.komarov.dev/min-bandwidth": "200mbps",
+		"network.komarov.dev/data-locality": "high",
+	}
+	pod.Spec.SchedulerName = "network-aware-scheduler"
+	if patch := m.Mutate(pod); len(patch) != 0 {
+		t.Errorf("second admission produced %d patch ops, want 0: %v", len(patch), patch)
+	}
+}
+
+func TestMutateSkipsNonMatchingPods(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "db"}}}
+	if patch := aiRules(t).Mutate(pod); len(patch) != 0 {
+		t.Errorf("non-matching pod got %d patch ops, want 0", len(patch))
+	}
+}
+
+func TestParseRulesRejectsGarbage(t *testing.T) {
+	if _, err := ParseRules("not: [valid"); err == nil {
+		t.Errorf("expected an error for malformed rules data")
+	}
+}
@@ -0,0 +1,186 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+)
+
+// LabelWebhookOptOut opts a namespace out of pod mutation when set to
+// "disabled" on the namespace.
+const LabelWebhookOptOut = "network.komarov.dev/webhook"
+
+// Server serves the /mutate admission endpoint and keeps the rule set in
+// sync with its source ConfigMap.
+type Server struct {
+	client             kubernetes.Interface
+	rulesNamespace     string
+	rulesConfigMapName string
+
+	// classExists, when set via WithClassValidation, backs the /validate
+	// endpoint's NetworkClass reference check.
+	classExists func(ctx context.Context, name string) (bool, error)
+	// listQuotas, when set via WithQuotaValidation, backs the /validate
+	// endpoint's NetworkQuota budget check.
+	listQuotas func(ctx context.Context, namespace string) ([]v1alpha1.NetworkQuota, error)
+	// affinity, when set via WithAffinityInjection, translates network
+	// annotations into nodeAffinity for pods not handled by the
+	// network-aware scheduler.
+	affinity *affinityInjector
+
+	mu      sync.RWMutex
+	mutator *Mutator
+}
+
+// NewServer builds a server that loads its rules from the given ConfigMap's
+// "rules" key. The initial load must succeed; later reload failures keep the
+// previous rule set.
+func NewServer(client kubernetes.Interface, rulesNamespace, rulesConfigMapName string) (*Server, error) {
+	s := &Server{
+		client:             client,
+		rulesNamespace:     rulesNamespace,
+		rulesConfigMapName: rulesConfigMapName,
+	}
+	if err := s.reloadRules(context.Background()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Run reloads the rules periodically until the context is cancelled.
+func (s *Server) Run(ctx context.Context, reloadInterval time.Duration) {
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.reloadRules(ctx); err != nil {
+				klog.ErrorS(err, "Keeping previous webhook rules after reload failure")
+			}
+		}
+	}
+}
+
+func (s *Server) reloadRules(ctx context.Context) error {
+	cm, err := s.client.CoreV1().ConfigMaps(s.rulesNamespace).Get(ctx, s.rulesConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("fetching rules ConfigMap %s/%s: %w", s.rulesNamespace, s.rulesConfigMapName, err)
+	}
+	rules, err := ParseRules(cm.Data["rules"])
+	if err != nil {
+		return err
+	}
+	limits, err := ParseBandwidthLimits(cm.Data["bandwidthLimits"])
+	if err != nil {
+		return err
+	}
+	mutator, err := NewMutator(rules)
+	if err != nil {
+		return err
+	}
+	mutator.WithBandwidthLimits(limits)
+	s.mu.Lock()
+	s.mutator = mutator
+	s.mu.Unlock()
+	klog.V(2).InfoS("Loaded webhook rules", "count", len(rules))
+	return nil
+}
+
+// Handler returns the HTTP handler serving /mutate and /healthz.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mutate", s.serveMutate)
+	mux.HandleFunc("/validate", s.serveValidate)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+// serveMutate handles one AdmissionReview. Every failure path allows the pod:
+// a broken webhook must never block cluster workloads.
+func (s *Server) serveMutate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "reading request body", http.StatusBadRequest)
+		return
+	}
+	review := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, "decoding AdmissionReview", http.StatusBadRequest)
+		return
+	}
+
+	response := &admissionv1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+	if patch := s.mutatePod(r.Context(), review.Request); patch != nil {
+		patchType := admissionv1.PatchTypeJSONPatch
+		response.Patch = patch
+		response.PatchType = &patchType
+	}
+	review.Response = response
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		klog.ErrorS(err, "Writing admission response")
+	}
+}
+
+// mutatePod returns the serialized patch for the request, or nil when no
+// mutation applies.
+func (s *Server) mutatePod(ctx context.Context, req *admissionv1.AdmissionRequest) []byte {
+	pod := &v1.Pod{}
+	if err := json.Unmarshal(req.Object.Raw, pod); err != nil {
+		klog.ErrorS(err, "Decoding pod from admission request")
+		return nil
+	}
+	if s.namespaceOptedOut(ctx, req.Namespace) {
+		return nil
+	}
+
+	s.mu.RLock()
+	mutator := s.mutator
+	s.mu.RUnlock()
+	patch := mutator.Mutate(pod)
+	if s.affinity != nil {
+		// Inject against the pod as the rules just mutated it, so
+		// annotations added this admission are translated too.
+		patch = append(patch, s.affinity.inject(ctx, applyPatch(pod, patch))...)
+	}
+	if len(patch) == 0 {
+		return nil
+	}
+	raw, err := json.Marshal(patch)
+	if err != nil {
+		klog.ErrorS(err, "Encoding patch", "pod", klog.KObj(pod))
+		return nil
+	}
+	klog.V(2).InfoS("Mutating pod", "namespace", req.Namespace, "pod", pod.GenerateName+pod.Name, "ops", len(patch))
+	return raw
+}
+
+func (s *Server) namespaceOptedOut(ctx context.Context, namespace string) bool {
+	ns, err := s.client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.V(2).InfoS("Namespace lookup failed, mutating anyway", "namespace", namespace, "err", err)
+		}
+		return false
+	}
+	return ns.Labels[LabelWebhookOptOut] == "disabled"
+}
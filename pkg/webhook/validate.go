@@ -0,0 +1,165 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+var (
+	classGVR = v1alpha1.SchemeGroupVersion.WithResource("networkclasses")
+	quotaGVR = v1alpha1.SchemeGroupVersion.WithResource("networkquotas")
+)
+
+// WithClassValidation makes the /validate endpoint reject pods referencing a
+// NetworkClass that does not exist. Lookup errors other than NotFound allow
+// the pod: a flaky API server must not block workloads.
+func (s *Server) WithClassValidation(client dynamic.Interface) *Server {
+	s.classExists = func(ctx context.Context, name string) (bool, error) {
+		_, err := client.Resource(classGVR).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	return s
+}
+
+// WithQuotaValidation makes the /validate endpoint enforce the namespace's
+// NetworkQuota against the pod's min-bandwidth claim. Lookup errors allow the
+// pod, same as the class check.
+func (s *Server) WithQuotaValidation(client dynamic.Interface) *Server {
+	s.listQuotas = func(ctx context.Context, namespace string) ([]v1alpha1.NetworkQuota, error) {
+		list, err := client.Resource(quotaGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		quotas := make([]v1alpha1.NetworkQuota, 0, len(list.Items))
+		for i := range list.Items {
+			quota := v1alpha1.NetworkQuota{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].Object, &quota); err != nil {
+				return nil, fmt.Errorf("converting NetworkQuota %q: %w", list.Items[i].GetName(), err)
+			}
+			quotas = append(quotas, quota)
+		}
+		return quotas, nil
+	}
+	return s
+}
+
+// serveValidate handles one validating AdmissionReview. Only a definite
+// reference to a nonexistent NetworkClass or a definite NetworkQuota breach
+// denies the pod; every failure path allows it.
+func (s *Server) serveValidate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "reading request body", http.StatusBadRequest)
+		return
+	}
+	review := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, "decoding AdmissionReview", http.StatusBadRequest)
+		return
+	}
+
+	response := &admissionv1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+	if reason := s.validatePod(r.Context(), review.Request); reason != "" {
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: reason,
+			Reason:  metav1.StatusReasonInvalid,
+		}
+	}
+	review.Response = response
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		klog.ErrorS(err, "Writing admission response")
+	}
+}
+
+// validatePod returns the denial reason for the request, or "" to allow.
+func (s *Server) validatePod(ctx context.Context, req *admissionv1.AdmissionRequest) string {
+	if s.classExists == nil && s.listQuotas == nil {
+		return ""
+	}
+	pod := &v1.Pod{}
+	if err := json.Unmarshal(req.Object.Raw, pod); err != nil {
+		klog.ErrorS(err, "Decoding pod from admission request")
+		return ""
+	}
+	if reason := s.validateClass(ctx, req.Namespace, pod); reason != "" {
+		return reason
+	}
+	return s.validateQuota(ctx, req.Namespace, pod)
+}
+
+func (s *Server) validateClass(ctx context.Context, namespace string, pod *v1.Pod) string {
+	if s.classExists == nil {
+		return ""
+	}
+	className := pod.Annotations[requirements.AnnotationClass]
+	if className == "" {
+		return ""
+	}
+	exists, err := s.classExists(ctx, className)
+	if err != nil {
+		klog.V(2).InfoS("NetworkClass lookup failed, allowing pod",
+			"namespace", namespace, "pod", pod.GenerateName+pod.Name, "class", className, "err", err)
+		return ""
+	}
+	if !exists {
+		return fmt.Sprintf("%s references NetworkClass %q, which does not exist",
+			requirements.AnnotationClass, className)
+	}
+	return ""
+}
+
+// validateQuota denies the pod when its min-bandwidth claim breaks the
+// namespace's NetworkQuota budget. The claim is compared against the status
+// the quota reconciler last wrote; the scheduler's Reserve step re-checks,
+// so a slightly stale status here only delays, not defeats, enforcement.
+func (s *Server) validateQuota(ctx context.Context, namespace string, pod *v1.Pod) string {
+	if s.listQuotas == nil {
+		return ""
+	}
+	reqs, err := requirements.Parse(pod)
+	if err != nil || reqs.MinBandwidthMbps == 0 {
+		// Malformed annotations are the scheduler's problem to surface.
+		return ""
+	}
+	quotas, err := s.listQuotas(ctx, namespace)
+	if err != nil {
+		klog.V(2).InfoS("NetworkQuota lookup failed, allowing pod",
+			"namespace", namespace, "pod", pod.GenerateName+pod.Name, "err", err)
+		return ""
+	}
+	for i := range quotas {
+		quota := &quotas[i]
+		if quota.Spec.MaxPodMbps > 0 && reqs.MinBandwidthMbps > quota.Spec.MaxPodMbps {
+			return fmt.Sprintf("pod requests %dmbps, above the %dmbps per-pod limit of NetworkQuota %q",
+				reqs.MinBandwidthMbps, quota.Spec.MaxPodMbps, quota.Name)
+		}
+		if quota.Spec.TotalReservableMbps > 0 &&
+			quota.Status.ReservedMbps+reqs.MinBandwidthMbps > quota.Spec.TotalReservableMbps {
+			return fmt.Sprintf("pod requests %dmbps, but NetworkQuota %q has %dmbps reserved of its %dmbps budget",
+				reqs.MinBandwidthMbps, quota.Name, quota.Status.ReservedMbps, quota.Spec.TotalReservableMbps)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/requirements"
+)
+
+func admissionRequestFor(t *testing.T, pod *v1.Pod) *admissionv1.AdmissionRequest {
+	t.Helper()
+	raw, err := json.Marshal(pod)
+	if err != nil {
+		t.Fatalf("marshaling pod: %v", err)
+	}
+	return &admissionv1.AdmissionRequest{Object: runtime.RawExtension{Raw: raw}}
+}
+
+func classPod(class string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        "p",
+		Annotations: map[string]string{requirements.AnnotationClass: class},
+	}}
+}
+
+func TestValidateRejectsNonexistentClass(t *testing.T) {
+	s := &Server{classExists: func(_ context.Context, name string) (bool, error) {
+		return name == "low-latency", nil
+	}}
+
+	if reason := s.validatePod(context.Background(), admissionRequestFor(t, classPod("low-latency"))); reason != "" {
+		t.Fatalf("existing class should be allowed, got %q", reason)
+	}
+	if reason := s.validatePod(context.Background(), admissionRequestFor(t, classPod("typo"))); reason == "" {
+		t.Fatal("nonexistent class should be rejected")
+	}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p"}}
+	if reason := s.validatePod(context.Background(), admissionRequestFor(t, pod)); reason != "" {
+		t.Fatalf("pods without a class reference should be allowed, got %q", reason)
+	}
+}
+
+func bandwidthPod(bandwidth string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        "p",
+		Annotations: map[string]string{requirements.AnnotationMinBandwidth: bandwidth},
+	}}
+}
+
+func quotaServer(quotas []v1alpha1.NetworkQuota, err error) *Server {
+	return &Server{listQuotas: func(context.Context, string) ([]v1alpha1.NetworkQuota, error) {
+		return quotas, err
+	}}
+}
+
+func TestValidateQuota(t *testing.T) {
+	quota := v1alpha1.NetworkQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-budget", Namespace: "default"},
+		Spec:       v1alpha1.NetworkQuotaSpec{TotalReservableMbps: 500, MaxPodMbps: 200},
+		Status:     v1alpha1.NetworkQuotaStatus{ReservedMbps: 400},
+	}
+	s := quotaServer([]v1alpha1.NetworkQuota{quota}, nil)
+
+	if reason := s.validatePod(context.Background(), admissionRequestFor(t, bandwidthPod("50mbps"))); reason != "" {
+		t.Fatalf("pod within budget should be allowed, got %q", reason)
+	}
+	reason := s.validatePod(context.Background(), admissionRequestFor(t, bandwidthPod("300mbps")))
+	if reason == "" || !strings.Contains(reason, "team-budget") {
+		t.Fatalf("pod over the per-pod limit should be rejected naming the quota, got %q", reason)
+	}
+	reason = s.validatePod(context.Background(), admissionRequestFor(t, bandwidthPod("150mbps")))
+	if reason == "" || !strings.Contains(reason, "team-budget") {
+		t.Fatalf("pod over the remaining budget should be rejected naming the quota, got %q", reason)
+	}
+	plain := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p"}}
+	if reason := s.validatePod(context.Background(), admissionRequestFor(t, plain)); reason != "" {
+		t.Fatalf("pod without a bandwidth claim should be allowed, got %q", reason)
+	}
+}
+
+func TestValidateQuotaFailsOpen(t *testing.T) {
+	s := quotaServer(nil, errors.New("apiserver unavailable"))
+	if reason := s.validatePod(context.Background(), admissionRequestFor(t, bandwidthPod("9999mbps"))); reason != "" {
+		t.Fatalf("lookup failures must allow the pod, got %q", reason)
+	}
+}
+
+func TestValidateFailsOpen(t *testing.T) {
+	s := &Server{classExists: func(context.Context, string) (bool, error) {
+		return false, errors.New("apiserver unavailable")
+	}}
+	if reason := s.validatePod(context.Background(), admissionRequestFor(t, classPod("low-latency"))); reason != "" {
+		t.Fatalf("lookup failures must allow the pod, got %q", reason)
+	}
+
+	// A server without class validation configured allows everything.
+	s = &Server{}
+	if reason := s.validatePod(context.Background(), admissionRequestFor(t, classPod("anything"))); reason != "" {
+		t.Fatalf("unconfigured validation must allow the pod, got %q", reason)
+	}
+}
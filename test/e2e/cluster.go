@@ -0,0 +1,159 @@
+//go:build e2e
+
+// Package e2e exercises the controller and scheduler against a real kind
+// cluster whose inter-node links are shaped with tc netem, proving the two
+// components cooperate end to end: injected delay shows up in the CRD, and
+// annotated pods land where the measured topology says they should.
+//
+// Run with:
+//
+//	go test -tags e2e ./test/e2e/ -timeout 20m
+//
+// The kind cluster is created on first use and reused across runs; set
+// E2E_DELETE_CLUSTER=true to tear it down after the tests.
+package e2e
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// kindConfig is the three-node cluster the tests expect: one control plane
+// and two workers to shape and schedule against.
+const kindConfig = `kind: Cluster
+apiVersion: kind.x-k8s.io/v1alpha4
+nodes:
+- role: control-plane
+- role: worker
+- role: worker
+`
+
+// harness is the shared cluster state for one test run.
+type harness struct {
+	name       string
+	kubeconfig string
+	// nodes maps Kubernetes node names to their docker container names
+	// (identical in kind, but keeping the mapping explicit).
+	nodes []string
+}
+
+func clusterName() string {
+	if name := os.Getenv("E2E_KIND_CLUSTER"); name != "" {
+		return name
+	}
+	return "netaware-e2e"
+}
+
+// ensureCluster creates (or reuses) the kind cluster, writes a kubeconfig for
+// it, and installs the NetworkTopology CRD.
+func ensureCluster(tb testing.TB) *harness {
+	tb.Helper()
+	name := clusterName()
+	out, err := exec.Command("kind", "get", "clusters").Output()
+	if err != nil {
+		tb.Fatalf("kind not available: %v", err)
+	}
+	exists := false
+	for _, line := range strings.Fields(string(out)) {
+		if line == name {
+			exists = true
+		}
+	}
+	if !exists {
+		tb.Logf("creating kind cluster %s", name)
+		cmd := exec.Command("kind", "create", "cluster", "--name", name, "--config", "-", "--wait", "5m")
+		cmd.Stdin = strings.NewReader(kindConfig)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			tb.Fatalf("creating cluster: %v\n%s", err, out)
+		}
+	}
+	if os.Getenv("E2E_DELETE_CLUSTER") == "true" {
+		tb.Cleanup(func() {
+			exec.Command("kind", "delete", "cluster", "--name", name).Run()
+		})
+	}
+
+	kubeconfig := filepath.Join(tb.TempDir(), "kubeconfig")
+	cfg, err := exec.Command("kind", "get", "kubeconfig", "--name", name).Output()
+	if err != nil {
+		tb.Fatalf("exporting kubeconfig: %v", err)
+	}
+	if err := os.WriteFile(kubeconfig, cfg, 0o600); err != nil {
+		tb.Fatal(err)
+	}
+
+	h := &harness{
+		name:       name,
+		kubeconfig: kubeconfig,
+		nodes:      []string{name + "-control-plane", name + "-worker", name + "-worker2"},
+	}
+	h.installCRD(tb)
+	return h
+}
+
+// installCRD renders the CRD with hack/gen-crd and applies it; apply is
+// idempotent so cluster reuse is free.
+func (h *harness) installCRD(tb testing.TB) {
+	tb.Helper()
+	manifest := filepath.Join(tb.TempDir(), "crd.yaml")
+	gen := exec.Command("go", "run", "./hack/gen-crd", "--output", manifest)
+	gen.Dir = repoRoot(tb)
+	if out, err := gen.CombinedOutput(); err != nil {
+		tb.Fatalf("generating CRD: %v\n%s", err, out)
+	}
+	h.kubectl(tb, "apply", "-f", manifest)
+}
+
+// kubectl runs one kubectl command against the harness cluster and returns
+// stdout, failing the test on error.
+func (h *harness) kubectl(tb testing.TB, args ...string) string {
+	tb.Helper()
+	full := append([]string{"--kubeconfig", h.kubeconfig}, args...)
+	out, err := exec.Command("kubectl", full...).CombinedOutput()
+	if err != nil {
+		tb.Fatalf("kubectl %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+// runController runs one controller measurement cycle from the host; kind
+// node addresses are plain docker-network IPs, reachable on Linux.
+func (h *harness) runController(tb testing.TB, extraArgs ...string) {
+	tb.Helper()
+	args := append([]string{"run", "./cmd/topology-controller",
+		"--once", "--kubeconfig", h.kubeconfig, "--ping-count", "2"}, extraArgs...)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = repoRoot(tb)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		tb.Fatalf("controller cycle: %v\n%s", err, out)
+	}
+}
+
+// waitFor polls cond until it returns true or the deadline passes.
+func waitFor(tb testing.TB, timeout time.Duration, what string, cond func() bool) {
+	tb.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(2 * time.Second)
+	}
+	tb.Fatalf("timed out waiting for %s", what)
+}
+
+func repoRoot(tb testing.TB) string {
+	tb.Helper()
+	root, err := filepath.Abs("../..")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "go.mod")); err != nil {
+		tb.Fatalf("test/e2e must run from the repository: %v", err)
+	}
+	return root
+}
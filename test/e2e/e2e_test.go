@@ -0,0 +1,167 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/apis/networktopology/v1alpha1"
+	"github.com/KomarovAI/k3s-network-aware-cluster/pkg/units"
+)
+
+// injectedDelay is the netem delay applied to the shaped worker. The
+// controller should measure roughly this much extra round-trip latency toward
+// it; the tolerance below is generous because docker bridges and ping
+// scheduling add noise.
+const injectedDelay = 100 * time.Millisecond
+
+func TestControllerMeasuresShapedDelay(t *testing.T) {
+	h := ensureCluster(t)
+	shaped := h.name + "-worker2"
+	shapeNodes(t, map[string]shape{shaped: {delay: injectedDelay}})
+
+	h.runController(t)
+
+	topo := h.topology(t)
+	spec, ok := topo.Spec.Nodes[h.name+"-worker"]
+	if !ok {
+		t.Fatalf("topology has no entry for %s-worker: %v", h.name, topo.Spec.Nodes)
+	}
+	latStr, ok := spec.Latency[shaped]
+	if !ok {
+		t.Fatalf("no measured latency toward the shaped node; have %v", spec.Latency)
+	}
+	lat, err := units.ParseLatency(latStr)
+	if err != nil {
+		t.Fatalf("unparseable latency %q: %v", latStr, err)
+	}
+	// Egress shaping delays the reply path once, so the floor is one
+	// injected delay; allow 2x plus slack for the unshaped baseline.
+	if lat < injectedDelay*8/10 || lat > 2*injectedDelay+50*time.Millisecond {
+		t.Errorf("measured latency %v not within tolerance of injected %v", lat, injectedDelay)
+	}
+
+	unshaped, ok := spec.Latency[h.name+"-control-plane"]
+	if ok {
+		base, err := units.ParseLatency(unshaped)
+		if err == nil && base > injectedDelay/2 {
+			t.Errorf("unshaped link measures %v, suspiciously close to the injected delay", base)
+		}
+	}
+}
+
+func TestSchedulerPlacesPodAwayFromShapedNode(t *testing.T) {
+	h := ensureCluster(t)
+	shaped := h.name + "-worker2"
+	shapeNodes(t, map[string]shape{shaped: {delay: injectedDelay}})
+	h.runController(t)
+
+	stop := h.startScheduler(t)
+	defer stop()
+
+	podName := fmt.Sprintf("e2e-latency-%d", time.Now().Unix())
+	manifest := fmt.Sprintf(`apiVersion: v1
+kind: Pod
+metadata:
+  name: %s
+  annotations:
+    network.komarov.dev/max-latency: "50ms"
+spec:
+  schedulerName: network-aware-scheduler
+  nodeSelector:
+    kubernetes.io/os: linux
+  tolerations:
+  - operator: Exists
+  containers:
+  - name: pause
+    image: registry.k8s.io/pause:3.9
+`, podName)
+	h.kubectlApplyStdin(t, manifest)
+	t.Cleanup(func() {
+		h.kubectl(t, "delete", "pod", podName, "--ignore-not-found", "--wait=false")
+	})
+
+	var node string
+	waitFor(t, 2*time.Minute, "pod to be scheduled", func() bool {
+		node = strings.TrimSpace(h.kubectl(t, "get", "pod", podName, "-o", "jsonpath={.spec.nodeName}"))
+		return node != ""
+	})
+	if node == shaped {
+		t.Errorf("pod landed on the shaped node %s despite max-latency=50ms", shaped)
+	}
+}
+
+// topology fetches and decodes the singleton NetworkTopology.
+func (h *harness) topology(tb testing.TB) *v1alpha1.NetworkTopology {
+	tb.Helper()
+	out := h.kubectl(tb, "get", "networktopologies.network.komarov.dev", "cluster", "-o", "json")
+	topo := &v1alpha1.NetworkTopology{}
+	if err := json.Unmarshal([]byte(out), topo); err != nil {
+		tb.Fatalf("decoding topology: %v", err)
+	}
+	return topo
+}
+
+func (h *harness) kubectlApplyStdin(tb testing.TB, manifest string) {
+	tb.Helper()
+	cmd := exec.Command("kubectl", "--kubeconfig", h.kubeconfig, "apply", "-f", "-")
+	cmd.Stdin = strings.NewReader(manifest)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		tb.Fatalf("kubectl apply: %v\n%s", err, out)
+	}
+}
+
+// startScheduler runs the network-aware scheduler from the host against the
+// kind API server and returns a stop function.
+func (h *harness) startScheduler(tb testing.TB) func() {
+	tb.Helper()
+	config := fmt.Sprintf(`apiVersion: kubescheduler.config.k8s.io/v1
+kind: KubeSchedulerConfiguration
+clientConnection:
+  kubeconfig: %s
+leaderElection:
+  leaderElect: false
+profiles:
+- schedulerName: network-aware-scheduler
+  plugins:
+    preFilter:
+      enabled:
+      - name: NetworkAware
+    filter:
+      enabled:
+      - name: NetworkAware
+    score:
+      enabled:
+      - name: NetworkAware
+    postBind:
+      enabled:
+      - name: NetworkAware
+`, h.kubeconfig)
+	path := filepath.Join(tb.TempDir(), "scheduler.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		tb.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, "go", "run", "./cmd/network-aware-scheduler",
+		"--config", path, "--secure-port", "0", "-v", "2")
+	cmd.Dir = repoRoot(tb)
+	if err := cmd.Start(); err != nil {
+		cancel()
+		tb.Fatalf("starting scheduler: %v", err)
+	}
+	// Give the scheduler time to sync informers before pods arrive.
+	time.Sleep(10 * time.Second)
+	return func() {
+		cancel()
+		cmd.Wait()
+	}
+}
@@ -0,0 +1,56 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// shape is one netem profile applied to a node container's uplink. Shaping
+// the container's eth0 delays and rate-limits everything that node sends,
+// which from any peer's perspective adds the delay to the round trip.
+type shape struct {
+	delay time.Duration
+	rate  string // tc rate syntax, e.g. "100mbit"; empty leaves rate unshaped
+}
+
+// applyShape replaces the root qdisc of the container's eth0 with the given
+// netem profile. Replace (not add) keeps it idempotent across test reruns on
+// a reused cluster.
+func applyShape(container string, s shape) error {
+	args := []string{"exec", container, "tc", "qdisc", "replace", "dev", "eth0", "root", "netem"}
+	if s.delay > 0 {
+		args = append(args, "delay", strconv.FormatInt(s.delay.Milliseconds(), 10)+"ms")
+	}
+	if s.rate != "" {
+		args = append(args, "rate", s.rate)
+	}
+	if out, err := exec.Command("docker", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("shaping %s: %v: %s", container, err, out)
+	}
+	return nil
+}
+
+// clearShape removes any qdisc the tests installed. A missing qdisc is not an
+// error: the cluster may already be clean.
+func clearShape(container string) {
+	exec.Command("docker", "exec", container, "tc", "qdisc", "del", "dev", "eth0", "root").Run()
+}
+
+// shapeNodes applies per-container shapes and registers cleanup so a reused
+// cluster never keeps stale shaping between runs. Benchmarks use the same
+// helper to hold a profile for the duration of a measurement.
+func shapeNodes(tb testing.TB, shapes map[string]shape) {
+	tb.Helper()
+	for container, s := range shapes {
+		if err := applyShape(container, s); err != nil {
+			tb.Fatal(err)
+		}
+		container := container
+		tb.Cleanup(func() { clearShape(container) })
+	}
+}